@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"bbs/internal/config"
+	"bbs/internal/paths"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check config.yaml and the menu files for mistakes",
+	Long: `Loads config.yaml and the menu fragments in the menus directory beside
+it, following their includes, and reports anything that would stop the
+server starting or confuse callers: files that don't parse, unknown
+settings in menu files, includes that loop, menus defined twice, and
+items on one menu that share an ID or hotkey. Nothing is written, so it
+is safe to run against a live board before sending it SIGHUP.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !runCheck() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
+
+// runCheck reports the problems in the configuration and returns whether
+// there were none
+func runCheck() bool {
+	layout := paths.Resolve(dataDir)
+	file := cfgFile
+	if file == "" {
+		file = layout.ConfigFile()
+	}
+	if _, err := os.Stat(file); err != nil {
+		fmt.Printf("Failed to read %s: %v\n", file, err)
+		return false
+	}
+
+	cfg, err := config.LoadFromLayout(layout, file)
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		return false
+	}
+
+	problems := cfg.CheckMenus()
+	for _, p := range problems {
+		fmt.Printf("Problem: %s\n", p)
+	}
+	fmt.Printf("%s: %d menu(s), %d menu file(s)\n", cfg.File, len(cfg.BBS.Menus), len(cfg.MenuFiles))
+	for _, f := range cfg.MenuFiles {
+		fmt.Printf("  %s\n", f)
+	}
+	if len(problems) > 0 {
+		fmt.Printf("%d problem(s) found\n", len(problems))
+		return false
+	}
+	fmt.Println("No problems found")
+	return true
+}
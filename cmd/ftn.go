@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"bbs/internal/ftn"
+)
+
+var ftnCmd = &cobra.Command{
+	Use:   "ftn",
+	Short: "Exchange FidoNet-style echomail with an uplink",
+	Long: `Links the topics listed under bbs.ftn.areas to echomail areas. A mailer
+such as binkd carries packets between this board and the uplink; run
+"ftn toss" after it receives mail and "ftn scan" before it calls out,
+or both from cron.`,
+}
+
+var ftnTossCmd = &cobra.Command{
+	Use:   "toss",
+	Short: "Read inbound packets into the linked topics",
+	Long: `Reads the packets and ARCmail bundles in bbs.ftn.inbound into the linked
+topics, threading answers under the message they answer, then removes
+them. Packets with the wrong password or that can't be read are renamed
+to .bad. Netmail and echoes with no linked topic are skipped.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runFTN(func(node *ftn.Node) {
+			result, err := node.Toss()
+			for _, bad := range result.Bad {
+				log.Printf("Set aside %s", bad)
+			}
+			if err != nil {
+				log.Fatalf("Failed to toss: %v", err)
+			}
+			fmt.Printf("Tossed %d packet(s): %d post(s) and %d replies added, %d duplicate(s), %d skipped\n",
+				result.Packets, result.Posts, result.Replies, result.Duplicates, result.Skipped)
+		})
+	},
+}
+
+var ftnScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Pack new local posts into a packet for the uplink",
+	Long: `Packs the posts and replies written in the linked topics since the last
+scan into a packet in bbs.ftn.outbound, and lists it in the uplink's flow
+file for the mailer to send. When an echo is first linked, only what is
+written after that is sent.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runFTN(func(node *ftn.Node) {
+			result, err := node.Scan()
+			if err != nil {
+				log.Fatalf("Failed to scan: %v", err)
+			}
+			if result.Packet == "" {
+				fmt.Println("Nothing to send")
+				return
+			}
+			fmt.Printf("Packed %d message(s) into %s\n", result.Messages, result.Packet)
+		})
+	},
+}
+
+func init() {
+	ftnCmd.AddCommand(ftnTossCmd)
+	ftnCmd.AddCommand(ftnScanCmd)
+	rootCmd.AddCommand(ftnCmd)
+}
+
+// runFTN opens the database and runs a toss or scan
+func runFTN(run func(node *ftn.Node)) {
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if cfg.Database.ReadOnly {
+		log.Fatalf("This board is a read-only mirror. Exchange echomail on the primary instead.")
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	node, err := ftn.NewNode(db, cfg)
+	if err != nil {
+		log.Fatalf("Echomail is not set up: %v", err)
+	}
+	run(node)
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"bbs/internal/database"
+	"bbs/internal/mailimport"
+)
+
+var (
+	importTopic       string
+	importAuthor      string
+	importDescription string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <mbox-or-maildir>...",
+	Short: "Import mailing list archives into a message board topic",
+	Long: `Reads mbox files and Maildir directories and adds their messages to a
+topic as posts, keeping the date each was sent. Answers are threaded
+under the message they answer. Every message is credited to a disabled
+placeholder account, with the original sender's name at the top.
+
+The topic is created if it doesn't exist. Messages imported before are
+skipped, so an archive can be imported again as it grows.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runImport(args)
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importTopic, "topic", "", "topic to import into (required)")
+	importCmd.Flags().StringVar(&importAuthor, "author", "archive", "placeholder account the posts are credited to")
+	importCmd.Flags().StringVar(&importDescription, "description", "Mailing list archive", "description for a topic that has to be created")
+	importCmd.MarkFlagRequired("topic")
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(sources []string) {
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if cfg.Database.ReadOnly {
+		log.Fatalf("This board is a read-only mirror. Import on the primary instead.")
+	}
+
+	// Seed a new database first, or the placeholder account would count as
+	// an existing user and the default accounts would never be created
+	db, err := openDatabase(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	topic, err := mailimport.FindTopic(db, importTopic)
+	if err != nil {
+		log.Fatalf("Failed to import: %v", err)
+	}
+	if topic == nil {
+		topic = &database.Topic{Name: importTopic, Description: importDescription}
+		if err := db.CreateTopic(topic); err != nil {
+			log.Fatalf("Failed to create topic %q: %v", importTopic, err)
+		}
+		fmt.Printf("Created topic %q\n", topic.Name)
+	}
+
+	importer, err := mailimport.NewImporter(db, topic, importAuthor)
+	if err != nil {
+		log.Fatalf("Failed to import: %v", err)
+	}
+
+	for _, source := range sources {
+		messages, err := mailimport.Read(source)
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", source, err)
+		}
+		result, err := importer.Import(messages)
+		if err != nil {
+			log.Fatalf("Failed to import %s: %v", source, err)
+		}
+		fmt.Printf("%s: %d post(s) and %d replies added to %s, %d already imported\n",
+			source, result.Posts, result.Replies, topic.Name, result.Duplicates)
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"bbs/internal/mirror"
+)
+
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Bring mirrored file areas up to date with their sources",
+	Long: `Fetches the files listed under bbs.files.mirror.sources into their file
+areas over HTTP or FTP. Each file is checked against its SHA-256 sum
+before it is listed; files the source changed are replaced and files it
+dropped are removed. The server does the same every interval_minutes,
+so this is for a first fill or an update on demand.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runMirror()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mirrorCmd)
+}
+
+func runMirror() {
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if cfg.Database.ReadOnly {
+		log.Fatalf("This board is a read-only mirror. Mirror file areas on the primary instead.")
+	}
+	if len(cfg.BBS.Files.Mirror.Sources) == 0 {
+		fmt.Println("No mirror sources are configured")
+		return
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	result, err := mirror.NewMirror(db, cfg).Run()
+	for _, failure := range result.Failed {
+		log.Printf("Failed: %s", failure)
+	}
+	if err != nil {
+		log.Fatalf("Failed to mirror: %v", err)
+	}
+	fmt.Printf("Mirrored: %d file(s) added, %d updated, %d removed, %d unchanged\n",
+		len(result.Added), len(result.Updated), len(result.Removed), result.Unchanged)
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"bbs/internal/server"
+	"bbs/internal/terminal"
+)
+
+// localNodes is how many local sessions --local opens
+var localNodes int
+
+// pane is a tmux pane holding an extra local node. A sleeping process
+// keeps the pane open without reading from its tty, so the session can
+// have the keyboard to itself.
+type pane struct {
+	id  string
+	tty string
+}
+
+// openPane splits the current tmux window and returns the new pane
+func openPane() (pane, error) {
+	out, err := exec.Command("tmux", "split-window", "-d", "-P", "-F", "#{pane_id} #{pane_tty}",
+		"exec sleep 2147483647").Output()
+	if err != nil {
+		return pane{}, fmt.Errorf("failed to open a tmux pane: %w", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return pane{}, fmt.Errorf("unexpected tmux output %q", out)
+	}
+	return pane{id: fields[0], tty: fields[1]}, nil
+}
+
+// close removes the pane from the window
+func (p pane) close() {
+	exec.Command("tmux", "kill-pane", "-t", p.id).Run()
+}
+
+// runExtraNodes opens a tmux pane for each local node after the first and
+// runs a session in it against bbsServer. The returned function ends the
+// sessions still running and closes their panes.
+func runExtraNodes(bbsServer *server.Server, nodes int) (func(), error) {
+	if os.Getenv("TMUX") == "" {
+		return nil, fmt.Errorf("--nodes opens its extra sessions in tmux panes; run it inside tmux")
+	}
+
+	var mu sync.Mutex
+	terms := make(map[pane]*terminal.LocalTerminal)
+	var wg sync.WaitGroup
+	stop := func() {
+		mu.Lock()
+		for p, term := range terms {
+			delete(terms, p)
+			term.Close()
+			p.close()
+		}
+		mu.Unlock()
+		wg.Wait()
+	}
+
+	for i := 1; i < nodes; i++ {
+		p, err := openPane()
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		term, err := terminal.OpenLocalTerminal(p.tty)
+		if err != nil {
+			p.close()
+			stop()
+			return nil, fmt.Errorf("failed to open %s: %w", p.tty, err)
+		}
+
+		mu.Lock()
+		terms[p] = term
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bbsServer.NewLocalSession(term).Run()
+
+			// A caller who logs off on an extra node takes its pane along
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := terms[p]; ok {
+				delete(terms, p)
+				term.Close()
+				p.close()
+			}
+		}()
+	}
+	exec.Command("tmux", "select-layout", "tiled").Run()
+	log.Printf("Opened %d more local sessions in tmux panes", nodes-1)
+	return stop, nil
+}
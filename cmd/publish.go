@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"bbs/internal/database"
+	"bbs/internal/publish"
+)
+
+var publishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Write the static HTML archive of public topics and bulletins",
+	Long: `Renders the topics listed under bbs.publish.topics, and the bulletins
+if bbs.publish.bulletins is set, into bbs.publish.dir. Only pages whose
+content changed are rewritten, so it is cheap to run from cron.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runPublish()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(publishCmd)
+}
+
+func runPublish() {
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Publishing only reads, so it can run alongside the server
+	db, err := database.InitializeReadOnly(cfg.DatabasePath())
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	publisher, err := publish.NewPublisher(db, cfg)
+	if err != nil {
+		log.Fatalf("Failed to publish: %v", err)
+	}
+	result, err := publisher.Run()
+	for _, name := range result.Skipped {
+		log.Printf("Topic %q is not a public topic, skipping it", name)
+	}
+	if err != nil {
+		log.Fatalf("Failed to publish: %v", err)
+	}
+	fmt.Printf("Published to %s: %d page(s) written, %d unchanged, %d removed\n",
+		publisher.Dir(), result.Written, result.Unchanged, result.Removed)
+}
@@ -1,24 +1,34 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"bbs/internal/api"
 	"bbs/internal/config"
 	"bbs/internal/database"
+	"bbs/internal/feeds"
+	"bbs/internal/gopher"
+	"bbs/internal/mailgateway"
+	"bbs/internal/paths"
 	"bbs/internal/server"
 	"bbs/internal/terminal"
 )
 
 var (
 	cfgFile   string
+	dataDir   string
 	localMode bool
 )
 
@@ -31,6 +41,9 @@ systems, accessible via SSH with authentic terminal-based interaction.
 Run without flags to start the SSH server, or use -l/--local to 
 connect directly to the BBS in your current terminal.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if localNodes < 1 || (localNodes > 1 && !localMode) {
+			log.Fatalf("--nodes needs --local and at least 1 node")
+		}
 		if localMode {
 			runLocalMode()
 		} else {
@@ -50,15 +63,17 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ./config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is <data-dir>/config/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", "", "data directory (default $"+paths.EnvDataDir+", ./ if it has config.yaml, else XDG data dir)")
 	rootCmd.Flags().BoolVarP(&localMode, "local", "l", false, "Run in local terminal mode instead of starting SSH server")
+	rootCmd.Flags().IntVar(&localNodes, "nodes", 1, "With --local, how many sessions to open; the extra ones open in tmux panes")
 }
 
 func initConfig() {
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 	} else {
-		viper.AddConfigPath(".")
+		viper.AddConfigPath(paths.Resolve(dataDir).Config)
 		viper.SetConfigType("yaml")
 		viper.SetConfigName("config")
 	}
@@ -70,18 +85,155 @@ func initConfig() {
 	}
 }
 
-func runLocalMode() {
-	configFile := "config.yaml"
-	if cfgFile != "" {
-		configFile = cfgFile
+// DefaultConfig is the stock config.yaml, written into an empty data directory
+// on first run. It is set by main from the embedded file.
+var DefaultConfig []byte
+
+// loadConfig resolves the data directory layout and loads the configuration from it
+func loadConfig() (*config.Config, error) {
+	layout := paths.Resolve(dataDir)
+	if err := layout.Ensure(); err != nil {
+		return nil, fmt.Errorf("failed to create data directory %s: %w", layout.Root, err)
+	}
+
+	if cfgFile == "" {
+		if err := writeDefaultConfig(layout); err != nil {
+			return nil, err
+		}
 	}
 
-	cfg, err := config.Load(configFile)
+	return config.LoadFromLayout(layout, cfgFile)
+}
+
+// writeDefaultConfig puts the stock config.yaml into a layout that has none
+func writeDefaultConfig(layout paths.Layout) error {
+	if len(DefaultConfig) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(layout.ConfigFile()); os.IsNotExist(err) {
+		log.Printf("Writing default configuration to %s", layout.ConfigFile())
+		if err := os.WriteFile(layout.ConfigFile(), DefaultConfig, 0644); err != nil {
+			return fmt.Errorf("failed to write default config: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadBoardConfig loads the configuration of an extra board from its own
+// data directory, creating the directory on first run
+func loadBoardConfig(main *config.Config, board config.BoardConfig) (*config.Config, error) {
+	if board.Name == "" || strings.Contains(board.Name, "/") {
+		return nil, fmt.Errorf("board name %q must be non-empty and contain no '/'", board.Name)
+	}
+	if board.Dir == "" {
+		return nil, fmt.Errorf("board %q has no data directory", board.Name)
+	}
+
+	layout := paths.Resolve(main.Paths.RootPath(board.Dir))
+	if err := layout.Ensure(); err != nil {
+		return nil, fmt.Errorf("failed to create data directory %s: %w", layout.Root, err)
+	}
+	if err := writeDefaultConfig(layout); err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.LoadFromLayout(layout, "")
+	if err != nil {
+		return nil, err
+	}
+	// The main configuration decides which ports are served, and every
+	// board logs to the main log
+	cfg.Server.Port = board.Port
+	cfg.Server.Boards = nil
+	cfg.Paths.Logs = main.Paths.Logs
+	return cfg, nil
+}
+
+// startJobs starts a board's scheduled events, nightly retention job,
+// maintenance jobs, email digest, archive publishing, file mirroring and
+// art watcher
+func startJobs(bbsServer *server.Server) {
+	if err := bbsServer.StartEventScheduler(); err != nil {
+		log.Fatalf("Invalid scheduled event: %v", err)
+	}
+	if err := bbsServer.StartRetentionJob(); err != nil {
+		log.Fatalf("Invalid retention settings: %v", err)
+	}
+	if err := bbsServer.StartJobScheduler(); err != nil {
+		log.Fatalf("Invalid job: %v", err)
+	}
+	if err := bbsServer.StartDigestJob(); err != nil {
+		log.Fatalf("Invalid digest settings: %v", err)
+	}
+	if err := bbsServer.StartPublishJob(); err != nil {
+		log.Fatalf("Invalid publish settings: %v", err)
+	}
+	if err := bbsServer.StartMirrorJob(); err != nil {
+		log.Fatalf("Invalid mirror settings: %v", err)
+	}
+	if err := bbsServer.StartArtWatcher(); err != nil {
+		log.Printf("Failed to watch the art directory, art is read each time it is shown: %v", err)
+	}
+}
+
+// serve accepts connections on a listener until it is closed
+func serve(listener net.Listener, bbsServer *server.Server) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("Failed to accept connection: %v", err)
+			continue
+		}
+		go bbsServer.HandleConnection(conn)
+	}
+}
+
+// openDatabase opens the configured database, as a read-only mirror if requested,
+// and starts monitoring it for runtime loss or corruption
+func openDatabase(cfg *config.Config) (*database.DB, error) {
+	var db *database.DB
+	var err error
+	if cfg.Database.ReadOnly {
+		log.Printf("Running as read-only mirror of %s", cfg.DatabasePath())
+		db, err = database.InitializeReadOnly(cfg.DatabasePath())
+	} else {
+		db, err = database.Initialize(cfg.DatabasePath())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A brand new database gets a sysop account so setup is optional. Without
+	// BBS_SYSOP_PASSWORD the sysop gets a random password, shown only here and
+	// only on stderr: the log is also written to logs/bbs.log.
+	if !cfg.Database.ReadOnly {
+		seeded, generated, err := db.SeedIfEmpty(os.Getenv("BBS_SYSOP_PASSWORD"))
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to seed database: %w", err)
+		}
+		if seeded {
+			log.Printf("Seeded new database at %s with the sysop account and bulletins", cfg.DatabasePath())
+		}
+		if generated != "" {
+			fmt.Fprintf(os.Stderr, "The sysop password is %s; it will not be shown again\n", generated)
+		}
+	}
+
+	db.StartHealthMonitor(10 * time.Second)
+	return db, nil
+}
+
+func runLocalMode() {
+	cfg, err := loadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	db, err := database.Initialize(cfg.Database.Path)
+	db, err := openDatabase(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
@@ -95,22 +247,36 @@ func runLocalMode() {
 
 	// Use unified server
 	bbsServer := server.NewServer(cfg, db)
+
+	// Extra nodes share the server, so callers on them can chat and page
+	// each other; logging off the first node ends them all
+	if localNodes > 1 {
+		stop, err := runExtraNodes(bbsServer, localNodes)
+		if err != nil {
+			log.Fatalf("Failed to open local nodes: %v", err)
+		}
+		defer stop()
+	}
+
 	session := bbsServer.NewLocalSession(term)
 	session.Run()
 }
 
 func runServerMode() {
-	configFile := "config.yaml"
-	if cfgFile != "" {
-		configFile = cfgFile
-	}
-
-	cfg, err := config.Load(configFile)
+	cfg, err := loadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	db, err := database.Initialize(cfg.Database.Path)
+	// Mirror the server log into the logs directory
+	if logFile, err := os.OpenFile(cfg.Paths.LogPath("bbs.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
+		defer logFile.Close()
+		log.SetOutput(io.MultiWriter(os.Stderr, logFile))
+	} else {
+		log.Printf("Failed to open log file: %v", err)
+	}
+
+	db, err := openDatabase(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
@@ -118,31 +284,157 @@ func runServerMode() {
 
 	// Use unified server for SSH
 	bbsServer := server.NewServer(cfg, db)
+	startJobs(bbsServer)
 
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
-	if err != nil {
-		log.Fatalf("Failed to listen on port %d: %v", cfg.Server.Port, err)
-	}
-	defer listener.Close()
+	// Extra boards each have their own configuration and database
+	servers := []*server.Server{bbsServer}
+	ports := map[int]*server.Server{cfg.Server.Port: bbsServer}
+	boards := make(map[string]*server.Server)
+	names := make(map[*server.Server]string)
+	for _, bc := range cfg.Server.Boards {
+		if _, dup := boards[bc.Name]; dup {
+			log.Fatalf("Board %q is configured twice", bc.Name)
+		}
+		boardCfg, err := loadBoardConfig(cfg, bc)
+		if err != nil {
+			log.Fatalf("Failed to load board %q: %v", bc.Name, err)
+		}
+		boardDB, err := openDatabase(boardCfg)
+		if err != nil {
+			log.Fatalf("Failed to initialize database for board %q: %v", bc.Name, err)
+		}
+		defer boardDB.Close()
 
-	log.Printf("Coastline BBS Server listening on port %d", cfg.Server.Port)
+		board := server.NewServer(boardCfg, boardDB)
+		startJobs(board)
+		servers = append(servers, board)
+		boards[bc.Name] = board
+		names[board] = bc.Name
+		if bc.Port != 0 {
+			if _, taken := ports[bc.Port]; taken {
+				log.Fatalf("Board %q uses port %d, which is already in use", bc.Name, bc.Port)
+			}
+			ports[bc.Port] = board
+		}
+		log.Printf("Serving board %q from %s", bc.Name, boardCfg.Paths.Root)
+	}
 
-	// Handle graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	go func() {
-		for {
-			conn, err := listener.Accept()
-			if err != nil {
-				log.Printf("Failed to accept connection: %v", err)
-				continue
+	// Callers on any port reach the extra boards as "name/username"
+	for _, srv := range servers {
+		for name, board := range boards {
+			if board != srv {
+				srv.AddBoard(name, board)
 			}
-			go bbsServer.HandleConnection(conn)
 		}
-	}()
+	}
+
+	for port, srv := range ports {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			log.Fatalf("Failed to listen on port %d: %v", port, err)
+		}
+		defer listener.Close()
+
+		if srv == bbsServer {
+			log.Printf("Coastline BBS Server listening on port %d", port)
+		} else {
+			log.Printf("Board %q listening on port %d", names[srv], port)
+		}
+		go serve(listener, srv)
+	}
+
+	// Start the optional HTTP API
+	if cfg.Server.API.Enabled {
+		apiServer := api.NewServer(cfg, db, bbsServer.WhosOnline, bbsServer.Metrics())
+		go func() {
+			if err := apiServer.ListenAndServe(); err != nil {
+				log.Printf("API server stopped: %v", err)
+			}
+		}()
+	}
 
-	// Wait for shutdown signal
-	<-sigChan
+	// Start the optional browser gateway
+	if cfg.Server.Web.Enabled {
+		go func() {
+			if err := bbsServer.ListenAndServeWeb(); err != nil {
+				log.Printf("Web gateway stopped: %v", err)
+			}
+		}()
+	}
+
+	// Start the optional Gopher server
+	if cfg.Server.Gopher.Enabled {
+		gopherServer := gopher.NewServer(cfg, db)
+		go func() {
+			if err := gopherServer.ListenAndServe(); err != nil {
+				log.Printf("Gopher server stopped: %v", err)
+			}
+		}()
+	}
+
+	// Start the optional feeds
+	if cfg.Server.Feeds.Enabled {
+		feedServer := feeds.NewServer(cfg, db)
+		go func() {
+			if err := feedServer.ListenAndServe(); err != nil {
+				log.Printf("Feeds stopped: %v", err)
+			}
+		}()
+	}
+
+	// Start the optional mail gateway
+	if cfg.Server.MailGateway.Enabled {
+		mailServer := mailgateway.NewServer(cfg, db, bbsServer.NotifyMail)
+		go func() {
+			if err := mailServer.ListenAndServe(); err != nil {
+				log.Printf("Mail gateway stopped: %v", err)
+			}
+		}()
+	}
+
+	// SIGHUP reloads the configuration; SIGINT and SIGTERM shut down
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reloadConfig(bbsServer, boards)
+	}
 	log.Println("Shutting down server...")
 }
+
+// reloadConfig reads config.yaml and each board's configuration again and
+// hands them to the running servers. A file that fails to load leaves its
+// board as it was.
+func reloadConfig(bbsServer *server.Server, boards map[string]*server.Server) {
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Printf("Config reload failed, keeping the running configuration: %v", err)
+		return
+	}
+	logReload("", bbsServer.Reload(cfg))
+
+	// Reload kept the running list of boards, so each one is being served
+	for _, bc := range cfg.Server.Boards {
+		boardCfg, err := loadBoardConfig(cfg, bc)
+		if err != nil {
+			log.Printf("Config reload failed for board %q, keeping its running configuration: %v", bc.Name, err)
+			continue
+		}
+		logReload(bc.Name, boards[bc.Name].Reload(boardCfg))
+	}
+}
+
+// logReload reports a reloaded configuration and the settings in it that
+// wait for a restart
+func logReload(board string, restart []string) {
+	prefix := "Config reload"
+	if board != "" {
+		prefix = fmt.Sprintf("Config reload for board %q", board)
+	}
+	log.Printf("%s: new sessions use the new configuration", prefix)
+	for _, setting := range restart {
+		log.Printf("%s: %s changed; restart the server to apply it", prefix, setting)
+	}
+}
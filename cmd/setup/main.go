@@ -1,30 +1,54 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"os"
 
 	"bbs/internal/config"
 	"bbs/internal/database"
+	"bbs/internal/paths"
 )
 
 func main() {
+	dataDir := flag.String("data-dir", "", "data directory (default $"+paths.EnvDataDir+", ./ if it has config.yaml, else XDG data dir)")
+	configFile := flag.String("config", "", "config file (default is <data-dir>/config/config.yaml)")
+	flag.Parse()
+
+	// Resolve the data directory and create its layout
+	layout := paths.Resolve(*dataDir)
+	if err := layout.Ensure(); err != nil {
+		log.Fatalf("Failed to create data directory %s: %v", layout.Root, err)
+	}
+
 	// Load configuration
-	cfg, err := config.Load("config.yaml")
+	cfg, err := config.LoadFromLayout(layout, *configFile)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	if cfg.Database.ReadOnly {
+		log.Fatalf("Database is configured as a read-only mirror; run setup against the primary")
+	}
+
 	// Initialize database
-	db, err := database.Initialize(cfg.Database.Path)
+	db, err := database.Initialize(cfg.DatabasePath())
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.Close()
 
-	// Load users from seed data
+	// Create the sysop account, with BBS_SYSOP_PASSWORD or a random password
+	sysopPassword := os.Getenv("BBS_SYSOP_PASSWORD")
+	generated := sysopPassword == ""
+	if generated {
+		if sysopPassword, err = database.GeneratePassword(); err != nil {
+			log.Fatalf("Failed to generate sysop password: %v", err)
+		}
+	}
 	fmt.Println("Loading users from seed data...")
-	err = db.LoadUsersFromSeed()
+	created, err := db.LoadUsersFromSeed(sysopPassword)
 	if err != nil {
 		fmt.Printf("Error loading users from seed data: %v\n", err)
 	} else {
@@ -40,8 +64,28 @@ func main() {
 		fmt.Println("Successfully loaded bulletins from seed data")
 	}
 
-	fmt.Println("\nDatabase setup complete!")
+	// Load message board topics from seed data
+	fmt.Println("Loading message board topics from seed data...")
+	err = db.LoadTopicsFromSeed()
+	if err != nil {
+		fmt.Printf("Error loading topics from seed data: %v\n", err)
+	} else {
+		fmt.Println("Successfully loaded topics from seed data")
+	}
+
+	// Load taglines from seed data
+	fmt.Println("Loading taglines from seed data...")
+	err = db.LoadTaglinesFromSeed()
+	if err != nil {
+		fmt.Printf("Error loading taglines from seed data: %v\n", err)
+	} else {
+		fmt.Println("Successfully loaded taglines from seed data")
+	}
+
+	fmt.Printf("\nDatabase setup complete! (%s)\n", cfg.DatabasePath())
 	fmt.Println("You can now run the BBS server with: go run main.go")
-	fmt.Println("Connect via SSH: ssh -p 2323 sysop@localhost (password: password)")
-	fmt.Println("Or connect as test user: ssh -p 2323 test@localhost (password: test)")
+	fmt.Println("Connect via SSH: ssh -p 2323 sysop@localhost")
+	if created && generated {
+		fmt.Printf("The sysop password is %s; it will not be shown again\n", sysopPassword)
+	}
 }
@@ -0,0 +1,192 @@
+// Package ansi measures text the board draws on the caller's screen, which
+// carries ANSI color codes and UTF-8 box drawing, arrows and, in callers'
+// names and posts, characters from any script. Widths are in terminal
+// columns, not bytes.
+package ansi
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Strip removes the escape sequences that color text and move the cursor,
+// ESC [ and its parameters up to the final letter
+func Strip(s string) string {
+	if !strings.Contains(s, "\033[") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if n := sequence(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteByte(s[i]) // A byte at a time, so UTF-8 passes through intact
+		i++
+	}
+	return b.String()
+}
+
+// Width returns how many columns s takes on a terminal, leaving out escape
+// sequences
+func Width(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if n := sequence(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		width += RuneWidth(r)
+		i += size
+	}
+	return width
+}
+
+// Truncate cuts s to at most width columns. Escape sequences are kept, so
+// colors set in what's left still take effect.
+func Truncate(s string, width int) string {
+	used := 0
+	for i := 0; i < len(s); {
+		if n := sequence(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if used+RuneWidth(r) > width {
+			return s[:i]
+		}
+		used += RuneWidth(r)
+		i += size
+	}
+	return s
+}
+
+// Ellipsize cuts s to at most width columns like Truncate, ending what's
+// left with "..." when anything had to go, for columns in lists and tables.
+// A cut that drops the reset ending a colored span adds one, so the color
+// doesn't run on into the rest of the row.
+func Ellipsize(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	cut := Truncate(s, width)
+	if width > 3 {
+		cut = Truncate(s, width-3) + "..."
+	}
+	if Strip(cut) != cut {
+		cut += "\033[0m"
+	}
+	return cut
+}
+
+// RuneWidth returns how many columns r takes: none for control and
+// combining characters, two for the wide characters of East Asian scripts
+// and for emoji, and one for everything else. Characters whose width
+// varies between terminals, such as box drawing, count as one, as they do
+// outside East Asian locales.
+func RuneWidth(r rune) int {
+	switch {
+	case r < 0x20 || r >= 0x7f && r < 0xa0:
+		return 0
+	case r < 0x300:
+		return 1
+	case r >= 0x1160 && r <= 0x11ff, // Hangul vowels and finals join the syllable before
+		unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	}
+	for _, w := range wide {
+		if r < w[0] {
+			break
+		}
+		if r <= w[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// wide are the ranges of characters terminals draw two columns wide, in
+// order
+var wide = [][2]rune{
+	{0x1100, 0x115f},   // Hangul initial consonants
+	{0x231a, 0x231b},   // Watch and hourglass
+	{0x2329, 0x232a},   // Angle brackets
+	{0x23e9, 0x23ec},   // Media buttons
+	{0x23f0, 0x23f0},   // Alarm clock
+	{0x23f3, 0x23f3},   // Hourglass
+	{0x25fd, 0x25fe},   // Small squares
+	{0x2614, 0x2615},   // Umbrella and hot drink
+	{0x2648, 0x2653},   // Zodiac
+	{0x267f, 0x267f},   // Wheelchair
+	{0x2693, 0x2693},   // Anchor
+	{0x26a1, 0x26a1},   // High voltage
+	{0x26aa, 0x26ab},   // Circles
+	{0x26bd, 0x26be},   // Balls
+	{0x26c4, 0x26c5},   // Snowman and sun
+	{0x26ce, 0x26ce},   // Ophiuchus
+	{0x26d4, 0x26d4},   // No entry
+	{0x26ea, 0x26ea},   // Church
+	{0x26f2, 0x26f3},   // Fountain and golf
+	{0x26f5, 0x26f5},   // Sailboat
+	{0x26fa, 0x26fa},   // Tent
+	{0x26fd, 0x26fd},   // Fuel pump
+	{0x2705, 0x2705},   // Check mark
+	{0x270a, 0x270b},   // Fists
+	{0x2728, 0x2728},   // Sparkles
+	{0x274c, 0x274c},   // Cross mark
+	{0x274e, 0x274e},   // Cross mark
+	{0x2753, 0x2755},   // Question and exclamation marks
+	{0x2757, 0x2757},   // Exclamation mark
+	{0x2795, 0x2797},   // Plus, minus and divide
+	{0x27b0, 0x27b0},   // Curly loop
+	{0x27bf, 0x27bf},   // Double curly loop
+	{0x2b1b, 0x2b1c},   // Large squares
+	{0x2b50, 0x2b50},   // Star
+	{0x2b55, 0x2b55},   // Circle
+	{0x2e80, 0x303e},   // CJK radicals, symbols and punctuation
+	{0x3041, 0x33ff},   // Kana, Bopomofo, Hangul compatibility and CJK compatibility
+	{0x3400, 0x4dbf},   // CJK extension A
+	{0x4e00, 0x9fff},   // CJK unified ideographs
+	{0xa000, 0xa4cf},   // Yi
+	{0xa960, 0xa97f},   // Hangul extended A
+	{0xac00, 0xd7a3},   // Hangul syllables
+	{0xf900, 0xfaff},   // CJK compatibility ideographs
+	{0xfe10, 0xfe19},   // Vertical forms
+	{0xfe30, 0xfe6f},   // CJK compatibility forms and small forms
+	{0xff00, 0xff60},   // Fullwidth forms
+	{0xffe0, 0xffe6},   // Fullwidth signs
+	{0x16fe0, 0x18cff}, // Tangut and Khitan
+	{0x1b000, 0x1b2ff}, // Kana supplement and Nushu
+	{0x1f004, 0x1f004}, // Mahjong tile
+	{0x1f0cf, 0x1f0cf}, // Playing card
+	{0x1f18e, 0x1f18e}, // AB button
+	{0x1f191, 0x1f19a}, // Squared words
+	{0x1f200, 0x1f2ff}, // Enclosed ideographs
+	{0x1f300, 0x1f64f}, // Pictographs and emoticons
+	{0x1f680, 0x1f6ff}, // Transport and map symbols
+	{0x1f7e0, 0x1f7eb}, // Colored circles and squares
+	{0x1f90c, 0x1f9ff}, // Supplemental pictographs
+	{0x1fa70, 0x1faff}, // Symbols and pictographs extended A
+	{0x20000, 0x2fffd}, // CJK extensions B to F
+	{0x30000, 0x3fffd}, // CJK extension G
+}
+
+// sequence returns the length of the escape sequence s starts with, or 0 if
+// it doesn't start with one
+func sequence(s string) int {
+	if len(s) < 2 || s[0] != '\033' || s[1] != '[' {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		// Parameters and intermediates run up to a final byte
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+		if s[i] < 0x20 || s[i] > 0x3f {
+			return i
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,88 @@
+package ansi
+
+import "testing"
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"\033[1;36mtitle\033[0m", "title"},
+		{"\033[2J\033[H\033[?25lscreen", "screen"},
+		{"box ─│┌ and → arrows", "box ─│┌ and → arrows"},
+		{"\033[44m日本\033[0m", "日本"},
+		{"cut off \033[1;3", "cut off "},
+		{"lone \033 escape", "lone \033 escape"},
+	}
+	for _, tt := range tests {
+		if got := Strip(tt.in); got != tt.want {
+			t.Errorf("Strip(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"\033[36m--- Main ---\033[0m", 12},
+		{"───", 3},
+		{"↑↓: Select", 10},
+		{"café", 4},
+		{"cafe\u0301", 4},
+		{"日本語", 6},
+		{"한국", 4},
+		{"ok 👍", 5},
+		{"\033[46m\033[30m Ｆｕｌｌ \033[0m", 10},
+	}
+	for _, tt := range tests {
+		if got := Width(tt.in); got != tt.want {
+			t.Errorf("Width(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"\033[31mhello\033[0m", 2, "\033[31mhe"},
+		{"─┼─┼─", 4, "─┼─┼"},
+		{"日本語", 5, "日本"},
+		{"日本語", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := Truncate(tt.in, tt.width); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestEllipsize(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"this is a very long string", 10, "this is..."},
+		{"exact", 5, "exact"},
+		{"ab", 1, "a"},
+		{"日本語のテキスト", 9, "日本語..."},
+		{"\033[1mbold text here\033[0m", 8, "\033[1mbold ...\033[0m"},
+		{"\033[31mred\033[0m", 2, "\033[31mre\033[0m"},
+		{"plain then \033[31mred\033[0m", 8, "plain..."},
+	}
+	for _, tt := range tests {
+		if got := Ellipsize(tt.in, tt.width); got != tt.want {
+			t.Errorf("Ellipsize(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package ansiart reads ANSI art screens drawn for DOS terminals: it strips
+// the SAUCE record, converts CP437 to UTF-8, and splits the art into screen
+// rows so long pieces can be shown a page at a time
+package ansiart
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// DefaultWidth is the width of art without a SAUCE record saying otherwise
+const DefaultWidth = 80
+
+// Reset turns off any colors the art left on
+const Reset = "\033[0m"
+
+// Art is a screen of ANSI art split into rows
+type Art struct {
+	Title string   // From the SAUCE record, if any
+	Width int      // Columns the art was drawn for
+	Rows  []string // Each starts with the colors in effect, so any row can begin a page
+}
+
+// Load reads an art file
+func Load(path string) (*Art, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data), nil
+}
+
+// Parse reads art from the bytes of a .ans file. Art that is already valid
+// UTF-8 with characters outside ASCII is taken as it is; anything else is
+// CP437.
+func Parse(data []byte) *Art {
+	art := &Art{Width: DefaultWidth}
+	data = art.stripSauce(data)
+	if i := bytes.IndexByte(data, 0x1a); i >= 0 {
+		data = data[:i] // DOS end of file
+	}
+
+	text := string(data)
+	if !utf8.Valid(data) || !hasNonASCII(data) {
+		text = decodeCP437(data)
+	}
+	art.Rows = split(text, art.Width)
+	return art
+}
+
+// stripSauce removes the SAUCE record and its comments from the end of
+// data, keeping the title and width it gives
+func (a *Art) stripSauce(data []byte) []byte {
+	const size = 128
+	if len(data) < size || !bytes.HasPrefix(data[len(data)-size:], []byte("SAUCE00")) {
+		return data
+	}
+	record := data[len(data)-size:]
+	data = data[:len(data)-size]
+
+	a.Title = strings.TrimRight(string(record[7:42]), " \x00")
+	dataType, fileType := record[94], record[95]
+	if dataType == 1 && fileType <= 2 { // Character: ASCII, ANSi or ANSiMation
+		if width := int(binary.LittleEndian.Uint16(record[96:98])); width > 0 {
+			a.Width = width
+		}
+	}
+
+	if comments := int(record[104]); comments > 0 {
+		block := 5 + comments*64
+		if len(data) >= block && bytes.HasPrefix(data[len(data)-block:], []byte("COMNT")) {
+			data = data[:len(data)-block]
+		}
+	}
+	return data
+}
+
+// split breaks art into the rows it fills on a terminal width columns wide,
+// wrapping long lines the way DOS did
+func split(text string, width int) []string {
+	var rows []string
+	var row strings.Builder
+	colors := ""  // SGR sequences in effect
+	col := 0      // Cursor column in the current row
+	full := false // The row is full; the next character starts another
+
+	endRow := func() {
+		rows = append(rows, row.String())
+		row.Reset()
+		row.WriteString(colors)
+		col = 0
+		full = false
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\x1b' && i+1 < len(runes) && runes[i+1] == '[':
+			j := i + 2
+			for j < len(runes) && (runes[j] < 0x40 || runes[j] > 0x7e) {
+				j++
+			}
+			if j == len(runes) {
+				i = j
+				continue
+			}
+			params, final := string(runes[i+2:j]), runes[j]
+			seq := string(runes[i : j+1])
+			i = j
+
+			switch final {
+			case 'm':
+				if resets(params) {
+					colors = seq
+				} else {
+					colors += seq
+				}
+				row.WriteString(seq)
+			case 'C': // Cursor forward, used in place of runs of spaces
+				n, err := strconv.Atoi(params)
+				if err != nil || n < 1 {
+					n = 1
+				}
+				for ; n > 0; n-- {
+					if full {
+						endRow()
+					}
+					row.WriteByte(' ')
+					if col++; col >= width {
+						full = true
+					}
+				}
+			}
+			// Other cursor movement would break paging, so it is dropped
+
+		case r == '\n':
+			endRow() // After a full row this is the wrap, not another row
+
+		case r == '\t':
+			for n := 8 - col%8; n > 0 && col < width; n-- {
+				row.WriteByte(' ')
+				col++
+			}
+			full = col >= width
+
+		case r < ' ' || r == 0x7f:
+			// Carriage returns and other control characters
+
+		default:
+			if full {
+				endRow()
+			}
+			row.WriteRune(r)
+			if col++; col >= width {
+				full = true
+			}
+		}
+	}
+	if col > 0 || full {
+		rows = append(rows, row.String())
+	}
+	return rows
+}
+
+// controlGlyphs are what DOS showed for the control characters 1 to 31
+var controlGlyphs = []rune("☺☻♥♦♣♠•◘○◙♂♀♪♫☼►◄↕‼¶§▬↨↑↓→←∟↔▲▼")
+
+// decodeCP437 converts CP437 to UTF-8. Control characters art uses as
+// pictures become those pictures; tabs, line breaks and escape sequences
+// are kept.
+func decodeCP437(data []byte) string {
+	var b strings.Builder
+	for _, c := range data {
+		switch {
+		case c == '\t' || c == '\n' || c == '\r' || c == 0x1b || c == 0:
+			b.WriteByte(c)
+		case c < ' ':
+			b.WriteRune(controlGlyphs[c-1])
+		case c == 0x7f:
+			b.WriteRune('⌂')
+		default:
+			b.WriteRune(charmap.CodePage437.DecodeByte(c))
+		}
+	}
+	return b.String()
+}
+
+// resets reports whether an SGR sequence starts from plain text
+func resets(params string) bool {
+	return params == "" || params == "0" || strings.HasPrefix(params, "0;")
+}
+
+// hasNonASCII reports whether data has any bytes above 127
+func hasNonASCII(data []byte) bool {
+	for _, b := range data {
+		if b >= 0x80 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package ansiart
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sauce builds a SAUCE record for art of the given width, with comment
+// lines before it
+func sauce(title string, width int, comments ...string) []byte {
+	var b bytes.Buffer
+	b.WriteByte(0x1a)
+	if len(comments) > 0 {
+		b.WriteString("COMNT")
+		for _, c := range comments {
+			b.WriteString(c + strings.Repeat(" ", 64-len(c)))
+		}
+	}
+	record := make([]byte, 128)
+	copy(record, "SAUCE00")
+	copy(record[7:42], title+strings.Repeat(" ", 35-len(title)))
+	record[94], record[95] = 1, 1 // Character, ANSi
+	binary.LittleEndian.PutUint16(record[96:98], uint16(width))
+	record[104] = byte(len(comments))
+	b.Write(record)
+	return b.Bytes()
+}
+
+func TestParseCP437(t *testing.T) {
+	data := append([]byte("\x1b[1;34m\xc9\xcd\xbb\x03\r\n\xb0\xb1\xb2\x1b[0m\r\n"), sauce("Harbor", 80, "Drawn in 1994")...)
+	art := Parse(data)
+	if art.Title != "Harbor" || art.Width != 80 {
+		t.Errorf("title %q width %d, want Harbor 80", art.Title, art.Width)
+	}
+	want := []string{"\x1b[1;34m╔═╗♥", "\x1b[1;34m░▒▓\x1b[0m"}
+	if !reflect.DeepEqual(art.Rows, want) {
+		t.Errorf("rows = %q, want %q", art.Rows, want)
+	}
+}
+
+func TestParseUTF8(t *testing.T) {
+	art := Parse([]byte("╔═╗\n"))
+	if want := []string{"╔═╗"}; !reflect.DeepEqual(art.Rows, want) {
+		t.Errorf("rows = %q, want %q", art.Rows, want)
+	}
+}
+
+func TestParseWraps(t *testing.T) {
+	data := append([]byte("abcdefghij\r\nab\x1b[3Cf\x1b[31mghijk\r\n"), sauce("", 5)...)
+	art := Parse(data)
+	want := []string{"abcde", "fghij", "ab   ", "f\x1b[31mghij", "\x1b[31mk"}
+	if !reflect.DeepEqual(art.Rows, want) {
+		t.Errorf("rows = %q, want %q", art.Rows, want)
+	}
+}
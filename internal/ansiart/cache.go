@@ -0,0 +1,119 @@
+package ansiart
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Cache keeps parsed art so screens shown on every call aren't read and
+// split again each time. It only caches while it is watching the art
+// directory for changes; until then every Load reads the file.
+type Cache struct {
+	mu       sync.Mutex
+	art      map[string]*Art
+	gen      int // Counts clears, so art read across one isn't cached
+	watching bool
+}
+
+// NewCache creates an empty art cache
+func NewCache() *Cache {
+	return &Cache{art: make(map[string]*Art)}
+}
+
+// Load returns the art at path, reading it if it isn't cached. Files that
+// can't be read aren't cached, so they are tried again next time.
+func (c *Cache) Load(path string) (*Art, error) {
+	c.mu.Lock()
+	art, ok := c.art[path]
+	gen, watching := c.gen, c.watching
+	c.mu.Unlock()
+	if ok {
+		return art, nil
+	}
+
+	art, err := Load(path)
+	if err != nil || !watching {
+		return art, err
+	}
+	c.mu.Lock()
+	if c.gen == gen {
+		c.art[path] = art
+	}
+	c.mu.Unlock()
+	return art, nil
+}
+
+// Clear empties the cache
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.art)
+	c.gen++
+}
+
+// Watch starts caching and empties the cache whenever anything under dir
+// changes, so edited art shows the next time it is drawn. changed, if not
+// nil, is called with the path of each change.
+func (c *Cache) Watch(dir string, changed func(path string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// fsnotify doesn't watch subdirectories, so each is added on its own
+	if err := watchTree(watcher, dir); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	c.watching = true
+	c.mu.Unlock()
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue // Touched, not changed
+				}
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						watchTree(watcher, event.Name)
+					}
+				}
+				c.Clear()
+				if changed != nil {
+					changed(event.Name)
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				// Events may have been dropped, so nothing cached can be trusted
+				c.Clear()
+			}
+		}
+	}()
+	return nil
+}
+
+// watchTree adds dir and every directory under it to watcher
+func watchTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,66 @@
+package ansiart
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheReloadsChangedArt(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "menus")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(sub, "main.ans")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCache()
+	changed := make(chan string, 16)
+	if err := c.Watch(dir, func(path string) { changed <- path }); err != nil {
+		t.Fatal(err)
+	}
+	art, err := c.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"old"}; !reflect.DeepEqual(art.Rows, want) {
+		t.Fatalf("rows = %q, want %q", art.Rows, want)
+	}
+	if again, _ := c.Load(path); again != art {
+		t.Error("art was read again while unchanged")
+	}
+
+	if err := os.WriteFile(path, []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change seen")
+	}
+	art, err = c.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"new"}; !reflect.DeepEqual(art.Rows, want) {
+		t.Errorf("rows after the edit = %q, want %q", art.Rows, want)
+	}
+}
+
+func TestCacheWithoutWatching(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "welcome.ans")
+	if err := os.WriteFile(path, []byte("hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewCache()
+	first, _ := c.Load(path)
+	second, _ := c.Load(path)
+	if first == nil || first == second {
+		t.Error("art should be read on every Load until the cache watches for changes")
+	}
+}
@@ -0,0 +1,580 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bbs/internal/database"
+	"bbs/internal/metrics"
+	"bbs/internal/modules/feedback"
+	"bbs/internal/modules/registration"
+)
+
+// sysopLevel is the access level the admin endpoints require
+const sysopLevel = 255
+
+// statsDays is how far back the activity in /admin/stats looks by default
+const statsDays = 7
+
+// requireSysop authenticates a request with a personal API token that
+// belongs to a sysop account
+func (s *Server) requireSysop(next http.HandlerFunc) http.Handler {
+	return s.requireToken(func(w http.ResponseWriter, r *http.Request) {
+		if currentUser(r).AccessLevel < sysopLevel {
+			writeError(w, http.StatusForbidden, "sysop access required")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// writable refuses changes on a read-only mirror and reports whether the
+// request may go ahead
+func (s *Server) writable(w http.ResponseWriter) bool {
+	if s.db.ReadOnly() {
+		writeError(w, http.StatusForbidden, "this board is a read-only mirror")
+		return false
+	}
+	return true
+}
+
+// decodeJSON reads a request body into v, refusing unknown fields so typos
+// aren't silently ignored
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// adminUserResponse is a user account as the sysop sees it
+type adminUserResponse struct {
+	ID int `json:"id"`
+	userResponse
+	IsActive bool `json:"is_active"`
+}
+
+func newAdminUserResponse(user *database.User) adminUserResponse {
+	return adminUserResponse{ID: user.ID, userResponse: newUserResponse(user), IsActive: user.IsActive}
+}
+
+// userRequest creates or changes an account. Fields left out of a PATCH
+// keep their current values.
+type userRequest struct {
+	Username    *string `json:"username"`
+	Password    *string `json:"password"`
+	RealName    *string `json:"real_name"`
+	Email       *string `json:"email"`
+	AccessLevel *int    `json:"access_level"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// validate checks the fields a request sets
+func (req *userRequest) validate() error {
+	if req.Password != nil && len(strings.TrimSpace(*req.Password)) < 6 {
+		return errors.New("password must be at least 6 characters")
+	}
+	if req.AccessLevel != nil && (*req.AccessLevel < 0 || *req.AccessLevel > sysopLevel) {
+		return fmt.Errorf("access_level must be between 0 and %d", sysopLevel)
+	}
+	return nil
+}
+
+// validateUsername checks a new account's name as registration does
+func validateUsername(username string) error {
+	switch {
+	case len(username) < 3:
+		return errors.New("username must be at least 3 characters")
+	case strings.ContainsAny(username, " \t"):
+		return errors.New("username cannot contain spaces")
+	case strings.Contains(username, "/"):
+		return errors.New("username cannot contain '/'")
+	case strings.EqualFold(username, registration.NewUserName) || strings.EqualFold(username, feedback.LoginName):
+		return fmt.Errorf("username %q is reserved", username)
+	}
+	return nil
+}
+
+// handleAdminListUsers lists accounts by name. Pass ?limit=N for up to N.
+func (s *Server) handleAdminListUsers(w http.ResponseWriter, r *http.Request) {
+	limit := 500
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 5000 {
+		limit = l
+	}
+
+	users, err := s.db.GetAllUsers(limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load users")
+		return
+	}
+	result := make([]adminUserResponse, 0, len(users))
+	for i := range users {
+		result = append(result, newAdminUserResponse(&users[i]))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// lookupUser loads the account named in the path, disabled or not,
+// answering 404 if there is none
+func (s *Server) lookupUser(w http.ResponseWriter, r *http.Request) (*database.User, bool) {
+	user, err := s.db.GetUserByName(r.PathValue("username"))
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "user not found")
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load user")
+		return nil, false
+	}
+	return user, true
+}
+
+// handleAdminGetUser returns one account
+func (s *Server) handleAdminGetUser(w http.ResponseWriter, r *http.Request) {
+	if user, ok := s.lookupUser(w, r); ok {
+		writeJSON(w, http.StatusOK, newAdminUserResponse(user))
+	}
+}
+
+// handleAdminCreateUser creates an account. Username and password are
+// required; the access level defaults to registration's.
+func (s *Server) handleAdminCreateUser(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	var req userRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Username == nil || req.Password == nil {
+		writeError(w, http.StatusBadRequest, "username and password are required")
+		return
+	}
+	username := strings.TrimSpace(*req.Username)
+	if err := validateUsername(username); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	exists, err := s.db.UsernameExists(username)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check username")
+		return
+	}
+	if exists {
+		writeError(w, http.StatusConflict, "username is already taken")
+		return
+	}
+
+	user := &database.User{
+		Username:    username,
+		Password:    strings.TrimSpace(*req.Password), // Hashed by the database layer
+		AccessLevel: s.config.BBS.Registration.DefaultAccessLevel,
+	}
+	if req.RealName != nil {
+		user.RealName = strings.TrimSpace(*req.RealName)
+	}
+	if req.Email != nil {
+		user.Email = strings.TrimSpace(*req.Email)
+	}
+	if req.AccessLevel != nil {
+		user.AccessLevel = *req.AccessLevel
+	}
+	if err := s.db.CreateUser(user); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create user")
+		return
+	}
+
+	created, err := s.db.GetUserByName(username)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load user")
+		return
+	}
+	// New accounts start active; a request may create one disabled
+	if req.IsActive != nil && !*req.IsActive {
+		if err := s.db.UpdateUser(created.ID, created.Username, created.Password, created.RealName, created.Email, created.AccessLevel, false); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to update user")
+			return
+		}
+		created.IsActive = false
+	}
+	log.Printf("API: %s created user %s (access level %d)", currentUser(r).Username, created.Username, created.AccessLevel)
+	writeJSON(w, http.StatusCreated, newAdminUserResponse(created))
+}
+
+// handleAdminUpdateUser changes the fields a request sets. Usernames can't
+// be changed, since posts and mail refer to them.
+func (s *Server) handleAdminUpdateUser(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	user, ok := s.lookupUser(w, r)
+	if !ok {
+		return
+	}
+	var req userRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Username != nil && *req.Username != user.Username {
+		writeError(w, http.StatusBadRequest, "usernames can't be changed")
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Password != nil {
+		user.Password = strings.TrimSpace(*req.Password)
+	}
+	if req.RealName != nil {
+		user.RealName = strings.TrimSpace(*req.RealName)
+	}
+	if req.Email != nil {
+		user.Email = strings.TrimSpace(*req.Email)
+	}
+	if req.AccessLevel != nil {
+		user.AccessLevel = *req.AccessLevel
+	}
+	if req.IsActive != nil {
+		user.IsActive = *req.IsActive
+	}
+	if err := s.db.UpdateUser(user.ID, user.Username, user.Password, user.RealName, user.Email, user.AccessLevel, user.IsActive); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update user")
+		return
+	}
+	log.Printf("API: %s updated user %s", currentUser(r).Username, user.Username)
+	writeJSON(w, http.StatusOK, newAdminUserResponse(user))
+}
+
+// handleAdminDeleteUser deletes an account and its private data, as the
+// retention job does. A sysop can't delete their own account this way.
+func (s *Server) handleAdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	user, ok := s.lookupUser(w, r)
+	if !ok {
+		return
+	}
+	if user.ID == currentUser(r).ID {
+		writeError(w, http.StatusConflict, "you can't delete your own account")
+		return
+	}
+	if err := s.db.DeleteAccount(user.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete user")
+		return
+	}
+	log.Printf("API: %s deleted user %s", currentUser(r).Username, user.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// bulletinRequest creates or changes a bulletin
+type bulletinRequest struct {
+	Title *string `json:"title"`
+	Body  *string `json:"body"`
+}
+
+// handleAdminListBulletins lists the current bulletins
+func (s *Server) handleAdminListBulletins(w http.ResponseWriter, r *http.Request) {
+	bulletins, err := s.db.GetBulletins(500)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load bulletins")
+		return
+	}
+	if bulletins == nil {
+		bulletins = []database.Bulletin{}
+	}
+	writeJSON(w, http.StatusOK, bulletins)
+}
+
+// lookupBulletin loads the bulletin named in the path, answering 404 if
+// there is none
+func (s *Server) lookupBulletin(w http.ResponseWriter, r *http.Request) (*database.Bulletin, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid bulletin id")
+		return nil, false
+	}
+	bulletin, err := s.db.GetBulletinByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "bulletin not found")
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load bulletin")
+		return nil, false
+	}
+	return bulletin, true
+}
+
+// handleAdminGetBulletin returns one bulletin
+func (s *Server) handleAdminGetBulletin(w http.ResponseWriter, r *http.Request) {
+	if bulletin, ok := s.lookupBulletin(w, r); ok {
+		writeJSON(w, http.StatusOK, bulletin)
+	}
+}
+
+// handleAdminCreateBulletin posts a bulletin in the token owner's name
+func (s *Server) handleAdminCreateBulletin(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	var req bulletinRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Title == nil || strings.TrimSpace(*req.Title) == "" || req.Body == nil {
+		writeError(w, http.StatusBadRequest, "title and body are required")
+		return
+	}
+
+	bulletin := &database.Bulletin{Title: strings.TrimSpace(*req.Title), Body: *req.Body, Author: currentUser(r).Username}
+	if err := s.db.CreateBulletin(bulletin); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create bulletin")
+		return
+	}
+	created, err := s.db.GetBulletinByID(bulletin.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load bulletin")
+		return
+	}
+	log.Printf("API: %s posted bulletin %d", currentUser(r).Username, created.ID)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// handleAdminUpdateBulletin changes a bulletin's title or body
+func (s *Server) handleAdminUpdateBulletin(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	bulletin, ok := s.lookupBulletin(w, r)
+	if !ok {
+		return
+	}
+	var req bulletinRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Title != nil {
+		if strings.TrimSpace(*req.Title) == "" {
+			writeError(w, http.StatusBadRequest, "title can't be empty")
+			return
+		}
+		bulletin.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Body != nil {
+		bulletin.Body = *req.Body
+	}
+	if err := s.db.UpdateBulletin(bulletin.ID, bulletin.Title, bulletin.Body); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update bulletin")
+		return
+	}
+	log.Printf("API: %s updated bulletin %d", currentUser(r).Username, bulletin.ID)
+	writeJSON(w, http.StatusOK, bulletin)
+}
+
+// handleAdminDeleteBulletin removes a bulletin
+func (s *Server) handleAdminDeleteBulletin(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	bulletin, ok := s.lookupBulletin(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteBulletin(bulletin.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete bulletin")
+		return
+	}
+	log.Printf("API: %s deleted bulletin %d", currentUser(r).Username, bulletin.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dayCount is one day of the posts graph
+type dayCount struct {
+	Day   string `json:"day"` // YYYY-MM-DD
+	Count int    `json:"count"`
+}
+
+// statsResponse summarizes the board for dashboards
+type statsResponse struct {
+	Totals               *database.BoardTotals `json:"totals"`
+	Online               int                   `json:"online"`
+	PendingRegistrations int                   `json:"pending_registrations"`
+	PendingUploads       int                   `json:"pending_uploads"`
+	Days                 int                   `json:"days"`
+	CallsByHour          [24]int               `json:"calls_by_hour"`
+	PostsByDay           []dayCount            `json:"posts_by_day"`
+}
+
+// handleAdminStats returns board totals and recent activity. Pass ?days=N
+// to look back N days (default 7, at most 365).
+func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	days := statsDays
+	if d, err := strconv.Atoi(r.URL.Query().Get("days")); err == nil && d > 0 && d <= 365 {
+		days = d
+	}
+
+	totals, err := s.db.GetBoardTotals()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to count totals")
+		return
+	}
+	activity, err := s.db.GetActivityStats(time.Now(), days)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load activity")
+		return
+	}
+	pending, err := s.db.GetPendingRegistrations()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load pending registrations")
+		return
+	}
+	uploads, err := s.db.CountPendingUploads()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to count pending uploads")
+		return
+	}
+
+	stats := statsResponse{
+		Totals:               totals,
+		PendingRegistrations: len(pending),
+		PendingUploads:       uploads,
+		Days:                 days,
+		CallsByHour:          activity.CallsByHour,
+	}
+	if s.online != nil {
+		stats.Online = len(s.online())
+	}
+	for _, d := range activity.PostsByDay {
+		stats.PostsByDay = append(stats.PostsByDay, dayCount{Day: d.Day.Format("2006-01-02"), Count: d.Count})
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// nodeResponse is a caller on one node
+type nodeResponse struct {
+	Node        int       `json:"node"`
+	Username    string    `json:"username"`
+	Activity    string    `json:"activity"`
+	LoginTime   time.Time `json:"login_time"`
+	IdleSeconds int       `json:"idle_seconds"`
+	Away        bool      `json:"away"`
+	Invisible   bool      `json:"invisible"`
+}
+
+// handleAdminOnline lists the callers online, invisible ones included
+func (s *Server) handleAdminOnline(w http.ResponseWriter, r *http.Request) {
+	result := []nodeResponse{}
+	if s.online != nil {
+		for _, n := range s.online() {
+			result = append(result, nodeResponse{
+				Node:        n.Number,
+				Username:    n.Username,
+				Activity:    n.Activity,
+				LoginTime:   n.LoginTime,
+				IdleSeconds: int(n.Idle.Seconds()),
+				Away:        n.Away,
+				Invisible:   n.Invisible,
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// metricsResponse is the board's per-command timings
+type metricsResponse struct {
+	Since    time.Time         `json:"since"`
+	SlowMS   int64             `json:"slow_ms"`
+	Commands []commandResponse `json:"commands"`
+}
+
+// commandResponse is what has been counted for one command. Busy is the
+// time the board spent working rather than waiting for the caller.
+type commandResponse struct {
+	Name      string `json:"name"`
+	Runs      int64  `json:"runs"`
+	Errors    int64  `json:"errors"`
+	TotalMS   int64  `json:"total_ms"`
+	BusyMS    int64  `json:"busy_ms"`
+	AvgBusyMS int64  `json:"avg_busy_ms"`
+	Keys      int64  `json:"keys"`
+	SlowKeys  int64  `json:"slow_keys"`
+	MaxWaitMS int64  `json:"max_wait_ms"`
+}
+
+// handleAdminMetrics returns how often each command has run, how long the
+// board spent on it and how quickly it answered keys. Pass
+// ?format=prometheus for the Prometheus text format.
+func (s *Server) handleAdminMetrics(w http.ResponseWriter, r *http.Request) {
+	commands, since := s.metrics.Snapshot()
+	if r.URL.Query().Get("format") == "prometheus" {
+		writePrometheus(w, commands)
+		return
+	}
+
+	result := metricsResponse{
+		Since:    since,
+		SlowMS:   s.metrics.Slow().Milliseconds(),
+		Commands: []commandResponse{},
+	}
+	for _, c := range commands {
+		result.Commands = append(result.Commands, commandResponse{
+			Name:      c.Name,
+			Runs:      c.Runs,
+			Errors:    c.Errors,
+			TotalMS:   c.Total.Milliseconds(),
+			BusyMS:    c.Busy.Milliseconds(),
+			AvgBusyMS: c.AvgBusy().Milliseconds(),
+			Keys:      c.Keys,
+			SlowKeys:  c.Slow,
+			MaxWaitMS: c.MaxWait.Milliseconds(),
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// writePrometheus writes the command timings in the Prometheus text format
+func writePrometheus(w http.ResponseWriter, commands []metrics.Command) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	series := []struct {
+		name, kind, help string
+		value            func(metrics.Command) float64
+	}{
+		{"bbs_command_runs_total", "counter", "Times each command has run",
+			func(c metrics.Command) float64 { return float64(c.Runs) }},
+		{"bbs_command_errors_total", "counter", "Runs that failed",
+			func(c metrics.Command) float64 { return float64(c.Errors) }},
+		{"bbs_command_seconds_total", "counter", "Time spent in each command, waiting for the caller included",
+			func(c metrics.Command) float64 { return c.Total.Seconds() }},
+		{"bbs_command_busy_seconds_total", "counter", "Time the board spent working on each command",
+			func(c metrics.Command) float64 { return c.Busy.Seconds() }},
+		{"bbs_command_keys_total", "counter", "Keys answered in each command",
+			func(c metrics.Command) float64 { return float64(c.Keys) }},
+		{"bbs_command_slow_keys_total", "counter", "Keys answered more slowly than the slow threshold",
+			func(c metrics.Command) float64 { return float64(c.Slow) }},
+		{"bbs_command_max_wait_seconds", "gauge", "Longest a caller waited for a key to be answered",
+			func(c metrics.Command) float64 { return c.MaxWait.Seconds() }},
+	}
+	for _, m := range series {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, c := range commands {
+			fmt.Fprintf(w, "%s{command=%q} %g\n", m.name, c.Name, m.value(c))
+		}
+	}
+}
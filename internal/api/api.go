@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/metrics"
+	"bbs/internal/modules/online"
+)
+
+// contextKey is used to store request-scoped values
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// Server exposes the BBS over an HTTP/JSON API
+type Server struct {
+	config  *config.Config
+	db      *database.DB
+	online  online.Lister     // Callers on the board, for the admin endpoints
+	metrics *metrics.Registry // Per-command timings, nil when there are none
+	mux     *http.ServeMux
+}
+
+// NewServer creates a new API server and registers its routes. whosOnline
+// lists the board's callers and may be nil when there are none to list;
+// commands holds the board's per-command timings and may also be nil.
+func NewServer(cfg *config.Config, db *database.DB, whosOnline online.Lister, commands *metrics.Registry) *Server {
+	s := &Server{
+		config:  cfg,
+		db:      db,
+		online:  whosOnline,
+		metrics: commands,
+		mux:     http.NewServeMux(),
+	}
+	s.registerRoutes()
+	return s
+}
+
+// registerRoutes wires endpoints to their handlers
+func (s *Server) registerRoutes() {
+	s.mux.Handle("GET /api/v1/me", s.requireToken(s.handleMe))
+	s.mux.Handle("GET /api/v1/mail", s.requireToken(s.handleListMail))
+	s.mux.Handle("GET /api/v1/mail/{id}", s.requireToken(s.handleGetMail))
+	s.mux.Handle("GET /api/v1/subscriptions", s.requireToken(s.handleListSubscriptions))
+	s.mux.Handle("PUT /api/v1/subscriptions/{topic}", s.requireToken(s.handleSubscribe))
+	s.mux.Handle("DELETE /api/v1/subscriptions/{topic}", s.requireToken(s.handleUnsubscribe))
+
+	// Board management, for tokens belonging to sysops
+	s.mux.Handle("GET /api/v1/admin/users", s.requireSysop(s.handleAdminListUsers))
+	s.mux.Handle("POST /api/v1/admin/users", s.requireSysop(s.handleAdminCreateUser))
+	s.mux.Handle("GET /api/v1/admin/users/{username}", s.requireSysop(s.handleAdminGetUser))
+	s.mux.Handle("PATCH /api/v1/admin/users/{username}", s.requireSysop(s.handleAdminUpdateUser))
+	s.mux.Handle("DELETE /api/v1/admin/users/{username}", s.requireSysop(s.handleAdminDeleteUser))
+	s.mux.Handle("GET /api/v1/admin/bulletins", s.requireSysop(s.handleAdminListBulletins))
+	s.mux.Handle("POST /api/v1/admin/bulletins", s.requireSysop(s.handleAdminCreateBulletin))
+	s.mux.Handle("GET /api/v1/admin/bulletins/{id}", s.requireSysop(s.handleAdminGetBulletin))
+	s.mux.Handle("PATCH /api/v1/admin/bulletins/{id}", s.requireSysop(s.handleAdminUpdateBulletin))
+	s.mux.Handle("DELETE /api/v1/admin/bulletins/{id}", s.requireSysop(s.handleAdminDeleteBulletin))
+	s.mux.Handle("GET /api/v1/admin/stats", s.requireSysop(s.handleAdminStats))
+	s.mux.Handle("GET /api/v1/admin/online", s.requireSysop(s.handleAdminOnline))
+	s.mux.Handle("GET /api/v1/admin/metrics", s.requireSysop(s.handleAdminMetrics))
+}
+
+// Handler returns the root HTTP handler
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// ListenAndServe starts serving the API on the configured address
+func (s *Server) ListenAndServe() error {
+	addr := s.config.Server.API.Listen
+	log.Printf("API listening on %s", addr)
+	return http.ListenAndServe(addr, s.mux)
+}
+
+// requireToken authenticates a request using a personal API token
+func (s *Server) requireToken(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || strings.TrimSpace(token) == "" {
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+
+		user, err := s.db.AuthenticateAPIToken(strings.TrimSpace(token))
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		next(w, r.WithContext(ctx))
+	})
+}
+
+// currentUser returns the user authenticated for this request
+func currentUser(r *http.Request) *database.User {
+	user, _ := r.Context().Value(userContextKey).(*database.User)
+	return user
+}
+
+// writeJSON encodes a value as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+func TestExpiredAccountRefused(t *testing.T) {
+	db, err := database.Initialize(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer db.Close()
+	if err := db.CreateUser(&database.User{Username: "alice", Password: "secret", AccessLevel: 10}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	alice, _ := db.GetUser("alice")
+	token, err := db.CreateAPIToken(alice.ID, "scripts")
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+	handler := NewServer(&config.Config{}, db, nil, nil).Handler()
+
+	get := func() int {
+		req := httptest.NewRequest("GET", "/api/v1/mail", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("mail before the account expired = %d, want 200", code)
+	}
+
+	lapsed := time.Now().Add(-time.Hour)
+	db.SetExpiry(alice.ID, &lapsed)
+	if code := get(); code != http.StatusUnauthorized {
+		t.Errorf("mail after the account expired = %d, want 401", code)
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	db, err := database.Initialize(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer db.Close()
+	if err := db.CreateUser(&database.User{Username: "alice", Password: "secret", AccessLevel: 10}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	alice, _ := db.GetUser("alice")
+	token, err := db.CreateAPIToken(alice.ID, "bridge")
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+	general := &database.Topic{Name: "General"}
+	staff := &database.Topic{Name: "Staff", AccessLevel: 100}
+	for _, topic := range []*database.Topic{general, staff} {
+		if err := db.CreateTopic(topic); err != nil {
+			t.Fatalf("CreateTopic failed: %v", err)
+		}
+	}
+	if err := db.CreatePost(&database.Post{TopicID: general.ID, Author: "bob", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	handler := NewServer(&config.Config{}, db, nil, nil).Handler()
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	list := func() []database.TopicSubscription {
+		rec := do("GET", "/api/v1/subscriptions")
+		var subs []database.TopicSubscription
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &subs) != nil {
+			t.Fatalf("GET subscriptions = %d %s", rec.Code, rec.Body)
+		}
+		return subs
+	}
+	path := func(topic *database.Topic) string {
+		return "/api/v1/subscriptions/" + strconv.Itoa(topic.ID)
+	}
+
+	if subs := list(); len(subs) != 0 {
+		t.Errorf("subscriptions before any = %+v", subs)
+	}
+	if rec := do("PUT", path(general)); rec.Code != http.StatusNoContent {
+		t.Errorf("subscribe = %d %s", rec.Code, rec.Body)
+	}
+	if rec := do("PUT", path(staff)); rec.Code != http.StatusNotFound {
+		t.Errorf("subscribe to a topic above the account's level = %d, want 404", rec.Code)
+	}
+	if subs := list(); len(subs) != 1 || subs[0].TopicID != general.ID || subs[0].NewPosts != 1 {
+		t.Errorf("subscriptions = %+v, want General with one new post", subs)
+	}
+	if rec := do("DELETE", path(general)); rec.Code != http.StatusNoContent {
+		t.Errorf("unsubscribe = %d %s", rec.Code, rec.Body)
+	}
+	if rec := do("DELETE", path(general)); rec.Code != http.StatusNotFound {
+		t.Errorf("unsubscribe twice = %d, want 404", rec.Code)
+	}
+	if subs := list(); len(subs) != 0 {
+		t.Errorf("subscriptions after unsubscribing = %+v", subs)
+	}
+}
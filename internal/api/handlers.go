@@ -0,0 +1,147 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bbs/internal/database"
+)
+
+// userResponse is the public view of a user account; it never includes the password
+type userResponse struct {
+	Username    string     `json:"username"`
+	RealName    string     `json:"real_name"`
+	Email       string     `json:"email"`
+	AccessLevel int        `json:"access_level"`
+	LastCall    *time.Time `json:"last_call"`
+	TotalCalls  int        `json:"total_calls"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func newUserResponse(user *database.User) userResponse {
+	return userResponse{
+		Username:    user.Username,
+		RealName:    user.RealName,
+		Email:       user.Email,
+		AccessLevel: user.AccessLevel,
+		LastCall:    user.LastCall,
+		TotalCalls:  user.TotalCalls,
+		CreatedAt:   user.CreatedAt,
+	}
+}
+
+// handleMe returns the account the token belongs to
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, newUserResponse(currentUser(r)))
+}
+
+// handleListMail lists private mail addressed to the token owner.
+// Pass ?unread=1 to only return unread messages.
+func (s *Server) handleListMail(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+
+	messages, err := s.db.GetMessages(user.Username, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load mail")
+		return
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "1"
+	result := make([]database.Message, 0, len(messages))
+	for _, msg := range messages {
+		if unreadOnly && msg.IsRead {
+			continue
+		}
+		result = append(result, msg)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleGetMail returns a single message owned by the token owner
+func (s *Server) handleGetMail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid message id")
+		return
+	}
+
+	msg, err := s.db.GetMessageByID(currentUser(r).Username, id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "message not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, msg)
+}
+
+// handleListSubscriptions lists the topics the token owner follows, with
+// how many posts in each they haven't read
+func (s *Server) handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	subscriptions, err := s.db.GetTopicSubscriptions(user.ID, user.AccessLevel)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load subscriptions")
+		return
+	}
+	if subscriptions == nil {
+		subscriptions = []database.TopicSubscription{}
+	}
+	writeJSON(w, http.StatusOK, subscriptions)
+}
+
+// handleSubscribe makes the token owner follow a topic they can read.
+// Following it again changes nothing.
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	topicID, err := strconv.Atoi(r.PathValue("topic"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid topic id")
+		return
+	}
+
+	user := currentUser(r)
+	err = s.db.SubscribeTopic(user.ID, user.AccessLevel, topicID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "topic not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to subscribe")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleUnsubscribe stops the token owner following a topic
+func (s *Server) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	topicID, err := strconv.Atoi(r.PathValue("topic"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid topic id")
+		return
+	}
+
+	err = s.db.UnsubscribeTopic(currentUser(r).ID, topicID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "not subscribed to that topic")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to unsubscribe")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
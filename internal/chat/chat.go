@@ -0,0 +1,210 @@
+// Package chat implements the teleconference: named channels shared by
+// everyone in them, with join and leave notices and private messages.
+package chat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// queueSize bounds each member's undelivered lines; a member who falls
+// further behind misses lines rather than stalling the channel
+const queueSize = 64
+
+// Hub tracks who is in which channel. One hub is shared by all sessions.
+type Hub struct {
+	mu       sync.Mutex
+	channels map[string]map[*Member]bool
+}
+
+// NewHub creates an empty hub
+func NewHub() *Hub {
+	return &Hub{
+		channels: make(map[string]map[*Member]bool),
+	}
+}
+
+// Kind says what sort of line a member receives
+type Kind int
+
+const (
+	Message     Kind = iota // Someone spoke in the channel
+	Notice                  // Someone joined or left
+	Private                 // A private message to the member
+	PrivateEcho             // A copy of a private message the member sent
+)
+
+// Line is something a member should see
+type Line struct {
+	Kind Kind
+	From string // Who spoke, or the recipient for PrivateEcho
+	Text string
+}
+
+// String formats the line the way the teleconference shows it
+func (l Line) String() string {
+	switch l.Kind {
+	case Notice:
+		return "*** " + l.Text
+	case Private:
+		return fmt.Sprintf("*%s* %s", l.From, l.Text)
+	case PrivateEcho:
+		return fmt.Sprintf("-> *%s* %s", l.From, l.Text)
+	default:
+		return fmt.Sprintf("<%s> %s", l.From, l.Text)
+	}
+}
+
+// Member is one caller's presence in the teleconference
+type Member struct {
+	Name string
+	Node int
+
+	// Lines delivers what the member should see. It is closed on Leave.
+	Lines chan Line
+
+	hub     *Hub
+	channel string
+}
+
+// Who describes a member for /who listings
+type Who struct {
+	Name string
+	Node int
+}
+
+// Join adds a caller to a channel and announces them to the others there
+func (h *Hub) Join(channel, name string, node int) *Member {
+	m := &Member{
+		Name:  name,
+		Node:  node,
+		Lines: make(chan Line, queueSize),
+		hub:   h,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.enter(m, channel)
+	return m
+}
+
+// Channel returns the member's current channel
+func (m *Member) Channel() string {
+	m.hub.mu.Lock()
+	defer m.hub.mu.Unlock()
+	return m.channel
+}
+
+// Say sends a line to everyone in the member's channel, including the member
+func (m *Member) Say(text string) {
+	h := m.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.broadcast(m.channel, nil, Line{Kind: Message, From: m.Name, Text: text})
+}
+
+// Whisper sends a private line to the named member, in any channel
+func (m *Member) Whisper(to, text string) error {
+	h := m.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, members := range h.channels {
+		for other := range members {
+			if strings.EqualFold(other.Name, to) && other != m {
+				other.deliver(Line{Kind: Private, From: m.Name, Text: text})
+				m.deliver(Line{Kind: PrivateEcho, From: other.Name, Text: text})
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s is not in the teleconference", to)
+}
+
+// Switch moves the member to another channel
+func (m *Member) Switch(channel string) {
+	h := m.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if m.channel == channel {
+		return
+	}
+	h.exit(m)
+	h.enter(m, channel)
+}
+
+// Leave removes the member from the teleconference and closes Lines
+func (m *Member) Leave() {
+	h := m.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if m.channel == "" {
+		return
+	}
+	h.exit(m)
+	m.channel = ""
+	close(m.Lines)
+}
+
+// Who lists the members of a channel by name
+func (h *Hub) Who(channel string) []Who {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var who []Who
+	for m := range h.channels[channel] {
+		who = append(who, Who{Name: m.Name, Node: m.Node})
+	}
+	sort.Slice(who, func(i, j int) bool { return who[i].Name < who[j].Name })
+	return who
+}
+
+// Count returns how many members are in a channel
+func (h *Hub) Count(channel string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.channels[channel])
+}
+
+// enter adds m to channel and announces it. The caller holds h.mu.
+func (h *Hub) enter(m *Member, channel string) {
+	if h.channels[channel] == nil {
+		h.channels[channel] = make(map[*Member]bool)
+	}
+	h.broadcast(channel, m, Line{Kind: Notice, Text: fmt.Sprintf("%s (node %d) has joined %s", m.Name, m.Node, channel)})
+	h.channels[channel][m] = true
+	m.channel = channel
+}
+
+// exit removes m from its channel and announces it. The caller holds h.mu.
+func (h *Hub) exit(m *Member) {
+	members := h.channels[m.channel]
+	delete(members, m)
+	if len(members) == 0 {
+		delete(h.channels, m.channel)
+	}
+	h.broadcast(m.channel, m, Line{Kind: Notice, Text: fmt.Sprintf("%s has left %s", m.Name, m.channel)})
+}
+
+// broadcast delivers a line to everyone in channel except skip. The caller
+// holds h.mu.
+func (h *Hub) broadcast(channel string, skip *Member, line Line) {
+	for m := range h.channels[channel] {
+		if m != skip {
+			m.deliver(line)
+		}
+	}
+}
+
+// deliver queues a line without blocking; it is dropped if the member's
+// queue is full
+func (m *Member) deliver(line Line) {
+	select {
+	case m.Lines <- line:
+	default:
+	}
+}
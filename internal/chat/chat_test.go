@@ -0,0 +1,84 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+// drain returns the lines waiting for a member
+func drain(m *Member) []string {
+	var lines []string
+	for {
+		select {
+		case line := <-m.Lines:
+			lines = append(lines, line.String())
+		default:
+			return lines
+		}
+	}
+}
+
+func TestChannelBroadcastAndNotices(t *testing.T) {
+	hub := NewHub()
+	alice := hub.Join("main", "alice", 1)
+	bob := hub.Join("main", "bob", 2)
+
+	if got := drain(alice); len(got) != 1 || !strings.Contains(got[0], "bob (node 2) has joined main") {
+		t.Errorf("alice should see bob join, got %q", got)
+	}
+	if got := drain(bob); len(got) != 0 {
+		t.Errorf("bob shouldn't be told about his own join, got %q", got)
+	}
+
+	bob.Say("hello")
+	for _, m := range []*Member{alice, bob} {
+		if got := drain(m); len(got) != 1 || got[0] != "<bob> hello" {
+			t.Errorf("%s got %q, want bob's line", m.Name, got)
+		}
+	}
+
+	if who := hub.Who("main"); len(who) != 2 || who[0].Name != "alice" || who[1].Name != "bob" {
+		t.Errorf("unexpected who listing: %+v", who)
+	}
+
+	bob.Leave()
+	if got := drain(alice); len(got) != 1 || !strings.Contains(got[0], "bob has left main") {
+		t.Errorf("alice should see bob leave, got %q", got)
+	}
+	if _, ok := <-bob.Lines; ok {
+		t.Error("Lines should be closed after Leave")
+	}
+	bob.Leave() // A second Leave is harmless
+}
+
+func TestSwitchAndWhisper(t *testing.T) {
+	hub := NewHub()
+	alice := hub.Join("main", "alice", 1)
+	bob := hub.Join("main", "bob", 2)
+	drain(alice)
+
+	bob.Switch("games")
+	if got := drain(alice); len(got) != 1 || !strings.Contains(got[0], "bob has left main") {
+		t.Errorf("alice should see bob leave for another channel, got %q", got)
+	}
+	if bob.Channel() != "games" || hub.Count("main") != 1 || hub.Count("games") != 1 {
+		t.Errorf("bob should be alone in games, channel=%q", bob.Channel())
+	}
+
+	alice.Say("anyone here?")
+	if got := drain(bob); len(got) != 0 {
+		t.Errorf("lines shouldn't cross channels, bob got %q", got)
+	}
+
+	if err := alice.Whisper("BOB", "psst"); err != nil {
+		t.Fatalf("Whisper failed: %v", err)
+	}
+	if got := drain(bob); len(got) != 1 || got[0] != "*alice* psst" {
+		t.Errorf("bob got %q, want alice's whisper", got)
+	}
+	drain(alice)
+
+	if err := alice.Whisper("carol", "hi"); err == nil {
+		t.Error("whispering to someone not in chat should fail")
+	}
+}
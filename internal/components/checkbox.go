@@ -0,0 +1,138 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/ansi"
+)
+
+// Checkbox is a yes or no setting, such as whether an account is active,
+// shown as a box that is ticked or not
+type Checkbox struct {
+	name        string
+	label       string
+	checked     bool
+	required    bool
+	focused     bool
+	colorScheme ColorScheme
+	validator   func(bool) error
+	width       int
+}
+
+// CheckboxConfig holds configuration for a checkbox
+type CheckboxConfig struct {
+	Name      string
+	Label     string
+	Checked   bool
+	Required  bool // The box must be ticked, as for agreeing to rules
+	Width     int
+	Validator func(bool) error
+}
+
+// NewCheckbox creates a new checkbox component
+func NewCheckbox(config CheckboxConfig, colorScheme ColorScheme) *Checkbox {
+	if config.Width <= 0 {
+		config.Width = 40
+	}
+
+	return &Checkbox{
+		name:        config.Name,
+		label:       config.Label,
+		checked:     config.Checked,
+		required:    config.Required,
+		colorScheme: colorScheme,
+		validator:   config.Validator,
+		width:       config.Width,
+	}
+}
+
+// SetFocus sets the focus state
+func (c *Checkbox) SetFocus(focused bool) {
+	c.focused = focused
+}
+
+// IsFocused returns the focus state
+func (c *Checkbox) IsFocused() bool {
+	return c.focused
+}
+
+// HandleKey handles keyboard input. Space and the left and right arrows
+// tick or clear the box; Y ticks it and N clears it.
+func (c *Checkbox) HandleKey(key rune) bool {
+	switch key {
+	case ' ', KeyLeft, KeyRight:
+		c.checked = !c.checked
+	case 'y', 'Y':
+		c.checked = true
+	case 'n', 'N':
+		c.checked = false
+	default:
+		return false
+	}
+	return true
+}
+
+// Render renders the checkbox on one line, the box before its label
+func (c *Checkbox) Render() string {
+	mark := " "
+	if c.checked {
+		mark = "X"
+	}
+	labelText := c.label
+	if c.required {
+		labelText += " *"
+	}
+	content := ansi.Truncate("["+mark+"] "+labelText, c.width)
+	content += strings.Repeat(" ", c.width-ansi.Width(content))
+
+	if c.focused {
+		return "\033[44m\033[37m" + content + "\033[0m" // White on blue, as text inputs
+	}
+	return c.colorScheme.Colorize(content, "text")
+}
+
+// GetValue returns whether the box is ticked, as a bool
+func (c *Checkbox) GetValue() interface{} {
+	return c.checked
+}
+
+// SetValue ticks or clears the box for a bool
+func (c *Checkbox) SetValue(value interface{}) {
+	if checked, ok := value.(bool); ok {
+		c.checked = checked
+	}
+}
+
+// Validate validates the checkbox
+func (c *Checkbox) Validate() error {
+	if c.required && !c.checked {
+		return fmt.Errorf("%s must be ticked", c.label)
+	}
+
+	if c.validator != nil {
+		return c.validator(c.checked)
+	}
+
+	return nil
+}
+
+// GetName returns the field name
+func (c *Checkbox) GetName() string {
+	return c.name
+}
+
+// IsRequired returns whether the field is required
+func (c *Checkbox) IsRequired() bool {
+	return c.required
+}
+
+// GetLabel returns the field label
+func (c *Checkbox) GetLabel() string {
+	return c.label
+}
+
+// IsChecked returns whether the box is ticked
+func (c *Checkbox) IsChecked() bool {
+	return c.checked
+}
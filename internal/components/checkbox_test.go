@@ -0,0 +1,48 @@
+package components
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckboxToggle(t *testing.T) {
+	active := NewCheckbox(CheckboxConfig{Name: "active", Label: "Active"}, plainColors{})
+	if active.Render() != "[ ] Active"+strings.Repeat(" ", 40-len("[ ] Active")) {
+		t.Errorf("unticked box renders as %q", active.Render())
+	}
+
+	active.HandleKey(' ')
+	if !active.IsChecked() || active.GetValue() != true {
+		t.Error("Space didn't tick the box")
+	}
+	if got := active.Render(); got[:10] != "[X] Active" {
+		t.Errorf("ticked box renders as %q", got)
+	}
+	active.HandleKey(KeyRight)
+	if active.IsChecked() {
+		t.Error("Right didn't clear the box")
+	}
+	active.HandleKey('y')
+	active.HandleKey('y')
+	if !active.IsChecked() {
+		t.Error("Y should tick the box, not toggle it")
+	}
+	active.HandleKey('n')
+	if active.IsChecked() {
+		t.Error("N didn't clear the box")
+	}
+	if active.HandleKey('x') {
+		t.Error("an unrelated key was taken")
+	}
+}
+
+func TestCheckboxRequired(t *testing.T) {
+	rules := NewCheckbox(CheckboxConfig{Name: "rules", Label: "I agree", Required: true}, plainColors{})
+	if rules.Validate() == nil {
+		t.Error("a required box passed unticked")
+	}
+	rules.HandleKey('y')
+	if err := rules.Validate(); err != nil {
+		t.Errorf("a ticked required box failed: %v", err)
+	}
+}
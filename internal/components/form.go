@@ -3,6 +3,10 @@ package components
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
+
+	"bbs/internal/draw"
+	"bbs/internal/input"
 )
 
 // Form represents a collection of form components
@@ -14,12 +18,17 @@ type Form struct {
 	submitted    bool
 	cancelled    bool
 	width        int
+	instructions string
+	compact      bool
+	errs         map[FormComponent]error // Shown under each field that failed validation
 }
 
 // FormConfig holds configuration for a form
 type FormConfig struct {
-	Title string
-	Width int
+	Title        string
+	Width        int
+	Instructions string // Shown under the form; the default explains moving, Enter and Esc
+	Compact      bool   // Leave out the blank lines between fields, for forms with many
 }
 
 // NewForm creates a new form
@@ -27,6 +36,9 @@ func NewForm(config FormConfig, colorScheme ColorScheme) *Form {
 	if config.Width <= 0 {
 		config.Width = 79
 	}
+	if config.Instructions == "" {
+		config.Instructions = "Tab/↑↓: Move  Enter: Submit  Esc: Cancel"
+	}
 
 	return &Form{
 		title:        config.Title,
@@ -36,6 +48,9 @@ func NewForm(config FormConfig, colorScheme ColorScheme) *Form {
 		submitted:    false,
 		cancelled:    false,
 		width:        config.Width,
+		instructions: config.Instructions,
+		compact:      config.Compact,
+		errs:         make(map[FormComponent]error),
 	}
 }
 
@@ -49,6 +64,7 @@ func (f *Form) AddComponent(component FormComponent) {
 func (f *Form) HandleKey(key rune) bool {
 	switch key {
 	case '\t': // Tab - next field
+		f.validateFocused()
 		f.focusManager.HandleTab()
 		return true
 	case '\r', '\n': // Enter - submit form
@@ -62,6 +78,68 @@ func (f *Form) HandleKey(key rune) bool {
 	}
 }
 
+// ReadKey reads a key press from keyReader and acts on it. Tab and Down
+// move to the next field, Shift+Tab and Up to the one before, Enter submits
+// the form and Escape or Ctrl+C cancels it; anything else goes to the field
+// with focus. Letters are read as typed, so q and g aren't taken as the
+// session's commands.
+func (f *Form) ReadKey(keyReader input.KeyReader) error {
+	key, err := input.TextKeys(keyReader)()
+	if err != nil {
+		return err
+	}
+
+	switch key {
+	case input.Enter:
+		f.HandleKey('\r')
+	case input.Escape, input.CtrlC:
+		f.HandleKey(27)
+	case "\t", input.Down:
+		f.HandleKey('\t')
+	case input.ShiftTab, input.Up:
+		f.FocusPrevious()
+	case input.Left:
+		f.HandleKey(KeyLeft)
+	case input.Right:
+		f.HandleKey(KeyRight)
+	case input.Backspace:
+		f.HandleKey('\b')
+	case input.Quit, input.Goodbye:
+		// A reader without ReadTextKey has turned q or g into a command
+		f.HandleKey(rune(key[0]))
+	default:
+		// Other named keys, such as Home or F1, mean nothing here
+		if r, size := utf8.DecodeRuneInString(key); r != utf8.RuneError && size == len(key) {
+			f.HandleKey(r)
+		}
+	}
+	return nil
+}
+
+// FocusPrevious moves focus back to the previous field, as Shift+Tab or the
+// up arrow do
+func (f *Form) FocusPrevious() {
+	f.validateFocused()
+	f.focusManager.HandleShiftTab()
+}
+
+// validateFocused checks the field being left, so its error shows under it
+// straight away
+func (f *Form) validateFocused() {
+	if component, ok := f.focusManager.GetFocusedComponent().(FormComponent); ok {
+		f.setError(component, component.Validate())
+	}
+}
+
+// setError records or clears the error shown under a field
+func (f *Form) setError(component FormComponent, err error) {
+	if err != nil {
+		f.errs[component] = err
+	} else {
+		delete(f.errs, component)
+	}
+}
+
 // Render renders the entire form
 func (f *Form) Render() string {
 	var result strings.Builder
@@ -71,12 +149,10 @@ func (f *Form) Render() string {
 
 	if f.title != "" {
 		header := f.colorScheme.Colorize(f.title, "primary")
-		centeredHeader := f.colorScheme.CenterText(header, f.width)
-		result.WriteString(centeredHeader + "\n")
-
-		separator := f.colorScheme.DrawSeparator(len(f.title), "═")
-		centeredSeparator := f.colorScheme.CenterText(separator, f.width)
-		result.WriteString(centeredSeparator + "\n\n")
+		for _, row := range f.colorScheme.DrawBox(draw.Box{Lines: []string{header}}) {
+			result.WriteString(f.colorScheme.CenterText(row, f.width) + "\n")
+		}
+		result.WriteString("\n")
 	}
 
 	// Render all components
@@ -92,14 +168,17 @@ func (f *Form) Render() string {
 			result.WriteString("\n")
 		}
 
-		if i < len(f.components)-1 {
+		// A field's error takes the place of the blank line after it
+		if err := f.errs[component]; err != nil {
+			result.WriteString(f.colorScheme.CenterText(f.colorScheme.Colorize(err.Error(), "error"), f.width) + "\n")
+		} else if i < len(f.components)-1 && !f.compact {
 			result.WriteString("\n")
 		}
 	}
 
 	// Show instructions
 	result.WriteString("\n")
-	instructions := f.colorScheme.Colorize("Tab: Next Field  Enter: Submit  Esc: Cancel", "secondary")
+	instructions := f.colorScheme.Colorize(f.instructions, "secondary")
 	centeredInstructions := f.colorScheme.CenterText(instructions, f.width)
 	result.WriteString(centeredInstructions)
 
@@ -123,11 +202,14 @@ func (f *Form) IsCancelled() bool {
 	return f.cancelled
 }
 
-// Validate validates all form components
+// Validate validates all form components. Each error also shows under its
+// field until the field is valid again.
 func (f *Form) Validate() []error {
 	var errors []error
 	for _, component := range f.components {
-		if err := component.Validate(); err != nil {
+		err := component.Validate()
+		f.setError(component, err)
+		if err != nil {
 			errors = append(errors, err)
 		}
 	}
@@ -147,8 +229,8 @@ func (f *Form) GetValues() map[string]interface{} {
 func (f *Form) GetStringValues() map[string]string {
 	values := make(map[string]string)
 	for _, component := range f.components {
-		if textInput, ok := component.(*TextInput); ok {
-			values[component.GetName()] = textInput.GetStringValue()
+		if stringer, ok := component.(interface{ GetStringValue() string }); ok {
+			values[component.GetName()] = stringer.GetStringValue()
 		} else {
 			values[component.GetName()] = fmt.Sprintf("%v", component.GetValue())
 		}
@@ -160,5 +242,6 @@ func (f *Form) GetStringValues() map[string]string {
 func (f *Form) Reset() {
 	f.submitted = false
 	f.cancelled = false
+	f.errs = make(map[FormComponent]error)
 	f.focusManager.SetActive(false)
 }
@@ -0,0 +1,100 @@
+package components
+
+import (
+	"testing"
+
+	"bbs/internal/input"
+)
+
+type fakeKeys struct{ keys []string }
+
+func (k *fakeKeys) ReadKey() (string, error) {
+	key := k.keys[0]
+	k.keys = k.keys[1:]
+	return key, nil
+}
+
+// newTestForm returns a started form with a name, a level and an active box
+func newTestForm() (*Form, *TextInput, *SelectList, *Checkbox) {
+	name := NewTextInput(TextInputConfig{Name: "name", Label: "Name", MaxLength: 20}, plainColors{})
+	level := NewSelectList(SelectListConfig{
+		Name: "level", Label: "Level",
+		Options: []Option{{Label: "User", Value: "10"}, {Label: "Sysop", Value: "255"}},
+		Value:   "10",
+	}, plainColors{})
+	active := NewCheckbox(CheckboxConfig{Name: "active", Label: "Active"}, plainColors{})
+
+	form := NewForm(FormConfig{Title: "Test"}, plainColors{})
+	form.AddComponent(name)
+	form.AddComponent(level)
+	form.AddComponent(active)
+	form.Start()
+	return form, name, level, active
+}
+
+// readKeys feeds keys to form.ReadKey one press at a time
+func readKeys(t *testing.T, form *Form, keys ...string) {
+	t.Helper()
+	reader := &fakeKeys{keys: keys}
+	for len(reader.keys) > 0 {
+		if err := form.ReadKey(reader); err != nil {
+			t.Fatalf("ReadKey failed: %v", err)
+		}
+	}
+}
+
+func TestFormReadKeyMovesBetweenFields(t *testing.T) {
+	form, name, level, active := newTestForm()
+
+	steps := []struct {
+		key  string
+		want Focusable
+	}{
+		{input.Down, level},
+		{"\t", active},
+		{input.Down, name}, // Round from the last field to the first
+		{input.ShiftTab, active},
+		{input.Up, level},
+		{input.ShiftTab, name},
+	}
+	for _, step := range steps {
+		readKeys(t, form, step.key)
+		if !step.want.IsFocused() {
+			t.Fatalf("after %q focus isn't on %s", step.key, step.want.(FormComponent).GetName())
+		}
+	}
+}
+
+func TestFormReadKeyEditsFocusedField(t *testing.T) {
+	form, name, level, active := newTestForm()
+
+	// A reader without ReadTextKey turns g and q into commands; the form
+	// types them as letters
+	readKeys(t, form, input.Goodbye, "r", "e", input.Quit, "x", input.Backspace)
+	readKeys(t, form, input.Down, input.Right)
+	readKeys(t, form, input.Down, " ")
+	readKeys(t, form, input.Enter)
+
+	if name.GetStringValue() != "greq" {
+		t.Errorf("name = %q, want greq", name.GetStringValue())
+	}
+	if level.GetStringValue() != "255" {
+		t.Errorf("level = %q, want 255", level.GetStringValue())
+	}
+	if !active.IsChecked() {
+		t.Error("Space didn't tick Active")
+	}
+	if !form.IsSubmitted() {
+		t.Error("Enter didn't submit the form")
+	}
+}
+
+func TestFormReadKeyCancels(t *testing.T) {
+	for _, key := range []string{input.Escape, input.CtrlC} {
+		form, _, _, _ := newTestForm()
+		readKeys(t, form, key)
+		if !form.IsCancelled() {
+			t.Errorf("%q didn't cancel the form", key)
+		}
+	}
+}
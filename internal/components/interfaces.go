@@ -1,11 +1,14 @@
 package components
 
+import "bbs/internal/draw"
+
 // ColorScheme interface to match your existing pattern
 type ColorScheme interface {
 	Colorize(text, colorName string) string
 	ColorizeWithBg(text, fgColor, bgColor string) string
 	CenterText(text string, terminalWidth int) string
 	DrawSeparator(width int, char string) string
+	DrawBox(box draw.Box) []string
 }
 
 // Focusable represents a component that can receive focus
@@ -0,0 +1,201 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"bbs/internal/ansi"
+)
+
+// Keys forms pass to components for the left and right arrows, which have
+// no character of their own. They are Ctrl+B and Ctrl+F, which move back
+// and forward a character in line editors.
+const (
+	KeyLeft  rune = 0x02
+	KeyRight rune = 0x06
+)
+
+// Option is one choice in a SelectList
+type Option struct {
+	Label string // What the caller sees
+	Value string // What GetValue returns
+}
+
+// SelectList lets the caller pick one of a fixed set of options, such as an
+// access level or a theme, stepping through them with the arrow keys
+type SelectList struct {
+	name        string
+	label       string
+	options     []Option
+	selected    int // -1 while nothing is picked
+	required    bool
+	focused     bool
+	colorScheme ColorScheme
+	validator   func(string) error
+	width       int
+}
+
+// SelectListConfig holds configuration for a select list
+type SelectListConfig struct {
+	Name      string
+	Label     string
+	Options   []Option
+	Value     string // The value of the option picked at first; none if no option has it
+	Required  bool
+	Width     int
+	Validator func(string) error
+}
+
+// NewSelectList creates a new select list component
+func NewSelectList(config SelectListConfig, colorScheme ColorScheme) *SelectList {
+	if config.Width <= 0 {
+		config.Width = 40
+	}
+
+	s := &SelectList{
+		name:        config.Name,
+		label:       config.Label,
+		options:     config.Options,
+		selected:    -1,
+		required:    config.Required,
+		colorScheme: colorScheme,
+		validator:   config.Validator,
+		width:       config.Width,
+	}
+	s.SetValue(config.Value)
+	return s
+}
+
+// SetFocus sets the focus state
+func (s *SelectList) SetFocus(focused bool) {
+	s.focused = focused
+}
+
+// IsFocused returns the focus state
+func (s *SelectList) IsFocused() bool {
+	return s.focused
+}
+
+// HandleKey handles keyboard input. Right and Space pick the next option
+// and Left the one before, going round at either end; a letter or digit
+// picks the next option whose label starts with it.
+func (s *SelectList) HandleKey(key rune) bool {
+	if len(s.options) == 0 {
+		return false
+	}
+	switch key {
+	case KeyRight, ' ':
+		s.selected = (s.selected + 1) % len(s.options)
+		return true
+	case KeyLeft:
+		if s.selected <= 0 {
+			s.selected = len(s.options)
+		}
+		s.selected--
+		return true
+	}
+	if !unicode.IsLetter(key) && !unicode.IsDigit(key) {
+		return false
+	}
+	key = unicode.ToLower(key)
+	for i := 1; i <= len(s.options); i++ {
+		next := (s.selected + i + len(s.options)) % len(s.options)
+		for _, r := range strings.ToLower(s.options[next].Label) {
+			if r == key {
+				s.selected = next
+				return true
+			}
+			break
+		}
+	}
+	return false
+}
+
+// Render renders the select list: the label, then the option picked between
+// arrows that show it can be changed
+func (s *SelectList) Render() string {
+	var result strings.Builder
+
+	if s.label != "" {
+		labelText := s.label
+		if s.required {
+			labelText += " *"
+		}
+		result.WriteString(s.colorScheme.Colorize(labelText+":", "text"))
+		result.WriteString("\n")
+	}
+
+	choice := ""
+	if s.selected >= 0 {
+		choice = s.options[s.selected].Label
+	}
+	inner := max(s.width-4, 1)
+	choice = ansi.Truncate(choice, inner)
+	content := "◄ " + choice + strings.Repeat(" ", inner-ansi.Width(choice)) + " ►"
+
+	if s.focused {
+		result.WriteString("\033[44m\033[37m") // White on blue, as text inputs
+		result.WriteString(content)
+		result.WriteString("\033[0m")
+	} else {
+		result.WriteString(s.colorScheme.Colorize(content, "text"))
+	}
+
+	return result.String()
+}
+
+// GetValue returns the value of the option picked, or "" if none is
+func (s *SelectList) GetValue() interface{} {
+	return s.GetStringValue()
+}
+
+// SetValue picks the option with value, a string
+func (s *SelectList) SetValue(value interface{}) {
+	str, ok := value.(string)
+	if !ok {
+		return
+	}
+	for i, option := range s.options {
+		if option.Value == str {
+			s.selected = i
+			return
+		}
+	}
+}
+
+// Validate validates the option picked
+func (s *SelectList) Validate() error {
+	if s.required && s.selected < 0 {
+		return fmt.Errorf("%s is required", s.label)
+	}
+
+	if s.validator != nil {
+		return s.validator(s.GetStringValue())
+	}
+
+	return nil
+}
+
+// GetName returns the field name
+func (s *SelectList) GetName() string {
+	return s.name
+}
+
+// IsRequired returns whether the field is required
+func (s *SelectList) IsRequired() bool {
+	return s.required
+}
+
+// GetLabel returns the field label
+func (s *SelectList) GetLabel() string {
+	return s.label
+}
+
+// GetStringValue returns the value of the option picked, or "" if none is
+func (s *SelectList) GetStringValue() string {
+	if s.selected < 0 {
+		return ""
+	}
+	return s.options[s.selected].Value
+}
@@ -0,0 +1,58 @@
+package components
+
+import "testing"
+
+func TestSelectListChoose(t *testing.T) {
+	levels := NewSelectList(SelectListConfig{
+		Name:  "level",
+		Label: "Access Level",
+		Options: []Option{
+			{Label: "New (0)", Value: "0"},
+			{Label: "User (10)", Value: "10"},
+			{Label: "Trusted (50)", Value: "50"},
+			{Label: "Sysop (255)", Value: "255"},
+		},
+		Value: "10",
+	}, plainColors{})
+
+	steps := []struct {
+		key  rune
+		want string
+	}{
+		{KeyRight, "50"},
+		{' ', "255"},
+		{KeyRight, "0"}, // Round from the last to the first
+		{KeyLeft, "255"},
+		{'t', "50"},
+		{'U', "10"},
+		{'s', "255"},
+	}
+	for _, step := range steps {
+		if !levels.HandleKey(step.key) {
+			t.Errorf("key %q wasn't taken", step.key)
+		}
+		if got := levels.GetStringValue(); got != step.want {
+			t.Errorf("after %q the value is %q, want %q", step.key, got, step.want)
+		}
+	}
+	if levels.HandleKey('z') {
+		t.Error("a letter no option starts with was taken")
+	}
+	if values := (&Form{components: []FormComponent{levels}}).GetStringValues(); values["level"] != "255" {
+		t.Errorf("the form reads the level as %q", values["level"])
+	}
+}
+
+func TestSelectListRequired(t *testing.T) {
+	theme := NewSelectList(SelectListConfig{
+		Name: "theme", Label: "Theme", Required: true,
+		Options: []Option{{Label: "Classic", Value: "classic"}},
+	}, plainColors{})
+	if theme.GetStringValue() != "" || theme.Validate() == nil {
+		t.Error("a required list passed with nothing picked")
+	}
+	theme.HandleKey(' ')
+	if err := theme.Validate(); err != nil || theme.GetStringValue() != "classic" {
+		t.Errorf("after picking, value %q and Validate() = %v", theme.GetStringValue(), err)
+	}
+}
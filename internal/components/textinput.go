@@ -4,34 +4,10 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
-)
+	"unicode/utf8"
 
-// stripAnsiCodes removes ANSI escape codes from text for length calculation
-func stripAnsiCodes(text string) string {
-	// More robust ANSI stripping using a simple state machine
-	result := ""
-	i := 0
-
-	for i < len(text) {
-		if i <= len(text)-4 && text[i] == '\033' && text[i+1] == '[' {
-			// Found ESC[, skip until we find a letter (usually 'm')
-			i += 2 // skip \033[
-			for i < len(text) {
-				char := text[i]
-				i++
-				// ANSI sequences typically end with a letter
-				if (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z') {
-					break
-				}
-			}
-		} else {
-			result += string(text[i])
-			i++
-		}
-	}
-
-	return result
-}
+	"bbs/internal/ansi"
+)
 
 // TextInput represents a text input field
 type TextInput struct {
@@ -45,6 +21,7 @@ type TextInput struct {
 	colorScheme ColorScheme
 	validator   func(string) error
 	width       int
+	mask        bool
 }
 
 // TextInputConfig holds configuration for text input
@@ -57,6 +34,7 @@ type TextInputConfig struct {
 	Required    bool
 	Width       int
 	Validator   func(string) error
+	Mask        bool // Show the value as asterisks, as for passwords
 }
 
 // NewTextInput creates a new text input component
@@ -79,6 +57,7 @@ func NewTextInput(config TextInputConfig, colorScheme ColorScheme) *TextInput {
 		colorScheme: colorScheme,
 		validator:   config.Validator,
 		width:       config.Width,
+		mask:        config.Mask,
 	}
 }
 
@@ -97,7 +76,8 @@ func (t *TextInput) HandleKey(key rune) bool {
 	switch key {
 	case '\b', 127: // Backspace
 		if len(t.value) > 0 {
-			t.value = t.value[:len(t.value)-1]
+			_, size := utf8.DecodeLastRuneInString(t.value)
+			t.value = t.value[:len(t.value)-size]
 		}
 		return true
 	case '\r', '\n': // Enter
@@ -105,7 +85,7 @@ func (t *TextInput) HandleKey(key rune) bool {
 	case '\t': // Tab
 		return false // Let focus manager handle this
 	default:
-		if unicode.IsPrint(key) && len(t.value) < t.maxLength {
+		if unicode.IsPrint(key) && utf8.RuneCountInString(t.value) < t.maxLength {
 			t.value += string(key)
 			return true
 		}
@@ -129,21 +109,23 @@ func (t *TextInput) Render() string {
 
 	// Create the input content
 	displayContent := t.value
+	if t.mask {
+		displayContent = strings.Repeat("*", utf8.RuneCountInString(t.value))
+	}
 	showPlaceholder := false
 	if displayContent == "" && t.placeholder != "" {
 		displayContent = t.placeholder
 		showPlaceholder = true
 	}
 
-	// Pad content to full width
-	if len(displayContent) > t.width {
-		displayContent = displayContent[:t.width]
-	}
+	// Pad content to full width, measured in columns
+	displayContent = ansi.Truncate(displayContent, t.width)
+	padding := strings.Repeat(" ", t.width-ansi.Width(displayContent))
 
 	// Create the input field with blue background when focused
 	if t.focused {
 		// Focused state - blue background with cursor
-		paddedContent := displayContent + strings.Repeat(" ", t.width-len(displayContent))
+		paddedContent := displayContent + padding
 		if showPlaceholder {
 			// Blue background with placeholder text in lighter color
 			result.WriteString("\033[44m") // Blue background
@@ -151,13 +133,13 @@ func (t *TextInput) Render() string {
 			result.WriteString("\033[0m") // Reset
 		} else {
 			// Blue background with white text and cursor
-			cursorPos := len(t.value)
+			cursorPos := ansi.Width(displayContent)
 			if cursorPos >= t.width {
 				cursorPos = t.width - 1
 			}
 
-			beforeCursor := displayContent[:cursorPos]
-			afterCursor := strings.Repeat(" ", t.width-cursorPos)
+			beforeCursor := ansi.Truncate(displayContent, cursorPos)
+			afterCursor := strings.Repeat(" ", t.width-ansi.Width(beforeCursor))
 
 			result.WriteString("\033[44m") // Blue background
 			result.WriteString("\033[37m") // White text
@@ -170,7 +152,7 @@ func (t *TextInput) Render() string {
 		}
 	} else {
 		// Unfocused state - no background, just content
-		paddedContent := displayContent + strings.Repeat(" ", t.width-len(displayContent))
+		paddedContent := displayContent + padding
 		if showPlaceholder {
 			result.WriteString(t.colorScheme.Colorize(paddedContent, "secondary"))
 		} else {
@@ -189,7 +171,7 @@ func (t *TextInput) GetValue() interface{} {
 // SetValue sets the current value
 func (t *TextInput) SetValue(value interface{}) {
 	if str, ok := value.(string); ok {
-		if len(str) <= t.maxLength {
+		if utf8.RuneCountInString(str) <= t.maxLength {
 			t.value = str
 		}
 	}
@@ -227,3 +209,14 @@ func (t *TextInput) GetLabel() string {
 func (t *TextInput) GetStringValue() string {
 	return t.value
 }
+
+// Matches returns a validator for a field that repeats other's value, such
+// as one confirming a new password
+func Matches(other *TextInput) func(string) error {
+	return func(value string) error {
+		if value != other.value {
+			return fmt.Errorf("%s does not match", strings.ToLower(other.label))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"bbs/internal/draw"
+)
+
+type plainColors struct{}
+
+func (plainColors) Colorize(text, colorName string) string              { return text }
+func (plainColors) ColorizeWithBg(text, fgColor, bgColor string) string { return text }
+func (plainColors) CenterText(text string, terminalWidth int) string    { return text }
+func (plainColors) DrawSeparator(width int, char string) string         { return strings.Repeat(char, width) }
+func (plainColors) DrawBox(box draw.Box) []string                       { return box.Lines }
+
+// typeInto sends each character of s to a component
+func typeInto(c interface{ HandleKey(rune) bool }, s string) {
+	for _, r := range s {
+		c.HandleKey(r)
+	}
+}
+
+func TestMatchesRejectsMismatch(t *testing.T) {
+	password := NewTextInput(TextInputConfig{Name: "password", Label: "Password", Mask: true}, plainColors{})
+	confirm := NewTextInput(TextInputConfig{
+		Name: "confirm", Label: "Confirm Password", Mask: true,
+		Validator: Matches(password),
+	}, plainColors{})
+
+	typeInto(password, "hunter2")
+	typeInto(confirm, "hunter3")
+	err := confirm.Validate()
+	if err == nil || err.Error() != "password does not match" {
+		t.Errorf("mismatched confirmation: Validate() = %v, want \"password does not match\"", err)
+	}
+
+	confirm.HandleKey('\b')
+	confirm.HandleKey('2')
+	if err := confirm.Validate(); err != nil {
+		t.Errorf("matching confirmation: Validate() = %v", err)
+	}
+}
+
+func TestMaskedEcho(t *testing.T) {
+	input := NewTextInput(TextInputConfig{Name: "password", Label: "Password", Width: 10, Mask: true}, plainColors{})
+	typeInto(input, "pässwd")
+
+	rendered := input.Render()
+	if strings.Contains(rendered, "pässwd") {
+		t.Errorf("masked field shows its value: %q", rendered)
+	}
+	if !strings.Contains(rendered, "******    ") {
+		t.Errorf("masked field should show one * a character: %q", rendered)
+	}
+	if input.GetStringValue() != "pässwd" {
+		t.Errorf("GetStringValue() = %q, want the value typed", input.GetStringValue())
+	}
+}
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 
 	"gopkg.in/yaml.v2"
+
+	"bbs/internal/paths"
 )
 
 // MenuOption represents a generic menu option from config
@@ -26,37 +31,468 @@ type Config struct {
 	Database DatabaseConfig        `yaml:"database"`
 	BBS      BBSConfig             `yaml:"bbs"`
 	Modules  map[string]MenuConfig `yaml:",inline"`
+
+	// Paths is the resolved on-disk layout; relative paths in the config are
+	// interpreted against it. It is set at startup and never saved.
+	Paths paths.Layout `yaml:"-"`
+
+	// File is the config file this was loaded from, where Save writes
+	// changes made while the server runs
+	File string `yaml:"-"`
+
+	// MenuFiles are the menu fragments merged into BBS.Menus, from the
+	// menus directory beside File or named by includes
+	MenuFiles []string `yaml:"-"`
 }
 
 type ServerConfig struct {
-	Port        int    `yaml:"port"`
-	HostKeyPath string `yaml:"host_key_path"`
-	MaxUsers    int    `yaml:"max_users"`
+	Port        int               `yaml:"port"`
+	HostKeyPath string            `yaml:"host_key_path"`
+	MaxUsers    int               `yaml:"max_users"`  // Most callers online at once; sysops can always log in (0 for no limit)
+	MaxPerIP    int               `yaml:"max_per_ip"` // Most connections open from one address (0 for no limit)
+	API         APIConfig         `yaml:"api"`
+	Web         WebConfig         `yaml:"web"`
+	Gopher      GopherConfig      `yaml:"gopher"`
+	Feeds       FeedsConfig       `yaml:"feeds"`
+	MailGateway MailGatewayConfig `yaml:"mail_gateway"`
+	SSH         SSHPolicy         `yaml:"ssh"`
+	Tarpit      TarpitConfig      `yaml:"tarpit"`
+	Logins      LoginConfig       `yaml:"logins"`
+	Terminal    TerminalConfig    `yaml:"terminal"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Boards      []BoardConfig     `yaml:"boards"`
+}
+
+// MetricsConfig tunes the per-command timings sysops see under Command
+// Timings and from the API
+type MetricsConfig struct {
+	SlowMillis int `yaml:"slow_ms"` // Answering a key this slowly counts as slow
+}
+
+// TerminalConfig smooths over SSH clients that send two bytes for Enter,
+// echo what the caller types themselves, or draw with CP437
+type TerminalConfig struct {
+	Echo         string `yaml:"echo"`          // "auto" (detect per client), "server" or "client"
+	JoinNewlines bool   `yaml:"join_newlines"` // Read CR LF, LF CR and CR NUL as one Enter
+	Encoding     string `yaml:"encoding"`      // "auto" (from the client's TERM), "ask", "utf-8" or "cp437"
+}
+
+// BoardConfig is another board served by the same process. It has its own
+// data directory, with its own config.yaml, database, art and file areas.
+type BoardConfig struct {
+	Name string `yaml:"name"` // Callers on any port reach the board by logging in as "name/username"
+	Dir  string `yaml:"dir"`  // Data directory, relative to the main one
+	Port int    `yaml:"port"` // Callers connecting to this port get the board; 0 for none
+}
+
+// TarpitConfig slows down addresses that keep disconnecting before logging
+// in, which is what internet-wide SSH scanners do
+type TarpitConfig struct {
+	Enabled            bool `yaml:"enabled"`
+	Strikes            int  `yaml:"strikes"`              // Aborted connections that trap an address
+	WindowMinutes      int  `yaml:"window_minutes"`       // Period the strikes must fall within
+	BannerDelaySeconds int  `yaml:"banner_delay_seconds"` // Wait before sending the SSH banner to a trapped address
+	AuthDelaySeconds   int  `yaml:"auth_delay_seconds"`   // Wait before answering each login attempt
+}
+
+// LoginConfig slows down repeated failed logins for a username or from an
+// address, and bans addresses that keep failing
+type LoginConfig struct {
+	Throttle         bool `yaml:"throttle"`
+	BaseDelaySeconds int  `yaml:"base_delay_seconds"` // Wait after the first failure; doubles after each one
+	MaxDelaySeconds  int  `yaml:"max_delay_seconds"`  // Longest wait before a login attempt is answered
+	WindowMinutes    int  `yaml:"window_minutes"`     // Failures are forgotten after this long without another
+	BanAfter         int  `yaml:"ban_after"`          // Failures from one address that get it banned (0 never bans)
+	BanMinutes       int  `yaml:"ban_minutes"`        // How long a ban lasts
+}
+
+// SSHPolicy restricts the algorithms the SSH server negotiates. Empty lists
+// keep the library defaults; clients that share nothing with the lists are
+// refused during the handshake.
+type SSHPolicy struct {
+	Ciphers      []string `yaml:"ciphers"`
+	KeyExchanges []string `yaml:"key_exchanges"`
+	MACs         []string `yaml:"macs"`
+	MinRSABits   int      `yaml:"min_rsa_bits"` // Smallest RSA host key accepted at startup (0 allows any)
+	DisableSHA1  bool     `yaml:"disable_sha1"` // Drop SHA-1 key exchanges, MACs and ssh-rsa host signatures
+}
+
+// APIConfig controls the optional HTTP/JSON API listener
+type APIConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"` // Address to bind, e.g. "127.0.0.1:8080"
+}
+
+// WebConfig controls the optional browser gateway, which serves a terminal
+// page and carries its WebSocket into an ordinary session
+type WebConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"` // Address to bind, e.g. "127.0.0.1:8081"
+}
+
+// GopherConfig serves the board's public bulletins and topics, read-only,
+// to Gopher clients
+type GopherConfig struct {
+	Enabled   bool     `yaml:"enabled"`
+	Listen    string   `yaml:"listen"`    // Address to bind, e.g. "0.0.0.0:70"
+	Hostname  string   `yaml:"hostname"`  // Host menus send clients back to (default: the host in listen)
+	Topics    []string `yaml:"topics"`    // Topics to serve; only ones open to every caller are served
+	Bulletins bool     `yaml:"bulletins"` // Serve the current bulletins
+}
+
+// FeedsConfig serves Atom and RSS feeds of new bulletins and of new posts in
+// chosen topics, so people can follow the board without logging in
+type FeedsConfig struct {
+	Enabled   bool     `yaml:"enabled"`
+	Listen    string   `yaml:"listen"`    // Address to bind, e.g. "127.0.0.1:8082"
+	BaseURL   string   `yaml:"base_url"`  // Public address of the feeds, e.g. "https://bbs.example.org/feeds" (default: from each request)
+	Topics    []string `yaml:"topics"`    // Topics with a feed; only ones open to every caller get one
+	Bulletins bool     `yaml:"bulletins"` // Offer a feed of the current bulletins
+	MaxItems  int      `yaml:"max_items"` // Newest entries in each feed (default 50)
+}
+
+// MailGatewayConfig accepts email on a small SMTP listener and delivers
+// what is addressed to username+key@domain as private mail from the member
+// whose mail key is key
+type MailGatewayConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"` // Address to bind, e.g. "127.0.0.1:2525"
+	Domain  string `yaml:"domain"` // Mail for username+key@domain is delivered, e.g. "bbs.example.org"
+	MaxKB   int    `yaml:"max_kb"` // Largest message accepted (default 256)
 }
 
 type DatabaseConfig struct {
-	Path string `yaml:"path"`
+	Path     string `yaml:"path"`
+	ReadOnly bool   `yaml:"read_only"` // Serve a replicated database as a read-only mirror
 }
 
 type BBSConfig struct {
-	SystemName    string      `yaml:"system_name"`
-	SysopName     string      `yaml:"sysop_name"`
-	WelcomeMsg    string      `yaml:"welcome_message"`
-	MaxLineLength int         `yaml:"max_line_length"`
-	Colors        ColorConfig `yaml:"colors"`
-	Menus         []MenuItem  `yaml:"menus"`
+	SystemName          string                 `yaml:"system_name"`
+	SysopName           string                 `yaml:"sysop_name"`
+	Language            string                 `yaml:"language"` // Catalog callers see the board in until they choose their own
+	WelcomeMsg          string                 `yaml:"welcome_message"`
+	Art                 ArtConfig              `yaml:"art"` // ANSI art shown instead of the built-in screens
+	MaxLineLength       int                    `yaml:"max_line_length"`
+	AnnounceLogins      bool                   `yaml:"announce_logins"`        // Tell online callers when someone logs on or off
+	InvisibleLoginLevel int                    `yaml:"invisible_login_level"`  // Minimum access level offered invisible login (0 disables)
+	AwayMinutes         int                    `yaml:"away_minutes"`           // Idle minutes before a caller shows as away (0 disables)
+	IdleMinutes         int                    `yaml:"idle_minutes"`           // Idle minutes before a caller is logged off (0 disables)
+	Exempt              ExemptConfig           `yaml:"exempt"`                 // Long-running activities the cutoffs leave alone
+	PasswordMaxAgeDays  int                    `yaml:"password_max_age_days"`  // Days before callers must pick a new password (0 disables)
+	TutorialOnFirstCall bool                   `yaml:"tutorial_on_first_call"` // Offer the keyboard tour to callers on their first call
+	BulletinsAtLogin    bool                   `yaml:"bulletins_at_login"`     // Show the bulletins each caller hasn't seen yet at login
+	NewScanAtLogin      bool                   `yaml:"new_scan_at_login"`      // Offer to read new bulletins and posts at login
+	UnreadKey           string                 `yaml:"unread_key"`             // Key that jumps to the next unread mail, bulletin or post; callers can change it
+	Registration        RegistrationConfig     `yaml:"registration"`
+	Feedback            FeedbackConfig         `yaml:"feedback"`
+	Taglines            TaglineConfig          `yaml:"taglines"`
+	Expiry              ExpiryConfig           `yaml:"expiry"`
+	Events              []EventConfig          `yaml:"events"` // Daily windows when callers are logged off
+	Jobs                []JobConfig            `yaml:"jobs"`   // Maintenance jobs run on cron-like schedules
+	Files               FilesConfig            `yaml:"files"`
+	Doors               []DoorConfig           `yaml:"doors"`         // External door programs listed under Games
+	ChatChannels        []ChatChannel          `yaml:"chat_channels"` // Teleconference channels; the first is joined on entry
+	Page                PageConfig             `yaml:"page"`
+	Bells               BellConfig             `yaml:"bells"`
+	Notify              NotifyConfig           `yaml:"notify"`
+	Time                TimeConfig             `yaml:"time"`
+	Retention           RetentionConfig        `yaml:"retention"`
+	Disk                DiskConfig             `yaml:"disk"`
+	Moderators          []TopicModerators      `yaml:"moderators"` // Callers who moderate each topic, besides the sysops
+	Publish             PublishConfig          `yaml:"publish"`
+	FTN                 FTNConfig              `yaml:"ftn"` // FidoNet-style echomail exchanged through a mailer
+	Colors              ColorConfig            `yaml:"colors"`
+	Themes              map[string]ColorConfig `yaml:"themes,omitempty"` // Other color schemes callers can pick for themselves
+	CommandAliases      map[string]string      `yaml:"command_aliases"`  // Other names callers can use for menu commands
+	Menus               []MenuItem             `yaml:"menus"`
+}
+
+// ArtConfig names .ans files in the art directory to show in place of the
+// built-in welcome banner and goodbye message. Menus name their own art.
+type ArtConfig struct {
+	Welcome string `yaml:"welcome"` // Shown as callers connect, before login
+	Logoff  string `yaml:"logoff"`  // Shown as callers hang up
+}
+
+// RegistrationConfig controls new callers applying for an account
+type RegistrationConfig struct {
+	Enabled            bool          `yaml:"enabled"`              // Allow logging in as "new" to register
+	DefaultAccessLevel int           `yaml:"default_access_level"` // Access level given to new accounts
+	RequireApproval    bool          `yaml:"require_approval"`     // Queue new accounts for sysop approval
+	Webhook            WebhookConfig `yaml:"webhook"`
+}
+
+// WebhookConfig names an external service that approves or denies new
+// accounts, such as a check against a club membership list
+type WebhookConfig struct {
+	URL     string `yaml:"url"`     // Applications are POSTed here; empty disables the check
+	Secret  string `yaml:"secret"`  // Signs each request with HMAC-SHA256 when set
+	Timeout int    `yaml:"timeout"` // Seconds to wait before leaving the account for the sysop
+}
+
+// FeedbackConfig controls the note callers can leave the sysop without an
+// account, such as when registration doesn't work for them
+type FeedbackConfig struct {
+	Enabled         bool `yaml:"enabled"`          // Allow logging in as "feedback" to leave a note
+	MaxLines        int  `yaml:"max_lines"`        // Longest note, in lines of up to 72 characters
+	IntervalMinutes int  `yaml:"interval_minutes"` // Minimum time between notes from one address
+	MaxPerHour      int  `yaml:"max_per_hour"`     // Notes accepted per hour from everyone together
+}
+
+// TaglineConfig sets where the board shows a random tagline. Callers send
+// taglines in and the sysop approves them before they are shown.
+type TaglineConfig struct {
+	Goodbye   bool `yaml:"goodbye"`    // Show one under the goodbye message
+	Posts     bool `yaml:"posts"`      // Add one to the foot of new posts and replies
+	MaxLength int  `yaml:"max_length"` // Longest tagline callers can send in
+}
+
+// ExpiryConfig sets what callers whose account has an expiry date are told
+type ExpiryConfig struct {
+	Message  string `yaml:"message"`   // Shown to callers whose account has expired, before they are logged off
+	WarnDays int    `yaml:"warn_days"` // Days before expiry that callers are warned at login (0 never warns)
+}
+
+// EventConfig is a daily event, such as nightly maintenance, during which
+// callers are warned, logged off, and kept out until it ends
+type EventConfig struct {
+	Name     string `yaml:"name"`
+	Time     string `yaml:"time"`     // Start time of day, "HH:MM" in server local time
+	Duration int    `yaml:"duration"` // Minutes the board stays closed
+	Warning  int    `yaml:"warning"`  // Minutes of warning before logoff (default 10)
+}
+
+// JobConfig is a maintenance job the scheduler runs, such as compacting
+// the database or posting a bulletin every week
+type JobConfig struct {
+	Name       string `yaml:"name"`
+	Schedule   string `yaml:"schedule"`              // "minute hour day month weekday" as in crontab, or @hourly, @daily, @weekly, @monthly
+	Task       string `yaml:"task"`                  // vacuum, expire_bulletins, stats_rollup or bulletin
+	Title      string `yaml:"title,omitempty"`       // bulletin: the bulletin's title
+	Body       string `yaml:"body,omitempty"`        // bulletin: its text
+	ExpireDays int    `yaml:"expire_days,omitempty"` // bulletin: days until it expires (0 never)
+}
+
+// FilesConfig controls the file areas and download ratios
+type FilesConfig struct {
+	Ratio          int `yaml:"ratio"`            // Bytes a caller may download per byte uploaded (0 disables)
+	FreeDownloadKB int `yaml:"free_download_kb"` // Downloads allowed before the ratio applies
+	MaxUploadKB    int `yaml:"max_upload_kb"`    // Largest upload accepted (0 for no limit)
+	MaxMemberKB    int `yaml:"max_member_kb"`    // Largest file taken out of a zip on its own (default 2048)
+	ExemptLevel    int `yaml:"exempt_level"`     // Access level not held to the ratio (0 exempts nobody)
+
+	ReviewAreas []string `yaml:"review_areas"` // Areas whose uploads wait for approval before they are listed
+	ReviewLevel int      `yaml:"review_level"` // Lowest access level that reviews uploads, for co-sysops (default 255)
+
+	AttachKB      int `yaml:"attach_kb"`       // Largest file attached to private mail (0 turns attachments off)
+	AttachQuotaKB int `yaml:"attach_quota_kb"` // Attachments one caller may have waiting in others' mailboxes
+
+	Mirror MirrorConfig `yaml:"mirror"` // Areas kept in step with files elsewhere
+}
+
+// MirrorConfig fills file areas from HTTP and FTP sources. Mirrored areas
+// take no uploads.
+type MirrorConfig struct {
+	IntervalMinutes int            `yaml:"interval_minutes"` // How often the server checks the sources (0 only when run by hand)
+	Bulletins       bool           `yaml:"bulletins"`        // Post a bulletin listing the files each run changed
+	MaxMB           int            `yaml:"max_mb"`           // Largest file fetched (default 1024)
+	Sources         []MirrorSource `yaml:"sources"`
+}
+
+// MirrorSource is where one area's files come from: a checksum list such as
+// SHA256SUMS with the files beside it, or files listed one by one
+type MirrorSource struct {
+	Area     string       `yaml:"area"`
+	Manifest string       `yaml:"manifest"` // URL of a sha256sum-style list
+	Files    []MirrorFile `yaml:"files"`
+}
+
+// MirrorFile is one file to mirror and the checksum it must match
+type MirrorFile struct {
+	URL    string `yaml:"url"`
+	Name   string `yaml:"name"` // Name in the area (default: the last part of the URL)
+	SHA256 string `yaml:"sha256"`
+}
+
+// DoorConfig is an external door program. Command and Args may use the
+// {node}, {user}, {dropdir}, {doorsys}, {dorinfo} and {timeleft} placeholders.
+type DoorConfig struct {
+	ID                string   `yaml:"id"`
+	Name              string   `yaml:"name"`
+	Description       string   `yaml:"description"`
+	Command           string   `yaml:"command"`
+	Args              []string `yaml:"args"`
+	Dir               string   `yaml:"dir"`                // Working directory, relative to the data directory
+	AccessLevel       int      `yaml:"access_level"`       // Minimum access level to run the door
+	TimeLimit         int      `yaml:"time_limit"`         // Minutes before the door is stopped (default 60)
+	TranslateNewlines bool     `yaml:"translate_newlines"` // Unix door: LF out becomes CR LF, Enter is sent as LF
+}
+
+// ChatChannel is a teleconference channel
+type ChatChannel struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	AccessLevel int    `yaml:"access_level"` // Minimum access level to join
+}
+
+// PageConfig controls how callers page the sysop. Sysops online are always
+// told; the bell and command reach a sysop at the server console.
+type PageConfig struct {
+	Enabled         bool     `yaml:"enabled"`
+	Bell            bool     `yaml:"bell"`             // Ring the terminal bell on the server console
+	Command         string   `yaml:"command"`          // Run on each page, e.g. a desktop notifier
+	Args            []string `yaml:"args"`             // May use {user}, {node} and {reason}
+	IntervalMinutes int      `yaml:"interval_minutes"` // Minimum time between pages from one caller
+}
+
+// ExemptConfig sets which cutoffs leave file transfers and doors running.
+// A caller whose time runs out during an exempt activity is logged off as
+// soon as it ends. Scheduled events still log everyone off.
+type ExemptConfig struct {
+	Transfers Exemption `yaml:"transfers"`
+	Doors     Exemption `yaml:"doors"`
+}
+
+// Exemption is the cutoffs one activity is exempt from
+type Exemption struct {
+	Idle      bool `yaml:"idle"`       // Not logged off for being idle; keys typed in a door don't count as input
+	TimeLimit bool `yaml:"time_limit"` // Not logged off when the day's time runs out
+}
+
+// BellConfig sets when the board rings a caller's terminal bell. Callers can
+// change these for themselves, or turn bells off altogether.
+type BellConfig struct {
+	Messages bool `yaml:"messages"` // Instant messages and teleconference whispers
+	Pages    bool `yaml:"pages"`    // Pages reaching a sysop, and a sysop breaking in to chat
+	Errors   bool `yaml:"errors"`   // Error messages
+}
+
+// NotifyConfig sets how callers hear about new mail, replies to their
+// posts, instant messages and announcements: "board" shows a one-line
+// message while they are online, "email" saves it for the daily digest and
+// "off" drops it. Callers can choose for themselves under User Settings.
+type NotifyConfig struct {
+	Mail       string       `yaml:"mail"`       // Private mail sent to them
+	Replies    string       `yaml:"replies"`    // Replies to their posts and replies
+	Messages   string       `yaml:"messages"`   // Instant messages from other callers
+	Broadcasts string       `yaml:"broadcasts"` // Login announcements, scheduled event warnings and sysop broadcasts
+	Digest     DigestConfig `yaml:"digest"`
+}
+
+// DigestConfig sets when and how the daily email digest is sent. Without a
+// mail server, email isn't offered and is treated as "board".
+type DigestConfig struct {
+	Time     string `yaml:"time"`     // When the digest is sent, "HH:MM" server local time
+	SMTP     string `yaml:"smtp"`     // Mail server as host:port; empty turns email off
+	Username string `yaml:"username"` // Signs in to the mail server when set
+	Password string `yaml:"password"`
+	From     string `yaml:"from"` // Sender address of the digest
+}
+
+// TimeConfig sets how long callers may stay on each day and how much unused
+// time they may save in the time bank
+type TimeConfig struct {
+	Limits  []TimeLimit `yaml:"limits"`   // Daily limits by access level; none means no limit
+	BankMax int         `yaml:"bank_max"` // Most minutes a caller may keep in the time bank (0 disables it)
+}
+
+// TimeLimit is the daily time allowed from an access level up to the next
+// listed level
+type TimeLimit struct {
+	AccessLevel int `yaml:"access_level"`
+	Minutes     int `yaml:"minutes"` // 0 for no limit
+}
+
+// DailyMinutes returns the daily limit for an access level: the entry with
+// the highest level at or below it. 0 means no limit.
+func (t TimeConfig) DailyMinutes(accessLevel int) int {
+	best := -1
+	minutes := 0
+	for _, limit := range t.Limits {
+		if limit.AccessLevel <= accessLevel && limit.AccessLevel > best {
+			best = limit.AccessLevel
+			minutes = limit.Minutes
+		}
+	}
+	return minutes
+}
+
+// RetentionConfig controls the nightly job that deletes closed accounts and
+// old records
+type RetentionConfig struct {
+	Time              string           `yaml:"time"`                // When the nightly job runs, "HH:MM" server local time
+	DeletionGraceDays int              `yaml:"deletion_grace_days"` // Days before a closed account is deleted; the caller can cancel until then
+	CallerLogDays     int              `yaml:"caller_log_days"`     // Days to keep the caller log (0 keeps it)
+	ReadMailDays      int              `yaml:"read_mail_days"`      // Days to keep private mail that has been read (0 keeps it)
+	Topics            []TopicRetention `yaml:"topics,omitempty"`
+}
+
+// TopicRetention moves a topic's older posts, with their replies, to its
+// archive, where they can still be read and searched but not replied to.
+// A post stays while it is one of the newest KeepPosts or its thread has
+// been active in the last KeepDays; 0 leaves out that test.
+type TopicRetention struct {
+	Topic     string `yaml:"topic"`      // Topic name
+	KeepPosts int    `yaml:"keep_posts"` // Newest posts kept in the topic
+	KeepDays  int    `yaml:"keep_days"`  // Days since a thread's last post or reply
+}
+
+// DiskConfig sets the sizes at which the sysop's disk usage screen warns.
+// 0 turns a warning off.
+type DiskConfig struct {
+	FilesMB    int `yaml:"files_mb"`    // All file areas together
+	LogsMB     int `yaml:"logs_mb"`     // The logs directory
+	DatabaseMB int `yaml:"database_mb"` // The database and its write-ahead log
+	MinFreeMB  int `yaml:"min_free_mb"` // Free space left on the data directory's volume
+}
+
+// TopicModerators names the callers who moderate a topic: they can edit and
+// delete its posts and replies, pin posts and lock it against new posts
+type TopicModerators struct {
+	Topic string   `yaml:"topic"` // Topic name
+	Users []string `yaml:"users"` // Usernames
+}
+
+// PublishConfig selects what goes into the static HTML archive of the board
+type PublishConfig struct {
+	Dir             string   `yaml:"dir"`              // Output directory, relative to the data directory
+	Topics          []string `yaml:"topics"`           // Public topics to publish, by name
+	Bulletins       bool     `yaml:"bulletins"`        // Publish the current bulletins
+	MaxPosts        int      `yaml:"max_posts"`        // Newest posts published per topic (0 for all)
+	IntervalMinutes int      `yaml:"interval_minutes"` // How often the server brings the archive up to date (0 disables)
+}
+
+// FTNConfig links topics to FidoNet-style echomail areas. Packets are
+// tossed from the inbound directory and scanned into the outbound one,
+// where a mailer such as binkd carries them to and from the uplink.
+type FTNConfig struct {
+	Address  string    `yaml:"address"`  // This board's address, such as 1:234/5
+	Uplink   string    `yaml:"uplink"`   // Hub that outbound packets are addressed to
+	Password string    `yaml:"password"` // Packet password agreed with the uplink, 8 characters at most
+	Inbound  string    `yaml:"inbound"`  // Where the mailer leaves packets, relative to the data directory
+	Outbound string    `yaml:"outbound"` // Where packets for the mailer are written, relative to the data directory
+	Origin   string    `yaml:"origin"`   // Origin line text (default: the system name)
+	Author   string    `yaml:"author"`   // Disabled account tossed messages are credited to
+	Areas    []FTNArea `yaml:"areas"`
+}
+
+// FTNArea links one echo to a topic
+type FTNArea struct {
+	Tag   string `yaml:"tag"`   // Echo tag, such as FIDO_SYSOPS
+	Topic string `yaml:"topic"` // Topic name
 }
 
 type ColorConfig struct {
-	Primary    string `yaml:"primary"`    // Main color (default: cyan)
-	Secondary  string `yaml:"secondary"`  // Secondary color (default: red)
-	Accent     string `yaml:"accent"`     // Accent color (default: yellow)
-	Text       string `yaml:"text"`       // Normal text (default: white)
-	Background string `yaml:"background"` // Background (default: black)
-	Border     string `yaml:"border"`     // Borders and frames (default: blue)
-	Success    string `yaml:"success"`    // Success messages (default: green)
-	Error      string `yaml:"error"`      // Error messages (default: red)
-	Highlight  string `yaml:"highlight"`  // Highlighted text (default: bright_white)
+	Primary    string `yaml:"primary,omitempty"`    // Main color (default: cyan)
+	Secondary  string `yaml:"secondary,omitempty"`  // Secondary color (default: red)
+	Accent     string `yaml:"accent,omitempty"`     // Accent color (default: yellow)
+	Text       string `yaml:"text,omitempty"`       // Normal text (default: white)
+	Background string `yaml:"background,omitempty"` // Background (default: black)
+	Border     string `yaml:"border,omitempty"`     // Borders and frames (default: blue)
+	Success    string `yaml:"success,omitempty"`    // Success messages (default: green)
+	Error      string `yaml:"error,omitempty"`      // Error messages (default: red)
+	Highlight  string `yaml:"highlight,omitempty"`  // Highlighted text (default: bright_white)
+	Borders    string `yaml:"borders,omitempty"`    // Box and rule style: double, single or ascii (default: double)
 }
 
 type MenuItem struct {
@@ -66,7 +502,9 @@ type MenuItem struct {
 	Command     string     `yaml:"command"`
 	AccessLevel int        `yaml:"access_level"`
 	Hotkey      string     `yaml:"hotkey,omitempty"`
+	Art         string     `yaml:"art,omitempty"` // .ans file shown in place of the list of items
 	Submenu     []MenuItem `yaml:"submenu,omitempty"`
+	Include     string     `yaml:"include,omitempty"` // Menu file whose items replace this one, from the menus directory
 }
 
 func Load(filename string) (*Config, error) {
@@ -76,15 +514,150 @@ func Load(filename string) (*Config, error) {
 			Port:        2323,
 			HostKeyPath: "host_key",
 			MaxUsers:    100,
+			MaxPerIP:    3,
+			API: APIConfig{
+				Enabled: false,
+				Listen:  "127.0.0.1:8080",
+			},
+			Web: WebConfig{
+				Enabled: false,
+				Listen:  "127.0.0.1:8081",
+			},
+			Gopher: GopherConfig{
+				Enabled:   false,
+				Listen:    "127.0.0.1:7070",
+				Bulletins: true,
+			},
+			Feeds: FeedsConfig{
+				Enabled:   false,
+				Listen:    "127.0.0.1:8082",
+				Bulletins: true,
+				MaxItems:  50,
+			},
+			MailGateway: MailGatewayConfig{
+				Enabled: false,
+				Listen:  "127.0.0.1:2525",
+				MaxKB:   256,
+			},
+			SSH: SSHPolicy{
+				MinRSABits: 2048,
+			},
+			Tarpit: TarpitConfig{
+				Enabled:            true,
+				Strikes:            5,
+				WindowMinutes:      10,
+				BannerDelaySeconds: 10,
+				AuthDelaySeconds:   5,
+			},
+			Logins: LoginConfig{
+				Throttle:         true,
+				BaseDelaySeconds: 1,
+				MaxDelaySeconds:  30,
+				WindowMinutes:    15,
+				BanAfter:         10,
+				BanMinutes:       60,
+			},
+			Terminal: TerminalConfig{
+				Echo:         "auto",
+				JoinNewlines: true,
+				Encoding:     "auto",
+			},
+			Metrics: MetricsConfig{
+				SlowMillis: 500,
+			},
 		},
 		Database: DatabaseConfig{
 			Path: "bbs.db",
 		},
 		BBS: BBSConfig{
-			SystemName:    "Coastline BBS",
-			SysopName:     "Sysop",
-			WelcomeMsg:    "Welcome to Coastline BBS!",
-			MaxLineLength: 79,
+			SystemName:          "Coastline BBS",
+			SysopName:           "Sysop",
+			Language:            "en",
+			WelcomeMsg:          "Welcome to Coastline BBS!",
+			MaxLineLength:       79,
+			AnnounceLogins:      true,
+			InvisibleLoginLevel: 255,
+			AwayMinutes:         5,
+			IdleMinutes:         20,
+			Exempt: ExemptConfig{
+				Transfers: Exemption{Idle: true, TimeLimit: true},
+				Doors:     Exemption{Idle: true},
+			},
+			TutorialOnFirstCall: true,
+			BulletinsAtLogin:    true,
+			NewScanAtLogin:      true,
+			UnreadKey:           "ctrl+n",
+			Registration: RegistrationConfig{
+				Enabled:            true,
+				DefaultAccessLevel: 10,
+				RequireApproval:    false,
+				Webhook: WebhookConfig{
+					Timeout: 10,
+				},
+			},
+			Feedback: FeedbackConfig{
+				Enabled:         true,
+				MaxLines:        5,
+				IntervalMinutes: 60,
+				MaxPerHour:      10,
+			},
+			Taglines: TaglineConfig{
+				Goodbye:   true,
+				MaxLength: 70,
+			},
+			Expiry: ExpiryConfig{
+				Message:  "Your account has expired. Please contact the sysop to renew it.",
+				WarnDays: 7,
+			},
+			Files: FilesConfig{
+				Ratio:          0,
+				FreeDownloadKB: 1024,
+				MaxUploadKB:    10240,
+				MaxMemberKB:    2048,
+				ExemptLevel:    255,
+				ReviewLevel:    255,
+				AttachKB:       1024,
+				AttachQuotaKB:  5120,
+			},
+			ChatChannels: []ChatChannel{
+				{Name: "main", Description: "General chat"},
+			},
+			Page: PageConfig{
+				Enabled:         true,
+				Bell:            true,
+				IntervalMinutes: 5,
+			},
+			Bells: BellConfig{
+				Messages: true,
+				Pages:    true,
+			},
+			Notify: NotifyConfig{
+				Mail:       "board",
+				Replies:    "board",
+				Messages:   "board",
+				Broadcasts: "board",
+				Digest: DigestConfig{
+					Time: "07:00",
+				},
+			},
+			Retention: RetentionConfig{
+				Time:              "04:00",
+				DeletionGraceDays: 7,
+				CallerLogDays:     365,
+			},
+			Disk: DiskConfig{
+				LogsMB:    100,
+				MinFreeMB: 500,
+			},
+			Publish: PublishConfig{
+				Dir:      "public",
+				MaxPosts: 200,
+			},
+			FTN: FTNConfig{
+				Inbound:  "ftn/inbound",
+				Outbound: "ftn/outbound",
+				Author:   "echomail",
+			},
 			Colors: ColorConfig{
 				Primary:    "cyan",
 				Secondary:  "red",
@@ -98,6 +671,7 @@ func Load(filename string) (*Config, error) {
 			},
 		},
 		Modules: make(map[string]MenuConfig),
+		File:    filename,
 	}
 
 	// Try to load config file if it exists
@@ -112,15 +686,81 @@ func Load(filename string) (*Config, error) {
 		}
 	}
 
+	if err := config.loadMenus(filepath.Join(filepath.Dir(filename), MenuDir)); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
+// DatabasePath returns the database file location resolved against the data directory
+func (c *Config) DatabasePath() string {
+	return c.Paths.DataPath(c.Database.Path)
+}
+
+// HostKeyPath returns the SSH host key location resolved against the data directory
+func (c *Config) HostKeyPath() string {
+	return c.Paths.DataPath(c.Server.HostKeyPath)
+}
+
+// LoadFromLayout loads the configuration for a data directory layout. If
+// filename is empty the layout's config.yaml is used.
+func LoadFromLayout(layout paths.Layout, filename string) (*Config, error) {
+	if filename == "" {
+		filename = layout.ConfigFile()
+	}
+
+	cfg, err := Load(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.ApplyEnv(); err != nil {
+		return nil, err
+	}
+
+	cfg.Paths = layout
+	return cfg, nil
+}
+
+// ApplyEnv overrides configuration values from BBS_* environment variables so
+// the server can be configured without editing YAML (e.g. under Docker).
+func (c *Config) ApplyEnv() error {
+	if port := os.Getenv("BBS_PORT"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("invalid BBS_PORT %q", port)
+		}
+		c.Server.Port = p
+	}
+	if path := os.Getenv("BBS_DB_PATH"); path != "" {
+		c.Database.Path = path
+	}
+	if path := os.Getenv("BBS_HOST_KEY_PATH"); path != "" {
+		c.Server.HostKeyPath = path
+	}
+	if name := os.Getenv("BBS_SYSTEM_NAME"); name != "" {
+		c.BBS.SystemName = name
+	}
+	if name := os.Getenv("BBS_SYSOP_NAME"); name != "" {
+		c.BBS.SysopName = name
+	}
+	return nil
+}
+
+// Save writes the configuration to filename. An existing file keeps its
+// comments and layout; only the settings that changed are rewritten.
 func (c *Config) Save(filename string) error {
 	data, err := yaml.Marshal(c)
 	if err != nil {
 		return err
 	}
 
+	if existing, err := os.ReadFile(filename); err == nil {
+		if data, err = mergeYAML(existing, data); err != nil {
+			return fmt.Errorf("failed to update %s: %w", filename, err)
+		}
+	}
 	return os.WriteFile(filename, data, 0644)
 }
 
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// MenuDir is the directory beside config.yaml that holds menu fragments
+const MenuDir = "menus"
+
+// menuLoader reads the menu fragments under one directory
+type menuLoader struct {
+	dir    string
+	parsed map[string][]MenuItem // Fragments read so far, by path
+	files  []string              // Fragments in the order they were first read
+}
+
+// loadMenus expands the include items in the menus and appends the
+// fragments in dir that nothing includes, in file name order. A fragment
+// is a YAML list of menu items; an item written as just `include: file`
+// is replaced by the items in that file, looked up in dir.
+func (c *Config) loadMenus(dir string) error {
+	l := &menuLoader{dir: dir, parsed: make(map[string][]MenuItem)}
+
+	fragments, err := fragmentFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	// Fragments included from anywhere are only shown where they're included
+	included := make(map[string]bool)
+	l.includes(c.BBS.Menus, included)
+	for _, path := range fragments {
+		items, err := l.read(path)
+		if err != nil {
+			return err
+		}
+		l.includes(items, included)
+	}
+
+	menus, err := l.expand(c.BBS.Menus, nil)
+	if err != nil {
+		return err
+	}
+	for _, path := range fragments {
+		// Included fragments are expanded too, so a loop of fragments that
+		// only include each other is reported rather than dropped
+		items, err := l.expand(l.parsed[path], []string{path})
+		if err != nil {
+			return err
+		}
+		if !included[path] {
+			menus = append(menus, items...)
+		}
+	}
+
+	c.BBS.Menus = menus
+	c.MenuFiles = l.files
+	return nil
+}
+
+// fragmentFiles lists the .yaml and .yml files in dir, which may not exist
+func fragmentFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// path resolves an include against the fragment directory
+func (l *menuLoader) path(include string) string {
+	if filepath.IsAbs(include) {
+		return filepath.Clean(include)
+	}
+	return filepath.Join(l.dir, include)
+}
+
+// read parses a fragment, once. Unknown keys are errors, so a misspelt
+// setting doesn't silently vanish.
+func (l *menuLoader) read(path string) ([]MenuItem, error) {
+	if items, ok := l.parsed[path]; ok {
+		return items, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read menu file: %w", err)
+	}
+	var items []MenuItem
+	if err := yaml.UnmarshalStrict(data, &items); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	l.parsed[path] = items
+	l.files = append(l.files, path)
+	return items, nil
+}
+
+// includes adds the fragments items include, at any depth, to seen
+func (l *menuLoader) includes(items []MenuItem, seen map[string]bool) {
+	for _, item := range items {
+		if item.Include != "" {
+			seen[l.path(item.Include)] = true
+		}
+		l.includes(item.Submenu, seen)
+	}
+}
+
+// expand replaces include items with the items of the fragment they name.
+// stack holds the fragments being expanded, to catch one including itself.
+func (l *menuLoader) expand(items []MenuItem, stack []string) ([]MenuItem, error) {
+	var out []MenuItem
+	for _, item := range items {
+		if item.Include == "" {
+			sub, err := l.expand(item.Submenu, stack)
+			if err != nil {
+				return nil, err
+			}
+			item.Submenu = sub
+			out = append(out, item)
+			continue
+		}
+
+		if item.ID != "" || item.Title != "" || item.Command != "" || len(item.Submenu) > 0 {
+			return nil, fmt.Errorf("menu include %q can't have other settings", item.Include)
+		}
+		path := l.path(item.Include)
+		for _, p := range stack {
+			if p == path {
+				return nil, fmt.Errorf("menu file %s includes itself: %s", path, strings.Join(append(stack, path), " -> "))
+			}
+		}
+		fragment, err := l.read(path)
+		if err != nil {
+			return nil, err
+		}
+		expanded, err := l.expand(fragment, append(stack, path))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, expanded...)
+	}
+	return out, nil
+}
+
+// CheckMenus returns the mistakes in the menus that would confuse callers:
+// a missing main menu, menus or items that share an ID, items that share
+// a hotkey, and items with no title or nothing to do
+func (c *Config) CheckMenus() []string {
+	var problems []string
+	hasMain := false
+	ids := make(map[string]bool)
+	for _, m := range c.BBS.Menus {
+		if m.ID == "main" {
+			hasMain = true
+		}
+		if ids[m.ID] {
+			problems = append(problems, fmt.Sprintf("menu %q is defined more than once", m.ID))
+		}
+		ids[m.ID] = true
+	}
+	if !hasMain {
+		problems = append(problems, `there is no "main" menu`)
+	}
+	for _, m := range c.BBS.Menus {
+		problems = append(problems, checkItems(m.ID, m.Submenu)...)
+	}
+	return problems
+}
+
+// checkItems checks the items on one menu and the menus under them
+func checkItems(menu string, items []MenuItem) []string {
+	var problems []string
+	ids := make(map[string]bool)
+	hotkeys := make(map[string]string)
+	for _, item := range items {
+		name := item.ID
+		if name == "" {
+			name = item.Title
+		}
+		switch {
+		case item.ID == "":
+			problems = append(problems, fmt.Sprintf("menu %q: item %q has no id", menu, item.Title))
+		case ids[item.ID]:
+			problems = append(problems, fmt.Sprintf("menu %q: item id %q is used twice", menu, item.ID))
+		}
+		ids[item.ID] = true
+		if item.Title == "" {
+			problems = append(problems, fmt.Sprintf("menu %q: item %q has no title", menu, name))
+		}
+		if item.Command == "" && len(item.Submenu) == 0 {
+			problems = append(problems, fmt.Sprintf("menu %q: item %q has no command", menu, name))
+		}
+		if key := strings.ToLower(item.Hotkey); key != "" {
+			if other, ok := hotkeys[key]; ok {
+				problems = append(problems, fmt.Sprintf("menu %q: items %q and %q share hotkey %q", menu, other, name, key))
+			} else {
+				hotkeys[key] = name
+			}
+		}
+		problems = append(problems, checkItems(menu+" > "+name, item.Submenu)...)
+	}
+	return problems
+}
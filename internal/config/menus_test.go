@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes name: contents pairs under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// menuIDs lists the IDs of items, with submenus in brackets
+func menuIDs(items []MenuItem) string {
+	var ids []string
+	for _, item := range items {
+		id := item.ID
+		if len(item.Submenu) > 0 {
+			id += "[" + menuIDs(item.Submenu) + "]"
+		}
+		ids = append(ids, id)
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestLoadMenuFragments(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+bbs:
+    menus:
+        - id: "main"
+          title: "Main"
+          command: "main"
+          submenu:
+              - include: "main_items.yaml"
+              - id: "goodbye"
+                title: "Goodbye"
+                command: "goodbye"
+`,
+		"menus/main_items.yaml": `
+- id: "bulletins"
+  title: "Bulletins"
+  command: "bulletins"
+- include: "games.yaml"
+`,
+		"menus/games.yaml": `
+- id: "games"
+  title: "Games"
+  command: "games"
+`,
+		"menus/sysop.yaml": `
+- id: "sysop_menu"
+  title: "Sysop"
+  command: "sysop_menu"
+  submenu:
+      - id: "view_users"
+        title: "Users"
+        command: "view_users"
+`,
+		"menus/notes.txt": "not a menu",
+	})
+
+	cfg, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := menuIDs(cfg.BBS.Menus), "main[bulletins games goodbye] sysop_menu[view_users]"; got != want {
+		t.Errorf("menus = %s, want %s", got, want)
+	}
+	want := []string{
+		filepath.Join(dir, "menus", "games.yaml"),
+		filepath.Join(dir, "menus", "main_items.yaml"),
+		filepath.Join(dir, "menus", "sysop.yaml"),
+	}
+	if !reflect.DeepEqual(cfg.MenuFiles, want) {
+		t.Errorf("MenuFiles = %v, want %v", cfg.MenuFiles, want)
+	}
+	if problems := cfg.CheckMenus(); len(problems) != 0 {
+		t.Errorf("CheckMenus() = %v", problems)
+	}
+}
+
+func TestLoadMenuFragmentErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"cycle", map[string]string{
+			"menus/a.yaml": `- include: "b.yaml"`,
+			"menus/b.yaml": `- include: "a.yaml"`,
+		}, "includes itself"},
+		{"missing", map[string]string{
+			"menus/a.yaml": `- include: "gone.yaml"`,
+		}, "failed to read menu file"},
+		{"unknown key", map[string]string{
+			"menus/a.yaml": "- id: x\n  titel: X\n",
+		}, "titel"},
+		{"include with settings", map[string]string{
+			"menus/a.yaml": "- include: b.yaml\n  id: x\n",
+			"menus/b.yaml": "[]",
+		}, "can't have other settings"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		writeFiles(t, dir, tt.files)
+		_, err := Load(filepath.Join(dir, "config.yaml"))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want one mentioning %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCheckMenus(t *testing.T) {
+	cfg := &Config{}
+	cfg.BBS.Menus = []MenuItem{
+		{ID: "games", Title: "Games", Command: "games"},
+		{ID: "games", Title: "More Games", Submenu: []MenuItem{
+			{ID: "tw", Title: "Trade Wars", Command: "door", Hotkey: "t"},
+			{ID: "lord", Title: "LORD", Command: "door", Hotkey: "T"},
+			{ID: "tw", Command: "door"},
+			{Title: "Empty"},
+		}},
+	}
+	want := []string{
+		`menu "games" is defined more than once`,
+		`there is no "main" menu`,
+		`menu "games": items "tw" and "lord" share hotkey "t"`,
+		`menu "games": item id "tw" is used twice`,
+		`menu "games": item "tw" has no title`,
+		`menu "games": item "Empty" has no id`,
+		`menu "games": item "Empty" has no command`,
+	}
+	if got := cfg.CheckMenus(); !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckMenus() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
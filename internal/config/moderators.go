@@ -0,0 +1,19 @@
+package config
+
+import "strings"
+
+// Moderates reports whether username moderates the named topic. Names are
+// matched in any case.
+func (b *BBSConfig) Moderates(username, topic string) bool {
+	for _, m := range b.Moderators {
+		if !strings.EqualFold(m.Topic, topic) {
+			continue
+		}
+		for _, user := range m.Users {
+			if strings.EqualFold(user, username) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestModerates(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+bbs:
+    moderators:
+        - {topic: "General", users: ["alice", "Bob"]}
+        - {topic: "Tech Talk", users: ["carol"]}
+`,
+	})
+	cfg, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		user, topic string
+		want        bool
+	}{
+		{"alice", "General", true},
+		{"bob", "general", true},
+		{"alice", "Tech Talk", false},
+		{"carol", "Tech Talk", true},
+		{"dave", "General", false},
+		{"carol", "Games", false},
+	}
+	for _, tt := range tests {
+		if got := cfg.BBS.Moderates(tt.user, tt.topic); got != tt.want {
+			t.Errorf("Moderates(%q, %q) = %v, want %v", tt.user, tt.topic, got, tt.want)
+		}
+	}
+}
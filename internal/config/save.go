@@ -0,0 +1,212 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// mergeYAML rewrites the YAML document existing so it holds the settings in
+// updated, changing only the values that differ. Comments, quoting, blank
+// lines and the order of keys in existing are kept for everything else.
+func mergeYAML(existing, updated []byte) ([]byte, error) {
+	var doc, next yamlv3.Node
+	if err := yamlv3.Unmarshal(existing, &doc); err != nil {
+		return nil, err
+	}
+	if err := yamlv3.Unmarshal(updated, &next); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yamlv3.DocumentNode || len(doc.Content) != 1 || next.Kind != yamlv3.DocumentNode || len(next.Content) != 1 {
+		return nil, fmt.Errorf("config is not a single YAML document")
+	}
+	doc.Content[0] = mergeNode(doc.Content[0], next.Content[0])
+
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(4)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return restoreBlankLines(existing, indentSequences(buf.Bytes())), nil
+}
+
+// mergeNode returns old changed to match next, reusing old's nodes wherever
+// they already say the same thing
+func mergeNode(old, next *yamlv3.Node) *yamlv3.Node {
+	switch {
+	case old.Kind == yamlv3.MappingNode && next.Kind == yamlv3.MappingNode:
+		// Keys stay in the file's order, with new ones at the end
+		merged := make([]*yamlv3.Node, 0, len(next.Content))
+		for i := 0; i+1 < len(old.Content); i += 2 {
+			if j := mappingIndex(next, old.Content[i].Value); j >= 0 {
+				merged = append(merged, old.Content[i], mergeNode(old.Content[i+1], next.Content[j+1]))
+			}
+		}
+		for i := 0; i+1 < len(next.Content); i += 2 {
+			if mappingIndex(old, next.Content[i].Value) < 0 {
+				quoteStrings(next.Content[i+1])
+				merged = append(merged, next.Content[i], next.Content[i+1])
+			}
+		}
+		old.Content = merged
+		return old
+
+	case old.Kind == yamlv3.SequenceNode && next.Kind == yamlv3.SequenceNode:
+		merged := make([]*yamlv3.Node, len(next.Content))
+		for i, item := range next.Content {
+			if i < len(old.Content) {
+				merged[i] = mergeNode(old.Content[i], item)
+				continue
+			}
+			quoteStrings(item)
+			merged[i] = item
+		}
+		old.Content = merged
+		return old
+
+	case old.Kind == yamlv3.ScalarNode && next.Kind == yamlv3.ScalarNode:
+		if old.Value == next.Value && old.ShortTag() == next.ShortTag() {
+			return old
+		}
+		if next.ShortTag() == "!!str" {
+			next.Style = old.Style
+		}
+	default:
+		quoteStrings(next)
+	}
+	next.HeadComment, next.LineComment, next.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	return next
+}
+
+// mappingIndex returns the position of key in a mapping node, or -1
+func mappingIndex(mapping *yamlv3.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// quoteStrings double-quotes the string values in a new part of the
+// document, as the rest of config.yaml does
+func quoteStrings(n *yamlv3.Node) {
+	switch n.Kind {
+	case yamlv3.ScalarNode:
+		if n.ShortTag() == "!!str" {
+			n.Style = yamlv3.DoubleQuotedStyle
+		}
+	case yamlv3.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			quoteStrings(n.Content[i])
+		}
+	case yamlv3.SequenceNode:
+		for _, item := range n.Content {
+			quoteStrings(item)
+		}
+	}
+}
+
+// indentSequences moves lists that sit inside another list's items out to
+// the same four-space indent as every other list. The encoder indents them
+// by two.
+func indentSequences(data []byte) []byte {
+	type block struct{ key, shift int }
+	var open []block
+	lines := strings.Split(string(data), "\n")
+	for n, line := range lines {
+		text := strings.TrimLeft(line, " ")
+		indent := len(line) - len(text)
+		if text == "" {
+			continue
+		}
+		for len(open) > 0 && indent <= open[len(open)-1].key {
+			open = open[:len(open)-1]
+		}
+		shift := 0
+		for _, b := range open {
+			shift += b.shift
+		}
+		lines[n] = strings.Repeat(" ", shift) + line
+
+		// A key with nothing after it, whose list starts two columns in
+		key := indent
+		if strings.HasPrefix(text, "- ") {
+			key += 2
+			text = text[2:]
+		}
+		if !strings.HasSuffix(strings.SplitN(text, " #", 2)[0], ":") {
+			continue
+		}
+		for _, next := range lines[n+1:] {
+			item := strings.TrimLeft(next, " ")
+			if item == "" || strings.HasPrefix(item, "#") {
+				continue
+			}
+			if strings.HasPrefix(item, "- ") && len(next)-len(item) == key+2 {
+				open = append(open, block{key, 2})
+			}
+			break
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// restoreBlankLines puts back the blank lines the encoder drops, before
+// each line of updated that is unchanged from existing
+func restoreBlankLines(existing, updated []byte) []byte {
+	var old []string
+	blanks := map[int]int{} // Blank lines before each line of old
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(line) == "" {
+			blanks[len(old)]++
+			continue
+		}
+		old = append(old, line)
+	}
+	lines := strings.Split(strings.TrimRight(string(updated), "\n"), "\n")
+
+	// Longest common subsequence, so repeated lines such as
+	// access_level: 0 match the right copy
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(lines)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(lines) - 1; j >= 0; j-- {
+			if old[i] == lines[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	for i, j := 0, 0; j < len(lines); {
+		switch {
+		case i < len(old) && old[i] == lines[j]:
+			for k := len(out) - 1; k >= 0 && out[k] == ""; k-- {
+				blanks[i]-- // Kept in a block scalar
+			}
+			for k := 0; k < blanks[i]; k++ {
+				out = append(out, "")
+			}
+			out = append(out, lines[j])
+			i++
+			j++
+		case i < len(old) && common[i+1][j] >= common[i][j+1]:
+			i++
+		default:
+			out = append(out, lines[j])
+			j++
+		}
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
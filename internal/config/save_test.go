@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveKeepsLayout(t *testing.T) {
+	original, err := os.ReadFile("../../config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := os.ReadFile(file)
+	if string(saved) != string(original) {
+		t.Fatalf("saving an unchanged config rewrote it:\n%s", saved)
+	}
+
+	main := &cfg.BBS.Menus[0]
+	main.Submenu[0].Title = "News"
+	main.Submenu = append(main.Submenu, MenuItem{ID: "lounge", Title: "Lounge", Command: "chat", Hotkey: "l"})
+	if err := cfg.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := reloaded.BBS.Menus[0].Submenu
+	if items[0].Title != "News" || items[len(items)-1].ID != "lounge" || items[len(items)-1].Hotkey != "l" {
+		t.Errorf("menu changes weren't saved: %+v", items)
+	}
+	if reloaded.BBS.SystemName != cfg.BBS.SystemName || len(reloaded.BBS.Menus) != len(cfg.BBS.Menus) {
+		t.Errorf("saving menus changed other settings")
+	}
+}
+
+func TestIndentSequences(t *testing.T) {
+	in := "menus:\n    - id: main\n      submenu:\n        - id: a\n          submenu:\n            - id: b\n      title: Main\n"
+	want := "menus:\n    - id: main\n      submenu:\n          - id: a\n            submenu:\n                - id: b\n      title: Main\n"
+	if got := string(indentSequences([]byte(in))); got != want {
+		t.Errorf("indentSequences() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import "sort"
+
+// ThemeNames lists the themes callers can pick, in name order
+func (b *BBSConfig) ThemeNames() []string {
+	names := make([]string, 0, len(b.Themes))
+	for name := range b.Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Theme returns the colors and border style of the named theme, with any it leaves out
+// taken from the board's own colors. It reports false for a theme that
+// doesn't exist.
+func (b *BBSConfig) Theme(name string) (ColorConfig, bool) {
+	theme, ok := b.Themes[name]
+	if !ok {
+		return b.Colors, false
+	}
+	colors := b.Colors
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&colors.Primary, theme.Primary},
+		{&colors.Secondary, theme.Secondary},
+		{&colors.Accent, theme.Accent},
+		{&colors.Text, theme.Text},
+		{&colors.Background, theme.Background},
+		{&colors.Border, theme.Border},
+		{&colors.Success, theme.Success},
+		{&colors.Error, theme.Error},
+		{&colors.Highlight, theme.Highlight},
+		{&colors.Borders, theme.Borders},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+	return colors, true
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestThemes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+bbs:
+    colors:
+        primary: "cyan"
+        text: "white"
+        borders: "single"
+    themes:
+        mono:
+            primary: "white"
+            accent: "bright_white"
+            borders: "ascii"
+        amber:
+            text: "yellow"
+`,
+	})
+	cfg, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := cfg.BBS.ThemeNames(), []string{"amber", "mono"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ThemeNames() = %v, want %v", got, want)
+	}
+
+	mono, ok := cfg.BBS.Theme("mono")
+	if !ok {
+		t.Fatal(`Theme("mono") not found`)
+	}
+	// Colors the theme leaves out come from the board's own
+	if mono.Primary != "white" || mono.Accent != "bright_white" || mono.Text != "white" || mono.Error != cfg.BBS.Colors.Error || mono.Borders != "ascii" {
+		t.Errorf(`Theme("mono") = %+v`, mono)
+	}
+
+	if amber, _ := cfg.BBS.Theme("amber"); amber.Borders != "single" {
+		t.Errorf(`Theme("amber") borders = %q, want the board's "single"`, amber.Borders)
+	}
+
+	if colors, ok := cfg.BBS.Theme("neon"); ok || colors != cfg.BBS.Colors {
+		t.Errorf(`Theme("neon") = %+v, %v; want the board's colors, false`, colors, ok)
+	}
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StartCall records a caller logging on to a node and returns the log
+// entry's ID for EndCall
+func (db *DB) StartCall(username string, node int) (int64, error) {
+	query := `INSERT INTO caller_log (username, node, login_at) VALUES (?, ?, ?)`
+	result, err := db.conn.Exec(query, username, node, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to record call: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// EndCall records when a call ended
+func (db *DB) EndCall(id int64) error {
+	query := `UPDATE caller_log SET logout_at = ? WHERE id = ?`
+	if _, err := db.conn.Exec(query, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to record end of call: %w", err)
+	}
+	return nil
+}
+
+// ActionInvisible marks a call made with invisible login, which is left out
+// of the caller lists other callers see
+const ActionInvisible = "invisible"
+
+// RecordCallAction notes something the caller did during a call, such as a
+// menu command they ran. Each action is kept once per call.
+func (db *DB) RecordCallAction(callID int64, action string) error {
+	query := `INSERT OR IGNORE INTO call_actions (call_id, action) VALUES (?, ?)`
+	if _, err := db.conn.Exec(query, callID, action); err != nil {
+		return fmt.Errorf("failed to record call action: %w", err)
+	}
+	return nil
+}
+
+// Call is one entry in the caller log
+type Call struct {
+	ID       int64
+	Username string
+	Node     int
+	LoginAt  time.Time
+	LogoutAt *time.Time // Nil while the call is in progress, or if the server stopped during it
+	Actions  []string   // In the order they were first done
+}
+
+// Invisible reports whether the call was made with invisible login
+func (c Call) Invisible() bool {
+	for _, action := range c.Actions {
+		if action == ActionInvisible {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRecentCalls returns the most recent calls, newest first. Invisible
+// calls are left out unless includeInvisible is set.
+func (db *DB) GetRecentCalls(limit int, includeInvisible bool) ([]Call, error) {
+	query := `SELECT c.id, c.username, c.node, c.login_at, c.logout_at,
+			  COALESCE((SELECT GROUP_CONCAT(action, ',') FROM
+			  (SELECT action FROM call_actions WHERE call_id = c.id ORDER BY rowid)), '')
+			  FROM caller_log c`
+	var args []interface{}
+	if !includeInvisible {
+		query += ` WHERE NOT EXISTS (SELECT 1 FROM call_actions a WHERE a.call_id = c.id AND a.action = ?)`
+		args = append(args, ActionInvisible)
+	}
+	query += ` ORDER BY c.login_at DESC, c.id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent calls: %w", err)
+	}
+	defer rows.Close()
+
+	var calls []Call
+	for rows.Next() {
+		var call Call
+		var actions string
+		if err := rows.Scan(&call.ID, &call.Username, &call.Node, &call.LoginAt, &call.LogoutAt, &actions); err != nil {
+			return nil, err
+		}
+		if actions != "" {
+			call.Actions = strings.Split(actions, ",")
+		}
+		calls = append(calls, call)
+	}
+	return calls, rows.Err()
+}
+
+// DayCount is a number of things that happened on one day
+type DayCount struct {
+	Day   time.Time // Local midnight
+	Count int
+}
+
+// ActivityStats summarizes recent board usage for the activity graphs
+type ActivityStats struct {
+	CallsByHour [24]int    // Calls by local hour of day
+	PostsByDay  []DayCount // Posts and replies per day, oldest first
+}
+
+// GetActivityStats counts calls by hour of day and posts per day over the
+// given number of days up to and including today
+func (db *DB) GetActivityStats(now time.Time, days int) (*ActivityStats, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	since := today.AddDate(0, 0, -(days - 1))
+
+	stats := &ActivityStats{}
+	for day := 0; day < days; day++ {
+		stats.PostsByDay = append(stats.PostsByDay, DayCount{Day: since.AddDate(0, 0, day)})
+	}
+
+	calls, err := db.timesSince(`SELECT login_at FROM caller_log WHERE login_at >= ?`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count calls: %w", err)
+	}
+	for _, t := range calls {
+		stats.CallsByHour[t.In(now.Location()).Hour()]++
+	}
+
+	posts, err := db.timesSince(`SELECT created_at FROM posts WHERE created_at >= ?
+			  UNION ALL SELECT created_at FROM replies WHERE created_at >= ?`, since, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count posts: %w", err)
+	}
+	for _, t := range posts {
+		t = t.In(now.Location())
+		day := int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location()).Sub(since).Hours()+12) / 24
+		if day >= 0 && day < days {
+			stats.PostsByDay[day].Count++
+		}
+	}
+
+	return stats, nil
+}
+
+// BoardTotals counts what the board holds
+type BoardTotals struct {
+	Users     int `json:"users"`
+	Topics    int `json:"topics"`
+	Posts     int `json:"posts"`
+	Replies   int `json:"replies"`
+	Mail      int `json:"mail"`
+	Bulletins int `json:"bulletins"`
+	Calls     int `json:"calls"` // Calls still in the caller log
+}
+
+// GetBoardTotals counts the board's accounts, messages and calls
+func (db *DB) GetBoardTotals() (*BoardTotals, error) {
+	totals := &BoardTotals{}
+	query := `SELECT
+			  (SELECT COUNT(*) FROM users),
+			  (SELECT COUNT(*) FROM topics),
+			  (SELECT COUNT(*) FROM posts),
+			  (SELECT COUNT(*) FROM replies),
+			  (SELECT COUNT(*) FROM messages WHERE deleted_at IS NULL),
+			  (SELECT COUNT(*) FROM bulletins),
+			  (SELECT COUNT(*) FROM caller_log)`
+	err := db.conn.QueryRow(query).Scan(&totals.Users, &totals.Topics, &totals.Posts,
+		&totals.Replies, &totals.Mail, &totals.Bulletins, &totals.Calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count board totals: %w", err)
+	}
+	return totals, nil
+}
+
+// timesSince runs a query returning a single time column
+func (db *DB) timesSince(query string, args ...interface{}) ([]time.Time, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, rows.Err()
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetActivityStats(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		id, err := db.StartCall("alice", 1)
+		if err != nil {
+			t.Fatalf("StartCall failed: %v", err)
+		}
+		if err := db.EndCall(id); err != nil {
+			t.Fatalf("EndCall failed: %v", err)
+		}
+	}
+
+	// A call and a post from before the period are left out
+	old := now.AddDate(0, 0, -30)
+	if _, err := db.conn.Exec(`INSERT INTO caller_log (username, node, login_at) VALUES ('bob', 2, ?)`, old); err != nil {
+		t.Fatalf("insert old call: %v", err)
+	}
+
+	if err := db.CreateTopic(&Topic{Name: "General"}); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	topics, err := db.GetTopics(0)
+	if err != nil || len(topics) == 0 {
+		t.Fatalf("GetTopics failed: %v", err)
+	}
+	post := &Post{TopicID: topics[0].ID, Author: "alice", Subject: "Hi", Body: "Hello"}
+	if err := db.CreatePost(post); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	if err := db.CreateReply(&Reply{PostID: post.ID, Author: "bob", Body: "Hey"}); err != nil {
+		t.Fatalf("CreateReply failed: %v", err)
+	}
+	if _, err := db.conn.Exec(`INSERT INTO posts (topic_id, author, subject, body, created_at) VALUES (?, 'bob', 'Old', 'Old', ?)`,
+		topics[0].ID, old); err != nil {
+		t.Fatalf("insert old post: %v", err)
+	}
+
+	stats, err := db.GetActivityStats(now, 7)
+	if err != nil {
+		t.Fatalf("GetActivityStats failed: %v", err)
+	}
+
+	total := 0
+	for _, n := range stats.CallsByHour {
+		total += n
+	}
+	if total != 3 || stats.CallsByHour[now.Hour()] != 3 {
+		t.Errorf("calls by hour = %v, expected 3 at hour %d", stats.CallsByHour, now.Hour())
+	}
+
+	if len(stats.PostsByDay) != 7 {
+		t.Fatalf("got %d days, expected 7", len(stats.PostsByDay))
+	}
+	last := stats.PostsByDay[6]
+	if last.Day.Day() != now.Day() || last.Count != 2 {
+		t.Errorf("today = %v with %d posts, expected %v with 2", last.Day, last.Count, now)
+	}
+	for _, day := range stats.PostsByDay[:6] {
+		if day.Count != 0 {
+			t.Errorf("%v has %d posts, expected none", day.Day, day.Count)
+		}
+	}
+}
+
+func TestGetRecentCalls(t *testing.T) {
+	db := newTestDB(t)
+
+	first, err := db.StartCall("alice", 1)
+	if err != nil {
+		t.Fatalf("StartCall failed: %v", err)
+	}
+	for _, action := range []string{"boards", "files", "boards"} {
+		if err := db.RecordCallAction(first, action); err != nil {
+			t.Fatalf("RecordCallAction failed: %v", err)
+		}
+	}
+	if err := db.EndCall(first); err != nil {
+		t.Fatalf("EndCall failed: %v", err)
+	}
+
+	hidden, err := db.StartCall("sysop", 2)
+	if err != nil {
+		t.Fatalf("StartCall failed: %v", err)
+	}
+	if err := db.RecordCallAction(hidden, ActionInvisible); err != nil {
+		t.Fatalf("RecordCallAction failed: %v", err)
+	}
+
+	calls, err := db.GetRecentCalls(10, false)
+	if err != nil {
+		t.Fatalf("GetRecentCalls failed: %v", err)
+	}
+	if len(calls) != 1 || calls[0].Username != "alice" {
+		t.Fatalf("visible calls = %+v, want only alice's", calls)
+	}
+	if got := strings.Join(calls[0].Actions, ","); got != "boards,files" {
+		t.Errorf("actions = %q, want %q", got, "boards,files")
+	}
+	if calls[0].LogoutAt == nil {
+		t.Error("finished call should have a logout time")
+	}
+
+	calls, err = db.GetRecentCalls(10, true)
+	if err != nil {
+		t.Fatalf("GetRecentCalls failed: %v", err)
+	}
+	if len(calls) != 2 || calls[0].Username != "sysop" || !calls[0].Invisible() {
+		t.Errorf("all calls = %+v, want sysop's invisible call first", calls)
+	}
+}
+
+func TestGetBoardTotals(t *testing.T) {
+	db := newTestDB(t)
+	before, err := db.GetBoardTotals()
+	if err != nil {
+		t.Fatalf("GetBoardTotals failed: %v", err)
+	}
+
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret1", AccessLevel: 10}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	topic := &Topic{Name: "General"}
+	if err := db.CreateTopic(topic); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	post := &Post{TopicID: topic.ID, Author: "alice", Subject: "Hi", Body: "Hello"}
+	if err := db.CreatePost(post); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	if err := db.CreateReply(&Reply{PostID: post.ID, Author: "alice", Body: "Again"}); err != nil {
+		t.Fatalf("CreateReply failed: %v", err)
+	}
+	if err := db.CreateMessage(&Message{FromUser: "alice", ToUser: "sysop", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	if _, err := db.StartCall("alice", 1); err != nil {
+		t.Fatalf("StartCall failed: %v", err)
+	}
+
+	after, err := db.GetBoardTotals()
+	if err != nil {
+		t.Fatalf("GetBoardTotals failed: %v", err)
+	}
+	want := *before
+	want.Users++
+	want.Topics++
+	want.Posts++
+	want.Replies++
+	want.Mail++
+	want.Calls++
+	if *after != want {
+		t.Errorf("GetBoardTotals = %+v, want %+v", *after, want)
+	}
+}
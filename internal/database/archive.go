@@ -0,0 +1,84 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrArchived is returned when replying to a post that has been archived
+var ErrArchived = errors.New("post is archived")
+
+// ArchivePosts moves the posts in a topic that its retention rule no longer
+// keeps, with their replies, into the archive tables, where they can still
+// be read and searched but not replied to. A post is kept while it is one
+// of the newest keepPosts in the topic or its thread has had a post or
+// reply since cutoff; 0 and the zero time leave out that test, and a rule
+// with neither archives nothing. Pinned posts are always kept. It returns
+// how many posts were archived.
+func (db *DB) ArchivePosts(topicID, keepPosts int, cutoff time.Time) (int64, error) {
+	if keepPosts <= 0 && cutoff.IsZero() {
+		return 0, nil
+	}
+
+	query := `SELECT id FROM (
+				SELECT p.id, p.pinned, ROW_NUMBER() OVER (ORDER BY p.created_at DESC, p.id DESC) AS n,
+				MAX(p.created_at, COALESCE((SELECT MAX(r.created_at) FROM replies r WHERE r.post_id = p.id), p.created_at)) AS active
+				FROM posts p WHERE p.topic_id = ?
+			  ) WHERE NOT pinned AND (? <= 0 OR n > ?) AND (? OR active < ?)`
+	rows, err := db.conn.Query(query, topicID, keepPosts, keepPosts, cutoff.IsZero(), cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find posts to archive: %w", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Deleting the post drops its search index row, which is put back for
+	// the archived copy under the same docid
+	now := time.Now()
+	statements := []string{
+		`INSERT INTO archived_posts (id, topic_id, author, subject, body, created_at, archived_at)
+		 SELECT id, topic_id, author, subject, body, created_at, ? FROM posts WHERE id = ?`,
+		`INSERT INTO archived_replies (id, post_id, parent_reply_id, author, body, created_at)
+		 SELECT id, post_id, parent_reply_id, author, body, created_at FROM replies WHERE post_id = ?`,
+		`DELETE FROM replies WHERE post_id = ?`,
+		`DELETE FROM posts WHERE id = ?`,
+		fmt.Sprintf(`INSERT INTO search_index (docid, title, body)
+		 SELECT id * %d + %d, subject, body FROM archived_posts WHERE id = ?`, searchKinds, searchPost),
+	}
+	for _, id := range ids {
+		for i, query := range statements {
+			args := []interface{}{id}
+			if i == 0 {
+				args = []interface{}{now, id}
+			}
+			if _, err := tx.Exec(query, args...); err != nil {
+				return 0, fmt.Errorf("failed to archive post %d: %w", id, err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestArchivePosts(t *testing.T) {
+	db := newTestDB(t)
+
+	topic := &Topic{Name: "General"}
+	if err := db.CreateTopic(topic); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	var posts []*Post
+	for _, subject := range []string{"Oldest modem", "Old modem", "Quiet modem", "Newest modem"} {
+		post := &Post{TopicID: topic.ID, Author: "alice", Subject: subject, Body: "About modems"}
+		if err := db.CreatePost(post); err != nil {
+			t.Fatalf("CreatePost failed: %v", err)
+		}
+		posts = append(posts, post)
+	}
+	// Date the posts a month back, oldest first
+	for i, post := range posts {
+		created := time.Now().AddDate(0, -1, 0).Add(time.Duration(i) * time.Hour)
+		if _, err := db.conn.Exec(`UPDATE posts SET created_at = ? WHERE id = ?`, created, post.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reply := &Reply{PostID: posts[0].ID, Author: "bob", Body: "Still going"}
+	if err := db.CreateReply(reply); err != nil {
+		t.Fatalf("CreateReply failed: %v", err)
+	}
+
+	if n, err := db.ArchivePosts(topic.ID, 0, time.Time{}); err != nil || n != 0 {
+		t.Fatalf("ArchivePosts with no rule = %d, %v; want nothing archived", n, err)
+	}
+
+	// Only the newest post is within the count, but the oldest has a new reply
+	n, err := db.ArchivePosts(topic.ID, 1, time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("ArchivePosts failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("ArchivePosts archived %d posts, want 2", n)
+	}
+	hot, err := db.GetPosts(topic.ID, 10)
+	if err != nil {
+		t.Fatalf("GetPosts failed: %v", err)
+	}
+	if len(hot) != 2 || hot[0].ID != posts[3].ID || hot[1].ID != posts[0].ID {
+		t.Errorf("GetPosts = %+v, want the newest post and the one with a new reply", hot)
+	}
+
+	archived, err := db.GetPost(posts[1].ID)
+	if err != nil {
+		t.Fatalf("GetPost of an archived post failed: %v", err)
+	}
+	if !archived.Archived || archived.Subject != "Old modem" {
+		t.Errorf("GetPost = %+v, want the archived post", archived)
+	}
+	if err := db.CreateReply(&Reply{PostID: archived.ID, Author: "bob", Body: "Late"}); !errors.Is(err, ErrArchived) {
+		t.Errorf("CreateReply to an archived post = %v, want ErrArchived", err)
+	}
+
+	results, total, err := db.Search("modem", "", 0, 10, 0)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	found := 0
+	for _, r := range results {
+		if r.Archived {
+			found++
+		}
+	}
+	if total != 4 || found != 2 {
+		t.Errorf("Search found %d posts, %d archived; want 4 with 2 archived", total, found)
+	}
+
+	// Archiving by age alone takes the post whose reply is now old too
+	if _, err := db.conn.Exec(`UPDATE replies SET created_at = ? WHERE id = ?`, time.Now().AddDate(0, -1, 0), reply.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := db.ArchivePosts(topic.ID, 0, time.Now().AddDate(0, 0, -7)); err != nil || n != 2 {
+		t.Fatalf("ArchivePosts by age = %d, %v; want 2", n, err)
+	}
+	replies, err := db.GetReplies(posts[0].ID)
+	if err != nil || len(replies) != 1 || replies[0].ID != reply.ID {
+		t.Errorf("GetReplies of an archived post = %+v, %v; want its reply", replies, err)
+	}
+}
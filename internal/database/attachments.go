@@ -0,0 +1,77 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Attachment is a file sent along with a private message
+type Attachment struct {
+	Filename string
+	Data     []byte
+}
+
+// CreateMessageWithAttachment sends msg with att attached. Both are saved
+// or neither is.
+func (db *DB) CreateMessageWithAttachment(msg *Message, att *Attachment) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO messages (from_user, to_user, subject, body, area, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		msg.FromUser, msg.ToUser, msg.Subject, msg.Body, msg.Area, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO mail_attachments (message_id, filename, size, data) VALUES (?, ?, ?, ?)`,
+		id, att.Filename, len(att.Data), att.Data); err != nil {
+		return fmt.Errorf("failed to attach file: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	msg.ID = int(id)
+	msg.Attachment = att.Filename
+	msg.AttachmentSize = int64(len(att.Data))
+	return nil
+}
+
+// GetAttachment returns the file attached to a message in toUser's
+// mailbox, or sql.ErrNoRows if there is none
+func (db *DB) GetAttachment(toUser string, messageID int) (*Attachment, error) {
+	att := &Attachment{}
+	err := db.conn.QueryRow(`SELECT a.filename, a.data FROM mail_attachments a
+		JOIN messages m ON m.id = a.message_id
+		WHERE a.message_id = ? AND m.to_user = ? AND m.deleted_at IS NULL`,
+		messageID, toUser).Scan(&att.Filename, &att.Data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attachment: %w", err)
+	}
+	return att, nil
+}
+
+// AttachmentUsage returns the bytes of attachments fromUser has sent that
+// are still in someone's mailbox, deleted or not, which is what counts
+// against their quota
+func (db *DB) AttachmentUsage(fromUser string) (int64, error) {
+	var used int64
+	err := db.conn.QueryRow(`SELECT COALESCE(SUM(a.size), 0) FROM mail_attachments a
+		JOIN messages m ON m.id = a.message_id WHERE m.from_user = ?`, fromUser).Scan(&used)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count attachments: %w", err)
+	}
+	return used, nil
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestMailAttachments(t *testing.T) {
+	db := newTestDB(t)
+
+	msg := &Message{FromUser: "alice", ToUser: "bob", Subject: "Map", Body: "Here it is", Area: "private"}
+	if err := db.CreateMessageWithAttachment(msg, &Attachment{Filename: "map.txt", Data: []byte("treasure")}); err != nil {
+		t.Fatalf("CreateMessageWithAttachment failed: %v", err)
+	}
+	plain := &Message{FromUser: "alice", ToUser: "bob", Subject: "Hi", Body: "Hello", Area: "private"}
+	if err := db.CreateMessage(plain); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	if msg.ID == 0 || plain.ID == 0 || msg.ID == plain.ID {
+		t.Fatalf("message IDs = %d and %d, want two distinct IDs", msg.ID, plain.ID)
+	}
+
+	got, err := db.GetMessageByID("bob", msg.ID)
+	if err != nil {
+		t.Fatalf("GetMessageByID failed: %v", err)
+	}
+	if got.Attachment != "map.txt" || got.AttachmentSize != 8 {
+		t.Errorf("attachment = %q (%d bytes), want map.txt (8 bytes)", got.Attachment, got.AttachmentSize)
+	}
+	mail, err := db.GetMessages("bob", 10)
+	if err != nil || len(mail) != 2 {
+		t.Fatalf("GetMessages = %d messages (%v), want 2", len(mail), err)
+	}
+	for _, m := range mail {
+		if (m.ID == msg.ID) != (m.Attachment == "map.txt") {
+			t.Errorf("message %d has attachment %q", m.ID, m.Attachment)
+		}
+	}
+
+	att, err := db.GetAttachment("bob", msg.ID)
+	if err != nil || string(att.Data) != "treasure" {
+		t.Fatalf("GetAttachment = %v, %v", att, err)
+	}
+	if _, err := db.GetAttachment("mallory", msg.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetAttachment for someone else's mail: err = %v, want ErrNoRows", err)
+	}
+	if _, err := db.GetAttachment("bob", plain.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetAttachment with nothing attached: err = %v, want ErrNoRows", err)
+	}
+
+	if used, err := db.AttachmentUsage("alice"); err != nil || used != 8 {
+		t.Errorf("AttachmentUsage = %d, %v; want 8", used, err)
+	}
+
+	// Deleted mail still counts until it is purged, and purging removes the file
+	if err := db.DeleteMessage("bob", msg.ID); err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+	if used, _ := db.AttachmentUsage("alice"); used != 8 {
+		t.Errorf("AttachmentUsage after delete = %d, want 8", used)
+	}
+	if err := db.PurgeMessage("bob", msg.ID); err != nil {
+		t.Fatalf("PurgeMessage failed: %v", err)
+	}
+	if used, _ := db.AttachmentUsage("alice"); used != 0 {
+		t.Errorf("AttachmentUsage after purge = %d, want 0", used)
+	}
+	var left int
+	db.conn.QueryRow(`SELECT COUNT(*) FROM mail_attachments`).Scan(&left)
+	if left != 0 {
+		t.Errorf("%d attachments left after their message was purged", left)
+	}
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// IPBan keeps an address from connecting until it expires
+type IPBan struct {
+	IP        string
+	Reason    string
+	BannedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// BanIP bans an address until expiresAt, replacing any earlier ban on it
+func (db *DB) BanIP(ip, reason string, now, expiresAt time.Time) error {
+	query := `INSERT INTO banned_ips (ip, reason, banned_at, expires_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(ip) DO UPDATE SET reason = excluded.reason, banned_at = excluded.banned_at, expires_at = excluded.expires_at`
+	if _, err := db.conn.Exec(query, ip, reason, now, expiresAt); err != nil {
+		return fmt.Errorf("failed to ban %s: %w", ip, err)
+	}
+	return nil
+}
+
+// GetIPBan returns the ban in force on an address, or nil if there is none
+func (db *DB) GetIPBan(ip string, now time.Time) (*IPBan, error) {
+	var ban IPBan
+	err := db.conn.QueryRow(`SELECT ip, reason, banned_at, expires_at FROM banned_ips WHERE ip = ? AND expires_at > ?`,
+		ip, now).Scan(&ban.IP, &ban.Reason, &ban.BannedAt, &ban.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check ban on %s: %w", ip, err)
+	}
+	return &ban, nil
+}
+
+// GetIPBans returns the bans in force, newest first
+func (db *DB) GetIPBans(now time.Time) ([]IPBan, error) {
+	rows, err := db.conn.Query(`SELECT ip, reason, banned_at, expires_at FROM banned_ips
+		WHERE expires_at > ? ORDER BY banned_at DESC`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []IPBan
+	for rows.Next() {
+		var ban IPBan
+		if err := rows.Scan(&ban.IP, &ban.Reason, &ban.BannedAt, &ban.ExpiresAt); err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+	return bans, rows.Err()
+}
+
+// UnbanIP lifts the ban on an address
+func (db *DB) UnbanIP(ip string) error {
+	result, err := db.conn.Exec(`DELETE FROM banned_ips WHERE ip = ?`, ip)
+	if err != nil {
+		return fmt.Errorf("failed to unban %s: %w", ip, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("%s is not banned", ip)
+	}
+	return nil
+}
+
+// UnbanAllIPs lifts every ban and returns how many there were
+func (db *DB) UnbanAllIPs() (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM banned_ips`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear bans: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// PruneExpiredBans deletes bans that have run out and returns how many
+func (db *DB) PruneExpiredBans(now time.Time) (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM banned_ips WHERE expires_at <= ?`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune expired bans: %w", err)
+	}
+	return result.RowsAffected()
+}
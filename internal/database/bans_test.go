@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIPBans(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := db.BanIP("192.0.2.1", "10 failed logins", now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("BanIP failed: %v", err)
+	}
+	if err := db.BanIP("192.0.2.2", "10 failed logins", now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("BanIP failed: %v", err)
+	}
+
+	ban, err := db.GetIPBan("192.0.2.1", now)
+	if err != nil {
+		t.Fatalf("GetIPBan failed: %v", err)
+	}
+	if ban == nil || ban.Reason != "10 failed logins" {
+		t.Fatalf("expected a ban, got %+v", ban)
+	}
+	if ban, _ := db.GetIPBan("192.0.2.3", now); ban != nil {
+		t.Errorf("unbanned address has a ban: %+v", ban)
+	}
+
+	// Half an hour later the short ban has run out
+	later := now.Add(30 * time.Minute)
+	if ban, _ := db.GetIPBan("192.0.2.2", later); ban != nil {
+		t.Errorf("expired ban still in force: %+v", ban)
+	}
+	bans, err := db.GetIPBans(later)
+	if err != nil {
+		t.Fatalf("GetIPBans failed: %v", err)
+	}
+	if len(bans) != 1 || bans[0].IP != "192.0.2.1" {
+		t.Errorf("expected only 192.0.2.1 banned, got %+v", bans)
+	}
+
+	if n, err := db.PruneExpiredBans(later); err != nil || n != 1 {
+		t.Errorf("PruneExpiredBans = %d, %v; want 1", n, err)
+	}
+
+	if err := db.UnbanIP("192.0.2.1"); err != nil {
+		t.Fatalf("UnbanIP failed: %v", err)
+	}
+	if ban, _ := db.GetIPBan("192.0.2.1", now); ban != nil {
+		t.Error("ban still in force after UnbanIP")
+	}
+	if err := db.UnbanIP("192.0.2.1"); err == nil {
+		t.Error("expected an error unbanning an address that isn't banned")
+	}
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// BellSettings is when a user wants the terminal bell rung
+type BellSettings struct {
+	Silent   bool // No bells at all, whatever the others say
+	Messages bool // Instant messages and teleconference whispers
+	Pages    bool // Pages and sysop chat
+	Errors   bool // Error messages
+}
+
+// GetBellSettings returns a user's bell settings, or nil if they have
+// never changed the board's defaults
+func (db *DB) GetBellSettings(userID int) (*BellSettings, error) {
+	b := &BellSettings{}
+	err := db.conn.QueryRow(`SELECT silent, messages, pages, errors FROM bell_settings WHERE user_id = ?`, userID).
+		Scan(&b.Silent, &b.Messages, &b.Pages, &b.Errors)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bell settings: %w", err)
+	}
+	return b, nil
+}
+
+// SaveBellSettings stores a user's bell settings
+func (db *DB) SaveBellSettings(userID int, b BellSettings) error {
+	_, err := db.conn.Exec(`INSERT INTO bell_settings (user_id, silent, messages, pages, errors) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET silent = excluded.silent, messages = excluded.messages,
+			pages = excluded.pages, errors = excluded.errors`,
+		userID, b.Silent, b.Messages, b.Pages, b.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to save bell settings: %w", err)
+	}
+	return nil
+}
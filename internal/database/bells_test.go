@@ -0,0 +1,43 @@
+package database
+
+import "testing"
+
+func TestBellSettings(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", IsActive: true}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := db.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+
+	b, err := db.GetBellSettings(user.ID)
+	if err != nil || b != nil {
+		t.Fatalf("settings before saving = %+v, %v; want nil", b, err)
+	}
+
+	want := BellSettings{Messages: true, Errors: true}
+	if err := db.SaveBellSettings(user.ID, want); err != nil {
+		t.Fatalf("SaveBellSettings failed: %v", err)
+	}
+	want.Silent = true
+	if err := db.SaveBellSettings(user.ID, want); err != nil {
+		t.Fatalf("SaveBellSettings failed: %v", err)
+	}
+	b, err = db.GetBellSettings(user.ID)
+	if err != nil {
+		t.Fatalf("GetBellSettings failed: %v", err)
+	}
+	if *b != want {
+		t.Errorf("settings = %+v, want %+v", *b, want)
+	}
+
+	// Deleting the account removes the settings with it
+	if err := db.DeleteAccount(user.ID); err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+	if b, err := db.GetBellSettings(user.ID); err != nil || b != nil {
+		t.Errorf("settings after deletion = %+v, %v; want nil", b, err)
+	}
+}
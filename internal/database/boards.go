@@ -0,0 +1,260 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Topic is a public message board area
+type Topic struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	AccessLevel int       `json:"access_level"`
+	CreatedAt   time.Time `json:"created_at"`
+	PostCount   int       `json:"post_count"`
+	Locked      bool      `json:"locked"` // Only moderators can post or reply
+}
+
+// Post is a message posted to a topic
+type Post struct {
+	ID         int       `json:"id"`
+	TopicID    int       `json:"topic_id"`
+	Author     string    `json:"author"`
+	Subject    string    `json:"subject"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplyCount int       `json:"reply_count"`
+	Archived   bool      `json:"archived"` // Moved to the topic's archive; it can be read but not replied to
+	Pinned     bool      `json:"pinned"`   // Listed above the other posts in its topic
+}
+
+// Reply is a response to a post, or to another reply in its thread
+type Reply struct {
+	ID        int       `json:"id"`
+	PostID    int       `json:"post_id"`
+	ParentID  int       `json:"parent_reply_id"` // Reply answered, 0 for the post itself
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetTopics returns the topics visible at the given access level
+func (db *DB) GetTopics(accessLevel int) ([]Topic, error) {
+	query := `SELECT t.id, t.name, t.description, t.access_level, t.created_at,
+			  (SELECT COUNT(*) FROM posts p WHERE p.topic_id = t.id), t.locked
+			  FROM topics t WHERE t.access_level <= ? ORDER BY t.id`
+
+	rows, err := db.conn.Query(query, accessLevel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var topics []Topic
+	for rows.Next() {
+		var topic Topic
+		err := rows.Scan(&topic.ID, &topic.Name, &topic.Description, &topic.AccessLevel,
+			&topic.CreatedAt, &topic.PostCount, &topic.Locked)
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, topic)
+	}
+
+	return topics, rows.Err()
+}
+
+// GetPublicTopics returns the topics named in names, matched without regard
+// to case, that are open to everyone, in the order the board lists them.
+// Names that match no such topic are returned as missing, so the feeds,
+// Gopher and the static archive can never serve a members' topic.
+func (db *DB) GetPublicTopics(names []string) (topics []Topic, missing []string, err error) {
+	public, err := db.GetTopics(0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load topics: %w", err)
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[strings.ToLower(name)] = true
+	}
+	for _, topic := range public {
+		if wanted[strings.ToLower(topic.Name)] {
+			topics = append(topics, topic)
+			delete(wanted, strings.ToLower(topic.Name))
+		}
+	}
+	for _, name := range names {
+		if wanted[strings.ToLower(name)] {
+			missing = append(missing, name)
+			delete(wanted, strings.ToLower(name))
+		}
+	}
+	return topics, missing, nil
+}
+
+// CreateTopic adds a new message board topic
+func (db *DB) CreateTopic(topic *Topic) error {
+	query := `INSERT INTO topics (name, description, access_level, created_at) VALUES (?, ?, ?, ?)`
+	result, err := db.conn.Exec(query, topic.Name, topic.Description, topic.AccessLevel, time.Now())
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	topic.ID = int(id)
+	return nil
+}
+
+// GetPosts returns the newest posts in a topic, pinned posts first
+func (db *DB) GetPosts(topicID int, limit int) ([]Post, error) {
+	query := `SELECT p.id, p.topic_id, p.author, p.subject, p.body, p.created_at,
+			  (SELECT COUNT(*) FROM replies r WHERE r.post_id = p.id), p.pinned
+			  FROM posts p WHERE p.topic_id = ? ORDER BY p.pinned DESC, p.created_at DESC, p.id DESC LIMIT ?`
+
+	rows, err := db.conn.Query(query, topicID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []Post
+	for rows.Next() {
+		var post Post
+		err := rows.Scan(&post.ID, &post.TopicID, &post.Author, &post.Subject, &post.Body,
+			&post.CreatedAt, &post.ReplyCount, &post.Pinned)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// GetPost retrieves a single post by ID, looking in the archive for one
+// that has been archived
+func (db *DB) GetPost(id int) (*Post, error) {
+	query := `SELECT p.id, p.topic_id, p.author, p.subject, p.body, p.created_at,
+			  (SELECT COUNT(*) FROM replies r WHERE r.post_id = p.id), 0, p.pinned
+			  FROM posts p WHERE p.id = ?
+			  UNION ALL
+			  SELECT a.id, a.topic_id, a.author, a.subject, a.body, a.created_at,
+			  (SELECT COUNT(*) FROM archived_replies r WHERE r.post_id = a.id), 1, 0
+			  FROM archived_posts a WHERE a.id = ?`
+
+	post := &Post{}
+	err := db.conn.QueryRow(query, id, id).Scan(&post.ID, &post.TopicID, &post.Author, &post.Subject,
+		&post.Body, &post.CreatedAt, &post.ReplyCount, &post.Archived, &post.Pinned)
+	if err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// CreatePost adds a post to a topic
+func (db *DB) CreatePost(post *Post) error {
+	query := `INSERT INTO posts (topic_id, author, subject, body, created_at) VALUES (?, ?, ?, ?, ?)`
+	result, err := db.conn.Exec(query, post.TopicID, post.Author, post.Subject, post.Body, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to create post: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	post.ID = int(id)
+	return nil
+}
+
+// GetReplies returns the replies to a post, archived or not, oldest first
+func (db *DB) GetReplies(postID int) ([]Reply, error) {
+	query := `SELECT id, post_id, COALESCE(parent_reply_id, 0), author, body, created_at
+			  FROM replies WHERE post_id = ?
+			  UNION ALL
+			  SELECT id, post_id, COALESCE(parent_reply_id, 0), author, body, created_at
+			  FROM archived_replies WHERE post_id = ?
+			  ORDER BY created_at, id`
+
+	rows, err := db.conn.Query(query, postID, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var replies []Reply
+	for rows.Next() {
+		var reply Reply
+		if err := rows.Scan(&reply.ID, &reply.PostID, &reply.ParentID, &reply.Author, &reply.Body, &reply.CreatedAt); err != nil {
+			return nil, err
+		}
+		replies = append(replies, reply)
+	}
+
+	return replies, rows.Err()
+}
+
+// CreateReply adds a reply to a post. Archived posts can't be replied to.
+func (db *DB) CreateReply(reply *Reply) error {
+	var archived int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM archived_posts WHERE id = ?`, reply.PostID).Scan(&archived); err != nil {
+		return fmt.Errorf("failed to create reply: %w", err)
+	}
+	if archived > 0 {
+		return ErrArchived
+	}
+
+	var parent interface{}
+	if reply.ParentID != 0 {
+		parent = reply.ParentID
+	}
+	query := `INSERT INTO replies (post_id, parent_reply_id, author, body, created_at) VALUES (?, ?, ?, ?, ?)`
+	result, err := db.conn.Exec(query, reply.PostID, parent, reply.Author, reply.Body, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to create reply: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	reply.ID = int(id)
+	return nil
+}
+
+// ThreadedReply is a reply placed in its thread
+type ThreadedReply struct {
+	Reply
+	Depth int // 0 for replies to the post itself
+}
+
+// Thread orders replies the way a thread reads: each reply is followed by
+// the replies to it, oldest first. Replies whose parent is missing are
+// treated as replies to the post.
+func Thread(replies []Reply) []ThreadedReply {
+	known := make(map[int]bool, len(replies))
+	for _, reply := range replies {
+		known[reply.ID] = true
+	}
+	children := make(map[int][]Reply)
+	for _, reply := range replies {
+		if !known[reply.ParentID] {
+			reply.ParentID = 0
+		}
+		children[reply.ParentID] = append(children[reply.ParentID], reply)
+	}
+
+	thread := make([]ThreadedReply, 0, len(replies))
+	var walk func(parent, depth int)
+	walk = func(parent, depth int) {
+		for _, reply := range children[parent] {
+			thread = append(thread, ThreadedReply{Reply: reply, Depth: depth})
+			walk(reply.ID, depth+1)
+		}
+	}
+	walk(0, 0)
+	return thread
+}
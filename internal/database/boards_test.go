@@ -0,0 +1,169 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPostsAndReplies(t *testing.T) {
+	db := newTestDB(t)
+
+	topic := &Topic{Name: "General", Description: "Anything goes", AccessLevel: 0}
+	if err := db.CreateTopic(topic); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	hidden := &Topic{Name: "Staff", AccessLevel: 100}
+	if err := db.CreateTopic(hidden); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+
+	post := &Post{TopicID: topic.ID, Author: "alice", Subject: "Hello", Body: "First post\nsecond line"}
+	if err := db.CreatePost(post); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	for _, author := range []string{"bob", "carol"} {
+		if err := db.CreateReply(&Reply{PostID: post.ID, Author: author, Body: "Hi " + author}); err != nil {
+			t.Fatalf("CreateReply failed: %v", err)
+		}
+	}
+
+	topics, err := db.GetTopics(10)
+	if err != nil {
+		t.Fatalf("GetTopics failed: %v", err)
+	}
+	if len(topics) != 1 || topics[0].PostCount != 1 {
+		t.Fatalf("expected one visible topic with one post, got %+v", topics)
+	}
+
+	got, err := db.GetPost(post.ID)
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if got.Body != post.Body || got.ReplyCount != 2 {
+		t.Errorf("unexpected post: %+v", got)
+	}
+
+	replies, err := db.GetReplies(post.ID)
+	if err != nil {
+		t.Fatalf("GetReplies failed: %v", err)
+	}
+	if len(replies) != 2 || replies[0].Author != "bob" || replies[1].Author != "carol" {
+		t.Errorf("expected replies oldest first, got %+v", replies)
+	}
+}
+
+func TestReplyThreads(t *testing.T) {
+	db := newTestDB(t)
+
+	topic := &Topic{Name: "General"}
+	if err := db.CreateTopic(topic); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	post := &Post{TopicID: topic.ID, Author: "alice", Subject: "Hello", Body: "First post"}
+	if err := db.CreatePost(post); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+
+	// bob and dave answer the post, carol answers bob, erin answers carol
+	reply := func(author string, parent int) int {
+		r := &Reply{PostID: post.ID, ParentID: parent, Author: author, Body: "Hi"}
+		if err := db.CreateReply(r); err != nil {
+			t.Fatalf("CreateReply failed: %v", err)
+		}
+		return r.ID
+	}
+	bob := reply("bob", 0)
+	reply("dave", 0)
+	carol := reply("carol", bob)
+	reply("erin", carol)
+
+	replies, err := db.GetReplies(post.ID)
+	if err != nil {
+		t.Fatalf("GetReplies failed: %v", err)
+	}
+	if replies[2].ParentID != bob || replies[0].ParentID != 0 {
+		t.Errorf("parents not stored: %+v", replies)
+	}
+
+	var got []string
+	for _, r := range Thread(replies) {
+		got = append(got, fmt.Sprintf("%s:%d", r.Author, r.Depth))
+	}
+	if want := "bob:0,carol:1,erin:2,dave:0"; strings.Join(got, ",") != want {
+		t.Errorf("thread = %s, want %s", strings.Join(got, ","), want)
+	}
+
+	// A reply whose parent is gone moves up to answer the post
+	orphan := Thread([]Reply{{ID: 9, ParentID: 7, Author: "frank"}})
+	if orphan[0].Depth != 0 || orphan[0].ParentID != 0 {
+		t.Errorf("orphan = %+v, want a reply to the post", orphan[0])
+	}
+}
+
+func TestRepliesTableUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = old.Exec(`CREATE TABLE replies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		post_id INTEGER NOT NULL,
+		author TEXT NOT NULL,
+		body TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err == nil {
+		_, err = old.Exec(`INSERT INTO replies (post_id, author, body) VALUES (1, 'bob', 'Hi')`)
+	}
+	old.Close()
+	if err != nil {
+		t.Fatalf("creating old table: %v", err)
+	}
+
+	db, err := Initialize(path)
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer db.Close()
+
+	replies, err := db.GetReplies(1)
+	if err != nil || len(replies) != 1 || replies[0].ParentID != 0 {
+		t.Fatalf("GetReplies = %+v, %v; want the old reply answering the post", replies, err)
+	}
+	if err := db.CreateReply(&Reply{PostID: 1, ParentID: replies[0].ID, Author: "carol", Body: "Hi bob"}); err != nil {
+		t.Errorf("CreateReply after upgrade failed: %v", err)
+	}
+}
+
+func TestGetPublicTopics(t *testing.T) {
+	db := newTestDB(t)
+
+	for _, topic := range []*Topic{
+		{Name: "General"},
+		{Name: "Staff", AccessLevel: 100},
+		{Name: "Tech Talk"},
+	} {
+		if err := db.CreateTopic(topic); err != nil {
+			t.Fatalf("CreateTopic failed: %v", err)
+		}
+	}
+
+	topics, missing, err := db.GetPublicTopics([]string{"tech talk", "Staff", "General", "Nowhere"})
+	if err != nil {
+		t.Fatalf("GetPublicTopics failed: %v", err)
+	}
+	var names []string
+	for _, topic := range topics {
+		names = append(names, topic.Name)
+	}
+	if strings.Join(names, ",") != "General,Tech Talk" {
+		t.Errorf("topics = %v, want General and Tech Talk in board order", names)
+	}
+	if strings.Join(missing, ",") != "Staff,Nowhere" {
+		t.Errorf("missing = %v, want the members' topic and the unknown one", missing)
+	}
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// createBulletinViews creates the table of bulletins each user has seen.
+// On a board upgraded to it, the bulletins up to each user's last-read
+// pointer count as seen, so callers aren't shown them all again.
+func (db *DB) createBulletinViews() error {
+	var existing int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'bulletin_views'`).Scan(&existing); err != nil {
+		return fmt.Errorf("failed to check bulletin views: %w", err)
+	}
+
+	_, err := db.conn.Exec(`CREATE TABLE IF NOT EXISTS bulletin_views (
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			bulletin_id INTEGER NOT NULL REFERENCES bulletins(id),
+			seen_at DATETIME NOT NULL,
+			PRIMARY KEY (user_id, bulletin_id)
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create bulletin views: %w", err)
+	}
+	if existing == 0 {
+		_, err = db.conn.Exec(`INSERT INTO bulletin_views (user_id, bulletin_id, seen_at)
+			SELECT l.user_id, b.id, ? FROM user_lastread l JOIN bulletins b ON b.id <= l.last_read_id
+			WHERE l.area = ?`, time.Now(), BulletinsArea)
+		if err != nil {
+			return fmt.Errorf("failed to fill bulletin views: %w", err)
+		}
+	}
+	return nil
+}
+
+// MarkBulletinSeen records that a user has read a bulletin
+func (db *DB) MarkBulletinSeen(userID, bulletinID int) error {
+	_, err := db.conn.Exec(`INSERT OR IGNORE INTO bulletin_views (user_id, bulletin_id, seen_at) VALUES (?, ?, ?)`,
+		userID, bulletinID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark bulletin seen: %w", err)
+	}
+	return nil
+}
+
+// GetUnseenBulletins returns the bulletins callers can see at now that a
+// user has never read, oldest first. Unlike the last-read pointer, this
+// finds a bulletin published after newer ones were posted.
+func (db *DB) GetUnseenBulletins(userID int, now time.Time) ([]Bulletin, error) {
+	rows, err := db.conn.Query(`SELECT `+bulletinColumns+`
+			  FROM bulletins b
+			  WHERE `+currentBulletin+`
+			  AND NOT EXISTS (SELECT 1 FROM bulletin_views v WHERE v.user_id = ? AND v.bulletin_id = b.id)
+			  ORDER BY `+bulletinDate+`, id`, now, now, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unseen bulletins: %w", err)
+	}
+	defer rows.Close()
+
+	var bulletins []Bulletin
+	for rows.Next() {
+		bulletin, err := scanBulletin(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bulletin: %w", err)
+		}
+		bulletins = append(bulletins, bulletin)
+	}
+	return bulletins, rows.Err()
+}
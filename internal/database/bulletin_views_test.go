@@ -0,0 +1,88 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnseenBulletins(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	var ids []int
+	for _, title := range []string{"Rules", "Hours", "Meeting"} {
+		b := &Bulletin{Title: title, Body: title, Author: "sysop"}
+		if title == "Meeting" {
+			b.PublishAt = &later
+		}
+		if err := db.CreateBulletin(b); err != nil {
+			t.Fatalf("CreateBulletin failed: %v", err)
+		}
+		ids = append(ids, b.ID)
+	}
+
+	if err := db.MarkBulletinSeen(1, ids[0]); err != nil {
+		t.Fatalf("MarkBulletinSeen failed: %v", err)
+	}
+	// Seeing a bulletin twice is fine
+	if err := db.MarkBulletinSeen(1, ids[0]); err != nil {
+		t.Fatalf("MarkBulletinSeen failed: %v", err)
+	}
+
+	unseen, err := db.GetUnseenBulletins(1, now)
+	if err != nil {
+		t.Fatalf("GetUnseenBulletins failed: %v", err)
+	}
+	if len(unseen) != 1 || unseen[0].ID != ids[1] {
+		t.Errorf("unseen bulletins = %+v, want only Hours", unseen)
+	}
+
+	// A bulletin published after a newer one was read still shows up
+	if err := db.MarkBulletinSeen(1, ids[1]); err != nil {
+		t.Fatalf("MarkBulletinSeen failed: %v", err)
+	}
+	unseen, err = db.GetUnseenBulletins(1, later)
+	if err != nil {
+		t.Fatalf("GetUnseenBulletins failed: %v", err)
+	}
+	if len(unseen) != 1 || unseen[0].ID != ids[2] {
+		t.Errorf("unseen bulletins once Meeting is published = %+v, want Meeting", unseen)
+	}
+
+	// Another user has seen none of them
+	if unseen, _ := db.GetUnseenBulletins(2, now); len(unseen) != 2 {
+		t.Errorf("unseen bulletins for a new user = %+v, want Rules and Hours", unseen)
+	}
+}
+
+func TestBulletinViewsFromLastRead(t *testing.T) {
+	db := newTestDB(t)
+	var ids []int
+	for _, title := range []string{"Rules", "Hours"} {
+		b := &Bulletin{Title: title, Body: title, Author: "sysop"}
+		if err := db.CreateBulletin(b); err != nil {
+			t.Fatalf("CreateBulletin failed: %v", err)
+		}
+		ids = append(ids, b.ID)
+	}
+
+	// A board from before bulletin views were kept
+	if _, err := db.conn.Exec(`DROP TABLE bulletin_views`); err != nil {
+		t.Fatalf("drop bulletin views: %v", err)
+	}
+	if err := db.MarkRead(1, BulletinsArea, ids[0]); err != nil {
+		t.Fatalf("MarkRead failed: %v", err)
+	}
+	if err := db.createBulletinViews(); err != nil {
+		t.Fatalf("createBulletinViews failed: %v", err)
+	}
+
+	unseen, err := db.GetUnseenBulletins(1, time.Now())
+	if err != nil {
+		t.Fatalf("GetUnseenBulletins failed: %v", err)
+	}
+	if len(unseen) != 1 || unseen[0].ID != ids[1] {
+		t.Errorf("unseen bulletins after upgrading = %+v, want only Hours", unseen)
+	}
+}
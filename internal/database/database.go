@@ -2,16 +2,35 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type DB struct {
-	conn *sql.DB
+	conn     *sql.DB
+	path     string
+	readOnly bool
+	health   health
+	stmts    statements
 }
 
+// statements are prepared once for the queries every call makes
+type statements struct {
+	getUser      *sql.Stmt
+	getBulletins *sql.Stmt
+}
+
+// busyTimeout is how long, in milliseconds, a connection waits for another
+// to release its lock before giving up with "database is locked"
+const busyTimeout = 5000
+
+// ErrReadOnly is returned when a write is attempted against a read-only mirror
+var ErrReadOnly = errors.New("database is a read-only mirror")
+
 type User struct {
 	ID          int        `json:"id"`
 	Username    string     `json:"username"`
@@ -34,6 +53,9 @@ type Message struct {
 	Area      string    `json:"area"`
 	CreatedAt time.Time `json:"created_at"`
 	IsRead    bool      `json:"is_read"`
+
+	Attachment     string `json:"attachment,omitempty"`      // Name of the file attached, if any
+	AttachmentSize int64  `json:"attachment_size,omitempty"` // Its size in bytes
 }
 
 type Bulletin struct {
@@ -42,28 +64,128 @@ type Bulletin struct {
 	Body      string     `json:"body"`
 	Author    string     `json:"author"`
 	CreatedAt time.Time  `json:"created_at"`
-	ExpiresAt *time.Time `json:"expires_at"`
+	PublishAt *time.Time `json:"publish_at"` // Hidden from callers until then; nil to publish at once
+	ExpiresAt *time.Time `json:"expires_at"` // Hidden from callers from then on; nil for never
+	Archived  bool       `json:"archived"`   // Expired and put away by the retention job
+}
+
+// Current reports whether callers can see the bulletin at now: it has been
+// published, hasn't expired and hasn't been archived
+func (b *Bulletin) Current(now time.Time) bool {
+	return !b.Archived && (b.PublishAt == nil || !b.PublishAt.After(now)) &&
+		(b.ExpiresAt == nil || b.ExpiresAt.After(now))
+}
+
+// currentBulletin is the SQL condition for bulletins callers can see, the
+// same as Bulletin.Current. It takes the current time twice.
+const currentBulletin = `archived_at IS NULL AND (publish_at IS NULL OR publish_at <= ?)
+			  AND (expires_at IS NULL OR expires_at > ?)`
+
+// bulletinColumns are the columns scanBulletin reads, in order
+const bulletinColumns = `id, title, body, author, created_at, publish_at, expires_at, archived_at IS NOT NULL`
+
+// bulletinDate orders bulletins by when callers first saw them
+const bulletinDate = `COALESCE(publish_at, created_at)`
+
+// scanBulletin reads the bulletinColumns of a row, followed by any extra
+// columns the query selects
+func scanBulletin(row interface{ Scan(...interface{}) error }, extra ...interface{}) (Bulletin, error) {
+	var b Bulletin
+	err := row.Scan(append([]interface{}{&b.ID, &b.Title, &b.Body, &b.Author,
+		&b.CreatedAt, &b.PublishAt, &b.ExpiresAt, &b.Archived}, extra...)...)
+	return b, err
 }
 
 func Initialize(dbPath string) (*DB, error) {
-	conn, err := sql.Open("sqlite3", dbPath)
+	// Create the file on first run, then reopen without the create flag so a
+	// database file lost at runtime is reported instead of silently recreated empty
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		create, err := sql.Open("sqlite3", dbPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
+		if err := create.Ping(); err != nil {
+			create.Close()
+			return nil, fmt.Errorf("failed to create database: %w", err)
+		}
+		create.Close()
+	}
+
+	// WAL lets callers read while another session writes, and the busy
+	// timeout makes writers queue for the lock instead of failing
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=rw&_journal_mode=WAL&_busy_timeout=%d", dbPath, busyTimeout))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, path: dbPath, health: health{healthy: true}}
 
 	if err := db.createTables(); err != nil {
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
+	if err := db.prepare(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	db.CheckHealth()
+
+	return db, nil
+}
+
+// InitializeReadOnly opens an existing (replicated) database without writing to it.
+// The schema is not created or migrated; it is expected to match the primary.
+func InitializeReadOnly(dbPath string) (*DB, error) {
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro&_busy_timeout=%d", dbPath, busyTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := conn.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to open read-only database: %w", err)
+	}
+
+	db := &DB{conn: conn, path: dbPath, readOnly: true, health: health{healthy: true}}
+	if err := db.prepare(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	db.CheckHealth()
 
 	return db, nil
 }
 
+// ReadOnly reports whether the database was opened as a read-only mirror
+func (db *DB) ReadOnly() bool {
+	return db.readOnly
+}
+
 func (db *DB) Close() error {
+	db.stmts.getUser.Close()
+	db.stmts.getBulletins.Close()
 	return db.conn.Close()
 }
 
+// prepare prepares the statements for the hottest queries
+func (db *DB) prepare() error {
+	var err error
+	db.stmts.getUser, err = db.conn.Prepare(`SELECT id, username, password, real_name, email, access_level,
+			  last_call, total_calls, created_at, is_active
+			  FROM users WHERE username = ? AND is_active = 1`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare user query: %w", err)
+	}
+	db.stmts.getBulletins, err = db.conn.Prepare(`SELECT ` + bulletinColumns + `
+			  FROM bulletins
+			  WHERE ` + currentBulletin + `
+			  ORDER BY ` + bulletinDate + ` DESC LIMIT ?`)
+	if err != nil {
+		db.stmts.getUser.Close()
+		return fmt.Errorf("failed to prepare bulletin query: %w", err)
+	}
+	return nil
+}
+
 func (db *DB) createTables() error {
 	queries := []string{
 		`CREATE TABLE IF NOT EXISTS users (
@@ -96,12 +218,247 @@ func (db *DB) createTables() error {
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			expires_at DATETIME
 		)`,
+		`CREATE TABLE IF NOT EXISTS topics (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			access_level INTEGER DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS posts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			topic_id INTEGER NOT NULL REFERENCES topics(id),
+			author TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			body TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS replies (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER NOT NULL REFERENCES posts(id),
+			parent_reply_id INTEGER REFERENCES replies(id),
+			author TEXT NOT NULL,
+			body TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		// Posts moved out of a topic by its retention rule keep their IDs,
+		// so links to them and their search index rows stay valid
+		`CREATE TABLE IF NOT EXISTS archived_posts (
+			id INTEGER PRIMARY KEY,
+			topic_id INTEGER NOT NULL REFERENCES topics(id),
+			author TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			body TEXT NOT NULL,
+			created_at DATETIME,
+			archived_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS archived_replies (
+			id INTEGER PRIMARY KEY,
+			post_id INTEGER NOT NULL REFERENCES archived_posts(id),
+			parent_reply_id INTEGER,
+			author TEXT NOT NULL,
+			body TEXT NOT NULL,
+			created_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS imported_messages (
+			message_id TEXT PRIMARY KEY,
+			post_id INTEGER NOT NULL REFERENCES posts(id),
+			reply_id INTEGER REFERENCES replies(id),
+			imported_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS echo_areas (
+			tag TEXT PRIMARY KEY,
+			last_post_id INTEGER NOT NULL,
+			last_reply_id INTEGER NOT NULL,
+			linked_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS echomail_sent (
+			msgid TEXT PRIMARY KEY,
+			post_id INTEGER NOT NULL REFERENCES posts(id),
+			reply_id INTEGER REFERENCES replies(id),
+			sent_at DATETIME NOT NULL
+		)`,
 		`CREATE TABLE IF NOT EXISTS sessions (
 			id TEXT PRIMARY KEY,
 			username TEXT NOT NULL,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			last_activity DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
+		`CREATE TABLE IF NOT EXISTS pending_registrations (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id),
+			requested_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS password_status (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id),
+			must_change BOOLEAN DEFAULT 0,
+			changed_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS file_transfers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			username TEXT NOT NULL,
+			area TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			direction TEXT NOT NULL,
+			bytes INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS mirrored_files (
+			area TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			url TEXT NOT NULL,
+			sha256 TEXT NOT NULL,
+			bytes INTEGER NOT NULL,
+			fetched_at DATETIME NOT NULL,
+			PRIMARY KEY (area, filename)
+		)`,
+		`CREATE TABLE IF NOT EXISTS pending_uploads (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			area TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			uploader TEXT NOT NULL,
+			bytes INTEGER NOT NULL,
+			uploaded_at DATETIME NOT NULL,
+			UNIQUE(area, filename)
+		)`,
+		`CREATE TABLE IF NOT EXISTS file_descriptions (
+			area TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			description TEXT NOT NULL,
+			source TEXT NOT NULL,
+			updated_at DATETIME NOT NULL,
+			PRIMARY KEY (area, filename)
+		)`,
+		`CREATE TABLE IF NOT EXISTS caller_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			username TEXT NOT NULL,
+			node INTEGER NOT NULL,
+			login_at DATETIME NOT NULL,
+			logout_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS call_actions (
+			call_id INTEGER NOT NULL REFERENCES caller_log(id),
+			action TEXT NOT NULL,
+			PRIMARY KEY (call_id, action)
+		)`,
+		`CREATE TABLE IF NOT EXISTS user_time (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id),
+			day TEXT NOT NULL,
+			used_seconds INTEGER NOT NULL DEFAULT 0,
+			bank_minutes INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS account_deletions (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id),
+			requested_at DATETIME NOT NULL,
+			delete_after DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS tutorial_progress (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id),
+			step INTEGER NOT NULL DEFAULT 0,
+			completed_at DATETIME,
+			dismissed BOOLEAN NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS bell_settings (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id),
+			silent BOOLEAN NOT NULL DEFAULT 0,
+			messages BOOLEAN NOT NULL DEFAULT 1,
+			pages BOOLEAN NOT NULL DEFAULT 1,
+			errors BOOLEAN NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS user_hotkeys (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id),
+			unread TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS taglines (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			text TEXT NOT NULL,
+			author TEXT NOT NULL,
+			approved BOOLEAN NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS user_prefs (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id),
+			theme TEXT NOT NULL DEFAULT '',
+			terminal TEXT NOT NULL DEFAULT '',
+			screen_lines INTEGER NOT NULL DEFAULT 0,
+			navigation TEXT NOT NULL DEFAULT 'lightbar'
+		)`,
+		`CREATE TABLE IF NOT EXISTS notify_settings (
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			kind TEXT NOT NULL,
+			delivery TEXT NOT NULL,
+			PRIMARY KEY (user_id, kind)
+		)`,
+		`CREATE TABLE IF NOT EXISTS notify_queue (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			kind TEXT NOT NULL,
+			text TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS user_lastread (
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			area TEXT NOT NULL,
+			last_read_id INTEGER NOT NULL,
+			PRIMARY KEY (user_id, area)
+		)`,
+		`CREATE TABLE IF NOT EXISTS banned_ips (
+			ip TEXT PRIMARY KEY,
+			reason TEXT NOT NULL,
+			banned_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS feedback (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL DEFAULT '',
+			contact TEXT NOT NULL DEFAULT '',
+			message TEXT NOT NULL,
+			address TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			is_read BOOLEAN NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS daily_stats (
+			day TEXT PRIMARY KEY,
+			calls INTEGER NOT NULL DEFAULT 0,
+			new_users INTEGER NOT NULL DEFAULT 0,
+			posts INTEGER NOT NULL DEFAULT 0,
+			replies INTEGER NOT NULL DEFAULT 0,
+			mail INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS mail_attachments (
+			message_id INTEGER PRIMARY KEY,
+			filename TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			data BLOB NOT NULL
+		)`,
+		// However mail is removed, its attachment goes with it
+		`CREATE TRIGGER IF NOT EXISTS mail_attachments_delete AFTER DELETE ON messages BEGIN
+			DELETE FROM mail_attachments WHERE message_id = old.id;
+		END`,
+		`CREATE TABLE IF NOT EXISTS script_data (
+			script TEXT NOT NULL,
+			key TEXT NOT NULL,
+			value TEXT NOT NULL,
+			updated_at DATETIME NOT NULL,
+			PRIMARY KEY (script, key)
+		)`,
+		`CREATE TABLE IF NOT EXISTS mail_keys (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id),
+			key TEXT NOT NULL UNIQUE
+		)`,
+		`CREATE TABLE IF NOT EXISTS topic_subscriptions (
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			topic_id INTEGER NOT NULL REFERENCES topics(id),
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (user_id, topic_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS health_probe (
+			id INTEGER PRIMARY KEY,
+			checked_at DATETIME
+		)`,
+		// Mailboxes and topic listings look rows up by these on every call
+		`CREATE INDEX IF NOT EXISTS idx_messages_to_user ON messages(to_user)`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_topic_id ON posts(topic_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_archived_replies_post_id ON archived_replies(post_id)`,
 	}
 
 	for _, query := range queries {
@@ -110,17 +467,76 @@ func (db *DB) createTables() error {
 		}
 	}
 
+	// Columns added since the table was first created
+	if err := db.addColumn("replies", "parent_reply_id", "INTEGER REFERENCES replies(id)"); err != nil {
+		return err
+	}
+	// Deleted mail is kept a few seconds in case the caller undoes it
+	if err := db.addColumn("messages", "deleted_at", "DATETIME"); err != nil {
+		return err
+	}
+	// Accounts with no expiry date never expire
+	if err := db.addColumn("users", "expires_at", "DATETIME"); err != nil {
+		return err
+	}
+	// Moderators lock topics and pin posts
+	if err := db.addColumn("topics", "locked", "BOOLEAN NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := db.addColumn("posts", "pinned", "BOOLEAN NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	// Bulletins can wait to be published, and expired ones are archived
+	if err := db.addColumn("bulletins", "publish_at", "DATETIME"); err != nil {
+		return err
+	}
+	if err := db.addColumn("bulletins", "archived_at", "DATETIME"); err != nil {
+		return err
+	}
+	// Callers choose the language the board is shown in
+	if err := db.addColumn("user_prefs", "language", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := db.createBulletinViews(); err != nil {
+		return err
+	}
+	if err := db.createAPITokens(); err != nil {
+		return err
+	}
+	return db.createSearchIndex()
+}
+
+// addColumn adds a column to a table created before the column existed
+func (db *DB) addColumn(table, column, definition string) error {
+	rows, err := db.conn.Query(fmt.Sprintf(`SELECT name FROM pragma_table_info('%s')`, table))
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if _, err := db.conn.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s to %s: %w", column, table, err)
+	}
 	return nil
 }
 
 // User management methods
 func (db *DB) GetUser(username string) (*User, error) {
 	user := &User{}
-	query := `SELECT id, username, password, real_name, email, access_level, 
-			  last_call, total_calls, created_at, is_active 
-			  FROM users WHERE username = ? AND is_active = 1`
-
-	err := db.conn.QueryRow(query, username).Scan(
+	err := db.stmts.getUser.QueryRow(username).Scan(
 		&user.ID, &user.Username, &user.Password, &user.RealName,
 		&user.Email, &user.AccessLevel, &user.LastCall, &user.TotalCalls,
 		&user.CreatedAt, &user.IsActive,
@@ -134,10 +550,15 @@ func (db *DB) GetUser(username string) (*User, error) {
 }
 
 func (db *DB) CreateUser(user *User) error {
+	password, err := ensureHashed(user.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
 	query := `INSERT INTO users (username, password, real_name, email, access_level, created_at)
 			  VALUES (?, ?, ?, ?, ?, ?)`
 
-	_, err := db.conn.Exec(query, user.Username, user.Password, user.RealName,
+	_, err = db.conn.Exec(query, user.Username, password, user.RealName,
 		user.Email, user.AccessLevel, time.Now())
 
 	return err
@@ -196,25 +617,36 @@ func (db *DB) GetUserByID(id int) (*User, error) {
 	return user, nil
 }
 
-// UpdateUser updates user information
+// GetUserByName retrieves a user by name, including disabled accounts,
+// which GetUser leaves out
+func (db *DB) GetUserByName(username string) (*User, error) {
+	var id int
+	if err := db.conn.QueryRow(`SELECT id FROM users WHERE username = ?`, username).Scan(&id); err != nil {
+		return nil, err
+	}
+	return db.GetUserByID(id)
+}
+
+// UpdateUser updates user information. A plaintext password is hashed before
+// storing; an existing hash is stored unchanged.
 func (db *DB) UpdateUser(id int, username, password, realName, email string, accessLevel int, isActive bool) error {
+	password, err := ensureHashed(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
 	query := `UPDATE users SET username = ?, password = ?, real_name = ?, 
 			  email = ?, access_level = ?, is_active = ? WHERE id = ?`
-	_, err := db.conn.Exec(query, username, password, realName, email, accessLevel, isActive, id)
-	return err
-}
-
-// DeleteUser deletes a user by ID
-func (db *DB) DeleteUser(id int) error {
-	query := `DELETE FROM users WHERE id = ?`
-	_, err := db.conn.Exec(query, id)
+	_, err = db.conn.Exec(query, username, password, realName, email, accessLevel, isActive, id)
 	return err
 }
 
 // Message methods
 func (db *DB) GetMessages(toUser string, limit int) ([]Message, error) {
-	query := `SELECT id, from_user, to_user, subject, body, area, created_at, is_read
-			  FROM messages WHERE to_user = ? ORDER BY created_at DESC LIMIT ?`
+	query := `SELECT m.id, m.from_user, m.to_user, m.subject, m.body, m.area, m.created_at, m.is_read,
+			  COALESCE(a.filename, ''), COALESCE(a.size, 0)
+			  FROM messages m LEFT JOIN mail_attachments a ON a.message_id = m.id
+			  WHERE m.to_user = ? AND m.deleted_at IS NULL ORDER BY m.created_at DESC LIMIT ?`
 
 	rows, err := db.conn.Query(query, toUser, limit)
 	if err != nil {
@@ -226,7 +658,7 @@ func (db *DB) GetMessages(toUser string, limit int) ([]Message, error) {
 	for rows.Next() {
 		var msg Message
 		err := rows.Scan(&msg.ID, &msg.FromUser, &msg.ToUser, &msg.Subject,
-			&msg.Body, &msg.Area, &msg.CreatedAt, &msg.IsRead)
+			&msg.Body, &msg.Area, &msg.CreatedAt, &msg.IsRead, &msg.Attachment, &msg.AttachmentSize)
 		if err != nil {
 			return nil, err
 		}
@@ -236,24 +668,97 @@ func (db *DB) GetMessages(toUser string, limit int) ([]Message, error) {
 	return messages, nil
 }
 
+// GetMessageByID retrieves a single message addressed to the given user
+func (db *DB) GetMessageByID(toUser string, id int) (*Message, error) {
+	query := `SELECT m.id, m.from_user, m.to_user, m.subject, m.body, m.area, m.created_at, m.is_read,
+			  COALESCE(a.filename, ''), COALESCE(a.size, 0)
+			  FROM messages m LEFT JOIN mail_attachments a ON a.message_id = m.id
+			  WHERE m.id = ? AND m.to_user = ? AND m.deleted_at IS NULL`
+
+	msg := &Message{}
+	err := db.conn.QueryRow(query, id, toUser).Scan(&msg.ID, &msg.FromUser, &msg.ToUser,
+		&msg.Subject, &msg.Body, &msg.Area, &msg.CreatedAt, &msg.IsRead, &msg.Attachment, &msg.AttachmentSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
 func (db *DB) CreateMessage(msg *Message) error {
 	query := `INSERT INTO messages (from_user, to_user, subject, body, area, created_at)
 			  VALUES (?, ?, ?, ?, ?, ?)`
 
-	_, err := db.conn.Exec(query, msg.FromUser, msg.ToUser, msg.Subject,
+	result, err := db.conn.Exec(query, msg.FromUser, msg.ToUser, msg.Subject,
 		msg.Body, msg.Area, time.Now())
+	if err != nil {
+		return err
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		msg.ID = int(id)
+	}
+	return nil
+}
+
+// UpdateMessageRead marks a message addressed to the given user as read or unread
+func (db *DB) UpdateMessageRead(toUser string, id int, read bool) error {
+	result, err := db.conn.Exec(`UPDATE messages SET is_read = ? WHERE id = ? AND to_user = ?`, read, id, toUser)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("message %d not found", id)
+	}
+	return nil
+}
+
+// CountUnreadMail returns how many messages in a user's mailbox are unread
+func (db *DB) CountUnreadMail(toUser string) (int, error) {
+	var n int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM messages WHERE to_user = ? AND is_read = 0 AND deleted_at IS NULL`, toUser).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread mail: %w", err)
+	}
+	return n, nil
+}
+
+// DeleteMessage removes a message addressed to the given user from their
+// mailbox. The message is kept until PurgeMessage so it can be restored.
+func (db *DB) DeleteMessage(toUser string, id int) error {
+	result, err := db.conn.Exec(`UPDATE messages SET deleted_at = ? WHERE id = ? AND to_user = ? AND deleted_at IS NULL`,
+		time.Now(), id, toUser)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("message %d not found", id)
+	}
+	return nil
+}
+
+// RestoreMessage puts a deleted message back in the given user's mailbox
+func (db *DB) RestoreMessage(toUser string, id int) error {
+	result, err := db.conn.Exec(`UPDATE messages SET deleted_at = NULL WHERE id = ? AND to_user = ? AND deleted_at IS NOT NULL`,
+		id, toUser)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("message %d is no longer deleted", id)
+	}
+	return nil
+}
 
+// PurgeMessage removes a deleted message for good
+func (db *DB) PurgeMessage(toUser string, id int) error {
+	_, err := db.conn.Exec(`DELETE FROM messages WHERE id = ? AND to_user = ? AND deleted_at IS NOT NULL`, id, toUser)
 	return err
 }
 
 // Bulletin methods
 func (db *DB) GetBulletins(limit int) ([]Bulletin, error) {
-	query := `SELECT id, title, body, author, created_at, expires_at
-			  FROM bulletins 
-			  WHERE expires_at IS NULL OR expires_at > ?
-			  ORDER BY created_at DESC LIMIT ?`
-
-	rows, err := db.conn.Query(query, time.Now(), limit)
+	now := time.Now()
+	rows, err := db.stmts.getBulletins.Query(now, now, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -261,9 +766,7 @@ func (db *DB) GetBulletins(limit int) ([]Bulletin, error) {
 
 	var bulletins []Bulletin
 	for rows.Next() {
-		var bulletin Bulletin
-		err := rows.Scan(&bulletin.ID, &bulletin.Title, &bulletin.Body,
-			&bulletin.Author, &bulletin.CreatedAt, &bulletin.ExpiresAt)
+		bulletin, err := scanBulletin(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -273,12 +776,60 @@ func (db *DB) GetBulletins(limit int) ([]Bulletin, error) {
 	return bulletins, nil
 }
 
+// GetBulletinPage returns a page of the current bulletins, newest first,
+// and how many there are in all
+func (db *DB) GetBulletinPage(limit, offset int) ([]Bulletin, int, error) {
+	query := `SELECT ` + bulletinColumns + `, COUNT(*) OVER ()
+			  FROM bulletins
+			  WHERE ` + currentBulletin + `
+			  ORDER BY ` + bulletinDate + ` DESC, id DESC LIMIT ? OFFSET ?`
+	now := time.Now()
+	rows, err := db.conn.Query(query, now, now, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get bulletins: %w", err)
+	}
+	defer rows.Close()
+
+	var bulletins []Bulletin
+	total := 0
+	for rows.Next() {
+		bulletin, err := scanBulletin(rows, &total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get bulletins: %w", err)
+		}
+		bulletins = append(bulletins, bulletin)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to get bulletins: %w", err)
+	}
+
+	// A page past the end still reports how many there are
+	if len(bulletins) == 0 && offset > 0 {
+		err := db.conn.QueryRow(`SELECT COUNT(*) FROM bulletins WHERE `+currentBulletin, now, now).Scan(&total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count bulletins: %w", err)
+		}
+	}
+	return bulletins, total, nil
+}
+
+// CreateBulletin posts a bulletin, to be published and to expire at the
+// bulletin's PublishAt and ExpiresAt if they are set
 func (db *DB) CreateBulletin(bulletin *Bulletin) error {
-	query := `INSERT INTO bulletins (title, body, author, created_at)
-			  VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO bulletins (title, body, author, created_at, publish_at, expires_at)
+			  VALUES (?, ?, ?, ?, ?, ?)`
 
-	_, err := db.conn.Exec(query, bulletin.Title, bulletin.Body, bulletin.Author, time.Now())
-	return err
+	result, err := db.conn.Exec(query, bulletin.Title, bulletin.Body, bulletin.Author, time.Now(),
+		bulletin.PublishAt, bulletin.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	bulletin.ID = int(id)
+	return nil
 }
 
 // UpdateBulletin updates an existing bulletin
@@ -288,8 +839,66 @@ func (db *DB) UpdateBulletin(id int, title, body string) error {
 	return err
 }
 
+// ScheduleBulletin sets when a bulletin is published and when it expires,
+// nil for now and never. A bulletin that was archived is brought back.
+func (db *DB) ScheduleBulletin(id int, publishAt, expiresAt *time.Time) error {
+	_, err := db.conn.Exec(`UPDATE bulletins SET publish_at = ?, expires_at = ?, archived_at = NULL WHERE id = ?`,
+		publishAt, expiresAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to schedule bulletin: %w", err)
+	}
+	return nil
+}
+
+// ArchiveExpiredBulletins archives the bulletins that expired by now and
+// returns how many. Archived bulletins are kept for the sysop but no longer
+// shown to callers.
+func (db *DB) ArchiveExpiredBulletins(now time.Time) (int64, error) {
+	result, err := db.conn.Exec(`UPDATE bulletins SET archived_at = ?
+			  WHERE archived_at IS NULL AND expires_at IS NOT NULL AND expires_at <= ?`, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive bulletins: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetAllBulletins returns a page of every bulletin, whether published,
+// scheduled, expired or archived, latest first, and how many there are
+func (db *DB) GetAllBulletins(limit, offset int) ([]Bulletin, int, error) {
+	rows, err := db.conn.Query(`SELECT `+bulletinColumns+`, COUNT(*) OVER ()
+			  FROM bulletins ORDER BY `+bulletinDate+` DESC, id DESC LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get bulletins: %w", err)
+	}
+	defer rows.Close()
+
+	var bulletins []Bulletin
+	total := 0
+	for rows.Next() {
+		bulletin, err := scanBulletin(rows, &total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get bulletins: %w", err)
+		}
+		bulletins = append(bulletins, bulletin)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to get bulletins: %w", err)
+	}
+
+	// A page past the end still reports how many there are
+	if len(bulletins) == 0 && offset > 0 {
+		if err := db.conn.QueryRow(`SELECT COUNT(*) FROM bulletins`).Scan(&total); err != nil {
+			return nil, 0, fmt.Errorf("failed to count bulletins: %w", err)
+		}
+	}
+	return bulletins, total, nil
+}
+
 // DeleteBulletin deletes a bulletin by ID
 func (db *DB) DeleteBulletin(id int) error {
+	if _, err := db.conn.Exec(`DELETE FROM bulletin_views WHERE bulletin_id = ?`, id); err != nil {
+		return err
+	}
 	query := `DELETE FROM bulletins WHERE id = ?`
 	_, err := db.conn.Exec(query, id)
 	return err
@@ -297,17 +906,13 @@ func (db *DB) DeleteBulletin(id int) error {
 
 // GetBulletinByID retrieves a single bulletin by ID
 func (db *DB) GetBulletinByID(id int) (*Bulletin, error) {
-	query := `SELECT id, title, body, author, created_at, expires_at
+	query := `SELECT ` + bulletinColumns + `
 			  FROM bulletins WHERE id = ?`
 
-	bulletin := &Bulletin{}
-	err := db.conn.QueryRow(query, id).Scan(
-		&bulletin.ID, &bulletin.Title, &bulletin.Body,
-		&bulletin.Author, &bulletin.CreatedAt, &bulletin.ExpiresAt)
-
+	bulletin, err := scanBulletin(db.conn.QueryRow(query, id))
 	if err != nil {
 		return nil, err
 	}
 
-	return bulletin, nil
+	return &bulletin, nil
 }
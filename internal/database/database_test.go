@@ -0,0 +1,171 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInitializeTuning(t *testing.T) {
+	db := newTestDB(t)
+
+	var mode string
+	if err := db.conn.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil || mode != "wal" {
+		t.Errorf("journal_mode = %q, %v; want wal", mode, err)
+	}
+	var timeout int
+	if err := db.conn.QueryRow(`PRAGMA busy_timeout`).Scan(&timeout); err != nil || timeout != busyTimeout {
+		t.Errorf("busy_timeout = %d, %v; want %d", timeout, err, busyTimeout)
+	}
+
+	for _, index := range []string{"idx_messages_to_user", "idx_posts_topic_id"} {
+		var name string
+		err := db.conn.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'index' AND name = ?`, index).Scan(&name)
+		if err != nil {
+			t.Errorf("index %s missing: %v", index, err)
+		}
+	}
+}
+
+func TestReadOnlyMirrorOfWALDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	primary, err := Initialize(path)
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := primary.CreateBulletin(&Bulletin{Title: "Welcome", Body: "Hello", Author: "sysop"}); err != nil {
+		t.Fatalf("CreateBulletin failed: %v", err)
+	}
+	primary.Close()
+
+	mirror, err := InitializeReadOnly(path)
+	if err != nil {
+		t.Fatalf("InitializeReadOnly failed: %v", err)
+	}
+	defer mirror.Close()
+
+	bulletins, err := mirror.GetBulletins(10)
+	if err != nil || len(bulletins) != 1 || bulletins[0].Title != "Welcome" {
+		t.Errorf("mirror bulletins = %+v, %v; want the one written", bulletins, err)
+	}
+	if err := mirror.CreateBulletin(&Bulletin{Title: "Nope", Body: "x", Author: "sysop"}); err == nil {
+		t.Error("mirror accepted a write")
+	}
+}
+
+func TestSizeAndCompact(t *testing.T) {
+	db := newTestDB(t)
+
+	body := strings.Repeat("x", 4096)
+	for i := 0; i < 200; i++ {
+		if err := db.CreateBulletin(&Bulletin{Title: "Filler", Body: body, Author: "sysop"}); err != nil {
+			t.Fatalf("CreateBulletin failed: %v", err)
+		}
+	}
+	if _, err := db.conn.Exec(`DELETE FROM bulletins WHERE title = 'Filler'`); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	before, err := db.Size()
+	if err != nil || before == 0 {
+		t.Fatalf("Size = %d, %v", before, err)
+	}
+	if err := db.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	after, err := db.Size()
+	if err != nil {
+		t.Fatalf("Size failed: %v", err)
+	}
+	if after >= before {
+		t.Errorf("Size after Compact = %d, want less than %d", after, before)
+	}
+}
+
+func TestGetBulletinPage(t *testing.T) {
+	db := newTestDB(t)
+
+	for i := 1; i <= 5; i++ {
+		if err := db.CreateBulletin(&Bulletin{Title: fmt.Sprintf("Bulletin %d", i), Body: "News", Author: "sysop"}); err != nil {
+			t.Fatalf("CreateBulletin failed: %v", err)
+		}
+	}
+	// Expired bulletins aren't listed or counted
+	if _, err := db.conn.Exec(`UPDATE bulletins SET expires_at = ? WHERE title = 'Bulletin 1'`, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	page, total, err := db.GetBulletinPage(3, 0)
+	if err != nil {
+		t.Fatalf("GetBulletinPage failed: %v", err)
+	}
+	if total != 4 || len(page) != 3 || page[0].Title != "Bulletin 5" {
+		t.Errorf("first page = %d bulletins of %d starting %+v; want 3 of 4 starting with the newest", len(page), total, page)
+	}
+
+	page, total, err = db.GetBulletinPage(3, 3)
+	if err != nil || total != 4 || len(page) != 1 || page[0].Title != "Bulletin 2" {
+		t.Errorf("second page = %+v of %d, %v; want Bulletin 2 of 4", page, total, err)
+	}
+
+	page, total, err = db.GetBulletinPage(3, 6)
+	if err != nil || total != 4 || len(page) != 0 {
+		t.Errorf("page past the end = %+v of %d, %v; want none of 4", page, total, err)
+	}
+}
+
+func TestBulletinSchedule(t *testing.T) {
+	db := newTestDB(t)
+
+	now := time.Now()
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+	for _, b := range []Bulletin{
+		{Title: "Posted", Body: "News", Author: "sysop"},
+		{Title: "Scheduled", Body: "News", Author: "sysop", PublishAt: &later},
+		{Title: "Expired", Body: "News", Author: "sysop", ExpiresAt: &earlier},
+	} {
+		if err := db.CreateBulletin(&b); err != nil {
+			t.Fatalf("CreateBulletin failed: %v", err)
+		}
+	}
+
+	current, err := db.GetBulletins(10)
+	if err != nil {
+		t.Fatalf("GetBulletins failed: %v", err)
+	}
+	if len(current) != 1 || current[0].Title != "Posted" {
+		t.Errorf("GetBulletins = %+v, want only the posted bulletin", current)
+	}
+
+	archived, err := db.ArchiveExpiredBulletins(now)
+	if err != nil || archived != 1 {
+		t.Fatalf("ArchiveExpiredBulletins = %d, %v; want 1", archived, err)
+	}
+	all, total, err := db.GetAllBulletins(10, 0)
+	if err != nil || total != 3 {
+		t.Fatalf("GetAllBulletins = %d bulletins, %v; want all 3", total, err)
+	}
+	var expired Bulletin
+	for _, b := range all {
+		if b.Title == "Expired" {
+			expired = b
+		}
+	}
+	if !expired.Archived || expired.Current(now) {
+		t.Errorf("expired bulletin = %+v, want it archived", expired)
+	}
+
+	// Scheduling an archived bulletin again brings it back
+	if err := db.ScheduleBulletin(expired.ID, nil, nil); err != nil {
+		t.Fatalf("ScheduleBulletin failed: %v", err)
+	}
+	restored, err := db.GetBulletinByID(expired.ID)
+	if err != nil || !restored.Current(now) {
+		t.Errorf("rescheduled bulletin = %+v, %v; want it current", restored, err)
+	}
+	if current, _ := db.GetBulletins(10); len(current) != 2 {
+		t.Errorf("GetBulletins after rescheduling = %+v, want 2", current)
+	}
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Where a file's description came from
+const (
+	DescriptionDIZ      = "diz"      // FILE_ID.DIZ or DESC.SDI packed in the archive
+	DescriptionUploader = "uploader" // Typed by the uploader
+)
+
+// SetFileDescription keeps the description of a file in an area, replacing
+// any it had
+func (db *DB) SetFileDescription(area, filename, description, source string) error {
+	_, err := db.conn.Exec(`INSERT INTO file_descriptions (area, filename, description, source, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(area, filename) DO UPDATE SET description = excluded.description,
+			source = excluded.source, updated_at = excluded.updated_at`,
+		area, filename, description, source, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save description: %w", err)
+	}
+	return nil
+}
+
+// FileDescriptions returns the descriptions of an area's files by file name
+func (db *DB) FileDescriptions(area string) (map[string]string, error) {
+	rows, err := db.conn.Query(`SELECT filename, description FROM file_descriptions WHERE area = ?`, area)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load descriptions: %w", err)
+	}
+	defer rows.Close()
+
+	descriptions := make(map[string]string)
+	for rows.Next() {
+		var filename, description string
+		if err := rows.Scan(&filename, &description); err != nil {
+			return nil, fmt.Errorf("failed to load descriptions: %w", err)
+		}
+		descriptions[filename] = description
+	}
+	return descriptions, rows.Err()
+}
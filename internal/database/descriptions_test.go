@@ -0,0 +1,37 @@
+package database
+
+import "testing"
+
+func TestFileDescriptions(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.SetFileDescription("utils", "PKZ204G.EXE", "PKZIP 2.04g", DescriptionUploader); err != nil {
+		t.Fatalf("SetFileDescription failed: %v", err)
+	}
+	if err := db.SetFileDescription("utils", "PKZ204G.EXE", "PKZIP 2.04g\nThe archiver", DescriptionDIZ); err != nil {
+		t.Fatalf("SetFileDescription failed: %v", err)
+	}
+	if err := db.SetFileDescription("games", "PKZ204G.EXE", "Not this one", DescriptionUploader); err != nil {
+		t.Fatalf("SetFileDescription failed: %v", err)
+	}
+
+	descriptions, err := db.FileDescriptions("utils")
+	if err != nil {
+		t.Fatalf("FileDescriptions failed: %v", err)
+	}
+	if len(descriptions) != 1 || descriptions["PKZ204G.EXE"] != "PKZIP 2.04g\nThe archiver" {
+		t.Errorf("FileDescriptions = %q", descriptions)
+	}
+
+	// A rejected upload takes its description with it
+	upload := &PendingUpload{Area: "games", Filename: "PKZ204G.EXE", Uploader: "bob", Bytes: 10}
+	if err := db.AddPendingUpload(upload); err != nil {
+		t.Fatalf("AddPendingUpload failed: %v", err)
+	}
+	if err := db.RejectUpload(upload.ID, "sysop", "Wrong area"); err != nil {
+		t.Fatalf("RejectUpload failed: %v", err)
+	}
+	if descriptions, _ := db.FileDescriptions("games"); len(descriptions) != 0 {
+		t.Errorf("rejected upload kept its description: %q", descriptions)
+	}
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// echomailPrefix marks the Message-IDs of tossed echomail in
+// imported_messages, apart from those of imported mailing lists
+const echomailPrefix = "ftn:"
+
+// Echomail is a local post or reply waiting to be sent to an echo
+type Echomail struct {
+	PostID    int
+	ReplyID   int // 0 for the post itself
+	ParentID  int // Reply answered, 0 for the post
+	Author    string
+	Subject   string // The post's subject, for replies too
+	Body      string
+	CreatedAt time.Time
+}
+
+// LinkEchoArea records that an echo is linked to a topic and returns the
+// last post and reply that existed when it was first linked. Only what is
+// written after that is sent, so linking a topic doesn't flood the echo
+// with its history.
+func (db *DB) LinkEchoArea(tag string) (lastPost, lastReply int, err error) {
+	_, err = db.conn.Exec(`INSERT OR IGNORE INTO echo_areas (tag, last_post_id, last_reply_id, linked_at)
+		VALUES (?, (SELECT COALESCE(MAX(id), 0) FROM posts), (SELECT COALESCE(MAX(id), 0) FROM replies), ?)`,
+		tag, time.Now())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to link echo %s: %w", tag, err)
+	}
+	err = db.conn.QueryRow(`SELECT last_post_id, last_reply_id FROM echo_areas WHERE tag = ?`, tag).
+		Scan(&lastPost, &lastReply)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to link echo %s: %w", tag, err)
+	}
+	return lastPost, lastReply, nil
+}
+
+// GetPendingEchomail returns the posts and replies written on this board in
+// a topic since the IDs given that haven't been sent yet, oldest first.
+// Tossed echomail and imported archives are left out, so nothing goes back
+// to the network it came from.
+func (db *DB) GetPendingEchomail(topicID, afterPost, afterReply int) ([]Echomail, error) {
+	query := `SELECT id, 0, 0, author, subject, body, created_at FROM posts p
+			  WHERE topic_id = ? AND id > ?
+			  AND NOT EXISTS (SELECT 1 FROM imported_messages i WHERE i.post_id = p.id AND i.reply_id IS NULL)
+			  AND NOT EXISTS (SELECT 1 FROM echomail_sent s WHERE s.post_id = p.id AND s.reply_id IS NULL)
+			  UNION ALL
+			  SELECT r.post_id, r.id, COALESCE(r.parent_reply_id, 0), r.author, p.subject, r.body, r.created_at
+			  FROM replies r JOIN posts p ON p.id = r.post_id
+			  WHERE p.topic_id = ? AND r.id > ?
+			  AND NOT EXISTS (SELECT 1 FROM imported_messages i WHERE i.reply_id = r.id)
+			  AND NOT EXISTS (SELECT 1 FROM echomail_sent s WHERE s.reply_id = r.id)
+			  ORDER BY 7, 1, 2`
+
+	rows, err := db.conn.Query(query, topicID, afterPost, topicID, afterReply)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load outbound echomail: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []Echomail
+	for rows.Next() {
+		var e Echomail
+		if err := rows.Scan(&e.PostID, &e.ReplyID, &e.ParentID, &e.Author, &e.Subject, &e.Body, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to load outbound echomail: %w", err)
+		}
+		pending = append(pending, e)
+	}
+	return pending, rows.Err()
+}
+
+// RecordEchomailSent marks posts and replies as sent under their MSGIDs,
+// all at once after the packet holding them has been written
+func (db *DB) RecordEchomailSent(sent map[string]Echomail) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for msgid, e := range sent {
+		var reply interface{}
+		if e.ReplyID != 0 {
+			reply = e.ReplyID
+		}
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO echomail_sent (msgid, post_id, reply_id, sent_at) VALUES (?, ?, ?, ?)`,
+			msgid, e.PostID, reply, now); err != nil {
+			return fmt.Errorf("failed to record sent echomail: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetEchomail finds the post or reply that has an echomail MSGID, whether
+// it was tossed in or sent from here. It returns nil when there isn't one.
+func (db *DB) GetEchomail(msgid string) (*ImportedMessage, error) {
+	if m, err := db.GetImportedMessage(echomailPrefix + msgid); m != nil || err != nil {
+		return m, err
+	}
+
+	var m ImportedMessage
+	var reply sql.NullInt64
+	err := db.conn.QueryRow(`SELECT post_id, reply_id FROM echomail_sent WHERE msgid = ?`, msgid).Scan(&m.PostID, &reply)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up echomail: %w", err)
+	}
+	m.ReplyID = int(reply.Int64)
+	return &m, nil
+}
+
+// GetEchomailID returns the MSGID a post or reply was tossed in or sent
+// with, or "" when it has none. replyID is 0 for the post itself.
+func (db *DB) GetEchomailID(postID, replyID int) (string, error) {
+	var msgid string
+	err := db.conn.QueryRow(`SELECT substr(message_id, ?) FROM imported_messages
+			  WHERE message_id LIKE ? AND post_id = ? AND COALESCE(reply_id, 0) = ?
+			  UNION ALL
+			  SELECT msgid FROM echomail_sent WHERE post_id = ? AND COALESCE(reply_id, 0) = ?
+			  LIMIT 1`,
+		len(echomailPrefix)+1, echomailPrefix+"%", postID, replyID, postID, replyID).Scan(&msgid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up echomail: %w", err)
+	}
+	return msgid, nil
+}
+
+// TossPost adds an echomail message as a post
+func (db *DB) TossPost(post *Post, msgid string) error {
+	return db.ImportPost(post, echomailPrefix+msgid)
+}
+
+// TossReply adds an echomail message as a reply
+func (db *DB) TossReply(reply *Reply, msgid string) error {
+	return db.ImportReply(reply, echomailPrefix+msgid)
+}
@@ -0,0 +1,63 @@
+package database
+
+import "testing"
+
+func TestEchomail(t *testing.T) {
+	db := newTestDB(t)
+
+	topic := &Topic{Name: "Tech"}
+	if err := db.CreateTopic(topic); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	old := &Post{TopicID: topic.ID, Author: "alice", Subject: "Old", Body: "Before linking"}
+	if err := db.CreatePost(old); err != nil {
+		t.Fatal(err)
+	}
+
+	lastPost, lastReply, err := db.LinkEchoArea("TECH")
+	if err != nil || lastPost != old.ID || lastReply != 0 {
+		t.Fatalf("LinkEchoArea = %d, %d, %v; want %d, 0", lastPost, lastReply, err, old.ID)
+	}
+
+	tossed := &Post{TopicID: topic.ID, Author: "echomail", Subject: "Modems", Body: "From: Bob"}
+	if err := db.TossPost(tossed, "1:234/1 00000001"); err != nil {
+		t.Fatalf("TossPost failed: %v", err)
+	}
+	answer := &Reply{PostID: tossed.ID, Author: "alice", Body: "A Hayes"}
+	if err := db.CreateReply(answer); err != nil {
+		t.Fatal(err)
+	}
+
+	// Linking again keeps the first high-water marks
+	if p, r, _ := db.LinkEchoArea("TECH"); p != lastPost || r != lastReply {
+		t.Errorf("LinkEchoArea again = %d, %d; want %d, %d", p, r, lastPost, lastReply)
+	}
+
+	pending, err := db.GetPendingEchomail(topic.ID, lastPost, lastReply)
+	if err != nil {
+		t.Fatalf("GetPendingEchomail failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ReplyID != answer.ID || pending[0].Subject != "Modems" {
+		t.Fatalf("GetPendingEchomail = %+v, want only the local reply", pending)
+	}
+
+	if err := db.RecordEchomailSent(map[string]Echomail{"1:234/5 0000abcd": pending[0]}); err != nil {
+		t.Fatalf("RecordEchomailSent failed: %v", err)
+	}
+	if pending, _ := db.GetPendingEchomail(topic.ID, lastPost, lastReply); len(pending) != 0 {
+		t.Errorf("GetPendingEchomail after sending = %+v", pending)
+	}
+
+	if m, err := db.GetEchomail("1:234/1 00000001"); err != nil || m == nil || m.PostID != tossed.ID || m.ReplyID != 0 {
+		t.Errorf("GetEchomail(tossed) = %+v, %v", m, err)
+	}
+	if m, err := db.GetEchomail("1:234/5 0000abcd"); err != nil || m == nil || m.ReplyID != answer.ID {
+		t.Errorf("GetEchomail(sent) = %+v, %v", m, err)
+	}
+	if id, _ := db.GetEchomailID(tossed.ID, 0); id != "1:234/1 00000001" {
+		t.Errorf("GetEchomailID(tossed) = %q", id)
+	}
+	if id, _ := db.GetEchomailID(old.ID, 0); id != "" {
+		t.Errorf("GetEchomailID(unsent) = %q", id)
+	}
+}
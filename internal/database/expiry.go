@@ -0,0 +1,138 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Subscription is an account and when it expires
+type Subscription struct {
+	UserID      int
+	Username    string
+	AccessLevel int
+	ExpiresAt   *time.Time // nil if the account never expires
+}
+
+// GetExpiry returns when a user's account expires, or nil if it never does
+func (db *DB) GetExpiry(userID int) (*time.Time, error) {
+	var expiresAt sql.NullTime
+	if err := db.conn.QueryRow(`SELECT expires_at FROM users WHERE id = ?`, userID).Scan(&expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to get account expiry: %w", err)
+	}
+	if !expiresAt.Valid {
+		return nil, nil
+	}
+	return &expiresAt.Time, nil
+}
+
+// ErrAccountExpired is returned by CheckExpiry for an account whose expiry
+// date has passed
+var ErrAccountExpired = errors.New("account expired")
+
+// CheckExpiry returns when user's account expires, or nil if it never does,
+// with ErrAccountExpired if that is no later than now. Every way onto the
+// board checks it once a caller has proved who they are: logging in, SSH
+// commands and API tokens. Sysops never expire, so an expiry set by mistake
+// can't lock the board's owner out.
+func (db *DB) CheckExpiry(user *User, now time.Time) (*time.Time, error) {
+	if user.AccessLevel >= 255 {
+		return nil, nil
+	}
+	expires, err := db.GetExpiry(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if expires != nil && !expires.After(now) {
+		return expires, ErrAccountExpired
+	}
+	return expires, nil
+}
+
+// SetExpiry sets when a user's account expires; nil means never
+func (db *DB) SetExpiry(userID int, expiresAt *time.Time) error {
+	var value interface{}
+	if expiresAt != nil {
+		value = *expiresAt
+	}
+	if _, err := db.conn.Exec(`UPDATE users SET expires_at = ? WHERE id = ?`, value, userID); err != nil {
+		return fmt.Errorf("failed to set account expiry: %w", err)
+	}
+	return nil
+}
+
+// GetSubscriptions returns the active accounts below sysop level, those
+// expiring soonest first and those that never expire last
+func (db *DB) GetSubscriptions() ([]Subscription, error) {
+	query := `SELECT id, username, access_level, expires_at FROM users
+			  WHERE is_active = 1 AND access_level < 255
+			  ORDER BY expires_at IS NULL, expires_at, username`
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		var sub Subscription
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&sub.UserID, &sub.Username, &sub.AccessLevel, &expiresAt); err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			sub.ExpiresAt = &expiresAt.Time
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// ExtendSubscriptions adds days to every expiring account at an access
+// level, counting from now for those that have already expired, and
+// returns how many accounts were extended. Accounts that never expire are
+// left alone.
+func (db *DB) ExtendSubscriptions(accessLevel, days int, now time.Time) (int64, error) {
+	subs, err := db.GetSubscriptions()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var extended int64
+	for _, sub := range subs {
+		if sub.AccessLevel != accessLevel || sub.ExpiresAt == nil {
+			continue
+		}
+		from := *sub.ExpiresAt
+		if from.Before(now) {
+			from = now
+		}
+		if _, err := tx.Exec(`UPDATE users SET expires_at = ? WHERE id = ?`, from.AddDate(0, 0, days), sub.UserID); err != nil {
+			return 0, fmt.Errorf("failed to extend %s: %w", sub.Username, err)
+		}
+		extended++
+	}
+	return extended, tx.Commit()
+}
+
+// ExpireSubscriptions sets every active account at an access level to
+// expire at the given time, including those that never expired before,
+// and returns how many accounts were changed. Sysop accounts can't be
+// given an expiry this way.
+func (db *DB) ExpireSubscriptions(accessLevel int, at time.Time) (int64, error) {
+	if accessLevel >= 255 {
+		return 0, fmt.Errorf("sysop accounts can't be expired")
+	}
+	result, err := db.conn.Exec(`UPDATE users SET expires_at = ? WHERE access_level = ? AND is_active = 1`, at, accessLevel)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire accounts: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscriptions(t *testing.T) {
+	db := newTestDB(t)
+	for _, u := range []User{
+		{Username: "alice", AccessLevel: 10},
+		{Username: "bob", AccessLevel: 10},
+		{Username: "carol", AccessLevel: 20},
+		{Username: "sysop", AccessLevel: 255},
+	} {
+		u.Password = "secret"
+		if err := db.CreateUser(&u); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	user := func(name string) *User {
+		u, err := db.GetUser(name)
+		if err != nil {
+			t.Fatalf("GetUser(%s) failed: %v", name, err)
+		}
+		return u
+	}
+	alice, bob, carol := user("alice"), user("bob"), user("carol")
+
+	if expires, err := db.GetExpiry(alice.ID); err != nil || expires != nil {
+		t.Fatalf("GetExpiry of a new account = %v, %v; want never", expires, err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	lapsed := now.AddDate(0, 0, -5)
+	if err := db.SetExpiry(alice.ID, &lapsed); err != nil {
+		t.Fatalf("SetExpiry failed: %v", err)
+	}
+	soon := now.AddDate(0, 0, 3)
+	if err := db.SetExpiry(carol.ID, &soon); err != nil {
+		t.Fatalf("SetExpiry failed: %v", err)
+	}
+
+	subs, err := db.GetSubscriptions()
+	if err != nil {
+		t.Fatalf("GetSubscriptions failed: %v", err)
+	}
+	if len(subs) != 3 || subs[0].Username != "alice" || subs[1].Username != "carol" || subs[2].ExpiresAt != nil {
+		t.Errorf("GetSubscriptions = %+v, want alice, carol, then bob who never expires", subs)
+	}
+
+	// Extending a level counts from now for lapsed accounts and skips bob
+	if n, err := db.ExtendSubscriptions(10, 30, now); err != nil || n != 1 {
+		t.Fatalf("ExtendSubscriptions = %d, %v; want 1", n, err)
+	}
+	if expires, _ := db.GetExpiry(alice.ID); expires == nil || !expires.Equal(now.AddDate(0, 0, 30)) {
+		t.Errorf("alice expires %v, want %v", expires, now.AddDate(0, 0, 30))
+	}
+	if expires, _ := db.GetExpiry(bob.ID); expires != nil {
+		t.Errorf("bob expires %v, want never", expires)
+	}
+
+	if n, err := db.ExpireSubscriptions(10, now); err != nil || n != 2 {
+		t.Fatalf("ExpireSubscriptions = %d, %v; want 2", n, err)
+	}
+	if expires, _ := db.GetExpiry(bob.ID); expires == nil || !expires.Equal(now) {
+		t.Errorf("bob expires %v, want %v", expires, now)
+	}
+	if _, err := db.ExpireSubscriptions(255, now); err == nil {
+		t.Error("ExpireSubscriptions of sysops succeeded, want an error")
+	}
+
+	if err := db.SetExpiry(bob.ID, nil); err != nil {
+		t.Fatalf("SetExpiry failed: %v", err)
+	}
+	if expires, _ := db.GetExpiry(bob.ID); expires != nil {
+		t.Errorf("bob expires %v after clearing, want never", expires)
+	}
+}
+
+func TestCheckExpiry(t *testing.T) {
+	db := newTestDB(t)
+	for _, u := range []User{
+		{Username: "alice", AccessLevel: 10},
+		{Username: "sysop", AccessLevel: 255},
+	} {
+		u.Password = "secret"
+		if err := db.CreateUser(&u); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	alice, _ := db.GetUser("alice")
+	sysop, _ := db.GetUser("sysop")
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if expires, err := db.CheckExpiry(alice, now); err != nil || expires != nil {
+		t.Errorf("CheckExpiry of an account that never expires = %v, %v", expires, err)
+	}
+
+	tomorrow := now.AddDate(0, 0, 1)
+	db.SetExpiry(alice.ID, &tomorrow)
+	if expires, err := db.CheckExpiry(alice, now); err != nil || expires == nil || !expires.Equal(tomorrow) {
+		t.Errorf("CheckExpiry before the expiry = %v, %v; want %v", expires, err, tomorrow)
+	}
+	if _, err := db.CheckExpiry(alice, tomorrow); err != ErrAccountExpired {
+		t.Errorf("CheckExpiry on the day = %v, want ErrAccountExpired", err)
+	}
+
+	db.SetExpiry(sysop.ID, &tomorrow)
+	if _, err := db.CheckExpiry(sysop, tomorrow.AddDate(1, 0, 0)); err != nil {
+		t.Errorf("a sysop's account expired: %v", err)
+	}
+}
+
+func TestAuthenticateAPIToken_Expired(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", AccessLevel: 10}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	alice, _ := db.GetUser("alice")
+	token, err := db.CreateAPIToken(alice.ID, "scripts")
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+	if _, err := db.AuthenticateAPIToken(token); err != nil {
+		t.Fatalf("AuthenticateAPIToken failed: %v", err)
+	}
+
+	lapsed := time.Now().Add(-time.Hour)
+	db.SetExpiry(alice.ID, &lapsed)
+	if user, err := db.AuthenticateAPIToken(token); err == nil {
+		t.Errorf("an expired account's token authenticated %s", user.Username)
+	}
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Feedback is a note for the sysop left by a caller without an account
+type Feedback struct {
+	ID        int
+	Name      string // Whatever the caller gave, may be empty
+	Contact   string // How to reach them, may be empty
+	Message   string
+	Address   string // Where the caller connected from
+	CreatedAt time.Time
+	IsRead    bool
+}
+
+// AddFeedback stores a note in the feedback queue
+func (db *DB) AddFeedback(f *Feedback) error {
+	result, err := db.conn.Exec(`INSERT INTO feedback (name, contact, message, address, created_at) VALUES (?, ?, ?, ?, ?)`,
+		f.Name, f.Contact, f.Message, f.Address, f.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	f.ID = int(id)
+	return nil
+}
+
+// CountFeedbackSince counts the notes left since a time, from one address
+// or, if address is empty, from everyone
+func (db *DB) CountFeedbackSince(address string, since time.Time) (int, error) {
+	var count int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM feedback WHERE created_at >= ? AND (? = '' OR address = ?)`,
+		since, address, address).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count feedback: %w", err)
+	}
+	return count, nil
+}
+
+// GetFeedback returns the feedback queue, newest first
+func (db *DB) GetFeedback() ([]Feedback, error) {
+	rows, err := db.conn.Query(`SELECT id, name, contact, message, address, created_at, is_read
+		FROM feedback ORDER BY created_at DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []Feedback
+	for rows.Next() {
+		var f Feedback
+		if err := rows.Scan(&f.ID, &f.Name, &f.Contact, &f.Message, &f.Address, &f.CreatedAt, &f.IsRead); err != nil {
+			return nil, err
+		}
+		notes = append(notes, f)
+	}
+	return notes, rows.Err()
+}
+
+// MarkFeedbackRead marks a note as read
+func (db *DB) MarkFeedbackRead(id int) error {
+	if _, err := db.conn.Exec(`UPDATE feedback SET is_read = 1 WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to mark feedback read: %w", err)
+	}
+	return nil
+}
+
+// DeleteFeedback removes a note from the queue
+func (db *DB) DeleteFeedback(id int) error {
+	result, err := db.conn.Exec(`DELETE FROM feedback WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete feedback: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("no feedback with id %d", id)
+	}
+	return nil
+}
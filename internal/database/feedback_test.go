@@ -0,0 +1,49 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFeedbackQueue(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	notes := []*Feedback{
+		{Name: "Erin", Message: "Registration says my email is invalid", Address: "192.0.2.1", CreatedAt: now.Add(-2 * time.Hour)},
+		{Message: "Is the board still running?", Address: "192.0.2.1", CreatedAt: now.Add(-10 * time.Minute)},
+		{Contact: "frank@example.com", Message: "Please open registration", Address: "192.0.2.2", CreatedAt: now},
+	}
+	for _, f := range notes {
+		if err := db.AddFeedback(f); err != nil {
+			t.Fatalf("AddFeedback failed: %v", err)
+		}
+	}
+
+	hourAgo := now.Add(-time.Hour)
+	if n, err := db.CountFeedbackSince("192.0.2.1", hourAgo); err != nil || n != 1 {
+		t.Errorf("count from 192.0.2.1 = %d, %v; want 1", n, err)
+	}
+	if n, err := db.CountFeedbackSince("", hourAgo); err != nil || n != 2 {
+		t.Errorf("count from everyone = %d, %v; want 2", n, err)
+	}
+
+	queue, err := db.GetFeedback()
+	if err != nil {
+		t.Fatalf("GetFeedback failed: %v", err)
+	}
+	if len(queue) != 3 || queue[0].Contact != "frank@example.com" {
+		t.Fatalf("expected newest note first, got %+v", queue)
+	}
+
+	if err := db.MarkFeedbackRead(queue[0].ID); err != nil {
+		t.Fatalf("MarkFeedbackRead failed: %v", err)
+	}
+	if err := db.DeleteFeedback(queue[2].ID); err != nil {
+		t.Fatalf("DeleteFeedback failed: %v", err)
+	}
+	queue, _ = db.GetFeedback()
+	if len(queue) != 2 || !queue[0].IsRead || queue[1].IsRead {
+		t.Errorf("unexpected queue after read and delete: %+v", queue)
+	}
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// health tracks whether the database file is currently usable
+type health struct {
+	mu        sync.RWMutex
+	healthy   bool
+	lastError error
+	since     time.Time
+}
+
+// Healthy reports whether the last health check succeeded
+func (db *DB) Healthy() bool {
+	db.health.mu.RLock()
+	defer db.health.mu.RUnlock()
+	return db.health.healthy
+}
+
+// LastError returns the error that marked the database unhealthy, if any
+func (db *DB) LastError() error {
+	db.health.mu.RLock()
+	defer db.health.mu.RUnlock()
+	return db.health.lastError
+}
+
+// IsUnavailableError reports whether err means the database file itself is
+// unusable (missing, unwritable, corrupt, or out of space) rather than a
+// problem with a single query. The health monitor and modules reporting a
+// failed command use it to tell an outage from a passing error.
+func IsUnavailableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code {
+		case sqlite3.ErrReadonly, sqlite3.ErrIoErr, sqlite3.ErrCorrupt,
+			sqlite3.ErrFull, sqlite3.ErrCantOpen, sqlite3.ErrNotADB:
+			return true
+		}
+	}
+	return errors.Is(err, errDatabaseMissing)
+}
+
+// IsDriverError reports whether err came from SQLite, whose wording means
+// nothing to callers, rather than from the board's own checks
+func IsDriverError(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr)
+}
+
+// errDatabaseMissing is reported when the database file has disappeared from disk
+var errDatabaseMissing = errors.New("database file is missing")
+
+// CheckHealth probes the database and updates the health state.
+// Writable databases are probed with a small write so that a file that has
+// become read-only or full is detected before a caller trips over it.
+func (db *DB) CheckHealth() error {
+	err := db.probe()
+	db.setHealth(err)
+	return err
+}
+
+// probe performs the actual health check
+func (db *DB) probe() error {
+	if db.path != "" {
+		if _, err := os.Stat(db.path); err != nil {
+			return fmt.Errorf("%w: %v", errDatabaseMissing, err)
+		}
+	}
+
+	if db.readOnly {
+		var count int
+		return db.conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master`).Scan(&count)
+	}
+
+	_, err := db.conn.Exec(`INSERT OR REPLACE INTO health_probe (id, checked_at) VALUES (1, ?)`, time.Now())
+	return err
+}
+
+// setHealth records a probe result and logs transitions. Only an error
+// that means the file itself is unusable marks the database unavailable; a
+// busy or locked database, as WAL mode sees under load, is still there and
+// sessions carry on.
+func (db *DB) setHealth(err error) {
+	if err != nil && !IsUnavailableError(err) {
+		log.Printf("Database health probe failed, still treating it as available: %v", err)
+		return
+	}
+
+	db.health.mu.Lock()
+	defer db.health.mu.Unlock()
+
+	wasHealthy := db.health.healthy
+	db.health.healthy = err == nil
+	db.health.lastError = err
+
+	switch {
+	case wasHealthy && err != nil:
+		db.health.since = time.Now()
+		log.Printf("ALERT: database unavailable, sessions will see a maintenance screen: %v", err)
+	case !wasHealthy && err == nil:
+		log.Printf("Database available again after %s", time.Since(db.health.since).Round(time.Second))
+	}
+}
+
+// StartHealthMonitor periodically probes the database. While the database is
+// unhealthy, pooled connections are recycled before each probe so a restored
+// file is picked up without restarting the server.
+func (db *DB) StartHealthMonitor(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if !db.Healthy() {
+				db.recycleConnections()
+			}
+			db.CheckHealth()
+		}
+	}()
+}
+
+// recycleConnections closes idle pooled connections so the next query reopens the file
+func (db *DB) recycleConnections() {
+	db.conn.SetMaxIdleConns(0)
+	db.conn.SetMaxIdleConns(2)
+}
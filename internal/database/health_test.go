@@ -0,0 +1,50 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestIsUnavailableError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("token not found"), false},
+		{sqlite3.Error{Code: sqlite3.ErrBusy}, false},
+		{sqlite3.Error{Code: sqlite3.ErrLocked}, false},
+		{sqlite3.Error{Code: sqlite3.ErrConstraint}, false},
+		{fmt.Errorf("failed to save: %w", sqlite3.Error{Code: sqlite3.ErrIoErr}), true},
+		{sqlite3.Error{Code: sqlite3.ErrCorrupt}, true},
+		{sqlite3.Error{Code: sqlite3.ErrFull}, true},
+		{sqlite3.Error{Code: sqlite3.ErrCantOpen}, true},
+		{fmt.Errorf("%w: gone", errDatabaseMissing), true},
+	}
+	for _, tt := range tests {
+		if got := IsUnavailableError(tt.err); got != tt.want {
+			t.Errorf("IsUnavailableError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSetHealthIgnoresBusy(t *testing.T) {
+	db := newTestDB(t)
+
+	db.setHealth(sqlite3.Error{Code: sqlite3.ErrBusy})
+	if !db.Healthy() {
+		t.Error("a busy database was marked unavailable")
+	}
+
+	db.setHealth(sqlite3.Error{Code: sqlite3.ErrIoErr})
+	if db.Healthy() {
+		t.Error("an I/O error didn't mark the database unavailable")
+	}
+	db.setHealth(nil)
+	if !db.Healthy() || db.LastError() != nil {
+		t.Error("a good probe didn't mark the database available again")
+	}
+}
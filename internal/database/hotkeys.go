@@ -0,0 +1,31 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetUnreadHotkey returns the key a user presses to jump to their next
+// unread item, or "" if they have never changed the board's default
+func (db *DB) GetUnreadHotkey(userID int) (string, error) {
+	var key string
+	err := db.conn.QueryRow(`SELECT unread FROM user_hotkeys WHERE user_id = ?`, userID).Scan(&key)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get hotkeys: %w", err)
+	}
+	return key, nil
+}
+
+// SaveUnreadHotkey stores the key a user presses to jump to their next
+// unread item
+func (db *DB) SaveUnreadHotkey(userID int, key string) error {
+	_, err := db.conn.Exec(`INSERT INTO user_hotkeys (user_id, unread) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET unread = excluded.unread`, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to save hotkeys: %w", err)
+	}
+	return nil
+}
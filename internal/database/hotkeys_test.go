@@ -0,0 +1,34 @@
+package database
+
+import "testing"
+
+func TestUnreadHotkey(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", IsActive: true}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := db.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+
+	if key, err := db.GetUnreadHotkey(user.ID); err != nil || key != "" {
+		t.Fatalf("hotkey before saving = %q, %v; want none", key, err)
+	}
+	for _, key := range []string{"ctrl+u", "off"} {
+		if err := db.SaveUnreadHotkey(user.ID, key); err != nil {
+			t.Fatalf("SaveUnreadHotkey failed: %v", err)
+		}
+	}
+	if key, err := db.GetUnreadHotkey(user.ID); err != nil || key != "off" {
+		t.Errorf("hotkey = %q, %v; want off", key, err)
+	}
+
+	// Deleting the account removes the hotkey with it
+	if err := db.DeleteAccount(user.ID); err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+	if key, err := db.GetUnreadHotkey(user.ID); err != nil || key != "" {
+		t.Errorf("hotkey after deletion = %q, %v; want none", key, err)
+	}
+}
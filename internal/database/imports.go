@@ -0,0 +1,123 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ImportedMessage is where a message brought in from a mailing list archive
+// ended up on the board
+type ImportedMessage struct {
+	PostID  int
+	ReplyID int // 0 when the message started its own post
+}
+
+// GetImportedMessage looks up an archived message by its Message-ID. It
+// returns nil when the message hasn't been imported.
+func (db *DB) GetImportedMessage(messageID string) (*ImportedMessage, error) {
+	var m ImportedMessage
+	var reply sql.NullInt64
+	err := db.conn.QueryRow(`SELECT post_id, reply_id FROM imported_messages WHERE message_id = ?`, messageID).
+		Scan(&m.PostID, &reply)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up imported message: %w", err)
+	}
+	m.ReplyID = int(reply.Int64)
+	return &m, nil
+}
+
+// ImportPost adds an archived message as a post, keeping the date it was
+// sent, and records its Message-ID so it isn't imported twice
+func (db *DB) ImportPost(post *Post, messageID string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO posts (topic_id, author, subject, body, created_at) VALUES (?, ?, ?, ?, ?)`,
+		post.TopicID, post.Author, post.Subject, post.Body, post.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to import post: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO imported_messages (message_id, post_id, imported_at) VALUES (?, ?, ?)`,
+		messageID, id, time.Now()); err != nil {
+		return fmt.Errorf("failed to record imported message: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	post.ID = int(id)
+	return nil
+}
+
+// ImportReply adds an archived message as a reply, keeping the date it was
+// sent, and records its Message-ID so it isn't imported twice
+func (db *DB) ImportReply(reply *Reply, messageID string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parent interface{}
+	if reply.ParentID != 0 {
+		parent = reply.ParentID
+	}
+	result, err := tx.Exec(`INSERT INTO replies (post_id, parent_reply_id, author, body, created_at) VALUES (?, ?, ?, ?, ?)`,
+		reply.PostID, parent, reply.Author, reply.Body, reply.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to import reply: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO imported_messages (message_id, post_id, reply_id, imported_at) VALUES (?, ?, ?, ?)`,
+		messageID, reply.PostID, id, time.Now()); err != nil {
+		return fmt.Errorf("failed to record imported message: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	reply.ID = int(id)
+	return nil
+}
+
+// EnsurePlaceholderUser creates a disabled account to hold a name that
+// imported posts are credited to, so no caller can register it. An
+// existing account of that name is left as it is.
+func (db *DB) EnsurePlaceholderUser(username, realName string) error {
+	exists, err := db.userExists(username)
+	if err != nil || exists {
+		return err
+	}
+
+	// Nobody is meant to log in, so the password is random and thrown away
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	password, err := HashPassword(hex.EncodeToString(secret))
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	_, err = db.conn.Exec(`INSERT INTO users (username, password, real_name, email, access_level, created_at, is_active)
+		VALUES (?, ?, ?, '', 0, ?, 0)`, username, password, realName, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to create placeholder user: %w", err)
+	}
+	return nil
+}
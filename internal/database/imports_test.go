@@ -0,0 +1,68 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestImportedMessages(t *testing.T) {
+	db := newTestDB(t)
+
+	topic := &Topic{Name: "List"}
+	if err := db.CreateTopic(topic); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+
+	if m, err := db.GetImportedMessage("1@example.com"); err != nil || m != nil {
+		t.Fatalf("GetImportedMessage before import = %+v, %v; want nil", m, err)
+	}
+
+	sent := time.Date(1998, 3, 14, 9, 30, 0, 0, time.UTC)
+	post := &Post{TopicID: topic.ID, Author: "archive", Subject: "Hello", Body: "Old news", CreatedAt: sent}
+	if err := db.ImportPost(post, "1@example.com"); err != nil {
+		t.Fatalf("ImportPost failed: %v", err)
+	}
+	reply := &Reply{PostID: post.ID, Author: "archive", Body: "Reply", CreatedAt: sent.Add(time.Hour)}
+	if err := db.ImportReply(reply, "2@example.com"); err != nil {
+		t.Fatalf("ImportReply failed: %v", err)
+	}
+
+	got, err := db.GetPost(post.ID)
+	if err != nil || !got.CreatedAt.Equal(sent) {
+		t.Errorf("imported post dated %v (%v), want %v", got.CreatedAt, err, sent)
+	}
+	if m, err := db.GetImportedMessage("2@example.com"); err != nil || m == nil || m.PostID != post.ID || m.ReplyID != reply.ID {
+		t.Errorf("GetImportedMessage = %+v, %v; want reply %d in post %d", m, err, reply.ID, post.ID)
+	}
+	if err := db.ImportPost(&Post{TopicID: topic.ID, Author: "archive", Subject: "Again"}, "1@example.com"); err == nil {
+		t.Error("imported the same Message-ID twice")
+	}
+	if posts, _ := db.GetPosts(topic.ID, 10); len(posts) != 1 {
+		t.Errorf("topic has %d posts after the failed import, want 1", len(posts))
+	}
+}
+
+func TestEnsurePlaceholderUser(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.EnsurePlaceholderUser("archive", "Mailing list archive"); err != nil {
+		t.Fatalf("EnsurePlaceholderUser failed: %v", err)
+	}
+	if exists, _ := db.UsernameExists("archive"); !exists {
+		t.Fatal("placeholder account wasn't created")
+	}
+	if _, err := db.GetUser("archive"); err == nil {
+		t.Error("placeholder account is active")
+	}
+
+	// An existing account is left as it is
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.EnsurePlaceholderUser("alice", "Mailing list archive"); err != nil {
+		t.Fatalf("EnsurePlaceholderUser failed: %v", err)
+	}
+	if _, err := db.VerifyPassword("alice", "secret"); err != nil {
+		t.Errorf("existing account changed: %v", err)
+	}
+}
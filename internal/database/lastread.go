@@ -0,0 +1,155 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// BulletinsArea is the last-read area for system bulletins
+const BulletinsArea = "bulletins"
+
+// topicAreaPrefix starts the last-read area of each message board topic
+const topicAreaPrefix = "topic:"
+
+// TopicArea returns the last-read area for a message board topic
+func TopicArea(topicID int) string {
+	return topicAreaPrefix + strconv.Itoa(topicID)
+}
+
+// GetLastRead returns the highest bulletin or post ID a user has read in
+// each area. Areas they have never read are missing, so everything in them
+// is new.
+func (db *DB) GetLastRead(userID int) (map[string]int, error) {
+	rows, err := db.conn.Query(`SELECT area, last_read_id FROM user_lastread WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last read: %w", err)
+	}
+	defer rows.Close()
+
+	lastRead := make(map[string]int)
+	for rows.Next() {
+		var area string
+		var id int
+		if err := rows.Scan(&area, &id); err != nil {
+			return nil, fmt.Errorf("failed to get last read: %w", err)
+		}
+		lastRead[area] = id
+	}
+	return lastRead, rows.Err()
+}
+
+// MarkRead moves a user's last-read pointer for an area up to id. Reading an
+// older item doesn't move it back.
+func (db *DB) MarkRead(userID int, area string, id int) error {
+	_, err := db.conn.Exec(`INSERT INTO user_lastread (user_id, area, last_read_id) VALUES (?, ?, ?)
+		ON CONFLICT(user_id, area) DO UPDATE SET last_read_id = MAX(last_read_id, excluded.last_read_id)`,
+		userID, area, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark read: %w", err)
+	}
+	return nil
+}
+
+// GetNewPostCounts returns how many posts in each topic visible at
+// accessLevel a user hasn't read, leaving out topics with none
+func (db *DB) GetNewPostCounts(userID, accessLevel int) (map[int]int, error) {
+	query := `SELECT p.topic_id, COUNT(*) FROM posts p
+			  JOIN topics t ON t.id = p.topic_id
+			  LEFT JOIN user_lastread l ON l.user_id = ? AND l.area = ? || p.topic_id
+			  WHERE t.access_level <= ? AND p.id > COALESCE(l.last_read_id, 0)
+			  GROUP BY p.topic_id`
+
+	rows, err := db.conn.Query(query, userID, topicAreaPrefix, accessLevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count new posts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int)
+	for rows.Next() {
+		var topicID, count int
+		if err := rows.Scan(&topicID, &count); err != nil {
+			return nil, fmt.Errorf("failed to count new posts: %w", err)
+		}
+		counts[topicID] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetPostsAfter returns up to limit posts in a topic with IDs above afterID,
+// oldest first, for reading new posts in order
+func (db *DB) GetPostsAfter(topicID, afterID, limit int) ([]Post, error) {
+	query := `SELECT p.id, p.topic_id, p.author, p.subject, p.body, p.created_at,
+			  (SELECT COUNT(*) FROM replies r WHERE r.post_id = p.id)
+			  FROM posts p WHERE p.topic_id = ? AND p.id > ? ORDER BY p.id LIMIT ?`
+
+	rows, err := db.conn.Query(query, topicID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get new posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []Post
+	for rows.Next() {
+		var post Post
+		err := rows.Scan(&post.ID, &post.TopicID, &post.Author, &post.Subject, &post.Body,
+			&post.CreatedAt, &post.ReplyCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get new posts: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+// Kinds of unread item NextUnread finds
+const (
+	UnreadMail     = "mail"
+	UnreadBulletin = "bulletin"
+	UnreadPost     = "post"
+)
+
+// Unread is the next thing a user hasn't read
+type Unread struct {
+	Kind string // UnreadMail, UnreadBulletin or UnreadPost
+	ID   int
+}
+
+// NextUnread returns the next item a user hasn't read, or nil when they are
+// caught up. Unread mail comes first, oldest first, then current bulletins
+// past their bulletins pointer, then posts past their pointer in each topic
+// visible at accessLevel, in topic order.
+func (db *DB) NextUnread(userID int, username string, accessLevel int) (*Unread, error) {
+	now := time.Now()
+	queries := []struct {
+		kind  string
+		query string
+		args  []interface{}
+	}{
+		{UnreadMail, `SELECT id FROM messages WHERE to_user = ? AND is_read = 0 AND deleted_at IS NULL
+			ORDER BY created_at, id LIMIT 1`, []interface{}{username}},
+		{UnreadBulletin, `SELECT b.id FROM bulletins b
+			LEFT JOIN user_lastread l ON l.user_id = ? AND l.area = ?
+			WHERE ` + currentBulletin + ` AND b.id > COALESCE(l.last_read_id, 0)
+			ORDER BY b.id LIMIT 1`, []interface{}{userID, BulletinsArea, now, now}},
+		{UnreadPost, `SELECT p.id FROM posts p
+			JOIN topics t ON t.id = p.topic_id
+			LEFT JOIN user_lastread l ON l.user_id = ? AND l.area = ? || p.topic_id
+			WHERE t.access_level <= ? AND p.id > COALESCE(l.last_read_id, 0)
+			ORDER BY p.topic_id, p.id LIMIT 1`, []interface{}{userID, topicAreaPrefix, accessLevel}},
+	}
+	for _, q := range queries {
+		var id int
+		err := db.conn.QueryRow(q.query, q.args...).Scan(&id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find unread %s: %w", q.kind, err)
+		}
+		return &Unread{Kind: q.kind, ID: id}, nil
+	}
+	return nil, nil
+}
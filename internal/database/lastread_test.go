@@ -0,0 +1,148 @@
+package database
+
+import "testing"
+
+func TestLastRead(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", IsActive: true}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := db.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+
+	general := &Topic{Name: "General"}
+	staff := &Topic{Name: "Staff", AccessLevel: 100}
+	for _, topic := range []*Topic{general, staff} {
+		if err := db.CreateTopic(topic); err != nil {
+			t.Fatalf("CreateTopic failed: %v", err)
+		}
+	}
+	var posts []*Post
+	for _, subject := range []string{"One", "Two", "Three"} {
+		post := &Post{TopicID: general.ID, Author: "bob", Subject: subject, Body: "..."}
+		if err := db.CreatePost(post); err != nil {
+			t.Fatalf("CreatePost failed: %v", err)
+		}
+		posts = append(posts, post)
+	}
+	if err := db.CreatePost(&Post{TopicID: staff.ID, Author: "sysop", Subject: "Secret", Body: "..."}); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+
+	counts, err := db.GetNewPostCounts(user.ID, 10)
+	if err != nil {
+		t.Fatalf("GetNewPostCounts failed: %v", err)
+	}
+	if len(counts) != 1 || counts[general.ID] != 3 {
+		t.Errorf("new counts = %v, want 3 in General only", counts)
+	}
+
+	// Reading the second post leaves only the third new, and reading the
+	// first afterwards doesn't move the pointer back
+	if err := db.MarkRead(user.ID, TopicArea(general.ID), posts[1].ID); err != nil {
+		t.Fatalf("MarkRead failed: %v", err)
+	}
+	if err := db.MarkRead(user.ID, TopicArea(general.ID), posts[0].ID); err != nil {
+		t.Fatalf("MarkRead failed: %v", err)
+	}
+	lastRead, err := db.GetLastRead(user.ID)
+	if err != nil {
+		t.Fatalf("GetLastRead failed: %v", err)
+	}
+	if lastRead[TopicArea(general.ID)] != posts[1].ID {
+		t.Errorf("last read = %v, want post %d", lastRead, posts[1].ID)
+	}
+
+	newPosts, err := db.GetPostsAfter(general.ID, lastRead[TopicArea(general.ID)], 10)
+	if err != nil {
+		t.Fatalf("GetPostsAfter failed: %v", err)
+	}
+	if len(newPosts) != 1 || newPosts[0].Subject != "Three" {
+		t.Errorf("new posts = %+v, want just Three", newPosts)
+	}
+	if counts, _ := db.GetNewPostCounts(user.ID, 10); counts[general.ID] != 1 {
+		t.Errorf("new in General = %d, want 1", counts[general.ID])
+	}
+
+	// Deleting the account removes the pointers with it
+	if err := db.DeleteAccount(user.ID); err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+	if lastRead, err := db.GetLastRead(user.ID); err != nil || len(lastRead) != 0 {
+		t.Errorf("last read after deletion = %v, %v; want none", lastRead, err)
+	}
+}
+
+func TestNextUnread(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", IsActive: true}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := db.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	next := func() *Unread {
+		t.Helper()
+		u, err := db.NextUnread(user.ID, user.Username, 10)
+		if err != nil {
+			t.Fatalf("NextUnread failed: %v", err)
+		}
+		return u
+	}
+
+	if u := next(); u != nil {
+		t.Fatalf("next unread on an empty board = %+v, want nil", u)
+	}
+
+	staff := &Topic{Name: "Staff", AccessLevel: 100}
+	general := &Topic{Name: "General"}
+	for _, topic := range []*Topic{staff, general} {
+		if err := db.CreateTopic(topic); err != nil {
+			t.Fatalf("CreateTopic failed: %v", err)
+		}
+	}
+	if err := db.CreatePost(&Post{TopicID: staff.ID, Author: "sysop", Subject: "Secret", Body: "..."}); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	post := &Post{TopicID: general.ID, Author: "bob", Subject: "Hello", Body: "..."}
+	if err := db.CreatePost(post); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	bulletin := &Bulletin{Title: "News", Body: "...", Author: "sysop"}
+	if err := db.CreateBulletin(bulletin); err != nil {
+		t.Fatalf("CreateBulletin failed: %v", err)
+	}
+	if err := db.CreateMessage(&Message{FromUser: "bob", ToUser: "alice", Subject: "Hi", Body: "...", Area: "general"}); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	mail, err := db.GetMessages("alice", 10)
+	if err != nil || len(mail) != 1 {
+		t.Fatalf("GetMessages = %v, %v; want one message", mail, err)
+	}
+
+	// Mail comes first, then bulletins, then posts the caller may read
+	want := []Unread{{UnreadMail, mail[0].ID}, {UnreadBulletin, bulletin.ID}, {UnreadPost, post.ID}}
+	for _, w := range want {
+		u := next()
+		if u == nil || *u != w {
+			t.Fatalf("next unread = %+v, want %+v", u, w)
+		}
+		switch w.Kind {
+		case UnreadMail:
+			err = db.UpdateMessageRead("alice", w.ID, true)
+		case UnreadBulletin:
+			err = db.MarkRead(user.ID, BulletinsArea, w.ID)
+		case UnreadPost:
+			err = db.MarkRead(user.ID, TopicArea(general.ID), w.ID)
+		}
+		if err != nil {
+			t.Fatalf("marking %+v read failed: %v", w, err)
+		}
+	}
+	if u := next(); u != nil {
+		t.Errorf("next unread after reading everything = %+v, want nil", u)
+	}
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"fmt"
+	"strings"
+)
+
+// mailKeyEncoding spells mail keys in letters and digits that survive any
+// mail system's handling of addresses
+var mailKeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// MailKey returns the secret a user's mail to the board's mail gateway is
+// addressed with, so the gateway knows it comes from them. The key is made
+// the first time it is asked for.
+func (db *DB) MailKey(userID int) (string, error) {
+	var key string
+	err := db.conn.QueryRow(`SELECT key FROM mail_keys WHERE user_id = ?`, userID).Scan(&key)
+	if err == nil {
+		return key, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get mail key: %w", err)
+	}
+
+	raw := make([]byte, 15)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate mail key: %w", err)
+	}
+	key = strings.ToLower(mailKeyEncoding.EncodeToString(raw))
+	if _, err := db.conn.Exec(`INSERT INTO mail_keys (user_id, key) VALUES (?, ?) ON CONFLICT(user_id) DO NOTHING`, userID, key); err != nil {
+		return "", fmt.Errorf("failed to save mail key: %w", err)
+	}
+	// Another caller may have made one first
+	if err := db.conn.QueryRow(`SELECT key FROM mail_keys WHERE user_id = ?`, userID).Scan(&key); err != nil {
+		return "", fmt.Errorf("failed to get mail key: %w", err)
+	}
+	return key, nil
+}
+
+// GetUserByMailKey retrieves the active user whose mail key is key,
+// ignoring case, or returns sql.ErrNoRows
+func (db *DB) GetUserByMailKey(key string) (*User, error) {
+	var id int
+	err := db.conn.QueryRow(`SELECT u.id FROM mail_keys k JOIN users u ON u.id = k.user_id
+		WHERE k.key = ? AND u.is_active = 1`, strings.ToLower(key)).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up mail key: %w", err)
+	}
+	return db.GetUserByID(id)
+}
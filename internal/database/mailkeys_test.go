@@ -0,0 +1,59 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestMailKey(t *testing.T) {
+	db := newTestDB(t)
+	for _, u := range []*User{
+		{Username: "alice", Password: "secret", IsActive: true},
+		{Username: "bob", Password: "secret", IsActive: true},
+	} {
+		if err := db.CreateUser(u); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	alice, _ := db.GetUser("alice")
+	bob, _ := db.GetUser("bob")
+
+	key, err := db.MailKey(alice.ID)
+	if err != nil || len(key) < 20 {
+		t.Fatalf("MailKey = %q, %v", key, err)
+	}
+	if again, _ := db.MailKey(alice.ID); again != key {
+		t.Errorf("MailKey changed from %q to %q", key, again)
+	}
+	if other, _ := db.MailKey(bob.ID); other == key {
+		t.Errorf("alice and bob share the key %q", key)
+	}
+
+	if user, err := db.GetUserByMailKey(strings.ToUpper(key)); err != nil || user.Username != "alice" {
+		t.Errorf("GetUserByMailKey = %+v, %v; want alice", user, err)
+	}
+	for _, guess := range []string{"", "nokey"} {
+		if _, err := db.GetUserByMailKey(guess); err != sql.ErrNoRows {
+			t.Errorf("GetUserByMailKey(%q) err = %v, want sql.ErrNoRows", guess, err)
+		}
+	}
+
+	// A disabled account's key no longer sends mail
+	if err := db.UpdateUser(alice.ID, alice.Username, alice.Password, "", "", alice.AccessLevel, false); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if _, err := db.GetUserByMailKey(key); err != sql.ErrNoRows {
+		t.Errorf("disabled account's key err = %v, want sql.ErrNoRows", err)
+	}
+
+	// Deleting the account removes its key
+	if err := db.DeleteAccount(bob.ID); err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+	var n int
+	db.conn.QueryRow(`SELECT COUNT(*) FROM mail_keys WHERE user_id = ?`, bob.ID).Scan(&n)
+	if n != 0 {
+		t.Errorf("bob's mail key survived deleting the account")
+	}
+}
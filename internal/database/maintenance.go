@@ -0,0 +1,35 @@
+package database
+
+import (
+	"fmt"
+	"os"
+)
+
+// Size returns the bytes the database takes on disk, counting the
+// write-ahead log and its index alongside the main file
+func (db *DB) Size() (int64, error) {
+	var total int64
+	for _, path := range []string{db.path, db.path + "-wal", db.path + "-shm"} {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to measure database: %w", err)
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
+// Compact rebuilds the database to give back the space left by deleted
+// rows, then folds the write-ahead log into the main file and empties it
+func (db *DB) Compact() error {
+	if _, err := db.conn.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+	if _, err := db.conn.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("failed to checkpoint database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import "testing"
+
+func TestMessageReadAndDeleteAreScopedToRecipient(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.CreateMessage(&Message{FromUser: "alice", ToUser: "bob", Subject: "Hi", Body: "Hello", Area: "private"}); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	mail, err := db.GetMessages("bob", 10)
+	if err != nil || len(mail) != 1 {
+		t.Fatalf("expected one message for bob, got %d (%v)", len(mail), err)
+	}
+	id := mail[0].ID
+
+	if err := db.UpdateMessageRead("mallory", id, true); err == nil {
+		t.Error("UpdateMessageRead should fail for a user who isn't the recipient")
+	}
+	if n, err := db.CountUnreadMail("bob"); err != nil || n != 1 {
+		t.Errorf("CountUnreadMail = %d, %v, want 1", n, err)
+	}
+	if err := db.UpdateMessageRead("bob", id, true); err != nil {
+		t.Fatalf("UpdateMessageRead failed: %v", err)
+	}
+	if n, _ := db.CountUnreadMail("bob"); n != 0 {
+		t.Errorf("CountUnreadMail after reading = %d, want 0", n)
+	}
+	msg, err := db.GetMessageByID("bob", id)
+	if err != nil {
+		t.Fatalf("GetMessageByID failed: %v", err)
+	}
+	if !msg.IsRead {
+		t.Error("message should be marked read")
+	}
+
+	if err := db.DeleteMessage("mallory", id); err == nil {
+		t.Error("DeleteMessage should fail for a user who isn't the recipient")
+	}
+	if err := db.UpdateMessageRead("bob", id, false); err != nil {
+		t.Fatalf("UpdateMessageRead failed: %v", err)
+	}
+	if err := db.DeleteMessage("bob", id); err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+	if _, err := db.GetMessageByID("bob", id); err == nil {
+		t.Error("message should be gone after delete")
+	}
+	if next, _ := db.NextUnread(0, "bob", 0); next != nil && next.Kind == UnreadMail {
+		t.Error("deleted message is still unread mail")
+	}
+
+	// Deleted mail can be restored until it is purged
+	if err := db.RestoreMessage("mallory", id); err == nil {
+		t.Error("RestoreMessage should fail for a user who isn't the recipient")
+	}
+	if err := db.RestoreMessage("bob", id); err != nil {
+		t.Fatalf("RestoreMessage failed: %v", err)
+	}
+	if mail, _ := db.GetMessages("bob", 10); len(mail) != 1 {
+		t.Fatalf("restored message missing from the mailbox: %v", mail)
+	}
+	if err := db.PurgeMessage("bob", id); err != nil {
+		t.Fatalf("PurgeMessage failed: %v", err)
+	}
+	if _, err := db.GetMessageByID("bob", id); err != nil {
+		t.Error("PurgeMessage removed a message that wasn't deleted")
+	}
+	if err := db.DeleteMessage("bob", id); err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+	if err := db.PurgeMessage("bob", id); err != nil {
+		t.Fatalf("PurgeMessage failed: %v", err)
+	}
+	if err := db.RestoreMessage("bob", id); err == nil {
+		t.Error("a purged message was restored")
+	}
+}
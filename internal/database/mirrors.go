@@ -0,0 +1,58 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// MirroredFile is a file the mirror fetched into a file area. Only these
+// files are replaced or removed when their source changes; anything else
+// in the area is left alone.
+type MirroredFile struct {
+	Area      string
+	Filename  string
+	URL       string
+	SHA256    string
+	Bytes     int64
+	FetchedAt time.Time
+}
+
+// GetMirroredFiles returns the files fetched into an area, by name
+func (db *DB) GetMirroredFiles(area string) (map[string]MirroredFile, error) {
+	rows, err := db.conn.Query(`SELECT area, filename, url, sha256, bytes, fetched_at FROM mirrored_files WHERE area = ?`, area)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mirrored files: %w", err)
+	}
+	defer rows.Close()
+
+	files := make(map[string]MirroredFile)
+	for rows.Next() {
+		var f MirroredFile
+		if err := rows.Scan(&f.Area, &f.Filename, &f.URL, &f.SHA256, &f.Bytes, &f.FetchedAt); err != nil {
+			return nil, fmt.Errorf("failed to load mirrored files: %w", err)
+		}
+		files[f.Filename] = f
+	}
+	return files, rows.Err()
+}
+
+// SaveMirroredFile records a file fetched into an area
+func (db *DB) SaveMirroredFile(f *MirroredFile) error {
+	if f.FetchedAt.IsZero() {
+		f.FetchedAt = time.Now()
+	}
+	_, err := db.conn.Exec(`INSERT OR REPLACE INTO mirrored_files (area, filename, url, sha256, bytes, fetched_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		f.Area, f.Filename, f.URL, f.SHA256, f.Bytes, f.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record mirrored file: %w", err)
+	}
+	return nil
+}
+
+// DeleteMirroredFile forgets a file removed from an area
+func (db *DB) DeleteMirroredFile(area, filename string) error {
+	if _, err := db.conn.Exec(`DELETE FROM mirrored_files WHERE area = ? AND filename = ?`, area, filename); err != nil {
+		return fmt.Errorf("failed to remove mirrored file: %w", err)
+	}
+	return nil
+}
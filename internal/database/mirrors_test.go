@@ -0,0 +1,34 @@
+package database
+
+import "testing"
+
+func TestMirroredFiles(t *testing.T) {
+	db := newTestDB(t)
+
+	f := &MirroredFile{Area: "retro", Filename: "pkz204g.exe", URL: "ftp://example.org/pkz204g.exe", SHA256: "aa", Bytes: 10}
+	if err := db.SaveMirroredFile(f); err != nil {
+		t.Fatalf("SaveMirroredFile failed: %v", err)
+	}
+	f.SHA256, f.Bytes = "bb", 20
+	if err := db.SaveMirroredFile(f); err != nil {
+		t.Fatalf("SaveMirroredFile again failed: %v", err)
+	}
+	if err := db.SaveMirroredFile(&MirroredFile{Area: "other", Filename: "pkz204g.exe", URL: "x", SHA256: "cc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := db.GetMirroredFiles("retro")
+	if err != nil || len(files) != 1 || files["pkz204g.exe"].SHA256 != "bb" || files["pkz204g.exe"].Bytes != 20 {
+		t.Fatalf("GetMirroredFiles = %+v, %v", files, err)
+	}
+
+	if err := db.DeleteMirroredFile("retro", "pkz204g.exe"); err != nil {
+		t.Fatalf("DeleteMirroredFile failed: %v", err)
+	}
+	if files, _ := db.GetMirroredFiles("retro"); len(files) != 0 {
+		t.Errorf("GetMirroredFiles after delete = %+v", files)
+	}
+	if files, _ := db.GetMirroredFiles("other"); len(files) != 1 {
+		t.Errorf("deleting from one area touched another: %+v", files)
+	}
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SetTopicLocked locks a topic against new posts and replies from callers
+// who don't moderate it, or unlocks it
+func (db *DB) SetTopicLocked(topicID int, locked bool) error {
+	if _, err := db.conn.Exec(`UPDATE topics SET locked = ? WHERE id = ?`, locked, topicID); err != nil {
+		return fmt.Errorf("failed to lock topic: %w", err)
+	}
+	return nil
+}
+
+// SetPostPinned pins a post above the others in its topic, or unpins it
+func (db *DB) SetPostPinned(postID int, pinned bool) error {
+	if _, err := db.conn.Exec(`UPDATE posts SET pinned = ? WHERE id = ?`, pinned, postID); err != nil {
+		return fmt.Errorf("failed to pin post: %w", err)
+	}
+	return nil
+}
+
+// UpdatePost changes the subject and body of a post. Archived posts can't
+// be changed.
+func (db *DB) UpdatePost(id int, subject, body string) error {
+	result, err := db.conn.Exec(`UPDATE posts SET subject = ?, body = ? WHERE id = ?`, subject, body, id)
+	if err != nil {
+		return fmt.Errorf("failed to update post: %w", err)
+	}
+	return expectRow(result, "post", id)
+}
+
+// UpdateReply changes the body of a reply to a post that isn't archived
+func (db *DB) UpdateReply(id int, body string) error {
+	result, err := db.conn.Exec(`UPDATE replies SET body = ? WHERE id = ?`, body, id)
+	if err != nil {
+		return fmt.Errorf("failed to update reply: %w", err)
+	}
+	return expectRow(result, "reply", id)
+}
+
+// DeletePost removes a post and every reply to it
+func (db *DB) DeletePost(id int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM replies WHERE post_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete replies: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM posts WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete post: %w", err)
+	}
+	if err := expectRow(result, "post", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteReply removes a reply. The replies that answered it move up to
+// answer what it answered, so the rest of the thread stays in place.
+func (db *DB) DeleteReply(id int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE replies SET parent_reply_id = (SELECT parent_reply_id FROM replies WHERE id = ?)
+			  WHERE parent_reply_id = ?`, id, id); err != nil {
+		return fmt.Errorf("failed to move answers to reply: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM replies WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete reply: %w", err)
+	}
+	if err := expectRow(result, "reply", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// expectRow reports an error wrapping sql.ErrNoRows when a statement
+// changed nothing, as for a post that was archived or deleted meanwhile
+func expectRow(result sql.Result, what string, id int) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("no %s %d: %w", what, id, sql.ErrNoRows)
+	}
+	return nil
+}
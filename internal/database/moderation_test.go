@@ -0,0 +1,104 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestModeration(t *testing.T) {
+	db := newTestDB(t)
+
+	topic := &Topic{Name: "General"}
+	if err := db.CreateTopic(topic); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	var posts []*Post
+	for _, subject := range []string{"Rules", "Hello", "Spam"} {
+		post := &Post{TopicID: topic.ID, Author: "alice", Subject: subject, Body: subject}
+		if err := db.CreatePost(post); err != nil {
+			t.Fatalf("CreatePost failed: %v", err)
+		}
+		posts = append(posts, post)
+	}
+	rules, hello, spam := posts[0], posts[1], posts[2]
+
+	// Pinned posts list first, however old
+	if err := db.SetPostPinned(rules.ID, true); err != nil {
+		t.Fatalf("SetPostPinned failed: %v", err)
+	}
+	listed, err := db.GetPosts(topic.ID, 10)
+	if err != nil {
+		t.Fatalf("GetPosts failed: %v", err)
+	}
+	if len(listed) != 3 || listed[0].ID != rules.ID || !listed[0].Pinned || listed[1].Pinned {
+		t.Errorf("GetPosts = %+v, want the pinned post first", listed)
+	}
+
+	if err := db.SetTopicLocked(topic.ID, true); err != nil {
+		t.Fatalf("SetTopicLocked failed: %v", err)
+	}
+	if topics, err := db.GetTopics(0); err != nil || len(topics) != 1 || !topics[0].Locked {
+		t.Errorf("GetTopics = %+v, %v; want the topic locked", topics, err)
+	}
+
+	// Deleting a reply keeps its answers in the thread
+	first := &Reply{PostID: hello.ID, Author: "bob", Body: "Hi"}
+	if err := db.CreateReply(first); err != nil {
+		t.Fatalf("CreateReply failed: %v", err)
+	}
+	middle := &Reply{PostID: hello.ID, ParentID: first.ID, Author: "carol", Body: "Off topic"}
+	if err := db.CreateReply(middle); err != nil {
+		t.Fatalf("CreateReply failed: %v", err)
+	}
+	last := &Reply{PostID: hello.ID, ParentID: middle.ID, Author: "dave", Body: "Agreed"}
+	if err := db.CreateReply(last); err != nil {
+		t.Fatalf("CreateReply failed: %v", err)
+	}
+	if err := db.DeleteReply(middle.ID); err != nil {
+		t.Fatalf("DeleteReply failed: %v", err)
+	}
+	replies, err := db.GetReplies(hello.ID)
+	if err != nil {
+		t.Fatalf("GetReplies failed: %v", err)
+	}
+	if len(replies) != 2 || replies[1].ID != last.ID || replies[1].ParentID != first.ID {
+		t.Errorf("replies after deleting the middle one = %+v, want the last answering the first", replies)
+	}
+
+	if err := db.UpdateReply(first.ID, "Hello there"); err != nil {
+		t.Fatalf("UpdateReply failed: %v", err)
+	}
+	if err := db.UpdatePost(hello.ID, "Hello all", "Edited"); err != nil {
+		t.Fatalf("UpdatePost failed: %v", err)
+	}
+	if got, err := db.GetPost(hello.ID); err != nil || got.Subject != "Hello all" || got.Body != "Edited" {
+		t.Errorf("GetPost after editing = %+v, %v", got, err)
+	}
+
+	// Deleting a post takes its replies along
+	if err := db.DeletePost(hello.ID); err != nil {
+		t.Fatalf("DeletePost failed: %v", err)
+	}
+	if replies, _ := db.GetReplies(hello.ID); len(replies) != 0 {
+		t.Errorf("replies to a deleted post = %+v, want none", replies)
+	}
+	if err := db.DeletePost(hello.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleting a deleted post = %v, want sql.ErrNoRows", err)
+	}
+	if err := db.UpdatePost(hello.ID, "Gone", "Gone"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("editing a deleted post = %v, want sql.ErrNoRows", err)
+	}
+
+	// Pinned posts are never archived
+	if _, err := db.ArchivePosts(topic.ID, 1, time.Time{}); err != nil {
+		t.Fatalf("ArchivePosts failed: %v", err)
+	}
+	if got, err := db.GetPost(rules.ID); err != nil || got.Archived {
+		t.Errorf("pinned post after archiving = %+v, %v; want it kept", got, err)
+	}
+	if got, err := db.GetPost(spam.ID); err != nil || got.Archived {
+		t.Errorf("newest post after archiving = %+v, %v; want it kept", got, err)
+	}
+}
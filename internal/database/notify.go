@@ -0,0 +1,90 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// QueuedNotification is a notification waiting for a user's email digest
+type QueuedNotification struct {
+	ID        int
+	UserID    int
+	Username  string
+	Email     string
+	Kind      string
+	Text      string
+	CreatedAt time.Time
+}
+
+// GetNotifySettings returns how a user wants to hear about each kind of
+// notification they have changed from the board's defaults
+func (db *DB) GetNotifySettings(userID int) (map[string]string, error) {
+	rows, err := db.conn.Query(`SELECT kind, delivery FROM notify_settings WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var kind, delivery string
+		if err := rows.Scan(&kind, &delivery); err != nil {
+			return nil, fmt.Errorf("failed to get notification settings: %w", err)
+		}
+		settings[kind] = delivery
+	}
+	return settings, rows.Err()
+}
+
+// SaveNotifySetting stores how a user wants to hear about one kind of
+// notification
+func (db *DB) SaveNotifySetting(userID int, kind, delivery string) error {
+	_, err := db.conn.Exec(`INSERT INTO notify_settings (user_id, kind, delivery) VALUES (?, ?, ?)
+		ON CONFLICT(user_id, kind) DO UPDATE SET delivery = excluded.delivery`, userID, kind, delivery)
+	if err != nil {
+		return fmt.Errorf("failed to save notification settings: %w", err)
+	}
+	return nil
+}
+
+// QueueNotification saves a notification for a user's next email digest
+func (db *DB) QueueNotification(userID int, kind, text string) error {
+	_, err := db.conn.Exec(`INSERT INTO notify_queue (user_id, kind, text, created_at) VALUES (?, ?, ?, ?)`,
+		userID, kind, text, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to queue notification: %w", err)
+	}
+	return nil
+}
+
+// GetQueuedNotifications returns every notification waiting for a digest,
+// grouped by user and oldest first
+func (db *DB) GetQueuedNotifications() ([]QueuedNotification, error) {
+	rows, err := db.conn.Query(`SELECT q.id, q.user_id, u.username, COALESCE(u.email, ''), q.kind, q.text, q.created_at
+		FROM notify_queue q JOIN users u ON u.id = q.user_id
+		ORDER BY q.user_id, q.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queued notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var queued []QueuedNotification
+	for rows.Next() {
+		var n QueuedNotification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Username, &n.Email, &n.Kind, &n.Text, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to get queued notifications: %w", err)
+		}
+		queued = append(queued, n)
+	}
+	return queued, rows.Err()
+}
+
+// DeleteNotifications removes a user's queued notifications up to and
+// including throughID, once their digest has been sent
+func (db *DB) DeleteNotifications(userID, throughID int) error {
+	_, err := db.conn.Exec(`DELETE FROM notify_queue WHERE user_id = ? AND id <= ?`, userID, throughID)
+	if err != nil {
+		return fmt.Errorf("failed to delete notifications: %w", err)
+	}
+	return nil
+}
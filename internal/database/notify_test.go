@@ -0,0 +1,62 @@
+package database
+
+import "testing"
+
+func TestNotifySettings(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", Email: "alice@example.com", IsActive: true}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := db.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+
+	if settings, err := db.GetNotifySettings(user.ID); err != nil || len(settings) != 0 {
+		t.Fatalf("settings before saving = %v, %v; want none", settings, err)
+	}
+	for _, s := range [][2]string{{"mail", "email"}, {"messages", "off"}, {"mail", "board"}} {
+		if err := db.SaveNotifySetting(user.ID, s[0], s[1]); err != nil {
+			t.Fatalf("SaveNotifySetting failed: %v", err)
+		}
+	}
+	settings, err := db.GetNotifySettings(user.ID)
+	if err != nil {
+		t.Fatalf("GetNotifySettings failed: %v", err)
+	}
+	if len(settings) != 2 || settings["mail"] != "board" || settings["messages"] != "off" {
+		t.Errorf("settings = %v, want mail on the board and messages off", settings)
+	}
+
+	for _, text := range []string{"New mail from bob", "carol replied to Modems"} {
+		if err := db.QueueNotification(user.ID, "mail", text); err != nil {
+			t.Fatalf("QueueNotification failed: %v", err)
+		}
+	}
+	queued, err := db.GetQueuedNotifications()
+	if err != nil {
+		t.Fatalf("GetQueuedNotifications failed: %v", err)
+	}
+	if len(queued) != 2 || queued[0].Text != "New mail from bob" || queued[0].Email != "alice@example.com" {
+		t.Fatalf("queued = %+v, want both, oldest first, with alice's address", queued)
+	}
+
+	// Sending the first leaves the second for the next digest
+	if err := db.DeleteNotifications(user.ID, queued[0].ID); err != nil {
+		t.Fatalf("DeleteNotifications failed: %v", err)
+	}
+	if queued, _ := db.GetQueuedNotifications(); len(queued) != 1 || queued[0].Text != "carol replied to Modems" {
+		t.Errorf("queued after sending one = %+v, want the reply only", queued)
+	}
+
+	// Deleting the account removes the settings and queue with it
+	if err := db.DeleteAccount(user.ID); err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+	if settings, err := db.GetNotifySettings(user.ID); err != nil || len(settings) != 0 {
+		t.Errorf("settings after deletion = %v, %v; want none", settings, err)
+	}
+	if queued, err := db.GetQueuedNotifications(); err != nil || len(queued) != 0 {
+		t.Errorf("queued after deletion = %+v, %v; want none", queued, err)
+	}
+}
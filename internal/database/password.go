@@ -0,0 +1,164 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials is returned when a username/password pair does not match
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// HashPassword returns a bcrypt hash of a plaintext password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// GeneratePassword returns a random password for an account nobody has
+// chosen one for yet, such as the sysop on a new board
+func GeneratePassword() (string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return strings.ToLower(mailKeyEncoding.EncodeToString(raw)), nil
+}
+
+var (
+	dummyHashOnce  sync.Once
+	dummyHashValue []byte
+)
+
+// dummyHash returns a throwaway hash used to equalize timing for unknown users
+func dummyHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHashValue, _ = bcrypt.GenerateFromPassword([]byte("coastline"), bcrypt.DefaultCost)
+	})
+	return dummyHashValue
+}
+
+// isHashed reports whether a stored password is already a bcrypt hash
+func isHashed(stored string) bool {
+	return len(stored) == 60 &&
+		(strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$"))
+}
+
+// ensureHashed hashes a password unless it is already stored in hashed form
+func ensureHashed(password string) (string, error) {
+	if isHashed(password) {
+		return password, nil
+	}
+	return HashPassword(password)
+}
+
+// VerifyPassword checks a user's password and returns the user on success.
+// Accounts still holding a legacy plaintext password are re-hashed on their
+// first successful login.
+func (db *DB) VerifyPassword(username, password string) (*User, error) {
+	user, err := db.GetUser(username)
+	if err != nil {
+		// Burn comparable time so unknown usernames aren't distinguishable
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+
+	if isHashed(user.Password) {
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+			return nil, ErrInvalidCredentials
+		}
+		return user, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Legacy plaintext password matched: upgrade it in place
+	if !db.readOnly {
+		if hash, err := HashPassword(password); err == nil {
+			if _, err := db.conn.Exec(`UPDATE users SET password = ? WHERE id = ?`, hash, user.ID); err == nil {
+				user.Password = hash
+			}
+		}
+	}
+
+	return user, nil
+}
+
+// PasswordStatus records whether a user must pick a new password and when
+// they last changed it
+type PasswordStatus struct {
+	MustChange bool
+	ChangedAt  time.Time // When the password was last set, or the account's creation time
+}
+
+// Expired reports whether the password is older than maxAge. A zero maxAge
+// means passwords never expire.
+func (p *PasswordStatus) Expired(maxAge time.Duration, now time.Time) bool {
+	return maxAge > 0 && now.Sub(p.ChangedAt) >= maxAge
+}
+
+// GetPasswordStatus returns a user's password status. Accounts that have
+// never changed their password count from when they were created.
+func (db *DB) GetPasswordStatus(userID int) (*PasswordStatus, error) {
+	query := `SELECT COALESCE(p.must_change, 0), p.changed_at, u.created_at
+			  FROM users u LEFT JOIN password_status p ON p.user_id = u.id
+			  WHERE u.id = ?`
+
+	status := &PasswordStatus{}
+	var changedAt *time.Time
+	if err := db.conn.QueryRow(query, userID).Scan(&status.MustChange, &changedAt, &status.ChangedAt); err != nil {
+		return nil, err
+	}
+	if changedAt != nil {
+		status.ChangedAt = *changedAt
+	}
+	return status, nil
+}
+
+// SetMustChangePassword flags or clears a user's forced password change at
+// next login
+func (db *DB) SetMustChangePassword(userID int, mustChange bool) error {
+	query := `INSERT INTO password_status (user_id, must_change) VALUES (?, ?)
+			  ON CONFLICT(user_id) DO UPDATE SET must_change = excluded.must_change`
+	if _, err := db.conn.Exec(query, userID, mustChange); err != nil {
+		return fmt.Errorf("failed to update password status: %w", err)
+	}
+	return nil
+}
+
+// ChangePassword sets a user's new password, clears any forced change, and
+// restarts the password's age
+func (db *DB) ChangePassword(userID int, password string) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET password = ? WHERE id = ?`, hash, userID); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO password_status (user_id, must_change, changed_at) VALUES (?, 0, ?)
+		ON CONFLICT(user_id) DO UPDATE SET must_change = 0, changed_at = excluded.changed_at`,
+		userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to update password status: %w", err)
+	}
+
+	return tx.Commit()
+}
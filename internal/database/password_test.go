@@ -0,0 +1,116 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := Initialize(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestCreateUserHashesPassword(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", IsActive: true}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	user, err := db.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	if !isHashed(user.Password) {
+		t.Errorf("password should be stored hashed, got %q", user.Password)
+	}
+
+	if _, err := db.VerifyPassword("alice", "secret"); err != nil {
+		t.Errorf("VerifyPassword with correct password failed: %v", err)
+	}
+	if _, err := db.VerifyPassword("alice", "wrong"); err != ErrInvalidCredentials {
+		t.Errorf("VerifyPassword with wrong password = %v, expected ErrInvalidCredentials", err)
+	}
+	if _, err := db.VerifyPassword("nobody", "secret"); err != ErrInvalidCredentials {
+		t.Errorf("VerifyPassword for unknown user = %v, expected ErrInvalidCredentials", err)
+	}
+}
+
+func TestVerifyPasswordMigratesLegacyPlaintext(t *testing.T) {
+	db := newTestDB(t)
+
+	// Simulate an account created before hashing existed
+	if _, err := db.conn.Exec(`INSERT INTO users (username, password, real_name, email) VALUES (?, ?, '', '')`, "legacy", "test"); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	if _, err := db.VerifyPassword("legacy", "wrong"); err != ErrInvalidCredentials {
+		t.Errorf("wrong legacy password = %v, expected ErrInvalidCredentials", err)
+	}
+
+	user, err := db.VerifyPassword("legacy", "test")
+	if err != nil {
+		t.Fatalf("legacy password should verify: %v", err)
+	}
+	if !isHashed(user.Password) {
+		t.Errorf("legacy password should be re-hashed after login")
+	}
+
+	stored, _ := db.GetUser("legacy")
+	if !isHashed(stored.Password) {
+		t.Errorf("re-hashed password should be persisted, got %q", stored.Password)
+	}
+	if _, err := db.VerifyPassword("legacy", "test"); err != nil {
+		t.Errorf("migrated password should still verify: %v", err)
+	}
+}
+
+func TestPasswordStatus(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", IsActive: true}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, _ := db.GetUser("alice")
+
+	status, err := db.GetPasswordStatus(user.ID)
+	if err != nil {
+		t.Fatalf("GetPasswordStatus failed: %v", err)
+	}
+	if status.MustChange {
+		t.Error("new account should not need a password change")
+	}
+	if !status.ChangedAt.Equal(user.CreatedAt) {
+		t.Errorf("password age should count from account creation, got %v", status.ChangedAt)
+	}
+	if !status.Expired(time.Hour, user.CreatedAt.Add(2*time.Hour)) || status.Expired(0, user.CreatedAt.Add(2*time.Hour)) {
+		t.Error("Expired should honor the maximum age, with zero disabling expiry")
+	}
+
+	if err := db.SetMustChangePassword(user.ID, true); err != nil {
+		t.Fatalf("SetMustChangePassword failed: %v", err)
+	}
+	if status, _ = db.GetPasswordStatus(user.ID); !status.MustChange {
+		t.Error("password change should be required after flagging")
+	}
+
+	if err := db.ChangePassword(user.ID, "newsecret"); err != nil {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+	status, _ = db.GetPasswordStatus(user.ID)
+	if status.MustChange {
+		t.Error("changing the password should clear the flag")
+	}
+	if time.Since(status.ChangedAt) > time.Minute {
+		t.Errorf("change time should be now, got %v", status.ChangedAt)
+	}
+	if _, err := db.VerifyPassword("alice", "newsecret"); err != nil {
+		t.Errorf("new password should verify: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Menu navigation modes a caller can choose
+const (
+	NavLightbar = "lightbar" // Arrow keys move a highlight, Enter chooses
+	NavHotkeys  = "hotkeys"  // Items are chosen only by their hotkeys
+)
+
+// UserPrefs are the display choices a caller makes for themselves
+type UserPrefs struct {
+	Theme       string // Color theme, "" for the board's own colors
+	Terminal    string // Terminal type used in place of the client's, "" to trust the client
+	ScreenLines int    // Screen length, 0 to use the height the client reports
+	Navigation  string // NavLightbar or NavHotkeys
+	Language    string // Catalog the board is shown from, "" for the board's language
+}
+
+// DefaultUserPrefs are the preferences of a caller who hasn't changed any
+func DefaultUserPrefs() UserPrefs {
+	return UserPrefs{Navigation: NavLightbar}
+}
+
+// GetUserPrefs returns a user's preferences, or the defaults if they have
+// never saved any
+func (db *DB) GetUserPrefs(userID int) (UserPrefs, error) {
+	prefs := DefaultUserPrefs()
+	err := db.conn.QueryRow(`SELECT theme, terminal, screen_lines, navigation, language FROM user_prefs WHERE user_id = ?`, userID).
+		Scan(&prefs.Theme, &prefs.Terminal, &prefs.ScreenLines, &prefs.Navigation, &prefs.Language)
+	if err == sql.ErrNoRows {
+		return DefaultUserPrefs(), nil
+	}
+	if err != nil {
+		return DefaultUserPrefs(), fmt.Errorf("failed to get preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// SaveUserPrefs stores a user's preferences
+func (db *DB) SaveUserPrefs(userID int, prefs UserPrefs) error {
+	_, err := db.conn.Exec(`INSERT INTO user_prefs (user_id, theme, terminal, screen_lines, navigation, language) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET theme = excluded.theme, terminal = excluded.terminal,
+			screen_lines = excluded.screen_lines, navigation = excluded.navigation, language = excluded.language`,
+		userID, prefs.Theme, prefs.Terminal, prefs.ScreenLines, prefs.Navigation, prefs.Language)
+	if err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return nil
+}
+
+// UpdateProfile changes the real name and email a user shows the board
+func (db *DB) UpdateProfile(userID int, realName, email string) error {
+	if _, err := db.conn.Exec(`UPDATE users SET real_name = ?, email = ? WHERE id = ?`, realName, email, userID); err != nil {
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+	return nil
+}
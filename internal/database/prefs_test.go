@@ -0,0 +1,43 @@
+package database
+
+import "testing"
+
+func TestUserPrefs(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", RealName: "Alice", Email: "alice@example.com", IsActive: true}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := db.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+
+	if prefs, err := db.GetUserPrefs(user.ID); err != nil || prefs != DefaultUserPrefs() {
+		t.Fatalf("prefs before saving = %+v, %v; want the defaults", prefs, err)
+	}
+	want := UserPrefs{Theme: "amber", Terminal: "ansi-bbs", ScreenLines: 25, Navigation: NavHotkeys, Language: "es"}
+	if err := db.SaveUserPrefs(user.ID, UserPrefs{Theme: "green"}); err != nil {
+		t.Fatalf("SaveUserPrefs failed: %v", err)
+	}
+	if err := db.SaveUserPrefs(user.ID, want); err != nil {
+		t.Fatalf("SaveUserPrefs failed: %v", err)
+	}
+	if prefs, err := db.GetUserPrefs(user.ID); err != nil || prefs != want {
+		t.Errorf("prefs = %+v, %v; want %+v", prefs, err, want)
+	}
+
+	if err := db.UpdateProfile(user.ID, "Alice Smith", "alice@example.org"); err != nil {
+		t.Fatalf("UpdateProfile failed: %v", err)
+	}
+	if user, err = db.GetUser("alice"); err != nil || user.RealName != "Alice Smith" || user.Email != "alice@example.org" {
+		t.Errorf("profile = %q, %q, %v; want the new name and email", user.RealName, user.Email, err)
+	}
+
+	// Deleting the account removes the preferences with it
+	if err := db.DeleteAccount(user.ID); err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+	if prefs, err := db.GetUserPrefs(user.ID); err != nil || prefs != DefaultUserPrefs() {
+		t.Errorf("prefs after deletion = %+v, %v; want the defaults", prefs, err)
+	}
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// PendingRegistration is a self-registered account waiting for sysop approval
+type PendingRegistration struct {
+	User        User
+	RequestedAt time.Time
+}
+
+// UsernameExists reports whether a username is taken, including by disabled
+// or pending accounts
+func (db *DB) UsernameExists(username string) (bool, error) {
+	return db.userExists(username)
+}
+
+// RegisterUser creates a self-registered account. When requireApproval is
+// set the account is created inactive and queued for sysop review.
+func (db *DB) RegisterUser(user *User, requireApproval bool) error {
+	password, err := ensureHashed(user.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO users (username, password, real_name, email, access_level, created_at, is_active)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		user.Username, password, user.RealName, user.Email, user.AccessLevel, time.Now(), !requireApproval)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if requireApproval {
+		if _, err := tx.Exec(`INSERT INTO pending_registrations (user_id, requested_at) VALUES (?, ?)`,
+			id, time.Now()); err != nil {
+			return fmt.Errorf("failed to queue registration: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	user.ID = int(id)
+	user.Password = password
+	user.IsActive = !requireApproval
+	return nil
+}
+
+// GetPendingRegistrations returns accounts awaiting approval, oldest first
+func (db *DB) GetPendingRegistrations() ([]PendingRegistration, error) {
+	query := `SELECT u.id, u.username, COALESCE(u.real_name, ''), COALESCE(u.email, ''),
+			  u.access_level, u.created_at, p.requested_at
+			  FROM pending_registrations p JOIN users u ON u.id = p.user_id
+			  ORDER BY p.requested_at`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []PendingRegistration
+	for rows.Next() {
+		var p PendingRegistration
+		err := rows.Scan(&p.User.ID, &p.User.Username, &p.User.RealName, &p.User.Email,
+			&p.User.AccessLevel, &p.User.CreatedAt, &p.RequestedAt)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+
+	return pending, rows.Err()
+}
+
+// ApproveRegistration activates a pending account
+func (db *DB) ApproveRegistration(userID int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM pending_registrations WHERE user_id = ?`, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("no pending registration for user %d", userID)
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET is_active = 1 WHERE id = ?`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RejectRegistration removes a pending account entirely
+func (db *DB) RejectRegistration(userID int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM pending_registrations WHERE user_id = ?`, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("no pending registration for user %d", userID)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM users WHERE id = ?`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
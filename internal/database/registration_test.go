@@ -0,0 +1,65 @@
+package database
+
+import "testing"
+
+func TestRegisterUserWithApproval(t *testing.T) {
+	db := newTestDB(t)
+
+	user := &User{Username: "carol", Password: "secret1", RealName: "Carol", Email: "carol@example.com", AccessLevel: 10}
+	if err := db.RegisterUser(user, true); err != nil {
+		t.Fatalf("RegisterUser failed: %v", err)
+	}
+
+	// Pending accounts cannot log in
+	if _, err := db.VerifyPassword("carol", "secret1"); err != ErrInvalidCredentials {
+		t.Errorf("pending account should not log in, got %v", err)
+	}
+
+	pending, err := db.GetPendingRegistrations()
+	if err != nil {
+		t.Fatalf("GetPendingRegistrations failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].User.Username != "carol" {
+		t.Fatalf("expected carol to be pending, got %+v", pending)
+	}
+
+	// Only GetUserByName finds the account while it waits
+	if _, err := db.GetUser("carol"); err == nil {
+		t.Error("GetUser found a pending account")
+	}
+	if found, err := db.GetUserByName("carol"); err != nil || found.ID != user.ID || found.IsActive {
+		t.Errorf("GetUserByName = %+v, %v", found, err)
+	}
+
+	if err := db.ApproveRegistration(user.ID); err != nil {
+		t.Fatalf("ApproveRegistration failed: %v", err)
+	}
+	if _, err := db.VerifyPassword("carol", "secret1"); err != nil {
+		t.Errorf("approved account should log in: %v", err)
+	}
+
+	pending, _ = db.GetPendingRegistrations()
+	if len(pending) != 0 {
+		t.Errorf("expected no pending registrations, got %d", len(pending))
+	}
+}
+
+func TestRejectRegistrationDeletesUser(t *testing.T) {
+	db := newTestDB(t)
+
+	user := &User{Username: "dave", Password: "secret1", AccessLevel: 10}
+	if err := db.RegisterUser(user, true); err != nil {
+		t.Fatalf("RegisterUser failed: %v", err)
+	}
+	if err := db.RejectRegistration(user.ID); err != nil {
+		t.Fatalf("RejectRegistration failed: %v", err)
+	}
+
+	exists, err := db.UsernameExists("dave")
+	if err != nil {
+		t.Fatalf("UsernameExists failed: %v", err)
+	}
+	if exists {
+		t.Error("rejected user should be deleted")
+	}
+}
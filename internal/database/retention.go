@@ -0,0 +1,175 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DeletedAuthor replaces a deleted account's name on the posts, replies and
+// mail it leaves behind. It contains a space, so no account can take it.
+const DeletedAuthor = "Deleted user"
+
+// AccountDeletion is an account its owner has asked to have deleted
+type AccountDeletion struct {
+	UserID      int
+	Username    string
+	RequestedAt time.Time
+	DeleteAfter time.Time
+}
+
+// RequestAccountDeletion schedules a user's account for deletion once
+// deleteAfter has passed
+func (db *DB) RequestAccountDeletion(userID int, deleteAfter time.Time) error {
+	query := `INSERT INTO account_deletions (user_id, requested_at, delete_after) VALUES (?, ?, ?)
+			  ON CONFLICT(user_id) DO UPDATE SET requested_at = excluded.requested_at, delete_after = excluded.delete_after`
+	if _, err := db.conn.Exec(query, userID, time.Now(), deleteAfter); err != nil {
+		return fmt.Errorf("failed to schedule account deletion: %w", err)
+	}
+	return nil
+}
+
+// CancelAccountDeletion keeps an account that was scheduled for deletion
+func (db *DB) CancelAccountDeletion(userID int) error {
+	if _, err := db.conn.Exec(`DELETE FROM account_deletions WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to cancel account deletion: %w", err)
+	}
+	return nil
+}
+
+// GetAccountDeletion returns when a user's account is due to be deleted, or
+// nil if it isn't scheduled for deletion
+func (db *DB) GetAccountDeletion(userID int) (*time.Time, error) {
+	var deleteAfter time.Time
+	err := db.conn.QueryRow(`SELECT delete_after FROM account_deletions WHERE user_id = ?`, userID).Scan(&deleteAfter)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account deletion: %w", err)
+	}
+	return &deleteAfter, nil
+}
+
+// GetDueAccountDeletions returns the accounts whose grace period has ended
+func (db *DB) GetDueAccountDeletions(now time.Time) ([]AccountDeletion, error) {
+	query := `SELECT d.user_id, u.username, d.requested_at, d.delete_after
+			  FROM account_deletions d JOIN users u ON u.id = d.user_id
+			  WHERE d.delete_after <= ? ORDER BY d.delete_after`
+
+	rows, err := db.conn.Query(query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var due []AccountDeletion
+	for rows.Next() {
+		var d AccountDeletion
+		if err := rows.Scan(&d.UserID, &d.Username, &d.RequestedAt, &d.DeleteAfter); err != nil {
+			return nil, err
+		}
+		due = append(due, d)
+	}
+	return due, rows.Err()
+}
+
+// DeleteAccount removes a user and their private data. Their posts and
+// replies stay, so threads remain readable, and are credited to
+// DeletedAuthor along with mail they sent; mail they received is deleted.
+func (db *DB) DeleteAccount(userID int) error {
+	var username string
+	if err := db.conn.QueryRow(`SELECT username FROM users WHERE id = ?`, userID).Scan(&username); err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`UPDATE posts SET author = ? WHERE author = ?`, []interface{}{DeletedAuthor, username}},
+		{`UPDATE replies SET author = ? WHERE author = ?`, []interface{}{DeletedAuthor, username}},
+		{`UPDATE archived_posts SET author = ? WHERE author = ?`, []interface{}{DeletedAuthor, username}},
+		{`UPDATE archived_replies SET author = ? WHERE author = ?`, []interface{}{DeletedAuthor, username}},
+		{`UPDATE messages SET from_user = ? WHERE from_user = ?`, []interface{}{DeletedAuthor, username}},
+		{`UPDATE caller_log SET username = ? WHERE username = ?`, []interface{}{DeletedAuthor, username}},
+		{`UPDATE taglines SET author = ? WHERE author = ?`, []interface{}{DeletedAuthor, username}},
+		{`DELETE FROM messages WHERE to_user = ?`, []interface{}{username}},
+		{`DELETE FROM api_tokens WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM sessions WHERE username = ?`, []interface{}{username}},
+		{`DELETE FROM file_transfers WHERE username = ?`, []interface{}{username}},
+		{`DELETE FROM pending_registrations WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM password_status WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM user_time WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM tutorial_progress WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM bell_settings WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM user_hotkeys WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM mail_keys WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM topic_subscriptions WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM user_prefs WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM notify_settings WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM notify_queue WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM user_lastread WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM bulletin_views WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM account_deletions WHERE user_id = ?`, []interface{}{userID}},
+		{`DELETE FROM users WHERE id = ?`, []interface{}{userID}},
+	}
+	for _, st := range statements {
+		if _, err := tx.Exec(st.query, st.args...); err != nil {
+			return fmt.Errorf("failed to delete account %s: %w", username, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// PruneCallerLog deletes caller log entries for calls before cutoff and
+// returns how many were removed
+func (db *DB) PruneCallerLog(cutoff time.Time) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM call_actions WHERE call_id IN
+		(SELECT id FROM caller_log WHERE login_at < ?)`, cutoff); err != nil {
+		return 0, fmt.Errorf("failed to prune caller log: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM caller_log WHERE login_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune caller log: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return removed, tx.Commit()
+}
+
+// PruneReadMail deletes private mail that has been read and was sent before
+// cutoff, and returns how many messages were removed
+func (db *DB) PruneReadMail(cutoff time.Time) (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM messages WHERE is_read = 1 AND created_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune read mail: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// PurgeDeletedMail removes mail deleted before cutoff whose undo never
+// made it final, as when the server stopped part way, and returns how many
+// messages were removed
+func (db *DB) PurgeDeletedMail(cutoff time.Time) (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM messages WHERE deleted_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted mail: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeleteAccountKeepsPosts(t *testing.T) {
+	db := newTestDB(t)
+	for _, name := range []string{"alice", "bob"} {
+		if err := db.CreateUser(&User{Username: name, Password: "secret", IsActive: true}); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	alice, err := db.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+
+	if err := db.CreateTopic(&Topic{Name: "General"}); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	topics, err := db.GetTopics(0)
+	if err != nil || len(topics) == 0 {
+		t.Fatalf("GetTopics failed: %v", err)
+	}
+	post := &Post{TopicID: topics[0].ID, Author: "alice", Subject: "Hi", Body: "Hello"}
+	if err := db.CreatePost(post); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	if err := db.CreateReply(&Reply{PostID: post.ID, Author: "bob", Body: "Hey"}); err != nil {
+		t.Fatalf("CreateReply failed: %v", err)
+	}
+	for _, msg := range []*Message{
+		{FromUser: "alice", ToUser: "bob", Subject: "To bob", Body: "Hi"},
+		{FromUser: "bob", ToUser: "alice", Subject: "To alice", Body: "Hi"},
+	} {
+		if err := db.CreateMessage(msg); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	// Not due until the grace period ends
+	now := time.Now()
+	if err := db.RequestAccountDeletion(alice.ID, now.Add(time.Hour)); err != nil {
+		t.Fatalf("RequestAccountDeletion failed: %v", err)
+	}
+	if due, err := db.GetDueAccountDeletions(now); err != nil || len(due) != 0 {
+		t.Fatalf("GetDueAccountDeletions = %v, %v; want none yet", due, err)
+	}
+	due, err := db.GetDueAccountDeletions(now.Add(2 * time.Hour))
+	if err != nil || len(due) != 1 || due[0].Username != "alice" {
+		t.Fatalf("GetDueAccountDeletions = %v, %v; want alice", due, err)
+	}
+
+	if err := db.DeleteAccount(alice.ID); err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+	if exists, _ := db.UsernameExists("alice"); exists {
+		t.Error("alice's account should be gone")
+	}
+
+	kept, err := db.GetPost(post.ID)
+	if err != nil {
+		t.Fatalf("post should be kept: %v", err)
+	}
+	if kept.Author != DeletedAuthor {
+		t.Errorf("post author = %q, want %q", kept.Author, DeletedAuthor)
+	}
+	if replies, err := db.GetReplies(post.ID); err != nil || len(replies) != 1 {
+		t.Errorf("replies to the post should be kept: %v, %v", replies, err)
+	}
+
+	mail, err := db.GetMessages("bob", 10)
+	if err != nil || len(mail) != 1 || mail[0].FromUser != DeletedAuthor {
+		t.Errorf("bob's mail from alice = %v, %v; want one from %q", mail, err, DeletedAuthor)
+	}
+	if mail, _ := db.GetMessages("alice", 10); len(mail) != 0 {
+		t.Errorf("alice's mail should be deleted, got %d messages", len(mail))
+	}
+}
+
+func TestPruneCallerLog(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := db.StartCall("alice", 1); err != nil {
+		t.Fatalf("StartCall failed: %v", err)
+	}
+	old := time.Now().AddDate(0, 0, -100)
+	if _, err := db.conn.Exec(`INSERT INTO caller_log (username, node, login_at) VALUES ('bob', 2, ?)`, old); err != nil {
+		t.Fatalf("insert old call: %v", err)
+	}
+
+	removed, err := db.PruneCallerLog(time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("PruneCallerLog failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("removed %d calls, want 1", removed)
+	}
+}
+
+func TestPurgeDeletedMail(t *testing.T) {
+	db := newTestDB(t)
+	for _, subject := range []string{"Kept", "Deleted"} {
+		if err := db.CreateMessage(&Message{FromUser: "alice", ToUser: "bob", Subject: subject, Body: "Hello"}); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+	mail, _ := db.GetMessages("bob", 10)
+	for _, msg := range mail {
+		if msg.Subject == "Deleted" {
+			if err := db.DeleteMessage("bob", msg.ID); err != nil {
+				t.Fatalf("DeleteMessage failed: %v", err)
+			}
+		}
+	}
+
+	if removed, err := db.PurgeDeletedMail(time.Now().Add(-time.Minute)); err != nil || removed != 0 {
+		t.Errorf("PurgeDeletedMail removed %d (%v) before the cutoff, want 0", removed, err)
+	}
+	if removed, err := db.PurgeDeletedMail(time.Now().Add(time.Minute)); err != nil || removed != 1 {
+		t.Errorf("PurgeDeletedMail removed %d (%v), want 1", removed, err)
+	}
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// GetScriptValue returns a value a script stored under key, and whether
+// there was one
+func (db *DB) GetScriptValue(script, key string) (string, bool, error) {
+	var value string
+	err := db.conn.QueryRow(`SELECT value FROM script_data WHERE script = ? AND key = ?`, script, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get script value: %w", err)
+	}
+	return value, true, nil
+}
+
+// SetScriptValue stores a value under key for a script
+func (db *DB) SetScriptValue(script, key, value string) error {
+	_, err := db.conn.Exec(`INSERT INTO script_data (script, key, value, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(script, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		script, key, value, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to set script value: %w", err)
+	}
+	return nil
+}
+
+// IncrementScriptValue adds by to the number a script stored under key and
+// returns the new number. A key with no number yet counts from zero. It is
+// one statement, so two callers voting at once both count.
+func (db *DB) IncrementScriptValue(script, key string, by int64) (int64, error) {
+	var n int64
+	err := db.conn.QueryRow(`INSERT INTO script_data (script, key, value, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(script, key) DO UPDATE SET value = CAST(CAST(value AS INTEGER) + ? AS TEXT), updated_at = excluded.updated_at
+		RETURNING CAST(value AS INTEGER)`,
+		script, key, strconv.FormatInt(by, 10), time.Now(), by).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment script value: %w", err)
+	}
+	return n, nil
+}
+
+// DeleteScriptValue removes what a script stored under key
+func (db *DB) DeleteScriptValue(script, key string) error {
+	if _, err := db.conn.Exec(`DELETE FROM script_data WHERE script = ? AND key = ?`, script, key); err != nil {
+		return fmt.Errorf("failed to delete script value: %w", err)
+	}
+	return nil
+}
+
+// ScriptKeys lists the keys a script has stored that start with prefix, in
+// order
+func (db *DB) ScriptKeys(script, prefix string) ([]string, error) {
+	rows, err := db.conn.Query(`SELECT key FROM script_data WHERE script = ? AND substr(key, 1, ?) = ? ORDER BY key`,
+		script, utf8.RuneCountInString(prefix), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list script keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan script key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestScriptValues(t *testing.T) {
+	db := newTestDB(t)
+
+	if _, ok, err := db.GetScriptValue("poll", "question"); err != nil || ok {
+		t.Fatalf("value before setting: ok = %v, err = %v; want none", ok, err)
+	}
+	if err := db.SetScriptValue("poll", "question", "Best editor?"); err != nil {
+		t.Fatalf("SetScriptValue failed: %v", err)
+	}
+	if err := db.SetScriptValue("quiz", "question", "Capital of France?"); err != nil {
+		t.Fatalf("SetScriptValue failed: %v", err)
+	}
+	if value, ok, err := db.GetScriptValue("poll", "question"); err != nil || !ok || value != "Best editor?" {
+		t.Errorf("GetScriptValue = %q, %v, %v; want the poll's own question", value, ok, err)
+	}
+
+	// Votes cast at the same time all count
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := db.IncrementScriptValue("poll", "votes:vi", 1); err != nil {
+				t.Errorf("IncrementScriptValue failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n, err := db.IncrementScriptValue("poll", "votes:vi", 2); err != nil || n != 12 {
+		t.Errorf("IncrementScriptValue = %d, %v; want 12", n, err)
+	}
+	if n, err := db.IncrementScriptValue("poll", "votes:emacs", -1); err != nil || n != -1 {
+		t.Errorf("IncrementScriptValue on a new key = %d, %v; want -1", n, err)
+	}
+	if err := db.SetScriptValue("poll", "voted:alice", "vi"); err != nil {
+		t.Fatalf("SetScriptValue failed: %v", err)
+	}
+
+	keys, err := db.ScriptKeys("poll", "votes:")
+	if err != nil {
+		t.Fatalf("ScriptKeys failed: %v", err)
+	}
+	if want := []string{"votes:emacs", "votes:vi"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("ScriptKeys = %v, want %v", keys, want)
+	}
+
+	if err := db.DeleteScriptValue("poll", "voted:alice"); err != nil {
+		t.Fatalf("DeleteScriptValue failed: %v", err)
+	}
+	keys, err = db.ScriptKeys("poll", "")
+	if err != nil {
+		t.Fatalf("ScriptKeys failed: %v", err)
+	}
+	if want := []string{"question", "votes:emacs", "votes:vi"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("ScriptKeys after delete = %v, want %v", keys, want)
+	}
+}
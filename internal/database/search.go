@@ -0,0 +1,156 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kinds of item a search can find
+const (
+	SearchPost     = "post"
+	SearchBulletin = "bulletin"
+	SearchMessage  = "message"
+)
+
+// The index holds posts, bulletins and mail in one table, so each row's
+// docid is the item's ID times searchKinds plus a number for its kind. The
+// triggers find an item's row by its docid instead of scanning the index.
+const (
+	searchKinds    = 3
+	searchPost     = 0
+	searchBulletin = 1
+	searchMessage  = 2
+)
+
+// SearchResult is one item matching a search
+type SearchResult struct {
+	Kind      string    `json:"kind"`
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author"`
+	Where     string    `json:"where"` // Topic name, "Bulletins" or "Mail"
+	Snippet   string    `json:"snippet"`
+	CreatedAt time.Time `json:"created_at"`
+	Archived  bool      `json:"archived"` // A post moved to its topic's archive, which can't be replied to
+}
+
+// createSearchIndex creates the full-text index and the triggers that keep
+// it in step with posts, bulletins and mail, filling it from the existing
+// rows the first time. The index uses FTS4 because FTS5 is only compiled
+// into the SQLite driver with the sqlite_fts5 build tag.
+func (db *DB) createSearchIndex() error {
+	var existing int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'search_index'`).Scan(&existing); err != nil {
+		return fmt.Errorf("failed to check search index: %w", err)
+	}
+
+	queries := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts4(title, body, tokenize=unicode61)`,
+	}
+	for _, source := range []struct {
+		table, title string
+		kind         int
+	}{
+		{"posts", "subject", searchPost},
+		{"bulletins", "title", searchBulletin},
+		{"messages", "subject", searchMessage},
+	} {
+		docid := func(row string) string {
+			return fmt.Sprintf("%s.id * %d + %d", row, searchKinds, source.kind)
+		}
+		queries = append(queries,
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_search_insert AFTER INSERT ON %[1]s BEGIN
+				INSERT INTO search_index (docid, title, body) VALUES (%[3]s, new.%[2]s, new.body);
+			END`, source.table, source.title, docid("new")),
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_search_update AFTER UPDATE OF %[2]s, body ON %[1]s BEGIN
+				UPDATE search_index SET title = new.%[2]s, body = new.body WHERE docid = %[3]s;
+			END`, source.table, source.title, docid("new")),
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_search_delete AFTER DELETE ON %[1]s BEGIN
+				DELETE FROM search_index WHERE docid = %[2]s;
+			END`, source.table, docid("old")),
+		)
+		if existing == 0 {
+			queries = append(queries, fmt.Sprintf(`INSERT INTO search_index (docid, title, body)
+				SELECT %s, %s, body FROM %s`, docid(source.table), source.title, source.table))
+		}
+	}
+	// Archived posts keep the rows they had in the index as posts
+	if existing == 0 {
+		queries = append(queries, fmt.Sprintf(`INSERT INTO search_index (docid, title, body)
+			SELECT id * %d + %d, subject, body FROM archived_posts`, searchKinds, searchPost))
+	}
+
+	for _, query := range queries {
+		if _, err := db.conn.Exec(query); err != nil {
+			return fmt.Errorf("failed to create search index: %w", err)
+		}
+	}
+	return nil
+}
+
+// Search finds the posts in topics visible at accessLevel, archived or
+// not, the current bulletins and the mail addressed to username that
+// contain every keyword, newest first. Keywords match the start of words, so "modem" finds
+// "modems". It returns a page of results and the number of matches in all.
+func (db *DB) Search(keywords, username string, accessLevel, limit, offset int) ([]SearchResult, int, error) {
+	match := matchQuery(keywords)
+	if match == "" {
+		return nil, 0, nil
+	}
+
+	query := fmt.Sprintf(`WITH hits AS (
+			SELECT docid, snippet(search_index, '', '', '...', 1, 12) AS snippet
+			FROM search_index WHERE search_index MATCH ?
+		)
+		SELECT kind, id, title, author, place, snippet, created_at, archived, COUNT(*) OVER () FROM (
+			SELECT '%[1]s' AS kind, p.id, p.subject AS title, p.author, t.name AS place, h.snippet, p.created_at, 0 AS archived
+			FROM hits h JOIN posts p ON p.id = h.docid / %[4]d AND h.docid %% %[4]d = %[5]d
+			JOIN topics t ON t.id = p.topic_id
+			WHERE t.access_level <= ?
+			UNION ALL
+			SELECT '%[1]s', a.id, a.subject, a.author, t.name, h.snippet, a.created_at, 1
+			FROM hits h JOIN archived_posts a ON a.id = h.docid / %[4]d AND h.docid %% %[4]d = %[5]d
+			JOIN topics t ON t.id = a.topic_id
+			WHERE t.access_level <= ?
+			UNION ALL
+			SELECT '%[2]s', b.id, b.title, b.author, 'Bulletins', h.snippet, COALESCE(b.publish_at, b.created_at), 0
+			FROM hits h JOIN bulletins b ON b.id = h.docid / %[4]d AND h.docid %% %[4]d = %[6]d
+			WHERE `+currentBulletin+`
+			UNION ALL
+			SELECT '%[3]s', m.id, m.subject, m.from_user, 'Mail', h.snippet, m.created_at, 0
+			FROM hits h JOIN messages m ON m.id = h.docid / %[4]d AND h.docid %% %[4]d = %[7]d
+			WHERE m.to_user = ? AND m.deleted_at IS NULL
+		)
+		ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`,
+		SearchPost, SearchBulletin, SearchMessage, searchKinds, searchPost, searchBulletin, searchMessage)
+
+	now := time.Now()
+	rows, err := db.conn.Query(query, match, accessLevel, accessLevel, now, now, username, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	total := 0
+	for rows.Next() {
+		var r SearchResult
+		if err := rows.Scan(&r.Kind, &r.ID, &r.Title, &r.Author, &r.Where, &r.Snippet, &r.CreatedAt, &r.Archived, &total); err != nil {
+			return nil, 0, fmt.Errorf("failed to search: %w", err)
+		}
+		results = append(results, r)
+	}
+	return results, total, rows.Err()
+}
+
+// matchQuery turns what the caller typed into an FTS query that looks for
+// every word as a prefix. Each word is quoted so AND, OR, NOT and
+// characters such as "-" are searched for instead of read as operators.
+func matchQuery(keywords string) string {
+	var terms []string
+	for _, word := range strings.Fields(strings.ReplaceAll(keywords, `"`, " ")) {
+		terms = append(terms, `"`+word+`*"`)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	db := newTestDB(t)
+
+	general := &Topic{Name: "General"}
+	sysops := &Topic{Name: "Sysops", AccessLevel: 255}
+	for _, topic := range []*Topic{general, sysops} {
+		if err := db.CreateTopic(topic); err != nil {
+			t.Fatalf("CreateTopic failed: %v", err)
+		}
+	}
+	post := &Post{TopicID: general.ID, Author: "alice", Subject: "Modem settings", Body: "Try 2400 baud first"}
+	hidden := &Post{TopicID: sysops.ID, Author: "sysop", Subject: "Modem budget", Body: "New modems this spring"}
+	for _, p := range []*Post{post, hidden} {
+		if err := db.CreatePost(p); err != nil {
+			t.Fatalf("CreatePost failed: %v", err)
+		}
+	}
+	if err := db.CreateBulletin(&Bulletin{Title: "Upgrades", Body: "The modem pool grows to eight lines", Author: "sysop"}); err != nil {
+		t.Fatalf("CreateBulletin failed: %v", err)
+	}
+	for _, to := range []string{"bob", "carol"} {
+		if err := db.CreateMessage(&Message{FromUser: "alice", ToUser: to, Subject: "Hi", Body: "Is your modem working?", Area: "private"}); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	results, total, err := db.Search("MODEM", "bob", 0, 10, 0)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if total != 3 || len(results) != 3 {
+		t.Fatalf("found %d of %d results, want bob's mail, the bulletin and the public post: %+v", len(results), total, results)
+	}
+	kinds := map[string]SearchResult{}
+	for _, r := range results {
+		kinds[r.Kind] = r
+	}
+	if r := kinds[SearchPost]; r.ID != post.ID || r.Where != "General" || r.Author != "alice" {
+		t.Errorf("post result = %+v", r)
+	}
+	if r := kinds[SearchMessage]; r.Where != "Mail" || r.Snippet != "Is your modem working?" {
+		t.Errorf("message result = %+v", r)
+	}
+
+	// The sysop sees the restricted topic too, and the word prefix matches "modems"
+	if _, total, _ := db.Search("modem", "sysop", 255, 10, 0); total != 3 {
+		t.Errorf("sysop found %d results, want both posts and the bulletin", total)
+	}
+
+	// Every keyword must match
+	if results, _, _ := db.Search("modem baud", "bob", 0, 10, 0); len(results) != 1 || results[0].ID != post.ID {
+		t.Errorf("two keywords found %+v, want the post only", results)
+	}
+
+	// Paging keeps the total
+	results, total, err = db.Search("modem", "bob", 0, 2, 2)
+	if err != nil || len(results) != 1 || total != 3 {
+		t.Errorf("second page = %d results of %d (%v), want 1 of 3", len(results), total, err)
+	}
+
+	// FTS operators and stray quotes are searched for, not parsed
+	for _, keywords := range []string{`"modem`, "modem AND", "NOT -modem", "*"} {
+		if _, _, err := db.Search(keywords, "bob", 0, 10, 0); err != nil {
+			t.Errorf("Search(%q) failed: %v", keywords, err)
+		}
+	}
+	if results, _, _ := db.Search("   ", "bob", 0, 10, 0); len(results) != 0 {
+		t.Error("blank search found results")
+	}
+
+	// Edits and deletes reach the index
+	if err := db.UpdateBulletin(1, "Upgrades", "More phone lines"); err != nil {
+		t.Fatalf("UpdateBulletin failed: %v", err)
+	}
+	mail, _ := db.GetMessages("bob", 10)
+	if err := db.DeleteMessage("bob", mail[0].ID); err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+	if _, total, _ := db.Search("modem", "bob", 0, 10, 0); total != 1 {
+		t.Errorf("found %d results after the edit and delete, want the post only", total)
+	}
+	if results, _, _ := db.Search("phone", "bob", 0, 10, 0); len(results) != 1 || results[0].Kind != SearchBulletin {
+		t.Errorf("edited bulletin not found by its new text: %+v", results)
+	}
+}
+
+func TestSearchIndexUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = old.Exec(`CREATE TABLE bulletins (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL,
+		author TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME
+	)`)
+	if err == nil {
+		_, err = old.Exec(`INSERT INTO bulletins (title, body, author) VALUES ('Welcome', 'Read the rules', 'sysop')`)
+	}
+	old.Close()
+	if err != nil {
+		t.Fatalf("creating old table: %v", err)
+	}
+
+	db, err := Initialize(path)
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer db.Close()
+
+	results, _, err := db.Search("rules", "", 0, 10, 0)
+	if err != nil || len(results) != 1 || results[0].Title != "Welcome" {
+		t.Errorf("Search = %+v, %v; want the bulletin written before the index", results, err)
+	}
+}
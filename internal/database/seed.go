@@ -1,6 +1,7 @@
 package database
 
 import (
+	"errors"
 	"time"
 )
 
@@ -11,6 +12,13 @@ type BulletinSeed struct {
 	Author string
 }
 
+// TopicSeed represents a message board topic for seeding
+type TopicSeed struct {
+	Name        string
+	Description string
+	AccessLevel int
+}
+
 // UserSeed represents a user for seeding
 type UserSeed struct {
 	Username    string
@@ -21,25 +29,18 @@ type UserSeed struct {
 	IsActive    bool
 }
 
-// getSeedUsers returns the default users for seeding the database
-func getSeedUsers() []UserSeed {
+// getSeedUsers returns the accounts a new board starts with: only the sysop,
+// whose password the caller supplies so no board ships with a known one
+func getSeedUsers(sysopPassword string) []UserSeed {
 	return []UserSeed{
 		{
 			Username:    "sysop",
-			Password:    "password", // In production, use proper password hashing
+			Password:    sysopPassword, // Hashed on insert by CreateUser
 			RealName:    "System Operator",
 			Email:       "sysop@localhost",
 			AccessLevel: 255, // Maximum access level
 			IsActive:    true,
 		},
-		{
-			Username:    "test",
-			Password:    "test",
-			RealName:    "Test User",
-			Email:       "test@localhost",
-			AccessLevel: 10,
-			IsActive:    true,
-		},
 	}
 }
 
@@ -176,14 +177,16 @@ Recent Updates:
 • Improved session management and stability
 • Added modular architecture for easy feature expansion
 • Better error handling and user feedback
+• Public message boards with a full-screen editor
+• File areas with ZMODEM downloads and uploads
+• Door game support with DOOR.SYS and DORINFO1.DEF drop files
+• Node-to-node messages and a multi-channel teleconference
 
 Coming Soon:
 • Enhanced message system with threaded conversations
-• File upload/download areas with descriptions
-• Online games and door game support
-• Real-time chat and instant messaging
+• File descriptions for the file areas
+• Built-in online games
 • User profiles and customizable settings
-• Forum-style message boards
 • File tagging and search capabilities
 
 In Development:
@@ -206,6 +209,63 @@ We love hearing from our users and community.`,
 	}
 }
 
+// getSeedTopics returns the default message board topics
+func getSeedTopics() []TopicSeed {
+	return []TopicSeed{
+		{Name: "General", Description: "General discussion", AccessLevel: 0},
+		{Name: "Tech Talk", Description: "Computers, software, and gadgets", AccessLevel: 0},
+		{Name: "BBS Feedback", Description: "Suggestions and bug reports for this board", AccessLevel: 0},
+	}
+}
+
+// LoadTopicsFromSeed loads default message board topics into the database
+func (db *DB) LoadTopicsFromSeed() error {
+	for _, seedTopic := range getSeedTopics() {
+		exists, err := db.topicExists(seedTopic.Name)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			topic := &Topic{
+				Name:        seedTopic.Name,
+				Description: seedTopic.Description,
+				AccessLevel: seedTopic.AccessLevel,
+			}
+			if err := db.CreateTopic(topic); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getSeedTaglines returns the taglines a new board starts with, approved
+func getSeedTaglines() []string {
+	return []string{
+		"Backup not found: (A)bort (R)etry (P)anic",
+		"A bug in the code is worth two in the documentation.",
+		"Press any key to continue. Where's the any key?",
+		"I'd tell you a UDP joke, but you might not get it.",
+		"Sysops do it in the middle of the night.",
+		"My other computer is a 286.",
+		"Modem: a device for sending lunch money to the phone company.",
+		"CONNECT 2400... NO CARRIER",
+	}
+}
+
+// LoadTaglinesFromSeed loads the default taglines into the database
+func (db *DB) LoadTaglinesFromSeed() error {
+	for _, text := range getSeedTaglines() {
+		err := db.AddTagline(&Tagline{Text: text, Author: "sysop", Approved: true})
+		if err != nil && err != ErrDuplicateTagline {
+			return err
+		}
+	}
+	return nil
+}
+
 // LoadBulletinsFromSeed loads default bulletins into the database
 func (db *DB) LoadBulletinsFromSeed() error {
 	seedBulletins := getSeedBulletins()
@@ -235,16 +295,23 @@ func (db *DB) LoadBulletinsFromSeed() error {
 	return nil
 }
 
-// LoadUsersFromSeed loads default users into the database
-func (db *DB) LoadUsersFromSeed() error {
-	seedUsers := getSeedUsers()
+// ErrNoSysopPassword is returned when seeding is asked to create the sysop
+// account without a password for it
+var ErrNoSysopPassword = errors.New("no sysop password given")
+
+// LoadUsersFromSeed creates the seed accounts that don't exist yet, giving
+// the sysop sysopPassword. It reports whether any account was created.
+func (db *DB) LoadUsersFromSeed(sysopPassword string) (bool, error) {
+	if sysopPassword == "" {
+		return false, ErrNoSysopPassword
+	}
 
-	// Insert users into database
-	for _, seedUser := range seedUsers {
+	created := false
+	for _, seedUser := range getSeedUsers(sysopPassword) {
 		// Check if user already exists (by username)
 		exists, err := db.userExists(seedUser.Username)
 		if err != nil {
-			return err
+			return created, err
 		}
 
 		if !exists {
@@ -259,12 +326,50 @@ func (db *DB) LoadUsersFromSeed() error {
 			}
 
 			if err := db.CreateUser(user); err != nil {
-				return err
+				return created, err
 			}
+			created = true
 		}
 	}
 
-	return nil
+	return created, nil
+}
+
+// SeedIfEmpty loads the sysop account, bulletins, topics and taglines into a
+// database that has no users yet, so a fresh install works without running
+// setup first. The sysop gets sysopPassword, or a random password if that is
+// empty, which is returned as generated so it can be shown once. It reports
+// whether seeding took place.
+func (db *DB) SeedIfEmpty(sysopPassword string) (seeded bool, generated string, err error) {
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return false, "", err
+	}
+	if count > 0 {
+		return false, "", nil
+	}
+
+	if sysopPassword == "" {
+		if generated, err = GeneratePassword(); err != nil {
+			return false, "", err
+		}
+		sysopPassword = generated
+	}
+
+	if _, err := db.LoadUsersFromSeed(sysopPassword); err != nil {
+		return false, "", err
+	}
+	if err := db.LoadBulletinsFromSeed(); err != nil {
+		return false, "", err
+	}
+	if err := db.LoadTopicsFromSeed(); err != nil {
+		return false, "", err
+	}
+	if err := db.LoadTaglinesFromSeed(); err != nil {
+		return false, "", err
+	}
+
+	return true, generated, nil
 }
 
 // userExists checks if a user with the given username already exists
@@ -288,3 +393,14 @@ func (db *DB) bulletinExists(title string) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// topicExists checks if a topic with the given name already exists
+func (db *DB) topicExists(name string) (bool, error) {
+	query := `SELECT COUNT(*) FROM topics WHERE name = ?`
+	var count int
+	err := db.conn.QueryRow(query, name).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
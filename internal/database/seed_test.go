@@ -0,0 +1,50 @@
+package database
+
+import "testing"
+
+func TestSeedIfEmpty_GeneratesSysopPassword(t *testing.T) {
+	db := newTestDB(t)
+
+	seeded, generated, err := db.SeedIfEmpty("")
+	if err != nil {
+		t.Fatalf("SeedIfEmpty failed: %v", err)
+	}
+	if !seeded || generated == "" {
+		t.Fatalf("SeedIfEmpty = %v, %q; want a seeded board and a generated password", seeded, generated)
+	}
+	if _, err := db.VerifyPassword("sysop", generated); err != nil {
+		t.Errorf("the generated password doesn't open the sysop account: %v", err)
+	}
+	if _, err := db.VerifyPassword("sysop", "password"); err == nil {
+		t.Error("the sysop account opened with the old default password")
+	}
+	if _, err := db.GetUser("test"); err == nil {
+		t.Error("a demo account was seeded")
+	}
+
+	// A board with users is left alone
+	seeded, generated, err = db.SeedIfEmpty("")
+	if err != nil || seeded || generated != "" {
+		t.Errorf("second SeedIfEmpty = %v, %q, %v; want nothing done", seeded, generated, err)
+	}
+}
+
+func TestSeedIfEmpty_GivenSysopPassword(t *testing.T) {
+	db := newTestDB(t)
+
+	seeded, generated, err := db.SeedIfEmpty("changeme")
+	if err != nil || !seeded || generated != "" {
+		t.Fatalf("SeedIfEmpty = %v, %q, %v; want seeded with no generated password", seeded, generated, err)
+	}
+	if _, err := db.VerifyPassword("sysop", "changeme"); err != nil {
+		t.Errorf("the given password doesn't open the sysop account: %v", err)
+	}
+}
+
+func TestLoadUsersFromSeed_NeedsPassword(t *testing.T) {
+	db := newTestDB(t)
+
+	if _, err := db.LoadUsersFromSeed(""); err != ErrNoSysopPassword {
+		t.Errorf("LoadUsersFromSeed(\"\") = %v, want ErrNoSysopPassword", err)
+	}
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DailyStats counts what happened on the board on one day
+type DailyStats struct {
+	Day      time.Time // Local midnight
+	Calls    int
+	NewUsers int
+	Posts    int
+	Replies  int
+	Mail     int
+}
+
+// RollupDailyStats counts the calls, new accounts, posts, replies and mail
+// of the day starting at day, local midnight, and keeps the counts,
+// replacing any made before. They outlast the caller log entries and
+// messages they were counted from.
+func (db *DB) RollupDailyStats(day time.Time) (*DailyStats, error) {
+	end := day.AddDate(0, 0, 1)
+	stats := &DailyStats{Day: day}
+	query := `SELECT
+			  (SELECT COUNT(*) FROM caller_log WHERE login_at >= ? AND login_at < ?),
+			  (SELECT COUNT(*) FROM users WHERE created_at >= ? AND created_at < ?),
+			  (SELECT COUNT(*) FROM posts WHERE created_at >= ? AND created_at < ?)
+			  + (SELECT COUNT(*) FROM archived_posts WHERE created_at >= ? AND created_at < ?),
+			  (SELECT COUNT(*) FROM replies WHERE created_at >= ? AND created_at < ?)
+			  + (SELECT COUNT(*) FROM archived_replies WHERE created_at >= ? AND created_at < ?),
+			  (SELECT COUNT(*) FROM messages WHERE created_at >= ? AND created_at < ?)`
+	var args []interface{}
+	for i := 0; i < 7; i++ {
+		args = append(args, day, end)
+	}
+	err := db.conn.QueryRow(query, args...).Scan(&stats.Calls, &stats.NewUsers, &stats.Posts, &stats.Replies, &stats.Mail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count daily stats: %w", err)
+	}
+
+	_, err = db.conn.Exec(`INSERT INTO daily_stats (day, calls, new_users, posts, replies, mail)
+			  VALUES (?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(day) DO UPDATE SET calls = excluded.calls, new_users = excluded.new_users,
+			  posts = excluded.posts, replies = excluded.replies, mail = excluded.mail`,
+		timeDay(day), stats.Calls, stats.NewUsers, stats.Posts, stats.Replies, stats.Mail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save daily stats: %w", err)
+	}
+	return stats, nil
+}
+
+// LastStatsDay returns the newest day rolled up, or nil if none has been
+func (db *DB) LastStatsDay() (*time.Time, error) {
+	var day sql.NullString
+	if err := db.conn.QueryRow(`SELECT MAX(day) FROM daily_stats`).Scan(&day); err != nil {
+		return nil, fmt.Errorf("failed to get last stats day: %w", err)
+	}
+	if !day.Valid {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", day.String, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stats day %q: %w", day.String, err)
+	}
+	return &t, nil
+}
+
+// GetDailyStats returns the days rolled up from since on, oldest first
+func (db *DB) GetDailyStats(since time.Time) ([]DailyStats, error) {
+	rows, err := db.conn.Query(`SELECT day, calls, new_users, posts, replies, mail
+			  FROM daily_stats WHERE day >= ? ORDER BY day`, timeDay(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily stats: %w", err)
+	}
+	defer rows.Close()
+
+	var days []DailyStats
+	for rows.Next() {
+		var day string
+		var stats DailyStats
+		if err := rows.Scan(&day, &stats.Calls, &stats.NewUsers, &stats.Posts, &stats.Replies, &stats.Mail); err != nil {
+			return nil, fmt.Errorf("failed to scan daily stats: %w", err)
+		}
+		if stats.Day, err = time.ParseInLocation("2006-01-02", day, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid stats day %q: %w", day, err)
+		}
+		days = append(days, stats)
+	}
+	return days, rows.Err()
+}
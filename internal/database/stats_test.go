@@ -0,0 +1,66 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRollupDailyStats(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	yesterday := today.AddDate(0, 0, -1)
+
+	if last, err := db.LastStatsDay(); err != nil || last != nil {
+		t.Fatalf("LastStatsDay on an empty table = %v, %v; want nil", last, err)
+	}
+
+	if _, err := db.StartCall("alice", 1); err != nil {
+		t.Fatalf("StartCall failed: %v", err)
+	}
+	if _, err := db.conn.Exec(`INSERT INTO caller_log (username, node, login_at) VALUES ('bob', 2, ?)`,
+		yesterday.Add(13*time.Hour)); err != nil {
+		t.Fatalf("insert yesterday's call: %v", err)
+	}
+	topic := &Topic{Name: "General"}
+	if err := db.CreateTopic(topic); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	post := &Post{TopicID: topic.ID, Author: "alice", Subject: "Hi", Body: "Hello"}
+	if err := db.CreatePost(post); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	if err := db.CreateReply(&Reply{PostID: post.ID, Author: "bob", Body: "Hey"}); err != nil {
+		t.Fatalf("CreateReply failed: %v", err)
+	}
+
+	if _, err := db.RollupDailyStats(yesterday); err != nil {
+		t.Fatalf("RollupDailyStats failed: %v", err)
+	}
+	stats, err := db.RollupDailyStats(today)
+	if err != nil {
+		t.Fatalf("RollupDailyStats failed: %v", err)
+	}
+	if stats.Calls != 1 || stats.Posts != 1 || stats.Replies != 1 {
+		t.Errorf("today's stats = %+v, want 1 call, 1 post and 1 reply", stats)
+	}
+
+	// Rolling a day up again replaces its counts
+	if _, err := db.StartCall("carol", 3); err != nil {
+		t.Fatalf("StartCall failed: %v", err)
+	}
+	if _, err := db.RollupDailyStats(today); err != nil {
+		t.Fatalf("RollupDailyStats failed: %v", err)
+	}
+
+	days, err := db.GetDailyStats(yesterday)
+	if err != nil {
+		t.Fatalf("GetDailyStats failed: %v", err)
+	}
+	if len(days) != 2 || !days[0].Day.Equal(yesterday) || days[0].Calls != 1 || days[0].Posts != 0 || days[1].Calls != 2 {
+		t.Errorf("GetDailyStats = %+v, want yesterday's call, then today's two", days)
+	}
+	if last, err := db.LastStatsDay(); err != nil || last == nil || !last.Equal(today) {
+		t.Errorf("LastStatsDay = %v, %v; want %s", last, err, today)
+	}
+}
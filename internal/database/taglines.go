@@ -0,0 +1,120 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDuplicateTagline is returned when a tagline is already on file,
+// approved or waiting
+var ErrDuplicateTagline = errors.New("that tagline is already on file")
+
+// Tagline is a one-liner shown at the foot of posts or on the goodbye screen
+type Tagline struct {
+	ID        int
+	Text      string
+	Author    string // Who sent it in
+	Approved  bool   // Only approved taglines are shown
+	CreatedAt time.Time
+}
+
+// AddTagline stores a tagline, approved straight away or waiting for the
+// sysop. The same text can't be added twice, whatever its case.
+func (db *DB) AddTagline(t *Tagline) error {
+	var exists int
+	err := db.conn.QueryRow(`SELECT 1 FROM taglines WHERE lower(text) = lower(?)`, t.Text).Scan(&exists)
+	if err == nil {
+		return ErrDuplicateTagline
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check taglines: %w", err)
+	}
+
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	result, err := db.conn.Exec(`INSERT INTO taglines (text, author, approved, created_at) VALUES (?, ?, ?, ?)`,
+		t.Text, t.Author, t.Approved, t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save tagline: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	t.ID = int(id)
+	return nil
+}
+
+// GetTaglines returns the approved taglines, or those waiting for the
+// sysop, oldest first
+func (db *DB) GetTaglines(approved bool) ([]Tagline, error) {
+	rows, err := db.conn.Query(`SELECT id, text, author, approved, created_at FROM taglines
+		WHERE approved = ? ORDER BY created_at, id`, approved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var taglines []Tagline
+	for rows.Next() {
+		var t Tagline
+		if err := rows.Scan(&t.ID, &t.Text, &t.Author, &t.Approved, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		taglines = append(taglines, t)
+	}
+	return taglines, rows.Err()
+}
+
+// CountPendingTaglines counts the taglines waiting for the sysop, sent in
+// by author or, if author is empty, by anyone
+func (db *DB) CountPendingTaglines(author string) (int, error) {
+	var count int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM taglines WHERE approved = 0 AND (? = '' OR author = ?)`,
+		author, author).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count taglines: %w", err)
+	}
+	return count, nil
+}
+
+// ApproveTagline lets a waiting tagline be shown
+func (db *DB) ApproveTagline(id int) error {
+	result, err := db.conn.Exec(`UPDATE taglines SET approved = 1 WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to approve tagline: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("no tagline with id %d", id)
+	}
+	return nil
+}
+
+// DeleteTagline removes a tagline, approved or not
+func (db *DB) DeleteTagline(id int) error {
+	result, err := db.conn.Exec(`DELETE FROM taglines WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tagline: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("no tagline with id %d", id)
+	}
+	return nil
+}
+
+// RandomTagline returns one of the approved taglines at random, or "" if
+// there are none
+func (db *DB) RandomTagline() (string, error) {
+	var text string
+	err := db.conn.QueryRow(`SELECT text FROM taglines WHERE approved = 1 ORDER BY RANDOM() LIMIT 1`).Scan(&text)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to pick a tagline: %w", err)
+	}
+	return text, nil
+}
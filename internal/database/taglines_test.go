@@ -0,0 +1,56 @@
+package database
+
+import "testing"
+
+func TestTaglines(t *testing.T) {
+	db := newTestDB(t)
+
+	if text, err := db.RandomTagline(); err != nil || text != "" {
+		t.Fatalf("RandomTagline() with none = %q, %v; want none", text, err)
+	}
+
+	shown := &Tagline{Text: "My other computer is a 286.", Author: "sysop", Approved: true}
+	waiting := &Tagline{Text: "CONNECT 2400", Author: "alice"}
+	for _, tl := range []*Tagline{shown, waiting} {
+		if err := db.AddTagline(tl); err != nil {
+			t.Fatalf("AddTagline failed: %v", err)
+		}
+	}
+	if err := db.AddTagline(&Tagline{Text: "connect 2400", Author: "bob"}); err != ErrDuplicateTagline {
+		t.Errorf("adding a tagline twice = %v, want ErrDuplicateTagline", err)
+	}
+
+	// Only approved taglines are shown
+	for i := 0; i < 5; i++ {
+		if text, err := db.RandomTagline(); err != nil || text != shown.Text {
+			t.Fatalf("RandomTagline() = %q, %v; want %q", text, err, shown.Text)
+		}
+	}
+	if n, err := db.CountPendingTaglines("alice"); err != nil || n != 1 {
+		t.Errorf("CountPendingTaglines(alice) = %d, %v; want 1", n, err)
+	}
+	if n, err := db.CountPendingTaglines("bob"); err != nil || n != 0 {
+		t.Errorf("CountPendingTaglines(bob) = %d, %v; want 0", n, err)
+	}
+
+	if err := db.ApproveTagline(waiting.ID); err != nil {
+		t.Fatalf("ApproveTagline failed: %v", err)
+	}
+	approved, err := db.GetTaglines(true)
+	if err != nil || len(approved) != 2 {
+		t.Fatalf("GetTaglines(true) = %v, %v; want both", approved, err)
+	}
+	if pending, err := db.GetTaglines(false); err != nil || len(pending) != 0 {
+		t.Errorf("GetTaglines(false) = %v, %v; want none", pending, err)
+	}
+
+	if err := db.DeleteTagline(shown.ID); err != nil {
+		t.Fatalf("DeleteTagline failed: %v", err)
+	}
+	if err := db.DeleteTagline(shown.ID); err == nil {
+		t.Error("deleting a deleted tagline succeeded")
+	}
+	if approved, _ := db.GetTaglines(true); len(approved) != 1 || approved[0].ID != waiting.ID {
+		t.Errorf("taglines after deleting = %v, want only %q", approved, waiting.Text)
+	}
+}
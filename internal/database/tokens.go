@@ -0,0 +1,161 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// APIToken represents a personal access token used by the HTTP API
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// apiTokensTable is the token table, keyed by user id so a token never
+// passes to someone who later registers a deleted user's name
+const apiTokensTable = `CREATE TABLE IF NOT EXISTS api_tokens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			name TEXT NOT NULL,
+			token_hash TEXT UNIQUE NOT NULL,
+			prefix TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_used_at DATETIME,
+			revoked BOOLEAN DEFAULT 0
+		)`
+
+// createAPITokens creates the token table. A table from before tokens were
+// keyed by user id is rebuilt, and tokens whose username no longer belongs
+// to an account are dropped.
+func (db *DB) createAPITokens() error {
+	var oldColumns int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('api_tokens') WHERE name = 'username'`).Scan(&oldColumns)
+	if err != nil {
+		return fmt.Errorf("failed to check api tokens: %w", err)
+	}
+	if oldColumns == 0 {
+		if _, err := db.conn.Exec(apiTokensTable); err != nil {
+			return fmt.Errorf("failed to create api tokens: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, query := range []string{
+		`ALTER TABLE api_tokens RENAME TO api_tokens_by_name`,
+		apiTokensTable,
+		`INSERT INTO api_tokens (id, user_id, name, token_hash, prefix, created_at, last_used_at, revoked)
+			SELECT t.id, u.id, t.name, t.token_hash, t.prefix, t.created_at, t.last_used_at, t.revoked
+			FROM api_tokens_by_name t JOIN users u ON u.username = t.username`,
+		`DROP TABLE api_tokens_by_name`,
+	} {
+		if _, err := tx.Exec(query); err != nil {
+			return fmt.Errorf("failed to key api tokens by user: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// tokenPrefixLength is how much of a token is kept in clear text so users can tell tokens apart
+const tokenPrefixLength = 8
+
+// hashToken returns the stored form of a token; only the hash is ever persisted
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken generates a new token for a user and returns the plaintext value.
+// The plaintext is only available at creation time.
+func (db *DB) CreateAPIToken(userID int, name string) (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := "cbt_" + hex.EncodeToString(raw)
+
+	query := `INSERT INTO api_tokens (user_id, name, token_hash, prefix, created_at)
+			  VALUES (?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(query, userID, name, hashToken(token), token[:len("cbt_")+tokenPrefixLength], time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// GetAPITokens lists the active tokens belonging to a user
+func (db *DB) GetAPITokens(userID int) ([]APIToken, error) {
+	query := `SELECT id, user_id, name, prefix, created_at, last_used_at
+			  FROM api_tokens WHERE user_id = ? AND revoked = 0
+			  ORDER BY created_at`
+
+	rows, err := db.conn.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []APIToken
+	for rows.Next() {
+		var token APIToken
+		if err := rows.Scan(&token.ID, &token.UserID, &token.Name, &token.Prefix,
+			&token.CreatedAt, &token.LastUsedAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+// RevokeAPIToken revokes one of a user's tokens. Tokens owned by other users are not touched.
+func (db *DB) RevokeAPIToken(userID, id int) error {
+	query := `UPDATE api_tokens SET revoked = 1 WHERE id = ? AND user_id = ? AND revoked = 0`
+	result, err := db.conn.Exec(query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("token not found")
+	}
+	return nil
+}
+
+// AuthenticateAPIToken resolves a plaintext token to its active owner, whose
+// account hasn't expired, and records its use
+func (db *DB) AuthenticateAPIToken(token string) (*User, error) {
+	var id, userID int
+	query := `SELECT id, user_id FROM api_tokens WHERE token_hash = ? AND revoked = 0`
+	if err := db.conn.QueryRow(query, hashToken(token)).Scan(&id, &userID); err != nil {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	user, err := db.GetUserByID(userID)
+	if err != nil || !user.IsActive {
+		return nil, fmt.Errorf("invalid token")
+	}
+	if _, err := db.CheckExpiry(user, time.Now()); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	db.conn.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, time.Now(), id)
+
+	return user, nil
+}
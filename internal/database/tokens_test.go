@@ -0,0 +1,73 @@
+package database
+
+import "testing"
+
+func TestDeletedAccountsTokensDontPassOn(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", AccessLevel: 10}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	alice, _ := db.GetUser("alice")
+	token, err := db.CreateAPIToken(alice.ID, "scripts")
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+	if err := db.DeleteAccount(alice.ID); err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+
+	// Someone else takes the name
+	if err := db.CreateUser(&User{Username: "alice", Password: "other", AccessLevel: 10}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if user, err := db.AuthenticateAPIToken(token); err == nil {
+		t.Errorf("the deleted account's token signed in as %+v", user)
+	}
+	newAlice, _ := db.GetUser("alice")
+	if tokens, _ := db.GetAPITokens(newAlice.ID); len(tokens) != 0 {
+		t.Errorf("the new account inherited tokens: %+v", tokens)
+	}
+}
+
+func TestAPITokensTableUpgrade(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", AccessLevel: 10}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	for _, query := range []string{
+		`DROP TABLE api_tokens`,
+		`CREATE TABLE api_tokens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			username TEXT NOT NULL,
+			name TEXT NOT NULL,
+			token_hash TEXT UNIQUE NOT NULL,
+			prefix TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_used_at DATETIME,
+			revoked BOOLEAN DEFAULT 0
+		)`,
+	} {
+		if _, err := db.conn.Exec(query); err != nil {
+			t.Fatalf("creating old table: %v", err)
+		}
+	}
+	for _, owner := range []string{"alice", "ghost"} {
+		_, err := db.conn.Exec(`INSERT INTO api_tokens (username, name, token_hash, prefix) VALUES (?, 'scripts', ?, 'cbt_')`,
+			owner, hashToken("cbt_"+owner))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.createAPITokens(); err != nil {
+		t.Fatalf("createAPITokens failed: %v", err)
+	}
+	if user, err := db.AuthenticateAPIToken("cbt_alice"); err != nil || user.Username != "alice" {
+		t.Errorf("alice's token after the upgrade = %+v, %v", user, err)
+	}
+	if _, err := db.AuthenticateAPIToken("cbt_ghost"); err == nil {
+		t.Error("a token for a name with no account survived the upgrade")
+	}
+}
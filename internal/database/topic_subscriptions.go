@@ -0,0 +1,85 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TopicSubscription is a topic a user follows, for scripts and notification
+// bridges that watch for new posts
+type TopicSubscription struct {
+	TopicID      int       `json:"topic_id"`
+	Topic        string    `json:"topic"`
+	NewPosts     int       `json:"new_posts"`
+	SubscribedAt time.Time `json:"subscribed_at"`
+}
+
+// GetTopicSubscriptions returns the topics a user follows that are visible at
+// accessLevel, with how many posts in each they haven't read
+func (db *DB) GetTopicSubscriptions(userID, accessLevel int) ([]TopicSubscription, error) {
+	rows, err := db.conn.Query(`SELECT t.id, t.name, s.created_at
+			  FROM topic_subscriptions s JOIN topics t ON t.id = s.topic_id
+			  WHERE s.user_id = ? AND t.access_level <= ? ORDER BY t.id`, userID, accessLevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []TopicSubscription
+	for rows.Next() {
+		var sub TopicSubscription
+		if err := rows.Scan(&sub.TopicID, &sub.Topic, &sub.SubscribedAt); err != nil {
+			return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	counts, err := db.GetNewPostCounts(userID, accessLevel)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i].NewPosts = counts[subscriptions[i].TopicID]
+	}
+	return subscriptions, nil
+}
+
+// SubscribeTopic makes a user follow a topic visible at accessLevel. Following
+// a topic twice is not an error; a topic they can't see is sql.ErrNoRows.
+func (db *DB) SubscribeTopic(userID, accessLevel, topicID int) error {
+	var visible int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM topics WHERE id = ? AND access_level <= ?`, topicID, accessLevel).Scan(&visible)
+	if err != nil {
+		return fmt.Errorf("failed to check topic: %w", err)
+	}
+	if visible == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = db.conn.Exec(`INSERT OR IGNORE INTO topic_subscriptions (user_id, topic_id, created_at) VALUES (?, ?, ?)`,
+		userID, topicID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+	return nil
+}
+
+// UnsubscribeTopic stops a user following a topic. It returns sql.ErrNoRows if
+// they didn't follow it.
+func (db *DB) UnsubscribeTopic(userID, topicID int) error {
+	result, err := db.conn.Exec(`DELETE FROM topic_subscriptions WHERE user_id = ? AND topic_id = ?`, userID, topicID)
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
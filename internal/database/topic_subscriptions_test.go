@@ -0,0 +1,52 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestTopicSubscriptions(t *testing.T) {
+	db := newTestDB(t)
+
+	general := &Topic{Name: "General"}
+	staff := &Topic{Name: "Staff", AccessLevel: 100}
+	for _, topic := range []*Topic{general, staff} {
+		if err := db.CreateTopic(topic); err != nil {
+			t.Fatalf("CreateTopic failed: %v", err)
+		}
+	}
+	for _, subject := range []string{"One", "Two"} {
+		if err := db.CreatePost(&Post{TopicID: general.ID, Author: "bob", Subject: subject, Body: "Hi"}); err != nil {
+			t.Fatalf("CreatePost failed: %v", err)
+		}
+	}
+
+	if err := db.SubscribeTopic(1, 10, general.ID); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if err := db.SubscribeTopic(1, 10, general.ID); err != nil {
+		t.Errorf("subscribing twice failed: %v", err)
+	}
+	if err := db.SubscribeTopic(1, 10, staff.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("subscribing to a hidden topic = %v, want sql.ErrNoRows", err)
+	}
+
+	subs, err := db.GetTopicSubscriptions(1, 10)
+	if err != nil {
+		t.Fatalf("GetTopicSubscriptions failed: %v", err)
+	}
+	if len(subs) != 1 || subs[0].Topic != "General" || subs[0].NewPosts != 2 {
+		t.Fatalf("GetTopicSubscriptions = %+v, want General with 2 new posts", subs)
+	}
+
+	if err := db.UnsubscribeTopic(1, general.ID); err != nil {
+		t.Fatalf("Unsubscribe failed: %v", err)
+	}
+	if err := db.UnsubscribeTopic(1, general.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("unsubscribing twice = %v, want sql.ErrNoRows", err)
+	}
+	if subs, _ := db.GetTopicSubscriptions(1, 10); len(subs) != 0 {
+		t.Errorf("GetTopicSubscriptions after unsubscribing = %+v", subs)
+	}
+}
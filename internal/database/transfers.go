@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Transfer directions recorded in the file_transfers table
+const (
+	TransferUpload   = "upload"
+	TransferDownload = "download"
+)
+
+// TransferTotals summarizes a user's file transfers for ratio accounting
+type TransferTotals struct {
+	Uploads       int   `json:"uploads"`
+	UploadBytes   int64 `json:"upload_bytes"`
+	Downloads     int   `json:"downloads"`
+	DownloadBytes int64 `json:"download_bytes"`
+}
+
+// RecordTransfer logs a completed upload or download
+func (db *DB) RecordTransfer(username, area, filename, direction string, bytes int64) error {
+	query := `INSERT INTO file_transfers (username, area, filename, direction, bytes, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err := db.conn.Exec(query, username, area, filename, direction, bytes, time.Now()); err != nil {
+		return fmt.Errorf("failed to record transfer: %w", err)
+	}
+	return nil
+}
+
+// GetTransferTotals returns how many files and bytes a user has uploaded and downloaded
+func (db *DB) GetTransferTotals(username string) (*TransferTotals, error) {
+	query := `SELECT
+			  COALESCE(SUM(CASE WHEN direction = ? THEN 1 END), 0),
+			  COALESCE(SUM(CASE WHEN direction = ? THEN bytes END), 0),
+			  COALESCE(SUM(CASE WHEN direction = ? THEN 1 END), 0),
+			  COALESCE(SUM(CASE WHEN direction = ? THEN bytes END), 0)
+			  FROM file_transfers WHERE username = ?`
+
+	totals := &TransferTotals{}
+	err := db.conn.QueryRow(query, TransferUpload, TransferUpload, TransferDownload, TransferDownload, username).
+		Scan(&totals.Uploads, &totals.UploadBytes, &totals.Downloads, &totals.DownloadBytes)
+	if err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
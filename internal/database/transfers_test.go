@@ -0,0 +1,29 @@
+package database
+
+import "testing"
+
+func TestTransferTotals(t *testing.T) {
+	db := newTestDB(t)
+
+	totals, err := db.GetTransferTotals("alice")
+	if err != nil {
+		t.Fatalf("GetTransferTotals failed: %v", err)
+	}
+	if *totals != (TransferTotals{}) {
+		t.Errorf("expected zero totals for a new user, got %+v", totals)
+	}
+
+	db.RecordTransfer("alice", "games", "doom.zip", TransferDownload, 1000)
+	db.RecordTransfer("alice", "games", "quake.zip", TransferDownload, 500)
+	db.RecordTransfer("alice", "uploads", "mine.zip", TransferUpload, 300)
+	db.RecordTransfer("bob", "games", "doom.zip", TransferDownload, 1000)
+
+	totals, err = db.GetTransferTotals("alice")
+	if err != nil {
+		t.Fatalf("GetTransferTotals failed: %v", err)
+	}
+	want := TransferTotals{Uploads: 1, UploadBytes: 300, Downloads: 2, DownloadBytes: 1500}
+	if *totals != want {
+		t.Errorf("got %+v, want %+v", *totals, want)
+	}
+}
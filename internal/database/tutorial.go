@@ -0,0 +1,60 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TutorialProgress is how far a user has got through the keyboard tutorial
+type TutorialProgress struct {
+	Step        int        // Lessons finished
+	CompletedAt *time.Time // When the last lesson was finished, nil if not yet
+	Dismissed   bool       // The user asked not to be offered it again
+}
+
+// GetTutorialProgress returns a user's tutorial progress, or nil if they
+// have never started or dismissed it
+func (db *DB) GetTutorialProgress(userID int) (*TutorialProgress, error) {
+	p := &TutorialProgress{}
+	err := db.conn.QueryRow(`SELECT step, completed_at, dismissed FROM tutorial_progress WHERE user_id = ?`, userID).
+		Scan(&p.Step, &p.CompletedAt, &p.Dismissed)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tutorial progress: %w", err)
+	}
+	return p, nil
+}
+
+// SaveTutorialStep records that a user has finished step lessons
+func (db *DB) SaveTutorialStep(userID, step int) error {
+	_, err := db.conn.Exec(`INSERT INTO tutorial_progress (user_id, step) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET step = excluded.step`, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to save tutorial progress: %w", err)
+	}
+	return nil
+}
+
+// CompleteTutorial records that a user finished the last of steps lessons
+func (db *DB) CompleteTutorial(userID, steps int, now time.Time) error {
+	_, err := db.conn.Exec(`INSERT INTO tutorial_progress (user_id, step, completed_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET step = excluded.step, completed_at = excluded.completed_at`, userID, steps, now)
+	if err != nil {
+		return fmt.Errorf("failed to complete tutorial: %w", err)
+	}
+	return nil
+}
+
+// DismissTutorial records that a user doesn't want to be offered the
+// tutorial again. They can still take it from the Help menu.
+func (db *DB) DismissTutorial(userID int) error {
+	_, err := db.conn.Exec(`INSERT INTO tutorial_progress (user_id, dismissed) VALUES (?, 1)
+		ON CONFLICT(user_id) DO UPDATE SET dismissed = 1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to dismiss tutorial: %w", err)
+	}
+	return nil
+}
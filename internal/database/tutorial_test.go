@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTutorialProgress(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", IsActive: true}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := db.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+
+	p, err := db.GetTutorialProgress(user.ID)
+	if err != nil || p != nil {
+		t.Fatalf("progress before starting = %+v, %v; want nil", p, err)
+	}
+
+	if err := db.SaveTutorialStep(user.ID, 2); err != nil {
+		t.Fatalf("SaveTutorialStep failed: %v", err)
+	}
+	if err := db.DismissTutorial(user.ID); err != nil {
+		t.Fatalf("DismissTutorial failed: %v", err)
+	}
+	p, err = db.GetTutorialProgress(user.ID)
+	if err != nil {
+		t.Fatalf("GetTutorialProgress failed: %v", err)
+	}
+	if p.Step != 2 || !p.Dismissed || p.CompletedAt != nil {
+		t.Errorf("progress = %+v, want step 2, dismissed, not completed", p)
+	}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := db.CompleteTutorial(user.ID, 5, now); err != nil {
+		t.Fatalf("CompleteTutorial failed: %v", err)
+	}
+	p, err = db.GetTutorialProgress(user.ID)
+	if err != nil {
+		t.Fatalf("GetTutorialProgress failed: %v", err)
+	}
+	if p.Step != 5 || p.CompletedAt == nil || !p.CompletedAt.Equal(now) {
+		t.Errorf("progress = %+v, want step 5 completed at %v", p, now)
+	}
+
+	// Deleting the account removes the progress with it
+	if err := db.DeleteAccount(user.ID); err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+	if p, err := db.GetTutorialProgress(user.ID); err != nil || p != nil {
+		t.Errorf("progress after deletion = %+v, %v; want nil", p, err)
+	}
+}
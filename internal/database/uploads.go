@@ -0,0 +1,129 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PendingUpload is a file uploaded to a reviewed area, waiting for a sysop
+// to approve it before it is listed
+type PendingUpload struct {
+	ID         int
+	Area       string
+	Filename   string
+	Uploader   string
+	Bytes      int64
+	UploadedAt time.Time
+}
+
+// AddPendingUpload queues an upload for review
+func (db *DB) AddPendingUpload(upload *PendingUpload) error {
+	if upload.UploadedAt.IsZero() {
+		upload.UploadedAt = time.Now()
+	}
+	result, err := db.conn.Exec(`INSERT INTO pending_uploads (area, filename, uploader, bytes, uploaded_at) VALUES (?, ?, ?, ?, ?)`,
+		upload.Area, upload.Filename, upload.Uploader, upload.Bytes, upload.UploadedAt)
+	if err != nil {
+		return fmt.Errorf("failed to queue upload: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	upload.ID = int(id)
+	return nil
+}
+
+// GetPendingUploads returns the uploads waiting for review, oldest first
+func (db *DB) GetPendingUploads() ([]PendingUpload, error) {
+	rows, err := db.conn.Query(`SELECT id, area, filename, uploader, bytes, uploaded_at
+		FROM pending_uploads ORDER BY uploaded_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pending uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var uploads []PendingUpload
+	for rows.Next() {
+		var u PendingUpload
+		if err := rows.Scan(&u.ID, &u.Area, &u.Filename, &u.Uploader, &u.Bytes, &u.UploadedAt); err != nil {
+			return nil, fmt.Errorf("failed to load pending uploads: %w", err)
+		}
+		uploads = append(uploads, u)
+	}
+	return uploads, rows.Err()
+}
+
+// CountPendingUploads returns how many uploads are waiting for review
+func (db *DB) CountPendingUploads() (int, error) {
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM pending_uploads`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count pending uploads: %w", err)
+	}
+	return count, nil
+}
+
+// ApproveUpload takes an upload off the queue, credits it to the uploader
+// for their ratio and tells them it is listed
+func (db *DB) ApproveUpload(id int, reviewer string) error {
+	return db.reviewUpload(id, func(tx *sql.Tx, u *PendingUpload) error {
+		if _, err := tx.Exec(`INSERT INTO file_transfers (username, area, filename, direction, bytes, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			u.Uploader, u.Area, u.Filename, TransferUpload, u.Bytes, time.Now()); err != nil {
+			return fmt.Errorf("failed to record transfer: %w", err)
+		}
+		body := fmt.Sprintf("Thanks for uploading %s. It has been approved and is now listed in %s.", u.Filename, u.Area)
+		return mailUploader(tx, reviewer, u, "Upload approved: "+u.Filename, body)
+	})
+}
+
+// RejectUpload takes an upload off the queue, forgets its description and
+// mails the uploader the reason. The file itself is left for the caller to
+// delete.
+func (db *DB) RejectUpload(id int, reviewer, reason string) error {
+	return db.reviewUpload(id, func(tx *sql.Tx, u *PendingUpload) error {
+		if _, err := tx.Exec(`DELETE FROM file_descriptions WHERE area = ? AND filename = ?`, u.Area, u.Filename); err != nil {
+			return fmt.Errorf("failed to remove description: %w", err)
+		}
+		body := fmt.Sprintf("Your upload %s to %s was not accepted.\n\nReason: %s", u.Filename, u.Area, reason)
+		return mailUploader(tx, reviewer, u, "Upload rejected: "+u.Filename, body)
+	})
+}
+
+// reviewUpload removes an upload from the queue and runs fn in the same
+// transaction, so a decision is either recorded in full or not at all
+func (db *DB) reviewUpload(id int, fn func(tx *sql.Tx, u *PendingUpload) error) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var u PendingUpload
+	err = tx.QueryRow(`SELECT id, area, filename, uploader, bytes, uploaded_at FROM pending_uploads WHERE id = ?`, id).
+		Scan(&u.ID, &u.Area, &u.Filename, &u.Uploader, &u.Bytes, &u.UploadedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("upload %d is not waiting for review", id)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM pending_uploads WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if err := fn(tx, &u); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// mailUploader leaves the uploader a private message about their upload
+func mailUploader(tx *sql.Tx, reviewer string, u *PendingUpload, subject, body string) error {
+	_, err := tx.Exec(`INSERT INTO messages (from_user, to_user, subject, body, area, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		reviewer, u.Uploader, subject, body, "private", time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mail %s: %w", u.Uploader, err)
+	}
+	return nil
+}
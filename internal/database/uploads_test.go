@@ -0,0 +1,54 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUploadReview(t *testing.T) {
+	db := newTestDB(t)
+
+	good := &PendingUpload{Area: "utils", Filename: "PKZ204G.EXE", Uploader: "alice", Bytes: 2048}
+	bad := &PendingUpload{Area: "utils", Filename: "virus.zip", Uploader: "bob", Bytes: 512}
+	for _, u := range []*PendingUpload{good, bad} {
+		if err := db.AddPendingUpload(u); err != nil {
+			t.Fatalf("AddPendingUpload failed: %v", err)
+		}
+	}
+	if err := db.AddPendingUpload(&PendingUpload{Area: "utils", Filename: "virus.zip", Uploader: "carol"}); err == nil {
+		t.Error("queued the same file twice")
+	}
+
+	pending, err := db.GetPendingUploads()
+	if err != nil || len(pending) != 2 || pending[0].Filename != "PKZ204G.EXE" {
+		t.Fatalf("GetPendingUploads = %+v, %v", pending, err)
+	}
+
+	if err := db.ApproveUpload(good.ID, "sysop"); err != nil {
+		t.Fatalf("ApproveUpload failed: %v", err)
+	}
+	if totals, _ := db.GetTransferTotals("alice"); totals.UploadBytes != 2048 {
+		t.Errorf("approved upload credited %d bytes, want 2048", totals.UploadBytes)
+	}
+	if err := db.ApproveUpload(good.ID, "sysop"); err == nil {
+		t.Error("approved the same upload twice")
+	}
+
+	if err := db.RejectUpload(bad.ID, "sysop", "Failed the virus scan"); err != nil {
+		t.Fatalf("RejectUpload failed: %v", err)
+	}
+	if totals, _ := db.GetTransferTotals("bob"); totals.UploadBytes != 0 {
+		t.Errorf("rejected upload credited %d bytes", totals.UploadBytes)
+	}
+	mail, _ := db.GetMessages("bob", 10)
+	if len(mail) != 1 || mail[0].FromUser != "sysop" || !strings.Contains(mail[0].Body, "Failed the virus scan") {
+		t.Errorf("rejection mail = %+v", mail)
+	}
+	if mail, _ := db.GetMessages("alice", 10); len(mail) != 1 || !strings.HasPrefix(mail[0].Subject, "Upload approved") {
+		t.Errorf("approval mail = %+v", mail)
+	}
+
+	if count, err := db.CountPendingUploads(); err != nil || count != 0 {
+		t.Errorf("CountPendingUploads = %d, %v; want 0", count, err)
+	}
+}
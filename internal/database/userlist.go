@@ -0,0 +1,104 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UserSort is an order FindUsers can list accounts in
+type UserSort int
+
+// Orders for FindUsers; each falls back to username
+const (
+	SortByName     UserSort = iota // Username, A to Z
+	SortByLevel                    // Highest access level first
+	SortByCalls                    // Most calls first
+	SortByLastCall                 // Most recent caller first, never called last
+)
+
+// AnyLevel in a UserFilter matches accounts at every access level
+const AnyLevel = -1
+
+// Account states a UserFilter can match
+const (
+	AnyStatus = iota
+	ActiveOnly
+	InactiveOnly
+)
+
+// UserFilter narrows the accounts FindUsers lists
+type UserFilter struct {
+	Name   string // Part of the username or real name, any case; "" for all
+	Level  int    // Exact access level, or AnyLevel
+	Status int    // AnyStatus, ActiveOnly or InactiveOnly
+}
+
+// userOrders are the ORDER BY clauses for each UserSort
+var userOrders = map[UserSort]string{
+	SortByName:     "username COLLATE NOCASE",
+	SortByLevel:    "access_level DESC, username COLLATE NOCASE",
+	SortByCalls:    "total_calls DESC, username COLLATE NOCASE",
+	SortByLastCall: "last_call IS NULL, last_call DESC, username COLLATE NOCASE",
+}
+
+// FindUsers returns a page of the accounts that match filter, in the given
+// order, and how many match in all
+func (db *DB) FindUsers(filter UserFilter, sort UserSort, limit, offset int) ([]User, int, error) {
+	var where []string
+	var args []interface{}
+	if name := strings.TrimSpace(filter.Name); name != "" {
+		pattern := "%" + escapeLike(strings.ToLower(name)) + "%"
+		where = append(where, `(LOWER(username) LIKE ? ESCAPE '\' OR LOWER(real_name) LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+	if filter.Level != AnyLevel {
+		where = append(where, "access_level = ?")
+		args = append(args, filter.Level)
+	}
+	switch filter.Status {
+	case ActiveOnly:
+		where = append(where, "is_active = 1")
+	case InactiveOnly:
+		where = append(where, "is_active = 0")
+	}
+
+	order, ok := userOrders[sort]
+	if !ok {
+		order = userOrders[SortByName]
+	}
+	query := `SELECT id, username, password, real_name, email, access_level,
+			  last_call, total_calls, created_at, is_active, COUNT(*) OVER ()
+			  FROM users`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + order + " LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	total := 0
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Password, &user.RealName,
+			&user.Email, &user.AccessLevel, &user.LastCall, &user.TotalCalls,
+			&user.CreatedAt, &user.IsActive, &total); err != nil {
+			return nil, 0, fmt.Errorf("failed to find users: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to find users: %w", err)
+	}
+	return users, total, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so they match themselves
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,68 @@
+package database
+
+import "testing"
+
+func TestFindUsers(t *testing.T) {
+	db := newTestDB(t)
+
+	for _, u := range []User{
+		{Username: "alice", RealName: "Alice Modem", AccessLevel: 10, TotalCalls: 3, IsActive: true},
+		{Username: "bob", RealName: "Bob 100%", AccessLevel: 50, TotalCalls: 9, IsActive: true},
+		{Username: "carol", RealName: "Carol Modem", AccessLevel: 10, TotalCalls: 1, IsActive: false},
+	} {
+		u.Password = "secret"
+		if err := db.CreateUser(&u); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		// CreateUser leaves out the call count and status
+		if _, err := db.conn.Exec(`UPDATE users SET total_calls = ?, is_active = ? WHERE username = ?`,
+			u.TotalCalls, u.IsActive, u.Username); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := func(users []User) []string {
+		var out []string
+		for _, u := range users {
+			out = append(out, u.Username)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		filter UserFilter
+		sort   UserSort
+		want   []string
+	}{
+		{"real name, any case", UserFilter{Name: "MODEM", Level: AnyLevel}, SortByName, []string{"alice", "carol"}},
+		{"level", UserFilter{Level: 10}, SortByCalls, []string{"alice", "carol"}},
+		{"inactive", UserFilter{Level: AnyLevel, Status: InactiveOnly}, SortByName, []string{"carol"}},
+		{"wildcard matches itself", UserFilter{Name: "%", Level: AnyLevel}, SortByName, []string{"bob"}},
+		{"active by calls", UserFilter{Level: 10, Status: ActiveOnly}, SortByCalls, []string{"alice"}},
+	}
+	for _, tt := range tests {
+		users, total, err := db.FindUsers(tt.filter, tt.sort, 10, 0)
+		if err != nil {
+			t.Fatalf("%s: FindUsers failed: %v", tt.name, err)
+		}
+		if got := names(users); len(got) != len(tt.want) || total != len(tt.want) {
+			t.Errorf("%s: FindUsers = %v (total %d), want %v", tt.name, got, total, tt.want)
+		} else {
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("%s: FindUsers = %v, want %v", tt.name, got, tt.want)
+					break
+				}
+			}
+		}
+	}
+
+	// A page reports how many match in all
+	users, total, err := db.FindUsers(UserFilter{Level: AnyLevel}, SortByLevel, 1, 1)
+	if err != nil {
+		t.Fatalf("FindUsers failed: %v", err)
+	}
+	if total < 3 || len(users) != 1 {
+		t.Errorf("FindUsers page = %v (total %d), want one user of at least 3", names(users), total)
+	}
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNotEnoughTime is returned when a deposit is larger than the time
+	// the caller has left today
+	ErrNotEnoughTime = errors.New("not enough time left today")
+	// ErrTimeBankFull is returned when a deposit would take the time bank
+	// past its limit
+	ErrTimeBankFull = errors.New("time bank is full")
+	// ErrTimeBankEmpty is returned when a withdrawal is larger than the
+	// time bank balance
+	ErrTimeBankEmpty = errors.New("not enough time in the time bank")
+)
+
+// UserTime is a user's time online for one day and their time bank balance
+type UserTime struct {
+	UsedSeconds int // Time used today; withdrawals from the bank make it negative
+	BankMinutes int
+}
+
+// timeDay is the key for a day's usage, in the server's local time
+func timeDay(now time.Time) string {
+	return now.Format("2006-01-02")
+}
+
+// GetUserTime returns a user's time used on now's day and their time bank
+// balance. Usage from earlier days doesn't count.
+func (db *DB) GetUserTime(userID int, now time.Time) (*UserTime, error) {
+	t, err := getUserTime(db.conn, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user time: %w", err)
+	}
+	return t, nil
+}
+
+// rowQuerier is a *sql.DB or *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// getUserTime reads a user's time with either the database or a transaction
+func getUserTime(q rowQuerier, userID int, now time.Time) (*UserTime, error) {
+	var day string
+	t := &UserTime{}
+	err := q.QueryRow(`SELECT day, used_seconds, bank_minutes FROM user_time WHERE user_id = ?`, userID).
+		Scan(&day, &t.UsedSeconds, &t.BankMinutes)
+	if err == sql.ErrNoRows {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if day != timeDay(now) {
+		t.UsedSeconds = 0
+	}
+	return t, nil
+}
+
+// AddTimeUsed adds seconds to the user's time used on now's day, starting
+// the day over if the last usage was on an earlier one
+func (db *DB) AddTimeUsed(userID int, now time.Time, seconds int) error {
+	query := `INSERT INTO user_time (user_id, day, used_seconds) VALUES (?, ?, ?)
+			  ON CONFLICT(user_id) DO UPDATE SET
+			  used_seconds = CASE WHEN day = excluded.day THEN used_seconds + excluded.used_seconds ELSE excluded.used_seconds END,
+			  day = excluded.day`
+	if _, err := db.conn.Exec(query, userID, timeDay(now), seconds); err != nil {
+		return fmt.Errorf("failed to record time used: %w", err)
+	}
+	return nil
+}
+
+// DepositTime moves minutes from the time the user has left today, under a
+// daily limit of dailyMinutes, into their time bank, which holds at most
+// bankMax minutes
+func (db *DB) DepositTime(userID int, now time.Time, minutes, dailyMinutes, bankMax int) error {
+	return db.updateUserTime(userID, now, func(t *UserTime) error {
+		if t.UsedSeconds+minutes*60 > dailyMinutes*60 {
+			return ErrNotEnoughTime
+		}
+		if t.BankMinutes+minutes > bankMax {
+			return ErrTimeBankFull
+		}
+		t.UsedSeconds += minutes * 60
+		t.BankMinutes += minutes
+		return nil
+	})
+}
+
+// WithdrawTime moves minutes from the user's time bank to their time today
+func (db *DB) WithdrawTime(userID int, now time.Time, minutes int) error {
+	return db.updateUserTime(userID, now, func(t *UserTime) error {
+		if minutes > t.BankMinutes {
+			return ErrTimeBankEmpty
+		}
+		t.UsedSeconds -= minutes * 60
+		t.BankMinutes -= minutes
+		return nil
+	})
+}
+
+// updateUserTime applies change to a user's time for now's day in a
+// transaction, saving the result unless change returns an error
+func (db *DB) updateUserTime(userID int, now time.Time, change func(*UserTime) error) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t, err := getUserTime(tx, userID, now)
+	if err != nil {
+		return fmt.Errorf("failed to get user time: %w", err)
+	}
+	if err := change(t); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO user_time (user_id, day, used_seconds, bank_minutes) VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET day = excluded.day, used_seconds = excluded.used_seconds,
+		bank_minutes = excluded.bank_minutes`,
+		userID, timeDay(now), t.UsedSeconds, t.BankMinutes); err != nil {
+		return fmt.Errorf("failed to update user time: %w", err)
+	}
+
+	return tx.Commit()
+}
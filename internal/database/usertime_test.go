@@ -0,0 +1,60 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserTime(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.CreateUser(&User{Username: "alice", Password: "secret", IsActive: true}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	user, err := db.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	if err := db.AddTimeUsed(user.ID, now, 600); err != nil {
+		t.Fatalf("AddTimeUsed failed: %v", err)
+	}
+	if err := db.AddTimeUsed(user.ID, now, 300); err != nil {
+		t.Fatalf("AddTimeUsed failed: %v", err)
+	}
+
+	// 15 minutes used of 60: 45 are left to deposit
+	if err := db.DepositTime(user.ID, now, 50, 60, 100); err != ErrNotEnoughTime {
+		t.Errorf("deposit past the daily limit: got %v, want ErrNotEnoughTime", err)
+	}
+	if err := db.DepositTime(user.ID, now, 30, 60, 20); err != ErrTimeBankFull {
+		t.Errorf("deposit past the bank limit: got %v, want ErrTimeBankFull", err)
+	}
+	if err := db.DepositTime(user.ID, now, 20, 60, 100); err != nil {
+		t.Fatalf("DepositTime failed: %v", err)
+	}
+
+	ut, err := db.GetUserTime(user.ID, now)
+	if err != nil {
+		t.Fatalf("GetUserTime failed: %v", err)
+	}
+	if ut.UsedSeconds != 35*60 || ut.BankMinutes != 20 {
+		t.Errorf("after deposit got %+v, want 2100 seconds used and 20 banked", ut)
+	}
+
+	// The next day starts fresh, and withdrawals go past the daily limit
+	tomorrow := now.AddDate(0, 0, 1)
+	if err := db.WithdrawTime(user.ID, tomorrow, 25); err != ErrTimeBankEmpty {
+		t.Errorf("overdrawn withdrawal: got %v, want ErrTimeBankEmpty", err)
+	}
+	if err := db.WithdrawTime(user.ID, tomorrow, 15); err != nil {
+		t.Fatalf("WithdrawTime failed: %v", err)
+	}
+	ut, err = db.GetUserTime(user.ID, tomorrow)
+	if err != nil {
+		t.Fatalf("GetUserTime failed: %v", err)
+	}
+	if ut.UsedSeconds != -15*60 || ut.BankMinutes != 5 {
+		t.Errorf("after withdrawal got %+v, want -900 seconds used and 5 banked", ut)
+	}
+}
@@ -0,0 +1,217 @@
+// Package dialog asks questions and gives notices in boxes drawn over the
+// middle of the caller's screen, which is put back as it was when the box
+// goes: a yes or no question, a message to read, or a line to type.
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/draw"
+	"bbs/internal/input"
+	"bbs/internal/modules"
+)
+
+// ColorScheme colors and frames dialogs
+type ColorScheme interface {
+	Colorize(text, colorName string) string
+	DrawBox(box draw.Box) []string
+}
+
+// Redrawer is a writer that keeps what the caller's screen shows, so a
+// dialog can put back the rows it covers, and the cursor, as they were.
+// Other writers have the rows cleared instead, for the screen underneath to
+// draw itself again.
+type Redrawer interface {
+	Redraw(first, last int) string
+}
+
+// maxWidth is how wide a dialog's text runs before it wraps
+const maxWidth = 60
+
+// Confirm asks a yes or no question. Y and N answer it, as do the first
+// letters of Yes and No in the caller's language; Left, Right and Tab move
+// between the buttons, and Enter presses the one lit, which starts as def.
+// Escape answers no.
+func Confirm(writer modules.Writer, keyReader modules.KeyReader, cs ColorScheme, question string, def bool) bool {
+	yesKey, noKey := initial(modules.T(writer, "Yes")), initial(modules.T(writer, "No"))
+	yes := def
+	lines := func() []string {
+		return append(wrap(question), "", buttons(writer, cs, yes))
+	}
+	d := open(writer, cs, lines(), true)
+	defer d.close()
+	for {
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+		switch key = strings.ToLower(key); {
+		case key == "y" || key == yesKey:
+			return true
+		case key == "n" || key == noKey || key == input.Escape || key == input.Quit:
+			return false
+		case key == input.Enter:
+			return yes
+		case key == input.Left || key == input.Right || key == "\t":
+			yes = !yes
+			d.draw(lines())
+		case key == "redraw":
+			d.draw(lines())
+		}
+	}
+}
+
+// Alert shows a message colored for messageType, such as "error" or
+// "success", until the caller presses a key
+func Alert(writer modules.Writer, keyReader modules.KeyReader, cs ColorScheme, message, messageType string) {
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+	var lines []string
+	for _, line := range wrap(modules.T(writer, message)) {
+		lines = append(lines, cs.Colorize(line, messageType))
+	}
+	lines = append(lines, "", cs.Colorize(modules.T(writer, "Press any key to continue..."), "text"))
+	d := open(writer, cs, lines, true)
+	keyReader.ReadKey()
+	d.close()
+}
+
+// Input asks for a line of at most max characters, up to the widest a
+// dialog goes, under prompt. It reports false if the caller pressed Escape.
+// prompt is shown as given, while Alert's message is translated first.
+func Input(writer modules.Writer, keyReader modules.KeyReader, cs ColorScheme, prompt string, max int) (string, bool) {
+	if max <= 0 || max > maxWidth {
+		max = maxWidth
+	}
+	hint := cs.Colorize(modules.T(writer, "Enter: OK  Esc: Cancel"), "secondary")
+	lines := append(wrap(prompt), "", strings.Repeat(" ", max), "", hint)
+	d := open(writer, cs, lines, false)
+	defer d.close()
+
+	// The field is the third line from the bottom, inside the frame and
+	// the space after it
+	writer.Write([]byte(fmt.Sprintf("\033[%d;%dH", d.top+len(lines)-2, d.left+2) + "\033[?25h"))
+	line, err := input.ReadLine(keyReader, writer, max)
+	if err != nil {
+		return "", false
+	}
+	return line, true
+}
+
+// dialog is a box drawn over the screen
+type dialog struct {
+	writer    modules.Writer
+	cs        ColorScheme
+	center    bool
+	top, left int    // Where the box's top left corner is, counted from 1
+	rows      int    // Rows the box and its shadow cover
+	under     string // Output that draws those rows as they were, if the writer knows
+}
+
+// open draws a box around lines, shadowed, in the middle of the screen
+func open(writer modules.Writer, cs ColorScheme, lines []string, center bool) *dialog {
+	d := &dialog{writer: writer, cs: cs, center: center}
+	rows := d.box(lines)
+	width, height := 80, 24
+	if sizer, ok := writer.(interface{ Size() (int, int, error) }); ok {
+		if w, h, err := sizer.Size(); err == nil && w > 0 && h > 0 {
+			width, height = w, h
+		}
+	}
+	d.top = max((height-len(rows))/2, 0) + 1
+	d.left = max((width-ansi.Width(rows[0]))/2, 0) + 1
+	d.rows = len(rows)
+
+	if r, ok := writer.(Redrawer); ok {
+		d.under = r.Redraw(d.top, d.top+d.rows-1)
+	} else {
+		writer.Write([]byte("\0337")) // Save the cursor at least
+	}
+	d.show(rows)
+	return d
+}
+
+// box returns the rows of the box around lines
+func (d *dialog) box(lines []string) []string {
+	return d.cs.DrawBox(draw.Box{Lines: lines, Center: d.center, Shadow: true})
+}
+
+// draw draws the box around new lines of the same size
+func (d *dialog) draw(lines []string) {
+	d.show(d.box(lines))
+}
+
+// show writes the rows of the box to the screen
+func (d *dialog) show(rows []string) {
+	var b strings.Builder
+	b.WriteString("\033[?25l")
+	for i, row := range rows {
+		fmt.Fprintf(&b, "\033[%d;%dH%s", d.top+i, d.left, row)
+	}
+	d.writer.Write([]byte(b.String()))
+}
+
+// close takes the box off the screen, leaving the cursor hidden as menus
+// and lists keep it
+func (d *dialog) close() {
+	if d.under != "" {
+		d.writer.Write([]byte(d.under + "\033[?25l"))
+		return
+	}
+	var b strings.Builder
+	for row := d.top; row < d.top+d.rows; row++ {
+		fmt.Fprintf(&b, "\033[%d;1H\033[2K", row)
+	}
+	b.WriteString("\0338\033[?25l")
+	d.writer.Write([]byte(b.String()))
+}
+
+// buttons draws Yes and No with the chosen one lit
+func buttons(writer modules.Writer, cs ColorScheme, yes bool) string {
+	button := func(label string, lit bool) string {
+		if lit {
+			return cs.Colorize("[ "+label+" ]", "highlight")
+		}
+		return cs.Colorize("  "+label+"  ", "text")
+	}
+	return button(modules.T(writer, "Yes"), yes) + "   " + button(modules.T(writer, "No"), !yes)
+}
+
+// initial returns the first letter of label in lower case
+func initial(label string) string {
+	for _, r := range strings.ToLower(label) {
+		return string(r)
+	}
+	return ""
+}
+
+// wrap breaks text into lines no wider than a dialog, at spaces where it
+// can. Newlines in text start new lines.
+func wrap(text string) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for ansi.Width(word) > maxWidth {
+				if line != "" {
+					lines, line = append(lines, line), ""
+				}
+				cut := ansi.Truncate(word, maxWidth)
+				lines, word = append(lines, cut), word[len(cut):]
+			}
+			switch {
+			case line == "":
+				line = word
+			case ansi.Width(line)+1+ansi.Width(word) <= maxWidth:
+				line += " " + word
+			default:
+				lines, line = append(lines, line), word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,133 @@
+package dialog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"bbs/internal/draw"
+	"bbs/internal/terminal"
+)
+
+// screenWriter draws on a copy of a terminal's screen
+type screenWriter struct {
+	*terminal.Screen
+}
+
+func (w screenWriter) Size() (int, int, error) {
+	return 40, 12, nil
+}
+
+// keys replays key presses, then fails
+type keys []string
+
+func (k *keys) ReadKey() (string, error) {
+	if len(*k) == 0 {
+		return "", errors.New("no more keys")
+	}
+	key := (*k)[0]
+	*k = (*k)[1:]
+	return key, nil
+}
+
+// plain draws boxes without colors
+type plain struct{}
+
+func (plain) Colorize(text, _ string) string {
+	return text
+}
+
+func (plain) DrawBox(box draw.Box) []string {
+	return box.Draw(nil)
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		keys []string
+		def  bool
+		want bool
+	}{
+		{[]string{"y"}, false, true},
+		{[]string{"N"}, true, false},
+		{[]string{"enter"}, false, false},
+		{[]string{"enter"}, true, true},
+		{[]string{"x", "right", "enter"}, false, true},
+		{[]string{"escape"}, true, false},
+		{nil, true, false},
+	}
+	for _, tt := range tests {
+		w := screenWriter{terminal.NewScreen(40, 12)}
+		k := keys(tt.keys)
+		if got := Confirm(w, &k, plain{}, "Delete it?", tt.def); got != tt.want {
+			t.Errorf("Confirm with %v, default %v = %v, want %v", tt.keys, tt.def, got, tt.want)
+		}
+	}
+}
+
+// spanish translates the buttons
+type spanish struct {
+	screenWriter
+}
+
+func (spanish) Translate(text string) string {
+	return map[string]string{"Yes": "Sí", "No": "No"}[text]
+}
+
+func TestConfirm_Translated(t *testing.T) {
+	w := spanish{screenWriter{terminal.NewScreen(40, 12)}}
+	k := keys{"S"}
+	if !Confirm(w, &k, plain{}, "¿Borrarlo?", false) {
+		t.Error("S didn't answer yes in Spanish")
+	}
+}
+
+func TestConfirm_PutsBackScreen(t *testing.T) {
+	w := screenWriter{terminal.NewScreen(40, 12)}
+	for i := 0; i < 11; i++ {
+		w.Write([]byte(strings.Repeat(string(rune('a'+i)), 40) + "\n"))
+	}
+	w.Write([]byte("\033[3;7H"))
+	before := w.Redraw(1, 12)
+
+	k := keys{"y"}
+	Confirm(w, &k, plain{}, "Delete it?", false)
+	if after := w.Redraw(1, 12); after != before {
+		t.Errorf("screen after the dialog =\n%q\nwant\n%q", after, before)
+	}
+}
+
+func TestAlert(t *testing.T) {
+	w := screenWriter{terminal.NewScreen(40, 12)}
+	k := keys{"x", "y"}
+	Alert(w, &k, plain{}, "Saved.", "success")
+	if len(k) != 1 {
+		t.Errorf("Alert read %d keys, want 1", 2-len(k))
+	}
+}
+
+func TestInput(t *testing.T) {
+	w := screenWriter{terminal.NewScreen(40, 12)}
+	k := keys{"b", "o", "b", "enter"}
+	if got, ok := Input(w, &k, plain{}, "Name:", 10); !ok || got != "bob" {
+		t.Errorf("Input = %q, %v, want bob, true", got, ok)
+	}
+	k = keys{"b", "escape"}
+	if got, ok := Input(w, &k, plain{}, "Name:", 10); ok {
+		t.Errorf("Input after Escape = %q, %v, want false", got, ok)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	long := strings.Repeat("word ", 20)
+	for _, line := range wrap(long) {
+		if len(line) > maxWidth {
+			t.Errorf("line %q is longer than %d", line, maxWidth)
+		}
+	}
+	if got := wrap("one\ntwo"); len(got) != 2 || got[1] != "two" {
+		t.Errorf("wrap kept newlines as %q", got)
+	}
+	if got := wrap(strings.Repeat("x", maxWidth+5)); len(got) != 2 || len(got[1]) != 5 {
+		t.Errorf("wrap cut a long word as %q", got)
+	}
+}
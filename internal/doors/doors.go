@@ -0,0 +1,173 @@
+// Package doors runs external door programs for callers. Each run gets the
+// standard DOOR.SYS and DORINFO1.DEF drop files and has its standard input
+// and output bridged to the caller's session.
+package doors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"bbs/internal/config"
+)
+
+// DefaultTimeLimit is how long a door may run when none is configured
+const DefaultTimeLimit = 60 * time.Minute
+
+// ErrTimeLimit is returned when a door is stopped for running too long
+var ErrTimeLimit = errors.New("door time limit reached")
+
+// exitPrompt is shown once the door exits. The key press that answers it is
+// consumed by the input bridge, which can't stop a pending read any other way.
+const exitPrompt = "\r\nPress any key to return to the BBS..."
+
+// TimeLimit returns how long the door may run
+func TimeLimit(door config.DoorConfig) time.Duration {
+	if door.TimeLimit > 0 {
+		return time.Duration(door.TimeLimit) * time.Minute
+	}
+	return DefaultTimeLimit
+}
+
+// doorEnv is the environment a door runs in. Doors are other people's
+// programs, so they get only what they need to find programs and draw for
+// the caller, never the server's own environment with its passwords.
+func doorEnv(info Info) []string {
+	env := []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + os.Getenv("HOME"),
+		fmt.Sprintf("BBS_NODE=%d", info.Node),
+		"BBS_USER=" + info.Username,
+		"BBS_DROPDIR=" + info.Dir,
+		fmt.Sprintf("COLUMNS=%d", info.Width),
+		fmt.Sprintf("LINES=%d", info.Height),
+		"TERM=ansi",
+	}
+	if tz, ok := os.LookupEnv("TZ"); ok {
+		env = append(env, "TZ="+tz)
+	}
+	return env
+}
+
+// Run writes the drop files, starts the door in workDir, and bridges its
+// standard input and output to rw until it exits. If the caller hangs up
+// the door is killed, like a dropped carrier.
+func Run(ctx context.Context, door config.DoorConfig, workDir string, info Info, rw io.ReadWriter) error {
+	if info.TimeLeft <= 0 {
+		info.TimeLeft = TimeLimit(door)
+	}
+	if err := WriteDropFiles(info); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, info.TimeLeft)
+	defer cancel()
+
+	args := make([]string, len(door.Args))
+	for i, arg := range door.Args {
+		args[i] = expand(arg, info)
+	}
+
+	cmd := exec.CommandContext(ctx, expand(door.Command, info), args...)
+	cmd.Dir = workDir
+	cmd.Env = doorEnv(info)
+
+	var out io.Writer = rw
+	if door.TranslateNewlines {
+		out = &crlfWriter{w: rw}
+	}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// Don't hang on a background child that inherited the door's output
+	cmd.WaitDelay = 2 * time.Second
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", door.Name, err)
+	}
+
+	bridged := make(chan struct{})
+	go func() {
+		defer close(bridged)
+		bridgeInput(stdin, rw, door.TranslateNewlines, cancel)
+	}()
+
+	err = cmd.Wait()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = ErrTimeLimit
+	}
+
+	// Wait for the bridge to finish so it can't steal the session's next key
+	rw.Write([]byte(exitPrompt))
+	<-bridged
+	return err
+}
+
+// bridgeInput copies the caller's key presses to the door, sending Enter as
+// a line feed for Unix doors. It stops once the door's input is closed, and
+// cancels the door if the caller hangs up.
+func bridgeInput(stdin io.WriteCloser, r io.Reader, translate bool, hangup func()) {
+	defer stdin.Close()
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if translate {
+				for i, b := range buf[:n] {
+					if b == '\r' {
+						buf[i] = '\n'
+					}
+				}
+			}
+			if _, werr := stdin.Write(buf[:n]); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			hangup()
+			return
+		}
+	}
+}
+
+// expand substitutes the {node}, {user}, {dropdir}, {doorsys}, {dorinfo}
+// and {timeleft} placeholders in a command or argument
+func expand(s string, info Info) string {
+	return strings.NewReplacer(
+		"{node}", fmt.Sprint(info.Node),
+		"{user}", info.Username,
+		"{dropdir}", info.Dir,
+		"{doorsys}", info.Dir+string(os.PathSeparator)+DoorSysFile,
+		"{dorinfo}", info.Dir+string(os.PathSeparator)+DorinfoFile,
+		"{timeleft}", fmt.Sprint(int(info.TimeLeft/time.Minute)),
+	).Replace(s)
+}
+
+// crlfWriter turns bare line feeds into CR LF for doors written for Unix
+type crlfWriter struct {
+	w      io.Writer
+	lastCR bool
+}
+
+func (c *crlfWriter) Write(p []byte) (int, error) {
+	var buf []byte
+	for _, b := range p {
+		if b == '\n' && !c.lastCR {
+			buf = append(buf, '\r')
+		}
+		buf = append(buf, b)
+		c.lastCR = b == '\r'
+	}
+	if _, err := c.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,155 @@
+package doors
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bbs/internal/config"
+)
+
+func testInfo(t *testing.T) Info {
+	return Info{
+		Node:        2,
+		Username:    "alice",
+		RealName:    "Alice Smith",
+		AccessLevel: 10,
+		TimeLeft:    30 * time.Minute,
+		Width:       80,
+		Height:      24,
+		ANSI:        true,
+		SystemName:  "Coastline BBS",
+		SysopName:   "Sysop",
+		Dir:         t.TempDir(),
+	}
+}
+
+func TestDropFiles(t *testing.T) {
+	info := testInfo(t)
+	if err := WriteDropFiles(info); err != nil {
+		t.Fatalf("WriteDropFiles failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(info.Dir, DoorSysFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\r\n"), "\r\n")
+	if len(lines) != 52 {
+		t.Fatalf("DOOR.SYS has %d lines, want 52", len(lines))
+	}
+	checks := map[int]string{4: "2", 10: "Alice Smith", 15: "10", 18: "1800", 19: "30", 20: "GR", 21: "24", 36: "alice"}
+	for line, want := range checks {
+		if lines[line-1] != want {
+			t.Errorf("DOOR.SYS line %d = %q, want %q", line, lines[line-1], want)
+		}
+	}
+
+	data, err = os.ReadFile(filepath.Join(info.Dir, DorinfoFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(strings.TrimSuffix(string(data), "\r\n"), "\r\n")
+	if len(lines) != 13 {
+		t.Fatalf("DORINFO1.DEF has %d lines, want 13", len(lines))
+	}
+	if lines[0] != "Coastline BBS" || lines[6] != "ALICE" || lines[7] != "SMITH" || lines[11] != "30" {
+		t.Errorf("unexpected DORINFO1.DEF contents: %q", lines)
+	}
+}
+
+// session stands in for the caller's connection: scripted input and
+// captured output
+type session struct {
+	io.Reader
+	out bytes.Buffer
+}
+
+func (s *session) Write(p []byte) (int, error) {
+	return s.out.Write(p)
+}
+
+func TestRunBridgesStdio(t *testing.T) {
+	info := testInfo(t)
+	door := config.DoorConfig{
+		Name:              "echo",
+		Command:           "/bin/sh",
+		Args:              []string{"-c", `read name; echo "hello $name on node {node}"; cat "{dorinfo}" | head -1`},
+		TranslateNewlines: true,
+	}
+
+	pr, pw := io.Pipe()
+	rw := &session{Reader: pr}
+	go func() {
+		// The final key press answers the exit prompt
+		pw.Write([]byte("bob\r"))
+		time.Sleep(200 * time.Millisecond)
+		pw.Write([]byte("x"))
+		pw.Close()
+	}()
+
+	if err := Run(context.Background(), door, t.TempDir(), info, rw); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	out := rw.out.String()
+	if !strings.Contains(out, "hello bob on node 2\r\n") {
+		t.Errorf("door output missing greeting: %q", out)
+	}
+	if !strings.Contains(out, "Coastline BBS") {
+		t.Errorf("door couldn't read its drop file: %q", out)
+	}
+	if !strings.HasSuffix(out, exitPrompt) {
+		t.Errorf("exit prompt not shown: %q", out)
+	}
+}
+
+func TestRunTimeLimit(t *testing.T) {
+	info := testInfo(t)
+	info.TimeLeft = 100 * time.Millisecond
+	door := config.DoorConfig{Name: "sleeper", Command: "/bin/sleep", Args: []string{"10"}}
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	rw := &session{Reader: pr}
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		pw.Write([]byte("x"))
+	}()
+
+	if err := Run(context.Background(), door, t.TempDir(), info, rw); err != ErrTimeLimit {
+		t.Errorf("got %v, want ErrTimeLimit", err)
+	}
+}
+
+func TestRunKeepsServerEnvironment(t *testing.T) {
+	t.Setenv("BBS_SYSOP_PASSWORD", "hunter2")
+	info := testInfo(t)
+	door := config.DoorConfig{Name: "env", Command: "/bin/sh", Args: []string{"-c", "env"}}
+
+	pr, pw := io.Pipe()
+	rw := &session{Reader: pr}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		pw.Write([]byte("x"))
+		pw.Close()
+	}()
+	if err := Run(context.Background(), door, t.TempDir(), info, rw); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	out := rw.out.String()
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("the door saw the server's environment: %q", out)
+	}
+	for _, want := range []string{"BBS_NODE=2", "BBS_USER=alice", "BBS_DROPDIR=" + info.Dir, "TERM=ansi", "PATH="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("door environment lacks %s: %q", want, out)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package doors
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Drop file names as doors expect to find them
+const (
+	DoorSysFile = "DOOR.SYS"
+	DorinfoFile = "DORINFO1.DEF"
+)
+
+// Info is the caller and node information written into drop files
+type Info struct {
+	Node        int
+	Username    string
+	RealName    string
+	Location    string
+	AccessLevel int
+	TotalCalls  int
+	LastCall    time.Time
+	TimeLeft    time.Duration
+	Width       int
+	Height      int
+	ANSI        bool
+	Uploads     int
+	Downloads   int
+	UploadKB    int64
+	DownloadKB  int64
+	SystemName  string
+	SysopName   string
+	Dir         string // Directory holding the drop files
+}
+
+// baudRate is reported for the virtual serial line; doors use it only for
+// display and pacing
+const baudRate = 115200
+
+// DoorSys renders a 52-line GAP-style DOOR.SYS
+func DoorSys(info Info) string {
+	name := info.RealName
+	if name == "" {
+		name = info.Username
+	}
+	graphics := "NG"
+	if info.ANSI {
+		graphics = "GR"
+	}
+	minutes := int(info.TimeLeft / time.Minute)
+	last := info.LastCall
+	if last.IsZero() {
+		last = time.Now()
+	}
+	now := time.Now()
+	dir := info.Dir + string(filepath.Separator)
+
+	lines := []string{
+		"COM0:",                                  // 1  Port (0 = local, no modem)
+		fmt.Sprint(baudRate),                     // 2  Baud rate
+		"8",                                      // 3  Data bits
+		fmt.Sprint(info.Node),                    // 4  Node number
+		fmt.Sprint(baudRate),                     // 5  Locked DTE rate
+		"Y",                                      // 6  Screen display
+		"N",                                      // 7  Printer
+		"N",                                      // 8  Page bell
+		"N",                                      // 9  Caller alarm
+		name,                                     // 10 Full name
+		location(info),                           // 11 City, state
+		"000-000-0000",                           // 12 Home phone
+		"000-000-0000",                           // 13 Work phone
+		"",                                       // 14 Password (never written)
+		fmt.Sprint(info.AccessLevel),             // 15 Security level
+		fmt.Sprint(info.TotalCalls),              // 16 Total calls
+		last.Format("01/02/06"),                  // 17 Last call date
+		fmt.Sprint(int(info.TimeLeft.Seconds())), // 18 Seconds remaining
+		fmt.Sprint(minutes),                      // 19 Minutes remaining
+		graphics,                                 // 20 Graphics mode
+		fmt.Sprint(info.Height),                  // 21 Screen length
+		"N",                                      // 22 Expert mode
+		"",                                       // 23 Conferences registered
+		"",                                       // 24 Conference exited from
+		"12/31/99",                               // 25 Expiration date
+		fmt.Sprint(info.Node),                    // 26 User record number
+		"Z",                                      // 27 Default protocol
+		fmt.Sprint(info.Uploads),                 // 28 Total uploads
+		fmt.Sprint(info.Downloads),               // 29 Total downloads
+		"0",                                      // 30 KB downloaded today
+		"0",                                      // 31 Daily download limit in KB
+		"01/01/70",                               // 32 Birth date
+		dir,                                      // 33 Main directory
+		dir,                                      // 34 General directory
+		info.SysopName,                           // 35 Sysop name
+		info.Username,                            // 36 Alias
+		"00:00",                                  // 37 Event time
+		"Y",                                      // 38 Error-correcting connection
+		"Y",                                      // 39 ANSI supported in NG mode
+		"Y",                                      // 40 Use record locking
+		"7",                                      // 41 Default color
+		"0",                                      // 42 Time credits
+		now.Format("01/02/06"),                   // 43 Last new files scan
+		now.Format("15:04"),                      // 44 Time of this call
+		last.Format("15:04"),                     // 45 Time of last call
+		"9999",                                   // 46 Daily file limit
+		"0",                                      // 47 Files downloaded today
+		fmt.Sprint(info.UploadKB),                // 48 Total KB uploaded
+		fmt.Sprint(info.DownloadKB),              // 49 Total KB downloaded
+		"",                                       // 50 Comment
+		"0",                                      // 51 Doors opened
+		"0",                                      // 52 Messages left
+	}
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+// Dorinfo renders a DORINFO1.DEF
+func Dorinfo(info Info) string {
+	sysopFirst, sysopLast := splitName(info.SysopName)
+	name := info.RealName
+	if name == "" {
+		name = info.Username
+	}
+	first, last := splitName(name)
+	graphics := "0"
+	if info.ANSI {
+		graphics = "1"
+	}
+
+	lines := []string{
+		info.SystemName,
+		sysopFirst,
+		sysopLast,
+		"COM0",
+		fmt.Sprintf("%d BAUD,N,8,1", baudRate),
+		"0",
+		first,
+		last,
+		location(info),
+		graphics,
+		fmt.Sprint(info.AccessLevel),
+		fmt.Sprint(int(info.TimeLeft / time.Minute)),
+		"-1",
+	}
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+// WriteDropFiles writes DOOR.SYS and DORINFO1.DEF into info.Dir
+func WriteDropFiles(info Info) error {
+	if err := os.MkdirAll(info.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create drop file directory: %w", err)
+	}
+
+	files := map[string]string{
+		DoorSysFile: DoorSys(info),
+		DorinfoFile: Dorinfo(info),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(info.Dir, name), []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// location returns the caller's location, which many doors refuse to leave blank
+func location(info Info) string {
+	if info.Location != "" {
+		return info.Location
+	}
+	return "Unknown"
+}
+
+// splitName splits a full name into first and last names, upper-cased as
+// DORINFO1.DEF readers expect
+func splitName(name string) (string, string) {
+	first, last, _ := strings.Cut(strings.TrimSpace(name), " ")
+	return strings.ToUpper(first), strings.ToUpper(strings.TrimSpace(last))
+}
@@ -0,0 +1,180 @@
+// Package draw draws the boxes, rules and borders around the board's
+// screens in a border style the sysop picks for each color theme: double
+// or single lines, or plain ASCII for terminals that can't show box
+// drawing characters.
+package draw
+
+import (
+	"strings"
+
+	"bbs/internal/ansi"
+)
+
+// Style is the set of characters a border is drawn with
+type Style struct {
+	Name        string
+	Horizontal  string
+	Vertical    string
+	TopLeft     string
+	TopRight    string
+	BottomLeft  string
+	BottomRight string
+	Shadow      string // Drawn below and to the right of a box that has one
+}
+
+// The border styles a theme can pick
+var (
+	Double = Style{"double", "═", "║", "╔", "╗", "╚", "╝", "▒"}
+	Single = Style{"single", "─", "│", "┌", "┐", "└", "┘", "▒"}
+	ASCII  = Style{"ascii", "-", "|", "+", "+", "+", "+", "#"}
+)
+
+// Styles lists the border styles by name
+var Styles = []Style{Double, Single, ASCII}
+
+// StyleNamed returns the border style called name, or Double for "". It
+// reports false, with Double, for a name it doesn't know.
+func StyleNamed(name string) (Style, bool) {
+	if name == "" {
+		return Double, true
+	}
+	for _, st := range Styles {
+		if st.Name == strings.ToLower(name) {
+			return st, true
+		}
+	}
+	return Double, false
+}
+
+// asciiLines replaces box drawing characters with the nearest ASCII
+var asciiLines = strings.NewReplacer(
+	"─", "-", "━", "-", "═", "=",
+	"│", "|", "┃", "|", "║", "|",
+	"┌", "+", "┐", "+", "└", "+", "┘", "+", "├", "+", "┤", "+", "┬", "+", "┴", "+", "┼", "+",
+	"╔", "+", "╗", "+", "╚", "+", "╝", "+", "╠", "+", "╣", "+", "╦", "+", "╩", "+", "╬", "+",
+	"╭", "+", "╮", "+", "╰", "+", "╯", "+",
+	"▒", "#", "░", ":", "▓", "#", "█", "#",
+)
+
+// Convert returns s with the box drawing characters the style can't show
+// replaced. Double and single lines can show them all, so only the ASCII
+// style changes anything.
+func (st Style) Convert(s string) string {
+	if st.Name != ASCII.Name {
+		return s
+	}
+	return asciiLines.Replace(s)
+}
+
+// Rule returns a line width columns long in the style
+func (st Style) Rule(width int) string {
+	return Pattern(st.Horizontal, width)
+}
+
+// Pattern repeats the characters of pattern to fill width columns, as in
+// the -=-=- under a menu's title. An empty pattern repeats "-".
+func Pattern(pattern string, width int) string {
+	if pattern == "" {
+		pattern = "-"
+	}
+	var b strings.Builder
+	runes := []rune(pattern)
+	for i, used := 0, 0; used < width; i++ {
+		r := runes[i%len(runes)]
+		w := ansi.RuneWidth(r)
+		if w == 0 || used+w > width {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	return b.String()
+}
+
+// Paint colors part of a box: "border" for the frame and shadow, and
+// "primary" for the title. Lines are drawn as they are given, colors and
+// all.
+type Paint func(text, role string) string
+
+// plain paints nothing
+func plain(text, _ string) string {
+	return text
+}
+
+// Box is a frame around lines of text, with an optional title set into the
+// top border
+type Box struct {
+	Style  Style
+	Title  string
+	Lines  []string
+	Width  int  // Columns the box takes, shadow aside; 0 fits it to the title and lines
+	Center bool // Center each line rather than starting it at the left
+	Shadow bool
+}
+
+// Draw returns the rows of the box, each as wide as the others. A line too
+// long for the box is cut short.
+func (b Box) Draw(paint Paint) []string {
+	if paint == nil {
+		paint = plain
+	}
+	st := b.Style
+	if st.Horizontal == "" {
+		st = Double
+	}
+
+	// A space either side of the lines inside the frame, and of the title
+	// set into it
+	width := b.Width
+	if width <= 0 {
+		width = ansi.Width(b.Title) + 4
+		for _, line := range b.Lines {
+			width = max(width, ansi.Width(line)+4)
+		}
+	}
+	width = max(width, 4)
+	inner := width - 2
+
+	rows := make([]string, 0, len(b.Lines)+3)
+	rows = append(rows, b.top(st, inner, paint))
+	for _, line := range b.Lines {
+		line = ansi.Truncate(line, inner-2)
+		gap := inner - 2 - ansi.Width(line)
+		left := 0
+		if b.Center {
+			left = gap / 2
+		}
+		body := " " + strings.Repeat(" ", left) + line + strings.Repeat(" ", gap-left) + " "
+		rows = append(rows, paint(st.Vertical, "border")+body+paint(st.Vertical, "border"))
+	}
+	rows = append(rows, paint(st.BottomLeft+st.Rule(inner)+st.BottomRight, "border"))
+
+	if b.Shadow {
+		// The shadow falls one column right of every row but the first,
+		// and along the bottom one row down
+		for i := 1; i < len(rows); i++ {
+			rows[i] += paint(st.Shadow, "border")
+		}
+		rows[0] += " "
+		rows = append(rows, " "+paint(strings.Repeat(st.Shadow, width), "border"))
+	}
+	return rows
+}
+
+// String returns the box's rows one to a line
+func (b Box) String() string {
+	return strings.Join(b.Draw(nil), "\n")
+}
+
+// top returns the top border with the title, if any, centered in it
+func (b Box) top(st Style, inner int, paint Paint) string {
+	title := ansi.Truncate(b.Title, inner-2)
+	if title == "" {
+		return paint(st.TopLeft+st.Rule(inner)+st.TopRight, "border")
+	}
+	span := inner - ansi.Width(title) - 2
+	left := span / 2
+	return paint(st.TopLeft+st.Rule(left), "border") +
+		" " + paint(title, "primary") + " " +
+		paint(st.Rule(span-left)+st.TopRight, "border")
+}
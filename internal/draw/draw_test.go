@@ -0,0 +1,98 @@
+package draw
+
+import (
+	"strings"
+	"testing"
+
+	"bbs/internal/ansi"
+)
+
+func TestStyleNamed(t *testing.T) {
+	tests := []struct {
+		name  string
+		want  string
+		known bool
+	}{
+		{"", "double", true},
+		{"double", "double", true},
+		{"Single", "single", true},
+		{"ascii", "ascii", true},
+		{"rounded", "double", false},
+	}
+	for _, tt := range tests {
+		st, ok := StyleNamed(tt.name)
+		if st.Name != tt.want || ok != tt.known {
+			t.Errorf("StyleNamed(%q) = %s, %v, want %s, %v", tt.name, st.Name, ok, tt.want, tt.known)
+		}
+	}
+}
+
+func TestPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		width   int
+		want    string
+	}{
+		{"-=", 5, "-=-=-"},
+		{"═", 3, "═══"},
+		{"", 3, "---"},
+		{"日", 5, "日日"},
+		{"-=", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := Pattern(tt.pattern, tt.width); got != tt.want {
+			t.Errorf("Pattern(%q, %d) = %q, want %q", tt.pattern, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	if got := ASCII.Convert("╔═╗ ─│ ▒"); got != "+=+ -| #" {
+		t.Errorf("ASCII.Convert = %q", got)
+	}
+	if got := Single.Convert("╔═╗"); got != "╔═╗" {
+		t.Errorf("Single.Convert = %q", got)
+	}
+}
+
+func TestBox(t *testing.T) {
+	box := Box{Style: Single, Title: "Hi", Lines: []string{"one", "three"}}
+	want := strings.Join([]string{
+		"┌─ Hi ──┐",
+		"│ one   │",
+		"│ three │",
+		"└───────┘",
+	}, "\n")
+	if got := box.String(); got != want {
+		t.Errorf("box =\n%s\nwant\n%s", got, want)
+	}
+
+	box = Box{Style: ASCII, Lines: []string{"ab"}, Width: 8, Center: true, Shadow: true}
+	want = strings.Join([]string{
+		"+------+ ",
+		"|  ab  |#",
+		"+------+#",
+		" ########",
+	}, "\n")
+	if got := box.String(); got != want {
+		t.Errorf("box =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBox_Fit(t *testing.T) {
+	// Lines too long for a box of a set width are cut short, and every row
+	// comes out the same width whatever colors the lines carry
+	box := Box{
+		Title: "A title far too long",
+		Lines: []string{"\033[31mred\033[0m", "日本語のテキスト", ""},
+		Width: 12,
+	}
+	rows := box.Draw(func(text, role string) string {
+		return "\033[36m" + text + "\033[0m"
+	})
+	for i, row := range rows {
+		if w := ansi.Width(row); w != 12 {
+			t.Errorf("row %d %q is %d columns, want 12", i, row, w)
+		}
+	}
+}
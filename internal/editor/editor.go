@@ -0,0 +1,304 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/input"
+)
+
+const (
+	// ANSI escape codes
+	ClearContentArea = "\033[H\033[0J" // Home cursor and clear from cursor to end (respects scroll region)
+	ShowCursor       = "\033[?25h"
+
+	// Control keys, which the session key reader passes through as typed
+	keySave       = "\x13" // Ctrl+S
+	keyAbort      = "\x18" // Ctrl+X
+	keyDeleteLine = "\x19" // Ctrl+Y
+
+	maxLines  = 500
+	firstLine = 4 // Screen row of the first text line, below the header
+)
+
+// Editor is a full-screen text editor for composing posts and messages
+type Editor struct {
+	writer      Writer
+	keyReader   KeyReader
+	colorScheme ColorScheme
+	title       string
+
+	lines []string
+	row   int // Cursor line within lines
+	col   int // Cursor column within the current line
+	top   int // First line shown on screen
+}
+
+// New creates an editor with the given screen title
+func New(writer Writer, keyReader KeyReader, colorScheme ColorScheme, title string) *Editor {
+	return &Editor{
+		writer:      writer,
+		keyReader:   keyReader,
+		colorScheme: colorScheme,
+		title:       title,
+		lines:       []string{""},
+	}
+}
+
+// Edit runs the editor on the initial text. It returns the edited text and
+// true when the user saved, or false if they aborted.
+func (e *Editor) Edit(initial string) (string, bool) {
+	if initial != "" {
+		e.lines = strings.Split(strings.ReplaceAll(initial, "\r\n", "\n"), "\n")
+	}
+	e.row = len(e.lines) - 1
+	e.col = len(e.lines[e.row])
+
+	e.writer.Write([]byte(ShowCursor))
+
+	read := input.TextKeys(e.keyReader)
+
+	for {
+		e.render()
+
+		key, err := read()
+		if err != nil {
+			return "", false
+		}
+
+		switch key {
+		case keySave:
+			return strings.TrimRight(strings.Join(e.lines, "\n"), "\n "), true
+		case keyAbort, input.Escape:
+			return "", false
+		case input.Up:
+			e.moveTo(e.row-1, e.col)
+		case input.Down:
+			e.moveTo(e.row+1, e.col)
+		case input.PageUp:
+			e.moveTo(max(e.row-e.visibleRows(), 0), e.col)
+		case input.PageDown:
+			e.moveTo(min(e.row+e.visibleRows(), len(e.lines)-1), e.col)
+		case input.Home:
+			e.col = 0
+		case input.End:
+			e.col = len(e.lines[e.row])
+		case input.Left:
+			if e.col > 0 {
+				e.col--
+			} else if e.row > 0 {
+				e.moveTo(e.row-1, len(e.lines[e.row-1]))
+			}
+		case input.Right:
+			if e.col < len(e.lines[e.row]) {
+				e.col++
+			} else if e.row < len(e.lines)-1 {
+				e.moveTo(e.row+1, 0)
+			}
+		case input.Enter:
+			e.splitLine()
+		case input.Backspace, "\x7f", "\b":
+			e.backspace()
+		case input.Delete:
+			e.delete()
+		case keyDeleteLine:
+			e.deleteLine()
+		case "\t":
+			for i := 0; i < 4; i++ {
+				e.insert(' ')
+			}
+		case input.Quit, input.Goodbye:
+			// A reader without ReadTextKey turns q and g into commands; here they are letters
+			e.insert(rune(key[0]))
+		default:
+			if len(key) == 1 && key[0] >= 32 && key[0] <= 126 {
+				e.insert(rune(key[0]))
+			}
+		}
+	}
+}
+
+// size returns the terminal dimensions, falling back to 80x24
+func (e *Editor) size() (int, int) {
+	if sizer, ok := e.writer.(interface{ Size() (int, int, error) }); ok {
+		if w, h, err := sizer.Size(); err == nil && w > 20 && h > 8 {
+			return w, h
+		}
+	}
+	return 80, 24
+}
+
+// lineWidth is the longest line the editor allows before wrapping
+func (e *Editor) lineWidth() int {
+	w, _ := e.size()
+	return w - 2
+}
+
+// visibleRows is the number of text lines that fit between header and footer
+func (e *Editor) visibleRows() int {
+	_, h := e.size()
+	// Header (3 rows), footer line, and the status bar line
+	return h - firstLine - 2
+}
+
+// moveTo places the cursor, clamping to the text
+func (e *Editor) moveTo(row, col int) {
+	if row < 0 || row >= len(e.lines) {
+		return
+	}
+	e.row = row
+	e.col = col
+	if e.col > len(e.lines[e.row]) {
+		e.col = len(e.lines[e.row])
+	}
+}
+
+// insert types a character at the cursor, wrapping the line if it gets too long
+func (e *Editor) insert(ch rune) {
+	line := e.lines[e.row]
+	e.lines[e.row] = line[:e.col] + string(ch) + line[e.col:]
+	e.col++
+
+	if len(e.lines[e.row]) > e.lineWidth() {
+		e.wrap()
+	}
+}
+
+// wrap moves the overflowing tail of the current line onto a new line,
+// breaking at the last space where possible
+func (e *Editor) wrap() {
+	if len(e.lines) >= maxLines {
+		// No room for another line: undo the insert
+		line := e.lines[e.row]
+		e.lines[e.row] = line[:e.col-1] + line[e.col:]
+		e.col--
+		return
+	}
+
+	line := e.lines[e.row]
+	width := e.lineWidth()
+
+	split := strings.LastIndex(line[:width], " ")
+	tailStart := split + 1
+	if split <= 0 {
+		split = width
+		tailStart = width
+	}
+
+	head, tail := line[:split], line[tailStart:]
+	e.lines[e.row] = head
+	e.lines = append(e.lines[:e.row+1], append([]string{tail}, e.lines[e.row+1:]...)...)
+
+	if e.col >= tailStart {
+		e.row++
+		e.col -= tailStart
+	} else if e.col > len(head) {
+		e.col = len(head)
+	}
+}
+
+// splitLine breaks the current line at the cursor
+func (e *Editor) splitLine() {
+	if len(e.lines) >= maxLines {
+		return
+	}
+
+	line := e.lines[e.row]
+	e.lines[e.row] = line[:e.col]
+	e.lines = append(e.lines[:e.row+1], append([]string{line[e.col:]}, e.lines[e.row+1:]...)...)
+	e.row++
+	e.col = 0
+}
+
+// backspace deletes the character before the cursor, joining lines at column 0
+func (e *Editor) backspace() {
+	if e.col > 0 {
+		line := e.lines[e.row]
+		e.lines[e.row] = line[:e.col-1] + line[e.col:]
+		e.col--
+		return
+	}
+
+	if e.row == 0 {
+		return
+	}
+
+	prev := e.lines[e.row-1]
+	if len(prev)+len(e.lines[e.row]) > e.lineWidth() {
+		return // Joined line wouldn't fit
+	}
+
+	e.lines[e.row-1] = prev + e.lines[e.row]
+	e.lines = append(e.lines[:e.row], e.lines[e.row+1:]...)
+	e.row--
+	e.col = len(prev)
+}
+
+// delete deletes the character under the cursor, joining the next line at
+// the end of one
+func (e *Editor) delete() {
+	if e.col < len(e.lines[e.row]) {
+		e.col++
+		e.backspace()
+	} else if e.row < len(e.lines)-1 {
+		row, col := e.row, e.col
+		e.moveTo(row+1, 0)
+		e.backspace()
+		if e.row != row {
+			// The joined line wouldn't fit
+			e.moveTo(row, col)
+		}
+	}
+}
+
+// deleteLine removes the current line
+func (e *Editor) deleteLine() {
+	if len(e.lines) == 1 {
+		e.lines[0] = ""
+		e.col = 0
+		return
+	}
+
+	e.lines = append(e.lines[:e.row], e.lines[e.row+1:]...)
+	if e.row >= len(e.lines) {
+		e.row = len(e.lines) - 1
+	}
+	e.moveTo(e.row, e.col)
+}
+
+// render redraws the editor screen and places the cursor
+func (e *Editor) render() {
+	width, _ := e.size()
+	rows := e.visibleRows()
+
+	// Keep the cursor line on screen
+	if e.row < e.top {
+		e.top = e.row
+	}
+	if e.row >= e.top+rows {
+		e.top = e.row - rows + 1
+	}
+
+	var out strings.Builder
+	out.WriteString(ClearContentArea)
+
+	title := e.colorScheme.Colorize(e.title, "primary")
+	out.WriteString(fmt.Sprintf("\033[1;1H%s", e.colorScheme.CenterText(title, width-1)))
+
+	help := e.colorScheme.Colorize("Ctrl+S: Save  Ctrl+X: Abort  Ctrl+Y: Delete Line", "secondary")
+	out.WriteString(fmt.Sprintf("\033[2;1H%s", e.colorScheme.CenterText(help, width-1)))
+
+	out.WriteString(fmt.Sprintf("\033[3;1H%s", e.colorScheme.Colorize(strings.Repeat("─", width-1), "secondary")))
+
+	for i := 0; i < rows && e.top+i < len(e.lines); i++ {
+		out.WriteString(fmt.Sprintf("\033[%d;1H%s", firstLine+i, e.colorScheme.Colorize(e.lines[e.top+i], "text")))
+	}
+
+	status := fmt.Sprintf("Line %d/%d  Col %d", e.row+1, len(e.lines), e.col+1)
+	out.WriteString(fmt.Sprintf("\033[%d;1H%s", firstLine+rows, e.colorScheme.Colorize(status, "accent")))
+
+	// Leave the cursor at the editing position
+	out.WriteString(fmt.Sprintf("\033[%d;%dH", firstLine+e.row-e.top, e.col+1))
+
+	e.writer.Write([]byte(out.String()))
+}
@@ -0,0 +1,92 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+type fakeWriter struct{ strings.Builder }
+
+func (w *fakeWriter) Size() (int, int, error) { return 30, 24, nil }
+
+type fakeKeys struct{ keys []string }
+
+func (k *fakeKeys) ReadKey() (string, error) {
+	key := k.keys[0]
+	k.keys = k.keys[1:]
+	return key, nil
+}
+
+type plainColors struct{}
+
+func (plainColors) Colorize(text, style string) string       { return text }
+func (plainColors) CenterText(text string, width int) string { return text }
+
+// typeKeys turns a string into single-key presses
+func typeKeys(s string) []string {
+	var keys []string
+	for _, ch := range s {
+		keys = append(keys, string(ch))
+	}
+	return keys
+}
+
+func runEditor(initial string, keys ...string) (string, bool) {
+	e := New(&fakeWriter{}, &fakeKeys{keys: keys}, plainColors{}, "Test")
+	return e.Edit(initial)
+}
+
+func TestEditTypingAndSave(t *testing.T) {
+	keys := append(typeKeys("hello"), "enter")
+	keys = append(keys, typeKeys("world")...)
+	keys = append(keys, keySave)
+
+	text, saved := runEditor("", keys...)
+	if !saved {
+		t.Fatal("expected text to be saved")
+	}
+	if text != "hello\nworld" {
+		t.Errorf("got %q", text)
+	}
+}
+
+func TestEditAbort(t *testing.T) {
+	if _, saved := runEditor("draft", "x", keyAbort); saved {
+		t.Error("abort should not save")
+	}
+}
+
+func TestEditWordWrap(t *testing.T) {
+	// Terminal is 30 wide, so lines wrap past 28 characters
+	keys := append(typeKeys("the quick brown fox jumps over the lazy dog"), keySave)
+
+	text, _ := runEditor("", keys...)
+	lines := strings.Split(text, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+	if lines[0] != "the quick brown fox jumps" || lines[1] != "over the lazy dog" {
+		t.Errorf("unexpected wrap: %q", lines)
+	}
+}
+
+func TestEditBackspaceJoinsLines(t *testing.T) {
+	text, _ := runEditor("one\ntwo", "left", "left", "left", "backspace", keySave)
+	if text != "onetwo" {
+		t.Errorf("got %q", text)
+	}
+}
+
+func TestEditQuitAndGoodbyeKeysTypeLetters(t *testing.T) {
+	text, _ := runEditor("", "quit", "goodbye", keySave)
+	if text != "qg" {
+		t.Errorf("got %q", text)
+	}
+}
+
+func TestEditHomeEndDelete(t *testing.T) {
+	text, _ := runEditor("xone\ntwo", "up", "home", "delete", "end", "delete", "!", keySave)
+	if text != "one!two" {
+		t.Errorf("got %q", text)
+	}
+}
@@ -0,0 +1,17 @@
+package editor
+
+// Writer interface for output operations
+type Writer interface {
+	Write([]byte) (int, error)
+}
+
+// KeyReader interface for reading user input
+type KeyReader interface {
+	ReadKey() (string, error)
+}
+
+// ColorScheme interface for colorizing text
+type ColorScheme interface {
+	Colorize(text string, style string) string
+	CenterText(text string, width int) string
+}
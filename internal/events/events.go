@@ -0,0 +1,80 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of event published on the bus
+type Type string
+
+const (
+	UserLogin  Type = "login"  // A caller finished logging in
+	UserLogout Type = "logout" // A caller left the board
+
+	ScheduledEventWarning Type = "event_warning" // A scheduled event will log everyone off soon
+	ScheduledEventStart   Type = "event_start"   // A scheduled event began; callers must log off
+)
+
+// Event is something that happened on the board that other sessions may
+// want to know about
+type Event struct {
+	Type     Type
+	Username string
+	Node     int
+	Message  string
+	Time     time.Time
+	At       time.Time // When a scheduled event begins
+}
+
+// Handler receives published events. Handlers run on the publisher's
+// goroutine and must not block.
+type Handler func(Event)
+
+// Bus delivers events to every subscriber
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[int]Handler
+	nextID   int
+}
+
+// NewBus creates an empty event bus
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[int]Handler),
+	}
+}
+
+// Subscribe registers a handler and returns a function that removes it
+func (b *Bus) Subscribe(handler Handler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// Publish sends an event to all current subscribers
+func (b *Bus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
@@ -0,0 +1,420 @@
+// Package feeds serves Atom and RSS feeds of the board's new bulletins and
+// of new posts in chosen topics open to every caller, so people can follow
+// the board in a feed reader without logging in.
+package feeds
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+// defaultMaxItems is how many entries a feed has when max_items isn't set
+const defaultMaxItems = 50
+
+// Feed formats, by the extension of their path
+const (
+	atomExt = ".atom"
+	rssExt  = ".rss"
+)
+
+// Server serves the feeds of one board
+type Server struct {
+	db     *database.DB
+	cfg    config.FeedsConfig
+	system string
+	sysop  string
+	mux    *http.ServeMux
+}
+
+// feed is what both formats are rendered from
+type feed struct {
+	Title       string
+	Description string
+	Path        string // Path of the feed without its extension, e.g. "/topics/3"
+	Entries     []entry
+}
+
+// entry is one bulletin or post
+type entry struct {
+	Key       string // Unique within the feed, e.g. "post-12"
+	Title     string
+	Author    string
+	Content   string
+	Published time.Time
+}
+
+// NewServer creates the feeds for the board cfg describes
+func NewServer(cfg *config.Config, db *database.DB) *Server {
+	s := &Server{
+		db:     db,
+		cfg:    cfg.Server.Feeds,
+		system: cfg.BBS.SystemName,
+		sysop:  cfg.BBS.SysopName,
+		mux:    http.NewServeMux(),
+	}
+	if s.cfg.MaxItems <= 0 {
+		s.cfg.MaxItems = defaultMaxItems
+	}
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.HandleFunc("GET /{feed}", s.handleBulletins)
+	s.mux.HandleFunc("GET /topics/{feed}", s.handleTopic)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the feeds on the configured address
+func (s *Server) ListenAndServe() error {
+	log.Printf("Feeds listening on %s", s.cfg.Listen)
+	return http.ListenAndServe(s.cfg.Listen, s)
+}
+
+// indexTemplate lists the feeds for people who open the address in a browser
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.System}} feeds</title>
+{{range .Feeds}}<link rel="alternate" type="application/atom+xml" title="{{.Title}}" href="{{.Href}}.atom">
+{{end}}</head>
+<body><h1>{{.System}} feeds</h1>
+{{if .Feeds}}<ul>
+{{range .Feeds}}<li>{{.Title}}: <a href="{{.Href}}.atom">Atom</a> or <a href="{{.Href}}.rss">RSS</a></li>
+{{end}}</ul>{{else}}<p>There are no feeds yet.</p>{{end}}
+</body></html>
+`))
+
+// handleIndex lists the feeds
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	type link struct{ Title, Href string }
+	var links []link
+	if s.cfg.Bulletins {
+		links = append(links, link{"System Bulletins", s.baseURL(r) + "/bulletins"})
+	}
+	topics, err := s.topics()
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	for _, topic := range topics {
+		links = append(links, link{topic.Name, fmt.Sprintf("%s/topics/%d", s.baseURL(r), topic.ID)})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	indexTemplate.Execute(w, struct {
+		System string
+		Feeds  []link
+	}{s.system, links})
+}
+
+// handleBulletins serves /bulletins.atom and /bulletins.rss
+func (s *Server) handleBulletins(w http.ResponseWriter, r *http.Request) {
+	name, ext, ok := splitFeed(r.PathValue("feed"))
+	if !ok || name != "bulletins" || !s.cfg.Bulletins {
+		http.NotFound(w, r)
+		return
+	}
+
+	bulletins, err := s.db.GetBulletins(s.cfg.MaxItems)
+	if err != nil {
+		s.fail(w, r, fmt.Errorf("failed to load bulletins: %w", err))
+		return
+	}
+	f := &feed{
+		Title:       s.system + ": System Bulletins",
+		Description: "Bulletins from the sysop of " + s.system,
+		Path:        "/bulletins",
+	}
+	for _, b := range bulletins {
+		published := b.CreatedAt
+		if b.PublishAt != nil {
+			published = *b.PublishAt
+		}
+		f.Entries = append(f.Entries, entry{
+			Key:       fmt.Sprintf("bulletin-%d", b.ID),
+			Title:     b.Title,
+			Author:    b.Author,
+			Content:   b.Body,
+			Published: published,
+		})
+	}
+	s.serve(w, r, f, ext)
+}
+
+// handleTopic serves /topics/ID.atom and /topics/ID.rss
+func (s *Server) handleTopic(w http.ResponseWriter, r *http.Request) {
+	name, ext, ok := splitFeed(r.PathValue("feed"))
+	id, err := strconv.Atoi(name)
+	if !ok || err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	topics, err := s.topics()
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	var topic *database.Topic
+	for i := range topics {
+		if topics[i].ID == id {
+			topic = &topics[i]
+		}
+	}
+	if topic == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	posts, err := s.db.GetPosts(topic.ID, s.cfg.MaxItems)
+	if err != nil {
+		s.fail(w, r, fmt.Errorf("failed to load posts in %s: %w", topic.Name, err))
+		return
+	}
+	f := &feed{
+		Title:       s.system + ": " + topic.Name,
+		Description: topic.Description,
+		Path:        fmt.Sprintf("/topics/%d", topic.ID),
+	}
+	if f.Description == "" {
+		f.Description = "New posts in " + topic.Name
+	}
+	for _, post := range posts {
+		f.Entries = append(f.Entries, entry{
+			Key:       fmt.Sprintf("post-%d", post.ID),
+			Title:     post.Subject,
+			Author:    post.Author,
+			Content:   post.Body,
+			Published: post.CreatedAt,
+		})
+	}
+	s.serve(w, r, f, ext)
+}
+
+// topics returns the configured topics that are open to everyone, in the
+// order the board lists them
+func (s *Server) topics() ([]database.Topic, error) {
+	topics, _, err := s.db.GetPublicTopics(s.cfg.Topics)
+	return topics, err
+}
+
+// serve renders a feed, newest entries first, and sends it. The newest
+// entry's date is sent as Last-Modified, so readers that poll with
+// If-Modified-Since are told when nothing is new.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, f *feed, ext string) {
+	// Pinned posts come first from the database; a feed is newest first
+	sort.SliceStable(f.Entries, func(i, j int) bool {
+		return f.Entries[i].Published.After(f.Entries[j].Published)
+	})
+	for i := range f.Entries {
+		f.Entries[i].Title = plain(f.Entries[i].Title)
+		f.Entries[i].Content = plain(f.Entries[i].Content)
+	}
+
+	var updated time.Time
+	if len(f.Entries) > 0 {
+		updated = f.Entries[0].Published
+	}
+
+	var body []byte
+	var err error
+	var contentType string
+	switch ext {
+	case atomExt:
+		body, err = s.atom(f, s.baseURL(r), updated)
+		contentType = "application/atom+xml; charset=utf-8"
+	default:
+		body, err = s.rss(f, s.baseURL(r), updated)
+		contentType = "application/rss+xml; charset=utf-8"
+	}
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	http.ServeContent(w, r, "", updated, bytes.NewReader(body))
+}
+
+// baseURL is where the feeds are reached, with no trailing slash
+func (s *Server) baseURL(r *http.Request) string {
+	if s.cfg.BaseURL != "" {
+		return strings.TrimRight(s.cfg.BaseURL, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// fail logs an error and tells the reader to try again later
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("feeds: %s: %v", r.URL.Path, err)
+	http.Error(w, "the board couldn't answer that right now", http.StatusInternalServerError)
+}
+
+// splitFeed splits "bulletins.atom" into its name and format
+func splitFeed(file string) (string, string, bool) {
+	for _, ext := range []string{atomExt, rssExt} {
+		if name, ok := strings.CutSuffix(file, ext); ok && name != "" {
+			return name, ext, true
+		}
+	}
+	return "", "", false
+}
+
+// plain drops ANSI codes and control characters other than line breaks and
+// tabs, which XML can't carry and readers can't show
+func plain(text string) string {
+	text = ansi.Strip(strings.ReplaceAll(text, "\r\n", "\n"))
+	return strings.Map(func(r rune) rune {
+		if r < ' ' && r != '\n' && r != '\t' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// Atom, as RFC 4287 describes it
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published"`
+	Author    atomPerson `xml:"author"`
+	Content   atomText   `xml:"content"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// atom renders a feed as Atom. Entry IDs are the feed's address with the
+// entry's key as the fragment, which stays the same between fetches.
+func (s *Server) atom(f *feed, base string, updated time.Time) ([]byte, error) {
+	if updated.IsZero() {
+		updated = time.Unix(0, 0)
+	}
+	self := base + f.Path + atomExt
+	out := atomFeed{
+		ID:      self,
+		Title:   f.Title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Author:  atomPerson{Name: s.sysop},
+		Links:   []atomLink{{Rel: "self", Href: self}},
+	}
+	for _, e := range f.Entries {
+		stamp := e.Published.UTC().Format(time.RFC3339)
+		out.Entries = append(out.Entries, atomEntry{
+			ID:        self + "#" + e.Key,
+			Title:     e.Title,
+			Updated:   stamp,
+			Published: stamp,
+			Author:    atomPerson{Name: e.Author},
+			Content:   atomText{Type: "text", Text: e.Content},
+		})
+	}
+	return marshal(out)
+}
+
+// RSS 2.0
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Author      string  `xml:"dc:creator"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate"`
+	GUID        rssGUID `xml:"guid"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Text        string `xml:",chardata"`
+}
+
+// rss renders a feed as RSS 2.0. RSS wants an email address for an author,
+// so authors go in the Dublin Core creator element instead.
+func (s *Server) rss(f *feed, base string, updated time.Time) ([]byte, error) {
+	self := base + f.Path + rssExt
+	out := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        base + "/",
+			Description: f.Description,
+		},
+	}
+	if !updated.IsZero() {
+		out.Channel.LastBuildDate = updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, e := range f.Entries {
+		out.Channel.Items = append(out.Channel.Items, rssItem{
+			Title:       e.Title,
+			Author:      e.Author,
+			Description: e.Content,
+			PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+			GUID:        rssGUID{Text: self + "#" + e.Key},
+		})
+	}
+	body, err := marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	// encoding/xml can't declare a namespace prefix, so dc: is declared here
+	return bytes.Replace(body, []byte(`<rss version="2.0">`),
+		[]byte(`<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">`), 1), nil
+}
+
+// marshal renders v as an indented XML document
+func marshal(v any) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render feed: %w", err)
+	}
+	return append([]byte(xml.Header), append(body, '\n')...), nil
+}
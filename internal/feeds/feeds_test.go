@@ -0,0 +1,153 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+func newTestServer(t *testing.T) (*Server, *database.DB) {
+	t.Helper()
+	db, err := database.Initialize(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := &config.Config{}
+	cfg.BBS.SystemName = "Test BBS"
+	cfg.BBS.SysopName = "Sysop"
+	cfg.Server.Feeds = config.FeedsConfig{
+		BaseURL:   "https://bbs.example.org/feeds/",
+		Topics:    []string{"general", "Sysops"},
+		Bulletins: true,
+		MaxItems:  2,
+	}
+	return NewServer(cfg, db), db
+}
+
+func get(t *testing.T, s *Server, path string, header http.Header) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+func body(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFeeds(t *testing.T) {
+	s, db := newTestServer(t)
+
+	general := &database.Topic{Name: "General"}
+	sysops := &database.Topic{Name: "Sysops", AccessLevel: 255}
+	for _, topic := range []*database.Topic{general, sysops} {
+		if err := db.CreateTopic(topic); err != nil {
+			t.Fatalf("CreateTopic failed: %v", err)
+		}
+	}
+	var posts []*database.Post
+	for i, subject := range []string{"Oldest", "Middle", "Newest <3"} {
+		post := &database.Post{TopicID: general.ID, Author: "alice", Subject: subject, Body: fmt.Sprintf("\x1b[1mPost\x1b[0m %d", i)}
+		if err := db.CreatePost(post); err != nil {
+			t.Fatalf("CreatePost failed: %v", err)
+		}
+		posts = append(posts, post)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := db.CreatePost(&database.Post{TopicID: sysops.ID, Author: "sysop", Subject: "Private", Body: "Not for feeds"}); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	if err := db.CreateBulletin(&database.Bulletin{Title: "News", Body: "Open house", Author: "sysop"}); err != nil {
+		t.Fatalf("CreateBulletin failed: %v", err)
+	}
+
+	// Atom
+	resp := get(t, s, fmt.Sprintf("/topics/%d.atom", general.ID), nil)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/atom+xml") {
+		t.Fatalf("atom feed: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	var atom atomFeed
+	if err := xml.Unmarshal([]byte(body(t, resp)), &atom); err != nil {
+		t.Fatalf("atom feed doesn't parse: %v", err)
+	}
+	self := fmt.Sprintf("https://bbs.example.org/feeds/topics/%d.atom", general.ID)
+	if atom.ID != self || atom.Title != "Test BBS: General" || len(atom.Entries) != 2 {
+		t.Fatalf("atom feed = %+v", atom)
+	}
+	first := atom.Entries[0]
+	if first.Title != "Newest <3" || first.Content.Text != "Post 2" || first.Author.Name != "alice" ||
+		first.ID != fmt.Sprintf("%s#post-%d", self, posts[2].ID) {
+		t.Errorf("newest entry = %+v", first)
+	}
+
+	// RSS
+	resp = get(t, s, "/bulletins.rss", nil)
+	text := body(t, resp)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/rss+xml") {
+		t.Errorf("rss content type = %s", resp.Header.Get("Content-Type"))
+	}
+	var rss rssFeed
+	if err := xml.Unmarshal([]byte(text), &rss); err != nil {
+		t.Fatalf("rss feed doesn't parse: %v", err)
+	}
+	if len(rss.Channel.Items) != 1 || rss.Channel.Items[0].Title != "News" || rss.Channel.Items[0].Description != "Open house" {
+		t.Errorf("rss feed = %+v", rss)
+	}
+	if !strings.Contains(text, `xmlns:dc="http://purl.org/dc/elements/1.1/"`) || !strings.Contains(text, "<dc:creator>sysop</dc:creator>") {
+		t.Errorf("rss feed doesn't credit the author:\n%s", text)
+	}
+
+	// Readers polling with the last date they saw are told nothing is new
+	modified := resp.Header.Get("Last-Modified")
+	if modified == "" {
+		t.Fatal("no Last-Modified on the feed")
+	}
+	if resp := get(t, s, "/bulletins.rss", http.Header{"If-Modified-Since": {modified}}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("conditional fetch: %d, want 304", resp.StatusCode)
+	}
+
+	index := body(t, get(t, s, "/", nil))
+	for _, want := range []string{"https://bbs.example.org/feeds/bulletins.atom", fmt.Sprintf("https://bbs.example.org/feeds/topics/%d.rss", general.ID)} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index missing %s:\n%s", want, index)
+		}
+	}
+	if strings.Contains(index, "Sysops") {
+		t.Errorf("index lists a private topic:\n%s", index)
+	}
+
+	for _, path := range []string{
+		fmt.Sprintf("/topics/%d.atom", sysops.ID),
+		"/topics/999.atom", "/topics/x.rss", fmt.Sprintf("/topics/%d.json", general.ID),
+		"/bulletins.json", "/users.atom", "/topics/.atom",
+	} {
+		if resp := get(t, s, path, nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: %d, want 404", path, resp.StatusCode)
+		}
+	}
+
+	s.cfg.Bulletins = false
+	if resp := get(t, s, "/bulletins.atom", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("bulletin feed served while turned off: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,57 @@
+package ftn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Address is a FidoNet-style node address, zone:net/node.point
+type Address struct {
+	Zone  int
+	Net   int
+	Node  int
+	Point int
+}
+
+// ParseAddress reads an address such as 1:234/5 or 1:234/5.6. A domain
+// after @, as in 1:234/5@fidonet, is ignored.
+func ParseAddress(s string) (Address, error) {
+	text := strings.TrimSpace(s)
+	if at := strings.Index(text, "@"); at >= 0 {
+		text = text[:at]
+	}
+
+	zone, rest, ok := strings.Cut(text, ":")
+	if !ok {
+		return Address{}, fmt.Errorf("invalid address %q: no zone", s)
+	}
+	net, rest, ok := strings.Cut(rest, "/")
+	if !ok {
+		return Address{}, fmt.Errorf("invalid address %q: no net", s)
+	}
+	node, point, hasPoint := strings.Cut(rest, ".")
+
+	fields := []string{zone, net, node}
+	if hasPoint {
+		fields = append(fields, point)
+	}
+	var values [4]int
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 || n > 0xFFFF {
+			return Address{}, fmt.Errorf("invalid address %q", s)
+		}
+		values[i] = n
+	}
+	return Address{Zone: values[0], Net: values[1], Node: values[2], Point: values[3]}, nil
+}
+
+// String formats the address, leaving off a zero point
+func (a Address) String() string {
+	s := fmt.Sprintf("%d:%d/%d", a.Zone, a.Net, a.Node)
+	if a.Point != 0 {
+		s += fmt.Sprintf(".%d", a.Point)
+	}
+	return s
+}
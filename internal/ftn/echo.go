@@ -0,0 +1,185 @@
+package ftn
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+
+	"bbs/internal/ansi"
+)
+
+// Echo is an echomail message with its control lines taken apart
+type Echo struct {
+	Area    string
+	MsgID   string // Such as "1:234/5 0badcafe"; empty if the sender gave none
+	Reply   string // MSGID of the message answered
+	From    string
+	To      string
+	Subject string
+	Origin  Address // Where the message was written, if it says
+	Date    time.Time
+	Body    string // Lines end in LF; the tear and origin lines are kept
+}
+
+// ParseEcho takes apart a packed message. It returns nil for netmail,
+// which has no AREA line.
+func ParseEcho(m Message) *Echo {
+	lines := strings.Split(strings.ReplaceAll(m.Text, "\r\n", "\r"), "\r")
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "AREA:") {
+		return nil
+	}
+	e := &Echo{Area: strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(lines[0], "AREA:")))}
+
+	charset := ""
+	var body []string
+	for _, line := range lines[1:] {
+		line = strings.TrimPrefix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "\x01"):
+			name, value, _ := strings.Cut(line[1:], ":")
+			value = strings.TrimSpace(value)
+			switch strings.ToUpper(name) {
+			case "MSGID":
+				e.MsgID = value
+			case "REPLY":
+				e.Reply = value
+			case "CHRS", "CHARSET":
+				charset, _, _ = strings.Cut(value, " ")
+			}
+		case strings.HasPrefix(line, "SEEN-BY:"):
+		default:
+			body = append(body, line)
+		}
+	}
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+
+	decode := decoder(charset)
+	e.From, e.To, e.Subject = decode(m.From), decode(m.To), decode(m.Subject)
+	e.Body = decode(strings.Join(body, "\n"))
+
+	if addr, _, ok := strings.Cut(e.MsgID, " "); ok {
+		e.Origin, _ = ParseAddress(addr)
+	}
+	if e.Origin == (Address{}) {
+		e.Origin = originAddress(body)
+	}
+	e.Date, _ = time.ParseInLocation(dateLayout, strings.TrimSpace(m.Date), time.Local)
+	return e
+}
+
+// originAddress reads the address at the end of the origin line, such as
+// " * Origin: Some BBS (1:234/5)"
+func originAddress(body []string) Address {
+	for i := len(body) - 1; i >= 0; i-- {
+		line := body[i]
+		if !strings.HasPrefix(line, " * Origin:") {
+			continue
+		}
+		open, end := strings.LastIndex(line, "("), strings.LastIndex(line, ")")
+		if open < 0 || end < open {
+			break
+		}
+		addr, _ := ParseAddress(line[open+1 : end])
+		return addr
+	}
+	return Address{}
+}
+
+// decoder returns a function that converts text in the charset a CHRS
+// kludge names to UTF-8. Messages without one are taken as CP437, which is
+// what most DOS-era software wrote.
+func decoder(charset string) func(string) string {
+	var enc encoding.Encoding
+	switch strings.ToUpper(charset) {
+	case "UTF-8", "ASCII":
+		return func(s string) string { return strings.ToValidUTF8(s, "?") }
+	case "", "CP437", "IBMPC", "PC-8":
+		enc = charmap.CodePage437
+	case "CP850":
+		enc = charmap.CodePage850
+	case "CP866":
+		enc = charmap.CodePage866
+	case "LATIN-1":
+		enc = charmap.ISO8859_1
+	case "LATIN-9":
+		enc = charmap.ISO8859_15
+	default:
+		var err error
+		if enc, err = htmlindex.Get(charset); err != nil {
+			enc = charmap.CodePage437
+		}
+	}
+	dec := enc.NewDecoder()
+	return func(s string) string {
+		out, err := dec.String(s)
+		if err != nil {
+			return strings.ToValidUTF8(s, "?")
+		}
+		return out
+	}
+}
+
+// encode packs an echo written on this board. Text is sent as UTF-8 with
+// the kludges saying so, and the tear, origin and routing lines a hub
+// expects after the body.
+func (e *Echo) encode(from, to Address, origin string) Message {
+	var text strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&text, format, args...)
+		text.WriteByte('\r')
+	}
+
+	line("AREA:%s", e.Area)
+	line("\x01MSGID: %s", e.MsgID)
+	if e.Reply != "" {
+		line("\x01REPLY: %s", e.Reply)
+	}
+	line("\x01CHRS: UTF-8 4")
+	line("\x01TZUTC: %s", strings.TrimPrefix(e.Date.Format("-0700"), "+"))
+	line("\x01PID: %s", software)
+	for _, body := range strings.Split(strings.TrimRight(e.Body, "\n"), "\n") {
+		body = strings.TrimRight(body, "\r")
+		// Keep body lines from being read as the control lines below
+		if body == "---" || strings.HasPrefix(body, "--- ") {
+			body = "-+-" + body[3:]
+		}
+		if strings.HasPrefix(body, " * Origin:") || strings.HasPrefix(body, "SEEN-BY:") {
+			body = " " + body
+		}
+		line("%s", body)
+	}
+	line("")
+	line("--- %s", software)
+	line(" * Origin: %s (%s)", ansi.Truncate(origin, 79-len(" * Origin:  ()")-len(from.String())), from)
+
+	// A point is hidden behind its boss, which adds its own lines
+	var seenBy []string
+	if from.Point == 0 {
+		seenBy = append(seenBy, fmt.Sprintf("%d/%d", from.Net, from.Node))
+	}
+	if to.Point == 0 && (to.Net != from.Net || to.Node != from.Node) {
+		seenBy = append(seenBy, fmt.Sprintf("%d/%d", to.Net, to.Node))
+	}
+	if len(seenBy) > 0 {
+		line("SEEN-BY: %s", strings.Join(seenBy, " "))
+	}
+	if from.Point == 0 {
+		line("\x01PATH: %d/%d", from.Net, from.Node)
+	}
+
+	return Message{
+		OrigNode: from.Node, OrigNet: from.Net,
+		DestNode: to.Node, DestNet: to.Net,
+		Date:    e.Date.Format(dateLayout),
+		To:      ansi.Truncate(e.To, 35),
+		From:    ansi.Truncate(e.From, 35),
+		Subject: ansi.Truncate(e.Subject, 71),
+		Text:    text.String(),
+	}
+}
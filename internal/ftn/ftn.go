@@ -0,0 +1,458 @@
+// Package ftn links message board topics to FidoNet-style echomail areas.
+// Tossing reads the Type 2+ packets a mailer such as binkd leaves in the
+// inbound directory into the linked topics; scanning packs the posts and
+// replies written here since into a packet in the outbound directory for
+// the mailer to carry to the uplink.
+package ftn
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+// software names this board in tear lines and PID kludges
+const software = "Coastline BBS"
+
+// bundlePattern matches the ARCmail bundles an uplink sends, named for the
+// day of the week, such as 0000fff1.mo0
+var bundlePattern = regexp.MustCompile(`(?i)\.(mo|tu|we|th|fr|sa|su)[0-9a-z]$`)
+
+// maxPacketSize is the most a packet in a bundle may unpack to. A day's
+// echomail is far smaller; a bundle that unpacks to more is set aside
+// rather than read into memory.
+const maxPacketSize = 16 << 20
+
+// TossResult counts what a toss did
+type TossResult struct {
+	Packets    int      // Packets read
+	Posts      int      // Messages that started a new post
+	Replies    int      // Messages threaded under an earlier one
+	Duplicates int      // Messages tossed before, left alone
+	Skipped    int      // Netmail and messages for echoes no topic is linked to
+	Bad        []string // Packets set aside as .bad, with the reason
+}
+
+// ScanResult says what a scan sent
+type ScanResult struct {
+	Messages int
+	Packet   string // Packet written, empty when there was nothing to send
+}
+
+// Node exchanges echomail for one board
+type Node struct {
+	db       *database.DB
+	cfg      config.FTNConfig
+	address  Address
+	uplink   Address
+	origin   string
+	inbound  string
+	outbound string
+	topics   map[string]*database.Topic // Linked topics by echo tag
+}
+
+// NewNode checks the board's FTN settings and finds the linked topics
+func NewNode(db *database.DB, cfg *config.Config) (*Node, error) {
+	ftn := cfg.BBS.FTN
+	if ftn.Address == "" {
+		return nil, fmt.Errorf("ftn.address is not set")
+	}
+	address, err := ParseAddress(ftn.Address)
+	if err != nil {
+		return nil, err
+	}
+	var uplink Address
+	if ftn.Uplink != "" {
+		if uplink, err = ParseAddress(ftn.Uplink); err != nil {
+			return nil, err
+		}
+	}
+	if len(ftn.Password) > 8 {
+		return nil, fmt.Errorf("ftn.password is longer than 8 characters")
+	}
+
+	topics, err := db.GetTopics(255)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load topics: %w", err)
+	}
+	n := &Node{
+		db:       db,
+		cfg:      ftn,
+		address:  address,
+		uplink:   uplink,
+		origin:   ftn.Origin,
+		inbound:  cfg.Paths.RootPath(ftn.Inbound),
+		outbound: cfg.Paths.RootPath(ftn.Outbound),
+		topics:   make(map[string]*database.Topic),
+	}
+	if n.origin == "" {
+		n.origin = cfg.BBS.SystemName
+	}
+	for _, area := range ftn.Areas {
+		tag := strings.ToUpper(area.Tag)
+		for i := range topics {
+			if strings.EqualFold(topics[i].Name, area.Topic) {
+				n.topics[tag] = &topics[i]
+			}
+		}
+		if n.topics[tag] == nil {
+			return nil, fmt.Errorf("echo %s is linked to topic %q, which doesn't exist", area.Tag, area.Topic)
+		}
+	}
+	return n, nil
+}
+
+// Toss reads every packet and bundle in the inbound directory, then removes
+// it. Packets with the wrong password or that can't be read are renamed
+// to .bad and left for the sysop. Messages tossed before are skipped, so a
+// toss that stops partway can be run again.
+func (n *Node) Toss() (TossResult, error) {
+	var result TossResult
+
+	if err := n.db.EnsurePlaceholderUser(n.cfg.Author, "Echomail"); err != nil {
+		return result, err
+	}
+
+	entries, err := os.ReadDir(n.inbound)
+	if os.IsNotExist(err) {
+		return result, nil // Nothing has arrived yet
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to read inbound: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(n.inbound, name)
+		if entry.IsDir() {
+			continue
+		}
+
+		var tossErr error
+		switch {
+		case strings.EqualFold(filepath.Ext(name), ".pkt"):
+			tossErr = n.tossFile(path, &result)
+		case bundlePattern.MatchString(name):
+			tossErr = n.tossBundle(path, &result)
+		default:
+			continue
+		}
+
+		var bad *badPacket
+		switch {
+		case tossErr == nil:
+			if err := os.Remove(path); err != nil {
+				return result, err
+			}
+		case errors.As(tossErr, &bad):
+			result.Bad = append(result.Bad, fmt.Sprintf("%s: %s", name, bad.reason))
+			if err := os.Rename(path, path+".bad"); err != nil {
+				return result, err
+			}
+		default:
+			return result, fmt.Errorf("%s: %w", name, tossErr)
+		}
+	}
+	return result, nil
+}
+
+// badPacket is a packet that can't be tossed however often it's tried
+type badPacket struct {
+	reason string
+}
+
+func (b *badPacket) Error() string {
+	return b.reason
+}
+
+// tossFile tosses one packet file
+func (n *Node) tossFile(path string, result *TossResult) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return n.tossPacket(f, result)
+}
+
+// tossBundle tosses every packet in a zipped ARCmail bundle
+func (n *Node) tossBundle(path string, result *TossResult) error {
+	bundle, err := zip.OpenReader(path)
+	if err != nil {
+		return &badPacket{reason: "not a zip bundle"}
+	}
+	defer bundle.Close()
+
+	// An oversized packet sets the whole bundle aside before any of it is tossed
+	var packets []*zip.File
+	for _, file := range bundle.File {
+		if !strings.EqualFold(filepath.Ext(file.Name), ".pkt") {
+			continue
+		}
+		if file.UncompressedSize64 > maxPacketSize {
+			return &badPacket{reason: fmt.Sprintf("%s unpacks to more than %d MB", file.Name, maxPacketSize>>20)}
+		}
+		packets = append(packets, file)
+	}
+
+	for _, file := range packets {
+		r, err := file.Open()
+		if err != nil {
+			return &badPacket{reason: err.Error()}
+		}
+		// The size in the zip is the sender's word, so the read is capped too
+		err = n.tossPacket(io.LimitReader(r, maxPacketSize), result)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tossPacket adds a packet's echomail to the linked topics
+func (n *Node) tossPacket(r io.Reader, result *TossResult) error {
+	packet, err := ReadPacket(r)
+	if err != nil {
+		return &badPacket{reason: err.Error()}
+	}
+	if n.cfg.Password != "" && !strings.EqualFold(packet.Password, n.cfg.Password) {
+		return &badPacket{reason: fmt.Sprintf("wrong password from %s", packet.Orig)}
+	}
+	result.Packets++
+
+	for _, m := range packet.Messages {
+		echo := ParseEcho(m)
+		if echo == nil || n.topics[echo.Area] == nil {
+			result.Skipped++
+			continue
+		}
+		if err := n.toss(echo, packet, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toss adds one echo as a post, or as a reply when it answers a message
+// already in the same topic
+func (n *Node) toss(echo *Echo, packet *Packet, result *TossResult) error {
+	topic := n.topics[echo.Area]
+
+	msgid := echo.MsgID
+	if msgid == "" {
+		sum := sha1.Sum([]byte(echo.Area + "\x00" + echo.From + "\x00" + echo.Subject + "\x00" + echo.Date.String() + "\x00" + echo.Body))
+		msgid = fmt.Sprintf("sha1:%x", sum)
+	}
+	existing, err := n.db.GetEchomail(msgid)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		result.Duplicates++
+		return nil
+	}
+
+	origin := echo.Origin
+	if origin == (Address{}) {
+		origin = packet.Orig
+	}
+	// Tossed messages are credited to a disabled account, like imported
+	// archives, with the sender at the top
+	header := fmt.Sprintf("From: %s (%s)\n", echo.From, origin)
+	if echo.To != "" && !strings.EqualFold(echo.To, "All") {
+		header += fmt.Sprintf("To: %s\n", echo.To)
+	}
+	body := header + "\n" + echo.Body
+
+	date := echo.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	parent, err := n.parent(echo, topic)
+	if err != nil {
+		return err
+	}
+	if parent != nil {
+		reply := &database.Reply{PostID: parent.PostID, ParentID: parent.ReplyID, Author: n.cfg.Author, Body: body, CreatedAt: date}
+		if err := n.db.TossReply(reply, msgid); err != nil {
+			return err
+		}
+		result.Replies++
+		return nil
+	}
+
+	post := &database.Post{TopicID: topic.ID, Author: n.cfg.Author, Subject: echo.Subject, Body: body, CreatedAt: date}
+	if post.Subject == "" {
+		post.Subject = "(no subject)"
+	}
+	if err := n.db.TossPost(post, msgid); err != nil {
+		return err
+	}
+	result.Posts++
+	return nil
+}
+
+// parent finds the message an echo answers, if it's in the same topic and
+// hasn't been archived
+func (n *Node) parent(echo *Echo, topic *database.Topic) (*database.ImportedMessage, error) {
+	if echo.Reply == "" {
+		return nil, nil
+	}
+	parent, err := n.db.GetEchomail(echo.Reply)
+	if err != nil || parent == nil {
+		return nil, err
+	}
+	post, err := n.db.GetPost(parent.PostID)
+	if err != nil || post.TopicID != topic.ID || post.Archived {
+		return nil, nil
+	}
+	return parent, nil
+}
+
+// Scan packs the posts and replies written in the linked topics since the
+// last scan into one packet for the uplink, and adds it to the mailer's
+// file list. When an echo is first linked, only what is written after
+// that is sent.
+func (n *Node) Scan() (ScanResult, error) {
+	var result ScanResult
+	if n.cfg.Uplink == "" {
+		return result, fmt.Errorf("ftn.uplink is not set")
+	}
+
+	tags := make([]string, 0, len(n.topics))
+	for tag := range n.topics {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	packet := &Packet{Orig: n.address, Dest: n.uplink, Password: n.cfg.Password, Created: time.Now()}
+	sent := make(map[string]database.Echomail)
+	ids := make(map[[2]int]string) // MSGIDs given out by this scan, by post and reply
+
+	for _, tag := range tags {
+		lastPost, lastReply, err := n.db.LinkEchoArea(tag)
+		if err != nil {
+			return result, err
+		}
+		pending, err := n.db.GetPendingEchomail(n.topics[tag].ID, lastPost, lastReply)
+		if err != nil {
+			return result, err
+		}
+
+		for _, e := range pending {
+			echo := &Echo{
+				Area:    tag,
+				MsgID:   n.msgid(e),
+				From:    e.Author,
+				To:      "All",
+				Subject: e.Subject,
+				Date:    e.CreatedAt.Local(),
+				Body:    e.Body,
+			}
+			if e.ReplyID != 0 {
+				echo.Subject = "Re: " + e.Subject
+				if echo.Reply = ids[[2]int{e.PostID, e.ParentID}]; echo.Reply == "" {
+					if echo.Reply, err = n.db.GetEchomailID(e.PostID, e.ParentID); err != nil {
+						return result, err
+					}
+				}
+			}
+			ids[[2]int{e.PostID, e.ReplyID}] = echo.MsgID
+			sent[echo.MsgID] = e
+			packet.Messages = append(packet.Messages, echo.encode(n.address, n.uplink, n.origin))
+		}
+	}
+
+	if len(packet.Messages) == 0 {
+		return result, nil
+	}
+	path, err := n.queue(packet)
+	if err != nil {
+		return result, err
+	}
+	if err := n.db.RecordEchomailSent(sent); err != nil {
+		return result, err
+	}
+	result.Messages = len(packet.Messages)
+	result.Packet = path
+	return result, nil
+}
+
+// msgid makes a MSGID for a post or reply from its IDs and the time it was
+// written, so it is the same each time it's made but differs between boards
+// that happen to reuse IDs
+func (n *Node) msgid(e database.Echomail) string {
+	serial := crc32.ChecksumIEEE([]byte(fmt.Sprintf("%d.%d.%d", e.PostID, e.ReplyID, e.CreatedAt.UnixNano())))
+	return fmt.Sprintf("%s %08x", n.address, serial)
+}
+
+// queue writes a packet to the outbound directory and lists it in the
+// uplink's flow file in the binkley-style outbound, marked to be deleted
+// once the mailer has sent it
+func (n *Node) queue(packet *Packet) (string, error) {
+	if err := os.MkdirAll(n.outbound, 0755); err != nil {
+		return "", fmt.Errorf("failed to create outbound: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(n.outbound, ".scan-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := packet.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write packet: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write packet: %w", err)
+	}
+
+	// Packet names are eight hex digits; count up past any still waiting
+	serial := uint32(time.Now().Unix())
+	var path string
+	for {
+		path = filepath.Join(n.outbound, fmt.Sprintf("%08x.pkt", serial))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		serial++
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to write packet: %w", err)
+	}
+
+	flow := filepath.Join(n.outbound, fmt.Sprintf("%04x%04x.flo", n.uplink.Net, n.uplink.Node))
+	if n.uplink.Point != 0 {
+		dir := strings.TrimSuffix(flow, ".flo") + ".pnt"
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+		flow = filepath.Join(dir, fmt.Sprintf("%08x.flo", n.uplink.Point))
+	}
+	f, err := os.OpenFile(flow, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to queue packet: %w", err)
+	}
+	defer f.Close()
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := fmt.Fprintf(f, "^%s\n", abs); err != nil {
+		return "", fmt.Errorf("failed to queue packet: %w", err)
+	}
+	return path, nil
+}
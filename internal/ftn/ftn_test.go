@@ -0,0 +1,288 @@
+package ftn
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/paths"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Address
+	}{
+		{"1:234/5", Address{1, 234, 5, 0}},
+		{"2:5020/1042.7", Address{2, 5020, 1042, 7}},
+		{" 21:1/100@fsxnet ", Address{21, 1, 100, 0}},
+	}
+	for _, tt := range tests {
+		got, err := ParseAddress(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAddress(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "234/5", "1:234", "1:x/5", "1:234/5.", "1:70000/1"} {
+		if _, err := ParseAddress(bad); err == nil {
+			t.Errorf("ParseAddress(%q) succeeded", bad)
+		}
+	}
+	if s := (Address{2, 5020, 1042, 7}).String(); s != "2:5020/1042.7" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	p := &Packet{
+		Orig:     Address{1, 234, 5, 0},
+		Dest:     Address{1, 234, 1, 0},
+		Password: "secret",
+		Created:  time.Date(2024, 2, 29, 23, 59, 58, 0, time.Local),
+		Messages: []Message{{
+			OrigNode: 5, OrigNet: 234, DestNode: 1, DestNet: 234,
+			Date: "29 Feb 24  23:59:58", To: "All", From: "Alice", Subject: "Hi",
+			Text: "AREA:TEST\rHello\r",
+		}},
+	}
+	var buf bytes.Buffer
+	if _, err := p.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	got, err := ReadPacket(&buf)
+	if err != nil {
+		t.Fatalf("ReadPacket failed: %v", err)
+	}
+	if got.Orig != p.Orig || got.Dest != p.Dest || got.Password != p.Password || !got.Created.Equal(p.Created) {
+		t.Errorf("header = %+v, want %+v", got, p)
+	}
+	if len(got.Messages) != 1 || got.Messages[0] != p.Messages[0] {
+		t.Errorf("messages = %+v, want %+v", got.Messages, p.Messages)
+	}
+}
+
+func TestParseEcho(t *testing.T) {
+	m := Message{
+		Date: "02 Jan 06  15:04:05", From: "J\x84rg", To: "All", Subject: "Gr\x81\xe1e",
+		Text: "AREA:fido_sysops\r\x01MSGID: 2:240/1 1234abcd\r\x01REPLY: 1:234/5 00000001\r" +
+			"Hallo\r\r--- Tosser\r * Origin: Somewhere (2:240/1.0)\rSEEN-BY: 240/1\r\x01PATH: 240/1\r",
+	}
+	e := ParseEcho(m)
+	if e == nil {
+		t.Fatal("ParseEcho returned nil for echomail")
+	}
+	if e.Area != "FIDO_SYSOPS" || e.MsgID != "2:240/1 1234abcd" || e.Reply != "1:234/5 00000001" {
+		t.Errorf("control lines = %q %q %q", e.Area, e.MsgID, e.Reply)
+	}
+	if e.From != "Järg" || e.Subject != "Grüße" {
+		t.Errorf("CP437 decoded to %q, %q", e.From, e.Subject)
+	}
+	if e.Body != "Hallo\n\n--- Tosser\n * Origin: Somewhere (2:240/1.0)" {
+		t.Errorf("Body = %q", e.Body)
+	}
+	if e.Origin != (Address{2, 240, 1, 0}) || e.Date.Year() != 2006 {
+		t.Errorf("Origin, Date = %v, %v", e.Origin, e.Date)
+	}
+
+	if ParseEcho(Message{Text: "Just netmail\r"}) != nil {
+		t.Error("ParseEcho returned netmail as echomail")
+	}
+}
+
+// newTestNode sets up a board at 1:234/5 with TECH linked to a topic
+func newTestNode(t *testing.T) (*Node, *database.DB, *database.Topic, string) {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := database.Initialize(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	topic := &database.Topic{Name: "Tech Talk"}
+	if err := db.CreateTopic(topic); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+
+	cfg := &config.Config{Paths: paths.Layout{Root: dir}}
+	cfg.BBS.SystemName = "Test BBS"
+	cfg.BBS.FTN = config.FTNConfig{
+		Address: "1:234/5", Uplink: "1:234/1", Password: "secret",
+		Inbound: "in", Outbound: "out", Author: "echomail",
+		Areas: []config.FTNArea{{Tag: "tech", Topic: "tech talk"}},
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "in"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	node, err := NewNode(db, cfg)
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	return node, db, topic, dir
+}
+
+func writePacket(t *testing.T, path, password string, texts ...string) {
+	t.Helper()
+	p := &Packet{Orig: Address{1, 234, 1, 0}, Dest: Address{1, 234, 5, 0}, Password: password, Created: time.Now()}
+	for _, text := range texts {
+		p.Messages = append(p.Messages, Message{
+			OrigNet: 234, OrigNode: 1, DestNet: 234, DestNode: 5,
+			Date: "02 Jan 06  15:04:05", To: "All", From: "Bob", Subject: "Modems", Text: text,
+		})
+	}
+	var buf bytes.Buffer
+	p.WriteTo(&buf)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTossAndScan(t *testing.T) {
+	node, db, topic, dir := newTestNode(t)
+
+	// Linking the echo on the first scan doesn't send what was already there
+	old := &database.Post{TopicID: topic.ID, Author: "alice", Subject: "Old", Body: "Before linking"}
+	if err := db.CreatePost(old); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := node.Scan(); err != nil || result.Messages != 0 {
+		t.Fatalf("first Scan = %+v, %v; want nothing sent", result, err)
+	}
+
+	writePacket(t, filepath.Join(dir, "in", "00000001.pkt"), "SECRET",
+		"AREA:TECH\r\x01MSGID: 1:234/1 00000001\rWhich modem?\r * Origin: Hub (1:234/1)\r",
+		"AREA:TECH\r\x01MSGID: 1:234/1 00000002\r\x01REPLY: 1:234/1 00000001\rA USR\r",
+		"AREA:OTHER\r\x01MSGID: 1:234/1 00000003\rNot linked\r",
+		"Netmail\r")
+	writePacket(t, filepath.Join(dir, "in", "00000002.pkt"), "wrong", "AREA:TECH\rIgnored\r")
+
+	result, err := node.Toss()
+	if err != nil {
+		t.Fatalf("Toss failed: %v", err)
+	}
+	if result.Packets != 1 || result.Posts != 1 || result.Replies != 1 || result.Skipped != 2 || len(result.Bad) != 1 {
+		t.Errorf("Toss = %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "in", "00000002.pkt.bad")); err != nil {
+		t.Errorf("packet with the wrong password wasn't set aside: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "in", "00000001.pkt")); !os.IsNotExist(err) {
+		t.Errorf("tossed packet wasn't removed: %v", err)
+	}
+
+	posts, _ := db.GetPosts(topic.ID, 10)
+	var tossed *database.Post
+	for i := range posts {
+		if posts[i].Subject == "Modems" {
+			tossed = &posts[i]
+		}
+	}
+	if tossed == nil || tossed.Author != "echomail" || !strings.HasPrefix(tossed.Body, "From: Bob (1:234/1)\n\nWhich modem?") {
+		t.Fatalf("tossed post = %+v", tossed)
+	}
+	replies, _ := db.GetReplies(tossed.ID)
+	if len(replies) != 1 || !strings.HasSuffix(replies[0].Body, "A USR") {
+		t.Fatalf("tossed replies = %+v", replies)
+	}
+
+	// The same packet again is all duplicates
+	writePacket(t, filepath.Join(dir, "in", "00000003.pkt"), "secret",
+		"AREA:TECH\r\x01MSGID: 1:234/1 00000001\rWhich modem?\r")
+	if result, err := node.Toss(); err != nil || result.Duplicates != 1 || result.Posts != 0 {
+		t.Errorf("second Toss = %+v, %v; want one duplicate", result, err)
+	}
+
+	// A local answer goes out threaded under the tossed post; tossed mail doesn't go back
+	answer := &database.Reply{PostID: tossed.ID, Author: "alice", Body: "--- not a tear line\nA Hayes"}
+	if err := db.CreateReply(answer); err != nil {
+		t.Fatal(err)
+	}
+	scan, err := node.Scan()
+	if err != nil || scan.Messages != 1 {
+		t.Fatalf("Scan = %+v, %v; want one message", scan, err)
+	}
+	f, err := os.Open(scan.Packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	packet, err := ReadPacket(f)
+	if err != nil {
+		t.Fatalf("reading scanned packet: %v", err)
+	}
+	if packet.Dest != (Address{1, 234, 1, 0}) || packet.Password != "secret" || len(packet.Messages) != 1 {
+		t.Fatalf("scanned packet = %+v", packet)
+	}
+	m := packet.Messages[0]
+	e := ParseEcho(m)
+	if e == nil || e.Area != "TECH" || e.Reply != "1:234/1 00000001" || m.From != "alice" || m.Subject != "Re: Modems" {
+		t.Errorf("scanned message = %+v, %+v", m, e)
+	}
+	if !strings.Contains(m.Text, "\r-+- not a tear line\r") || !strings.Contains(m.Text, " * Origin: Test BBS (1:234/5)\r") {
+		t.Errorf("scanned text = %q", m.Text)
+	}
+
+	flow, err := os.ReadFile(filepath.Join(dir, "out", "00ea0001.flo"))
+	if err != nil || !strings.Contains(string(flow), "^") || !strings.Contains(string(flow), filepath.Base(scan.Packet)) {
+		t.Errorf("flow file = %q, %v", flow, err)
+	}
+
+	// Sent once, and its own MSGID coming back is a duplicate
+	if again, err := node.Scan(); err != nil || again.Messages != 0 {
+		t.Errorf("second Scan = %+v, %v; want nothing sent", again, err)
+	}
+	if found, _ := db.GetEchomail(e.MsgID); found == nil || found.ReplyID != answer.ID {
+		t.Errorf("GetEchomail(%q) = %+v, want reply %d", e.MsgID, found, answer.ID)
+	}
+}
+
+func TestTossBundleTooLarge(t *testing.T) {
+	node, db, topic, dir := newTestNode(t)
+
+	writePacket(t, filepath.Join(dir, "small.pkt"), "secret", "AREA:TECH\r\x01MSGID: 1:234/1 00000001\rHello\r")
+	small, err := os.ReadFile(filepath.Join(dir, "small.pkt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "in", "0000fff1.mo0")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := zip.NewWriter(f)
+	for name, data := range map[string][]byte{
+		"00000001.pkt": small,
+		"00000002.pkt": make([]byte, maxPacketSize+1),
+	} {
+		w, err := bundle.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := bundle.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	result, err := node.Toss()
+	if err != nil {
+		t.Fatalf("Toss failed: %v", err)
+	}
+	if len(result.Bad) != 1 || !strings.Contains(result.Bad[0], "00000002.pkt unpacks to more than 16 MB") {
+		t.Errorf("Toss = %+v; want the bundle set aside", result)
+	}
+	if _, err := os.Stat(path + ".bad"); err != nil {
+		t.Errorf("oversized bundle wasn't set aside: %v", err)
+	}
+	if posts, _ := db.GetPosts(topic.ID, 10); len(posts) != 0 {
+		t.Errorf("a bundle that was set aside tossed %d posts", len(posts))
+	}
+}
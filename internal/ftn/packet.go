@@ -0,0 +1,179 @@
+package ftn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// capability word of a Type 2+ packet. The header carries it twice, once
+// byte-swapped, so older software can tell a 2+ packet from a plain one.
+const capWord = 0x0001
+
+// productCode identifies the software that wrote a packet. 0xFE is the
+// code FTSC sets aside for software without one of its own.
+const productCode = 0xFE
+
+// dateLayout is how a packed message's date is written
+const dateLayout = "02 Jan 06  15:04:05"
+
+// Packet is a bundle of messages passed between two nodes
+type Packet struct {
+	Orig     Address
+	Dest     Address
+	Password string
+	Created  time.Time
+	Messages []Message
+}
+
+// Message is one packed message. Echomail carries its area, kludges and
+// routing lines in Text; see ParseEcho.
+type Message struct {
+	OrigNode, OrigNet int
+	DestNode, DestNet int
+	Attr              uint16
+	Date              string // As written, such as "02 Jan 06  15:04:05"
+	To, From          string
+	Subject           string
+	Text              string // Lines end in CR
+}
+
+// packetHeader is the 58-byte Type 2+ header (FSC-0048) as laid out on
+// disk, little-endian
+type packetHeader struct {
+	OrigNode, DestNode                 uint16
+	Year, Month, Day                   uint16
+	Hour, Minute, Second               uint16
+	Baud, PacketType                   uint16
+	OrigNet, DestNet                   uint16
+	ProductLo, RevisionMajor           uint8
+	Password                           [8]byte
+	QOrigZone, QDestZone               uint16
+	AuxNet, CapValid                   uint16
+	ProductHi, RevisionMinor           uint8
+	CapWord                            uint16
+	OrigZone, DestZone, OrigPt, DestPt uint16
+	ProductData                        [4]byte
+}
+
+// messageHeader starts each packed message
+type messageHeader struct {
+	Type               uint16
+	OrigNode, DestNode uint16
+	OrigNet, DestNet   uint16
+	Attr, Cost         uint16
+}
+
+// ReadPacket reads a Type 2 or 2+ packet
+func ReadPacket(r io.Reader) (*Packet, error) {
+	br := bufio.NewReader(r)
+
+	var h packetHeader
+	if err := binary.Read(br, binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("reading packet header: %w", err)
+	}
+	if h.PacketType != 2 {
+		return nil, fmt.Errorf("unsupported packet type %d", h.PacketType)
+	}
+
+	p := &Packet{
+		Orig:     Address{Zone: int(h.OrigZone), Net: int(h.OrigNet), Node: int(h.OrigNode)},
+		Dest:     Address{Zone: int(h.DestZone), Net: int(h.DestNet), Node: int(h.DestNode)},
+		Password: strings.TrimRight(string(h.Password[:]), "\x00"),
+		Created:  time.Date(int(h.Year), time.Month(h.Month+1), int(h.Day), int(h.Hour), int(h.Minute), int(h.Second), 0, time.Local),
+	}
+	// Zones and points are only in the 2+ part of the header
+	if h.CapValid == capWord<<8 && h.CapWord&capWord != 0 {
+		p.Orig.Point, p.Dest.Point = int(h.OrigPt), int(h.DestPt)
+		// A point's packet gives its boss's net as -1 and the real one in AuxNet
+		if h.OrigNet == 0xFFFF && h.OrigPt != 0 {
+			p.Orig.Net = int(h.AuxNet)
+		}
+	} else {
+		p.Orig.Zone, p.Dest.Zone = int(h.QOrigZone), int(h.QDestZone)
+	}
+
+	for {
+		var msgType uint16
+		if err := binary.Read(br, binary.LittleEndian, &msgType); err != nil {
+			// Some software leaves off the closing zero
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return p, nil
+			}
+			return nil, err
+		}
+		if msgType == 0 {
+			return p, nil
+		}
+		if msgType != 2 {
+			return nil, fmt.Errorf("message %d: unsupported message type %d", len(p.Messages)+1, msgType)
+		}
+		// Nodes and nets, then attributes; the cost that follows is unused
+		fields := make([]uint16, 6)
+		if err := binary.Read(br, binary.LittleEndian, fields); err != nil {
+			return nil, fmt.Errorf("message %d: %w", len(p.Messages)+1, err)
+		}
+
+		m := Message{
+			OrigNode: int(fields[0]), DestNode: int(fields[1]),
+			OrigNet: int(fields[2]), DestNet: int(fields[3]),
+			Attr: fields[4],
+		}
+		strs := make([]string, 5)
+		for i := range strs {
+			s, err := br.ReadString(0)
+			if err != nil {
+				return nil, fmt.Errorf("message %d: truncated", len(p.Messages)+1)
+			}
+			strs[i] = strings.TrimSuffix(s, "\x00")
+		}
+		m.Date, m.To, m.From, m.Subject, m.Text = strs[0], strs[1], strs[2], strs[3], strs[4]
+		p.Messages = append(p.Messages, m)
+	}
+}
+
+// WriteTo writes the packet as Type 2+
+func (p *Packet) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	created := p.Created
+	h := packetHeader{
+		OrigNode: uint16(p.Orig.Node), DestNode: uint16(p.Dest.Node),
+		Year: uint16(created.Year()), Month: uint16(created.Month() - 1), Day: uint16(created.Day()),
+		Hour: uint16(created.Hour()), Minute: uint16(created.Minute()), Second: uint16(created.Second()),
+		PacketType: 2,
+		OrigNet:    uint16(p.Orig.Net), DestNet: uint16(p.Dest.Net),
+		ProductLo: productCode,
+		QOrigZone: uint16(p.Orig.Zone), QDestZone: uint16(p.Dest.Zone),
+		CapValid: capWord << 8, CapWord: capWord,
+		OrigZone: uint16(p.Orig.Zone), DestZone: uint16(p.Dest.Zone),
+		OrigPt: uint16(p.Orig.Point), DestPt: uint16(p.Dest.Point),
+	}
+	if p.Orig.Point != 0 {
+		h.AuxNet, h.OrigNet = h.OrigNet, 0xFFFF
+	}
+	copy(h.Password[:], p.Password)
+	binary.Write(&buf, binary.LittleEndian, &h)
+
+	for _, m := range p.Messages {
+		binary.Write(&buf, binary.LittleEndian, &messageHeader{
+			Type:     2,
+			OrigNode: uint16(m.OrigNode), DestNode: uint16(m.DestNode),
+			OrigNet: uint16(m.OrigNet), DestNet: uint16(m.DestNet),
+			Attr: m.Attr,
+		})
+		for _, s := range []string{m.Date, m.To, m.From, m.Subject, m.Text} {
+			buf.WriteString(strings.ReplaceAll(s, "\x00", ""))
+			buf.WriteByte(0)
+		}
+	}
+	buf.Write([]byte{0, 0})
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
@@ -0,0 +1,97 @@
+package gopher
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"bbs/internal/ansi"
+)
+
+// menu writes a Gopher menu, keeping the first write error
+type menu struct {
+	w    io.Writer
+	host string
+	port int
+	err  error
+}
+
+// item writes one menu line
+func (m *menu) item(kind byte, display, selector, host string, port int) {
+	if m.err != nil {
+		return
+	}
+	_, m.err = fmt.Fprintf(m.w, "%c%s\t%s\t%s\t%d\r\n", kind, clean(ansi.Strip(display)), clean(selector), host, port)
+}
+
+// link writes a line pointing at another selector on this server
+func (m *menu) link(kind byte, display, selector string) {
+	m.item(kind, display, selector, m.host, m.port)
+}
+
+// info writes a line of text that leads nowhere
+func (m *menu) info(display string) {
+	m.item(typeInfo, display, "", "error.host", 1)
+}
+
+// end finishes the menu
+func (m *menu) end() error {
+	if m.err == nil {
+		_, m.err = io.WriteString(m.w, ".\r\n")
+	}
+	return m.err
+}
+
+// text writes a Gopher text document, keeping the first write error
+type text struct {
+	w   io.Writer
+	err error
+}
+
+// line writes one line as plain text. A leading period is doubled, as RFC
+// 1436 asks, so a line of just a period doesn't end the document early.
+func (t *text) line(s string) {
+	if t.err != nil {
+		return
+	}
+	s = strings.TrimRight(s, "\r")
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' && r != '\t' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, ansi.Strip(s))
+	if strings.HasPrefix(s, ".") {
+		s = "." + s
+	}
+	_, t.err = io.WriteString(t.w, s+"\r\n")
+}
+
+// body writes text as it was written, each line after indent
+func (t *text) body(s, indent string) {
+	for _, line := range strings.Split(strings.TrimRight(s, "\r\n "), "\n") {
+		t.line(indent + strings.TrimRight(line, "\r"))
+	}
+}
+
+// end finishes the document
+func (t *text) end() error {
+	if t.err == nil {
+		_, t.err = io.WriteString(t.w, ".\r\n")
+	}
+	return t.err
+}
+
+// clean keeps tabs and line breaks, which separate a menu's fields and
+// lines, out of what is written in them
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case r < ' ' || r == 0x7f:
+			return -1
+		}
+		return r
+	}, s)
+}
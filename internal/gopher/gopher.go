@@ -0,0 +1,361 @@
+// Package gopher serves the board's public content to Gopher clients:
+// the current bulletins, chosen topics open to every caller, and the
+// boards this server hosts. Nothing can be posted or changed over it.
+package gopher
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+// maxPosts is how many of a topic's newest posts are listed
+const maxPosts = 100
+
+// maxSelector is the longest selector read from a client. RFC 1436 keeps
+// them to 255 characters.
+const maxSelector = 255
+
+// maxConns is how many clients are answered at once; others are dropped
+const maxConns = 32
+
+// Timeouts for reading the selector and writing the answer
+const (
+	readTimeout  = 10 * time.Second
+	writeTimeout = 30 * time.Second
+)
+
+// Item types used in menus
+const (
+	typeText  = '0'
+	typeMenu  = '1'
+	typeError = '3'
+	typeInfo  = 'i'
+)
+
+// errNotFound answers selectors for anything that isn't served
+var errNotFound = errors.New("not found")
+
+// Server answers Gopher requests for one board
+type Server struct {
+	db      *database.DB
+	cfg     config.GopherConfig
+	system  string
+	sysop   string
+	sshPort int
+	boards  []config.BoardConfig
+	host    string // Host and port menus send clients back to
+	port    int
+	slots   chan struct{}
+}
+
+// NewServer creates a Gopher server for the board cfg describes
+func NewServer(cfg *config.Config, db *database.DB) *Server {
+	host, portText, _ := net.SplitHostPort(cfg.Server.Gopher.Listen)
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		port = 70
+	}
+	if cfg.Server.Gopher.Hostname != "" {
+		host = cfg.Server.Gopher.Hostname
+	}
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		host = "localhost"
+	}
+
+	return &Server{
+		db:      db,
+		cfg:     cfg.Server.Gopher,
+		system:  cfg.BBS.SystemName,
+		sysop:   cfg.BBS.SysopName,
+		sshPort: cfg.Server.Port,
+		boards:  cfg.Server.Boards,
+		host:    host,
+		port:    port,
+		slots:   make(chan struct{}, maxConns),
+	}
+}
+
+// ListenAndServe serves Gopher on the configured address
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.cfg.Listen)
+	if err != nil {
+		return err
+	}
+	log.Printf("Gopher server listening on %s", s.cfg.Listen)
+	return s.Serve(listener)
+}
+
+// Serve answers the clients that connect to listener until it is closed
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		select {
+		case s.slots <- struct{}{}:
+			go func() {
+				defer func() { <-s.slots }()
+				s.handle(conn)
+			}()
+		default:
+			conn.Close()
+		}
+	}
+}
+
+// handle reads one selector and answers it
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	line, err := bufio.NewReader(io.LimitReader(conn, maxSelector+2)).ReadString('\n')
+	if err != nil {
+		return
+	}
+	// Gopher+ clients may send a tab and more after the selector
+	selector, _, _ := strings.Cut(strings.TrimRight(line, "\r\n"), "\t")
+
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	w := bufio.NewWriter(conn)
+	if err := s.Respond(w, selector); err != nil && !errors.Is(err, errNotFound) {
+		log.Printf("gopher: %s asked for %q: %v", conn.RemoteAddr(), selector, err)
+	}
+	w.Flush()
+}
+
+// Respond writes the answer to selector. A selector for something that
+// isn't served gets an error menu.
+func (s *Server) Respond(w io.Writer, selector string) error {
+	var err error
+	switch {
+	case selector == "" || selector == "/":
+		err = s.root(w)
+	case selector == "/bulletins" && s.cfg.Bulletins:
+		err = s.bulletins(w)
+	case selector == "/topics":
+		err = s.topicList(w)
+	case selector == "/systems":
+		err = s.systems(w)
+	default:
+		kind, id, ok := parseSelector(selector)
+		switch {
+		case !ok:
+			err = errNotFound
+		case kind == "bulletins" && s.cfg.Bulletins:
+			err = s.bulletin(w, id)
+		case kind == "topics":
+			err = s.topic(w, id)
+		case kind == "posts":
+			err = s.post(w, id)
+		default:
+			err = errNotFound
+		}
+	}
+
+	if err != nil {
+		m := &menu{w: w, host: s.host, port: s.port}
+		if errors.Is(err, errNotFound) {
+			m.item(typeError, "Not found: "+selector, "", "error.host", 1)
+		} else {
+			m.item(typeError, "The board couldn't answer that right now", "", "error.host", 1)
+		}
+		m.end()
+	}
+	return err
+}
+
+// parseSelector splits selectors such as "/posts/12" into their kind and ID
+func parseSelector(selector string) (string, int, bool) {
+	kind, idText, ok := strings.Cut(strings.TrimPrefix(selector, "/"), "/")
+	if !ok {
+		return "", 0, false
+	}
+	id, err := strconv.Atoi(idText)
+	if err != nil || id <= 0 {
+		return "", 0, false
+	}
+	return kind, id, true
+}
+
+// root is the board's main menu
+func (s *Server) root(w io.Writer) error {
+	m := &menu{w: w, host: s.host, port: s.port}
+	m.info(s.system)
+	m.info(strings.Repeat("=", len([]rune(s.system))))
+	if s.sysop != "" {
+		m.info("Sysop: " + s.sysop)
+	}
+	m.info("")
+	if s.cfg.Bulletins {
+		m.link(typeMenu, "System Bulletins", "/bulletins")
+	}
+	m.link(typeMenu, "Message Boards", "/topics")
+	m.link(typeText, "Boards on This System", "/systems")
+	m.info("")
+	m.info(fmt.Sprintf("This is a read-only view. Call in over SSH on port %d to join in.", s.sshPort))
+	return m.end()
+}
+
+// bulletins lists the current bulletins, newest first
+func (s *Server) bulletins(w io.Writer) error {
+	bulletins, err := s.db.GetBulletins(-1)
+	if err != nil {
+		return fmt.Errorf("failed to load bulletins: %w", err)
+	}
+
+	m := &menu{w: w, host: s.host, port: s.port}
+	m.info("System Bulletins")
+	m.info("")
+	if len(bulletins) == 0 {
+		m.info("There are no bulletins.")
+	}
+	for _, b := range bulletins {
+		m.link(typeText, fmt.Sprintf("%s  %s", b.CreatedAt.Local().Format("2006-01-02"), b.Title), fmt.Sprintf("/bulletins/%d", b.ID))
+	}
+	return m.end()
+}
+
+// bulletin shows one bulletin, if callers can see it now
+func (s *Server) bulletin(w io.Writer, id int) error {
+	b, err := s.db.GetBulletinByID(id)
+	if err != nil || !b.Current(time.Now()) {
+		return errNotFound
+	}
+
+	t := &text{w: w}
+	t.line(b.Title)
+	t.line(fmt.Sprintf("By %s, %s", b.Author, b.CreatedAt.Local().Format("Jan 02, 2006 15:04")))
+	t.line("")
+	t.body(b.Body, "")
+	return t.end()
+}
+
+// servedTopics returns the configured topics that are open to everyone, in
+// the order the board lists them
+func (s *Server) servedTopics() ([]database.Topic, error) {
+	topics, _, err := s.db.GetPublicTopics(s.cfg.Topics)
+	return topics, err
+}
+
+// servedTopic returns the topic with id if it is served
+func (s *Server) servedTopic(id int) (*database.Topic, error) {
+	topics, err := s.servedTopics()
+	if err != nil {
+		return nil, err
+	}
+	for i := range topics {
+		if topics[i].ID == id {
+			return &topics[i], nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// topicList lists the served topics
+func (s *Server) topicList(w io.Writer) error {
+	topics, err := s.servedTopics()
+	if err != nil {
+		return err
+	}
+
+	m := &menu{w: w, host: s.host, port: s.port}
+	m.info("Message Boards")
+	m.info("")
+	if len(topics) == 0 {
+		m.info("No topics are open to Gopher visitors.")
+	}
+	for _, topic := range topics {
+		m.link(typeMenu, fmt.Sprintf("%s (%d posts)", topic.Name, topic.PostCount), fmt.Sprintf("/topics/%d", topic.ID))
+		if topic.Description != "" {
+			m.info("    " + topic.Description)
+		}
+	}
+	return m.end()
+}
+
+// topic lists a served topic's newest posts
+func (s *Server) topic(w io.Writer, id int) error {
+	topic, err := s.servedTopic(id)
+	if err != nil {
+		return err
+	}
+	posts, err := s.db.GetPosts(topic.ID, maxPosts)
+	if err != nil {
+		return fmt.Errorf("failed to load posts in %s: %w", topic.Name, err)
+	}
+
+	m := &menu{w: w, host: s.host, port: s.port}
+	m.info(topic.Name)
+	if topic.Description != "" {
+		m.info(topic.Description)
+	}
+	m.info("")
+	if len(posts) == 0 {
+		m.info("Nothing has been posted here yet.")
+	}
+	for _, post := range posts {
+		display := fmt.Sprintf("%s  %s - %s", post.CreatedAt.Local().Format("2006-01-02"), post.Subject, post.Author)
+		if post.ReplyCount > 0 {
+			display += fmt.Sprintf(" (%d replies)", post.ReplyCount)
+		}
+		m.link(typeText, display, fmt.Sprintf("/posts/%d", post.ID))
+	}
+	return m.end()
+}
+
+// post shows a post in a served topic and its replies, threaded
+func (s *Server) post(w io.Writer, id int) error {
+	post, err := s.db.GetPost(id)
+	if err != nil {
+		return errNotFound
+	}
+	topic, err := s.servedTopic(post.TopicID)
+	if err != nil {
+		return err
+	}
+	replies, err := s.db.GetReplies(post.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load replies to post %d: %w", post.ID, err)
+	}
+
+	t := &text{w: w}
+	t.line(post.Subject)
+	t.line(fmt.Sprintf("In %s, by %s, %s", topic.Name, post.Author, post.CreatedAt.Local().Format("Jan 02, 2006 15:04")))
+	t.line("")
+	t.body(post.Body, "")
+	for _, reply := range database.Thread(replies) {
+		// Replies are indented under the reply they answer
+		indent := strings.Repeat(" ", 4*(reply.Depth+1))
+		t.line("")
+		t.line(indent + fmt.Sprintf("%s replied, %s:", reply.Author, reply.CreatedAt.Local().Format("Jan 02, 2006 15:04")))
+		t.body(reply.Body, indent)
+	}
+	return t.end()
+}
+
+// systems lists the boards this server hosts and how to call each one
+func (s *Server) systems(w io.Writer) error {
+	t := &text{w: w}
+	t.line("Boards on This System")
+	t.line("")
+	t.line(fmt.Sprintf("%s: SSH to port %d.", s.system, s.sshPort))
+	for _, board := range s.boards {
+		how := fmt.Sprintf("log in as %s/yourname", board.Name)
+		if board.Port != 0 {
+			how = fmt.Sprintf("SSH to port %d, or %s", board.Port, how)
+		}
+		t.line(fmt.Sprintf("%s: %s.", board.Name, how))
+	}
+	return t.end()
+}
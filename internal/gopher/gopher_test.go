@@ -0,0 +1,173 @@
+package gopher
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+func newTestServer(t *testing.T) (*Server, *database.DB) {
+	t.Helper()
+	db, err := database.Initialize(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := &config.Config{}
+	cfg.BBS.SystemName = "Test BBS"
+	cfg.BBS.SysopName = "Sysop"
+	cfg.Server.Port = 2323
+	cfg.Server.Boards = []config.BoardConfig{{Name: "retro", Port: 2424}, {Name: "quiet"}}
+	cfg.Server.Gopher = config.GopherConfig{
+		Listen:    "0.0.0.0:7070",
+		Hostname:  "bbs.example.org",
+		Topics:    []string{"general", "Sysops"},
+		Bulletins: true,
+	}
+	return NewServer(cfg, db), db
+}
+
+func respond(t *testing.T, s *Server, selector string) string {
+	t.Helper()
+	var out strings.Builder
+	s.Respond(&out, selector)
+	if !strings.HasSuffix(out.String(), "\r\n.\r\n") {
+		t.Errorf("answer to %q doesn't end with a period line: %q", selector, out.String())
+	}
+	return out.String()
+}
+
+func TestRespond(t *testing.T) {
+	s, db := newTestServer(t)
+
+	general := &database.Topic{Name: "General", Description: "Anything goes"}
+	sysops := &database.Topic{Name: "Sysops", AccessLevel: 255}
+	tech := &database.Topic{Name: "Tech"}
+	for _, topic := range []*database.Topic{general, sysops, tech} {
+		if err := db.CreateTopic(topic); err != nil {
+			t.Fatalf("CreateTopic failed: %v", err)
+		}
+	}
+	post := &database.Post{TopicID: general.ID, Author: "alice", Subject: "Hello\tthere", Body: "\x1b[31mFirst\x1b[0m post\n.\nbye"}
+	if err := db.CreatePost(post); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	if err := db.CreateReply(&database.Reply{PostID: post.ID, Author: "bob", Body: "Welcome!"}); err != nil {
+		t.Fatalf("CreateReply failed: %v", err)
+	}
+	secret := &database.Post{TopicID: sysops.ID, Author: "sysop", Subject: "Private", Body: "Not for visitors"}
+	other := &database.Post{TopicID: tech.ID, Author: "carol", Subject: "Unlisted", Body: "Not served"}
+	for _, p := range []*database.Post{secret, other} {
+		if err := db.CreatePost(p); err != nil {
+			t.Fatalf("CreatePost failed: %v", err)
+		}
+	}
+	news := &database.Bulletin{Title: "News", Body: "Open house", Author: "sysop"}
+	if err := db.CreateBulletin(news); err != nil {
+		t.Fatalf("CreateBulletin failed: %v", err)
+	}
+	later := time.Now().Add(time.Hour)
+	pending := &database.Bulletin{Title: "Soon", Body: "Not yet", Author: "sysop", PublishAt: &later}
+	if err := db.CreateBulletin(pending); err != nil {
+		t.Fatalf("CreateBulletin failed: %v", err)
+	}
+
+	root := respond(t, s, "")
+	for _, want := range []string{
+		"iTest BBS\t\terror.host\t1\r\n",
+		"1System Bulletins\t/bulletins\tbbs.example.org\t7070\r\n",
+		"1Message Boards\t/topics\tbbs.example.org\t7070\r\n",
+		"0Boards on This System\t/systems\tbbs.example.org\t7070\r\n",
+	} {
+		if !strings.Contains(root, want) {
+			t.Errorf("root menu missing %q:\n%s", want, root)
+		}
+	}
+
+	topics := respond(t, s, "/topics")
+	if !strings.Contains(topics, fmt.Sprintf("1General (1 posts)\t/topics/%d\t", general.ID)) {
+		t.Errorf("topic list missing General:\n%s", topics)
+	}
+	if strings.Contains(topics, "Sysops") || strings.Contains(topics, "Tech") {
+		t.Errorf("topic list shows a private or unlisted topic:\n%s", topics)
+	}
+
+	posts := respond(t, s, fmt.Sprintf("/topics/%d", general.ID))
+	if !strings.Contains(posts, fmt.Sprintf("Hello there - alice (1 replies)\t/posts/%d\t", post.ID)) {
+		t.Errorf("post list = %q", posts)
+	}
+
+	thread := respond(t, s, fmt.Sprintf("/posts/%d", post.ID))
+	if !strings.Contains(thread, "First post\r\n..\r\nbye\r\n") || !strings.Contains(thread, "    Welcome!\r\n") {
+		t.Errorf("post = %q", thread)
+	}
+
+	bulletins := respond(t, s, "/bulletins")
+	if !strings.Contains(bulletins, fmt.Sprintf("News\t/bulletins/%d\t", news.ID)) || strings.Contains(bulletins, "Soon") {
+		t.Errorf("bulletin list = %q", bulletins)
+	}
+	if b := respond(t, s, fmt.Sprintf("/bulletins/%d", news.ID)); !strings.Contains(b, "Open house\r\n") {
+		t.Errorf("bulletin = %q", b)
+	}
+
+	systems := respond(t, s, "/systems")
+	for _, want := range []string{"Test BBS: SSH to port 2323.", "retro: SSH to port 2424, or log in as retro/yourname.", "quiet: log in as quiet/yourname."} {
+		if !strings.Contains(systems, want) {
+			t.Errorf("systems missing %q:\n%s", want, systems)
+		}
+	}
+
+	for _, selector := range []string{
+		fmt.Sprintf("/posts/%d", secret.ID),
+		fmt.Sprintf("/posts/%d", other.ID),
+		fmt.Sprintf("/topics/%d", sysops.ID),
+		fmt.Sprintf("/bulletins/%d", pending.ID),
+		"/posts/999", "/posts/x", "/users", "../etc/passwd",
+	} {
+		if got := respond(t, s, selector); !strings.HasPrefix(got, "3Not found") {
+			t.Errorf("%q = %q, want not found", selector, got)
+		}
+	}
+
+	s.cfg.Bulletins = false
+	if got := respond(t, s, "/bulletins"); !strings.HasPrefix(got, "3") {
+		t.Errorf("bulletins served while turned off: %q", got)
+	}
+	if strings.Contains(respond(t, s, "/"), "/bulletins") {
+		t.Error("root menu links to bulletins while they are turned off")
+	}
+}
+
+func TestServe(t *testing.T) {
+	s, _ := newTestServer(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go s.Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// Gopher+ clients send more after a tab
+	fmt.Fprintf(conn, "/systems\t+\r\n")
+	answer, err := io.ReadAll(bufio.NewReader(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(answer), "Boards on This System\r\n") {
+		t.Errorf("answer = %q", answer)
+	}
+}
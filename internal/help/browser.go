@@ -0,0 +1,161 @@
+package help
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/pager"
+)
+
+// Browser is the Help menu: it lists every topic, searches them, and shows
+// them in the pager
+type Browser struct {
+	registry    *Registry
+	colorScheme menu.ColorScheme
+	tutorial    func() bool // Runs the keyboard tour, if the board has one
+}
+
+// NewBrowser creates the help browser
+func NewBrowser(registry *Registry, colorScheme menu.ColorScheme) *Browser {
+	return &Browser{
+		registry:    registry,
+		colorScheme: colorScheme,
+	}
+}
+
+// WithTutorial offers the keyboard tour from the Help menu
+func (b *Browser) WithTutorial(run func() bool) *Browser {
+	b.tutorial = run
+	return b
+}
+
+// Execute lists the topics until the caller quits
+func (b *Browser) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	query := ""
+	for {
+		topics := b.registry.Search(query)
+
+		writer.Write([]byte(menu.ClearScreen))
+		title := modules.T(writer, "--- Help ---")
+		if query != "" {
+			title = fmt.Sprintf(modules.T(writer, "--- Help: %q ---"), query)
+		}
+		writer.Write([]byte(b.colorScheme.CenterText(b.colorScheme.Colorize(title, "primary"), modules.Width(writer)) + "\n\n"))
+
+		if len(topics) == 0 {
+			msg := b.colorScheme.Colorize(modules.T(writer, "No topics match."), "secondary")
+			writer.Write([]byte(b.colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+		} else {
+			headerLine := fmt.Sprintf("%-3s %-40s", "#", "Topic")
+			writer.Write([]byte(b.colorScheme.CenterText(b.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+			separator := b.colorScheme.DrawSeparator(len(headerLine), "─")
+			writer.Write([]byte(b.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+			for i, t := range topics {
+				line := fmt.Sprintf("%-3d %-40s", i+1, ansi.Ellipsize(t.Title, 40))
+				writer.Write([]byte(b.colorScheme.CenterText(b.colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+			}
+		}
+
+		writer.Write([]byte("\n"))
+		instructions := modules.T(writer, "R: Read a Topic  S: Search  Q: Quit")
+		if query != "" {
+			instructions = modules.T(writer, "R: Read a Topic  S: Search  A: All Topics  Q: Quit")
+		}
+		if b.tutorial != nil {
+			instructions = strings.Replace(instructions, "Q: Quit", "T: Tour  Q: Quit", 1)
+		}
+		writer.Write([]byte(b.colorScheme.CenterText(b.colorScheme.Colorize(instructions, "secondary"), modules.Width(writer)) + "\n"))
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "r", "enter":
+			if len(topics) == 0 {
+				continue
+			}
+			writer.Write([]byte("\n" + b.colorScheme.Colorize(modules.T(writer, "Topic number: "), "text")))
+			input, err := readLine(keyReader, writer, 4)
+			if err != nil || strings.TrimSpace(input) == "" {
+				continue
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(input))
+			if err != nil || index < 1 || index > len(topics) {
+				showMessage(writer, keyReader, b.colorScheme, "Invalid topic number.", "error")
+				continue
+			}
+			if !b.Show(writer, keyReader, topics[index-1]) {
+				return false
+			}
+		case "s":
+			writer.Write([]byte("\n" + b.colorScheme.Colorize(modules.T(writer, "Search for: "), "text")))
+			input, err := readLine(keyReader, writer, 40)
+			if err != nil {
+				continue
+			}
+			query = strings.TrimSpace(input)
+		case "a":
+			query = ""
+		case "t":
+			if b.tutorial != nil && !b.tutorial() {
+				return false
+			}
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// Show displays one topic in the pager
+func (b *Browser) Show(writer modules.Writer, keyReader modules.KeyReader, t *Topic) bool {
+	termSizer := pager.NewTerminalSizerFromWriter(writer)
+	writerAdapter := pager.NewWriterAdapter(writer, termSizer)
+
+	// Give the pager status bar control when the writer supports it
+	type StatusBarController interface {
+		Pause()
+		Resume()
+	}
+	if sbCtrl, ok := writer.(StatusBarController); ok {
+		writerAdapter.WithStatusBarManager(sbCtrl)
+	}
+
+	width, _, _ := writerAdapter.Size()
+	if width <= 0 || width > 80 {
+		width = 80
+	}
+	lines := Render(t, b.colorScheme, width-2)
+
+	p := pager.NewPager(writerAdapter, keyReader, writerAdapter, b.colorScheme)
+	if writerAdapter.StatusBarMgr != nil {
+		p.WithStatusBar(writerAdapter)
+	}
+	if err := p.Display(lines, "--- "+t.Title+" ---"); err != nil {
+		return false
+	}
+	return true
+}
+
+// readLine reads a line of at most max characters, echoing printable ones
+func readLine(keyReader modules.KeyReader, writer modules.Writer, max int) (string, error) {
+	return input.ReadLine(keyReader, writer, max)
+}
+
+// showMessage displays a message and waits for a key
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(modules.T(writer, message), messageType), modules.Width(writer)) + "\n\n"))
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	writer.Write([]byte(colorScheme.CenterText(prompt, modules.Width(writer))))
+	keyReader.ReadKey()
+}
@@ -0,0 +1,120 @@
+package help
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Topic is one page of help. Topics are written as markdown-ish text files:
+// the first line is the title ("# Title") and the rest is the body.
+type Topic struct {
+	Name   string   // File name without .md; matches the menu command it explains
+	Module string   // Module that registered it
+	Title  string   // From the first line
+	Body   []string // Lines after the title
+}
+
+// Registry holds the help topics the modules register
+type Registry struct {
+	topics map[string]*Topic
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{topics: make(map[string]*Topic)}
+}
+
+// Register loads every .md file at the top of fsys as a topic of module. A
+// topic replaces any registered earlier under the same name, so files the
+// sysop drops in the help directory override the built-in ones.
+func (r *Registry) Register(module string, fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return fmt.Errorf("failed to read %s help: %w", module, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".md" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return fmt.Errorf("failed to read %s help: %w", module, err)
+		}
+		name := strings.TrimSuffix(entry.Name(), ".md")
+		r.topics[name] = parse(name, module, string(data))
+	}
+	return nil
+}
+
+// parse splits a topic file into its title and body
+func parse(name, module, text string) *Topic {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	t := &Topic{Name: name, Module: module, Title: name}
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "# ") {
+		t.Title = strings.TrimSpace(lines[0][2:])
+		lines = lines[1:]
+	}
+	// Blank lines around the body would only pad the page
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	t.Body = lines
+	return t
+}
+
+// Topic returns the topic with the given name, or nil if there is none
+func (r *Registry) Topic(name string) *Topic {
+	return r.topics[name]
+}
+
+// Topics returns every topic sorted by title
+func (r *Registry) Topics() []*Topic {
+	topics := make([]*Topic, 0, len(r.topics))
+	for _, t := range r.topics {
+		topics = append(topics, t)
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		return strings.ToLower(topics[i].Title) < strings.ToLower(topics[j].Title)
+	})
+	return topics
+}
+
+// Search returns the topics containing every word of query, ignoring case.
+// Topics with all the words in the title come first.
+func (r *Registry) Search(query string) []*Topic {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return r.Topics()
+	}
+
+	var inTitle, inBody []*Topic
+	for _, t := range r.Topics() {
+		title := strings.ToLower(t.Title)
+		text := title + "\n" + strings.ToLower(strings.Join(t.Body, "\n"))
+		if !containsAll(text, words) {
+			continue
+		}
+		if containsAll(title, words) {
+			inTitle = append(inTitle, t)
+		} else {
+			inBody = append(inBody, t)
+		}
+	}
+	return append(inTitle, inBody...)
+}
+
+// containsAll reports whether text contains every word
+func containsAll(text string, words []string) bool {
+	for _, w := range words {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package help
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// plainColors leaves text uncolored so rendered lines can be compared
+type plainColors struct{}
+
+func (plainColors) Colorize(text, style string) string { return text }
+func (plainColors) CenterText(text string, width int) string {
+	return text
+}
+
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	registry := NewRegistry()
+	builtIn := fstest.MapFS{
+		"files.md":   {Data: []byte("# File Areas\n\nDownload files with ZMODEM.\n")},
+		"boards.md":  {Data: []byte("# Message Boards\r\n\r\nPost and reply to topics.\r\n")},
+		"notes.txt":  {Data: []byte("not a topic")},
+		"upload.md":  {Data: []byte("# Uploading Files\n\nUse ZMODEM to upload.\n")},
+		"untitle.md": {Data: []byte("No title line here.\n")},
+	}
+	if err := registry.Register("modules", builtIn); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return registry
+}
+
+func TestRegister(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	var titles []string
+	for _, topic := range registry.Topics() {
+		titles = append(titles, topic.Title)
+	}
+	want := "File Areas,Message Boards,untitle,Uploading Files"
+	if got := strings.Join(titles, ","); got != want {
+		t.Errorf("topics = %s, want %s", got, want)
+	}
+
+	boards := registry.Topic("boards")
+	if boards == nil {
+		t.Fatal("boards topic missing")
+	}
+	if len(boards.Body) != 1 || boards.Body[0] != "Post and reply to topics." {
+		t.Errorf("boards body = %q", boards.Body)
+	}
+	if registry.Topic("notes") != nil {
+		t.Error("registered a file that isn't .md")
+	}
+
+	// A later registration replaces a topic of the same name
+	sysop := fstest.MapFS{"files.md": {Data: []byte("# Our Files\n\nAsk before uploading.\n")}}
+	if err := registry.Register("sysop", sysop); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if files := registry.Topic("files"); files.Title != "Our Files" || files.Module != "sysop" {
+		t.Errorf("files topic = %q from %s, want the sysop's", files.Title, files.Module)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	names := func(topics []*Topic) string {
+		var n []string
+		for _, topic := range topics {
+			n = append(n, topic.Name)
+		}
+		return strings.Join(n, ",")
+	}
+
+	if got := names(registry.Search("zmodem FILES")); got != "files,upload" {
+		t.Errorf("search = %s, want files,upload", got)
+	}
+	// Both mention files, but only one in its title, so it comes first
+	if got := names(registry.Search("files")); got != "upload,files" {
+		t.Errorf("search = %s, want upload,files", got)
+	}
+
+	if got := registry.Search("zmodem reply"); len(got) != 0 {
+		t.Errorf("search matched %d topics without every word", len(got))
+	}
+	if got := registry.Search("  "); len(got) != len(registry.Topics()) {
+		t.Errorf("empty search returned %d topics", len(got))
+	}
+}
+
+func TestRender(t *testing.T) {
+	topic := parse("test", "modules", strings.Join([]string{
+		"# Test",
+		"",
+		"A **short** paragraph that",
+		"goes on to a `second` line.",
+		"",
+		"## Keys",
+		"- Enter reads the selected item",
+		"  and shows it",
+		"- Q quits",
+		"",
+		"    /s",
+	}, "\n"))
+
+	got := Render(topic, plainColors{}, 24)
+	want := []string{
+		"A short paragraph that",
+		"goes on to a second",
+		"line.",
+		"",
+		"Keys",
+		"  • Enter reads the",
+		"    selected item and",
+		"    shows it",
+		"  • Q quits",
+		"",
+		"/s",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Render =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
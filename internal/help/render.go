@@ -0,0 +1,106 @@
+package help
+
+import (
+	"strings"
+
+	"bbs/internal/pager"
+)
+
+// Render formats a topic's body for the pager. Paragraphs are wrapped to
+// width; "## " lines become headings and "- " lines bullets, which continue
+// on indented lines. Other lines indented by four spaces are shown as they
+// are. ** and ` markers are dropped.
+func Render(t *Topic, colorScheme pager.ColorScheme, width int) []string {
+	if width < 20 {
+		width = 20
+	}
+
+	var lines []string
+	var block []string // Paragraph or bullet being collected
+	bullet := false
+	flush := func() {
+		if len(block) == 0 {
+			return
+		}
+		text := strings.Join(block, " ")
+		if bullet {
+			for i, l := range wrap(text, width-4) {
+				prefix := "    "
+				if i == 0 {
+					prefix = "  • "
+				}
+				lines = append(lines, colorScheme.Colorize(prefix+l, "text"))
+			}
+		} else {
+			for _, l := range wrap(text, width) {
+				lines = append(lines, colorScheme.Colorize(l, "text"))
+			}
+		}
+		block = nil
+		bullet = false
+	}
+
+	for _, raw := range t.Body {
+		line := strings.TrimRight(raw, " \t")
+		indented := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+		switch {
+		case line == "":
+			flush()
+			lines = append(lines, "")
+		case bullet && indented:
+			block = append(block, plain(strings.TrimSpace(line)))
+		case strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"):
+			flush()
+			lines = append(lines, colorScheme.Colorize(strings.TrimLeft(line, " \t"), "secondary"))
+		case strings.HasPrefix(line, "#"):
+			flush()
+			heading := strings.TrimSpace(strings.TrimLeft(line, "#"))
+			lines = append(lines, colorScheme.Colorize(plain(heading), "accent"))
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			flush()
+			bullet = true
+			block = []string{plain(strings.TrimSpace(line[2:]))}
+		default:
+			if bullet {
+				flush()
+			}
+			block = append(block, plain(strings.TrimSpace(line)))
+		}
+	}
+	flush()
+	return lines
+}
+
+// plain drops the inline markdown markers the pager can't show
+func plain(s string) string {
+	return strings.NewReplacer("**", "", "`", "").Replace(s)
+}
+
+// wrap breaks text into lines no longer than width, at spaces where it can
+func wrap(text string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		for len(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
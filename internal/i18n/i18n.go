@@ -0,0 +1,154 @@
+// Package i18n holds the board's string catalogs: what callers see in each
+// language, keyed by the English text the board is written in
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Dir is the directory beside config.yaml that holds the sysop's catalogs
+const Dir = "strings"
+
+// English is the language the board is written in. It needs no catalog.
+const English = "en"
+
+//go:embed locales/*.yaml
+var builtIn embed.FS
+
+// Locale is a language callers can choose
+type Locale struct {
+	Code string // The catalog's file name, e.g. "es"
+	Name string // What the language calls itself, e.g. "Español"
+}
+
+// file is a catalog as written in YAML
+type file struct {
+	Name    string            `yaml:"name"`
+	Strings map[string]string `yaml:"strings"`
+}
+
+// Catalog holds every language's strings
+type Catalog struct {
+	names   map[string]string
+	strings map[string]map[string]string
+}
+
+// New creates a catalog with only English
+func New() *Catalog {
+	return &Catalog{
+		names:   map[string]string{English: "English"},
+		strings: make(map[string]map[string]string),
+	}
+}
+
+// Load reads the catalogs built into the board, then those in dir, which
+// may not exist. A file in dir adds to the built-in catalog for its
+// language, and its strings win over the built-in ones.
+func Load(dir string) (*Catalog, error) {
+	c := New()
+
+	entries, err := builtIn.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		data, err := builtIn.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if err := c.add(entry.Name(), data); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	for _, f := range files {
+		if f.IsDir() || !isYAML(f.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if err := c.add(f.Name(), data); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// add merges a catalog file into the language named by the file
+func (c *Catalog) add(name string, data []byte) error {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	code := strings.TrimSuffix(name, filepath.Ext(name))
+	if f.Name != "" {
+		c.names[code] = f.Name
+	} else if _, ok := c.names[code]; !ok {
+		c.names[code] = code
+	}
+
+	if c.strings[code] == nil {
+		c.strings[code] = make(map[string]string)
+	}
+	for text, translation := range f.Strings {
+		if translation != "" {
+			c.strings[code][text] = translation
+		}
+	}
+	return nil
+}
+
+// isYAML reports whether a file name is a catalog
+func isYAML(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Has reports whether code is a language in the catalog
+func (c *Catalog) Has(code string) bool {
+	_, ok := c.names[code]
+	return ok
+}
+
+// Locales lists the languages in the catalog, English first and the rest
+// by code
+func (c *Catalog) Locales() []Locale {
+	locales := make([]Locale, 0, len(c.names))
+	for code, name := range c.names {
+		locales = append(locales, Locale{Code: code, Name: name})
+	}
+	sort.Slice(locales, func(i, j int) bool {
+		if (locales[i].Code == English) != (locales[j].Code == English) {
+			return locales[i].Code == English
+		}
+		return locales[i].Code < locales[j].Code
+	})
+	return locales
+}
+
+// Translate returns text in the language code, or text itself when the
+// language has no translation for it. Format strings are translated
+// before their arguments are filled in, so a translation keeps the verbs.
+func (c *Catalog) Translate(code, text string) string {
+	if translated, ok := c.strings[code][text]; ok {
+		return translated
+	}
+	return text
+}
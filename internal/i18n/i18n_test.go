@@ -0,0 +1,87 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	dir := t.TempDir()
+	sysop := `name: "Français"
+strings:
+  "Press any key to continue...": "Appuyez sur une touche..."
+  "Message sent to %s on node %d.": "Message envoyé à %s sur le nœud %d."
+  "Untranslated": ""
+`
+	if err := os.WriteFile(filepath.Join(dir, "fr.yaml"), []byte(sysop), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A sysop's catalog adds to the built-in one for its language
+	override := `strings:
+  "Press any key to continue...": "Pulse cualquier tecla..."
+`
+	if err := os.WriteFile(filepath.Join(dir, "es.yml"), []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a catalog"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := []struct {
+		code, text, want string
+	}{
+		{"fr", "Press any key to continue...", "Appuyez sur une touche..."},
+		{"fr", "Message sent to %s on node %d.", "Message envoyé à %s sur le nœud %d."},
+		{"fr", "Untranslated", "Untranslated"},
+		{"fr", "Not in the catalog", "Not in the catalog"},
+		{"es", "Press any key to continue...", "Pulse cualquier tecla..."},
+		{"es", "Post \"%s\"?", "¿Publicar \"%s\"?"},
+		{English, "Press any key to continue...", "Press any key to continue..."},
+		{"de", "Press any key to continue...", "Press any key to continue..."},
+	}
+	for _, tt := range tests {
+		if got := c.Translate(tt.code, tt.text); got != tt.want {
+			t.Errorf("Translate(%q, %q) = %q, want %q", tt.code, tt.text, got, tt.want)
+		}
+	}
+
+	locales := c.Locales()
+	want := []Locale{{English, "English"}, {"es", "Español"}, {"fr", "Français"}}
+	if len(locales) != len(want) {
+		t.Fatalf("Locales() = %+v, want %+v", locales, want)
+	}
+	for i := range want {
+		if locales[i] != want[i] {
+			t.Errorf("Locales()[%d] = %+v, want %+v", i, locales[i], want[i])
+		}
+	}
+	if c.Has("de") {
+		t.Error("Has(de) = true for a language with no catalog")
+	}
+}
+
+func TestLoadWithoutDir(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !c.Has(English) || !c.Has("es") {
+		t.Errorf("Locales() = %+v, want English and the built-in catalogs", c.Locales())
+	}
+}
+
+func TestLoadRejectsBadYAML(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fr.yaml"), []byte("strings: [not, a, map"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("Load accepted a catalog that isn't valid YAML")
+	}
+}
@@ -0,0 +1,22 @@
+package input
+
+// MaxHistory is how many lines a History keeps
+const MaxHistory = 50
+
+// History is the lines a caller has typed at prompts, oldest first. A
+// session keeps one for the length of the call.
+type History struct {
+	lines []string
+}
+
+// Add records line, unless it is blank or the same as the line before it.
+// The oldest line goes once there are MaxHistory.
+func (h *History) Add(line string) {
+	if line == "" || len(h.lines) > 0 && h.lines[len(h.lines)-1] == line {
+		return
+	}
+	h.lines = append(h.lines, line)
+	if len(h.lines) > MaxHistory {
+		h.lines = h.lines[len(h.lines)-MaxHistory:]
+	}
+}
@@ -0,0 +1,245 @@
+// Package input turns the bytes a caller's terminal sends into key presses.
+// Sessions decode with it, and forms read lines with it, so every screen
+// understands the same keys.
+package input
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Names of the keys a Decoder reports. Printable characters and control
+// codes it has no name for are reported as themselves.
+const (
+	Enter     = "enter"
+	Escape    = "escape"
+	Backspace = "backspace"
+	Delete    = "delete"
+	Insert    = "insert"
+	Up        = "up"
+	Down      = "down"
+	Left      = "left"
+	Right     = "right"
+	ShiftTab  = "shift+tab"
+	Home      = "home"
+	End       = "end"
+	PageUp    = "pageup"
+	PageDown  = "pagedown"
+	CtrlC     = "ctrl+c"
+	Paste     = "paste" // Text the caller pasted, in Key.Text
+)
+
+// Menu commands the session turns q, g and Ctrl+C into. Forms that read
+// text put the letters back.
+const (
+	Quit    = "quit"
+	Goodbye = "goodbye"
+)
+
+// PasteOn and PasteOff ask the terminal to mark text the caller pastes, so
+// it arrives as one Paste instead of keys that might be taken as commands
+const (
+	PasteOn  = "\033[?2004h"
+	PasteOff = "\033[?2004l"
+)
+
+// MaxPaste is the most pasted text kept; the rest of a longer paste is read
+// and dropped
+const MaxPaste = 64 * 1024
+
+// maxSequence is the longest escape sequence read before giving up on it
+const maxSequence = 16
+
+// pasteEnd is what the terminal sends after pasted text
+const pasteEnd = "\033[201~"
+
+// Key is one key press
+type Key struct {
+	Name string // One of the names above, "f1" to "f12", "alt-" and a letter, or the character typed; "" for a sequence with no meaning here
+	Text string // The pasted text, for Paste
+}
+
+// Decoder reads key presses from a terminal. It reads a byte at a time, so
+// nothing is held back from whoever reads the terminal next, such as a file
+// transfer. An escape sequence is read as soon as its ESC arrives, so a lone
+// Escape is only seen once the key after it is pressed.
+type Decoder struct {
+	r   io.Reader
+	alt bool
+	buf [1]byte
+}
+
+// NewDecoder creates a decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// SetAlt sets whether ESC followed by a letter is reported as Alt and the
+// letter, as terminals send it, rather than as Escape. Off, a caller who
+// presses Escape and then a letter gets Escape.
+func (d *Decoder) SetAlt(on bool) {
+	d.alt = on
+}
+
+// ReadKey reads the next key press
+func (d *Decoder) ReadKey() (Key, error) {
+	b, err := d.readByte()
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch b {
+	case '\r', '\n':
+		return Key{Name: Enter}, nil
+	case 127, '\b':
+		return Key{Name: Backspace}, nil
+	case 3:
+		return Key{Name: CtrlC}, nil
+	case 27:
+		// A read that fails partway through a sequence leaves just the
+		// Escape; the next read reports the error
+		return d.escape(), nil
+	}
+	return Key{Name: string(b)}, nil
+}
+
+// readByte reads one byte from the terminal
+func (d *Decoder) readByte() (byte, error) {
+	for {
+		n, err := d.r.Read(d.buf[:])
+		if n == 1 {
+			return d.buf[0], nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// escape reads what follows an ESC
+func (d *Decoder) escape() Key {
+	b, err := d.readByte()
+	if err != nil {
+		return Key{Name: Escape}
+	}
+
+	switch b {
+	case '[':
+		return d.csi()
+	case 'O':
+		return d.ss3()
+	}
+	if d.alt && (b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z') {
+		return Key{Name: "alt-" + strings.ToLower(string(b))}
+	}
+	return Key{Name: Escape}
+}
+
+// ss3 reads the key after ESC O, which xterm-style terminals send for F1 to
+// F4, and for the arrows, Home and End in application cursor mode
+func (d *Decoder) ss3() Key {
+	b, err := d.readByte()
+	if err != nil {
+		return Key{Name: Escape}
+	}
+	if name, ok := finalKeys[b]; ok {
+		return Key{Name: name}
+	}
+	if b >= 'P' && b <= 'S' {
+		return Key{Name: functionKey(int(b-'P') + 1)}
+	}
+	return Key{}
+}
+
+// finalKeys are the keys named by the last byte of ESC [ or ESC O
+var finalKeys = map[byte]string{
+	'A': Up, 'B': Down, 'C': Right, 'D': Left, 'H': Home, 'F': End,
+	'Z': ShiftTab,
+}
+
+// tildeKeys are the keys sent as ESC [ number ~
+var tildeKeys = map[string]string{
+	"1": Home, "2": Insert, "3": Delete, "4": End, "5": PageUp, "6": PageDown,
+	"7": Home, "8": End,
+	"11": "f1", "12": "f2", "13": "f3", "14": "f4", "15": "f5",
+	"17": "f6", "18": "f7", "19": "f8", "20": "f9", "21": "f10",
+	"23": "f11", "24": "f12",
+}
+
+// csi reads the rest of an ESC [ sequence. Modifiers, as in ESC [ 1 ; 5 A
+// for Ctrl and Up, are ignored, so the key is the same whatever is held.
+func (d *Decoder) csi() Key {
+	var params []byte
+	for len(params) < maxSequence {
+		b, err := d.readByte()
+		if err != nil {
+			return Key{Name: Escape}
+		}
+
+		// The Linux console sends F1 to F5 as ESC [ [ A to ESC [ [ E
+		if b == '[' && len(params) == 0 {
+			b, err = d.readByte()
+			if err != nil {
+				return Key{Name: Escape}
+			}
+			if b >= 'A' && b <= 'E' {
+				return Key{Name: functionKey(int(b-'A') + 1)}
+			}
+			return Key{}
+		}
+
+		if b >= 0x20 && b <= 0x3f {
+			params = append(params, b)
+			continue
+		}
+		if b == '~' {
+			number, _, _ := strings.Cut(string(params), ";")
+			if number == "200" {
+				return d.paste()
+			}
+			return Key{Name: tildeKeys[number]}
+		}
+		return Key{Name: finalKeys[b]}
+	}
+	return Key{}
+}
+
+// paste reads pasted text up to the sequence that ends it. Line endings
+// become "\n".
+func (d *Decoder) paste() Key {
+	var text []byte
+	matched := 0
+	keep := func(b byte) {
+		if len(text) < MaxPaste {
+			text = append(text, b)
+		}
+	}
+	for matched < len(pasteEnd) {
+		b, err := d.readByte()
+		if err != nil {
+			break
+		}
+		if b == pasteEnd[matched] {
+			matched++
+			continue
+		}
+		for i := 0; i < matched; i++ {
+			keep(pasteEnd[i])
+		}
+		matched = 0
+		if b == pasteEnd[0] {
+			matched = 1
+			continue
+		}
+		keep(b)
+	}
+
+	pasted := strings.ReplaceAll(string(text), "\r\n", "\n")
+	return Key{Name: Paste, Text: strings.ReplaceAll(pasted, "\r", "\n")}
+}
+
+// functionKey names function key n
+func functionKey(n int) string {
+	return "f" + strconv.Itoa(n)
+}
@@ -0,0 +1,121 @@
+package input
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readAll decodes every key in sent
+func readAll(t *testing.T, d *Decoder) []Key {
+	t.Helper()
+	var keys []Key
+	for {
+		key, err := d.ReadKey()
+		if errors.Is(err, io.EOF) {
+			return keys
+		}
+		if err != nil {
+			t.Fatalf("ReadKey failed: %v", err)
+		}
+		keys = append(keys, key)
+	}
+}
+
+func TestDecoder_Keys(t *testing.T) {
+	tests := []struct {
+		sent string
+		want string
+	}{
+		{"a", "a"},
+		{"Q", "Q"},
+		{"\r", Enter},
+		{"\n", Enter},
+		{"\x7f", Backspace},
+		{"\b", Backspace},
+		{"\x03", CtrlC},
+		{"\t", "\t"},
+		{"\x0e", "\x0e"},
+		{"\x1b[A", Up},
+		{"\x1b[B", Down},
+		{"\x1b[C", Right},
+		{"\x1b[D", Left},
+		{"\x1bOA", Up},
+		{"\x1b[H", Home},
+		{"\x1b[F", End},
+		{"\x1bOH", Home},
+		{"\x1b[1~", Home},
+		{"\x1b[4~", End},
+		{"\x1b[7~", Home},
+		{"\x1b[8~", End},
+		{"\x1b[2~", Insert},
+		{"\x1b[3~", Delete},
+		{"\x1b[5~", PageUp},
+		{"\x1b[6~", PageDown},
+		{"\x1bOP", "f1"},
+		{"\x1bOS", "f4"},
+		{"\x1b[11~", "f1"},
+		{"\x1b[15~", "f5"},
+		{"\x1b[17~", "f6"},
+		{"\x1b[24~", "f12"},
+		{"\x1b[[A", "f1"},
+		{"\x1b[[E", "f5"},
+		{"\x1b[1;5A", Up},
+		{"\x1b[3;2~", Delete},
+		{"\x1b[99~", ""},
+		{"\x1b[Z", ShiftTab},
+		{"\x1bx", Escape},
+		{"\x1b\x1b", Escape},
+		{"\x1b", Escape},
+		{"\x1b[", Escape},
+	}
+	for _, tt := range tests {
+		keys := readAll(t, NewDecoder(strings.NewReader(tt.sent)))
+		if len(keys) != 1 || keys[0].Name != tt.want {
+			t.Errorf("%q decoded as %+v, want %q", tt.sent, keys, tt.want)
+		}
+	}
+}
+
+func TestDecoder_Alt(t *testing.T) {
+	d := NewDecoder(strings.NewReader("\x1bT\x1bh\x1b[A\x1b1"))
+	d.SetAlt(true)
+	var names []string
+	for _, key := range readAll(t, d) {
+		names = append(names, key.Name)
+	}
+	if got := strings.Join(names, " "); got != "alt-t alt-h up escape" {
+		t.Errorf("decoded %q", got)
+	}
+}
+
+func TestDecoder_Sequence(t *testing.T) {
+	d := NewDecoder(strings.NewReader("ab\x1b[Bq\r"))
+	var names []string
+	for _, key := range readAll(t, d) {
+		names = append(names, key.Name)
+	}
+	if got := strings.Join(names, " "); got != "a b down q enter" {
+		t.Errorf("decoded %q", got)
+	}
+}
+
+func TestDecoder_Paste(t *testing.T) {
+	d := NewDecoder(strings.NewReader("x\x1b[200~quit\r\nnow \x1b[A\x1b[201~y"))
+	keys := readAll(t, d)
+	if len(keys) != 3 || keys[0].Name != "x" || keys[2].Name != "y" {
+		t.Fatalf("decoded %+v", keys)
+	}
+	if keys[1].Name != Paste || keys[1].Text != "quit\nnow \x1b[A" {
+		t.Errorf("paste decoded as %+v", keys[1])
+	}
+}
+
+func TestDecoder_LongPaste(t *testing.T) {
+	long := strings.Repeat("x", MaxPaste+10)
+	keys := readAll(t, NewDecoder(strings.NewReader("\x1b[200~"+long+"\x1b[201~a")))
+	if len(keys) != 2 || len(keys[0].Text) != MaxPaste || keys[1].Name != "a" {
+		t.Errorf("long paste decoded as %d keys, %d bytes kept", len(keys), len(keys[0].Text))
+	}
+}
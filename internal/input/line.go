@@ -0,0 +1,260 @@
+package input
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrCancelled is returned by ReadLine when the caller presses Escape
+var ErrCancelled = errors.New("cancelled")
+
+// KeyReader reads key presses by name, as the session hands them to modules
+type KeyReader interface {
+	ReadKey() (string, error)
+}
+
+// TextReader is implemented by key readers that can return every letter as
+// typed. ReadKey turns q, g and Ctrl+C into menu commands; ReadLine reads
+// with ReadTextKey when it can, so a capital Q stays one.
+type TextReader interface {
+	ReadTextKey() (string, error)
+}
+
+// HistoryReader is implemented by key readers that remember the lines typed
+// into them. ReadLine adds each line it reads, and Up and Down step back and
+// forth through them.
+type HistoryReader interface {
+	History() *History
+}
+
+// TextKeys returns the function to read keys from keys with every letter
+// as typed, if it can, or else its ReadKey
+func TextKeys(keys KeyReader) func() (string, error) {
+	if t, ok := keys.(TextReader); ok {
+		return t.ReadTextKey
+	}
+	return keys.ReadKey
+}
+
+// ReadLine reads a line of printable characters, at most max of them or any
+// number if max is 0, echoing them to w. Left, Right, Home and End move
+// along the line, and Backspace and Delete take characters out of it; Ctrl+U
+// takes out everything before the cursor and Ctrl+W the word before it. If
+// keys keeps a History, Up and Down bring back earlier lines. Escape or
+// Ctrl+C returns ErrCancelled.
+func ReadLine(keys KeyReader, w io.Writer, max int) (string, error) {
+	return ReadLineFunc(keys, w, max, Printable)
+}
+
+// ReadPassword is ReadLine with each character shown as *. Passwords are
+// kept out of the History.
+func ReadPassword(keys KeyReader, w io.Writer, max int) (string, error) {
+	return readLine(keys, &lineEditor{w: w, mask: true}, max, Printable)
+}
+
+// ReadLineFunc is ReadLine for a field that takes only the characters allow
+// reports true for
+func ReadLineFunc(keys KeyReader, w io.Writer, max int, allow func(byte) bool) (string, error) {
+	return readLine(keys, &lineEditor{w: w}, max, allow)
+}
+
+// readLine reads a line into e
+func readLine(keys KeyReader, e *lineEditor, max int, allow func(byte) bool) (string, error) {
+	read := TextKeys(keys)
+	w := e.w
+
+	var history *History
+	if h, ok := keys.(HistoryReader); ok && !e.mask {
+		history = h.History()
+	}
+	// recalled is how far back Up has gone, and draft the line typed before
+	recalled, draft := 0, ""
+
+	for {
+		key, err := read()
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case Enter:
+			w.Write([]byte("\n"))
+			if history != nil {
+				history.Add(string(e.line))
+			}
+			return string(e.line), nil
+		case Escape, CtrlC:
+			return "", ErrCancelled
+		case Backspace, "\x7f", "\b":
+			if e.pos > 0 {
+				e.left()
+				e.remove(1)
+			}
+		case Delete:
+			e.remove(1)
+		case killLine:
+			n := e.pos
+			e.home()
+			e.remove(n)
+		case killWord:
+			start := e.pos
+			for start > 0 && e.line[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && e.line[start-1] != ' ' {
+				start--
+			}
+			n := e.pos - start
+			for e.pos > start {
+				e.left()
+			}
+			e.remove(n)
+		case Left:
+			e.left()
+		case Right:
+			if e.pos < len(e.line) {
+				e.pos++
+				w.Write([]byte(e.show(e.line[e.pos-1 : e.pos])))
+			}
+		case Home:
+			e.home()
+		case End:
+			w.Write([]byte(e.show(e.line[e.pos:])))
+			e.pos = len(e.line)
+		case Up:
+			if history == nil {
+				continue
+			}
+			// Skip lines this field wouldn't take
+			for back := recalled + 1; back <= len(history.lines); back++ {
+				line := history.lines[len(history.lines)-back]
+				if fits(line, max, allow) {
+					if recalled == 0 {
+						draft = string(e.line)
+					}
+					recalled = back
+					e.replace(line)
+					break
+				}
+			}
+		case Down:
+			if history == nil || recalled == 0 {
+				continue
+			}
+			for recalled--; recalled > 0; recalled-- {
+				if line := history.lines[len(history.lines)-recalled]; fits(line, max, allow) {
+					break
+				}
+			}
+			if recalled == 0 {
+				e.replace(draft)
+			} else {
+				e.replace(history.lines[len(history.lines)-recalled])
+			}
+		case Quit, Goodbye:
+			// A reader without ReadTextKey has turned q or g into a command
+			e.insert(key[0], max, allow)
+		default:
+			if len(key) == 1 {
+				e.insert(key[0], max, allow)
+			}
+		}
+	}
+}
+
+// Ctrl+U and Ctrl+W, which terminals send as control codes
+const (
+	killLine = "\x15"
+	killWord = "\x17"
+)
+
+// fits reports whether a field of at most max characters that allow takes
+// would take line
+func fits(line string, max int, allow func(byte) bool) bool {
+	if max > 0 && len(line) > max {
+		return false
+	}
+	for i := 0; i < len(line); i++ {
+		if !allow(line[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Printable reports whether b is a printable ASCII character
+func Printable(b byte) bool {
+	return b >= 32 && b <= 126
+}
+
+// Digit reports whether b is a digit, for number fields
+func Digit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// lineEditor keeps the line being typed and the cursor's place in it, and
+// echoes each change
+type lineEditor struct {
+	w    io.Writer
+	mask bool // Echo * for each character, for passwords
+	line []byte
+	pos  int
+}
+
+// show returns what to echo for part of the line
+func (e *lineEditor) show(part []byte) string {
+	if e.mask {
+		return strings.Repeat("*", len(part))
+	}
+	return string(part)
+}
+
+// insert puts b at the cursor if the field takes it and has room
+func (e *lineEditor) insert(b byte, max int, allow func(byte) bool) {
+	if !allow(b) || max > 0 && len(e.line) >= max {
+		return
+	}
+	e.line = append(e.line[:e.pos], append([]byte{b}, e.line[e.pos:]...)...)
+	e.pos++
+	// Redraw from the new character on, then step back to just after it
+	tail := e.line[e.pos-1:]
+	e.w.Write([]byte(e.show(tail) + strings.Repeat("\b", len(tail)-1)))
+}
+
+// remove takes out n characters from the cursor on
+func (e *lineEditor) remove(n int) {
+	n = min(n, len(e.line)-e.pos)
+	if n <= 0 {
+		return
+	}
+	e.line = append(e.line[:e.pos], e.line[e.pos+n:]...)
+	// Close the gap and blank what were the last characters
+	tail := e.line[e.pos:]
+	e.w.Write([]byte(e.show(tail) + strings.Repeat(" ", n) + strings.Repeat("\b", len(tail)+n)))
+}
+
+// replace puts line in place of the one being typed, with the cursor at
+// its end
+func (e *lineEditor) replace(line string) {
+	e.home()
+	gap := max(len(e.line)-len(line), 0)
+	e.line = []byte(line)
+	e.pos = len(e.line)
+	e.w.Write([]byte(e.show(e.line) + strings.Repeat(" ", gap) + strings.Repeat("\b", gap)))
+}
+
+// home moves the cursor to the start of the line
+func (e *lineEditor) home() {
+	for e.pos > 0 {
+		e.left()
+	}
+}
+
+// left moves the cursor back a character
+func (e *lineEditor) left() {
+	if e.pos > 0 {
+		e.pos--
+		e.w.Write([]byte("\b"))
+	}
+}
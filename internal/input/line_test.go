@@ -0,0 +1,169 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// keyList hands out keys by name, as a session would
+type keyList []string
+
+func (k *keyList) ReadKey() (string, error) {
+	if len(*k) == 0 {
+		return "", io.EOF
+	}
+	key := (*k)[0]
+	*k = (*k)[1:]
+	return key, nil
+}
+
+// textKeys also hands out keys as typed
+type textKeys struct{ keyList }
+
+func (k *textKeys) ReadTextKey() (string, error) {
+	return k.ReadKey()
+}
+
+func TestReadLine(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		max  int
+		want string
+		echo string
+	}{
+		{"typed", []string{"h", "i", Enter}, 0, "hi", "hi\n"},
+		{"backspace", []string{"a", "b", Backspace, "c", Enter}, 0, "ac", "ab\b \bc\n"},
+		{"commands are letters", []string{Quit, Goodbye, Enter}, 0, "qg", "qg\n"},
+		{"too long", []string{"a", "b", "c", Enter}, 2, "ab", "ab\n"},
+		{"not printable", []string{"a", "\x0e", Up, "f1", Enter}, 0, "a", "a\n"},
+		{"insert", []string{"a", "c", Left, "b", Enter}, 0, "abc", "ac\bbc\b\n"},
+		{"home and delete", []string{"x", "a", "b", Home, Delete, End, "c", Enter}, 0, "abc", "xab\b\b\bab \b\b\babc\n"},
+		{"backspace in the middle", []string{"a", "b", "c", Left, Backspace, Enter}, 0, "ac", "abc\b\bc \b\b\n"},
+		{"right", []string{"a", "b", Home, Right, "x", Enter}, 0, "axb", "ab\b\baxb\b\n"},
+		{"ctrl+u", []string{"a", "b", "c", Left, "\x15", Enter}, 0, "c", "abc\b\b\bc  \b\b\b\n"},
+		{"ctrl+w", []string{"a", " ", "b", "c", " ", "\x17", Enter}, 0, "a ", "a bc \b\b\b   \b\b\b\n"},
+		{"up without history", []string{"a", Up, Enter}, 0, "a", "a\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		keys := keyList(tt.keys)
+		got, err := ReadLine(&keys, &out, tt.max)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: ReadLine = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+		if out.String() != tt.echo {
+			t.Errorf("%s: echoed %q, want %q", tt.name, out.String(), tt.echo)
+		}
+	}
+}
+
+func TestReadLine_Cancel(t *testing.T) {
+	for _, key := range []string{Escape, CtrlC} {
+		keys := keyList{"a", key}
+		if _, err := ReadLine(&keys, io.Discard, 0); !errors.Is(err, ErrCancelled) {
+			t.Errorf("%s: err = %v, want ErrCancelled", key, err)
+		}
+	}
+
+	keys := keyList{"a"}
+	if _, err := ReadLine(&keys, io.Discard, 0); !errors.Is(err, io.EOF) {
+		t.Errorf("err = %v, want the reader's error", err)
+	}
+}
+
+func TestReadLine_TextKeys(t *testing.T) {
+	keys := &textKeys{keyList{"Q", "u", Enter}}
+	if got, _ := ReadLine(keys, io.Discard, 0); got != "Qu" {
+		t.Errorf("ReadLine = %q", got)
+	}
+}
+
+// historyKeys hands out keys and keeps a History
+type historyKeys struct {
+	keyList
+	history History
+}
+
+func (k *historyKeys) History() *History {
+	return &k.history
+}
+
+func TestReadLine_History(t *testing.T) {
+	keys := &historyKeys{}
+	for _, line := range []string{"first", "second", "second", ""} {
+		keys.keyList = append(keyList(strings.Split(line, "")), Enter)
+		ReadLine(keys, io.Discard, 0)
+	}
+	if want := []string{"first", "second"}; !slices.Equal(keys.history.lines, want) {
+		t.Fatalf("history = %q, want %q", keys.history.lines, want)
+	}
+
+	tests := []struct {
+		name string
+		keys []string
+		max  int
+		want string
+	}{
+		{"up", []string{Up, Enter}, 0, "second"},
+		{"up twice", []string{Up, Up, "!", Enter}, 0, "first!"},
+		{"past the oldest", []string{Up, Up, Up, Enter}, 0, "first"},
+		{"back to the draft", []string{"d", Up, Up, Down, Down, Enter}, 0, "d"},
+		{"too long for the field", []string{Up, Enter}, 5, "first"},
+	}
+	for _, tt := range tests {
+		keys.history.lines = []string{"first", "second"}
+		keys.keyList = tt.keys
+		if got, _ := ReadLine(keys, io.Discard, tt.max); got != tt.want {
+			t.Errorf("%s: ReadLine = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	var out bytes.Buffer
+	keys.history.lines = []string{"much longer"}
+	keys.keyList = keyList{"a", "b", Up, Down, Enter}
+	ReadLine(keys, &out, 0)
+	if want := "ab\b\bmuch longer\b\b\b\b\b\b\b\b\b\b\bab         \b\b\b\b\b\b\b\b\b\n"; out.String() != want {
+		t.Errorf("echoed %q, want %q", out.String(), want)
+	}
+
+	keys.keyList = keyList{"p", Enter}
+	ReadPassword(keys, io.Discard, 0)
+	if slices.Contains(keys.history.lines, "p") {
+		t.Error("a password went into the history")
+	}
+}
+
+func TestHistory_Max(t *testing.T) {
+	var h History
+	for i := 0; i < MaxHistory+5; i++ {
+		h.Add(strconv.Itoa(i))
+	}
+	if len(h.lines) != MaxHistory || h.lines[0] != "5" {
+		t.Errorf("kept %d lines from %q", len(h.lines), h.lines[0])
+	}
+}
+
+func TestReadLineFunc(t *testing.T) {
+	keys := keyList{"1", "a", "2", Enter}
+	if got, _ := ReadLineFunc(&keys, io.Discard, 3, Digit); got != "12" {
+		t.Errorf("ReadLineFunc = %q", got)
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	var out bytes.Buffer
+	keys := keyList{"p", "w", Left, "x", Enter}
+	got, err := ReadPassword(&keys, &out, 0)
+	if err != nil || got != "pxw" {
+		t.Errorf("ReadPassword = %q, %v", got, err)
+	}
+	if out.String() != "**\b**\b\n" {
+		t.Errorf("echoed %q", out.String())
+	}
+}
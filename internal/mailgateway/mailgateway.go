@@ -0,0 +1,357 @@
+// Package mailgateway accepts email for the board's callers on a small SMTP
+// listener, so callers can answer mail from their email digest without
+// logging in. A message addressed to username+key@domain becomes private
+// mail to that caller from the member whose mail key is key. The key comes
+// with each member's digest and can't be guessed, unlike a From header,
+// which anyone can write; mail without a member's key is refused.
+package mailgateway
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/mailimport"
+)
+
+// maxConns is how many mail servers are talked to at once; others are
+// turned away
+const maxConns = 16
+
+// maxRecipients is how many callers one message can be addressed to
+const maxRecipients = 20
+
+// maxMessages is how many messages one connection can deliver
+const maxMessages = 20
+
+// Timeouts for reading a command and a message
+const (
+	commandTimeout = 2 * time.Minute
+	dataTimeout    = 10 * time.Minute
+)
+
+// Server delivers the mail sent to one board
+type Server struct {
+	db       *database.DB
+	cfg      config.MailGatewayConfig
+	system   string
+	maxBytes int64
+	notify   func(username, text string)
+	slots    chan struct{}
+}
+
+// NewServer creates a mail gateway for the board cfg describes. notify
+// tells a recipient about new mail the way they asked to hear about it.
+func NewServer(cfg *config.Config, db *database.DB, notify func(username, text string)) *Server {
+	maxKB := cfg.Server.MailGateway.MaxKB
+	if maxKB <= 0 {
+		maxKB = 256
+	}
+	return &Server{
+		db:       db,
+		cfg:      cfg.Server.MailGateway,
+		system:   cfg.BBS.SystemName,
+		maxBytes: int64(maxKB) * 1024,
+		notify:   notify,
+		slots:    make(chan struct{}, maxConns),
+	}
+}
+
+// ListenAndServe accepts mail on the configured address
+func (s *Server) ListenAndServe() error {
+	if s.cfg.Domain == "" {
+		return errors.New("mail gateway needs a domain")
+	}
+	if s.db.ReadOnly() {
+		return database.ErrReadOnly
+	}
+	listener, err := net.Listen("tcp", s.cfg.Listen)
+	if err != nil {
+		return err
+	}
+	log.Printf("Mail gateway listening on %s for %s", s.cfg.Listen, s.cfg.Domain)
+	return s.Serve(listener)
+}
+
+// Serve talks to the mail servers that connect to listener until it is
+// closed
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		select {
+		case s.slots <- struct{}{}:
+			go func() {
+				defer func() { <-s.slots }()
+				s.handle(conn)
+			}()
+		default:
+			fmt.Fprintf(conn, "421 4.3.2 Too busy, try again later\r\n")
+			conn.Close()
+		}
+	}
+}
+
+// session is one connection's SMTP conversation
+type session struct {
+	s         *Server
+	conn      net.Conn
+	r         *textproto.Reader
+	w         *textproto.Writer
+	greeted   bool
+	from      string   // Envelope sender, once MAIL is given
+	sender    string   // Member whose key the recipients were addressed with
+	to        []string // Callers the message is for
+	delivered int
+}
+
+// handle talks SMTP with one mail server until it quits
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	// Bound what a connection can send, lines included
+	limit := (maxMessages + 1) * s.maxBytes
+	c := &session{
+		s:    s,
+		conn: conn,
+		r:    textproto.NewReader(bufio.NewReader(io.LimitReader(conn, limit))),
+		w:    textproto.NewWriter(bufio.NewWriter(conn)),
+	}
+	conn.SetDeadline(time.Now().Add(commandTimeout))
+	if c.reply(220, "%s ESMTP %s mail gateway", s.cfg.Domain, s.system) != nil {
+		return
+	}
+	for {
+		conn.SetDeadline(time.Now().Add(commandTimeout))
+		line, err := c.r.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		if !c.command(strings.ToUpper(verb), strings.TrimSpace(arg)) {
+			return
+		}
+	}
+}
+
+// reply sends one response line
+func (c *session) reply(code int, format string, args ...interface{}) error {
+	return c.w.PrintfLine("%d %s", code, fmt.Sprintf(format, args...))
+}
+
+// reset forgets the message being sent
+func (c *session) reset() {
+	c.from = ""
+	c.sender = ""
+	c.to = nil
+}
+
+// command answers one command. It reports whether to keep talking.
+func (c *session) command(verb, arg string) bool {
+	var err error
+	switch verb {
+	case "HELO":
+		c.greeted = true
+		c.reset()
+		err = c.reply(250, "%s", c.s.cfg.Domain)
+	case "EHLO":
+		c.greeted = true
+		c.reset()
+		for _, line := range []string{"250-" + c.s.cfg.Domain, fmt.Sprintf("250-SIZE %d", c.s.maxBytes), "250 8BITMIME"} {
+			if err = c.w.PrintfLine("%s", line); err != nil {
+				break
+			}
+		}
+	case "MAIL":
+		err = c.mail(arg)
+	case "RCPT":
+		err = c.rcpt(arg)
+	case "DATA":
+		err = c.data()
+	case "RSET":
+		c.reset()
+		err = c.reply(250, "2.0.0 OK")
+	case "NOOP":
+		err = c.reply(250, "2.0.0 OK")
+	case "VRFY":
+		err = c.reply(252, "2.5.2 Send some mail and see")
+	case "QUIT":
+		c.reply(221, "2.0.0 Bye")
+		return false
+	default:
+		err = c.reply(502, "5.5.2 Command not recognized")
+	}
+	return err == nil
+}
+
+// mail starts a message from the envelope sender in arg
+func (c *session) mail(arg string) error {
+	switch {
+	case !c.greeted:
+		return c.reply(503, "5.5.1 Say HELO first")
+	case c.from != "":
+		return c.reply(503, "5.5.1 Sender already given")
+	case c.delivered >= maxMessages:
+		return c.reply(452, "4.5.3 Too many messages, send the rest later")
+	}
+	from, params, ok := path(arg, "FROM:")
+	if !ok {
+		return c.reply(501, "5.5.4 Syntax: MAIL FROM:<address>")
+	}
+	for _, param := range strings.Fields(params) {
+		var size int64
+		if _, err := fmt.Sscanf(strings.ToUpper(param), "SIZE=%d", &size); err == nil && size > c.s.maxBytes {
+			return c.reply(552, "5.3.4 Message is larger than %d bytes", c.s.maxBytes)
+		}
+	}
+	// Bounces have an empty sender; like any mail, they are refused unless
+	// addressed with a member's key
+	c.from = from
+	if c.from == "" {
+		c.from = "<>"
+	}
+	return c.reply(250, "2.1.0 OK")
+}
+
+// rcpt adds a caller to the message
+func (c *session) rcpt(arg string) error {
+	if c.from == "" {
+		return c.reply(503, "5.5.1 Send MAIL first")
+	}
+	if len(c.to) >= maxRecipients {
+		return c.reply(452, "4.5.3 Too many recipients")
+	}
+	addr, _, ok := path(arg, "TO:")
+	at := strings.LastIndex(addr, "@")
+	if !ok || at <= 0 {
+		return c.reply(501, "5.5.4 Syntax: RCPT TO:<address>")
+	}
+	if !strings.EqualFold(addr[at+1:], c.s.cfg.Domain) {
+		return c.reply(550, "5.7.1 Mail for %s isn't taken here", addr[at+1:])
+	}
+	local := strings.Trim(addr[:at], `"`)
+	plus := strings.LastIndex(local, "+")
+	if plus <= 0 {
+		return c.reply(550, "5.7.1 Write to %s+yourkey@%s, as your digest says", local, c.s.cfg.Domain)
+	}
+	sender, err := c.s.db.GetUserByMailKey(local[plus+1:])
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.reply(550, "5.7.1 No member of %s has that key", c.s.system)
+	}
+	if err != nil {
+		log.Printf("Mail gateway: %v", err)
+		return c.reply(451, "4.3.0 Couldn't check the address, try again later")
+	}
+	if c.sender != "" && sender.Username != c.sender {
+		return c.reply(452, "4.5.3 Send mail from one member at a time")
+	}
+	user, err := c.s.db.GetUser(local[:plus])
+	if err != nil {
+		return c.reply(550, "5.1.1 No caller named %s here", local[:plus])
+	}
+	c.sender = sender.Username
+	c.to = append(c.to, user.Username)
+	return c.reply(250, "2.1.5 OK")
+}
+
+// data reads the message and delivers it
+func (c *session) data() error {
+	if len(c.to) == 0 {
+		return c.reply(503, "5.5.1 Send RCPT first")
+	}
+	if err := c.reply(354, "End with <CRLF>.<CRLF>"); err != nil {
+		return err
+	}
+
+	c.conn.SetDeadline(time.Now().Add(dataTimeout))
+	dot := c.r.DotReader()
+	raw, err := io.ReadAll(io.LimitReader(dot, c.s.maxBytes+1))
+	if err == nil && int64(len(raw)) > c.s.maxBytes {
+		_, err = io.Copy(io.Discard, dot)
+		if err == nil {
+			c.reset()
+			return c.reply(552, "5.3.4 Message is larger than %d bytes", c.s.maxBytes)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	sender, to := c.sender, c.to
+	c.reset()
+	if err := c.s.deliver(raw, sender, to); err != nil {
+		var refused *refused
+		if errors.As(err, &refused) {
+			return c.reply(550, "5.7.1 %s", refused.reason)
+		}
+		log.Printf("Mail gateway: %v", err)
+		return c.reply(451, "4.3.0 Couldn't deliver the message, try again later")
+	}
+	c.delivered++
+	return c.reply(250, "2.0.0 Delivered")
+}
+
+// path reads the address in angle brackets after prefix, as MAIL and RCPT
+// give it, and the parameters after it
+func path(arg, prefix string) (addr, params string, ok bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", "", false
+	}
+	rest := strings.TrimSpace(arg[len(prefix):])
+	end := strings.Index(rest, ">")
+	if !strings.HasPrefix(rest, "<") || end < 0 {
+		return "", "", false
+	}
+	return rest[1:end], strings.TrimSpace(rest[end+1:]), true
+}
+
+// refused explains why a message won't be delivered. Sending it again
+// won't help.
+type refused struct {
+	reason string
+}
+
+func (r *refused) Error() string {
+	return r.reason
+}
+
+// deliver stores an email as private mail from sender to each of the
+// callers in to, and tells them about it
+func (s *Server) deliver(raw []byte, sender string, to []string) error {
+	msg, err := mailimport.Parse(raw)
+	if err != nil {
+		return &refused{reason: "Message can't be read"}
+	}
+	if strings.TrimSpace(msg.Body) == "" {
+		return &refused{reason: "Message has no plain text"}
+	}
+
+	for _, username := range to {
+		m := &database.Message{
+			FromUser: sender,
+			ToUser:   username,
+			Subject:  msg.Subject,
+			Body:     msg.Body,
+			Area:     "private",
+		}
+		if err := s.db.CreateMessage(m); err != nil {
+			return fmt.Errorf("failed to deliver mail from %s to %s: %w", sender, username, err)
+		}
+		log.Printf("Mail gateway: delivered mail from %s to %s", sender, username)
+		if s.notify != nil {
+			s.notify(username, fmt.Sprintf("New mail from %s: %s", sender, msg.Subject))
+		}
+	}
+	return nil
+}
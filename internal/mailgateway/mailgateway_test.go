@@ -0,0 +1,157 @@
+package mailgateway
+
+import (
+	"net"
+	"net/smtp"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+type notice struct{ to, text string }
+
+func newTestServer(t *testing.T) (*Server, *database.DB, *[]notice) {
+	t.Helper()
+	db, err := database.Initialize(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, u := range []*database.User{
+		{Username: "alice", Password: "x", Email: "alice@example.org"},
+		{Username: "bob", Password: "x"},
+		{Username: "test test", Password: "x"},
+	} {
+		if err := db.CreateUser(u); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+
+	cfg := &config.Config{}
+	cfg.BBS.SystemName = "Test BBS"
+	cfg.Server.MailGateway = config.MailGatewayConfig{Domain: "bbs.example.org", MaxKB: 1}
+	var notices []notice
+	s := NewServer(cfg, db, func(to, text string) { notices = append(notices, notice{to, text}) })
+	return s, db, &notices
+}
+
+// mailKey returns username's mail key
+func mailKey(t *testing.T, db *database.DB, username string) string {
+	t.Helper()
+	user, err := db.GetUser(username)
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	key, err := db.MailKey(user.ID)
+	if err != nil {
+		t.Fatalf("MailKey failed: %v", err)
+	}
+	return key
+}
+
+// start serves SMTP on a local port and returns its address
+func start(t *testing.T, s *Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go s.Serve(listener)
+	return listener.Addr().String()
+}
+
+func TestSendMail(t *testing.T) {
+	s, db, notices := newTestServer(t)
+	addr := start(t, s)
+
+	// The From header doesn't matter; the key in the address says who wrote
+	key := mailKey(t, db, "alice")
+	msg := "From: Someone <someone@example.net>\r\nTo: bob@bbs.example.org\r\nSubject: Re: Lunch\r\n\r\nSee you at noon.\r\n.dotted line\r\n"
+	to := []string{"bob+" + key + "@bbs.example.org", `"test test+` + strings.ToUpper(key) + `"@BBS.example.org`}
+	if err := smtp.SendMail(addr, nil, "someone@example.net", to, []byte(msg)); err != nil {
+		t.Fatalf("SendMail failed: %v", err)
+	}
+
+	for _, name := range []string{"bob", "test test"} {
+		mail, err := db.GetMessages(name, 10)
+		if err != nil || len(mail) != 1 {
+			t.Fatalf("%s's mail = %+v, %v", name, mail, err)
+		}
+		got := mail[0]
+		if got.FromUser != "alice" || got.Subject != "Re: Lunch" || got.Body != "See you at noon.\n.dotted line" || got.Area != "private" {
+			t.Errorf("%s got %+v", name, got)
+		}
+	}
+	if len(*notices) != 2 || (*notices)[0] != (notice{"bob", "New mail from alice: Re: Lunch"}) {
+		t.Errorf("notices = %+v", *notices)
+	}
+}
+
+func TestRefused(t *testing.T) {
+	s, db, notices := newTestServer(t)
+	addr := start(t, s)
+	key := mailKey(t, db, "alice")
+	bob := "bob+" + key + "@bbs.example.org"
+
+	tests := []struct {
+		name string
+		to   string
+		msg  string
+		want string
+	}{
+		{"unknown caller", "carol+" + key + "@bbs.example.org", "From: alice@example.org\r\n\r\nHi\r\n", "No caller named carol"},
+		{"other domain", "bob+" + key + "@example.com", "From: alice@example.org\r\n\r\nHi\r\n", "550"},
+		{"no key", "bob@bbs.example.org", "From: alice@example.org\r\n\r\nHi\r\n", "as your digest says"},
+		{"wrong key", "bob+" + key + "x@bbs.example.org", "From: alice@example.org\r\n\r\nHi\r\n", "has that key"},
+		{"no plain text", bob, "From: alice@example.org\r\nContent-Type: text/html\r\n\r\n<p>Hi</p>\r\n", "no plain text"},
+		{"too large", bob, "From: alice@example.org\r\n\r\n" + strings.Repeat("x", 2000) + "\r\n", "552"},
+	}
+	for _, tt := range tests {
+		err := smtp.SendMail(addr, nil, "alice@example.org", []string{tt.to}, []byte(tt.msg))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	if mail, _ := db.GetMessages("bob", 10); len(mail) != 0 {
+		t.Errorf("refused mail was delivered: %+v", mail)
+	}
+	if len(*notices) != 0 {
+		t.Errorf("notices for refused mail: %+v", *notices)
+	}
+}
+
+func TestOneSender(t *testing.T) {
+	s, db, _ := newTestServer(t)
+	addr := start(t, s)
+
+	to := []string{"bob+" + mailKey(t, db, "alice") + "@bbs.example.org", "bob+" + mailKey(t, db, "test test") + "@bbs.example.org"}
+	err := smtp.SendMail(addr, nil, "alice@example.org", to, []byte("Subject: Hi\r\n\r\nHi\r\n"))
+	if err == nil || !strings.Contains(err.Error(), "one member at a time") {
+		t.Errorf("err = %v, want mail from two members refused", err)
+	}
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		arg, addr, params string
+		ok                bool
+	}{
+		{"FROM:<a@b.c>", "a@b.c", "", true},
+		{"from: <a@b.c> SIZE=100 BODY=8BITMIME", "a@b.c", "SIZE=100 BODY=8BITMIME", true},
+		{"FROM:<>", "", "", true},
+		{"FROM:a@b.c", "", "", false},
+		{"TO:<a@b.c>", "", "", false},
+	}
+	for _, tt := range tests {
+		addr, params, ok := path(tt.arg, "FROM:")
+		if addr != tt.addr || params != tt.params || ok != tt.ok {
+			t.Errorf("path(%q) = %q, %q, %v", tt.arg, addr, params, ok)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package mailimport brings mailing list archives onto the message boards.
+// Messages from an mbox file or Maildir become posts in a topic, with the
+// answers to each one threaded under it as replies, so a community moving
+// from a list keeps its history.
+package mailimport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"bbs/internal/database"
+)
+
+// Result counts what an import did
+type Result struct {
+	Posts      int // Messages that started a new post
+	Replies    int // Messages threaded under an earlier one
+	Duplicates int // Messages imported before, left alone
+}
+
+// Importer adds archived messages to one topic
+type Importer struct {
+	db     *database.DB
+	topic  *database.Topic
+	author string
+}
+
+// NewImporter creates an importer for a topic. Imported messages are
+// credited to author, a disabled account created if it doesn't exist, with
+// each original sender's name at the top of the message.
+func NewImporter(db *database.DB, topic *database.Topic, author string) (*Importer, error) {
+	if err := db.EnsurePlaceholderUser(author, "Mailing list archive"); err != nil {
+		return nil, err
+	}
+	return &Importer{db: db, topic: topic, author: author}, nil
+}
+
+// Import adds the messages oldest first, so each answer arrives after the
+// message it answers. Messages already on the board are skipped, so an
+// archive can be imported again as it grows.
+func (im *Importer) Import(messages []Message) (Result, error) {
+	var result Result
+
+	sorted := append([]Message(nil), messages...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	for _, msg := range sorted {
+		existing, err := im.db.GetImportedMessage(msg.ID)
+		if err != nil {
+			return result, err
+		}
+		if existing != nil {
+			result.Duplicates++
+			continue
+		}
+
+		body := fmt.Sprintf("From: %s\n\n%s", msg.From, msg.Body)
+
+		parent, err := im.parent(msg)
+		if err != nil {
+			return result, err
+		}
+		if parent != nil {
+			reply := &database.Reply{PostID: parent.PostID, ParentID: parent.ReplyID, Author: im.author, Body: body, CreatedAt: msg.Date}
+			if err := im.db.ImportReply(reply, msg.ID); err != nil {
+				return result, err
+			}
+			result.Replies++
+			continue
+		}
+
+		post := &database.Post{TopicID: im.topic.ID, Author: im.author, Subject: msg.Subject, Body: body, CreatedAt: msg.Date}
+		if err := im.db.ImportPost(post, msg.ID); err != nil {
+			return result, err
+		}
+		result.Posts++
+	}
+	return result, nil
+}
+
+// parent finds the message a message answers among those already imported
+// into this topic and not archived, trying In-Reply-To and then References
+// from the newest. It returns nil for a message that starts a thread.
+func (im *Importer) parent(msg Message) (*database.ImportedMessage, error) {
+	candidates := []string{msg.InReplyTo}
+	for i := len(msg.References) - 1; i >= 0; i-- {
+		candidates = append(candidates, msg.References[i])
+	}
+
+	for _, id := range candidates {
+		if id == "" || id == msg.ID {
+			continue
+		}
+		found, err := im.db.GetImportedMessage(id)
+		if err != nil {
+			return nil, err
+		}
+		if found == nil {
+			continue
+		}
+		post, err := im.db.GetPost(found.PostID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load post %d: %w", found.PostID, err)
+		}
+		if post.TopicID == im.topic.ID && !post.Archived {
+			return found, nil
+		}
+	}
+	return nil, nil
+}
+
+// FindTopic returns the topic with the given name, ignoring case, or nil
+// when there isn't one
+func FindTopic(db *database.DB, name string) (*database.Topic, error) {
+	topics, err := db.GetTopics(255)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load topics: %w", err)
+	}
+	for i := range topics {
+		if strings.EqualFold(topics[i].Name, name) {
+			return &topics[i], nil
+		}
+	}
+	return nil, nil
+}
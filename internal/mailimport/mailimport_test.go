@@ -0,0 +1,195 @@
+package mailimport
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bbs/internal/database"
+)
+
+const archive = `From alice@example.com Mon Jan  2 15:04:05 2006
+From: Alice Example <alice@example.com>
+Subject: =?utf-8?q?Caf=C3=A9_meetup?=
+Date: Mon, 02 Jan 2006 15:04:05 +0000
+Message-ID: <1@example.com>
+
+Meet at the cafe.
+>From the organisers: bring a modem.
+
+From bob@example.com Tue Jan  3 09:00:00 2006
+From: bob@example.com
+Subject: Re: Cafe meetup
+Message-ID: <2@example.com>
+In-Reply-To: <1@example.com>
+Content-Type: text/plain; charset=iso-8859-1
+Content-Transfer-Encoding: quoted-printable
+
+I'll be there. Gr=FC=DFe!
+
+From carol@example.com Wed Jan  4 10:00:00 2006
+From: "Carol" <carol@example.com>
+Subject: Re: Cafe meetup
+Date: Wed, 04 Jan 2006 10:00:00 +0000
+Message-ID: <3@example.com>
+References: <1@example.com> <2@example.com>
+MIME-Version: 1.0
+Content-Type: multipart/alternative; boundary="b1"
+
+--b1
+Content-Type: text/html
+
+<p>HTML copy</p>
+--b1
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: base64
+
+U2VlIHlvdSBib3RoLg==
+--b1--
+`
+
+func TestReadMbox(t *testing.T) {
+	messages, err := ReadMbox(strings.NewReader(archive))
+	if err != nil {
+		t.Fatalf("ReadMbox failed: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("read %d messages, want 3", len(messages))
+	}
+
+	alice := messages[0]
+	if alice.ID != "1@example.com" || alice.From != "Alice Example" || alice.Subject != "Café meetup" {
+		t.Errorf("first message = %+v", alice)
+	}
+	if alice.Body != "Meet at the cafe.\nFrom the organisers: bring a modem." {
+		t.Errorf("escaped From line not restored: %q", alice.Body)
+	}
+
+	bob := messages[1]
+	if bob.From != "bob" {
+		t.Errorf("sender without a name = %q, want the mailbox without the domain", bob.From)
+	}
+	if want := time.Date(2006, 1, 3, 9, 0, 0, 0, time.UTC); !bob.Date.Equal(want) {
+		t.Errorf("message without a Date = %v, want the envelope date %v", bob.Date, want)
+	}
+	if bob.InReplyTo != "1@example.com" || bob.Body != "I'll be there. Grüße!" {
+		t.Errorf("second message = %+v", bob)
+	}
+
+	carol := messages[2]
+	if carol.Body != "See you both." {
+		t.Errorf("multipart body = %q, want the plain text part", carol.Body)
+	}
+	if strings.Join(carol.References, " ") != "1@example.com 2@example.com" {
+		t.Errorf("references = %q", carol.References)
+	}
+}
+
+func TestReadMaildir(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cur/1.host:2,S", "From: a@example.com\nSubject: One\nMessage-ID: <a@x>\n\nFirst\n")
+	write("new/2.host", "From: b@example.com\nSubject: Two\n\nSecond\n")
+	write("tmp/3.host", "From: c@example.com\nSubject: Half delivered\n\nThird\n")
+
+	messages, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("read %d messages, want the two in cur and new", len(messages))
+	}
+	if !strings.HasPrefix(messages[1].ID, "sha1:") {
+		t.Errorf("message without a Message-ID got ID %q, want one made from its content", messages[1].ID)
+	}
+
+	if _, err := ReadMaildir(t.TempDir()); err == nil {
+		t.Error("an empty directory was read as a Maildir")
+	}
+}
+
+func TestImport(t *testing.T) {
+	db, err := database.Initialize(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer db.Close()
+
+	topic := &database.Topic{Name: "Cafe List"}
+	if err := db.CreateTopic(topic); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	found, err := FindTopic(db, "cafe list")
+	if err != nil || found == nil || found.ID != topic.ID {
+		t.Fatalf("FindTopic = %+v, %v", found, err)
+	}
+
+	importer, err := NewImporter(db, topic, "archive")
+	if err != nil {
+		t.Fatalf("NewImporter failed: %v", err)
+	}
+	messages, err := ReadMbox(strings.NewReader(archive))
+	if err != nil {
+		t.Fatalf("ReadMbox failed: %v", err)
+	}
+
+	// Out of order, to check the answers still find their messages
+	reversed := []Message{messages[2], messages[1], messages[0]}
+	result, err := importer.Import(reversed)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Posts != 1 || result.Replies != 2 {
+		t.Errorf("imported %d posts and %d replies, want 1 and 2", result.Posts, result.Replies)
+	}
+
+	posts, err := db.GetPosts(topic.ID, 10)
+	if err != nil || len(posts) != 1 {
+		t.Fatalf("GetPosts = %d posts, %v", len(posts), err)
+	}
+	post := posts[0]
+	if post.Author != "archive" || !strings.HasPrefix(post.Body, "From: Alice Example\n\n") {
+		t.Errorf("post = %+v, want it credited to the placeholder with the sender on top", post)
+	}
+	if !post.CreatedAt.Equal(messages[0].Date) {
+		t.Errorf("post dated %v, want the date it was sent, %v", post.CreatedAt, messages[0].Date)
+	}
+
+	thread := database.Thread(mustReplies(t, db, post.ID))
+	if len(thread) != 2 || thread[1].Depth != 1 || !strings.Contains(thread[1].Body, "See you both.") {
+		t.Errorf("thread = %+v, want carol answering bob", thread)
+	}
+
+	if _, err := db.VerifyPassword("archive", ""); err == nil {
+		t.Error("placeholder account can log in")
+	}
+
+	// A second run adds nothing
+	result, err = importer.Import(messages)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Duplicates != 3 || result.Posts+result.Replies != 0 {
+		t.Errorf("second import = %+v, want all three skipped", result)
+	}
+}
+
+func mustReplies(t *testing.T, db *database.DB, postID int) []database.Reply {
+	t.Helper()
+	replies, err := db.GetReplies(postID)
+	if err != nil {
+		t.Fatalf("GetReplies failed: %v", err)
+	}
+	return replies
+}
@@ -0,0 +1,325 @@
+package mailimport
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// Message is one message read from an archive
+type Message struct {
+	ID         string   // Message-ID without the angle brackets
+	InReplyTo  string   // Message-ID of the message answered, if any
+	References []string // Earlier messages in the thread, oldest first
+	From       string   // Sender's name, or the mailbox part of their address
+	Subject    string
+	Date       time.Time
+	Body       string
+}
+
+// Read loads the messages in an mbox file or a Maildir directory
+func Read(path string) ([]Message, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return ReadMaildir(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadMbox(f)
+}
+
+// ReadMbox reads the messages in an mbox file. Each message starts with a
+// "From " line at the top of the file or after a blank line, and body
+// lines escaped as ">From " are restored.
+func ReadMbox(r io.Reader) ([]Message, error) {
+	var messages []Message
+	var raw bytes.Buffer
+	var sent time.Time
+	started, blank := false, true
+
+	flush := func() error {
+		if !started {
+			return nil
+		}
+		msg, err := parse(raw.Bytes(), sent)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, msg)
+		raw.Reset()
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if blank && strings.HasPrefix(line, "From ") {
+			if err := flush(); err != nil {
+				return nil, fmt.Errorf("message %d: %w", len(messages)+1, err)
+			}
+			started = true
+			sent = envelopeDate(line)
+			blank = false
+			continue
+		}
+		blank = line == ""
+		if !started {
+			continue
+		}
+		if trimmed := strings.TrimLeft(line, ">"); trimmed != line && strings.HasPrefix(trimmed, "From ") {
+			line = line[1:]
+		}
+		raw.WriteString(line)
+		raw.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, fmt.Errorf("message %d: %w", len(messages)+1, err)
+	}
+	return messages, nil
+}
+
+// ReadMaildir reads the messages in a Maildir's cur and new folders
+func ReadMaildir(dir string) ([]Message, error) {
+	var paths []string
+	for _, sub := range []string{"cur", "new"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+				paths = append(paths, filepath.Join(dir, sub, entry.Name()))
+			}
+		}
+	}
+	if paths == nil {
+		return nil, fmt.Errorf("%s is not a Maildir: it has no cur or new folder", dir)
+	}
+	sort.Strings(paths)
+
+	var messages []Message
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// Delivery time is the best guess for a message without a Date
+		var sent time.Time
+		if info, err := os.Stat(path); err == nil {
+			sent = info.ModTime()
+		}
+		msg, err := parse(raw, sent)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// envelopeDate reads the date from an mbox "From " line, such as
+// "From alice@example.com Mon Jan  2 15:04:05 2006"
+func envelopeDate(line string) time.Time {
+	fields := strings.Fields(line)
+	if len(fields) < 7 {
+		return time.Time{}
+	}
+	date, err := time.Parse(time.ANSIC, strings.Join(fields[2:7], " "))
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
+
+// decoder decodes encoded words in headers in any charset the board knows
+var decoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Parse reads one message, such as one delivered by mail. Multipart
+// messages give their plain text, so HTML copies and attachments are left
+// out.
+func Parse(raw []byte) (Message, error) {
+	return parse(raw, time.Now())
+}
+
+// parse reads one message. sent is used when it has no readable Date.
+func parse(raw []byte, sent time.Time) (Message, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return Message{}, err
+	}
+
+	msg := Message{
+		ID:         firstID(m.Header.Get("Message-Id")),
+		InReplyTo:  firstID(m.Header.Get("In-Reply-To")),
+		References: ids(m.Header.Get("References")),
+		From:       sender(m.Header.Get("From")),
+		Date:       sent,
+	}
+	// Archives without Message-IDs are still only imported once
+	if msg.ID == "" {
+		sum := sha1.Sum(raw)
+		msg.ID = "sha1:" + hex.EncodeToString(sum[:])
+	}
+	if date, err := m.Header.Date(); err == nil {
+		msg.Date = date
+	}
+	if msg.Date.IsZero() {
+		msg.Date = time.Now()
+	}
+
+	subject, err := decoder.DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		subject = m.Header.Get("Subject")
+	}
+	msg.Subject = strings.Join(strings.Fields(subject), " ")
+	if msg.Subject == "" {
+		msg.Subject = "(no subject)"
+	}
+
+	body, err := text(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), m.Body)
+	if err != nil {
+		return Message{}, err
+	}
+	msg.Body = body
+	return msg, nil
+}
+
+// text returns the plain text of a message or part. Multipart messages
+// give the first plain text part found, so HTML copies and attachments are
+// left out.
+func text(contentType, encoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		parts := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := parts.NextPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", fmt.Errorf("reading %s: %w", mediaType, err)
+			}
+			found, err := text(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", err
+			}
+			if found != "" {
+				return found, nil
+			}
+		}
+	}
+	if mediaType != "text/plain" {
+		return "", nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	if charset := params["charset"]; charset != "" {
+		if decoded, err := charsetReader(charset, body); err == nil {
+			body = decoded
+		}
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("reading body: %w", err)
+	}
+	s := strings.ToValidUTF8(strings.ReplaceAll(string(data), "\r\n", "\n"), "?")
+	return strings.TrimRight(s, " \t\n"), nil
+}
+
+// charsetReader converts text in a named charset to UTF-8
+func charsetReader(charset string, r io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii":
+		return r, nil
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Reader(r), nil
+}
+
+// sender returns the name to credit a message to. Addresses are left off
+// so the board doesn't publish them.
+func sender(from string) string {
+	parser := mail.AddressParser{WordDecoder: decoder}
+	addr, err := parser.Parse(from)
+	if err != nil {
+		if decoded, err := decoder.DecodeHeader(from); err == nil {
+			from = decoded
+		}
+		from = strings.TrimSpace(from)
+		if at := strings.Index(from, "@"); at > 0 && !strings.Contains(from, " ") {
+			from = from[:at]
+		}
+		if from == "" {
+			return "Unknown"
+		}
+		return from
+	}
+	if name := strings.TrimSpace(addr.Name); name != "" {
+		return name
+	}
+	if at := strings.Index(addr.Address, "@"); at > 0 {
+		return addr.Address[:at]
+	}
+	return addr.Address
+}
+
+// ids returns the Message-IDs in a References or In-Reply-To header
+func ids(header string) []string {
+	var found []string
+	for _, field := range strings.Fields(strings.ReplaceAll(header, "><", "> <")) {
+		if id := strings.Trim(field, "<>,"); strings.HasPrefix(field, "<") && id != "" {
+			found = append(found, id)
+		}
+	}
+	return found
+}
+
+// firstID returns the first Message-ID in a header, or the header itself
+// when the sender left off the angle brackets
+func firstID(header string) string {
+	if found := ids(header); len(found) > 0 {
+		return found[0]
+	}
+	return strings.TrimSpace(header)
+}
@@ -0,0 +1,38 @@
+package menu
+
+import "bbs/internal/input"
+
+// ListRows is how many items of a menu fit on the caller's screen between
+// the title and borders above and the instructions and status bar below
+func ListRows(writer Writer) int {
+	height := 24
+	if sizer, ok := writer.(interface{ Size() (int, int, error) }); ok {
+		if _, h, err := sizer.Size(); err == nil && h > 0 {
+			height = h
+		}
+	}
+	if rows := height - 7; rows > 5 {
+		return rows
+	}
+	return 5
+}
+
+// Jump returns where Page Up, Page Down, Home or End moves the selection in
+// a list of count items shown rows at a time. Page Up and Page Down move a
+// screen and stop at the ends; any other key leaves the selection alone.
+func Jump(key string, selected, count, rows int) int {
+	if count == 0 {
+		return selected
+	}
+	switch key {
+	case input.PageUp:
+		selected -= rows
+	case input.PageDown:
+		selected += rows
+	case input.Home:
+		selected = 0
+	case input.End:
+		selected = count - 1
+	}
+	return max(min(selected, count-1), 0)
+}
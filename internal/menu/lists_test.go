@@ -0,0 +1,51 @@
+package menu
+
+import (
+	"bytes"
+	"testing"
+)
+
+// sizedWriter is a terminal of a given height
+type sizedWriter struct {
+	bytes.Buffer
+	height int
+}
+
+func (w *sizedWriter) Size() (int, int, error) {
+	return 80, w.height, nil
+}
+
+func TestListRows(t *testing.T) {
+	if got := ListRows(&bytes.Buffer{}); got != 17 {
+		t.Errorf("ListRows without a size = %d, want 17", got)
+	}
+	if got := ListRows(&sizedWriter{height: 50}); got != 43 {
+		t.Errorf("ListRows on 50 lines = %d, want 43", got)
+	}
+	if got := ListRows(&sizedWriter{height: 8}); got != 5 {
+		t.Errorf("ListRows on 8 lines = %d, want 5", got)
+	}
+}
+
+func TestJump(t *testing.T) {
+	tests := []struct {
+		key      string
+		selected int
+		count    int
+		want     int
+	}{
+		{"pagedown", 0, 30, 10},
+		{"pagedown", 25, 30, 29},
+		{"pageup", 15, 30, 5},
+		{"pageup", 3, 30, 0},
+		{"home", 17, 30, 0},
+		{"end", 2, 30, 29},
+		{"down", 2, 30, 2},
+		{"end", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := Jump(tt.key, tt.selected, tt.count, 10); got != tt.want {
+			t.Errorf("Jump(%q, %d, %d) = %d, want %d", tt.key, tt.selected, tt.count, got, tt.want)
+		}
+	}
+}
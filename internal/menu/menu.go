@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"bbs/internal/ansi"
 	"bbs/internal/config"
+	"bbs/internal/draw"
 	"bbs/internal/modules"
 )
 
@@ -14,7 +16,7 @@ type ColorScheme interface {
 	ColorizeWithBg(text, fgColor, bgColor string) string
 	CenterText(text string, terminalWidth int) string
 	DrawSeparator(width int, char string) string
-	CreateBorderPattern(width int, pattern string) string
+	DrawBox(box draw.Box) []string
 	HighlightSelection(text string, selected bool, maxWidth int) string
 	StripAnsiCodes(text string) string
 }
@@ -41,6 +43,7 @@ type MenuRenderer struct {
 	colorScheme   ColorScheme
 	writer        Writer
 	terminalWidth int
+	hotkeysOnly   bool // Config menus have no lightbar and are chosen by hotkey
 }
 
 // Screen control constants
@@ -60,14 +63,27 @@ func NewMenuRenderer(colorScheme ColorScheme, writer Writer) *MenuRenderer {
 	}
 }
 
+// SetHotkeysOnly turns the lightbar off on config menus, for callers who
+// choose items by their hotkeys alone
+func (r *MenuRenderer) SetHotkeysOnly(on bool) {
+	r.hotkeysOnly = on
+}
+
 // RenderConfigMenu displays a config-based menu with access level filtering
 func (r *MenuRenderer) RenderConfigMenu(menuItem *config.MenuItem, selectedIndex int, userAccessLevel int) {
 	// Create menu items from config, filtering by access level
 	var items []MenuItem
 	for _, item := range menuItem.Submenu {
 		if item.AccessLevel <= userAccessLevel {
-			// Process the description to highlight hotkeys
-			description := r.highlightHotkey(item.Description, item.Hotkey)
+			// Process the description, in the caller's language, to
+			// highlight hotkeys. A translation without the hotkey's letter
+			// names the key in front.
+			description := modules.T(r.writer, item.Description)
+			if description != item.Description && item.Hotkey != "" &&
+				!strings.Contains(strings.ToLower(description), strings.ToLower(item.Hotkey)) {
+				description = "(" + strings.ToUpper(item.Hotkey) + ") " + description
+			}
+			description = r.highlightHotkey(description, item.Hotkey)
 			items = append(items, MenuItem{
 				ID:          item.ID,
 				Description: description,
@@ -77,9 +93,9 @@ func (r *MenuRenderer) RenderConfigMenu(menuItem *config.MenuItem, selectedIndex
 	}
 
 	// Default instructions for config menus with hotkey info
-	instructions := "Navigate: ↑↓  Select: Enter  Hotkeys: Execute  Quit: Q"
+	instructions := "Navigate: ↑↓  Select: Enter  Hotkeys: Execute  Help: ?  Quit: Q"
 
-	r.renderMenu(menuItem.Title, items, selectedIndex, instructions)
+	r.renderMenu(menuItem.Title, items, selectedIndex, instructions, !r.hotkeysOnly)
 }
 
 // RenderModuleMenu displays a module-provided menu
@@ -88,36 +104,36 @@ func (r *MenuRenderer) RenderModuleMenu(provider MenuProvider, selectedIndex int
 	items := provider.GetMenuItems()
 	instructions := provider.GetInstructions()
 
-	r.renderMenu(title, items, selectedIndex, instructions)
+	r.renderMenu(title, items, selectedIndex, instructions, true)
 }
 
-// renderMenu is the unified rendering method
-func (r *MenuRenderer) renderMenu(title string, items []MenuItem, selectedIndex int, instructions string) {
+// updateWidth picks up the current terminal width when the writer can report it
+func (r *MenuRenderer) updateWidth() {
+	sizer, ok := r.writer.(interface{ Size() (int, int, error) })
+	if !ok {
+		return
+	}
+	if w, _, err := sizer.Size(); err == nil && w > 1 {
+		r.terminalWidth = w - 1
+	}
+}
+
+// renderMenu is the unified rendering method. Without a lightbar no item
+// is highlighted and the instructions leave out the arrow keys.
+func (r *MenuRenderer) renderMenu(title string, items []MenuItem, selectedIndex int, instructions string, lightbar bool) {
+	r.updateWidth()
+
 	// Clear content area only (respects scroll region) and hide cursor
 	r.writer.Write([]byte(ClearContentArea + HideCursor))
 
 	// Menu title with color and centering
-	coloredTitle := r.colorScheme.Colorize(title, "primary")
+	coloredTitle := r.colorScheme.Colorize(modules.T(r.writer, title), "primary")
 	centeredTitle := r.colorScheme.CenterText(coloredTitle, r.terminalWidth)
 	r.writer.Write([]byte(fmt.Sprintf("%s\n\n", centeredTitle)))
 
 	// Calculate maximum width needed for highlight bar
 	maxWidth := r.calculateMaxWidth(items)
 
-	// Calculate centering offset for menu items
-	centerOffset := (r.terminalWidth - maxWidth) / 2
-	if centerOffset < 0 {
-		centerOffset = 0
-	}
-
-	// Create decorative border pattern longer than menu options
-	borderPattern := r.colorScheme.CreateBorderPattern(maxWidth+8, "-=")
-	borderCenterPadding := strings.Repeat(" ", (r.terminalWidth-(maxWidth+8))/2)
-	menuCenterPadding := strings.Repeat(" ", centerOffset)
-
-	// Top border (centered under title)
-	r.writer.Write([]byte(borderCenterPadding + borderPattern + "\n"))
-
 	// Ensure selected index is valid
 	if selectedIndex >= len(items) {
 		selectedIndex = 0
@@ -126,65 +142,82 @@ func (r *MenuRenderer) renderMenu(title string, items []MenuItem, selectedIndex
 		selectedIndex = len(items) - 1
 	}
 
-	// Display menu items with highlighting and centering
-	for i, item := range items {
-		selected := (i == selectedIndex)
-		menuLine := r.colorScheme.HighlightSelection(item.Description, selected, maxWidth)
-		r.writer.Write([]byte(menuCenterPadding + menuLine + "\n"))
+	// A menu longer than the screen shows the screenful the selection is on
+	first, last := 0, len(items)
+	rows := ListRows(r.writer)
+	paged := len(items) > rows
+	if paged {
+		first = selectedIndex / rows * rows
+		last = min(first+rows, len(items))
 	}
 
-	// Bottom border (centered under title)
-	r.writer.Write([]byte(borderCenterPadding + borderPattern + "\n"))
+	// Frame the items in a box a little wider than the highlight bar,
+	// centered under the title
+	lines := make([]string, 0, last-first)
+	for i := first; i < last; i++ {
+		selected := lightbar && i == selectedIndex
+		lines = append(lines, r.colorScheme.HighlightSelection(items[i].Description, selected, maxWidth))
+	}
+	box := r.colorScheme.DrawBox(draw.Box{Lines: lines, Width: maxWidth + 8, Center: true})
+	boxPadding := strings.Repeat(" ", max((r.terminalWidth-(maxWidth+8))/2, 0))
+	for _, row := range box {
+		r.writer.Write([]byte(boxPadding + row + "\n"))
+	}
 
 	// Instructions with proper color styling
-	r.renderInstructions(instructions)
+	r.renderInstructions(instructions, lightbar, paged)
 }
 
-// renderInstructions displays formatted instructions
-func (r *MenuRenderer) renderInstructions(instructionText string) {
-	// Build the plain text version first to calculate proper centering
-	plainInstructions := "Navigate: ↑↓  Select: Enter"
+// renderInstructions displays formatted instructions, in the caller's
+// language. paged adds the paging keys for a menu longer than the screen.
+func (r *MenuRenderer) renderInstructions(instructionText string, lightbar, paged bool) {
+	type hint struct{ label, key string }
+	var hints []hint
+	if lightbar {
+		hints = append(hints, hint{"Navigate:", "↑↓"}, hint{"Select:", "Enter"})
+	}
+
+	// Add paging section for lists longer than a screen
+	if paged || strings.Contains(instructionText, "PgUp") {
+		hints = append(hints, hint{"Page:", "PgUp/PgDn"})
+	}
 
 	// Add hotkeys section if mentioned in instructions
 	if strings.Contains(instructionText, "hotkey") || strings.Contains(instructionText, "Hotkeys") {
-		plainInstructions += "  Hotkeys: Execute"
+		hints = append(hints, hint{"Hotkeys:", "Execute"})
 	}
 
 	// Add read section if mentioned in instructions
 	if strings.Contains(instructionText, "read") || strings.Contains(instructionText, "Read") {
-		plainInstructions += "  Read: Enter"
+		hints = append(hints, hint{"Read:", "Enter"})
 	}
 
-	plainInstructions += "  Quit: Q"
-
-	// Calculate centering based on plain text
-	textLen := len(plainInstructions)
-	padding := (r.terminalWidth - textLen) / 2
-	if padding < 0 {
-		padding = 0
+	// Add help section if mentioned in instructions
+	if strings.Contains(instructionText, "Help") {
+		hints = append(hints, hint{"Help:", "?"})
 	}
 
-	// Now build the colored version
-	coloredInstructions := r.colorScheme.Colorize("Navigate: ", "text") +
-		r.colorScheme.Colorize("↑↓", "accent") +
-		r.colorScheme.Colorize("  Select: ", "text") +
-		r.colorScheme.Colorize("Enter", "accent")
-
-	// Add hotkeys section if mentioned in instructions
-	if strings.Contains(instructionText, "hotkey") || strings.Contains(instructionText, "Hotkeys") {
-		coloredInstructions += r.colorScheme.Colorize("  Hotkeys: ", "text") +
-			r.colorScheme.Colorize("Execute", "accent")
+	hints = append(hints, hint{"Quit:", "Q"})
+
+	// Build the plain text version alongside the colored one, so the
+	// centering is calculated without the color codes
+	plainInstructions := ""
+	coloredInstructions := ""
+	for i, h := range hints {
+		label := modules.T(r.writer, h.label) + " "
+		key := modules.T(r.writer, h.key)
+		if i > 0 {
+			label = "  " + label
+		}
+		plainInstructions += label + key
+		coloredInstructions += r.colorScheme.Colorize(label, "text") + r.colorScheme.Colorize(key, "accent")
 	}
 
-	// Add read section if mentioned in instructions
-	if strings.Contains(instructionText, "read") || strings.Contains(instructionText, "Read") {
-		coloredInstructions += r.colorScheme.Colorize("  Read: ", "text") +
-			r.colorScheme.Colorize("Enter", "accent")
+	padding := (r.terminalWidth - ansi.Width(plainInstructions)) / 2
+	if padding < 0 {
+		padding = 0
 	}
 
-	coloredInstructions += r.colorScheme.Colorize("  Quit: ", "text") +
-		r.colorScheme.Colorize("Q", "accent")
-
 	// Apply the padding calculated from plain text to the colored version
 	centeredInstructions := strings.Repeat(" ", padding) + coloredInstructions
 	r.writer.Write([]byte("\n" + centeredInstructions + "\n"))
@@ -194,9 +227,8 @@ func (r *MenuRenderer) renderInstructions(instructionText string) {
 func (r *MenuRenderer) calculateMaxWidth(items []MenuItem) int {
 	maxWidth := 0
 	for _, item := range items {
-		cleanDesc := r.colorScheme.StripAnsiCodes(item.Description)
-		if len(cleanDesc) > maxWidth {
-			maxWidth = len(cleanDesc)
+		if width := ansi.Width(item.Description); width > maxWidth {
+			maxWidth = width
 		}
 	}
 	// Add some padding but keep it reasonable
@@ -0,0 +1,144 @@
+// Package metrics counts how often each board command runs, how long the
+// board spends on it, and how quickly it answers each key the caller
+// presses, so sysops can find what makes the board feel slow
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Names keys are counted under when no command is running
+const (
+	Menu  = "menu"  // At the menus
+	Login = "login" // Before the caller has logged in
+)
+
+// DefaultSlow is the slow threshold when none is given
+const DefaultSlow = 500 * time.Millisecond
+
+// Command is what has been counted for one command since the server
+// started or the counts were reset
+type Command struct {
+	Name    string
+	Runs    int64
+	Errors  int64         // Runs that failed rather than returning normally
+	Total   time.Duration // Time from start to finish, waiting included
+	Busy    time.Duration // Time the board was working rather than waiting for keys
+	Keys    int64         // Keys answered
+	Slow    int64         // Keys answered more slowly than the slow threshold
+	MaxWait time.Duration // Longest a caller waited for the board to answer a key
+}
+
+// AvgBusy is the average time the board worked on one run
+func (c Command) AvgBusy() time.Duration {
+	if c.Runs == 0 {
+		return 0
+	}
+	return c.Busy / time.Duration(c.Runs)
+}
+
+// Registry collects counts for every command. A nil Registry counts
+// nothing.
+type Registry struct {
+	mu       sync.Mutex
+	slow     time.Duration
+	commands map[string]*Command
+	since    time.Time
+}
+
+// New creates a registry that counts keys answered in slow or longer as
+// slow, or in DefaultSlow if slow isn't positive
+func New(slow time.Duration) *Registry {
+	if slow <= 0 {
+		slow = DefaultSlow
+	}
+	return &Registry{slow: slow, commands: make(map[string]*Command), since: time.Now()}
+}
+
+// command returns the counts for name, creating them. r.mu must be held.
+func (r *Registry) command(name string) *Command {
+	c, ok := r.commands[name]
+	if !ok {
+		c = &Command{Name: name}
+		r.commands[name] = c
+	}
+	return c
+}
+
+// Run records one run of a command
+func (r *Registry) Run(name string, total, busy time.Duration, failed bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.command(name)
+	c.Runs++
+	if failed {
+		c.Errors++
+	}
+	c.Total += total
+	c.Busy += busy
+}
+
+// Key records how long the board took to answer a key while name was
+// running, and reports whether that was slow
+func (r *Registry) Key(name string, wait time.Duration) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.command(name)
+	c.Keys++
+	slow := wait >= r.slow
+	if slow {
+		c.Slow++
+	}
+	if wait > c.MaxWait {
+		c.MaxWait = wait
+	}
+	return slow
+}
+
+// Slow returns the threshold above which an answer counts as slow
+func (r *Registry) Slow() time.Duration {
+	if r == nil {
+		return 0
+	}
+	return r.slow
+}
+
+// Snapshot returns the counts for every command, those the board spent
+// longest on first, and when counting began
+func (r *Registry) Snapshot() ([]Command, time.Time) {
+	if r == nil {
+		return nil, time.Time{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]Command, 0, len(r.commands))
+	for _, c := range r.commands {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Busy != list[j].Busy {
+			return list[i].Busy > list[j].Busy
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list, r.since
+}
+
+// Reset clears the counts and starts counting again from now
+func (r *Registry) Reset() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.commands)
+	r.since = time.Now()
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	r := New(500 * time.Millisecond)
+	r.Run("messages", 30*time.Second, 200*time.Millisecond, false)
+	r.Run("messages", 10*time.Second, 100*time.Millisecond, true)
+	r.Run("bulletins", time.Second, 50*time.Millisecond, false)
+	if r.Key("messages", 100*time.Millisecond) {
+		t.Error("a quick answer counted as slow")
+	}
+	if !r.Key("messages", 700*time.Millisecond) {
+		t.Error("a slow answer wasn't counted as slow")
+	}
+	r.Key(Menu, 10*time.Millisecond)
+
+	list, _ := r.Snapshot()
+	if len(list) != 3 || list[0].Name != "messages" || list[1].Name != "bulletins" || list[2].Name != Menu {
+		t.Fatalf("snapshot order = %+v", list)
+	}
+	m := list[0]
+	if m.Runs != 2 || m.Errors != 1 || m.Total != 40*time.Second || m.Busy != 300*time.Millisecond {
+		t.Errorf("messages runs = %+v", m)
+	}
+	if m.Keys != 2 || m.Slow != 1 || m.MaxWait != 700*time.Millisecond {
+		t.Errorf("messages keys = %+v", m)
+	}
+	if got := m.AvgBusy(); got != 150*time.Millisecond {
+		t.Errorf("AvgBusy() = %v", got)
+	}
+
+	r.Reset()
+	if list, _ := r.Snapshot(); len(list) != 0 {
+		t.Errorf("counts left after Reset: %+v", list)
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var r *Registry
+	r.Run("messages", time.Second, time.Second, false)
+	if r.Key("messages", time.Minute) {
+		t.Error("a nil registry reported a slow key")
+	}
+	if list, _ := r.Snapshot(); list != nil {
+		t.Errorf("nil registry snapshot = %+v", list)
+	}
+}
@@ -0,0 +1,134 @@
+package mirror
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ftpFile is a file being read from an FTP server. Closing it waits for
+// the server to confirm the transfer finished and logs out.
+type ftpFile struct {
+	ctrl *textproto.Conn
+	data net.Conn
+}
+
+func (f *ftpFile) Read(p []byte) (int, error) {
+	return f.data.Read(p)
+}
+
+func (f *ftpFile) Close() error {
+	f.data.Close()
+	defer f.ctrl.Close()
+	// 226 says the whole file was sent; anything else means it was cut short
+	if _, _, err := f.ctrl.ReadResponse(2); err != nil {
+		return err
+	}
+	f.ctrl.Cmd("QUIT")
+	return nil
+}
+
+// ftpGet retrieves a file from an ftp:// URL in binary passive mode, logging
+// in anonymously unless the URL gives a user
+func ftpGet(u *url.URL, timeout time.Duration) (io.ReadCloser, error) {
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "21")
+	}
+	conn, err := net.DialTimeout("tcp", host, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	ctrl := textproto.NewConn(conn)
+
+	ok := false
+	defer func() {
+		if !ok {
+			ctrl.Close()
+		}
+	}()
+
+	if _, _, err := ctrl.ReadResponse(2); err != nil {
+		return nil, err
+	}
+
+	user, pass := "anonymous", "anonymous@"
+	if u.User != nil {
+		user = u.User.Username()
+		pass, _ = u.User.Password()
+	}
+	code, _, err := command(ctrl, 0, "USER %s", user)
+	if err != nil {
+		return nil, err
+	}
+	if code == 331 {
+		if _, _, err := command(ctrl, 2, "PASS %s", pass); err != nil {
+			return nil, err
+		}
+	} else if code/100 != 2 {
+		return nil, fmt.Errorf("login refused: %d", code)
+	}
+	if _, _, err := command(ctrl, 2, "TYPE I"); err != nil {
+		return nil, err
+	}
+
+	_, msg, err := command(ctrl, 2, "PASV")
+	if err != nil {
+		return nil, err
+	}
+	port, err := pasvPort(msg)
+	if err != nil {
+		return nil, err
+	}
+	// Connect to the address already in use rather than the one the server
+	// names, which is often wrong behind NAT
+	data, err := net.DialTimeout("tcp", net.JoinHostPort(u.Hostname(), strconv.Itoa(port)), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := command(ctrl, 1, "RETR %s", u.Path); err != nil {
+		data.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	ok = true
+	return &ftpFile{ctrl: ctrl, data: data}, nil
+}
+
+// command sends an FTP command and reads the reply, which must start with
+// the digit want unless want is 0
+func command(ctrl *textproto.Conn, want int, format string, args ...interface{}) (int, string, error) {
+	id, err := ctrl.Cmd(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+	ctrl.StartResponse(id)
+	defer ctrl.EndResponse(id)
+	return ctrl.ReadResponse(want)
+}
+
+// pasvPort reads the data port from a reply to PASV, such as
+// "Entering Passive Mode (192,168,1,2,19,137)"
+func pasvPort(msg string) (int, error) {
+	start, end := strings.Index(msg, "("), strings.LastIndex(msg, ")")
+	if start < 0 || end < start {
+		return 0, fmt.Errorf("unexpected reply to PASV: %s", msg)
+	}
+	fields := strings.Split(msg[start+1:end], ",")
+	if len(fields) != 6 {
+		return 0, fmt.Errorf("unexpected reply to PASV: %s", msg)
+	}
+	hi, err1 := strconv.Atoi(strings.TrimSpace(fields[4]))
+	lo, err2 := strconv.Atoi(strings.TrimSpace(fields[5]))
+	if err1 != nil || err2 != nil {
+		return 0, fmt.Errorf("unexpected reply to PASV: %s", msg)
+	}
+	return hi<<8 | lo, nil
+}
@@ -0,0 +1,391 @@
+// Package mirror keeps file areas in step with files published elsewhere,
+// such as retro software archives on HTTP and FTP sites. Every file is
+// checked against its SHA-256 sum before it is listed, files the source
+// changes are replaced and files it drops are removed. A bulletin can tell
+// callers what each run changed.
+package mirror
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+// timeout limits how long a source may take to answer
+const timeout = 60 * time.Second
+
+// Result lists what a run changed, as "area/file"
+type Result struct {
+	Added     []string
+	Updated   []string
+	Removed   []string
+	Unchanged int
+	Failed    []string // What couldn't be fetched, with the reason
+}
+
+// Changed reports whether the run added, replaced or removed any file
+func (r Result) Changed() bool {
+	return len(r.Added)+len(r.Updated)+len(r.Removed) > 0
+}
+
+// entry is a file a source offers
+type entry struct {
+	name   string
+	url    *url.URL
+	sha256 string
+}
+
+// Mirror fetches the configured sources into their file areas
+type Mirror struct {
+	db     *database.DB
+	cfg    config.MirrorConfig
+	dir    string
+	author string
+	client *http.Client
+	// maxBytes is the largest file fetched, so a source can't fill the disk
+	maxBytes int64
+}
+
+// NewMirror creates a mirror for the board's file areas
+func NewMirror(db *database.DB, cfg *config.Config) *Mirror {
+	maxMB := cfg.BBS.Files.Mirror.MaxMB
+	if maxMB <= 0 {
+		maxMB = 1024
+	}
+	return &Mirror{
+		db:     db,
+		cfg:    cfg.BBS.Files.Mirror,
+		dir:    cfg.Paths.Files,
+		author: "sysop",
+		// Large archives may take a while, so only the wait for an answer
+		// is limited, not the whole download
+		client: &http.Client{Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: timeout}).DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+		}},
+		maxBytes: int64(maxMB) << 20,
+	}
+}
+
+// Run checks every source once. A source that can't be reached is reported
+// and its area left as it was, so an outage never empties an area.
+func (m *Mirror) Run() (Result, error) {
+	var result Result
+
+	// Areas fed by more than one source are pruned against all of them
+	wanted := make(map[string]map[string]bool)
+	failed := make(map[string]bool)
+	var areas []string
+
+	for _, src := range m.cfg.Sources {
+		area := src.Area
+		if !validName(area) {
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: not a valid area name", area))
+			continue
+		}
+		if wanted[area] == nil {
+			wanted[area] = make(map[string]bool)
+			areas = append(areas, area)
+		}
+
+		entries, err := m.entries(src)
+		if err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", area, err))
+			failed[area] = true
+			continue
+		}
+		if err := os.MkdirAll(filepath.Join(m.dir, area), 0755); err != nil {
+			return result, fmt.Errorf("failed to create area %s: %w", area, err)
+		}
+
+		mirrored, err := m.db.GetMirroredFiles(area)
+		if err != nil {
+			return result, err
+		}
+		for _, e := range entries {
+			wanted[area][e.name] = true
+			if err := m.sync(area, e, mirrored, &result); err != nil {
+				result.Failed = append(result.Failed, fmt.Sprintf("%s/%s: %v", area, e.name, err))
+				failed[area] = true
+			}
+		}
+	}
+
+	for _, area := range areas {
+		if failed[area] {
+			continue
+		}
+		if err := m.prune(area, wanted[area], &result); err != nil {
+			return result, err
+		}
+	}
+
+	if m.cfg.Bulletins && result.Changed() {
+		bulletin := &database.Bulletin{Title: "File Areas Updated", Body: summary(result), Author: m.author}
+		if err := m.db.CreateBulletin(bulletin); err != nil {
+			return result, fmt.Errorf("failed to post bulletin: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// sync fetches one file unless the area already has the right copy
+func (m *Mirror) sync(area string, e entry, mirrored map[string]database.MirroredFile, result *Result) error {
+	target := filepath.Join(m.dir, area, e.name)
+	known, owned := mirrored[e.name]
+	_, statErr := os.Stat(target)
+	exists := statErr == nil
+
+	switch {
+	case owned && exists && known.SHA256 == e.sha256:
+		result.Unchanged++
+		return nil
+	case !owned && exists:
+		// A file the sysop put there is only taken over if it matches
+		sum, size, err := hashFile(target)
+		if err != nil {
+			return err
+		}
+		if sum != e.sha256 {
+			return fmt.Errorf("a different file by that name is already in the area")
+		}
+		result.Unchanged++
+		return m.db.SaveMirroredFile(&database.MirroredFile{Area: area, Filename: e.name, URL: e.url.String(), SHA256: sum, Bytes: size})
+	}
+
+	size, err := m.fetch(e, target)
+	if err != nil {
+		return err
+	}
+	if err := m.db.SaveMirroredFile(&database.MirroredFile{Area: area, Filename: e.name, URL: e.url.String(), SHA256: e.sha256, Bytes: size}); err != nil {
+		return err
+	}
+	if owned && exists {
+		result.Updated = append(result.Updated, area+"/"+e.name)
+	} else {
+		result.Added = append(result.Added, area+"/"+e.name)
+	}
+	return nil
+}
+
+// prune removes the files the mirror fetched that no source offers now
+func (m *Mirror) prune(area string, wanted map[string]bool, result *Result) error {
+	mirrored, err := m.db.GetMirroredFiles(area)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range mirrored {
+		if !wanted[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(m.dir, area, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s/%s: %w", area, name, err)
+		}
+		if err := m.db.DeleteMirroredFile(area, name); err != nil {
+			return err
+		}
+		result.Removed = append(result.Removed, area+"/"+name)
+	}
+	return nil
+}
+
+// fetch downloads a file into place, checking it against its sum first.
+// Nothing replaces the area's copy unless it matches.
+func (m *Mirror) fetch(e entry, target string) (int64, error) {
+	body, err := m.open(e.url)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	// Hidden while it downloads, so the area doesn't list a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".mirror-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(body, m.maxBytes+1))
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if size > m.maxBytes {
+		tmp.Close()
+		return 0, fmt.Errorf("larger than %d bytes", m.maxBytes)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := body.Close(); err != nil {
+		return 0, err
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != e.sha256 {
+		return 0, fmt.Errorf("checksum mismatch: got %s, want %s", sum, e.sha256)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// entries lists the files a source offers
+func (m *Mirror) entries(src config.MirrorSource) ([]entry, error) {
+	var entries []entry
+	if src.Manifest != "" {
+		base, err := url.Parse(src.Manifest)
+		if err != nil {
+			return nil, err
+		}
+		listed, err := m.readManifest(base)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", src.Manifest, err)
+		}
+		entries = append(entries, listed...)
+	}
+
+	for _, f := range src.Files {
+		u, err := url.Parse(f.URL)
+		if err != nil {
+			return nil, err
+		}
+		name := f.Name
+		if name == "" {
+			name = path.Base(u.Path)
+		}
+		sum := strings.ToLower(strings.TrimSpace(f.SHA256))
+		if !validName(name) || !validSum(sum) {
+			return nil, fmt.Errorf("%s needs a file name and a SHA-256 sum", f.URL)
+		}
+		entries = append(entries, entry{name: name, url: u, sha256: sum})
+	}
+	return entries, nil
+}
+
+// readManifest reads a list of sums in the form sha256sum writes,
+// "<sum>  <name>", or the BSD form "SHA256 (<name>) = <sum>". Files are
+// fetched from beside the list. Names with directories are skipped.
+func (m *Mirror) readManifest(base *url.URL) ([]entry, error) {
+	body, err := m.open(base)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var entries []entry
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		var sum, name string
+		if rest, ok := strings.CutPrefix(line, "SHA256 ("); ok {
+			name, sum, _ = strings.Cut(rest, ") = ")
+		} else if s, n, ok := strings.Cut(line, " "); ok {
+			sum, name = s, strings.TrimPrefix(strings.TrimSpace(n), "*")
+		}
+		sum = strings.ToLower(sum)
+		if !validSum(sum) || !validName(name) {
+			continue
+		}
+		u, err := base.Parse(url.PathEscape(name))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{name: name, url: u, sha256: sum})
+	}
+	return entries, scanner.Err()
+}
+
+// open starts reading a URL over HTTP, HTTPS or FTP
+func (m *Mirror) open(u *url.URL) (io.ReadCloser, error) {
+	switch u.Scheme {
+	case "http", "https":
+		resp, err := m.client.Get(u.String())
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s: %s", u, resp.Status)
+		}
+		return resp.Body, nil
+	case "ftp":
+		return ftpGet(u, timeout)
+	default:
+		return nil, fmt.Errorf("%s: unsupported scheme %q", u, u.Scheme)
+	}
+}
+
+// summary describes a run's changes for the bulletin
+func summary(r Result) string {
+	var b strings.Builder
+	b.WriteString("The file areas have been brought up to date with their sources.\n")
+	for _, group := range []struct {
+		label string
+		files []string
+	}{
+		{"New", r.Added},
+		{"Updated", r.Updated},
+		{"Removed", r.Removed},
+	} {
+		if len(group.files) > 0 {
+			fmt.Fprintf(&b, "\n%s: %s\n", group.label, strings.Join(group.files, ", "))
+		}
+	}
+	return b.String()
+}
+
+// hashFile returns the SHA-256 sum and size of a file
+func hashFile(name string) (string, int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// validName reports whether name is a plain file or area name that can't
+// reach outside the files directory or hide from listings
+func validName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\`) && filepath.Base(name) == name
+}
+
+// validSum reports whether s is a SHA-256 sum in lower-case hex
+func validSum(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
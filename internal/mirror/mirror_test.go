@@ -0,0 +1,297 @@
+package mirror
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/paths"
+)
+
+func sum(data string) string {
+	h := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(h[:])
+}
+
+// archive is a fake software archive serving a sha256sum list and its files
+type archive struct {
+	mu       sync.Mutex
+	files    map[string]string
+	manifest string // Served instead of the real sums when set
+}
+
+func (a *archive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	name := strings.TrimPrefix(r.URL.Path, "/pub/")
+	if name == "SHA256SUMS" {
+		if a.manifest != "" {
+			fmt.Fprint(w, a.manifest)
+			return
+		}
+		for file, data := range a.files {
+			fmt.Fprintf(w, "%s  %s\n", sum(data), file)
+		}
+		return
+	}
+	data, ok := a.files[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	fmt.Fprint(w, data)
+}
+
+func newTestMirror(t *testing.T, sources ...config.MirrorSource) (*Mirror, *database.DB, string) {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := database.Initialize(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := &config.Config{Paths: paths.Layout{Root: dir, Files: filepath.Join(dir, "files")}}
+	cfg.BBS.Files.Mirror = config.MirrorConfig{Bulletins: true, Sources: sources}
+	return NewMirror(db, cfg), db, cfg.Paths.Files
+}
+
+func TestRun(t *testing.T) {
+	a := &archive{files: map[string]string{"PKZ204G.EXE": "pkzip", "QMODEM.ZIP": "qmodem"}}
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	m, db, dir := newTestMirror(t, config.MirrorSource{Area: "retro", Manifest: server.URL + "/pub/SHA256SUMS"})
+	area := filepath.Join(dir, "retro")
+
+	// The sysop's own files are left alone
+	if err := os.MkdirAll(area, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(area, "LOCAL.TXT"), []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := m.Run()
+	if err != nil || len(result.Added) != 2 || len(result.Failed) != 0 {
+		t.Fatalf("first Run = %+v, %v", result, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(area, "QMODEM.ZIP")); string(data) != "qmodem" {
+		t.Errorf("QMODEM.ZIP = %q", data)
+	}
+
+	// Nothing changed, so nothing is fetched or announced
+	result, err = m.Run()
+	if err != nil || result.Changed() || result.Unchanged != 2 {
+		t.Fatalf("second Run = %+v, %v", result, err)
+	}
+
+	a.mu.Lock()
+	a.files["QMODEM.ZIP"] = "qmodem 5.0"
+	delete(a.files, "PKZ204G.EXE")
+	a.mu.Unlock()
+	result, err = m.Run()
+	if err != nil || len(result.Updated) != 1 || len(result.Removed) != 1 || result.Removed[0] != "retro/PKZ204G.EXE" {
+		t.Fatalf("third Run = %+v, %v", result, err)
+	}
+	if _, err := os.Stat(filepath.Join(area, "PKZ204G.EXE")); !os.IsNotExist(err) {
+		t.Errorf("PKZ204G.EXE was not removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(area, "LOCAL.TXT")); err != nil {
+		t.Errorf("LOCAL.TXT was removed: %v", err)
+	}
+
+	bulletins, err := db.GetBulletins(10)
+	if err != nil || len(bulletins) != 2 {
+		t.Fatalf("GetBulletins = %+v, %v", bulletins, err)
+	}
+	for _, b := range bulletins {
+		if strings.Contains(b.Body, "Updated: retro/QMODEM.ZIP") && strings.Contains(b.Body, "Removed: retro/PKZ204G.EXE") {
+			return
+		}
+	}
+	t.Errorf("no bulletin lists the update: %+v", bulletins)
+}
+
+func TestRunChecksumMismatch(t *testing.T) {
+	a := &archive{files: map[string]string{"GOOD.ZIP": "good", "BAD.ZIP": "tampered"}}
+	a.manifest = fmt.Sprintf("%s  GOOD.ZIP\n%s *BAD.ZIP\nSHA256 (../ESCAPE.ZIP) = %s\nnot a sum\n", sum("good"), sum("original"), sum("x"))
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	m, db, dir := newTestMirror(t, config.MirrorSource{Area: "retro", Manifest: server.URL + "/pub/SHA256SUMS"})
+	result, err := m.Run()
+	if err != nil || len(result.Added) != 1 || len(result.Failed) != 1 || !strings.Contains(result.Failed[0], "checksum mismatch") {
+		t.Fatalf("Run = %+v, %v", result, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "retro", "BAD.ZIP")); !os.IsNotExist(err) {
+		t.Errorf("BAD.ZIP was listed: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "retro")); len(entries) != 1 {
+		t.Errorf("area holds %d files, want only GOOD.ZIP", len(entries))
+	}
+	if files, _ := db.GetMirroredFiles("retro"); len(files) != 1 {
+		t.Errorf("GetMirroredFiles = %+v", files)
+	}
+
+	// An area with a failed file isn't pruned against a partial list
+	a.mu.Lock()
+	a.manifest = fmt.Sprintf("%s *BAD.ZIP\n", sum("original"))
+	a.mu.Unlock()
+	if result, _ := m.Run(); len(result.Removed) != 0 {
+		t.Errorf("Run pruned after a failure: %+v", result)
+	}
+}
+
+func TestRunTooLarge(t *testing.T) {
+	a := &archive{files: map[string]string{"SMALL.ZIP": "small", "HUGE.ZIP": "far too large"}}
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	m, _, dir := newTestMirror(t, config.MirrorSource{Area: "retro", Manifest: server.URL + "/pub/SHA256SUMS"})
+	m.maxBytes = 10
+	result, err := m.Run()
+	if err != nil || len(result.Added) != 1 || len(result.Failed) != 1 || !strings.Contains(result.Failed[0], "larger than 10 bytes") {
+		t.Fatalf("Run = %+v, %v", result, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "retro")); len(entries) != 1 {
+		t.Errorf("area holds %d files, want only SMALL.ZIP", len(entries))
+	}
+}
+
+func TestRunUnreachable(t *testing.T) {
+	m, _, _ := newTestMirror(t,
+		config.MirrorSource{Area: "retro", Manifest: "http://127.0.0.1:1/SHA256SUMS"},
+		config.MirrorSource{Area: "../etc", Files: []config.MirrorFile{{URL: "http://127.0.0.1:1/x", SHA256: sum("x")}}},
+		config.MirrorSource{Area: "docs", Files: []config.MirrorFile{{URL: "http://127.0.0.1:1/x"}}},
+	)
+	result, err := m.Run()
+	if err != nil || len(result.Failed) != 3 || result.Changed() {
+		t.Fatalf("Run = %+v, %v", result, err)
+	}
+}
+
+func TestRunAdoptsMatchingFile(t *testing.T) {
+	a := &archive{files: map[string]string{"SAME.ZIP": "same", "OTHER.ZIP": "theirs"}}
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	m, db, dir := newTestMirror(t, config.MirrorSource{Area: "retro", Manifest: server.URL + "/pub/SHA256SUMS"})
+	area := filepath.Join(dir, "retro")
+	os.MkdirAll(area, 0755)
+	os.WriteFile(filepath.Join(area, "SAME.ZIP"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(area, "OTHER.ZIP"), []byte("mine"), 0644)
+
+	result, err := m.Run()
+	if err != nil || result.Unchanged != 1 || len(result.Failed) != 1 || result.Changed() {
+		t.Fatalf("Run = %+v, %v", result, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(area, "OTHER.ZIP")); string(data) != "mine" {
+		t.Errorf("OTHER.ZIP was overwritten: %q", data)
+	}
+	if files, _ := db.GetMirroredFiles("retro"); len(files) != 1 || files["SAME.ZIP"].Bytes != 4 {
+		t.Errorf("GetMirroredFiles = %+v", files)
+	}
+}
+
+// serveFTP answers one anonymous passive-mode RETR of data
+func serveFTP(t *testing.T, data string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 ready\r\n")
+
+		var pasv net.Listener
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+			switch cmd {
+			case "USER":
+				fmt.Fprint(conn, "331 password please\r\n")
+			case "PASS":
+				if arg != "anonymous@" {
+					fmt.Fprint(conn, "530 no\r\n")
+					continue
+				}
+				fmt.Fprint(conn, "230 in\r\n")
+			case "TYPE":
+				fmt.Fprint(conn, "200 binary\r\n")
+			case "PASV":
+				pasv, _ = net.Listen("tcp", "127.0.0.1:0")
+				port := pasv.Addr().(*net.TCPAddr).Port
+				// A wrong address, as servers behind NAT give
+				fmt.Fprintf(conn, "227 Entering Passive Mode (10,0,0,1,%d,%d)\r\n", port>>8, port&0xff)
+			case "RETR":
+				if arg != "/pub/PKZ204G.EXE" {
+					fmt.Fprint(conn, "550 not found\r\n")
+					continue
+				}
+				fmt.Fprint(conn, "150 sending\r\n")
+				dc, err := pasv.Accept()
+				if err != nil {
+					return
+				}
+				fmt.Fprint(dc, data)
+				dc.Close()
+				pasv.Close()
+				fmt.Fprint(conn, "226 done\r\n")
+			case "QUIT":
+				fmt.Fprint(conn, "221 bye\r\n")
+				return
+			}
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRunFTP(t *testing.T) {
+	host := serveFTP(t, "pkzip 2.04g")
+	u := url.URL{Scheme: "ftp", Host: host, Path: "/pub/PKZ204G.EXE"}
+	m, _, dir := newTestMirror(t, config.MirrorSource{Area: "retro", Files: []config.MirrorFile{{URL: u.String(), SHA256: sum("pkzip 2.04g")}}})
+
+	result, err := m.Run()
+	if err != nil || len(result.Added) != 1 {
+		t.Fatalf("Run = %+v, %v", result, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "retro", "PKZ204G.EXE")); string(data) != "pkzip 2.04g" {
+		t.Errorf("PKZ204G.EXE = %q", data)
+	}
+}
+
+func TestPasvPort(t *testing.T) {
+	port, err := pasvPort("Entering Passive Mode (192,168,1,2,19,137)")
+	if err != nil || port != 19*256+137 {
+		t.Errorf("pasvPort = %d, %v", port, err)
+	}
+	if _, err := pasvPort("Entering Passive Mode"); err == nil {
+		t.Error("pasvPort accepted a reply without an address")
+	}
+}
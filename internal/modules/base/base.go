@@ -1,6 +1,7 @@
 package base
 
 import (
+	"fmt"
 	"strconv"
 
 	"bbs/internal/database"
@@ -23,6 +24,24 @@ type OptionProvider interface {
 	GetInstructions() string
 }
 
+// PagedProvider is implemented by providers with too many options to load
+// at once, such as bulletins. The module loads a screenful at a time and
+// pages with the arrow and Page Up/Down keys.
+type PagedProvider interface {
+	OptionProvider
+	// LoadPage loads up to limit options starting at offset and returns how
+	// many options there are in all
+	LoadPage(db *database.DB, offset, limit int) ([]MenuOption, int, error)
+}
+
+// KeyHandler is implemented by providers that act on keys of their own,
+// such as X to export the selected bulletin
+type KeyHandler interface {
+	// HandleKey acts on a key for the selected option and returns false to
+	// leave the module
+	HandleKey(key string, option MenuOption, writer modules.Writer, keyReader modules.KeyReader) bool
+}
+
 // Module provides common functionality for all menu-based modules
 type Module struct {
 	db            *database.DB
@@ -31,10 +50,18 @@ type Module struct {
 	options       []MenuOption
 	selectedIndex int
 	menuRenderer  *menu.MenuRenderer
+
+	// Paging, for a PagedProvider
+	page     int
+	pageSize int
+	total    int
 }
 
 // GetMenuTitle implements MenuProvider interface
 func (m *Module) GetMenuTitle() string {
+	if pages := m.pages(); pages > 1 {
+		return fmt.Sprintf("%s (Page %d of %d)", m.provider.GetMenuTitle(), m.page+1, pages)
+	}
 	return m.provider.GetMenuTitle()
 }
 
@@ -70,12 +97,20 @@ func NewModule(db *database.DB, colorScheme menu.ColorScheme, provider OptionPro
 func (m *Module) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
 	m.menuRenderer = menu.NewMenuRenderer(m.colorScheme, writer)
 	m.selectedIndex = 0
+	m.page, m.total = 0, 0
 
 	// Load options from provider
-	options, err := m.provider.LoadOptions(m.db)
+	var options []MenuOption
+	var err error
+	if paged, ok := m.provider.(PagedProvider); ok {
+		m.pageSize = pageSize(writer)
+		options, m.total, err = paged.LoadPage(m.db, 0, m.pageSize)
+	} else {
+		options, err = m.provider.LoadOptions(m.db)
+	}
 	if err != nil {
-		errorMsg := m.colorScheme.Colorize("Error loading menu options.", "error")
-		centeredError := m.colorScheme.CenterText(errorMsg, 79)
+		errorMsg := m.colorScheme.Colorize(modules.T(writer, "Error loading menu options."), "error")
+		centeredError := m.colorScheme.CenterText(errorMsg, modules.Width(writer))
 		writer.Write([]byte(centeredError + "\n"))
 		return true
 	}
@@ -117,13 +152,31 @@ func (m *Module) handleKey(key string, writer modules.Writer, keyReader modules.
 	case "up":
 		m.selectedIndex--
 		if m.selectedIndex < 0 {
-			m.selectedIndex = len(m.options) - 1
+			if m.pages() > 1 {
+				m.turnPage(m.page-1, -1)
+			} else {
+				m.selectedIndex = len(m.options) - 1
+			}
 		}
 	case "down":
 		m.selectedIndex++
 		if m.selectedIndex >= len(m.options) {
-			m.selectedIndex = 0
+			if m.pages() > 1 {
+				m.turnPage(m.page+1, 0)
+			} else {
+				m.selectedIndex = 0
+			}
+		}
+	case "left":
+		if m.pages() > 1 && m.page > 0 {
+			m.turnPage(m.page-1, 0)
 		}
+	case "right":
+		if m.pages() > 1 && m.page < m.pages()-1 {
+			m.turnPage(m.page+1, 0)
+		}
+	case "pageup", "pagedown", "home", "end":
+		m.jump(key, writer)
 	case "enter":
 		option := m.options[m.selectedIndex]
 		return option.Execute(writer, keyReader, m.db, m.colorScheme)
@@ -134,23 +187,95 @@ func (m *Module) handleKey(key string, writer modules.Writer, keyReader modules.
 			option := m.options[num-1]
 			return option.Execute(writer, keyReader, m.db, m.colorScheme)
 		}
+	default:
+		if handler, ok := m.provider.(KeyHandler); ok {
+			return handler.HandleKey(key, m.options[m.selectedIndex], writer, keyReader)
+		}
 	}
 	return true
 }
 
+// jump moves a screenful at a time with Page Up and Page Down, and to the
+// first or last option with Home and End. A provider that pages turns its
+// pages instead.
+func (m *Module) jump(key string, writer modules.Writer) {
+	pages := m.pages()
+	if pages <= 1 {
+		m.selectedIndex = menu.Jump(key, m.selectedIndex, len(m.options), menu.ListRows(writer))
+		return
+	}
+	switch key {
+	case "pageup":
+		if m.page > 0 {
+			m.turnPage(m.page-1, 0)
+		}
+	case "pagedown":
+		if m.page < pages-1 {
+			m.turnPage(m.page+1, 0)
+		}
+	case "home":
+		m.turnPage(0, 0)
+	case "end":
+		m.turnPage(pages-1, -1)
+	}
+}
+
+// pageSize is how many options fit on the caller's screen between the
+// title, borders, instructions and status bar
+func pageSize(writer modules.Writer) int {
+	height := 24
+	if sizer, ok := writer.(interface{ Size() (int, int, error) }); ok {
+		if _, h, err := sizer.Size(); err == nil && h > 0 {
+			height = h
+		}
+	}
+	if size := height - 8; size > 5 {
+		return size
+	}
+	return 5
+}
+
+// pages is how many pages a PagedProvider's options take, 0 for a provider
+// that loads them all
+func (m *Module) pages() int {
+	if m.pageSize == 0 {
+		return 0
+	}
+	return (m.total + m.pageSize - 1) / m.pageSize
+}
+
+// turnPage loads another page, wrapping around at either end, and selects
+// the option at index on it; -1 selects the last
+func (m *Module) turnPage(page, index int) {
+	pages := m.pages()
+	page = (page + pages) % pages
+
+	options, total, err := m.provider.(PagedProvider).LoadPage(m.db, page*m.pageSize, m.pageSize)
+	if err != nil || len(options) == 0 {
+		// Keep showing the page we have
+		m.selectedIndex = 0
+		return
+	}
+	m.options, m.total, m.page = options, total, page
+	if index < 0 || index >= len(options) {
+		index = len(options) - 1
+	}
+	m.selectedIndex = index
+}
+
 // showEmptyMessage displays a message when no items are available
 func (m *Module) showEmptyMessage(writer modules.Writer, keyReader modules.KeyReader) {
 	writer.Write([]byte(menu.ClearContentArea))
 	header := m.colorScheme.Colorize("--- "+m.provider.GetMenuTitle()+" ---", "primary")
-	centeredHeader := m.colorScheme.CenterText(header, 79)
+	centeredHeader := m.colorScheme.CenterText(header, modules.Width(writer))
 	writer.Write([]byte(centeredHeader + "\n\n"))
 
-	noMsg := m.colorScheme.Colorize("No items available.", "secondary")
-	centeredNoMsg := m.colorScheme.CenterText(noMsg, 79)
+	noMsg := m.colorScheme.Colorize(modules.T(writer, "No items available."), "secondary")
+	centeredNoMsg := m.colorScheme.CenterText(noMsg, modules.Width(writer))
 	writer.Write([]byte(centeredNoMsg + "\n\n"))
 
-	prompt := m.colorScheme.Colorize("Press any key to continue...", "text")
-	centeredPrompt := m.colorScheme.CenterText(prompt, 79)
+	prompt := m.colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := m.colorScheme.CenterText(prompt, modules.Width(writer))
 	writer.Write([]byte(centeredPrompt))
 
 	keyReader.ReadKey()
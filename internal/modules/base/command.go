@@ -34,8 +34,8 @@ func (c *CommandOption) GetDescription() string {
 // Execute implements MenuOption interface
 func (c *CommandOption) Execute(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
 	if c.Handler == nil {
-		errorMsg := colorScheme.Colorize(fmt.Sprintf("No handler defined for command: %s", c.ID), "error")
-		centeredError := colorScheme.CenterText(errorMsg, 79)
+		errorMsg := colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "No handler defined for command: %s"), c.ID), "error")
+		centeredError := colorScheme.CenterText(errorMsg, modules.Width(writer))
 		writer.Write([]byte(centeredError + "\n"))
 		return true
 	}
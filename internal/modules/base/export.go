@@ -0,0 +1,95 @@
+package base
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/transcript"
+	"bbs/internal/transfer"
+)
+
+// Export asks for a line width, renders a plain text transcript at that
+// width and sends it to the caller over ZMODEM as name
+func Export(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, name string, render func(width int) string) {
+	writer.Write([]byte(menu.ClearContentArea))
+	header := colorScheme.Colorize("--- Export "+name+" ---", "primary")
+	writer.Write([]byte(colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	info := fmt.Sprintf("Plain text with no colors, wrapped to %d-%d columns.", transcript.MinWidth, transcript.MaxWidth)
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(info, "text"), modules.Width(writer)) + "\n\n"))
+	prompt := fmt.Sprintf(modules.T(writer, "Line width [%d]: "), transcript.DefaultWidth)
+	writer.Write([]byte("  " + colorScheme.Colorize(prompt, "accent") + menu.ShowCursor))
+
+	input, err := readLine(keyReader, writer)
+	writer.Write([]byte(menu.HideCursor))
+	if err != nil {
+		return
+	}
+	width := 0
+	if input = strings.TrimSpace(input); input != "" {
+		if width, err = strconv.Atoi(input); err != nil {
+			showMessage(writer, keyReader, colorScheme, "Enter the width as a number of columns.", "error")
+			return
+		}
+	}
+	data := []byte(render(transcript.ClampWidth(width)))
+
+	writer.Write([]byte(menu.ClearContentArea))
+	title := colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "--- Sending %s (%d bytes) ---"), name, len(data)), "primary")
+	writer.Write([]byte(colorScheme.CenterText(title, modules.Width(writer)) + "\n\n"))
+	for _, line := range []string{
+		"Starting ZMODEM. Most terminals begin the transfer automatically;",
+		"otherwise start a ZMODEM transfer from your terminal now.",
+		"Press Ctrl+X five times to cancel.",
+	} {
+		writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+	}
+	writer.Write([]byte("\n"))
+
+	var sent int64
+	err = modules.Transfer(writer, func(rw io.ReadWriter) error {
+		var err error
+		sent, err = transfer.Send(rw, []transfer.File{{Name: name, Size: int64(len(data)), ModTime: time.Now(), Data: bytes.NewReader(data)}},
+			transfer.Options{})
+		return err
+	})
+
+	switch {
+	case errors.Is(err, transfer.ErrCancelled):
+		showMessage(writer, keyReader, colorScheme, "Transfer cancelled.", "error")
+	case err != nil:
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Transfer failed: ")+modules.ErrorText(writer, err), "error")
+	case sent == 0:
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Your terminal skipped ")+name+".", "secondary")
+	default:
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Sent ")+name+".", "success")
+	}
+}
+
+// readLine reads a line of input from the user
+func readLine(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
+	return input.ReadLine(keyReader, writer, 0)
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	writer.Write([]byte(colorScheme.CenterText(coloredMessage, modules.Width(writer)) + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	writer.Write([]byte(colorScheme.CenterText(prompt, modules.Width(writer))))
+
+	keyReader.ReadKey()
+}
@@ -0,0 +1,136 @@
+package boards
+
+import (
+	"strings"
+
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/modules/base"
+)
+
+// Module implements the public message boards, one menu option per topic
+type Module struct {
+	*base.Module
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	username    string
+	userID      int
+	accessLevel int
+	lastRead    map[string]int          // Highest post ID the user has read in each topic
+	newPosts    map[int]int             // Posts the user hasn't read in each topic
+	tagline     func() string           // Picks a tagline for the foot of new posts, nil for none
+	moderator   func(topic string) bool // Reports the topics the caller moderates, nil for none
+}
+
+// NewModule creates a message boards module for the given user
+func NewModule(db *database.DB, colorScheme menu.ColorScheme, username string, userID, accessLevel int) *Module {
+	m := &Module{
+		db:          db,
+		colorScheme: colorScheme,
+		username:    username,
+		userID:      userID,
+		accessLevel: accessLevel,
+	}
+	m.Module = base.NewModule(db, colorScheme, m)
+	return m
+}
+
+// SetTagline has new posts and replies end with a tagline from pick, which
+// may return "" to leave one out
+func (m *Module) SetTagline(pick func() string) {
+	m.tagline = pick
+}
+
+// withTagline adds a tagline to the foot of body, if the board adds them
+func (m *Module) withTagline(body string) string {
+	if m.tagline == nil {
+		return body
+	}
+	tagline := m.tagline()
+	if tagline == "" {
+		return body
+	}
+	return strings.TrimRight(body, "\n") + "\n\n... " + tagline
+}
+
+// LoadOptions implements OptionProvider interface
+func (m *Module) LoadOptions(db *database.DB) ([]base.MenuOption, error) {
+	topics, err := db.GetTopics(m.accessLevel)
+	if err != nil {
+		return nil, err
+	}
+	if m.lastRead, err = db.GetLastRead(m.userID); err != nil {
+		return nil, err
+	}
+	if m.newPosts, err = db.GetNewPostCounts(m.userID, m.accessLevel); err != nil {
+		return nil, err
+	}
+
+	var options []base.MenuOption
+	for i := range topics {
+		options = append(options, NewTopicOption(&topics[i], i, m))
+	}
+
+	return options, nil
+}
+
+// GetMenuTitle implements OptionProvider interface
+func (m *Module) GetMenuTitle() string {
+	return "Message Boards"
+}
+
+// GetInstructions implements OptionProvider interface
+func (m *Module) GetInstructions() string {
+	return "Navigate: ↑↓  Open: Enter  Quit: Q"
+}
+
+// isNew reports whether the user hasn't read a post in a topic yet
+func (m *Module) isNew(post *database.Post) bool {
+	return post.ID > m.lastRead[database.TopicArea(post.TopicID)]
+}
+
+// markRead moves the user's last-read pointer for a topic past a post
+func (m *Module) markRead(post *database.Post) {
+	area := database.TopicArea(post.TopicID)
+	if post.ID > m.lastRead[area] {
+		m.lastRead[area] = post.ID
+	}
+	if !m.db.ReadOnly() {
+		m.db.MarkRead(m.userID, area, post.ID)
+	}
+}
+
+// refreshNewPosts recounts the posts the user hasn't read, after they have
+// been reading
+func (m *Module) refreshNewPosts() {
+	if counts, err := m.db.GetNewPostCounts(m.userID, m.accessLevel); err == nil {
+		m.newPosts = counts
+	}
+}
+
+// ShowPost opens a post's thread outside the topic list, as when a search
+// finds it. Posts in topics the user can't read aren't shown.
+func (m *Module) ShowPost(writer modules.Writer, keyReader modules.KeyReader, postID int) {
+	post, err := m.db.GetPost(postID)
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to load post: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	topics, err := m.db.GetTopics(m.accessLevel)
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to load topics: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	if m.lastRead == nil {
+		if m.lastRead, err = m.db.GetLastRead(m.userID); err != nil {
+			m.lastRead = make(map[string]int)
+		}
+	}
+	for i := range topics {
+		if topics[i].ID == post.TopicID {
+			NewTopicOption(&topics[i], i, m).showPost(writer, keyReader, m.db, m.colorScheme, postID)
+			return
+		}
+	}
+}
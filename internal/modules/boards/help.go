@@ -0,0 +1,8 @@
+package boards
+
+import "embed"
+
+// Help holds the help topics for the message boards
+//
+//go:embed help/*.md
+var Help embed.FS
@@ -0,0 +1,164 @@
+package boards
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/database"
+	"bbs/internal/dialog"
+	"bbs/internal/editor"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// SetModerator has moderates report whether the caller moderates a topic,
+// by name. Sysops moderate every topic whatever it reports.
+func (m *Module) SetModerator(moderates func(topic string) bool) {
+	m.moderator = moderates
+}
+
+// moderates reports whether the caller can edit, delete and pin posts in a
+// topic, lock it, and post in it while it is locked
+func (m *Module) moderates(topic *database.Topic) bool {
+	return m.accessLevel >= 255 || (m.moderator != nil && m.moderator(topic.Name))
+}
+
+// denyIfLocked shows a notice and returns true when the topic is locked
+// and the caller doesn't moderate it
+func (t *TopicOption) denyIfLocked(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme) bool {
+	if !t.topic.Locked || t.module.moderates(t.topic) {
+		return false
+	}
+	showMessage(writer, keyReader, colorScheme, "This topic is locked. Only its moderators can post.", "error")
+	return true
+}
+
+// toggleLock locks the topic against new posts, or unlocks it
+func (t *TopicOption) toggleLock(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) {
+	if denyIfReadOnly(writer, keyReader, db, colorScheme) {
+		return
+	}
+	locked := !t.topic.Locked
+	if err := db.SetTopicLocked(t.topic.ID, locked); err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.ErrorText(writer, err), "error")
+		return
+	}
+	t.topic.Locked = locked
+	msg := t.topic.Name + " is unlocked."
+	if locked {
+		msg = t.topic.Name + " is locked. Only its moderators can post or reply."
+	}
+	showMessage(writer, keyReader, colorScheme, msg, "success")
+}
+
+// togglePin pins a post above the others in the topic, or unpins it
+func (t *TopicOption) togglePin(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, post *database.Post) {
+	if denyIfReadOnly(writer, keyReader, db, colorScheme) {
+		return
+	}
+	if err := db.SetPostPinned(post.ID, !post.Pinned); err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.ErrorText(writer, err), "error")
+	}
+}
+
+// editPost has a moderator change a post's subject and body. It returns
+// true if the post was changed.
+func (t *TopicOption) editPost(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, post *database.Post) bool {
+	if denyIfReadOnly(writer, keyReader, db, colorScheme) || denyIfArchived(writer, keyReader, colorScheme, post) {
+		return false
+	}
+
+	writer.Write([]byte(menu.ClearContentArea + menu.ShowCursor))
+	header := colorScheme.Colorize(modules.T(writer, "--- Edit Post ---"), "primary")
+	writer.Write([]byte(colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	writer.Write([]byte(colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "Subject (Enter keeps %q): "), post.Subject), "text")))
+	input, err := readLine(keyReader, writer)
+	if err != nil {
+		return false
+	}
+	subject := strings.TrimSpace(input)
+	if subject == "" {
+		subject = post.Subject
+	}
+
+	body, ok := editor.New(writer, keyReader, colorScheme, "Edit Post: "+subject).Edit(post.Body)
+	if !ok || strings.TrimSpace(body) == "" || !dialog.Confirm(writer, keyReader, colorScheme, fmt.Sprintf(modules.T(writer, "Save the changes to \"%s\"?"), subject), true) {
+		showMessage(writer, keyReader, colorScheme, "Post left as it was.", "secondary")
+		return false
+	}
+	if err := db.UpdatePost(post.ID, subject, body); err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to save the post: ")+modules.ErrorText(writer, err), "error")
+		return false
+	}
+	showMessage(writer, keyReader, colorScheme, "Post saved.", "success")
+	return true
+}
+
+// editReply has a moderator change a reply's body
+func (t *TopicOption) editReply(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, post *database.Post, reply *database.Reply) {
+	if denyIfReadOnly(writer, keyReader, db, colorScheme) || denyIfArchived(writer, keyReader, colorScheme, post) {
+		return
+	}
+
+	title := fmt.Sprintf(modules.T(writer, "Edit Reply from %s"), reply.Author)
+	body, ok := editor.New(writer, keyReader, colorScheme, title).Edit(reply.Body)
+	if !ok || strings.TrimSpace(body) == "" || !dialog.Confirm(writer, keyReader, colorScheme, fmt.Sprintf(modules.T(writer, "Save the changes to this reply from %s?"), reply.Author), true) {
+		showMessage(writer, keyReader, colorScheme, "Reply left as it was.", "secondary")
+		return
+	}
+	if err := db.UpdateReply(reply.ID, body); err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to save the reply: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	showMessage(writer, keyReader, colorScheme, "Reply saved.", "success")
+}
+
+// deletePost has a moderator confirm, then removes a post and its replies.
+// It returns true if the post was deleted.
+func (t *TopicOption) deletePost(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, post *database.Post) bool {
+	if denyIfReadOnly(writer, keyReader, db, colorScheme) || denyIfArchived(writer, keyReader, colorScheme, post) {
+		return false
+	}
+	question := fmt.Sprintf(modules.T(writer, "Delete \"%s\"?"), post.Subject)
+	if post.ReplyCount > 0 {
+		question = fmt.Sprintf(modules.T(writer, "Delete \"%s\" and its %d replies?"), post.Subject, post.ReplyCount)
+	}
+	if !dialog.Confirm(writer, keyReader, colorScheme, question, false) {
+		return false
+	}
+	if err := db.DeletePost(post.ID); err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to delete the post: ")+modules.ErrorText(writer, err), "error")
+		return false
+	}
+	t.topic.PostCount--
+	showMessage(writer, keyReader, colorScheme, "Post deleted.", "success")
+	return true
+}
+
+// deleteReply has a moderator confirm, then removes a reply. The replies
+// that answered it stay in the thread. It returns true if the reply was
+// deleted.
+func (t *TopicOption) deleteReply(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, post *database.Post, reply *database.Reply) bool {
+	if denyIfReadOnly(writer, keyReader, db, colorScheme) || denyIfArchived(writer, keyReader, colorScheme, post) {
+		return false
+	}
+	if !dialog.Confirm(writer, keyReader, colorScheme, fmt.Sprintf(modules.T(writer, "Delete this reply from %s?"), reply.Author), false) {
+		return false
+	}
+	if err := db.DeleteReply(reply.ID); err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to delete the reply: ")+modules.ErrorText(writer, err), "error")
+		return false
+	}
+	showMessage(writer, keyReader, colorScheme, "Reply deleted.", "success")
+	return true
+}
+
+// denyIfArchived shows a notice and returns true for an archived post,
+// which moderators can no longer change
+func denyIfArchived(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, post *database.Post) bool {
+	if !post.Archived {
+		return false
+	}
+	showMessage(writer, keyReader, colorScheme, "This thread is archived and can't be changed.", "error")
+	return true
+}
@@ -0,0 +1,449 @@
+package boards
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/modules/base"
+	"bbs/internal/pager"
+	"bbs/internal/transcript"
+)
+
+// threadRows is how many lines of the thread tree are shown at once
+const threadRows = 14
+
+// threadView shows a post and its replies as a tree. Item 0 is the post and
+// item i is thread[i-1], so moving through the items in order reads the
+// thread from top to bottom.
+type threadView struct {
+	topic     *TopicOption
+	postID    int
+	post      *database.Post
+	thread    []database.ThreadedReply
+	collapsed map[int]bool // Replies whose answers are folded away
+}
+
+// showPost reads a post, then shows its thread until the caller goes back
+// to the post list
+func (t *TopicOption) showPost(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, postID int) {
+	v := &threadView{topic: t, postID: postID, collapsed: make(map[int]bool)}
+	if !v.load(writer, keyReader, db, colorScheme) {
+		return
+	}
+
+	selected := v.read(writer, keyReader, db, colorScheme, 0)
+	if len(v.thread) == 0 {
+		return
+	}
+
+	offset := 0
+	for {
+		if !v.load(writer, keyReader, db, colorScheme) {
+			return
+		}
+		if selected > len(v.thread) {
+			selected = len(v.thread)
+		}
+		v.reveal(selected)
+
+		visible := v.visible()
+		row := 0
+		for i, item := range visible {
+			if item == selected {
+				row = i
+			}
+		}
+		if row < offset {
+			offset = row
+		}
+		if row >= offset+threadRows {
+			offset = row - threadRows + 1
+		}
+
+		v.render(writer, colorScheme, visible, row, offset)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return
+		}
+
+		switch strings.ToLower(key) {
+		case "up":
+			if row > 0 {
+				selected = visible[row-1]
+			}
+		case "down":
+			if row < len(visible)-1 {
+				selected = visible[row+1]
+			}
+		case "pageup", "pagedown", "home", "end":
+			selected = visible[menu.Jump(key, row, len(visible), threadRows)]
+		case "left":
+			// Fold the answers away, or step out to the reply answered
+			if selected > 0 && v.hasAnswers(selected) && !v.collapsed[v.reply(selected).ID] {
+				v.collapsed[v.reply(selected).ID] = true
+			} else {
+				selected = v.parent(selected)
+			}
+		case "right":
+			if selected > 0 {
+				delete(v.collapsed, v.reply(selected).ID)
+			}
+		case "enter":
+			selected = v.read(writer, keyReader, db, colorScheme, selected)
+		case "n":
+			if selected < len(v.thread) {
+				selected = v.read(writer, keyReader, db, colorScheme, selected+1)
+			}
+		case "p":
+			if selected > 0 {
+				selected = v.read(writer, keyReader, db, colorScheme, selected-1)
+			}
+		case "r":
+			if id := v.answer(writer, keyReader, db, colorScheme, selected); id != 0 {
+				selected = v.find(id)
+			}
+		case "x":
+			v.export(writer, keyReader, colorScheme)
+		case "e":
+			if v.topic.module.moderates(v.topic.topic) {
+				if selected == 0 {
+					v.topic.editPost(writer, keyReader, db, colorScheme, v.post)
+				} else {
+					v.topic.editReply(writer, keyReader, db, colorScheme, v.post, &v.reply(selected).Reply)
+				}
+			}
+		case "d":
+			if v.topic.module.moderates(v.topic.topic) {
+				if selected == 0 {
+					if v.topic.deletePost(writer, keyReader, db, colorScheme, v.post) {
+						return
+					}
+				} else {
+					if v.topic.deleteReply(writer, keyReader, db, colorScheme, v.post, &v.reply(selected).Reply) {
+						// Stay on the reply above the one deleted
+						selected--
+					}
+				}
+			}
+		case "q", "quit", "escape":
+			return
+		}
+	}
+}
+
+// load fetches the post and its replies, reporting false if they couldn't
+// be read
+func (v *threadView) load(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
+	post, err := db.GetPost(v.postID)
+	if err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to load post: ")+modules.ErrorText(writer, err), "error")
+		return false
+	}
+	replies, err := db.GetReplies(v.postID)
+	if err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to load replies: ")+modules.ErrorText(writer, err), "error")
+		return false
+	}
+	v.post = post
+	v.thread = database.Thread(replies)
+	return true
+}
+
+// read shows an item in the pager, then offers the next and previous items
+// in the thread. It returns the item the caller stopped on.
+func (v *threadView) read(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, item int) int {
+	for {
+		v.page(writer, keyReader, colorScheme, item)
+
+		writer.Write([]byte(menu.ClearContentArea))
+		title := colorScheme.Colorize(v.post.Subject, "primary")
+		writer.Write([]byte(colorScheme.CenterText(title, modules.Width(writer)) + "\n\n"))
+
+		var keys []string
+		if item < len(v.thread) {
+			keys = append(keys, "N: Next in Thread")
+		}
+		if item > 0 {
+			keys = append(keys, "P: Previous")
+		}
+		if !v.post.Archived {
+			keys = append(keys, "R: Reply")
+		}
+		keys = append(keys, "X: Export")
+		if len(v.thread) > 0 {
+			keys = append(keys, "Any other key: Thread")
+		} else {
+			keys = append(keys, "Any other key: Back to posts")
+		}
+		prompt := colorScheme.Colorize(strings.Join(keys, "  "), "secondary")
+		writer.Write([]byte(colorScheme.CenterText(prompt, modules.Width(writer))))
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return item
+		}
+
+		switch strings.ToLower(key) {
+		case "n":
+			if item < len(v.thread) {
+				item++
+				continue
+			}
+		case "p":
+			if item > 0 {
+				item--
+				continue
+			}
+		case "r":
+			if id := v.answer(writer, keyReader, db, colorScheme, item); id != 0 {
+				return v.find(id)
+			}
+		case "x":
+			v.export(writer, keyReader, colorScheme)
+			continue
+		}
+		return item
+	}
+}
+
+// page shows the post or a reply in the pager
+func (v *threadView) page(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, item int) {
+	var contentLines []string
+	if item == 0 {
+		info := fmt.Sprintf("By: %s | Date: %s", v.post.Author, v.post.CreatedAt.Format("January 2, 2006 15:04"))
+		if v.post.Archived {
+			info += " | Archived"
+		}
+		contentLines = append(contentLines, colorScheme.CenterText(colorScheme.Colorize(info, "secondary"), modules.Width(writer)), "")
+		contentLines = append(contentLines, bodyLines(v.post.Body, colorScheme)...)
+	} else {
+		reply := v.reply(item)
+		answered := v.post.Author
+		if reply.ParentID != 0 {
+			answered = v.reply(v.parent(item)).Author
+		}
+		info := fmt.Sprintf("Reply %d of %d | From %s to %s | %s", item, len(v.thread), reply.Author, answered,
+			reply.CreatedAt.Format("January 2, 2006 15:04"))
+		contentLines = append(contentLines, colorScheme.CenterText(colorScheme.Colorize(info, "secondary"), modules.Width(writer)), "")
+		contentLines = append(contentLines, bodyLines(reply.Body, colorScheme)...)
+	}
+
+	termSizer := pager.NewTerminalSizerFromWriter(writer)
+	writerAdapter := pager.NewWriterAdapter(writer, termSizer)
+
+	// Give the pager status bar control when the writer supports it
+	type StatusBarController interface {
+		Pause()
+		Resume()
+	}
+	if sbCtrl, ok := writer.(StatusBarController); ok {
+		writerAdapter.WithStatusBarManager(sbCtrl)
+	}
+
+	p := pager.NewPager(writerAdapter, keyReader, writerAdapter, colorScheme)
+	if writerAdapter.StatusBarMgr != nil {
+		p.WithStatusBar(writerAdapter)
+	}
+	p.Display(contentLines, fmt.Sprintf("--- %s ---", v.post.Subject))
+	// A caller who hung up partway through hasn't read it
+	if item == 0 && !modules.HungUp(writer) {
+		v.topic.module.markRead(v.post)
+	}
+}
+
+// render draws the thread tree with the selected row highlighted
+func (v *threadView) render(writer modules.Writer, colorScheme menu.ColorScheme, visible []int, row, offset int) {
+	writer.Write([]byte(menu.ClearContentArea + menu.HideCursor))
+
+	header := colorScheme.Colorize(fmt.Sprintf("--- %s ---", v.post.Subject), "primary")
+	writer.Write([]byte(colorScheme.CenterText(header, modules.Width(writer)) + "\n"))
+	count := fmt.Sprintf("%d replies in this thread", len(v.thread))
+	if len(v.thread) == 1 {
+		count = "1 reply in this thread"
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(count, "secondary"), modules.Width(writer)) + "\n\n"))
+
+	prefixes := v.prefixes()
+	end := offset + threadRows
+	if end > len(visible) {
+		end = len(visible)
+	}
+	for i := offset; i < end; i++ {
+		item := visible[i]
+		var label, date string
+		if item == 0 {
+			label = fmt.Sprintf("%s - %s", v.post.Subject, v.post.Author)
+			date = v.post.CreatedAt.Format("Jan 02")
+		} else {
+			reply := v.reply(item)
+			folded := ""
+			if v.collapsed[reply.ID] {
+				folded = fmt.Sprintf("[+%d] ", v.answers(item))
+			}
+			label = prefixes[item-1] + folded + reply.Author + ": " + snippet(reply.Body)
+			date = reply.CreatedAt.Format("Jan 02")
+		}
+		line := fmt.Sprintf("%-64s %-6s", ansi.Ellipsize(label, 64), date)
+		writer.Write([]byte("  " + colorScheme.HighlightSelection(line, i == row, len(line)+2) + "\n"))
+	}
+
+	writer.Write([]byte("\n"))
+	if offset > 0 || end < len(visible) {
+		more := fmt.Sprintf("Showing %d-%d of %d", offset+1, end, len(visible))
+		writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(more, "secondary"), modules.Width(writer)) + "\n"))
+	}
+	keys := "↑↓: Select  Enter: Read  ←→: Fold  N/P: Next/Prev  R: Reply  X: Export  Q: Back"
+	if v.post.Archived {
+		keys = "↑↓: Select  Enter: Read  ←→: Fold  N/P: Next/Prev  X: Export  Q: Back"
+	}
+	instructions := colorScheme.Colorize(keys, "secondary")
+	writer.Write([]byte(colorScheme.CenterText(instructions, modules.Width(writer))))
+	if v.topic.module.moderates(v.topic.topic) && !v.post.Archived {
+		moderate := colorScheme.Colorize(modules.T(writer, "E: Edit  D: Delete"), "secondary")
+		writer.Write([]byte("\n" + colorScheme.CenterText(moderate, modules.Width(writer))))
+	}
+}
+
+// answer writes a reply to an item and returns the new reply's ID, or 0
+func (v *threadView) answer(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, item int) int {
+	if v.post.Archived {
+		showMessage(writer, keyReader, colorScheme, "This thread is archived and can't be replied to.", "error")
+		return 0
+	}
+	var parent *database.Reply
+	if item > 0 {
+		parent = &v.reply(item).Reply
+	}
+	id := v.topic.showReplyForm(writer, keyReader, db, colorScheme, v.post, parent)
+	if id != 0 && parent != nil {
+		// Show the new reply rather than leave it folded away
+		delete(v.collapsed, parent.ID)
+	}
+	if id != 0 {
+		v.load(writer, keyReader, db, colorScheme)
+	}
+	return id
+}
+
+// export sends the whole thread to the caller as plain text
+func (v *threadView) export(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme) {
+	base.Export(writer, keyReader, colorScheme, fmt.Sprintf("THREAD-%d.TXT", v.post.ID), func(width int) string {
+		return transcript.Thread(v.topic.topic.Name, v.post, v.thread, width)
+	})
+}
+
+// reply returns the reply shown as item, which must be above 0
+func (v *threadView) reply(item int) *database.ThreadedReply {
+	return &v.thread[item-1]
+}
+
+// find returns the item showing the reply with the given ID, or 0
+func (v *threadView) find(id int) int {
+	for i, reply := range v.thread {
+		if reply.ID == id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// parent returns the item an item answers; replies to the post answer item 0
+func (v *threadView) parent(item int) int {
+	if item == 0 {
+		return 0
+	}
+	return v.find(v.reply(item).ParentID)
+}
+
+// hasAnswers reports whether any reply answers an item
+func (v *threadView) hasAnswers(item int) bool {
+	return v.answers(item) > 0
+}
+
+// answers counts the replies below an item in its part of the thread
+func (v *threadView) answers(item int) int {
+	if item == 0 {
+		return len(v.thread)
+	}
+	depth := v.reply(item).Depth
+	n := 0
+	for i := item; i < len(v.thread) && v.thread[i].Depth > depth; i++ {
+		n++
+	}
+	return n
+}
+
+// visible returns the items not hidden inside a folded reply
+func (v *threadView) visible() []int {
+	items := []int{0}
+	hiddenBelow := -1 // Depth of the folded reply being skipped over
+	for i, reply := range v.thread {
+		if hiddenBelow >= 0 && reply.Depth > hiddenBelow {
+			continue
+		}
+		hiddenBelow = -1
+		items = append(items, i+1)
+		if v.collapsed[reply.ID] {
+			hiddenBelow = reply.Depth
+		}
+	}
+	return items
+}
+
+// reveal unfolds the replies above an item so that it can be selected
+func (v *threadView) reveal(item int) {
+	for item > 0 {
+		item = v.parent(item)
+		if item > 0 {
+			delete(v.collapsed, v.reply(item).ID)
+		}
+	}
+}
+
+// prefixes draws the tree lines in front of each reply
+func (v *threadView) prefixes() []string {
+	prefixes := make([]string, len(v.thread))
+	var open []bool // Whether the thread carries on below each depth
+	for i, reply := range v.thread {
+		last := true
+		for j := i + 1; j < len(v.thread) && v.thread[j].Depth >= reply.Depth; j++ {
+			if v.thread[j].Depth == reply.Depth {
+				last = false
+				break
+			}
+		}
+
+		var b strings.Builder
+		for d := 0; d < reply.Depth; d++ {
+			if open[d] {
+				b.WriteString("│  ")
+			} else {
+				b.WriteString("   ")
+			}
+		}
+		if last {
+			b.WriteString("└─ ")
+		} else {
+			b.WriteString("├─ ")
+		}
+		prefixes[i] = b.String()
+
+		open = append(open[:reply.Depth], !last)
+	}
+	return prefixes
+}
+
+// snippet returns the first line of a message body with text on it
+func snippet(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,326 @@
+package boards
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+	"bbs/internal/dialog"
+	"bbs/internal/editor"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/notify"
+)
+
+// maxPosts caps how many posts are listed for a topic
+const maxPosts = 100
+
+// TopicOption represents a message board topic menu option
+type TopicOption struct {
+	topic  *database.Topic
+	index  int
+	module *Module // The caller and what they have read
+}
+
+// NewTopicOption creates a new topic option
+func NewTopicOption(topic *database.Topic, index int, module *Module) *TopicOption {
+	return &TopicOption{
+		topic:  topic,
+		index:  index,
+		module: module,
+	}
+}
+
+// GetID implements MenuOption interface
+func (t *TopicOption) GetID() string {
+	return fmt.Sprintf("topic_%d", t.topic.ID)
+}
+
+// GetTitle implements MenuOption interface
+func (t *TopicOption) GetTitle() string {
+	return t.topic.Name
+}
+
+// GetDescription implements MenuOption interface
+func (t *TopicOption) GetDescription() string {
+	unread := ""
+	if n := t.module.newPosts[t.topic.ID]; n > 0 {
+		unread = fmt.Sprintf("%d new", n)
+	}
+	return fmt.Sprintf("%d) %-20s %4d posts %-8s %s", t.index+1, t.topic.Name, t.topic.PostCount, unread, t.topic.Description)
+}
+
+// Execute implements MenuOption interface by showing the topic's posts
+func (t *TopicOption) Execute(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
+	selected := 0
+	follow := 0 // Post to keep selected as it moves in the list
+
+	for {
+		posts, err := db.GetPosts(t.topic.ID, maxPosts)
+		if err != nil {
+			showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to load posts: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+		for i := range posts {
+			if follow != 0 && posts[i].ID == follow {
+				selected = i
+			}
+		}
+		follow = 0
+
+		if selected >= len(posts) {
+			selected = len(posts) - 1
+		}
+		if selected < 0 {
+			selected = 0
+		}
+
+		t.renderPosts(writer, colorScheme, posts, selected)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "up":
+			if selected > 0 {
+				selected--
+			}
+		case "down":
+			if selected < len(posts)-1 {
+				selected++
+			}
+		case "pageup", "pagedown", "home", "end":
+			selected = menu.Jump(key, selected, len(posts), menu.ListRows(writer))
+		case "enter":
+			if len(posts) > 0 {
+				t.showPost(writer, keyReader, db, colorScheme, posts[selected].ID)
+			}
+		case "n":
+			if t.showNewPostForm(writer, keyReader, db, colorScheme) {
+				// New posts sort below any pinned ones
+				selected = 0
+				for selected < len(posts) && posts[selected].Pinned {
+					selected++
+				}
+			}
+		case "e":
+			if len(posts) > 0 && t.module.moderates(t.topic) {
+				t.editPost(writer, keyReader, db, colorScheme, &posts[selected])
+			}
+		case "d":
+			if len(posts) > 0 && t.module.moderates(t.topic) {
+				t.deletePost(writer, keyReader, db, colorScheme, &posts[selected])
+			}
+		case "p":
+			if len(posts) > 0 && t.module.moderates(t.topic) {
+				t.togglePin(writer, keyReader, db, colorScheme, &posts[selected])
+				follow = posts[selected].ID
+			}
+		case "l":
+			if t.module.moderates(t.topic) {
+				t.toggleLock(writer, keyReader, db, colorScheme)
+			}
+		case "q", "quit", "escape":
+			t.module.refreshNewPosts()
+			writer.Write([]byte(menu.HideCursor))
+			return true
+		}
+	}
+}
+
+// renderPosts draws the topic's post list with the selected post highlighted
+func (t *TopicOption) renderPosts(writer modules.Writer, colorScheme menu.ColorScheme, posts []database.Post, selected int) {
+	writer.Write([]byte(menu.ClearContentArea + menu.HideCursor))
+
+	title := fmt.Sprintf("--- %s ---", t.topic.Name)
+	if t.topic.Locked {
+		title = fmt.Sprintf(modules.T(writer, "--- %s (Locked) ---"), t.topic.Name)
+	}
+	header := colorScheme.Colorize(title, "primary")
+	writer.Write([]byte(colorScheme.CenterText(header, modules.Width(writer)) + "\n"))
+	if t.topic.Description != "" {
+		desc := colorScheme.Colorize(t.topic.Description, "secondary")
+		writer.Write([]byte(colorScheme.CenterText(desc, modules.Width(writer)) + "\n"))
+	}
+	writer.Write([]byte("\n"))
+
+	if len(posts) == 0 {
+		msg := colorScheme.Colorize(modules.T(writer, "No posts yet. Press N to start the conversation."), "secondary")
+		writer.Write([]byte(colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+	} else {
+		headerLine := fmt.Sprintf("  %-3s %-34s %-16s %-7s %-10s", "#", "Subject", "Author", "Replies", "Date")
+		writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+		separator := colorScheme.DrawSeparator(len(headerLine), "─")
+		writer.Write([]byte(colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+		for i, post := range posts {
+			marker := " "
+			if t.module.isNew(&post) {
+				marker = "*"
+			}
+			subject := post.Subject
+			if post.Pinned {
+				subject = "[Pinned] " + subject
+			}
+			line := fmt.Sprintf("%s %-3d %-34s %-16s %-7d %-10s", marker, i+1, ansi.Ellipsize(subject, 34),
+				ansi.Ellipsize(post.Author, 16), post.ReplyCount, post.CreatedAt.Format("2006-01-02"))
+			writer.Write([]byte(colorScheme.CenterText(colorScheme.HighlightSelection(line, i == selected, len(line)+2), modules.Width(writer)) + "\n"))
+		}
+	}
+
+	writer.Write([]byte("\n"))
+	instructions := colorScheme.Colorize(modules.T(writer, "↑↓: Select  Enter: Read  N: New Post  Q: Back"), "secondary")
+	writer.Write([]byte(colorScheme.CenterText(instructions, modules.Width(writer)) + "\n"))
+	legend := "* = new"
+	if t.module.moderates(t.topic) {
+		legend = "E: Edit  D: Delete  P: Pin  L: Lock Topic  * = new"
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(legend, "text"), modules.Width(writer))))
+}
+
+// showNewPostForm prompts for a subject, opens the editor for the body, and
+// posts the message to the topic after confirmation. It returns true if a
+// post was created.
+func (t *TopicOption) showNewPostForm(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
+	if denyIfReadOnly(writer, keyReader, db, colorScheme) || t.denyIfLocked(writer, keyReader, colorScheme) {
+		return false
+	}
+
+	writer.Write([]byte(menu.ClearContentArea + menu.ShowCursor))
+	header := colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "--- New Post in %s ---"), t.topic.Name), "primary")
+	writer.Write([]byte(colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	writer.Write([]byte(colorScheme.Colorize(modules.T(writer, "Subject: "), "text")))
+	input, err := readLine(keyReader, writer)
+	subject := strings.TrimSpace(input)
+	if err != nil || subject == "" {
+		return false
+	}
+
+	body, ok := editor.New(writer, keyReader, colorScheme, "New Post: "+subject).Edit("")
+	if !ok || strings.TrimSpace(body) == "" {
+		showMessage(writer, keyReader, colorScheme, "Post aborted.", "secondary")
+		return false
+	}
+
+	if !dialog.Confirm(writer, keyReader, colorScheme, fmt.Sprintf(modules.T(writer, "Post \"%s\"?"), subject), true) {
+		showMessage(writer, keyReader, colorScheme, "Post aborted.", "secondary")
+		return false
+	}
+
+	post := &database.Post{
+		TopicID: t.topic.ID,
+		Author:  t.module.username,
+		Subject: subject,
+		Body:    t.module.withTagline(body),
+	}
+	if err := db.CreatePost(post); err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to post message: ")+modules.ErrorText(writer, err), "error")
+		return false
+	}
+
+	t.topic.PostCount++
+	// Your own post isn't news to you
+	t.module.markRead(post)
+	showMessage(writer, keyReader, colorScheme, "Message posted.", "success")
+	return true
+}
+
+// showReplyForm opens the editor for a reply to post, or to parent within
+// its thread, and saves it after confirmation. It returns the new reply's
+// ID, or 0 if nothing was posted.
+func (t *TopicOption) showReplyForm(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, post *database.Post, parent *database.Reply) int {
+	if denyIfReadOnly(writer, keyReader, db, colorScheme) || t.denyIfLocked(writer, keyReader, colorScheme) {
+		return 0
+	}
+
+	subject := post.Subject
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+	title := subject
+	if parent != nil {
+		title = fmt.Sprintf(modules.T(writer, "%s (to %s)"), subject, parent.Author)
+	}
+
+	body, ok := editor.New(writer, keyReader, colorScheme, title).Edit("")
+	if !ok || strings.TrimSpace(body) == "" {
+		showMessage(writer, keyReader, colorScheme, "Reply aborted.", "secondary")
+		return 0
+	}
+
+	if !dialog.Confirm(writer, keyReader, colorScheme, fmt.Sprintf(modules.T(writer, "Post this reply to \"%s\"?"), post.Subject), true) {
+		showMessage(writer, keyReader, colorScheme, "Reply aborted.", "secondary")
+		return 0
+	}
+
+	reply := &database.Reply{
+		PostID: post.ID,
+		Author: t.module.username,
+		Body:   t.module.withTagline(body),
+	}
+	if parent != nil {
+		reply.ParentID = parent.ID
+	}
+	if err := db.CreateReply(reply); err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to post reply: ")+modules.ErrorText(writer, err), "error")
+		return 0
+	}
+
+	// Tell whoever was answered, unless they answered themselves
+	answered, text := post.Author, fmt.Sprintf("%s replied to your post: %s", reply.Author, post.Subject)
+	if parent != nil {
+		answered, text = parent.Author, fmt.Sprintf("%s replied to you in: %s", reply.Author, post.Subject)
+	}
+	if answered != reply.Author {
+		modules.Notify(writer, answered, notify.Replies, text)
+	}
+
+	showMessage(writer, keyReader, colorScheme, "Reply posted.", "success")
+	return reply.ID
+}
+
+// bodyLines formats a post or reply body for the pager
+func bodyLines(body string, colorScheme menu.ColorScheme) []string {
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		lines = append(lines, "  "+colorScheme.Colorize(strings.TrimRight(line, "\r"), "text"))
+	}
+	return lines
+}
+
+// denyIfReadOnly shows a notice and returns true when the board is a read-only mirror
+func denyIfReadOnly(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
+	if !db.ReadOnly() {
+		return false
+	}
+	showMessage(writer, keyReader, colorScheme, "This board is a read-only mirror. Posting is disabled.", "error")
+	return true
+}
+
+// readLine reads a line of input from the user
+func readLine(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
+	return input.ReadLine(keyReader, writer, 0)
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
+	writer.Write([]byte(centeredMessage + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	keyReader.ReadKey()
+}
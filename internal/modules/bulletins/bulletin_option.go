@@ -15,14 +15,16 @@ type BulletinOption struct {
 	bulletin    *database.Bulletin
 	index       int
 	colorScheme menu.ColorScheme
+	module      *Module // Tracks which bulletins the user has read
 }
 
 // NewBulletinOption creates a new bulletin option
-func NewBulletinOption(bulletin *database.Bulletin, index int, colorScheme menu.ColorScheme) *BulletinOption {
+func NewBulletinOption(bulletin *database.Bulletin, index int, colorScheme menu.ColorScheme, module *Module) *BulletinOption {
 	return &BulletinOption{
 		bulletin:    bulletin,
 		index:       index,
 		colorScheme: colorScheme,
+		module:      module,
 	}
 }
 
@@ -38,7 +40,11 @@ func (b *BulletinOption) GetTitle() string {
 
 // GetDescription implements MenuOption interface
 func (b *BulletinOption) GetDescription() string {
-	return fmt.Sprintf("%d) %s", b.index+1, b.bulletin.Title)
+	marker := " "
+	if b.module.isNew(b.bulletin.ID) {
+		marker = "*"
+	}
+	return fmt.Sprintf("%s %d) %s", marker, b.index+1, b.bulletin.Title)
 }
 
 // Execute implements MenuOption interface
@@ -52,7 +58,7 @@ func (b *BulletinOption) Execute(writer modules.Writer, keyReader modules.KeyRea
 	// Author and date info
 	info := fmt.Sprintf("By: %s | Date: %s", b.bulletin.Author, b.bulletin.CreatedAt.Format("January 2, 2006"))
 	infoColored := colorScheme.Colorize(info, "secondary")
-	centeredInfo := colorScheme.CenterText(infoColored, 79)
+	centeredInfo := colorScheme.CenterText(infoColored, modules.Width(writer))
 	contentLines = append(contentLines, centeredInfo, "")
 
 	// Add body lines with proper formatting
@@ -61,7 +67,7 @@ func (b *BulletinOption) Execute(writer modules.Writer, keyReader modules.KeyRea
 			contentLines = append(contentLines, "")
 		} else {
 			lineColored := colorScheme.Colorize(line, "text")
-			centeredLine := colorScheme.CenterText(lineColored, 79)
+			centeredLine := colorScheme.CenterText(lineColored, modules.Width(writer))
 			contentLines = append(contentLines, centeredLine)
 		}
 	}
@@ -93,6 +99,11 @@ func (b *BulletinOption) Execute(writer modules.Writer, keyReader modules.KeyRea
 	// Display bulletin using pager
 	title := fmt.Sprintf("--- %s ---", b.bulletin.Title)
 	p.Display(contentLines, title)
+	// A caller who hung up partway through hasn't read it
+	if modules.HungUp(writer) {
+		return false
+	}
+	b.module.markRead(b.bulletin.ID)
 
 	return true
 }
@@ -1,9 +1,15 @@
 package bulletins
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"bbs/internal/database"
 	"bbs/internal/menu"
+	"bbs/internal/modules"
 	"bbs/internal/modules/base"
+	"bbs/internal/transcript"
 )
 
 // Module implements the bulletins functionality using database-driven options
@@ -11,13 +17,16 @@ type Module struct {
 	*base.Module
 	db          *database.DB
 	colorScheme menu.ColorScheme
+	userID      int
+	lastRead    int // Highest bulletin ID the user has read
 }
 
-// NewModule creates a new bulletins module
-func NewModule(db *database.DB, colorScheme menu.ColorScheme) *Module {
+// NewModule creates a bulletins module for the given user
+func NewModule(db *database.DB, colorScheme menu.ColorScheme, userID int) *Module {
 	m := &Module{
 		db:          db,
 		colorScheme: colorScheme,
+		userID:      userID,
 	}
 	m.Module = base.NewModule(db, colorScheme, m)
 	return m
@@ -25,18 +34,30 @@ func NewModule(db *database.DB, colorScheme menu.ColorScheme) *Module {
 
 // LoadOptions implements OptionProvider interface
 func (m *Module) LoadOptions(db *database.DB) ([]base.MenuOption, error) {
-	bulletins, err := db.GetBulletins(50)
+	options, _, err := m.LoadPage(db, 0, 50)
+	return options, err
+}
+
+// LoadPage implements PagedProvider interface. Bulletins are numbered on
+// each page from 1, to match the number keys.
+func (m *Module) LoadPage(db *database.DB, offset, limit int) ([]base.MenuOption, int, error) {
+	bulletins, total, err := db.GetBulletinPage(limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
+	lastRead, err := db.GetLastRead(m.userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	m.lastRead = lastRead[database.BulletinsArea]
 
 	var options []base.MenuOption
 	for i, bulletin := range bulletins {
-		option := NewBulletinOption(&bulletin, i, m.colorScheme)
+		option := NewBulletinOption(&bulletin, i, m.colorScheme, m)
 		options = append(options, option)
 	}
 
-	return options, nil
+	return options, total, nil
 }
 
 // GetMenuTitle implements OptionProvider interface
@@ -46,5 +67,72 @@ func (m *Module) GetMenuTitle() string {
 
 // GetInstructions implements OptionProvider interface
 func (m *Module) GetInstructions() string {
-	return "Navigate: ↑↓  Read: Enter  Quit: Q"
+	return "Navigate: ↑↓  Page: PgUp/PgDn  Read: Enter  Export: X  Quit: Q"
+}
+
+// HandleKey implements KeyHandler interface, sending the selected bulletin
+// as plain text when X is pressed
+func (m *Module) HandleKey(key string, option base.MenuOption, writer modules.Writer, keyReader modules.KeyReader) bool {
+	if b, ok := option.(*BulletinOption); ok && strings.ToLower(key) == "x" {
+		base.Export(writer, keyReader, m.colorScheme, fmt.Sprintf("BULLETIN-%d.TXT", b.bulletin.ID), func(width int) string {
+			return transcript.Bulletin(b.bulletin, width)
+		})
+	}
+	return true
+}
+
+// isNew reports whether the user hasn't read a bulletin yet
+func (m *Module) isNew(id int) bool {
+	return id > m.lastRead
+}
+
+// markRead moves the user's last-read pointer past a bulletin and notes
+// they have seen it
+func (m *Module) markRead(id int) {
+	if id > m.lastRead {
+		m.lastRead = id
+	}
+	if !m.db.ReadOnly() {
+		m.db.MarkRead(m.userID, database.BulletinsArea, id)
+		m.db.MarkBulletinSeen(m.userID, id)
+	}
+}
+
+// ShowBulletin reads one bulletin outside the list, as when a search
+// finds it
+func (m *Module) ShowBulletin(writer modules.Writer, keyReader modules.KeyReader, id int) {
+	bulletin, err := m.db.GetBulletinByID(id)
+	if err != nil {
+		return
+	}
+	NewBulletinOption(bulletin, 0, m.colorScheme, m).Execute(writer, keyReader, m.db, m.colorScheme)
+}
+
+// ShowUnseen shows the bulletins the user has never read, oldest first,
+// as when they log in. After each one they can go on to the next or leave
+// the rest for their next call.
+func (m *Module) ShowUnseen(writer modules.Writer, keyReader modules.KeyReader) {
+	unseen, err := m.db.GetUnseenBulletins(m.userID, time.Now())
+	if err != nil || len(unseen) == 0 {
+		return
+	}
+
+	for i := range unseen {
+		if i > 0 {
+			writer.Write([]byte(menu.ClearScreen))
+			left := fmt.Sprintf("%d more bulletin(s) you haven't seen. Enter: Next  S: Skip", len(unseen)-i)
+			writer.Write([]byte(m.colorScheme.CenterText(m.colorScheme.Colorize(left, "secondary"), modules.Width(writer))))
+			key, err := keyReader.ReadKey()
+			if err != nil {
+				return
+			}
+			switch strings.ToLower(key) {
+			case "s", "q", "quit", "escape":
+				return
+			}
+		}
+		if !NewBulletinOption(&unseen[i], i, m.colorScheme, m).Execute(writer, keyReader, m.db, m.colorScheme) {
+			return
+		}
+	}
 }
@@ -0,0 +1,8 @@
+package bulletins
+
+import "embed"
+
+// Help holds the help topics for the bulletins
+//
+//go:embed help/*.md
+var Help embed.FS
@@ -0,0 +1,164 @@
+package callers
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/pager"
+)
+
+// lastCallersCount is how many calls the public list shows
+const lastCallersCount = 10
+
+// reportCount is how many calls the sysop report covers
+const reportCount = 200
+
+// LastCallers shows the most recent callers to everyone
+type LastCallers struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+}
+
+// NewLastCallers creates the last callers screen
+func NewLastCallers(db *database.DB, colorScheme menu.ColorScheme) *LastCallers {
+	return &LastCallers{
+		db:          db,
+		colorScheme: colorScheme,
+	}
+}
+
+// Execute lists the last callers until the user presses a key. Invisible
+// logins are left out.
+func (l *LastCallers) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	calls, err := l.db.GetRecentCalls(lastCallersCount, false)
+	if err != nil {
+		showMessage(writer, keyReader, l.colorScheme, "The caller log is not available right now.", "error")
+		return true
+	}
+
+	writer.Write([]byte(menu.ClearContentArea + menu.HideCursor))
+	header := l.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "--- Last %d Callers ---"), lastCallersCount), "primary")
+	writer.Write([]byte(l.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	headerLine := fmt.Sprintf("%-16s %-5s %-14s %-8s", "User", "Node", "Logged On", "Length")
+	writer.Write([]byte(l.colorScheme.CenterText(l.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+	separator := l.colorScheme.DrawSeparator(len(headerLine), "─")
+	writer.Write([]byte(l.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+	if len(calls) == 0 {
+		msg := l.colorScheme.Colorize(modules.T(writer, "Nobody has called yet."), "secondary")
+		writer.Write([]byte(l.colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+	}
+	for _, call := range calls {
+		line := fmt.Sprintf("%-16s %-5d %-14s %-8s", ansi.Ellipsize(call.Username, 16), call.Node,
+			call.LoginAt.Format("Jan 02 15:04"), callLength(call))
+		writer.Write([]byte(l.colorScheme.CenterText(l.colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+	}
+
+	writer.Write([]byte("\n" + l.colorScheme.CenterText(l.colorScheme.Colorize(modules.T(writer, "Press any key to return."), "secondary"), modules.Width(writer))))
+	if _, err := keyReader.ReadKey(); err != nil {
+		return false
+	}
+	return true
+}
+
+// Report is the sysop's view of the caller log, including invisible calls
+// and what each caller did
+type Report struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+}
+
+// NewReport creates the sysop caller report
+func NewReport(db *database.DB, colorScheme menu.ColorScheme) *Report {
+	return &Report{
+		db:          db,
+		colorScheme: colorScheme,
+	}
+}
+
+// Execute pages through the recent calls
+func (r *Report) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	calls, err := r.db.GetRecentCalls(reportCount, true)
+	if err != nil {
+		showMessage(writer, keyReader, r.colorScheme, "The caller log is not available right now.", "error")
+		return true
+	}
+	if len(calls) == 0 {
+		showMessage(writer, keyReader, r.colorScheme, "The caller log is empty.", "secondary")
+		return true
+	}
+
+	headerLine := fmt.Sprintf("%-14s %-16s %-4s %-6s %s", "Logged On", "User", "Node", "Length", "Did")
+	lines := []string{
+		r.colorScheme.Colorize(headerLine, "accent"),
+		r.colorScheme.DrawSeparator(77, "─"),
+	}
+	for _, call := range calls {
+		username := call.Username
+		var actions []string
+		for _, action := range call.Actions {
+			if action == database.ActionInvisible {
+				username += "*"
+				continue
+			}
+			actions = append(actions, action)
+		}
+		line := fmt.Sprintf("%-14s %-16s %-4d %-6s %s", call.LoginAt.Format("Jan 02 15:04"), ansi.Ellipsize(username, 16),
+			call.Node, callLength(call), ansi.Ellipsize(strings.Join(actions, " "), 32))
+		lines = append(lines, r.colorScheme.Colorize(line, "text"))
+	}
+	lines = append(lines, "", r.colorScheme.Colorize(modules.T(writer, "* Invisible login"), "secondary"))
+
+	termSizer := pager.NewTerminalSizerFromWriter(writer)
+	writerAdapter := pager.NewWriterAdapter(writer, termSizer)
+
+	// Give the pager status bar control when the writer supports it
+	type StatusBarController interface {
+		Pause()
+		Resume()
+	}
+	if sbCtrl, ok := writer.(StatusBarController); ok {
+		writerAdapter.WithStatusBarManager(sbCtrl)
+	}
+
+	p := pager.NewPager(writerAdapter, keyReader, writerAdapter, r.colorScheme)
+	if writerAdapter.StatusBarMgr != nil {
+		p.WithStatusBar(writerAdapter)
+	}
+	if err := p.Display(lines, "--- Caller Report ---"); err != nil {
+		return false
+	}
+	return true
+}
+
+// callLength formats how long a call lasted, or "-" when it has no end
+func callLength(call database.Call) string {
+	if call.LogoutAt == nil {
+		return "-"
+	}
+	d := call.LogoutAt.Sub(call.LoginAt)
+	return fmt.Sprintf("%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
+	writer.Write([]byte(centeredMessage + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	keyReader.ReadKey()
+}
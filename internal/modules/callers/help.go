@@ -0,0 +1,8 @@
+package callers
+
+import "embed"
+
+// Help holds the help topics for the last callers list
+//
+//go:embed help/*.md
+var Help embed.FS
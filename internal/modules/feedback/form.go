@@ -0,0 +1,159 @@
+package feedback
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// LoginName is the login name callers without an account use to leave the
+// sysop a note
+const LoginName = "feedback"
+
+// lineWidth is the longest line of a note
+const lineWidth = 72
+
+// Form lets a caller who isn't logged in leave a short note for the sysop.
+// Notes are limited per address and for the whole board so the form can't
+// be used to flood the queue.
+type Form struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	config      config.FeedbackConfig
+	address     string
+}
+
+// NewForm creates the feedback form for a caller connecting from address
+func NewForm(db *database.DB, colorScheme menu.ColorScheme, cfg config.FeedbackConfig, address string) *Form {
+	return &Form{
+		db:          db,
+		colorScheme: colorScheme,
+		config:      cfg,
+		address:     address,
+	}
+}
+
+// Execute takes the caller's note and stores it. It returns true if a note
+// was saved.
+func (f *Form) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	if !f.config.Enabled || f.db.ReadOnly() {
+		showMessage(writer, keyReader, f.colorScheme, "Feedback is not being taken right now.", "error")
+		return false
+	}
+	if msg := f.limited(time.Now()); msg != "" {
+		showMessage(writer, keyReader, f.colorScheme, msg, "error")
+		return false
+	}
+
+	writer.Write([]byte(menu.ClearScreen))
+	header := f.colorScheme.Colorize(modules.T(writer, "--- Leave a Note for the Sysop ---"), "primary")
+	writer.Write([]byte(f.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	intro := modules.T(writer, "Having trouble registering or logging in? Tell the sysop what happened.")
+	writer.Write([]byte(f.colorScheme.Colorize(intro, "text") + "\n"))
+	writer.Write([]byte(f.colorScheme.Colorize(modules.T(writer, "Press ESC at any time to cancel."), "secondary") + "\n\n"))
+
+	writer.Write([]byte(f.colorScheme.Colorize(modules.T(writer, "Your name (optional): "), "text")))
+	name, err := readLine(keyReader, writer, 40)
+	if err != nil {
+		return false
+	}
+	writer.Write([]byte(f.colorScheme.Colorize(modules.T(writer, "How to reach you, e.g. email (optional): "), "text")))
+	contact, err := readLine(keyReader, writer, 60)
+	if err != nil {
+		return false
+	}
+
+	maxLines := f.config.MaxLines
+	if maxLines <= 0 {
+		maxLines = 5
+	}
+	prompt := fmt.Sprintf(modules.T(writer, "\nYour message, up to %d lines. An empty line sends it.\n"), maxLines)
+	writer.Write([]byte(f.colorScheme.Colorize(prompt, "text")))
+
+	var lines []string
+	for len(lines) < maxLines {
+		writer.Write([]byte(f.colorScheme.Colorize(fmt.Sprintf("%d: ", len(lines)+1), "accent")))
+		line, err := readLine(keyReader, writer, lineWidth)
+		if err != nil {
+			return false
+		}
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		showMessage(writer, keyReader, f.colorScheme, "Nothing to send. No note was left.", "text")
+		return false
+	}
+
+	// Check again in case another note arrived while this one was typed
+	now := time.Now()
+	if msg := f.limited(now); msg != "" {
+		showMessage(writer, keyReader, f.colorScheme, msg, "error")
+		return false
+	}
+
+	note := &database.Feedback{
+		Name:      strings.TrimSpace(name),
+		Contact:   strings.TrimSpace(contact),
+		Message:   strings.Join(lines, "\n"),
+		Address:   f.address,
+		CreatedAt: now,
+	}
+	if err := f.db.AddFeedback(note); err != nil {
+		showMessage(writer, keyReader, f.colorScheme, "Your note could not be saved. Please try again later.", "error")
+		return false
+	}
+
+	showMessage(writer, keyReader, f.colorScheme, "Thanks! Your note has been left for the sysop.", "success")
+	return true
+}
+
+// limited returns why the caller can't leave a note right now, or "" if
+// they can
+func (f *Form) limited(now time.Time) string {
+	if f.config.IntervalMinutes > 0 {
+		since := now.Add(-time.Duration(f.config.IntervalMinutes) * time.Minute)
+		count, err := f.db.CountFeedbackSince(f.address, since)
+		if err != nil {
+			return "Feedback is not being taken right now."
+		}
+		if count > 0 {
+			return "A note was already left from your address. Please try again later."
+		}
+	}
+	if f.config.MaxPerHour > 0 {
+		count, err := f.db.CountFeedbackSince("", now.Add(-time.Hour))
+		if err != nil {
+			return "Feedback is not being taken right now."
+		}
+		if count >= f.config.MaxPerHour {
+			return "The sysop's feedback queue is full. Please try again later."
+		}
+	}
+	return ""
+}
+
+// readLine reads a line of at most max characters, echoing printable ones
+func readLine(keyReader modules.KeyReader, writer modules.Writer, max int) (string, error) {
+	return input.ReadLine(keyReader, writer, max)
+}
+
+// showMessage displays a message and waits for a key
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(modules.T(writer, message), messageType), modules.Width(writer)) + "\n\n"))
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	writer.Write([]byte(colorScheme.CenterText(prompt, modules.Width(writer))))
+	keyReader.ReadKey()
+}
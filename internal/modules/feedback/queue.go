@@ -0,0 +1,143 @@
+package feedback
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// Queue lets the sysop read and delete the notes left through the form
+type Queue struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+}
+
+// NewQueue creates the sysop's feedback queue screen
+func NewQueue(db *database.DB, colorScheme menu.ColorScheme) *Queue {
+	return &Queue{
+		db:          db,
+		colorScheme: colorScheme,
+	}
+}
+
+// Execute lists the notes until the sysop quits
+func (q *Queue) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		notes, err := q.db.GetFeedback()
+		if err != nil {
+			showMessage(writer, keyReader, q.colorScheme, modules.T(writer, "Failed to load feedback: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+
+		writer.Write([]byte(menu.ClearScreen))
+
+		header := q.colorScheme.Colorize(modules.T(writer, "--- Feedback Queue ---"), "primary")
+		writer.Write([]byte(q.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+		if len(notes) == 0 {
+			msg := q.colorScheme.Colorize(modules.T(writer, "No feedback has been left."), "secondary")
+			writer.Write([]byte(q.colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+		} else {
+			headerLine := fmt.Sprintf("%-3s %-1s %-12s %-16s %-36s", "#", "", "Left", "Name", "Message")
+			writer.Write([]byte(q.colorScheme.CenterText(q.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+			separator := q.colorScheme.DrawSeparator(len(headerLine), "─")
+			writer.Write([]byte(q.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+			for i, n := range notes {
+				mark := "*"
+				if n.IsRead {
+					mark = " "
+				}
+				name := n.Name
+				if name == "" {
+					name = "(no name)"
+				}
+				first := strings.SplitN(n.Message, "\n", 2)[0]
+				line := fmt.Sprintf("%-3d %-1s %-12s %-16s %-36s", i+1, mark,
+					n.CreatedAt.Local().Format("Jan 02 15:04"), ansi.Ellipsize(name, 16), ansi.Ellipsize(first, 36))
+				writer.Write([]byte(q.colorScheme.CenterText(q.colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+			}
+			writer.Write([]byte("\n" + q.colorScheme.CenterText(q.colorScheme.Colorize(modules.T(writer, "* = unread"), "secondary"), modules.Width(writer)) + "\n"))
+		}
+
+		writer.Write([]byte("\n"))
+		instructions := q.colorScheme.Colorize(modules.T(writer, "R: Read a Note  D: Delete a Note  Q: Quit"), "secondary")
+		writer.Write([]byte(q.colorScheme.CenterText(instructions, modules.Width(writer)) + "\n"))
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "r":
+			if n := q.pick(writer, keyReader, notes, "Note number to read: "); n != nil {
+				q.show(writer, keyReader, n)
+			}
+		case "d":
+			n := q.pick(writer, keyReader, notes, "Note number to delete: ")
+			if n == nil {
+				continue
+			}
+			if err := q.db.DeleteFeedback(n.ID); err != nil {
+				showMessage(writer, keyReader, q.colorScheme, modules.ErrorText(writer, err), "error")
+				continue
+			}
+			showMessage(writer, keyReader, q.colorScheme, "Note deleted.", "success")
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// pick asks for a note number and returns that note, or nil
+func (q *Queue) pick(writer modules.Writer, keyReader modules.KeyReader, notes []database.Feedback, prompt string) *database.Feedback {
+	if len(notes) == 0 {
+		return nil
+	}
+	writer.Write([]byte("\n" + q.colorScheme.Colorize(prompt, "text")))
+	input, err := readLine(keyReader, writer, 4)
+	if err != nil || strings.TrimSpace(input) == "" {
+		return nil
+	}
+	index, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || index < 1 || index > len(notes) {
+		showMessage(writer, keyReader, q.colorScheme, "Invalid note number.", "error")
+		return nil
+	}
+	return &notes[index-1]
+}
+
+// show displays a whole note and marks it read
+func (q *Queue) show(writer modules.Writer, keyReader modules.KeyReader, n *database.Feedback) {
+	writer.Write([]byte(menu.ClearScreen))
+	header := q.colorScheme.Colorize(modules.T(writer, "--- Feedback ---"), "primary")
+	writer.Write([]byte(q.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	field := func(label, value string) {
+		if value == "" {
+			value = "-"
+		}
+		writer.Write([]byte(q.colorScheme.Colorize(fmt.Sprintf("%-9s ", label), "accent") + q.colorScheme.Colorize(value, "text") + "\n"))
+	}
+	field("Left:", n.CreatedAt.Local().Format("2006-01-02 15:04"))
+	field("From:", n.Address)
+	field("Name:", n.Name)
+	field("Contact:", n.Contact)
+	writer.Write([]byte("\n"))
+	for _, line := range strings.Split(n.Message, "\n") {
+		writer.Write([]byte(q.colorScheme.Colorize(line, "text") + "\n"))
+	}
+
+	if !n.IsRead {
+		q.db.MarkFeedbackRead(n.ID)
+	}
+
+	writer.Write([]byte("\n" + q.colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")))
+	keyReader.ReadKey()
+}
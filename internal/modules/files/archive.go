@@ -0,0 +1,187 @@
+package files
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bbs/internal/ansi"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// maxDIZSize is the most of a FILE_ID.DIZ that is shown
+const maxDIZSize = 4096
+
+// errNotZip is returned for uploads that aren't zip archives, which are the
+// only kind that can be looked inside
+var errNotZip = errors.New("not a zip archive")
+
+// descriptionFiles are the names a description is packed under in an
+// archive, the one to prefer first
+var descriptionFiles = []string{"FILE_ID.DIZ", "DESC.SDI"}
+
+// readDIZ returns the FILE_ID.DIZ or DESC.SDI description packed in a zip
+// archive, or "" if it has neither. DOS-era descriptions are CP437, so text
+// that isn't UTF-8 is read as that.
+func readDIZ(path string) (string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return "", errNotZip
+	}
+	defer archive.Close()
+
+	for _, name := range descriptionFiles {
+		for _, file := range archive.File {
+			if strings.EqualFold(filepath.Base(file.Name), name) {
+				return readDescription(file)
+			}
+		}
+	}
+	return "", nil
+}
+
+// readDescription reads a description file out of an archive
+func readDescription(file *zip.File) (string, error) {
+	r, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, maxDIZSize))
+	if err != nil {
+		return "", err
+	}
+
+	text := string(data)
+	if !utf8.ValidString(text) {
+		if text, err = charmap.CodePage437.NewDecoder().String(text); err != nil {
+			return "", err
+		}
+	}
+	// Keep the leading spaces that center DIZ lines, but not blank lines
+	return strings.Trim(strings.TrimRight(printable(text), " \t\n"), "\n"), nil
+}
+
+// printable drops ANSI codes and other control characters so a description
+// can't redraw the reviewer's screen
+func printable(text string) string {
+	text = ansi.Strip(strings.ReplaceAll(text, "\r\n", "\n"))
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r >= ' ' && r != 0x7f {
+			return r
+		}
+		return -1
+	}, text)
+}
+
+// testArchive reads every file in a zip archive through to the end, which
+// checks each one against its CRC, and returns how many there were
+func testArchive(path string) (int, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return 0, errNotZip
+	}
+	defer archive.Close()
+
+	count := 0
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return count, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		_, err = io.Copy(io.Discard, r)
+		r.Close()
+		if err != nil {
+			return count, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// maxMembers is the most files of an archive that are listed
+const maxMembers = 500
+
+// errTooLarge is returned for archive members over the extraction limit
+var errTooLarge = errors.New("too large to take out of the archive online")
+
+// member is a file packed in a zip archive
+type member struct {
+	Index     int // Position in the archive's directory
+	Name      string
+	Size      int64
+	Packed    int64
+	ModTime   time.Time
+	Encrypted bool
+}
+
+// listArchive returns the files in a zip archive, leaving out directories.
+// At most maxMembers are returned, along with how many there are in all.
+func listArchive(path string) ([]member, int, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, 0, errNotZip
+	}
+	defer archive.Close()
+
+	var members []member
+	total := 0
+	for i, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		total++
+		if len(members) == maxMembers {
+			continue
+		}
+		members = append(members, member{
+			Index:     i,
+			Name:      printable(file.Name),
+			Size:      int64(file.UncompressedSize64),
+			Packed:    int64(file.CompressedSize64),
+			ModTime:   file.Modified,
+			Encrypted: file.Flags&0x1 != 0,
+		})
+	}
+	return members, total, nil
+}
+
+// extractMember reads one file out of a zip archive. Files larger than
+// limit bytes are refused, whatever size the archive claims for them.
+func extractMember(path string, m member, limit int64) ([]byte, error) {
+	if m.Size > limit {
+		return nil, errTooLarge
+	}
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, errNotZip
+	}
+	defer archive.Close()
+	if m.Index >= len(archive.File) {
+		return nil, errors.New("archive changed")
+	}
+
+	r, err := archive.File[m.Index].Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errTooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,518 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/transfer"
+)
+
+// visibleFiles is how many files are listed on screen at once
+const visibleFiles = 14
+
+// maxDescriptionLength caps a description typed by the uploader
+const maxDescriptionLength = 70
+
+// Area is a directory of files callers can download from and upload to
+type Area struct {
+	Name  string
+	Path  string
+	Count int // Files in the area when it was listed
+}
+
+// FileInfo describes a file in an area
+type FileInfo struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Files returns the area's files sorted by name. Hidden files, including
+// uploads still in progress, are left out.
+func (a *Area) Files() ([]FileInfo, error) {
+	entries, err := os.ReadDir(a.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []FileInfo
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, FileInfo{Name: entry.Name(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return strings.ToLower(files[i].Name) < strings.ToLower(files[j].Name)
+	})
+	return files, nil
+}
+
+// AreaOption represents a file area menu option
+type AreaOption struct {
+	area   *Area
+	index  int
+	module *Module
+}
+
+// NewAreaOption creates a new file area option
+func NewAreaOption(area *Area, index int, module *Module) *AreaOption {
+	return &AreaOption{
+		area:   area,
+		index:  index,
+		module: module,
+	}
+}
+
+// GetID implements MenuOption interface
+func (a *AreaOption) GetID() string {
+	return "area_" + a.area.Name
+}
+
+// GetTitle implements MenuOption interface
+func (a *AreaOption) GetTitle() string {
+	return a.area.Name
+}
+
+// GetDescription implements MenuOption interface
+func (a *AreaOption) GetDescription() string {
+	return fmt.Sprintf("%d) %-30s %4d files", a.index+1, a.area.Name, a.area.Count)
+}
+
+// Execute implements MenuOption interface by listing the area's files
+func (a *AreaOption) Execute(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
+	selected := 0
+	reload := true
+	var files []FileInfo
+	var descriptions map[string]string
+	var totals *database.TransferTotals
+
+	for {
+		if reload {
+			var err error
+			if files, err = a.area.Files(); err != nil {
+				showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to list files: ")+modules.ErrorText(writer, err), "error")
+				return true
+			}
+			if descriptions, err = db.FileDescriptions(a.area.Name); err != nil {
+				showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to list files: ")+modules.ErrorText(writer, err), "error")
+				return true
+			}
+			if totals, err = db.GetTransferTotals(a.module.user.Username); err != nil {
+				showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to load transfer totals: ")+modules.ErrorText(writer, err), "error")
+				return true
+			}
+			a.area.Count = len(files)
+			reload = false
+		}
+
+		if selected >= len(files) {
+			selected = len(files) - 1
+		}
+		if selected < 0 {
+			selected = 0
+		}
+
+		a.renderFiles(writer, colorScheme, files, descriptions, totals, selected)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "up":
+			if selected > 0 {
+				selected--
+			}
+		case "down":
+			if selected < len(files)-1 {
+				selected++
+			}
+		case "pageup", "pagedown", "home", "end":
+			selected = menu.Jump(key, selected, len(files), menu.ListRows(writer))
+		case "enter", "d":
+			if len(files) > 0 {
+				a.download(writer, keyReader, db, colorScheme, files[selected], totals)
+				reload = true
+			}
+		case "i":
+			if len(files) > 0 {
+				a.showDescription(writer, keyReader, colorScheme, files[selected], descriptions[files[selected].Name])
+			}
+		case "v":
+			if len(files) > 0 {
+				a.viewArchive(writer, keyReader, db, colorScheme, files[selected])
+				reload = true
+			}
+		case "u":
+			a.upload(writer, keyReader, db, colorScheme)
+			reload = true
+		case "q", "quit", "escape":
+			writer.Write([]byte(menu.HideCursor))
+			return true
+		}
+	}
+}
+
+// renderFiles draws the area's file list with the selected file highlighted
+func (a *AreaOption) renderFiles(writer modules.Writer, colorScheme menu.ColorScheme, files []FileInfo, descriptions map[string]string, totals *database.TransferTotals, selected int) {
+	writer.Write([]byte(menu.ClearContentArea + menu.HideCursor))
+
+	header := colorScheme.Colorize(fmt.Sprintf("--- %s ---", a.area.Name), "primary")
+	writer.Write([]byte(colorScheme.CenterText(header, modules.Width(writer)) + "\n"))
+
+	stats := fmt.Sprintf("Downloaded: %s  Uploaded: %s  Available: %s",
+		formatSize(totals.DownloadBytes), formatSize(totals.UploadBytes), a.allowanceText(totals))
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(stats, "secondary"), modules.Width(writer)) + "\n\n"))
+
+	if len(files) == 0 {
+		msg := colorScheme.Colorize(modules.T(writer, "No files yet. Press U to upload one."), "secondary")
+		writer.Write([]byte(colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+	} else {
+		headerLine := fmt.Sprintf("%-4s %-44s %8s  %-10s", "#", "File", "Size", "Date")
+		writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+		separator := colorScheme.DrawSeparator(len(headerLine), "─")
+		writer.Write([]byte(colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+		// Scroll so the selected file stays on screen
+		start := 0
+		if selected >= visibleFiles {
+			start = selected - visibleFiles + 1
+		}
+		end := start + visibleFiles
+		if end > len(files) {
+			end = len(files)
+		}
+
+		for i := start; i < end; i++ {
+			file := files[i]
+			line := fmt.Sprintf("%-4d %-44s %8s  %-10s", i+1, ansi.Ellipsize(file.Name, 44),
+				formatSize(file.Size), file.ModTime.Format("2006-01-02"))
+			writer.Write([]byte(colorScheme.CenterText(colorScheme.HighlightSelection(line, i == selected, len(line)+2), modules.Width(writer)) + "\n"))
+		}
+
+		// The first line of the selected file's description; I shows it all
+		if description := firstLine(descriptions[files[selected].Name]); description != "" {
+			writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(ansi.Ellipsize(description, 75), "text"), modules.Width(writer))))
+		}
+	}
+
+	writer.Write([]byte("\n"))
+	instructions := colorScheme.Colorize(modules.T(writer, "↑↓: Select  Enter/D: Download  I: Info  V: View Zip  U: Upload  Q: Back"), "secondary")
+	writer.Write([]byte(colorScheme.CenterText(instructions, modules.Width(writer))))
+}
+
+// showDescription shows the whole description of a file
+func (a *AreaOption) showDescription(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, file FileInfo, description string) {
+	if description == "" {
+		showMessage(writer, keyReader, colorScheme, file.Name+" has no description.", "secondary")
+		return
+	}
+
+	writer.Write([]byte(menu.ClearContentArea))
+	header := colorScheme.Colorize("--- "+file.Name+" ---", "primary")
+	writer.Write([]byte(colorScheme.CenterText(header, modules.Width(writer)) + "\n"))
+	info := fmt.Sprintf("%s  %s", formatSize(file.Size), file.ModTime.Format("2006-01-02"))
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(info, "secondary"), modules.Width(writer)) + "\n\n"))
+	for _, line := range strings.Split(description, "\n") {
+		writer.Write([]byte("  " + colorScheme.Colorize(line, "text") + "\n"))
+	}
+	writer.Write([]byte("\n" + colorScheme.CenterText(colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text"), modules.Width(writer))))
+	keyReader.ReadKey()
+}
+
+// firstLine returns the first line of a description with any text on it
+func firstLine(description string) string {
+	for _, line := range strings.Split(description, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// allowanceText describes how much the user may still download
+func (a *AreaOption) allowanceText(totals *database.TransferTotals) string {
+	allowance := DownloadAllowance(a.module.config, a.module.user.AccessLevel, totals)
+	if allowance < 0 {
+		return "Unlimited"
+	}
+	return formatSize(allowance)
+}
+
+// download sends a file to the caller if their ratio allows it
+func (a *AreaOption) download(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, file FileInfo, totals *database.TransferTotals) {
+	allowance := DownloadAllowance(a.module.config, a.module.user.AccessLevel, totals)
+	if allowance >= 0 && file.Size > allowance {
+		msg := fmt.Sprintf(modules.T(writer, "%s is %s but your ratio allows %s more. Upload files to earn download credit."),
+			file.Name, formatSize(file.Size), formatSize(allowance))
+		showMessage(writer, keyReader, colorScheme, msg, "error")
+		return
+	}
+
+	f, err := os.Open(filepath.Join(a.area.Path, file.Name))
+	if err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to open file: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	defer f.Close()
+
+	a.showTransferScreen(writer, colorScheme, fmt.Sprintf("Sending %s (%s)", file.Name, formatSize(file.Size)))
+
+	var sent int64
+	start := time.Now()
+	err = a.module.channel.Transfer(func(rw io.ReadWriter) error {
+		var err error
+		sent, err = transfer.Send(rw, []transfer.File{{Name: file.Name, Size: file.Size, ModTime: file.ModTime, Data: f}},
+			transfer.Options{Progress: a.progress("Downloading")})
+		return err
+	})
+	elapsed := time.Since(start)
+
+	if err != nil {
+		showMessage(writer, keyReader, colorScheme, transferError(err), "error")
+		return
+	}
+	if sent == 0 {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Your terminal skipped ")+file.Name+".", "secondary")
+		return
+	}
+
+	if !db.ReadOnly() {
+		if err := db.RecordTransfer(a.module.user.Username, a.area.Name, file.Name, database.TransferDownload, sent); err != nil {
+			showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to record download: ")+modules.ErrorText(writer, err), "error")
+			return
+		}
+	}
+
+	showMessage(writer, keyReader, colorScheme, modules.T(writer, "Sent ")+file.Name+". "+transferSummary(sent, elapsed), "success")
+}
+
+// upload receives files from the caller into the area
+func (a *AreaOption) upload(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) {
+	if denyIfReadOnly(writer, keyReader, db, colorScheme) {
+		return
+	}
+	if Mirrored(a.module.config, a.area.Name) {
+		showMessage(writer, keyReader, colorScheme, a.area.Name+" is kept in step with another site. Uploads are disabled.", "error")
+		return
+	}
+
+	// Uploads to a reviewed area wait out of sight until they are approved
+	dest := a.area.Path
+	reviewed := Reviewed(a.module.config, a.area.Name)
+	if reviewed {
+		dest = filepath.Join(a.area.Path, pendingDir)
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to prepare the upload: ")+modules.ErrorText(writer, err), "error")
+			return
+		}
+	}
+
+	limit := ""
+	if a.module.config.MaxUploadKB > 0 {
+		limit = fmt.Sprintf(" (up to %s per file)", formatSize(int64(a.module.config.MaxUploadKB)*1024))
+	}
+	a.showTransferScreen(writer, colorScheme, "Upload to "+a.area.Name+limit)
+
+	var received []transfer.Received
+	start := time.Now()
+	err := a.module.channel.Transfer(func(rw io.ReadWriter) error {
+		var err error
+		received, err = transfer.Receive(rw, dest, transfer.Options{
+			Progress: a.progress("Uploading"),
+			MaxSize:  int64(a.module.config.MaxUploadKB) * 1024,
+		})
+		return err
+	})
+	elapsed := time.Since(start)
+
+	// Credit whatever arrived, even if the batch ended with an error. Files
+	// waiting for review are credited when they are approved.
+	var total int64
+	var names []string
+	for _, file := range received {
+		var recordErr error
+		if reviewed {
+			recordErr = db.AddPendingUpload(&database.PendingUpload{Area: a.area.Name, Filename: file.Name, Uploader: a.module.user.Username, Bytes: file.Size})
+		} else {
+			recordErr = db.RecordTransfer(a.module.user.Username, a.area.Name, file.Name, database.TransferUpload, file.Size)
+		}
+		if recordErr != nil {
+			showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to record upload: ")+recordErr.Error(), "error")
+			return
+		}
+		total += file.Size
+		names = append(names, file.Name)
+	}
+
+	a.describe(writer, keyReader, db, colorScheme, received)
+
+	switch {
+	case err != nil && len(received) == 0:
+		showMessage(writer, keyReader, colorScheme, transferError(err), "error")
+	case len(received) == 0:
+		showMessage(writer, keyReader, colorScheme, "No files were received.", "secondary")
+	case reviewed:
+		msg := fmt.Sprintf(modules.T(writer, "Received %s. %s. Uploads here are listed once the sysop approves them."),
+			strings.Join(names, ", "), transferSummary(total, elapsed))
+		showMessage(writer, keyReader, colorScheme, msg, "success")
+	default:
+		msg := fmt.Sprintf(modules.T(writer, "Received %s. %s"), strings.Join(names, ", "), transferSummary(total, elapsed))
+		showMessage(writer, keyReader, colorScheme, msg, "success")
+	}
+}
+
+// describe gives each uploaded file a description: the FILE_ID.DIZ or
+// DESC.SDI packed in a zip, or else one line asked of the uploader
+func (a *AreaOption) describe(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, received []transfer.Received) {
+	asked := false
+	for _, file := range received {
+		description, source := "", database.DescriptionDIZ
+		if diz, err := readDIZ(file.Path); err == nil {
+			description = diz
+		}
+		if description == "" {
+			if !asked {
+				writer.Write([]byte(menu.ClearContentArea + menu.ShowCursor))
+				header := colorScheme.Colorize(modules.T(writer, "--- Describe Your Uploads ---"), "primary")
+				writer.Write([]byte(colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+				asked = true
+			}
+			writer.Write([]byte(colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "%s (Enter to skip): "), file.Name), "text")))
+			line, err := readLine(keyReader, writer)
+			if err != nil {
+				break
+			}
+			description, source = ansi.Ellipsize(strings.TrimSpace(line), maxDescriptionLength), database.DescriptionUploader
+		}
+		if description == "" {
+			continue
+		}
+		if err := db.SetFileDescription(a.area.Name, file.Name, description, source); err != nil {
+			showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to save the description: ")+modules.ErrorText(writer, err), "error")
+			break
+		}
+	}
+	if asked {
+		writer.Write([]byte(menu.HideCursor))
+	}
+}
+
+// showTransferScreen tells the caller to start their terminal's transfer
+func (a *AreaOption) showTransferScreen(writer modules.Writer, colorScheme menu.ColorScheme, title string) {
+	writer.Write([]byte(menu.ClearContentArea))
+	header := colorScheme.Colorize("--- "+title+" ---", "primary")
+	writer.Write([]byte(colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	lines := []string{
+		"Starting ZMODEM. Most terminals begin the transfer automatically;",
+		"otherwise start a ZMODEM transfer from your terminal now.",
+		"Press Ctrl+X five times to cancel.",
+	}
+	for _, line := range lines {
+		writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+	}
+	writer.Write([]byte("\n"))
+}
+
+// progress returns a transfer callback that shows the file and percentage
+// done in who's online
+func (a *AreaOption) progress(verb string) transfer.Progress {
+	lastPercent := -1
+	return func(name string, transferred, total int64) {
+		percent := 100
+		if total > 0 {
+			percent = int(transferred * 100 / total)
+		}
+		if percent == lastPercent {
+			return
+		}
+		lastPercent = percent
+		a.module.channel.SetActivity(fmt.Sprintf("%s %s (%d%%)", verb, name, percent))
+	}
+}
+
+// transferError describes why a transfer failed
+func transferError(err error) string {
+	if errors.Is(err, transfer.ErrCancelled) {
+		return "Transfer cancelled."
+	}
+	return "Transfer failed: " + err.Error()
+}
+
+// transferSummary reports the size and speed of a finished transfer
+func transferSummary(bytes int64, elapsed time.Duration) string {
+	seconds := elapsed.Seconds()
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("%s in %s (%d cps)", formatSize(bytes), elapsed.Round(time.Second), int64(float64(bytes)/seconds))
+}
+
+// formatSize formats a byte count for display
+func formatSize(bytes int64) string {
+	switch {
+	case bytes >= 1024*1024:
+		return fmt.Sprintf("%.1fM", float64(bytes)/(1024*1024))
+	case bytes >= 1024:
+		return fmt.Sprintf("%.1fK", float64(bytes)/1024)
+	default:
+		return fmt.Sprintf("%dB", bytes)
+	}
+}
+
+// denyIfReadOnly shows a notice and returns true when the board is a read-only mirror
+func denyIfReadOnly(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
+	if !db.ReadOnly() {
+		return false
+	}
+	showMessage(writer, keyReader, colorScheme, "This board is a read-only mirror. Uploads are disabled.", "error")
+	return true
+}
+
+// Mirrored reports whether an area is filled from a mirror source, and so
+// takes no uploads
+func Mirrored(cfg config.FilesConfig, area string) bool {
+	for _, src := range cfg.Mirror.Sources {
+		if strings.EqualFold(src.Area, area) {
+			return true
+		}
+	}
+	return false
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
+	writer.Write([]byte(centeredMessage + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	keyReader.ReadKey()
+}
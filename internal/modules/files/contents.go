@@ -0,0 +1,204 @@
+package files
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/transfer"
+)
+
+// defaultMaxMemberKB is the largest file taken out of an archive online
+// when the config doesn't say
+const defaultMaxMemberKB = 2048
+
+// memberLimit returns the largest archive member a caller may download on
+// its own, in bytes
+func (a *AreaOption) memberLimit() int64 {
+	kb := a.module.config.MaxMemberKB
+	if kb <= 0 {
+		kb = defaultMaxMemberKB
+	}
+	return int64(kb) * 1024
+}
+
+// viewArchive lists the files packed in a zip archive and sends single
+// files out of it
+func (a *AreaOption) viewArchive(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, file FileInfo) {
+	archivePath := filepath.Join(a.area.Path, file.Name)
+	members, total, err := listArchive(archivePath)
+	if errors.Is(err, errNotZip) {
+		showMessage(writer, keyReader, colorScheme, file.Name+" isn't a zip archive, so its contents can't be shown.", "error")
+		return
+	}
+	if err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to read archive: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+
+	selected := 0
+	for {
+		a.renderMembers(writer, colorScheme, file, members, total, selected)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return
+		}
+
+		switch strings.ToLower(key) {
+		case "up":
+			if selected > 0 {
+				selected--
+			}
+		case "down":
+			if selected < len(members)-1 {
+				selected++
+			}
+		case "pageup", "pagedown", "home", "end":
+			selected = menu.Jump(key, selected, len(members), menu.ListRows(writer))
+		case "enter", "d":
+			if len(members) > 0 {
+				a.downloadMember(writer, keyReader, db, colorScheme, file, members[selected])
+			}
+		case "q", "quit", "escape":
+			return
+		}
+	}
+}
+
+// renderMembers draws an archive's file list with the selected file
+// highlighted
+func (a *AreaOption) renderMembers(writer modules.Writer, colorScheme menu.ColorScheme, file FileInfo, members []member, total, selected int) {
+	writer.Write([]byte(menu.ClearContentArea + menu.HideCursor))
+
+	header := colorScheme.Colorize(fmt.Sprintf("--- %s ---", file.Name), "primary")
+	writer.Write([]byte(colorScheme.CenterText(header, modules.Width(writer)) + "\n"))
+
+	summary := fmt.Sprintf("%d files  Largest download: %s", total, formatSize(a.memberLimit()))
+	if total > len(members) {
+		summary = fmt.Sprintf("First %d of %d files  Largest download: %s", len(members), total, formatSize(a.memberLimit()))
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(summary, "secondary"), modules.Width(writer)) + "\n\n"))
+
+	if len(members) == 0 {
+		msg := colorScheme.Colorize(modules.T(writer, "The archive is empty."), "secondary")
+		writer.Write([]byte(colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+	} else {
+		headerLine := fmt.Sprintf("%-4s %-38s %8s %6s  %-10s", "#", "File", "Size", "Ratio", "Date")
+		writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+		separator := colorScheme.DrawSeparator(len(headerLine), "─")
+		writer.Write([]byte(colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+		// Scroll so the selected file stays on screen
+		start := 0
+		if selected >= visibleFiles {
+			start = selected - visibleFiles + 1
+		}
+		end := start + visibleFiles
+		if end > len(members) {
+			end = len(members)
+		}
+
+		for i := start; i < end; i++ {
+			m := members[i]
+			name := m.Name
+			if m.Encrypted {
+				name = "*" + name
+			}
+			line := fmt.Sprintf("%-4d %-38s %8s %6s  %-10s", i+1, ansi.Ellipsize(name, 38),
+				formatSize(m.Size), packedRatio(m), m.ModTime.Format("2006-01-02"))
+			writer.Write([]byte(colorScheme.CenterText(colorScheme.HighlightSelection(line, i == selected, len(line)+2), modules.Width(writer)) + "\n"))
+		}
+	}
+
+	writer.Write([]byte("\n"))
+	instructions := colorScheme.Colorize(modules.T(writer, "↑↓: Select  Enter/D: Download File  Q: Back"), "secondary")
+	writer.Write([]byte(colorScheme.CenterText(instructions, modules.Width(writer))))
+}
+
+// packedRatio shows how much of a member's size compression saved
+func packedRatio(m member) string {
+	if m.Size <= 0 || m.Packed >= m.Size {
+		return "0%"
+	}
+	return fmt.Sprintf("%d%%", 100-m.Packed*100/m.Size)
+}
+
+// downloadMember sends one file out of an archive if it is small enough and
+// the caller's ratio allows it
+func (a *AreaOption) downloadMember(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme, file FileInfo, m member) {
+	name := path.Base(strings.ReplaceAll(m.Name, "\\", "/"))
+	if m.Encrypted {
+		showMessage(writer, keyReader, colorScheme, name+" is password protected. Download the whole archive instead.", "error")
+		return
+	}
+	if limit := a.memberLimit(); m.Size > limit {
+		msg := fmt.Sprintf(modules.T(writer, "%s is %s; files over %s can't be taken out online. Download the whole archive instead."),
+			name, formatSize(m.Size), formatSize(limit))
+		showMessage(writer, keyReader, colorScheme, msg, "error")
+		return
+	}
+
+	totals, err := db.GetTransferTotals(a.module.user.Username)
+	if err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to load transfer totals: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	allowance := DownloadAllowance(a.module.config, a.module.user.AccessLevel, totals)
+	if allowance >= 0 && m.Size > allowance {
+		msg := fmt.Sprintf(modules.T(writer, "%s is %s but your ratio allows %s more. Upload files to earn download credit."),
+			name, formatSize(m.Size), formatSize(allowance))
+		showMessage(writer, keyReader, colorScheme, msg, "error")
+		return
+	}
+
+	data, err := extractMember(filepath.Join(a.area.Path, file.Name), m, a.memberLimit())
+	if errors.Is(err, errTooLarge) {
+		showMessage(writer, keyReader, colorScheme, name+modules.T(writer, " is ")+errTooLarge.Error()+". Download the whole archive instead.", "error")
+		return
+	}
+	if err != nil {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to extract ")+name+": "+modules.ErrorText(writer, err), "error")
+		return
+	}
+
+	a.showTransferScreen(writer, colorScheme, fmt.Sprintf("Sending %s from %s (%s)", name, file.Name, formatSize(int64(len(data)))))
+
+	var sent int64
+	start := time.Now()
+	err = a.module.channel.Transfer(func(rw io.ReadWriter) error {
+		var err error
+		sent, err = transfer.Send(rw, []transfer.File{{Name: name, Size: int64(len(data)), ModTime: m.ModTime, Data: bytes.NewReader(data)}},
+			transfer.Options{Progress: a.progress("Downloading")})
+		return err
+	})
+	elapsed := time.Since(start)
+
+	if err != nil {
+		showMessage(writer, keyReader, colorScheme, transferError(err), "error")
+		return
+	}
+	if sent == 0 {
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Your terminal skipped ")+name+".", "secondary")
+		return
+	}
+
+	if !db.ReadOnly() {
+		// Recorded as ARCHIVE.ZIP/FILE so the archive it came from is kept
+		if err := db.RecordTransfer(a.module.user.Username, a.area.Name, file.Name+"/"+name, database.TransferDownload, sent); err != nil {
+			showMessage(writer, keyReader, colorScheme, modules.T(writer, "Failed to record download: ")+modules.ErrorText(writer, err), "error")
+			return
+		}
+	}
+
+	showMessage(writer, keyReader, colorScheme, modules.T(writer, "Sent ")+name+". "+transferSummary(sent, elapsed), "success")
+}
@@ -0,0 +1,127 @@
+package files
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/modules/base"
+)
+
+// Channel gives the file areas raw access to the caller's connection
+type Channel interface {
+	// Transfer runs fn with the raw connection while other output is held back
+	Transfer(fn func(rw io.ReadWriter) error) error
+	// SetActivity updates what the caller is shown doing in who's online
+	SetActivity(doing string)
+}
+
+// Module lists the file areas, one menu option per subdirectory of the
+// files directory
+type Module struct {
+	*base.Module
+	colorScheme menu.ColorScheme
+	dir         string
+	config      config.FilesConfig
+	user        *database.User
+	channel     Channel
+}
+
+// NewModule creates a file areas module for the given user
+func NewModule(db *database.DB, colorScheme menu.ColorScheme, dir string, cfg config.FilesConfig, user *database.User, channel Channel) *Module {
+	m := &Module{
+		colorScheme: colorScheme,
+		dir:         dir,
+		config:      cfg,
+		user:        user,
+		channel:     channel,
+	}
+	m.Module = base.NewModule(db, colorScheme, m)
+	return m
+}
+
+// LoadOptions implements OptionProvider interface
+func (m *Module) LoadOptions(db *database.DB) ([]base.MenuOption, error) {
+	areas, err := Areas(m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var options []base.MenuOption
+	for i := range areas {
+		area := &areas[i]
+		if files, err := area.Files(); err == nil {
+			area.Count = len(files)
+		}
+		options = append(options, NewAreaOption(area, i, m))
+	}
+
+	// Sysops and co-sysops find the upload queue after the areas
+	if CanReview(m.config, m.user.AccessLevel) && len(m.config.ReviewAreas) > 0 {
+		count, err := db.CountPendingUploads()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, &base.CommandOption{
+			ID:          "pending_uploads",
+			Title:       "Pending Uploads",
+			Description: fmt.Sprintf("%d) %-30s %4d files", len(options)+1, "Pending Uploads", count),
+			Handler: func(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
+				return NewReview(db, colorScheme, m.dir, m.user.Username).Execute(writer, keyReader)
+			},
+		})
+	}
+
+	return options, nil
+}
+
+// Areas returns the file areas in dir, the files directory, sorted by name
+func Areas(dir string) ([]Area, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var areas []Area
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			areas = append(areas, Area{Name: entry.Name(), Path: filepath.Join(dir, entry.Name())})
+		}
+	}
+	sort.Slice(areas, func(i, j int) bool { return areas[i].Name < areas[j].Name })
+	return areas, nil
+}
+
+// GetMenuTitle implements OptionProvider interface
+func (m *Module) GetMenuTitle() string {
+	return "File Areas"
+}
+
+// GetInstructions implements OptionProvider interface
+func (m *Module) GetInstructions() string {
+	return "Navigate: ↑↓  Open: Enter  Quit: Q"
+}
+
+// DownloadAllowance returns how many more bytes the user may download, or
+// -1 when downloads aren't limited by the ratio
+func DownloadAllowance(cfg config.FilesConfig, accessLevel int, totals *database.TransferTotals) int64 {
+	if cfg.Ratio <= 0 || (cfg.ExemptLevel > 0 && accessLevel >= cfg.ExemptLevel) {
+		return -1
+	}
+
+	allowed := int64(cfg.FreeDownloadKB)*1024 + totals.UploadBytes*int64(cfg.Ratio) - totals.DownloadBytes
+	if allowed < 0 {
+		return 0
+	}
+	return allowed
+}
@@ -0,0 +1,8 @@
+package files
+
+import "embed"
+
+// Help holds the help topics for the file areas
+//
+//go:embed help/*.md
+var Help embed.FS
@@ -0,0 +1,259 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// pendingDir holds an area's uploads until they are approved. It is
+// hidden, so its files aren't listed with the area's.
+const pendingDir = ".pending"
+
+// Reviewed reports whether uploads to an area wait for approval
+func Reviewed(cfg config.FilesConfig, area string) bool {
+	for _, name := range cfg.ReviewAreas {
+		if strings.EqualFold(name, area) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanReview reports whether a caller at the given access level reviews
+// uploads
+func CanReview(cfg config.FilesConfig, accessLevel int) bool {
+	level := cfg.ReviewLevel
+	if level <= 0 {
+		level = 255
+	}
+	return accessLevel >= level
+}
+
+// Review is the queue of uploads waiting for a sysop or co-sysop to look
+// inside them and approve or reject them
+type Review struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	dir         string
+	reviewer    string
+}
+
+// NewReview creates the upload queue for the files directory dir
+func NewReview(db *database.DB, colorScheme menu.ColorScheme, dir, reviewer string) *Review {
+	return &Review{
+		db:          db,
+		colorScheme: colorScheme,
+		dir:         dir,
+		reviewer:    reviewer,
+	}
+}
+
+// Execute lists the waiting uploads until the reviewer quits
+func (r *Review) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	selected := 0
+	for {
+		uploads, err := r.db.GetPendingUploads()
+		if err != nil {
+			showMessage(writer, keyReader, r.colorScheme, modules.T(writer, "Failed to load uploads: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+		if selected >= len(uploads) {
+			selected = len(uploads) - 1
+		}
+		if selected < 0 {
+			selected = 0
+		}
+
+		r.render(writer, uploads, selected)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "up":
+			if selected > 0 {
+				selected--
+			}
+		case "down":
+			if selected < len(uploads)-1 {
+				selected++
+			}
+		case "pageup", "pagedown", "home", "end":
+			selected = menu.Jump(key, selected, len(uploads), menu.ListRows(writer))
+		case "v", "enter":
+			if len(uploads) > 0 {
+				r.showDIZ(writer, keyReader, uploads[selected])
+			}
+		case "t":
+			if len(uploads) > 0 {
+				r.test(writer, keyReader, uploads[selected])
+			}
+		case "a":
+			if len(uploads) > 0 {
+				r.approve(writer, keyReader, uploads[selected])
+			}
+		case "r":
+			if len(uploads) > 0 {
+				r.reject(writer, keyReader, uploads[selected])
+			}
+		case "q", "quit", "escape":
+			writer.Write([]byte(menu.HideCursor))
+			return true
+		}
+	}
+}
+
+// render draws the queue with the selected upload highlighted
+func (r *Review) render(writer modules.Writer, uploads []database.PendingUpload, selected int) {
+	cs := r.colorScheme
+	writer.Write([]byte(menu.ClearContentArea + menu.HideCursor))
+
+	header := cs.Colorize(modules.T(writer, "--- Uploads Waiting for Approval ---"), "primary")
+	writer.Write([]byte(cs.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	if len(uploads) == 0 {
+		msg := cs.Colorize(modules.T(writer, "No uploads are waiting for approval."), "secondary")
+		writer.Write([]byte(cs.CenterText(msg, modules.Width(writer)) + "\n"))
+	} else {
+		headerLine := fmt.Sprintf("%-3s %-24s %-14s %8s %-14s %-10s", "#", "File", "Area", "Size", "Uploader", "Date")
+		writer.Write([]byte(cs.CenterText(cs.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+		separator := cs.DrawSeparator(len(headerLine), "─")
+		writer.Write([]byte(cs.CenterText(separator, modules.Width(writer)) + "\n"))
+
+		start := 0
+		if selected >= visibleFiles {
+			start = selected - visibleFiles + 1
+		}
+		end := start + visibleFiles
+		if end > len(uploads) {
+			end = len(uploads)
+		}
+		for i := start; i < end; i++ {
+			u := uploads[i]
+			line := fmt.Sprintf("%-3d %-24s %-14s %8s %-14s %-10s", i+1, ansi.Ellipsize(u.Filename, 24), ansi.Ellipsize(u.Area, 14),
+				formatSize(u.Bytes), ansi.Ellipsize(u.Uploader, 14), u.UploadedAt.Format("2006-01-02"))
+			writer.Write([]byte(cs.CenterText(cs.HighlightSelection(line, i == selected, len(line)+2), modules.Width(writer)) + "\n"))
+		}
+	}
+
+	writer.Write([]byte("\n"))
+	instructions := cs.Colorize(modules.T(writer, "↑↓: Select  V: View DIZ  T: Test  A: Approve  R: Reject  Q: Back"), "secondary")
+	writer.Write([]byte(cs.CenterText(instructions, modules.Width(writer))))
+}
+
+// path is where an upload waits
+func (r *Review) path(u database.PendingUpload) string {
+	return filepath.Join(r.dir, u.Area, pendingDir, u.Filename)
+}
+
+// showDIZ shows the description packed in an upload
+func (r *Review) showDIZ(writer modules.Writer, keyReader modules.KeyReader, u database.PendingUpload) {
+	diz, err := readDIZ(r.path(u))
+	switch {
+	case errors.Is(err, errNotZip):
+		showMessage(writer, keyReader, r.colorScheme, u.Filename+" is not a zip archive, so it has no FILE_ID.DIZ or DESC.SDI.", "secondary")
+		return
+	case err != nil:
+		showMessage(writer, keyReader, r.colorScheme, modules.T(writer, "Failed to read ")+u.Filename+": "+modules.ErrorText(writer, err), "error")
+		return
+	case diz == "":
+		showMessage(writer, keyReader, r.colorScheme, u.Filename+" has no FILE_ID.DIZ or DESC.SDI.", "secondary")
+		return
+	}
+
+	cs := r.colorScheme
+	writer.Write([]byte(menu.ClearContentArea))
+	header := cs.Colorize("--- "+u.Filename+" ---", "primary")
+	writer.Write([]byte(cs.CenterText(header, modules.Width(writer)) + "\n\n"))
+	for _, line := range strings.Split(strings.TrimRight(diz, "\n"), "\n") {
+		writer.Write([]byte("  " + cs.Colorize(line, "text") + "\n"))
+	}
+	writer.Write([]byte("\n" + cs.CenterText(cs.Colorize(modules.T(writer, "Press any key to continue..."), "text"), modules.Width(writer))))
+	keyReader.ReadKey()
+}
+
+// test checks that every file in an upload can be extracted
+func (r *Review) test(writer modules.Writer, keyReader modules.KeyReader, u database.PendingUpload) {
+	count, err := testArchive(r.path(u))
+	switch {
+	case errors.Is(err, errNotZip):
+		showMessage(writer, keyReader, r.colorScheme, u.Filename+" is not a zip archive, so it can't be tested.", "secondary")
+	case err != nil:
+		showMessage(writer, keyReader, r.colorScheme, fmt.Sprintf(modules.T(writer, "%s failed after %d file(s): %v"), u.Filename, count, err), "error")
+	default:
+		showMessage(writer, keyReader, r.colorScheme, fmt.Sprintf(modules.T(writer, "%s is OK: %d file(s) extracted without errors."), u.Filename, count), "success")
+	}
+}
+
+// approve lists an upload in its area and credits the uploader
+func (r *Review) approve(writer modules.Writer, keyReader modules.KeyReader, u database.PendingUpload) {
+	if denyIfReadOnly(writer, keyReader, r.db, r.colorScheme) {
+		return
+	}
+
+	src := r.path(u)
+	dst := filepath.Join(r.dir, u.Area, u.Filename)
+	if _, err := os.Stat(src); err != nil {
+		showMessage(writer, keyReader, r.colorScheme, u.Filename+" is missing from the queue. Reject it instead.", "error")
+		return
+	}
+	if _, err := os.Stat(dst); err == nil {
+		showMessage(writer, keyReader, r.colorScheme, fmt.Sprintf(modules.T(writer, "%s already has a file named %s."), u.Area, u.Filename), "error")
+		return
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		showMessage(writer, keyReader, r.colorScheme, modules.T(writer, "Failed to move ")+u.Filename+": "+modules.ErrorText(writer, err), "error")
+		return
+	}
+	if err := r.db.ApproveUpload(u.ID, r.reviewer); err != nil {
+		// Put the file back so it isn't listed without being approved
+		os.Rename(dst, src)
+		showMessage(writer, keyReader, r.colorScheme, modules.T(writer, "Failed to approve ")+u.Filename+": "+modules.ErrorText(writer, err), "error")
+		return
+	}
+	showMessage(writer, keyReader, r.colorScheme, fmt.Sprintf(modules.T(writer, "%s is now listed in %s. %s has been told."), u.Filename, u.Area, u.Uploader), "success")
+}
+
+// reject asks why, mails the uploader and deletes the upload
+func (r *Review) reject(writer modules.Writer, keyReader modules.KeyReader, u database.PendingUpload) {
+	if denyIfReadOnly(writer, keyReader, r.db, r.colorScheme) {
+		return
+	}
+
+	writer.Write([]byte(menu.ClearContentArea + menu.ShowCursor))
+	writer.Write([]byte(r.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "Rejecting %s from %s.\n"), u.Filename, u.Uploader), "text")))
+	writer.Write([]byte(r.colorScheme.Colorize(modules.T(writer, "Reason (mailed to the uploader, Esc cancels): "), "text")))
+	reason, err := readLine(keyReader, writer)
+	writer.Write([]byte(menu.HideCursor))
+	if err != nil || strings.TrimSpace(reason) == "" {
+		return
+	}
+
+	if err := r.db.RejectUpload(u.ID, r.reviewer, strings.TrimSpace(reason)); err != nil {
+		showMessage(writer, keyReader, r.colorScheme, modules.T(writer, "Failed to reject ")+u.Filename+": "+modules.ErrorText(writer, err), "error")
+		return
+	}
+	if err := os.Remove(r.path(u)); err != nil && !os.IsNotExist(err) {
+		showMessage(writer, keyReader, r.colorScheme, modules.T(writer, "Rejected, but failed to delete ")+u.Filename+": "+modules.ErrorText(writer, err), "error")
+		return
+	}
+	showMessage(writer, keyReader, r.colorScheme, fmt.Sprintf(modules.T(writer, "%s rejected and deleted. %s has been told why."), u.Filename, u.Uploader), "success")
+}
+
+// readLine reads a line of input, echoing printable characters
+func readLine(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
+	return input.ReadLine(keyReader, writer, 0)
+}
@@ -0,0 +1,114 @@
+package games
+
+import (
+	"errors"
+	"fmt"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/doors"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/modules/base"
+)
+
+// Launcher runs a door for the current caller and returns when it exits
+type Launcher func(door config.DoorConfig) error
+
+// Module lists the configured doors the caller has access to
+type Module struct {
+	*base.Module
+	doors       []config.DoorConfig
+	accessLevel int
+	launch      Launcher
+}
+
+// NewModule creates a games module offering doors up to the caller's access level
+func NewModule(db *database.DB, colorScheme menu.ColorScheme, doorList []config.DoorConfig, accessLevel int, launch Launcher) *Module {
+	m := &Module{
+		doors:       doorList,
+		accessLevel: accessLevel,
+		launch:      launch,
+	}
+	m.Module = base.NewModule(db, colorScheme, m)
+	return m
+}
+
+// LoadOptions implements OptionProvider interface
+func (m *Module) LoadOptions(db *database.DB) ([]base.MenuOption, error) {
+	var options []base.MenuOption
+	for _, door := range m.doors {
+		if door.AccessLevel <= m.accessLevel {
+			options = append(options, &DoorOption{door: door, index: len(options), launch: m.launch})
+		}
+	}
+	return options, nil
+}
+
+// GetMenuTitle implements OptionProvider interface
+func (m *Module) GetMenuTitle() string {
+	return "Games"
+}
+
+// GetInstructions implements OptionProvider interface
+func (m *Module) GetInstructions() string {
+	return "Navigate: ↑↓  Play: Enter  Quit: Q"
+}
+
+// DoorOption represents a door game menu option
+type DoorOption struct {
+	door   config.DoorConfig
+	index  int
+	launch Launcher
+}
+
+// GetID implements MenuOption interface
+func (d *DoorOption) GetID() string {
+	return "door_" + d.door.ID
+}
+
+// GetTitle implements MenuOption interface
+func (d *DoorOption) GetTitle() string {
+	return d.door.Name
+}
+
+// GetDescription implements MenuOption interface
+func (d *DoorOption) GetDescription() string {
+	return fmt.Sprintf("%d) %-24s %s", d.index+1, d.door.Name, d.door.Description)
+}
+
+// Execute implements MenuOption interface by running the door
+func (d *DoorOption) Execute(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
+	writer.Write([]byte(menu.ClearScreen + menu.ShowCursor))
+	loading := colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "Loading %s..."), d.door.Name), "accent")
+	writer.Write([]byte(colorScheme.CenterText(loading, modules.Width(writer)) + "\n\n"))
+
+	err := d.launch(d.door)
+	writer.Write([]byte(menu.HideCursor))
+
+	switch {
+	case errors.Is(err, doors.ErrTimeLimit):
+		showMessage(writer, keyReader, colorScheme, fmt.Sprintf(modules.T(writer, "Your time in %s is up."), d.door.Name), "secondary")
+	case err != nil:
+		showMessage(writer, keyReader, colorScheme, fmt.Sprintf(modules.T(writer, "%s ended with an error: %v"), d.door.Name, err), "error")
+	}
+	return true
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
+	writer.Write([]byte(centeredMessage + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	keyReader.ReadKey()
+}
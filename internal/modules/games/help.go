@@ -0,0 +1,8 @@
+package games
+
+import "embed"
+
+// Help holds the help topics for the games menu
+//
+//go:embed help/*.md
+var Help embed.FS
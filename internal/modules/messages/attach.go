@@ -0,0 +1,321 @@
+package messages
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/modules/files"
+	"bbs/internal/transfer"
+)
+
+// visibleFiles is how many files of an area are listed when picking one
+const visibleFiles = 14
+
+// attachment is a file waiting to go out with a message. One taken from a
+// file area names it, so the copy is charged as a download once it is sent.
+type attachment struct {
+	database.Attachment
+	area string
+}
+
+// SetAttachments lets the caller attach files to the mail they send, from
+// an upload or from the file areas in filesDir. Without it mail can still
+// be read and attachments downloaded, but nothing can be attached.
+func (m *Messages) SetAttachments(filesDir string, cfg config.FilesConfig, accessLevel int) {
+	m.filesDir = filesDir
+	m.filesConfig = cfg
+	m.accessLevel = accessLevel
+}
+
+// attachLimit returns the largest file the caller may attach now, which
+// is the smaller of the per-file limit and what is left of their quota
+func (m *Messages) attachLimit() (int64, error) {
+	limit := int64(m.filesConfig.AttachKB) * 1024
+	if m.filesConfig.AttachQuotaKB <= 0 {
+		return limit, nil
+	}
+	used, err := m.db.AttachmentUsage(m.username)
+	if err != nil {
+		return 0, err
+	}
+	if left := int64(m.filesConfig.AttachQuotaKB)*1024 - used; left < limit {
+		limit = max(left, 0)
+	}
+	return limit, nil
+}
+
+// offerAttachment asks whether to attach a file to the message being sent.
+// It returns nil when the caller attaches nothing, and false when they
+// back out of sending altogether.
+func (m *Messages) offerAttachment(writer modules.Writer, keyReader modules.KeyReader) (*attachment, bool) {
+	if m.filesConfig.AttachKB <= 0 {
+		return nil, true
+	}
+
+	writer.Write([]byte("\n" + m.colorScheme.Colorize(modules.T(writer, "Attach a file? U: Upload  F: From a file area  Enter: No "), "accent")))
+	key, err := keyReader.ReadKey()
+	if err != nil {
+		return nil, false
+	}
+	writer.Write([]byte("\n"))
+
+	var att *attachment
+	switch strings.ToLower(key) {
+	case "u":
+		att = m.uploadAttachment(writer, keyReader)
+	case "f":
+		att = m.pickAttachment(writer, keyReader)
+	default:
+		return nil, true
+	}
+	if att == nil {
+		// What went wrong has been shown; the message can still go without it
+		writer.Write([]byte(menu.ClearContentArea))
+		return nil, true
+	}
+
+	writer.Write([]byte(menu.ClearContentArea))
+	notice := fmt.Sprintf(modules.T(writer, "Attached %s (%s)."), att.Filename, formatSize(int64(len(att.Data))))
+	writer.Write([]byte(m.colorScheme.CenterText(m.colorScheme.Colorize(notice, "success"), modules.Width(writer)) + "\n"))
+	return att, true
+}
+
+// uploadAttachment receives one file from the caller by ZMODEM
+func (m *Messages) uploadAttachment(writer modules.Writer, keyReader modules.KeyReader) *attachment {
+	limit, ok := m.checkQuota(writer, keyReader)
+	if !ok {
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "bbs-attach-")
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to prepare the upload: ")+modules.ErrorText(writer, err), "error")
+		return nil
+	}
+	defer os.RemoveAll(dir)
+
+	m.showTransferScreen(writer, fmt.Sprintf("Upload an attachment (up to %s)", formatSize(limit)))
+	var received []transfer.Received
+	err = modules.Transfer(writer, func(rw io.ReadWriter) error {
+		var err error
+		received, err = transfer.Receive(rw, dir, transfer.Options{MaxSize: limit})
+		return err
+	})
+	switch {
+	case err != nil && len(received) == 0:
+		showMessage(writer, keyReader, m.colorScheme, transferError(err), "error")
+		return nil
+	case len(received) == 0:
+		msg := fmt.Sprintf(modules.T(writer, "No file was received. Attachments can be up to %s."), formatSize(limit))
+		showMessage(writer, keyReader, m.colorScheme, msg, "secondary")
+		return nil
+	}
+
+	// Only the first file of a batch is attached
+	data, err := os.ReadFile(received[0].Path)
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to read the upload: ")+modules.ErrorText(writer, err), "error")
+		return nil
+	}
+	return &attachment{Attachment: database.Attachment{Filename: received[0].Name, Data: data}}
+}
+
+// pickAttachment attaches a copy of a file from one of the file areas.
+// Sending it counts against the caller's download ratio, as if they had
+// downloaded it.
+func (m *Messages) pickAttachment(writer modules.Writer, keyReader modules.KeyReader) *attachment {
+	limit, ok := m.checkQuota(writer, keyReader)
+	if !ok {
+		return nil
+	}
+
+	areas, err := files.Areas(m.filesDir)
+	if err != nil || len(areas) == 0 {
+		showMessage(writer, keyReader, m.colorScheme, "There are no file areas to attach from.", "error")
+		return nil
+	}
+
+	writer.Write([]byte(menu.ClearContentArea))
+	header := m.colorScheme.Colorize(modules.T(writer, "--- Attach From a File Area ---"), "primary")
+	writer.Write([]byte(m.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	for i, area := range areas {
+		writer.Write([]byte(m.colorScheme.Colorize(fmt.Sprintf("  %2d) %s", i+1, area.Name), "text") + "\n"))
+	}
+	writer.Write([]byte("\n" + m.colorScheme.Colorize(modules.T(writer, "Area number: "), "text")))
+	input, err := readLine(keyReader, writer)
+	if err != nil {
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || n < 1 || n > len(areas) {
+		return nil
+	}
+	area := areas[n-1]
+
+	list, err := area.Files()
+	if err != nil || len(list) == 0 {
+		showMessage(writer, keyReader, m.colorScheme, fmt.Sprintf(modules.T(writer, "%s has no files."), area.Name), "error")
+		return nil
+	}
+
+	writer.Write([]byte(menu.ClearContentArea))
+	header = m.colorScheme.Colorize("--- "+area.Name+" ---", "primary")
+	writer.Write([]byte(m.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	for _, file := range list[:min(len(list), visibleFiles)] {
+		writer.Write([]byte(m.colorScheme.Colorize(fmt.Sprintf("  %-40s %8s", ansi.Ellipsize(file.Name, 40), formatSize(file.Size)), "text") + "\n"))
+	}
+	if more := len(list) - visibleFiles; more > 0 {
+		writer.Write([]byte(m.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "  ...and %d more"), more), "secondary") + "\n"))
+	}
+	writer.Write([]byte("\n" + m.colorScheme.Colorize(modules.T(writer, "File name: "), "text")))
+	input, err = readLine(keyReader, writer)
+	if err != nil || strings.TrimSpace(input) == "" {
+		return nil
+	}
+
+	var file *files.FileInfo
+	for i := range list {
+		if strings.EqualFold(list[i].Name, strings.TrimSpace(input)) {
+			file = &list[i]
+			break
+		}
+	}
+	if file == nil {
+		showMessage(writer, keyReader, m.colorScheme, fmt.Sprintf(modules.T(writer, "%s has no file called %s."), area.Name, strings.TrimSpace(input)), "error")
+		return nil
+	}
+	if file.Size > limit {
+		msg := fmt.Sprintf(modules.T(writer, "%s is %s but attachments can be up to %s."), file.Name, formatSize(file.Size), formatSize(limit))
+		showMessage(writer, keyReader, m.colorScheme, msg, "error")
+		return nil
+	}
+
+	totals, err := m.db.GetTransferTotals(m.username)
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to load your ratio: ")+modules.ErrorText(writer, err), "error")
+		return nil
+	}
+	if allowance := files.DownloadAllowance(m.filesConfig, m.accessLevel, totals); allowance >= 0 && file.Size > allowance {
+		msg := fmt.Sprintf(modules.T(writer, "%s is %s but your ratio allows %s more. Upload files to earn download credit."),
+			file.Name, formatSize(file.Size), formatSize(allowance))
+		showMessage(writer, keyReader, m.colorScheme, msg, "error")
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(area.Path, file.Name))
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to open file: ")+modules.ErrorText(writer, err), "error")
+		return nil
+	}
+	return &attachment{Attachment: database.Attachment{Filename: file.Name, Data: data}, area: area.Name}
+}
+
+// checkQuota returns how large an attachment may be, or tells the caller
+// why they can't attach anything
+func (m *Messages) checkQuota(writer modules.Writer, keyReader modules.KeyReader) (int64, bool) {
+	limit, err := m.attachLimit()
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to check your quota: ")+modules.ErrorText(writer, err), "error")
+		return 0, false
+	}
+	if limit <= 0 {
+		msg := fmt.Sprintf(modules.T(writer, "Files you sent that are still waiting in mailboxes use your %s attachment quota."),
+			formatSize(int64(m.filesConfig.AttachQuotaKB)*1024))
+		showMessage(writer, keyReader, m.colorScheme, msg, "error")
+		return 0, false
+	}
+	return limit, true
+}
+
+// downloadAttachment sends the file attached to msg to the caller
+func (m *Messages) downloadAttachment(writer modules.Writer, keyReader modules.KeyReader, msg *database.Message) {
+	att, err := m.db.GetAttachment(m.username, msg.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		showMessage(writer, keyReader, m.colorScheme, "This message has no attachment.", "error")
+		return
+	}
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to load attachment: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+
+	size := int64(len(att.Data))
+	m.showTransferScreen(writer, fmt.Sprintf("Sending %s (%s)", att.Filename, formatSize(size)))
+	var sent int64
+	start := time.Now()
+	err = modules.Transfer(writer, func(rw io.ReadWriter) error {
+		var err error
+		sent, err = transfer.Send(rw, []transfer.File{{Name: att.Filename, Size: size, ModTime: msg.CreatedAt, Data: bytes.NewReader(att.Data)}},
+			transfer.Options{})
+		return err
+	})
+	elapsed := time.Since(start)
+
+	switch {
+	case err != nil:
+		showMessage(writer, keyReader, m.colorScheme, transferError(err), "error")
+	case sent == 0:
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Your terminal skipped ")+att.Filename+".", "secondary")
+	default:
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Sent ")+att.Filename+". "+transferSummary(sent, elapsed), "success")
+	}
+}
+
+// showTransferScreen tells the caller to start their terminal's transfer
+func (m *Messages) showTransferScreen(writer modules.Writer, title string) {
+	writer.Write([]byte(menu.ClearContentArea))
+	header := m.colorScheme.Colorize("--- "+title+" ---", "primary")
+	writer.Write([]byte(m.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	lines := []string{
+		"Starting ZMODEM. Most terminals begin the transfer automatically;",
+		"otherwise start a ZMODEM transfer from your terminal now.",
+		"Press Ctrl+X five times to cancel.",
+	}
+	for _, line := range lines {
+		writer.Write([]byte(m.colorScheme.CenterText(m.colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+	}
+	writer.Write([]byte("\n"))
+}
+
+// transferError describes why a transfer failed
+func transferError(err error) string {
+	if errors.Is(err, transfer.ErrCancelled) {
+		return "Transfer cancelled."
+	}
+	return "Transfer failed: " + err.Error()
+}
+
+// transferSummary reports the size and speed of a finished transfer
+func transferSummary(bytes int64, elapsed time.Duration) string {
+	seconds := elapsed.Seconds()
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("%s in %s (%d cps)", formatSize(bytes), elapsed.Round(time.Second), int64(float64(bytes)/seconds))
+}
+
+// formatSize formats a byte count for display
+func formatSize(bytes int64) string {
+	switch {
+	case bytes >= 1024*1024:
+		return fmt.Sprintf("%.1fM", float64(bytes)/(1024*1024))
+	case bytes >= 1024:
+		return fmt.Sprintf("%.1fK", float64(bytes)/1024)
+	default:
+		return fmt.Sprintf("%dB", bytes)
+	}
+}
@@ -0,0 +1,129 @@
+package messages
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/database"
+	"bbs/internal/dialog"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/notify"
+)
+
+// maxBodyLines caps the length of a composed message
+const maxBodyLines = 200
+
+// Compose writes and sends a private message. to and subject pre-fill the
+// header when replying; empty values are prompted for.
+func (m *Messages) Compose(writer modules.Writer, keyReader modules.KeyReader, to, subject string) bool {
+	if m.denyIfReadOnly(writer, keyReader) {
+		return true
+	}
+
+	writer.Write([]byte(menu.ClearContentArea + menu.ShowCursor))
+	header := m.colorScheme.Colorize(modules.T(writer, "--- Compose Message ---"), "primary")
+	writer.Write([]byte(m.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	// Recipient
+	if to == "" {
+		writer.Write([]byte(m.colorScheme.Colorize(modules.T(writer, "To: "), "text")))
+		input, err := readLine(keyReader, writer)
+		if err != nil || strings.TrimSpace(input) == "" {
+			return true
+		}
+		to = strings.TrimSpace(input)
+	} else {
+		writer.Write([]byte(m.colorScheme.Colorize(modules.T(writer, "To: "), "text") + to + "\n"))
+	}
+
+	recipient, err := m.db.GetUser(to)
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, fmt.Sprintf(modules.T(writer, "No active user named %s."), to), "error")
+		return true
+	}
+
+	// Subject
+	if subject == "" {
+		writer.Write([]byte(m.colorScheme.Colorize(modules.T(writer, "Subject: "), "text")))
+		input, err := readLine(keyReader, writer)
+		if err != nil || strings.TrimSpace(input) == "" {
+			return true
+		}
+		subject = strings.TrimSpace(input)
+	} else {
+		writer.Write([]byte(m.colorScheme.Colorize(modules.T(writer, "Subject: "), "text") + subject + "\n"))
+	}
+
+	// Body
+	writer.Write([]byte("\n" + m.colorScheme.Colorize(modules.T(writer, "Enter your message. Type /s on a line by itself to send, /a to abort."), "secondary") + "\n\n"))
+
+	var body []string
+	for {
+		writer.Write([]byte(m.colorScheme.Colorize(fmt.Sprintf("%3d: ", len(body)+1), "accent")))
+		line, err := readLine(keyReader, writer)
+		if err != nil {
+			return true
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "/a":
+			showMessage(writer, keyReader, m.colorScheme, "Message aborted.", "secondary")
+			return true
+		case "/s":
+			return m.send(writer, keyReader, recipient.Username, subject, body)
+		}
+
+		body = append(body, line)
+		if len(body) >= maxBodyLines {
+			writer.Write([]byte(m.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "Message is limited to %d lines."), maxBodyLines), "error") + "\n"))
+			return m.send(writer, keyReader, recipient.Username, subject, body)
+		}
+	}
+}
+
+// send confirms and stores a composed message
+func (m *Messages) send(writer modules.Writer, keyReader modules.KeyReader, to, subject string, body []string) bool {
+	text := strings.TrimRight(strings.Join(body, "\n"), "\n ")
+	if text == "" {
+		showMessage(writer, keyReader, m.colorScheme, "Empty message not sent.", "error")
+		return true
+	}
+
+	att, ok := m.offerAttachment(writer, keyReader)
+	if !ok {
+		return true
+	}
+
+	if !dialog.Confirm(writer, keyReader, m.colorScheme, fmt.Sprintf(modules.T(writer, "Send to %s?"), to), true) {
+		showMessage(writer, keyReader, m.colorScheme, "Message not sent.", "secondary")
+		return true
+	}
+
+	msg := &database.Message{
+		FromUser: m.username,
+		ToUser:   to,
+		Subject:  subject,
+		Body:     text,
+		Area:     "private",
+	}
+	var err error
+	if att != nil {
+		err = m.db.CreateMessageWithAttachment(msg, &att.Attachment)
+	} else {
+		err = m.db.CreateMessage(msg)
+	}
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to send message: ")+modules.ErrorText(writer, err), "error")
+		return true
+	}
+	if att != nil && att.area != "" {
+		if err := m.db.RecordTransfer(m.username, att.area, att.Filename, database.TransferDownload, int64(len(att.Data))); err != nil {
+			showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to record download: ")+modules.ErrorText(writer, err), "error")
+		}
+	}
+	modules.Notify(writer, to, notify.Mail, fmt.Sprintf("New mail from %s: %s", m.username, subject))
+
+	showMessage(writer, keyReader, m.colorScheme, fmt.Sprintf(modules.T(writer, "Message sent to %s."), to), "success")
+	return true
+}
@@ -0,0 +1,8 @@
+package messages
+
+import "embed"
+
+// Help holds the help topics for the private mail
+//
+//go:embed help/*.md
+var Help embed.FS
@@ -0,0 +1,300 @@
+package messages
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/dialog"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/pager"
+	"bbs/internal/undo"
+)
+
+// Messages implements private mail: reading, composing, replying, and deleting
+type Messages struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	username    string
+
+	// Attaching files, once SetAttachments is called
+	filesDir    string
+	filesConfig config.FilesConfig
+	accessLevel int
+}
+
+// NewMessages creates a mail module for the given user
+func NewMessages(db *database.DB, colorScheme menu.ColorScheme, username string) *Messages {
+	return &Messages{
+		db:          db,
+		colorScheme: colorScheme,
+		username:    username,
+	}
+}
+
+// Execute shows the user's mailbox
+func (m *Messages) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	selected := 0
+
+	for {
+		mail, err := m.db.GetMessages(m.username, 100)
+		if err != nil {
+			showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to load mail: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+
+		if selected >= len(mail) {
+			selected = len(mail) - 1
+		}
+		if selected < 0 {
+			selected = 0
+		}
+
+		m.renderMailbox(writer, mail, selected, modules.UndoBuffer(writer).Pending())
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "up":
+			if selected > 0 {
+				selected--
+			}
+		case "down":
+			if selected < len(mail)-1 {
+				selected++
+			}
+		case "pageup", "pagedown", "home", "end":
+			selected = menu.Jump(key, selected, len(mail), menu.ListRows(writer))
+		case "enter":
+			if len(mail) > 0 {
+				m.readMessage(writer, keyReader, &mail[selected])
+			}
+		case "c":
+			m.Compose(writer, keyReader, "", "")
+		case "d":
+			if len(mail) > 0 {
+				m.deleteMessage(writer, keyReader, &mail[selected])
+			}
+		case "u":
+			m.undoDelete(writer, keyReader)
+		case "m":
+			if len(mail) > 0 && !m.denyIfReadOnly(writer, keyReader) {
+				msg := mail[selected]
+				if err := m.db.UpdateMessageRead(m.username, msg.ID, !msg.IsRead); err != nil {
+					showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to update message: ")+modules.ErrorText(writer, err), "error")
+				}
+			}
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// renderMailbox draws the message list with the selected message
+// highlighted, and the deletion that can still be undone, if any
+func (m *Messages) renderMailbox(writer modules.Writer, mail []database.Message, selected int, undoable string) {
+	writer.Write([]byte(menu.ClearContentArea + menu.HideCursor))
+
+	header := m.colorScheme.Colorize(modules.T(writer, "--- Private Mail ---"), "primary")
+	writer.Write([]byte(m.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	if len(mail) == 0 {
+		msg := m.colorScheme.Colorize(modules.T(writer, "Your mailbox is empty."), "secondary")
+		writer.Write([]byte(m.colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+	} else {
+		headerLine := fmt.Sprintf("  %-3s %-16s %-36s %-10s", "#", "From", "Subject", "Date")
+		writer.Write([]byte(m.colorScheme.CenterText(m.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+		separator := m.colorScheme.DrawSeparator(len(headerLine), "─")
+		writer.Write([]byte(m.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+		for i, msg := range mail {
+			marker, attached := " ", " "
+			if !msg.IsRead {
+				marker = "*"
+			}
+			if msg.Attachment != "" {
+				attached = "+"
+			}
+			line := fmt.Sprintf("%s%s%-3d %-16s %-36s %-10s", marker, attached, i+1, ansi.Ellipsize(msg.FromUser, 16),
+				ansi.Ellipsize(msg.Subject, 36), msg.CreatedAt.Format("2006-01-02"))
+			writer.Write([]byte(m.colorScheme.CenterText(m.colorScheme.HighlightSelection(line, i == selected, len(line)+2), modules.Width(writer)) + "\n"))
+		}
+	}
+
+	writer.Write([]byte("\n"))
+	if undoable != "" {
+		notice := m.colorScheme.Colorize(undoable+". U to undo", "accent")
+		writer.Write([]byte(m.colorScheme.CenterText(notice, modules.Width(writer)) + "\n"))
+	}
+	instructions := m.colorScheme.Colorize(modules.T(writer, "↑↓: Select  Enter: Read  C: Compose  D: Delete  M: Mark Read/Unread  Q: Quit"), "secondary")
+	writer.Write([]byte(m.colorScheme.CenterText(instructions, modules.Width(writer)) + "\n"))
+	writer.Write([]byte(m.colorScheme.CenterText(m.colorScheme.Colorize(modules.T(writer, "* = unread  + = attachment"), "text"), modules.Width(writer))))
+}
+
+// readMessage displays a message, marks it read, and offers reply and
+// delete. It reports whether the message was deleted.
+func (m *Messages) readMessage(writer modules.Writer, keyReader modules.KeyReader, msg *database.Message) bool {
+	if !msg.IsRead && !m.db.ReadOnly() {
+		if err := m.db.UpdateMessageRead(m.username, msg.ID, true); err == nil {
+			msg.IsRead = true
+		}
+	}
+
+	var contentLines []string
+	info := fmt.Sprintf("From: %s | Date: %s", msg.FromUser, msg.CreatedAt.Format("January 2, 2006 15:04"))
+	contentLines = append(contentLines, m.colorScheme.CenterText(m.colorScheme.Colorize(info, "secondary"), modules.Width(writer)), "")
+	for _, line := range strings.Split(msg.Body, "\n") {
+		contentLines = append(contentLines, "  "+m.colorScheme.Colorize(strings.TrimRight(line, "\r"), "text"))
+	}
+	if msg.Attachment != "" {
+		attached := fmt.Sprintf(modules.T(writer, "Attachment: %s (%s)"), msg.Attachment, formatSize(msg.AttachmentSize))
+		contentLines = append(contentLines, "", "  "+m.colorScheme.Colorize(attached, "accent"))
+	}
+
+	termSizer := pager.NewTerminalSizerFromWriter(writer)
+	writerAdapter := pager.NewWriterAdapter(writer, termSizer)
+
+	// Give the pager status bar control when the writer supports it
+	type StatusBarController interface {
+		Pause()
+		Resume()
+	}
+	if sbCtrl, ok := writer.(StatusBarController); ok {
+		writerAdapter.WithStatusBarManager(sbCtrl)
+	}
+
+	p := pager.NewPager(writerAdapter, keyReader, writerAdapter, m.colorScheme)
+	if writerAdapter.StatusBarMgr != nil {
+		p.WithStatusBar(writerAdapter)
+	}
+	p.Display(contentLines, fmt.Sprintf("--- %s ---", msg.Subject))
+
+	writer.Write([]byte(menu.ClearContentArea))
+	title := m.colorScheme.Colorize(msg.Subject, "primary")
+	writer.Write([]byte(m.colorScheme.CenterText(title, modules.Width(writer)) + "\n\n"))
+	prompt := modules.T(writer, "R: Reply  D: Delete  Any other key: Back to mailbox")
+	if msg.Attachment != "" {
+		prompt = modules.T(writer, "R: Reply  D: Delete  A: Download attachment  Any other key: Back")
+	}
+	writer.Write([]byte(m.colorScheme.CenterText(m.colorScheme.Colorize(prompt, "secondary"), modules.Width(writer))))
+
+	key, err := keyReader.ReadKey()
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(key) {
+	case "a":
+		if msg.Attachment != "" {
+			m.downloadAttachment(writer, keyReader, msg)
+		}
+	case "r":
+		subject := msg.Subject
+		if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+			subject = "Re: " + subject
+		}
+		m.Compose(writer, keyReader, msg.FromUser, subject)
+	case "d":
+		return m.deleteMessage(writer, keyReader, msg)
+	}
+	return false
+}
+
+// Read shows one message outside the mailbox, as when a search finds it
+func (m *Messages) Read(writer modules.Writer, keyReader modules.KeyReader, id int) {
+	msg, err := m.db.GetMessageByID(m.username, id)
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to load message: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	if !m.readMessage(writer, keyReader, msg) {
+		return
+	}
+
+	// Away from the mailbox, offer the undo before moving on
+	writer.Write([]byte(menu.ClearScreen))
+	notice := m.colorScheme.Colorize(modules.T(writer, "Message deleted."), "success")
+	writer.Write([]byte(m.colorScheme.CenterText(notice, modules.Width(writer)) + "\n\n"))
+	prompt := m.colorScheme.Colorize(modules.T(writer, "U: Undo  Any other key: Continue"), "text")
+	writer.Write([]byte(m.colorScheme.CenterText(prompt, modules.Width(writer))))
+	if key, err := keyReader.ReadKey(); err == nil && strings.ToLower(key) == "u" {
+		m.undoDelete(writer, keyReader)
+	}
+}
+
+// deleteMessage asks for confirmation and deletes a message. The caller
+// can undo it for a few seconds; after that it is gone for good. It
+// reports whether the message was deleted.
+func (m *Messages) deleteMessage(writer modules.Writer, keyReader modules.KeyReader, msg *database.Message) bool {
+	if m.denyIfReadOnly(writer, keyReader) {
+		return false
+	}
+
+	question := fmt.Sprintf(modules.T(writer, "Delete \"%s\" from %s?"), msg.Subject, msg.FromUser)
+	if !dialog.Confirm(writer, keyReader, m.colorScheme, question, false) {
+		return false
+	}
+
+	if err := m.db.DeleteMessage(m.username, msg.ID); err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to delete message: ")+modules.ErrorText(writer, err), "error")
+		return false
+	}
+
+	// A purge that fails leaves the message for the retention job
+	id := msg.ID
+	modules.UndoBuffer(writer).Push(fmt.Sprintf("Deleted \"%s\"", msg.Subject),
+		func() error { return m.db.RestoreMessage(m.username, id) },
+		func() error { return m.db.PurgeMessage(m.username, id) })
+	return true
+}
+
+// undoDelete puts back the message deleted last, if it isn't too late
+func (m *Messages) undoDelete(writer modules.Writer, keyReader modules.KeyReader) {
+	if _, err := modules.UndoBuffer(writer).Undo(); err != nil {
+		if errors.Is(err, undo.ErrNothing) {
+			showMessage(writer, keyReader, m.colorScheme, "Nothing to undo. Deleted mail can only be put back for a few seconds.", "error")
+			return
+		}
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Failed to undo: ")+modules.ErrorText(writer, err), "error")
+	}
+}
+
+// denyIfReadOnly shows a notice and returns true when the board is a read-only mirror
+func (m *Messages) denyIfReadOnly(writer modules.Writer, keyReader modules.KeyReader) bool {
+	if !m.db.ReadOnly() {
+		return false
+	}
+	showMessage(writer, keyReader, m.colorScheme, "This board is a read-only mirror. Changes are disabled.", "error")
+	return true
+}
+
+// readLine reads a line of input from the user
+func readLine(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
+	return input.ReadLine(keyReader, writer, 0)
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
+	writer.Write([]byte(centeredMessage + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	keyReader.ReadKey()
+}
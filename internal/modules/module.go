@@ -1,13 +1,35 @@
 package modules
 
 import (
-	"golang.org/x/term"
+	"errors"
+	"io"
+	"log"
+
+	"bbs/internal/database"
+	"bbs/internal/notify"
+	"bbs/internal/undo"
 )
 
-// Module interface defines the contract for all BBS modules
+// Module is a feature callers reach through a menu command. Modules are
+// registered once at startup and run for every caller who chooses their
+// command; who that is comes through the writer.
 type Module interface {
+	// Name is the menu command that runs the module
+	Name() string
+	// MenuEntry says how menus list the module and who may run it
+	MenuEntry() MenuEntry
 	// Execute runs the module and returns true if the session should continue
-	Execute(term *term.Terminal) bool
+	Execute(writer Writer, keyReader KeyReader) bool
+}
+
+// MenuEntry is how a module appears in the menus. A menu item bound to the
+// module uses the title and description when it has none of its own.
+type MenuEntry struct {
+	Title       string
+	Description string
+	Activity    string // Shown in who's online while it runs; modules with one are kept in the caller log
+	AccessLevel int    // Minimum access level to run it, whatever the menu item says
+	Writes      bool   // Changes the board, so a read-only mirror refuses it
 }
 
 // KeyReader interface for reading user input
@@ -19,3 +41,162 @@ type KeyReader interface {
 type Writer interface {
 	Write([]byte) (int, error)
 }
+
+// Reasons a module may ring the terminal bell
+const (
+	BellMessage = "message" // An instant message or whisper arrived
+	BellPage    = "page"    // Someone wants to chat
+	BellError   = "error"   // Something the caller did failed
+)
+
+// Beller is implemented by writers that can ring the caller's terminal
+// bell. The session decides from the caller's bell settings whether it
+// actually rings.
+type Beller interface {
+	Bell(reason string)
+}
+
+// Bell rings the bell for reason if the writer supports it
+func Bell(writer Writer, reason string) {
+	if b, ok := writer.(Beller); ok {
+		b.Bell(reason)
+	}
+}
+
+// Sizer is implemented by writers that know the size of the caller's
+// terminal
+type Sizer interface {
+	Size() (width, height int, err error)
+}
+
+// Width returns the usable line width for the caller's screen, one column
+// short of their terminal so full-width lines don't wrap, or the classic 79
+// if the writer doesn't know
+func Width(writer Writer) int {
+	if s, ok := writer.(Sizer); ok {
+		if w, _, err := s.Size(); err == nil && w >= 2 {
+			return w - 1
+		}
+	}
+	return 79
+}
+
+// Transferrer is implemented by writers that can hand the caller's raw
+// connection to a file transfer
+type Transferrer interface {
+	Transfer(fn func(rw io.ReadWriter) error) error
+}
+
+// ErrNoTransfers is returned when the writer can't carry a file transfer
+var ErrNoTransfers = errors.New("file transfers aren't available on this connection")
+
+// Transfer runs fn with the caller's raw connection if the writer supports it
+func Transfer(writer Writer, fn func(rw io.ReadWriter) error) error {
+	if t, ok := writer.(Transferrer); ok {
+		return t.Transfer(fn)
+	}
+	return ErrNoTransfers
+}
+
+// Notifier is implemented by writers that can tell another caller about
+// something that concerns them. The board decides from that caller's
+// notification settings whether and how they are told.
+type Notifier interface {
+	Notify(username string, kind notify.Kind, text string)
+}
+
+// Notify tells username about something if the writer supports it
+func Notify(writer Writer, username string, kind notify.Kind, text string) {
+	if n, ok := writer.(Notifier); ok {
+		n.Notify(username, kind, text)
+	}
+}
+
+// Undoer is implemented by writers that keep the caller's undo buffer for
+// the length of their call
+type Undoer interface {
+	UndoBuffer() *undo.Buffer
+}
+
+// UndoBuffer returns the caller's undo buffer, or nil if the writer has
+// none. Actions pushed to a nil buffer are made final straight away.
+func UndoBuffer(writer Writer) *undo.Buffer {
+	if u, ok := writer.(Undoer); ok {
+		return u.UndoBuffer()
+	}
+	return nil
+}
+
+// ErrHungUp is returned by writes and key reads once the caller's
+// connection has been lost
+var ErrHungUp = errors.New("the caller has hung up")
+
+// HangupDetector is implemented by writers that know when the caller's
+// connection has been lost
+type HangupDetector interface {
+	HungUp() bool
+}
+
+// HungUp reports whether the caller has hung up. Modules that write a lot
+// without reading a key check it to stop early; a failed read tells the
+// rest.
+func HungUp(writer Writer) bool {
+	if h, ok := writer.(HangupDetector); ok {
+		return h.HungUp()
+	}
+	return false
+}
+
+// Translator is implemented by writers that show the caller the board in
+// their chosen language
+type Translator interface {
+	Translate(text string) string
+}
+
+// T returns text in the caller's language, or unchanged if the writer has
+// no catalog or no translation for it. A format string goes through T
+// before its arguments are filled in.
+func T(writer Writer, text string) string {
+	if t, ok := writer.(Translator); ok {
+		return t.Translate(text)
+	}
+	return text
+}
+
+// ErrorText is how a failed command's error is shown to the caller. The
+// board's own errors already say what went wrong and pass through; a
+// database outage or a driver error is logged for the sysop and the caller
+// is told something they can act on instead.
+func ErrorText(writer Writer, err error) string {
+	switch {
+	case database.IsUnavailableError(err):
+		log.Printf("Database unavailable during a command: %v", err)
+		return T(writer, "the system is undergoing maintenance, please try again later")
+	case database.IsDriverError(err):
+		log.Printf("Database error during a command: %v", err)
+		return T(writer, "the database is busy, please try again")
+	}
+	return err.Error()
+}
+
+// Caller is who is using a module
+type Caller struct {
+	UserID      int
+	Username    string
+	AccessLevel int
+	Node        int
+}
+
+// CallerReporter is implemented by writers that know whose call they are
+type CallerReporter interface {
+	Caller() Caller
+}
+
+// CallerOf returns who the writer is writing to, or the zero Caller if the
+// writer doesn't know
+func CallerOf(writer Writer) Caller {
+	if c, ok := writer.(CallerReporter); ok {
+		return c.Caller()
+	}
+	return Caller{}
+}
@@ -0,0 +1,8 @@
+package newscan
+
+import "embed"
+
+// Help holds the help topics for the new message scan
+//
+//go:embed help/*.md
+var Help embed.FS
@@ -0,0 +1,225 @@
+package newscan
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/pager"
+)
+
+// maxPerArea caps how many new items are read from one area in a scan
+const maxPerArea = 100
+
+// Scan walks the bulletins and every topic the caller may read, showing
+// only what they haven't read, oldest first, and moving their last-read
+// pointers as they go
+type Scan struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	userID      int
+	accessLevel int
+}
+
+// area is a bulletin list or topic with new items in it
+type area struct {
+	name     string
+	lastRead string // The area's key in user_lastread
+	items    []item
+}
+
+// item is a new bulletin or post
+type item struct {
+	id      int
+	subject string
+	author  string
+	date    time.Time
+	body    string
+	replies int
+}
+
+// NewScan creates a new message scan for a user
+func NewScan(db *database.DB, colorScheme menu.ColorScheme, userID, accessLevel int) *Scan {
+	return &Scan{
+		db:          db,
+		colorScheme: colorScheme,
+		userID:      userID,
+		accessLevel: accessLevel,
+	}
+}
+
+// Offer tells a caller logging in what is new in each area and asks whether
+// to read it now. Callers with nothing new aren't asked.
+func (s *Scan) Offer(writer modules.Writer, keyReader modules.KeyReader) bool {
+	areas, err := s.load()
+	if err != nil || len(areas) == 0 {
+		return true
+	}
+
+	writer.Write([]byte(menu.ClearScreen))
+	header := s.colorScheme.Colorize(modules.T(writer, "--- New Since Your Last Call ---"), "primary")
+	writer.Write([]byte(s.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	for _, a := range areas {
+		line := fmt.Sprintf("%-30s %4d new", ansi.Ellipsize(a.name, 30), len(a.items))
+		writer.Write([]byte(s.colorScheme.CenterText(s.colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+	}
+	writer.Write([]byte("\n"))
+	prompt := s.colorScheme.Colorize(modules.T(writer, "Read them now? Y: Yes  N: Later"), "accent")
+	writer.Write([]byte(s.colorScheme.CenterText(prompt, modules.Width(writer))))
+
+	key, err := keyReader.ReadKey()
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(key) {
+	case "y", "enter":
+		return s.read(writer, keyReader, areas)
+	}
+	return true
+}
+
+// Execute runs the scan from a menu
+func (s *Scan) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	areas, err := s.load()
+	if err != nil {
+		showMessage(writer, keyReader, s.colorScheme, modules.T(writer, "Failed to load new messages: ")+modules.ErrorText(writer, err), "error")
+		return true
+	}
+	if len(areas) == 0 {
+		showMessage(writer, keyReader, s.colorScheme, "Nothing new since you last read.", "success")
+		return true
+	}
+	return s.read(writer, keyReader, areas)
+}
+
+// load finds the new items in each area, leaving out areas with none
+func (s *Scan) load() ([]area, error) {
+	lastRead, err := s.db.GetLastRead(s.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var areas []area
+
+	bulletins, err := s.db.GetBulletins(maxPerArea)
+	if err != nil {
+		return nil, err
+	}
+	news := area{name: "System Bulletins", lastRead: database.BulletinsArea}
+	// Bulletins come newest first; read them oldest first like posts
+	for i := len(bulletins) - 1; i >= 0; i-- {
+		b := bulletins[i]
+		if b.ID > lastRead[database.BulletinsArea] {
+			news.items = append(news.items, item{id: b.ID, subject: b.Title, author: b.Author, date: b.CreatedAt, body: b.Body})
+		}
+	}
+	if len(news.items) > 0 {
+		areas = append(areas, news)
+	}
+
+	topics, err := s.db.GetTopics(s.accessLevel)
+	if err != nil {
+		return nil, err
+	}
+	for _, topic := range topics {
+		key := database.TopicArea(topic.ID)
+		posts, err := s.db.GetPostsAfter(topic.ID, lastRead[key], maxPerArea)
+		if err != nil {
+			return nil, err
+		}
+		if len(posts) == 0 {
+			continue
+		}
+		a := area{name: topic.Name, lastRead: key}
+		for _, p := range posts {
+			a.items = append(a.items, item{id: p.ID, subject: p.Subject, author: p.Author, date: p.CreatedAt, body: p.Body, replies: p.ReplyCount})
+		}
+		areas = append(areas, a)
+	}
+	return areas, nil
+}
+
+// read shows each new item in turn until the caller stops
+func (s *Scan) read(writer modules.Writer, keyReader modules.KeyReader, areas []area) bool {
+	for _, a := range areas {
+	items:
+		for i, it := range a.items {
+			s.show(writer, keyReader, a, i)
+			// A caller who hung up partway through hasn't read it
+			if modules.HungUp(writer) {
+				return false
+			}
+			if !s.db.ReadOnly() {
+				s.db.MarkRead(s.userID, a.lastRead, it.id)
+			}
+
+			writer.Write([]byte(menu.ClearContentArea))
+			title := s.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "%s: %d of %d new"), a.name, i+1, len(a.items)), "primary")
+			writer.Write([]byte(s.colorScheme.CenterText(title, modules.Width(writer)) + "\n\n"))
+			prompt := s.colorScheme.Colorize(modules.T(writer, "Enter: Next  S: Skip to the Next Area  Q: Stop Scanning"), "secondary")
+			writer.Write([]byte(s.colorScheme.CenterText(prompt, modules.Width(writer))))
+
+			key, err := keyReader.ReadKey()
+			if err != nil {
+				return false
+			}
+			switch strings.ToLower(key) {
+			case "s":
+				break items
+			case "q", "quit", "escape":
+				return true
+			}
+		}
+	}
+
+	showMessage(writer, keyReader, s.colorScheme, "You're all caught up.", "success")
+	return true
+}
+
+// show displays one new item in the pager
+func (s *Scan) show(writer modules.Writer, keyReader modules.KeyReader, a area, index int) {
+	it := a.items[index]
+
+	info := fmt.Sprintf("%s | By: %s | Date: %s", a.name, it.author, it.date.Format("January 2, 2006 15:04"))
+	if it.replies > 0 {
+		info += fmt.Sprintf(" | %d replies", it.replies)
+	}
+	contentLines := []string{s.colorScheme.CenterText(s.colorScheme.Colorize(info, "secondary"), modules.Width(writer)), ""}
+	for _, line := range strings.Split(it.body, "\n") {
+		contentLines = append(contentLines, "  "+s.colorScheme.Colorize(strings.TrimRight(line, "\r"), "text"))
+	}
+
+	termSizer := pager.NewTerminalSizerFromWriter(writer)
+	writerAdapter := pager.NewWriterAdapter(writer, termSizer)
+
+	// Give the pager status bar control when the writer supports it
+	type StatusBarController interface {
+		Pause()
+		Resume()
+	}
+	if sbCtrl, ok := writer.(StatusBarController); ok {
+		writerAdapter.WithStatusBarManager(sbCtrl)
+	}
+
+	p := pager.NewPager(writerAdapter, keyReader, writerAdapter, s.colorScheme)
+	if writerAdapter.StatusBarMgr != nil {
+		p.WithStatusBar(writerAdapter)
+	}
+	p.Display(contentLines, fmt.Sprintf("--- %s ---", it.subject))
+}
+
+// showMessage displays a message and waits for a key
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(modules.T(writer, message), messageType), modules.Width(writer)) + "\n\n"))
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	writer.Write([]byte(colorScheme.CenterText(prompt, modules.Width(writer))))
+	keyReader.ReadKey()
+}
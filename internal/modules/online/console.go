@@ -0,0 +1,123 @@
+package online
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/dialog"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// Broadcaster shows a one-line message to every other caller online and
+// returns how many it reached
+type Broadcaster func(text string) int
+
+// UserLogoff ends a logged-in user's call and returns the node they were on
+type UserLogoff func(username string) (int, error)
+
+// Broadcast lets the sysop send one line to everyone online
+type Broadcast struct {
+	colorScheme menu.ColorScheme
+	broadcast   Broadcaster
+}
+
+// NewBroadcast creates the sysop's broadcast prompt
+func NewBroadcast(colorScheme menu.ColorScheme, broadcast Broadcaster) *Broadcast {
+	return &Broadcast{
+		colorScheme: colorScheme,
+		broadcast:   broadcast,
+	}
+}
+
+// Execute asks for the message and sends it
+func (b *Broadcast) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	cs := b.colorScheme
+	writer.Write([]byte(menu.ClearContentArea))
+	header := cs.Colorize(modules.T(writer, "--- Broadcast ---"), "primary")
+	writer.Write([]byte(cs.CenterText(header, modules.Width(writer)) + "\n\n"))
+	note := modules.T(writer, "Every caller online sees this above their status bar. Enter to cancel.")
+	writer.Write([]byte(cs.CenterText(cs.Colorize(note, "secondary"), modules.Width(writer)) + "\n\n"))
+
+	writer.Write([]byte(menu.ShowCursor + cs.Colorize(modules.T(writer, "Message: "), "text")))
+	text, err := readLine(keyReader, writer, maxMessageLength)
+	writer.Write([]byte(menu.HideCursor))
+	if err != nil || strings.TrimSpace(text) == "" {
+		return true
+	}
+
+	reached := b.broadcast(strings.TrimSpace(text))
+	if reached == 0 {
+		showMessage(writer, keyReader, cs, "Nobody else is online to see it.", "error")
+		return true
+	}
+	showMessage(writer, keyReader, cs, fmt.Sprintf(modules.T(writer, "Broadcast sent to %d caller(s)."), reached), "success")
+	return true
+}
+
+// ForceLogoff lets the sysop end another caller's call by username
+type ForceLogoff struct {
+	colorScheme menu.ColorScheme
+	list        Lister
+	logoff      UserLogoff
+	node        int // The sysop's own node
+}
+
+// NewForceLogoff creates the force logoff screen for the sysop on node
+func NewForceLogoff(colorScheme menu.ColorScheme, list Lister, logoff UserLogoff, node int) *ForceLogoff {
+	return &ForceLogoff{
+		colorScheme: colorScheme,
+		list:        list,
+		logoff:      logoff,
+		node:        node,
+	}
+}
+
+// Execute lists who is online, asks whose call to end and confirms it
+func (f *ForceLogoff) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	cs := f.colorScheme
+	nodes := f.list()
+	writer.Write([]byte(menu.ClearContentArea))
+	header := cs.Colorize(modules.T(writer, "--- Force Logoff ---"), "primary")
+	writer.Write([]byte(cs.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	headerLine := fmt.Sprintf("%-4s %-15s %-30s", "Node", "User", "Activity")
+	writer.Write([]byte(cs.CenterText(cs.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+	writer.Write([]byte(cs.CenterText(cs.DrawSeparator(len(headerLine), "─"), modules.Width(writer)) + "\n"))
+	for _, node := range nodes {
+		line := fmt.Sprintf("%-4d %-15s %-30s", node.Number, ansi.Ellipsize(node.Username, 15), ansi.Ellipsize(node.Activity, 30))
+		style := "text"
+		if node.Number == f.node {
+			style = "secondary"
+		}
+		writer.Write([]byte(cs.CenterText(cs.Colorize(line, style), modules.Width(writer)) + "\n"))
+	}
+
+	input, ok := dialog.Input(writer, keyReader, cs, modules.T(writer, "Log off which user?"), 30)
+	username := strings.TrimSpace(input)
+	if !ok || username == "" {
+		return true
+	}
+
+	// Match the name as listed, whatever case the sysop typed it in
+	for _, node := range nodes {
+		if strings.EqualFold(node.Username, username) {
+			username = node.Username
+			break
+		}
+	}
+
+	question := fmt.Sprintf(modules.T(writer, "End %s's call?"), username)
+	if !dialog.Confirm(writer, keyReader, cs, question, false) {
+		return true
+	}
+
+	node, err := f.logoff(username)
+	if err != nil {
+		dialog.Alert(writer, keyReader, cs, modules.T(writer, "Can't log off: ")+modules.ErrorText(writer, err), "error")
+		return true
+	}
+	dialog.Alert(writer, keyReader, cs, fmt.Sprintf(modules.T(writer, "%s was logged off node %d."), username, node), "success")
+	return true
+}
@@ -0,0 +1,8 @@
+package online
+
+import "embed"
+
+// Help holds the help topics for the who's online list
+//
+//go:embed help/*.md
+var Help embed.FS
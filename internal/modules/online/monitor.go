@@ -0,0 +1,160 @@
+package online
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/dialog"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// Disconnecter logs off the caller on a node and returns their username
+type Disconnecter func(node int) (string, error)
+
+// NodeMonitor shows the sysop every node, with what its caller is doing and
+// for how long, and lets them message or disconnect the selected node
+type NodeMonitor struct {
+	colorScheme menu.ColorScheme
+	list        Lister
+	send        Sender
+	disconnect  Disconnecter
+	node        int // The sysop's own node
+	selected    int
+}
+
+// NewNodeMonitor creates the node monitor for the sysop on node
+func NewNodeMonitor(colorScheme menu.ColorScheme, list Lister, send Sender, disconnect Disconnecter, node int) *NodeMonitor {
+	return &NodeMonitor{
+		colorScheme: colorScheme,
+		list:        list,
+		send:        send,
+		disconnect:  disconnect,
+		node:        node,
+	}
+}
+
+// Execute shows the nodes, listed again each time a key is pressed, until
+// the sysop quits
+func (m *NodeMonitor) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		nodes := m.list()
+		m.selected = max(min(m.selected, len(nodes)-1), 0)
+		m.render(writer, nodes)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "up":
+			if m.selected > 0 {
+				m.selected--
+			}
+		case "down":
+			if m.selected < len(nodes)-1 {
+				m.selected++
+			}
+		case "pageup", "pagedown", "home", "end":
+			m.selected = menu.Jump(key, m.selected, len(nodes), menu.ListRows(writer))
+		case "m":
+			if len(nodes) > 0 {
+				m.message(writer, keyReader, nodes[m.selected])
+			}
+		case "d":
+			if len(nodes) > 0 {
+				m.disconnectNode(writer, keyReader, nodes[m.selected])
+			}
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// message sends a one-line message to the caller on a node
+func (m *NodeMonitor) message(writer modules.Writer, keyReader modules.KeyReader, node Node) {
+	if node.Number == m.node {
+		showMessage(writer, keyReader, m.colorScheme, "That's your own node.", "error")
+		return
+	}
+
+	writer.Write([]byte(menu.ShowCursor + "\n\n"))
+	prompt := fmt.Sprintf(modules.T(writer, "Message to %s on node %d: "), node.Username, node.Number)
+	writer.Write([]byte(m.colorScheme.Colorize(prompt, "text")))
+	text, err := readLine(keyReader, writer, maxMessageLength)
+	writer.Write([]byte(menu.HideCursor))
+	if err != nil || strings.TrimSpace(text) == "" {
+		return
+	}
+
+	username, err := m.send(node.Number, strings.TrimSpace(text))
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, modules.T(writer, "Message not sent: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	showMessage(writer, keyReader, m.colorScheme, fmt.Sprintf(modules.T(writer, "Message sent to %s on node %d."), username, node.Number), "success")
+}
+
+// disconnectNode asks the sysop to confirm, then logs the caller on a node off
+func (m *NodeMonitor) disconnectNode(writer modules.Writer, keyReader modules.KeyReader, node Node) {
+	if node.Number == m.node {
+		dialog.Alert(writer, keyReader, m.colorScheme, "That's your own node.", "error")
+		return
+	}
+
+	question := fmt.Sprintf(modules.T(writer, "Disconnect %s from node %d?"), node.Username, node.Number)
+	if !dialog.Confirm(writer, keyReader, m.colorScheme, question, false) {
+		return
+	}
+
+	username, err := m.disconnect(node.Number)
+	if err != nil {
+		dialog.Alert(writer, keyReader, m.colorScheme, modules.T(writer, "Can't disconnect: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	dialog.Alert(writer, keyReader, m.colorScheme, fmt.Sprintf(modules.T(writer, "%s was disconnected from node %d."), username, node.Number), "success")
+}
+
+// render draws the nodes with the selected one highlighted and its details
+// below
+func (m *NodeMonitor) render(writer modules.Writer, nodes []Node) {
+	cs := m.colorScheme
+	writer.Write([]byte(menu.ClearContentArea + menu.HideCursor))
+
+	header := cs.Colorize(modules.T(writer, "--- Node Monitor ---"), "primary")
+	writer.Write([]byte(cs.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	headerLine := fmt.Sprintf("%-4s %-15s %-26s %-6s %-9s %-6s", "Node", "User", "Activity", "Idle", "Connected", "On For")
+	writer.Write([]byte(cs.CenterText(cs.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+	separator := cs.DrawSeparator(len(headerLine), "─")
+	writer.Write([]byte(cs.CenterText(separator, modules.Width(writer)) + "\n"))
+
+	for i, node := range nodes {
+		username := node.Username
+		if node.Invisible {
+			username += "*"
+		}
+		idle := formatDuration(node.Idle)
+		if node.Away {
+			idle = "Away"
+		}
+		line := fmt.Sprintf("%-4d %-15s %-26s %-6s %-9s %-6s", node.Number, ansi.Ellipsize(username, 15),
+			ansi.Ellipsize(node.Activity, 26), idle, node.ConnectedAt.Format("15:04"), formatDuration(time.Since(node.LoginTime)))
+		writer.Write([]byte(cs.CenterText(cs.HighlightSelection(line, i == m.selected, len(line)+2), modules.Width(writer)) + "\n"))
+	}
+
+	writer.Write([]byte("\n"))
+	if len(nodes) > 0 {
+		node := nodes[m.selected]
+		detail := fmt.Sprintf("Node %d: from %s, connected %s, logged in %s", node.Number, node.Address,
+			node.ConnectedAt.Format("Jan 02 15:04:05"), node.LoginTime.Format("15:04:05"))
+		writer.Write([]byte(cs.CenterText(cs.Colorize(ansi.Ellipsize(detail, 77), "text"), modules.Width(writer)) + "\n"))
+	}
+	count := fmt.Sprintf("%d caller(s) online  * = invisible", len(nodes))
+	writer.Write([]byte(cs.CenterText(cs.Colorize(count, "secondary"), modules.Width(writer)) + "\n"))
+	instructions := cs.Colorize(modules.T(writer, "↑↓: Select  M: Message  D: Disconnect  R: Refresh  Q: Quit"), "secondary")
+	writer.Write([]byte(cs.CenterText(instructions, modules.Width(writer))))
+}
@@ -0,0 +1,234 @@
+package online
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// Node describes a caller on one node of the board
+type Node struct {
+	Number      int
+	Username    string
+	Activity    string        // What the caller is doing, e.g. "Reading mail"
+	LoginTime   time.Time     // When the caller logged on
+	ConnectedAt time.Time     // When the caller connected, before logging on
+	Address     string        // Where the caller connected from
+	Idle        time.Duration // Time since the caller last pressed a key
+	Away        bool          // Idle long enough to be marked away
+	Invisible   bool          // Logged in invisibly; only listed for sysops
+}
+
+// Lister returns the callers currently online, in node order
+type Lister func() []Node
+
+// Sender delivers an instant message to the caller on a node and returns
+// their username
+type Sender func(node int, text string) (string, error)
+
+// BreakIn runs a sysop chat with the caller on a node until the sysop ends
+// it. It returns false if the sysop's connection was lost.
+type BreakIn func(node int) (bool, error)
+
+// maxMessageLength keeps instant messages to one line on the recipient's screen
+const maxMessageLength = 60
+
+// WhosOnline lists the callers currently logged in
+type WhosOnline struct {
+	colorScheme menu.ColorScheme
+	list        Lister
+	send        Sender
+	breakIn     BreakIn // Nil unless the viewer is a sysop
+	node        int     // The viewer's own node
+}
+
+// NewWhosOnline creates a who's online listing for the caller on node.
+// breakIn may be nil to hide the sysop chat command.
+func NewWhosOnline(colorScheme menu.ColorScheme, list Lister, send Sender, breakIn BreakIn, node int) *WhosOnline {
+	return &WhosOnline{
+		colorScheme: colorScheme,
+		list:        list,
+		send:        send,
+		breakIn:     breakIn,
+		node:        node,
+	}
+}
+
+// Execute shows who is online until the user quits
+func (w *WhosOnline) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		w.render(writer, w.list())
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "m":
+			w.sendMessage(writer, keyReader)
+		case "c":
+			if w.breakIn != nil {
+				if !w.chat(writer, keyReader) {
+					return false
+				}
+			}
+		case "q", "quit", "escape", "enter":
+			return true
+		}
+	}
+}
+
+// sendMessage asks for a node and a one-line message and delivers it
+func (w *WhosOnline) sendMessage(writer modules.Writer, keyReader modules.KeyReader) {
+	node, ok := w.promptNode(writer, keyReader, "Send to node: ")
+	if !ok {
+		return
+	}
+
+	writer.Write([]byte(menu.ShowCursor))
+	writer.Write([]byte(w.colorScheme.Colorize(modules.T(writer, "Message: "), "text")))
+	text, err := readLine(keyReader, writer, maxMessageLength)
+	writer.Write([]byte(menu.HideCursor))
+	if err != nil || strings.TrimSpace(text) == "" {
+		return
+	}
+
+	username, err := w.send(node, strings.TrimSpace(text))
+	if err != nil {
+		showMessage(writer, keyReader, w.colorScheme, modules.T(writer, "Message not sent: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	showMessage(writer, keyReader, w.colorScheme, fmt.Sprintf(modules.T(writer, "Message sent to %s on node %d."), username, node), "success")
+}
+
+// promptNode asks for another caller's node number
+func (w *WhosOnline) promptNode(writer modules.Writer, keyReader modules.KeyReader, prompt string) (int, bool) {
+	writer.Write([]byte(menu.ShowCursor + "\n\n"))
+	writer.Write([]byte(w.colorScheme.Colorize(prompt, "text")))
+	input, err := readLine(keyReader, writer, 4)
+	writer.Write([]byte(menu.HideCursor))
+	if err != nil || strings.TrimSpace(input) == "" {
+		return 0, false
+	}
+
+	node, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || node < 1 {
+		showMessage(writer, keyReader, w.colorScheme, "Enter a node number from the list.", "error")
+		return 0, false
+	}
+	if node == w.node {
+		showMessage(writer, keyReader, w.colorScheme, "That's your own node.", "error")
+		return 0, false
+	}
+	return node, true
+}
+
+// chat asks for a node and breaks into that caller's session. It returns
+// false if the connection was lost during the chat.
+func (w *WhosOnline) chat(writer modules.Writer, keyReader modules.KeyReader) bool {
+	node, ok := w.promptNode(writer, keyReader, "Chat with node: ")
+	if !ok {
+		return true
+	}
+
+	connected, err := w.breakIn(node)
+	if err != nil {
+		showMessage(writer, keyReader, w.colorScheme, modules.T(writer, "Can't chat: ")+modules.ErrorText(writer, err), "error")
+	}
+	return connected
+}
+
+// render draws the node listing
+func (w *WhosOnline) render(writer modules.Writer, nodes []Node) {
+	writer.Write([]byte(menu.ClearContentArea + menu.HideCursor))
+
+	header := w.colorScheme.Colorize(modules.T(writer, "--- Who's Online ---"), "primary")
+	writer.Write([]byte(w.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	headerLine := fmt.Sprintf("%-5s %-16s %-28s %-8s %-8s", "Node", "User", "Activity", "On For", "Status")
+	writer.Write([]byte(w.colorScheme.CenterText(w.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+	separator := w.colorScheme.DrawSeparator(len(headerLine), "─")
+	writer.Write([]byte(w.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+	anyInvisible := false
+	for _, node := range nodes {
+		username := node.Username
+		if node.Invisible {
+			username += "*"
+			anyInvisible = true
+		}
+
+		line := fmt.Sprintf("%-5d %-16s %-28s %-8s %-8s", node.Number, ansi.Ellipsize(username, 16),
+			ansi.Ellipsize(node.Activity, 28), formatDuration(time.Since(node.LoginTime)), status(node))
+
+		color := "text"
+		switch {
+		case node.Number == w.node:
+			color = "highlight"
+		case node.Away:
+			color = "secondary"
+		}
+		writer.Write([]byte(w.colorScheme.CenterText(w.colorScheme.Colorize(line, color), modules.Width(writer)) + "\n"))
+	}
+
+	writer.Write([]byte("\n"))
+	count := fmt.Sprintf("%d caller(s) online", len(nodes))
+	writer.Write([]byte(w.colorScheme.CenterText(w.colorScheme.Colorize(count, "secondary"), modules.Width(writer)) + "\n"))
+	commands := "R: Refresh  M: Message a Node  Q: Quit"
+	if w.breakIn != nil {
+		commands = "R: Refresh  M: Message a Node  C: Chat  Q: Quit"
+	}
+	instructions := w.colorScheme.Colorize(commands, "secondary")
+	writer.Write([]byte(w.colorScheme.CenterText(instructions, modules.Width(writer))))
+	if anyInvisible {
+		legend := w.colorScheme.Colorize(modules.T(writer, "* = invisible"), "text")
+		writer.Write([]byte("\n" + w.colorScheme.CenterText(legend, modules.Width(writer))))
+	}
+}
+
+// status summarizes how recently a caller was active
+func status(node Node) string {
+	switch {
+	case node.Away:
+		return "Away"
+	case node.Idle >= time.Minute:
+		return fmt.Sprintf("Idle %dm", int(node.Idle.Minutes()))
+	default:
+		return "Active"
+	}
+}
+
+// formatDuration formats a duration as H:MM
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// readLine reads a line of at most max characters from the user
+func readLine(keyReader modules.KeyReader, writer modules.Writer, max int) (string, error) {
+	return input.ReadLine(keyReader, writer, max)
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
+	writer.Write([]byte(centeredMessage + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	keyReader.ReadKey()
+}
@@ -0,0 +1,8 @@
+package page
+
+import "embed"
+
+// Help holds the help topics for the sysop page
+//
+//go:embed help/*.md
+var Help embed.FS
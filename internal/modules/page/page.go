@@ -0,0 +1,77 @@
+package page
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// Pager notifies the sysop that the caller wants to chat
+type Pager func(reason string) error
+
+// maxReasonLength keeps the reason to one line on the sysop's screen
+const maxReasonLength = 50
+
+// Page asks the caller why they want the sysop and pages them
+type Page struct {
+	colorScheme menu.ColorScheme
+	page        Pager
+	sysopName   string
+}
+
+// New creates a page prompt
+func New(colorScheme menu.ColorScheme, page Pager, sysopName string) *Page {
+	return &Page{
+		colorScheme: colorScheme,
+		page:        page,
+		sysopName:   sysopName,
+	}
+}
+
+// Execute prompts for a reason and sends the page
+func (p *Page) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	writer.Write([]byte(menu.ClearContentArea + menu.ShowCursor))
+	header := p.colorScheme.Colorize("--- Page "+p.sysopName+" ---", "primary")
+	writer.Write([]byte(p.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	writer.Write([]byte(p.colorScheme.Colorize(modules.T(writer, "Reason for paging (Enter to cancel): "), "text")))
+
+	reason, err := readLine(keyReader, writer, maxReasonLength)
+	writer.Write([]byte(menu.HideCursor))
+	if err != nil || strings.TrimSpace(reason) == "" {
+		return true
+	}
+
+	if err := p.page(strings.TrimSpace(reason)); err != nil {
+		showMessage(writer, keyReader, p.colorScheme, modules.ErrorText(writer, err), "error")
+		return true
+	}
+	message := fmt.Sprintf(modules.T(writer, "%s has been paged. Stay online; if available, they will break in to chat."), p.sysopName)
+	showMessage(writer, keyReader, p.colorScheme, message, "success")
+	return true
+}
+
+// readLine reads a line of at most max characters from the user
+func readLine(keyReader modules.KeyReader, writer modules.Writer, max int) (string, error) {
+	return input.ReadLine(keyReader, writer, max)
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
+	writer.Write([]byte(centeredMessage + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	keyReader.ReadKey()
+}
@@ -0,0 +1,297 @@
+package registration
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bbs/internal/components"
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/modules/feedback"
+	"bbs/internal/webhook"
+)
+
+// NewUserName is the login name callers use to apply for an account
+const NewUserName = "new"
+
+// Logger records what the registration webhook decided
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// Registration implements the new caller application flow
+type Registration struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	config      config.RegistrationConfig
+	log         Logger
+}
+
+// NewRegistration creates a registration module
+func NewRegistration(db *database.DB, colorScheme menu.ColorScheme, cfg config.RegistrationConfig, log Logger) *Registration {
+	return &Registration{
+		db:          db,
+		colorScheme: colorScheme,
+		config:      cfg,
+		log:         log,
+	}
+}
+
+// Register walks a new caller through the application form. It returns the
+// new account if it can be used right away, or nil if the caller cancelled
+// or the account is waiting for sysop approval.
+func (r *Registration) Register(writer modules.Writer, keyReader modules.KeyReader) *database.User {
+	if !r.config.Enabled {
+		showMessage(writer, keyReader, r.colorScheme, "New user registration is closed.", "error")
+		return nil
+	}
+	if r.db.ReadOnly() {
+		showMessage(writer, keyReader, r.colorScheme, "This board is a read-only mirror. Registration is disabled.", "error")
+		return nil
+	}
+
+	form := r.buildForm(writer)
+	form.Start()
+
+	for {
+		writer.Write([]byte(form.Render()))
+
+		if err := form.ReadKey(keyReader); err != nil {
+			return nil
+		}
+
+		if form.IsCancelled() {
+			return nil
+		}
+
+		if form.IsSubmitted() {
+			if errs := form.Validate(); len(errs) > 0 {
+				errorMsg := "Please correct the following:\n"
+				for _, err := range errs {
+					errorMsg += "• " + err.Error() + "\n"
+				}
+				showMessage(writer, keyReader, r.colorScheme, errorMsg, "error")
+				form.Reset()
+				form.Start()
+				continue
+			}
+
+			return r.createAccount(writer, keyReader, form.GetStringValues())
+		}
+	}
+}
+
+// buildForm creates the application form
+func (r *Registration) buildForm(writer modules.Writer) *components.Form {
+	form := components.NewForm(components.FormConfig{
+		Title: "New User Application",
+		Width: modules.Width(writer),
+	}, r.colorScheme)
+
+	form.AddComponent(components.NewTextInput(components.TextInputConfig{
+		Name:        "username",
+		Label:       "Username",
+		Placeholder: "Choose a username...",
+		MaxLength:   32,
+		Required:    true,
+		Width:       40,
+		Validator:   r.validateUsername,
+	}, r.colorScheme))
+
+	password := components.NewTextInput(components.TextInputConfig{
+		Name:        "password",
+		Label:       "Password",
+		Placeholder: "At least 6 characters...",
+		MaxLength:   64,
+		Required:    true,
+		Width:       40,
+		Mask:        true,
+		Validator: func(value string) error {
+			if len(strings.TrimSpace(value)) < 6 {
+				return fmt.Errorf("password must be at least 6 characters")
+			}
+			return nil
+		},
+	}, r.colorScheme)
+	form.AddComponent(password)
+
+	form.AddComponent(components.NewTextInput(components.TextInputConfig{
+		Name:        "confirm_password",
+		Label:       "Confirm Password",
+		Placeholder: "Type it again...",
+		MaxLength:   64,
+		Required:    true,
+		Width:       40,
+		Mask:        true,
+		Validator:   components.Matches(password),
+	}, r.colorScheme))
+
+	form.AddComponent(components.NewTextInput(components.TextInputConfig{
+		Name:        "real_name",
+		Label:       "Real Name",
+		Placeholder: "Your name...",
+		MaxLength:   64,
+		Required:    true,
+		Width:       40,
+	}, r.colorScheme))
+
+	form.AddComponent(components.NewTextInput(components.TextInputConfig{
+		Name:        "email",
+		Label:       "Email",
+		Placeholder: "you@example.com",
+		MaxLength:   128,
+		Required:    true,
+		Width:       40,
+		Validator: func(value string) error {
+			if !strings.Contains(value, "@") {
+				return fmt.Errorf("email address looks invalid")
+			}
+			return nil
+		},
+	}, r.colorScheme))
+
+	return form
+}
+
+// validateUsername checks that a requested username is well formed and free
+func (r *Registration) validateUsername(value string) error {
+	username := strings.TrimSpace(value)
+	if len(username) < 3 {
+		return fmt.Errorf("username must be at least 3 characters")
+	}
+	if strings.ContainsAny(username, " \t") {
+		return fmt.Errorf("username cannot contain spaces")
+	}
+	if strings.Contains(username, "/") {
+		return fmt.Errorf("username cannot contain '/'")
+	}
+	if strings.EqualFold(username, NewUserName) || strings.EqualFold(username, feedback.LoginName) {
+		return fmt.Errorf("username %q is reserved", username)
+	}
+
+	exists, err := r.db.UsernameExists(username)
+	if err != nil {
+		return fmt.Errorf("could not check username: %v", err)
+	}
+	if exists {
+		return fmt.Errorf("username is already taken")
+	}
+	return nil
+}
+
+// createAccount stores the application and tells the caller what happens next
+func (r *Registration) createAccount(writer modules.Writer, keyReader modules.KeyReader, values map[string]string) *database.User {
+	user := &database.User{
+		Username:    strings.TrimSpace(values["username"]),
+		Password:    strings.TrimSpace(values["password"]), // Hashed by the database layer
+		RealName:    strings.TrimSpace(values["real_name"]),
+		Email:       strings.TrimSpace(values["email"]),
+		AccessLevel: r.config.DefaultAccessLevel,
+		CreatedAt:   time.Now(),
+	}
+
+	// With a webhook the account is held until the webhook answers
+	useWebhook := r.config.Webhook.URL != ""
+	if err := r.db.RegisterUser(user, r.config.RequireApproval || useWebhook); err != nil {
+		showMessage(writer, keyReader, r.colorScheme, modules.T(writer, "Registration failed: ")+modules.ErrorText(writer, err), "error")
+		return nil
+	}
+
+	if useWebhook {
+		return r.checkWebhook(writer, keyReader, user)
+	}
+
+	if r.config.RequireApproval {
+		showPending(writer, keyReader, r.colorScheme)
+		return nil
+	}
+
+	showWelcome(writer, keyReader, r.colorScheme, user)
+	return user
+}
+
+// checkWebhook asks the registration webhook about a held account. An
+// approved account is activated and returned, a denied one is deleted, and
+// if the webhook can't decide the account is left for the sysop.
+func (r *Registration) checkWebhook(writer modules.Writer, keyReader modules.KeyReader, user *database.User) *database.User {
+	writer.Write([]byte(menu.ClearScreen))
+	checking := r.colorScheme.Colorize(modules.T(writer, "Checking your application, please wait..."), "text")
+	writer.Write([]byte(r.colorScheme.CenterText(checking, modules.Width(writer)) + "\n"))
+
+	timeout := time.Duration(r.config.Webhook.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	checker := webhook.New(r.config.Webhook.URL, r.config.Webhook.Secret, timeout)
+
+	decision, err := checker.Check(webhook.Application{
+		Username:    user.Username,
+		RealName:    user.RealName,
+		Email:       user.Email,
+		RequestedAt: user.CreatedAt,
+	})
+	if err != nil {
+		r.log.Printf("registration webhook failed for %s, left for sysop approval: %v", user.Username, err)
+		showPending(writer, keyReader, r.colorScheme)
+		return nil
+	}
+
+	if !decision.Approved {
+		r.log.Printf("registration webhook denied %s", user.Username)
+		if err := r.db.RejectRegistration(user.ID); err != nil {
+			r.log.Printf("failed to remove denied registration %s: %v", user.Username, err)
+		}
+		message := modules.T(writer, "Sorry, your application was not accepted.")
+		if decision.Reason != "" {
+			message += "\n" + decision.Reason
+		}
+		showMessage(writer, keyReader, r.colorScheme, message, "error")
+		return nil
+	}
+
+	// The webhook's approval stands in for the sysop's
+	if err := r.db.ApproveRegistration(user.ID); err != nil {
+		r.log.Printf("failed to activate %s after webhook approval: %v", user.Username, err)
+		showPending(writer, keyReader, r.colorScheme)
+		return nil
+	}
+	r.log.Printf("registration webhook approved %s", user.Username)
+	user.IsActive = true
+
+	showWelcome(writer, keyReader, r.colorScheme, user)
+	return user
+}
+
+// showPending tells the caller their account is waiting for the sysop
+func showPending(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme) {
+	showMessage(writer, keyReader, colorScheme,
+		"Thanks! Your application is waiting for sysop approval. Please call back later.", "success")
+}
+
+// showWelcome tells the caller their account is ready
+func showWelcome(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, user *database.User) {
+	showMessage(writer, keyReader, colorScheme, fmt.Sprintf(modules.T(writer, "Welcome aboard, %s! Your account is ready."), user.Username), "success")
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(modules.T(writer, message), "\n"), "\n") {
+		coloredLine := colorScheme.Colorize(line, messageType)
+		writer.Write([]byte(colorScheme.CenterText(coloredLine, modules.Width(writer)) + "\n"))
+	}
+	writer.Write([]byte("\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	keyReader.ReadKey()
+}
@@ -0,0 +1,54 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry binds menu commands to the modules that run them
+type Registry struct {
+	mu      sync.RWMutex
+	modules map[string]Module
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{modules: make(map[string]Module)}
+}
+
+// Register adds a module under its name. Two modules can't share a name.
+func (r *Registry) Register(m Module) error {
+	name := m.Name()
+	if name == "" {
+		return fmt.Errorf("module has no name")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.modules[name]; ok {
+		return fmt.Errorf("module %q is already registered", name)
+	}
+	r.modules[name] = m
+	return nil
+}
+
+// Lookup returns the module for a menu command
+func (r *Registry) Lookup(name string) (Module, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.modules[name]
+	return m, ok
+}
+
+// Modules lists every registered module by name
+func (r *Registry) Modules() []Module {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Module, 0, len(r.modules))
+	for _, m := range r.modules {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
@@ -0,0 +1,8 @@
+package search
+
+import "embed"
+
+// Help holds the help topics for search
+//
+//go:embed help/*.md
+var Help embed.FS
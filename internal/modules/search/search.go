@@ -0,0 +1,193 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/modules/boards"
+	"bbs/internal/modules/bulletins"
+	"bbs/internal/modules/messages"
+)
+
+// resultRows is how many results are listed on a page
+const resultRows = 10
+
+// Search finds posts, bulletins and mail by keyword and opens the one the
+// caller picks
+type Search struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	username    string
+	userID      int
+	accessLevel int
+}
+
+// NewSearch creates a search for a user
+func NewSearch(db *database.DB, colorScheme menu.ColorScheme, username string, userID, accessLevel int) *Search {
+	return &Search{
+		db:          db,
+		colorScheme: colorScheme,
+		username:    username,
+		userID:      userID,
+		accessLevel: accessLevel,
+	}
+}
+
+// Execute asks for keywords and lists what matches until the caller quits
+func (s *Search) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		keywords, err := s.prompt(writer, keyReader)
+		if err != nil || strings.TrimSpace(keywords) == "" {
+			return true
+		}
+		if !s.browse(writer, keyReader, keywords) {
+			return true
+		}
+	}
+}
+
+// prompt asks for the words to search for
+func (s *Search) prompt(writer modules.Writer, keyReader modules.KeyReader) (string, error) {
+	writer.Write([]byte(menu.ClearContentArea + menu.ShowCursor))
+	header := s.colorScheme.Colorize(modules.T(writer, "--- Search ---"), "primary")
+	writer.Write([]byte(s.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	help := s.colorScheme.Colorize(modules.T(writer, "Find posts, bulletins and your mail containing every word you type."), "secondary")
+	writer.Write([]byte(s.colorScheme.CenterText(help, modules.Width(writer)) + "\n"))
+	help = s.colorScheme.Colorize(modules.T(writer, "Press Enter on a blank line to go back."), "secondary")
+	writer.Write([]byte(s.colorScheme.CenterText(help, modules.Width(writer)) + "\n\n"))
+	writer.Write([]byte(s.colorScheme.Colorize(modules.T(writer, "Search for: "), "text")))
+	return readLine(keyReader, writer)
+}
+
+// browse pages through the results for keywords. It returns true when the
+// caller wants a new search and false when they quit.
+func (s *Search) browse(writer modules.Writer, keyReader modules.KeyReader, keywords string) bool {
+	page, selected := 0, 0
+
+	for {
+		results, total, err := s.db.Search(keywords, s.username, s.accessLevel, resultRows, page*resultRows)
+		if err != nil {
+			showMessage(writer, keyReader, s.colorScheme, modules.T(writer, "Search failed: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+		if total == 0 {
+			showMessage(writer, keyReader, s.colorScheme, fmt.Sprintf(modules.T(writer, "Nothing matches \"%s\"."), strings.TrimSpace(keywords)), "secondary")
+			return true
+		}
+		// Items deleted since the last page was shown can leave this one empty
+		if len(results) == 0 {
+			page = (total - 1) / resultRows
+			continue
+		}
+		if selected >= len(results) {
+			selected = len(results) - 1
+		}
+
+		s.render(writer, keywords, results, total, page, selected)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "up":
+			if selected > 0 {
+				selected--
+			}
+		case "down":
+			if selected < len(results)-1 {
+				selected++
+			}
+		case "left", "pageup":
+			if page > 0 {
+				page--
+				selected = 0
+			}
+		case "right", "pagedown":
+			if (page+1)*resultRows < total {
+				page++
+				selected = 0
+			}
+		case "home":
+			page, selected = 0, 0
+		case "end":
+			// The selection is clamped to the last result once it loads
+			page, selected = (total-1)/resultRows, resultRows-1
+		case "enter":
+			s.open(writer, keyReader, results[selected])
+		case "n":
+			return true
+		case "q", "quit", "escape":
+			return false
+		}
+	}
+}
+
+// render draws a page of results with the selected one highlighted and the
+// words around its match underneath
+func (s *Search) render(writer modules.Writer, keywords string, results []database.SearchResult, total, page, selected int) {
+	writer.Write([]byte(menu.ClearContentArea + menu.HideCursor))
+
+	header := s.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "--- Search: %s ---"), ansi.Ellipsize(strings.TrimSpace(keywords), 50)), "primary")
+	writer.Write([]byte(s.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	headerLine := fmt.Sprintf("  %-3s %-14s %-30s %-12s %-10s", "#", "Where", "Title", "By", "Date")
+	writer.Write([]byte(s.colorScheme.CenterText(s.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+	separator := s.colorScheme.DrawSeparator(len(headerLine), "─")
+	writer.Write([]byte(s.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+	for i, r := range results {
+		line := fmt.Sprintf("  %-3d %-14s %-30s %-12s %-10s", page*resultRows+i+1, ansi.Ellipsize(r.Where, 14),
+			ansi.Ellipsize(r.Title, 30), ansi.Ellipsize(r.Author, 12), r.CreatedAt.Format("2006-01-02"))
+		writer.Write([]byte(s.colorScheme.CenterText(s.colorScheme.HighlightSelection(line, i == selected, len(line)+2), modules.Width(writer)) + "\n"))
+	}
+
+	writer.Write([]byte(s.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+	snippet := strings.Join(strings.Fields(results[selected].Snippet), " ")
+	if results[selected].Archived {
+		snippet = "(Archived) " + snippet
+	}
+	snippet = ansi.Ellipsize(snippet, 75)
+	writer.Write([]byte(s.colorScheme.CenterText(s.colorScheme.Colorize(snippet, "text"), modules.Width(writer)) + "\n\n"))
+
+	first := page*resultRows + 1
+	status := fmt.Sprintf(modules.T(writer, "Showing %d-%d of %d"), first, first+len(results)-1, total)
+	writer.Write([]byte(s.colorScheme.CenterText(s.colorScheme.Colorize(status, "secondary"), modules.Width(writer)) + "\n"))
+	instructions := s.colorScheme.Colorize(modules.T(writer, "↑↓: Select  Enter: Read  ←→: Page  N: New Search  Q: Quit"), "secondary")
+	writer.Write([]byte(s.colorScheme.CenterText(instructions, modules.Width(writer))))
+}
+
+// open shows a result the way its own area would
+func (s *Search) open(writer modules.Writer, keyReader modules.KeyReader, r database.SearchResult) {
+	switch r.Kind {
+	case database.SearchPost:
+		boards.NewModule(s.db, s.colorScheme, s.username, s.userID, s.accessLevel).ShowPost(writer, keyReader, r.ID)
+	case database.SearchBulletin:
+		bulletins.NewModule(s.db, s.colorScheme, s.userID).ShowBulletin(writer, keyReader, r.ID)
+	case database.SearchMessage:
+		messages.NewMessages(s.db, s.colorScheme, s.username).Read(writer, keyReader, r.ID)
+	}
+}
+
+// readLine reads a line of input from the user
+func readLine(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
+	return input.ReadLine(keyReader, writer, 0)
+}
+
+// showMessage displays a message and waits for a key
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(modules.T(writer, message), messageType), modules.Width(writer)) + "\n\n"))
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	writer.Write([]byte(colorScheme.CenterText(prompt, modules.Width(writer))))
+	keyReader.ReadKey()
+}
@@ -0,0 +1,95 @@
+package settings
+
+import (
+	"fmt"
+	"time"
+
+	"bbs/internal/dialog"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// AccountCloser schedules the caller's account for deletion and returns
+// when it will be deleted
+type AccountCloser func() (time.Time, error)
+
+// DeleteAccount explains what closing the account does and, after the
+// caller confirms with their password, schedules it for deletion. If it is
+// already scheduled the caller is offered to keep it instead.
+func (st *Settings) DeleteAccount(writer modules.Writer, keyReader modules.KeyReader, graceDays int, closeAccount AccountCloser) bool {
+	if st.denyIfReadOnly(writer, keyReader) {
+		return true
+	}
+
+	user, err := st.db.GetUser(st.username)
+	if err != nil {
+		showMessage(writer, keyReader, st.colorScheme, "Failed to load your account.", "error")
+		return true
+	}
+	deleteAfter, err := st.db.GetAccountDeletion(user.ID)
+	if err != nil {
+		showMessage(writer, keyReader, st.colorScheme, "Failed to load your account.", "error")
+		return true
+	}
+
+	writer.Write([]byte(menu.ClearScreen + menu.ShowCursor))
+	defer writer.Write([]byte(menu.HideCursor))
+	header := st.colorScheme.Colorize(modules.T(writer, "--- Delete Account ---"), "primary")
+	writer.Write([]byte(st.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	if deleteAfter != nil {
+		notice := fmt.Sprintf("Your account will be deleted after %s.", deleteAfter.Format("Jan 2, 2006"))
+		writer.Write([]byte(st.colorScheme.Colorize(notice, "accent") + "\n\n"))
+		if !dialog.Confirm(writer, keyReader, st.colorScheme, modules.T(writer, "Keep your account?"), false) {
+			return true
+		}
+		if err := st.db.CancelAccountDeletion(user.ID); err != nil {
+			showMessage(writer, keyReader, st.colorScheme, "Failed to cancel the deletion.", "error")
+			return true
+		}
+		showMessage(writer, keyReader, st.colorScheme, "Your account will be kept.", "success")
+		return true
+	}
+
+	lines := []string{
+		"Deleting your account removes your profile, your private mail, your",
+		"API tokens and your call history.",
+		"",
+		"Your public posts and replies stay so the discussions still make sense,",
+		"but they will be shown as written by \"Deleted user\".",
+		"",
+		fmt.Sprintf("The account is deleted after %d days. Log in before then to keep it.", graceDays),
+	}
+	for _, line := range lines {
+		writer.Write([]byte(st.colorScheme.Colorize(line, "text") + "\n"))
+	}
+
+	if !dialog.Confirm(writer, keyReader, st.colorScheme, modules.T(writer, "Delete your account?"), false) {
+		return true
+	}
+
+	writer.Write([]byte("\n" + menu.ShowCursor + st.colorScheme.Colorize(modules.T(writer, "Enter your password to confirm: "), "accent")))
+	password, err := readPassword(keyReader, writer)
+	if err != nil || password == "" {
+		return true
+	}
+	if _, err := st.db.VerifyPassword(st.username, password); err != nil {
+		showMessage(writer, keyReader, st.colorScheme, "Incorrect password. Your account was not deleted.", "error")
+		return true
+	}
+
+	when, err := closeAccount()
+	if err != nil {
+		showMessage(writer, keyReader, st.colorScheme, "Failed to delete your account. Please try again later.", "error")
+		return true
+	}
+	message := fmt.Sprintf(modules.T(writer, "Your account will be deleted after %s."), when.Format("Jan 2, 2006"))
+	showMessage(writer, keyReader, st.colorScheme, message, "success")
+	return true
+}
+
+// readPassword reads a line without echoing it
+func readPassword(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
+	return input.ReadPassword(keyReader, writer, 0)
+}
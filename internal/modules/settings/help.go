@@ -0,0 +1,8 @@
+package settings
+
+import "embed"
+
+// Help holds the help topics for the user settings
+//
+//go:embed help/*.md
+var Help embed.FS
@@ -0,0 +1,314 @@
+package settings
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+	"bbs/internal/i18n"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// Limits on what callers can enter in their profile, matching registration
+const (
+	maxRealName    = 64
+	maxEmail       = 128
+	minScreenLines = 20
+	maxScreenLines = 99
+)
+
+// terminalTypes are the terminal types a caller can pick, in the order the
+// profile offers them. The first goes by whatever their client reports.
+var terminalTypes = []struct {
+	name  string // As stored in user_prefs
+	label string
+}{
+	{"", "Auto"},
+	{"ansi-bbs", "ANSI-BBS (IBM PC characters)"},
+	{"xterm", "ANSI (UTF-8 characters)"},
+}
+
+// ProfileSession is the caller's session as the profile editor sees it
+type ProfileSession interface {
+	Prefs() database.UserPrefs
+	// SetPrefs puts preferences into effect for the rest of the call
+	SetPrefs(prefs database.UserPrefs)
+	// SetContact updates the caller's name and email for the rest of the call
+	SetContact(realName, email string)
+	// Themes lists the color themes the caller can pick
+	Themes() []string
+	// ThemeSample returns a line of text drawn in a theme's colors, "" for
+	// the board's own
+	ThemeSample(theme string) string
+	// CheckPassword returns why a new password is unacceptable, or ""
+	CheckPassword(password, confirm string) string
+	// Languages lists the languages the board can be shown in
+	Languages() []i18n.Locale
+}
+
+// EditProfile lets the caller change their real name, email and password,
+// and the color theme, terminal type, screen length, menu style and
+// language the board uses for them. Each change is saved and takes effect straight away.
+func (st *Settings) EditProfile(writer modules.Writer, keyReader modules.KeyReader, session ProfileSession) bool {
+	if st.denyIfReadOnly(writer, keyReader) {
+		return true
+	}
+
+	user, err := st.db.GetUser(st.username)
+	if err != nil {
+		showMessage(writer, keyReader, st.colorScheme, "Failed to load your profile.", "error")
+		return true
+	}
+
+	for {
+		prefs := session.Prefs()
+		st.drawProfile(writer, user, prefs, session.Languages())
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "n":
+			st.editContact(writer, keyReader, session, user, "Real name", &user.RealName, maxRealName)
+		case "e":
+			st.editContact(writer, keyReader, session, user, "Email", &user.Email, maxEmail)
+		case "p":
+			st.changePassword(writer, keyReader, session, user)
+		case "t":
+			if theme, ok := st.pickTheme(writer, keyReader, session, prefs.Theme); ok {
+				prefs.Theme = theme
+				st.savePrefs(writer, keyReader, session, user, prefs)
+			}
+		case "y":
+			prefs.Terminal = terminalTypes[(terminalTypeIndex(prefs.Terminal)+1)%len(terminalTypes)].name
+			st.savePrefs(writer, keyReader, session, user, prefs)
+		case "l":
+			if lines, ok := st.readScreenLines(writer, keyReader, prefs.ScreenLines); ok {
+				prefs.ScreenLines = lines
+				st.savePrefs(writer, keyReader, session, user, prefs)
+			}
+		case "m":
+			if prefs.Navigation == database.NavHotkeys {
+				prefs.Navigation = database.NavLightbar
+			} else {
+				prefs.Navigation = database.NavHotkeys
+			}
+			st.savePrefs(writer, keyReader, session, user, prefs)
+		case "a":
+			prefs.Language = nextLanguage(session.Languages(), prefs.Language)
+			st.savePrefs(writer, keyReader, session, user, prefs)
+		case "quit", "q", "escape":
+			return true
+		}
+	}
+}
+
+// drawProfile shows the caller's details and preferences
+func (st *Settings) drawProfile(writer modules.Writer, user *database.User, prefs database.UserPrefs, languages []i18n.Locale) {
+	writer.Write([]byte(menu.ClearScreen))
+	header := st.colorScheme.Colorize(modules.T(writer, "--- Your Profile ---"), "primary")
+	writer.Write([]byte(st.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	theme := prefs.Theme
+	if theme == "" {
+		theme = "Board colors"
+	}
+	lines := "Auto"
+	if prefs.ScreenLines > 0 {
+		lines = fmt.Sprintf("%d lines", prefs.ScreenLines)
+	}
+	navigation := "Lightbar (arrow keys and Enter)"
+	if prefs.Navigation == database.NavHotkeys {
+		navigation = "Hotkeys only"
+	}
+	language := "Board default"
+	for _, l := range languages {
+		if l.Code == prefs.Language {
+			language = l.Name
+		}
+	}
+
+	rows := []struct{ label, value string }{
+		{"N) Real name:", user.RealName},
+		{"E) Email:", user.Email},
+		{"P) Password:", "********"},
+		{"T) Color theme:", theme},
+		{"Y) Terminal type:", terminalTypes[terminalTypeIndex(prefs.Terminal)].label},
+		{"L) Screen length:", lines},
+		{"M) Menus:", navigation},
+		{"A) Language:", language},
+	}
+	for _, row := range rows {
+		writer.Write([]byte(st.colorScheme.Colorize(fmt.Sprintf("%-28s", modules.T(writer, row.label)), "accent") +
+			st.colorScheme.Colorize(ansi.Ellipsize(row.value, 50), "text") + "\n"))
+	}
+
+	writer.Write([]byte("\n" + st.colorScheme.Colorize(modules.T(writer, "N/E/P/T/L: Edit  Y/M/A: Change  Q: Quit"), "secondary") + "\n"))
+}
+
+// editContact asks for a new real name or email and saves it
+func (st *Settings) editContact(writer modules.Writer, keyReader modules.KeyReader, session ProfileSession, user *database.User, label string, field *string, max int) {
+	writer.Write([]byte(menu.ShowCursor))
+	defer writer.Write([]byte(menu.HideCursor))
+	writer.Write([]byte("\n" + st.colorScheme.Colorize(label+" (Enter keeps it): ", "accent")))
+	value, err := readLine(keyReader, writer)
+	value = strings.TrimSpace(value)
+	if err != nil || value == "" || value == *field {
+		return
+	}
+
+	switch {
+	case len(value) > max:
+		showMessage(writer, keyReader, st.colorScheme, fmt.Sprintf(modules.T(writer, "%s can be at most %d characters."), label, max), "error")
+		return
+	case field == &user.Email && !strings.Contains(value, "@"):
+		showMessage(writer, keyReader, st.colorScheme, "That email address looks invalid.", "error")
+		return
+	}
+
+	old := *field
+	*field = value
+	if err := st.db.UpdateProfile(user.ID, user.RealName, user.Email); err != nil {
+		*field = old
+		showMessage(writer, keyReader, st.colorScheme, "Failed to save your profile.", "error")
+		return
+	}
+	session.SetContact(user.RealName, user.Email)
+}
+
+// changePassword asks for the current password, then a new one twice
+func (st *Settings) changePassword(writer modules.Writer, keyReader modules.KeyReader, session ProfileSession, user *database.User) {
+	writer.Write([]byte(menu.ShowCursor))
+	defer writer.Write([]byte(menu.HideCursor))
+
+	writer.Write([]byte("\n" + st.colorScheme.Colorize(modules.T(writer, "Current password: "), "accent")))
+	current, err := readPassword(keyReader, writer)
+	if err != nil || current == "" {
+		return
+	}
+	if _, err := st.db.VerifyPassword(st.username, current); err != nil {
+		showMessage(writer, keyReader, st.colorScheme, "Incorrect password. Your password was not changed.", "error")
+		return
+	}
+
+	writer.Write([]byte(st.colorScheme.Colorize(modules.T(writer, "New password: "), "accent")))
+	password, err := readPassword(keyReader, writer)
+	if err != nil || password == "" {
+		return
+	}
+	writer.Write([]byte(st.colorScheme.Colorize(modules.T(writer, "Confirm new password: "), "accent")))
+	confirm, err := readPassword(keyReader, writer)
+	if err != nil {
+		return
+	}
+	if problem := session.CheckPassword(password, confirm); problem != "" {
+		showMessage(writer, keyReader, st.colorScheme, problem, "error")
+		return
+	}
+
+	if err := st.db.ChangePassword(user.ID, password); err != nil {
+		showMessage(writer, keyReader, st.colorScheme, "Failed to save your new password. Please try again later.", "error")
+		return
+	}
+	showMessage(writer, keyReader, st.colorScheme, "Password changed.", "success")
+}
+
+// readScreenLines asks for a screen length, returning 0 for the client's own
+func (st *Settings) readScreenLines(writer modules.Writer, keyReader modules.KeyReader, current int) (int, bool) {
+	writer.Write([]byte(menu.ShowCursor))
+	defer writer.Write([]byte(menu.HideCursor))
+	prompt := fmt.Sprintf(modules.T(writer, "Screen length (%d-%d, 0 for auto): "), minScreenLines, maxScreenLines)
+	writer.Write([]byte("\n" + st.colorScheme.Colorize(prompt, "accent")))
+	input, err := readLine(keyReader, writer)
+	input = strings.TrimSpace(input)
+	if err != nil || input == "" {
+		return current, false
+	}
+	lines, err := strconv.Atoi(input)
+	if err != nil || (lines != 0 && (lines < minScreenLines || lines > maxScreenLines)) {
+		message := fmt.Sprintf(modules.T(writer, "Enter a length from %d to %d, or 0 for auto."), minScreenLines, maxScreenLines)
+		showMessage(writer, keyReader, st.colorScheme, message, "error")
+		return current, false
+	}
+	return lines, true
+}
+
+// savePrefs stores the caller's preferences and puts them into effect
+func (st *Settings) savePrefs(writer modules.Writer, keyReader modules.KeyReader, session ProfileSession, user *database.User, prefs database.UserPrefs) {
+	if err := st.db.SaveUserPrefs(user.ID, prefs); err != nil {
+		showMessage(writer, keyReader, st.colorScheme, "Failed to save your preferences.", "error")
+		return
+	}
+	session.SetPrefs(prefs)
+}
+
+// pickTheme shows each theme with a sample of its colors and returns the
+// one the caller picks, "" for the board's own colors. It reports false if
+// they keep the theme they have.
+func (st *Settings) pickTheme(writer modules.Writer, keyReader modules.KeyReader, session ProfileSession, current string) (string, bool) {
+	themes := append([]string{""}, session.Themes()...)
+
+	writer.Write([]byte(menu.ClearScreen + menu.ShowCursor))
+	defer writer.Write([]byte(menu.HideCursor))
+	header := st.colorScheme.Colorize(modules.T(writer, "--- Color Theme ---"), "primary")
+	writer.Write([]byte(st.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	for i, theme := range themes {
+		name := theme
+		if name == "" {
+			name = "Board colors"
+		}
+		mark := " "
+		if theme == current {
+			mark = "*"
+		}
+		label := fmt.Sprintf("%s%2d) %-20s", mark, i+1, ansi.Ellipsize(name, 20))
+		writer.Write([]byte(st.colorScheme.Colorize(label, "accent") + "  " + session.ThemeSample(theme) + "\n"))
+	}
+
+	writer.Write([]byte("\n" + st.colorScheme.Colorize(modules.T(writer, "Theme number (Enter keeps yours): "), "accent")))
+	input, err := readLine(keyReader, writer)
+	input = strings.TrimSpace(input)
+	if err != nil || input == "" {
+		return current, false
+	}
+	n, err := strconv.Atoi(input)
+	if err != nil || n < 1 || n > len(themes) {
+		showMessage(writer, keyReader, st.colorScheme, fmt.Sprintf(modules.T(writer, "Choose a theme from 1 to %d."), len(themes)), "error")
+		return current, false
+	}
+	return themes[n-1], themes[n-1] != current
+}
+
+// nextLanguage returns the language after current, going from the board's
+// default through each catalog and back
+func nextLanguage(languages []i18n.Locale, current string) string {
+	if current == "" {
+		if len(languages) == 0 {
+			return ""
+		}
+		return languages[0].Code
+	}
+	for i, l := range languages {
+		if l.Code == current && i+1 < len(languages) {
+			return languages[i+1].Code
+		}
+	}
+	return ""
+}
+
+// terminalTypeIndex returns where name is in terminalTypes, or 0 for one
+// the profile doesn't offer
+func terminalTypeIndex(name string) int {
+	for i, t := range terminalTypes {
+		if t.name == name {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package settings
+
+import (
+	"bbs/internal/database"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// Settings implements the per-user settings screens
+type Settings struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	userID      int
+	username    string
+}
+
+// NewSettings creates a settings module for the given user
+func NewSettings(db *database.DB, colorScheme menu.ColorScheme, userID int, username string) *Settings {
+	return &Settings{
+		db:          db,
+		colorScheme: colorScheme,
+		userID:      userID,
+		username:    username,
+	}
+}
+
+// denyIfReadOnly shows a notice and returns true when the board is a read-only mirror
+func (st *Settings) denyIfReadOnly(writer modules.Writer, keyReader modules.KeyReader) bool {
+	if !st.db.ReadOnly() {
+		return false
+	}
+	showMessage(writer, keyReader, st.colorScheme, "This board is a read-only mirror. Changes are disabled.", "error")
+	return true
+}
+
+// readLine reads a line of input from the user
+func readLine(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
+	return input.ReadLine(keyReader, writer, 0)
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
+	writer.Write([]byte(centeredMessage + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	keyReader.ReadKey()
+}
@@ -0,0 +1,119 @@
+package settings
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// ManageTokens lets the user list, generate, and revoke personal API tokens
+func (st *Settings) ManageTokens(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		tokens, err := st.db.GetAPITokens(st.userID)
+		if err != nil {
+			showMessage(writer, keyReader, st.colorScheme, modules.T(writer, "Failed to load tokens: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+
+		writer.Write([]byte(menu.ClearScreen))
+
+		header := st.colorScheme.Colorize(modules.T(writer, "--- Personal API Tokens ---"), "primary")
+		centeredHeader := st.colorScheme.CenterText(header, modules.Width(writer))
+		writer.Write([]byte(centeredHeader + "\n\n"))
+
+		if len(tokens) == 0 {
+			msg := st.colorScheme.Colorize(modules.T(writer, "You have no API tokens."), "secondary")
+			writer.Write([]byte(st.colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+		} else {
+			headerLine := fmt.Sprintf("%-3s %-20s %-14s %-10s %-10s", "#", "Name", "Token", "Created", "Last Used")
+			writer.Write([]byte(st.colorScheme.CenterText(st.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+			separator := st.colorScheme.DrawSeparator(len(headerLine), "─")
+			writer.Write([]byte(st.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+			for i, token := range tokens {
+				lastUsed := "never"
+				if token.LastUsedAt != nil {
+					lastUsed = token.LastUsedAt.Format("2006-01-02")
+				}
+				name := token.Name
+				if len(name) > 20 {
+					name = name[:17] + "..."
+				}
+				line := fmt.Sprintf("%-3d %-20s %-14s %-10s %-10s", i+1, name, token.Prefix+"…",
+					token.CreatedAt.Format("2006-01-02"), lastUsed)
+				writer.Write([]byte(st.colorScheme.CenterText(st.colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+			}
+		}
+
+		writer.Write([]byte("\n"))
+		instructions := st.colorScheme.Colorize(modules.T(writer, "N: New Token  R: Revoke Token  Q: Quit"), "secondary")
+		writer.Write([]byte(st.colorScheme.CenterText(instructions, modules.Width(writer)) + "\n"))
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "n":
+			if st.denyIfReadOnly(writer, keyReader) {
+				continue
+			}
+			st.createToken(writer, keyReader)
+		case "r":
+			if len(tokens) == 0 || st.denyIfReadOnly(writer, keyReader) {
+				continue
+			}
+			writer.Write([]byte("\n" + st.colorScheme.Colorize(modules.T(writer, "Token number to revoke: "), "text")))
+			input, err := readLine(keyReader, writer)
+			if err != nil || strings.TrimSpace(input) == "" {
+				continue
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(input))
+			if err != nil || index < 1 || index > len(tokens) {
+				showMessage(writer, keyReader, st.colorScheme, "Invalid token number.", "error")
+				continue
+			}
+			if err := st.db.RevokeAPIToken(st.userID, tokens[index-1].ID); err != nil {
+				showMessage(writer, keyReader, st.colorScheme, modules.T(writer, "Failed to revoke token: ")+modules.ErrorText(writer, err), "error")
+				continue
+			}
+			showMessage(writer, keyReader, st.colorScheme, "Token revoked.", "success")
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// createToken prompts for a token name and displays the new token once
+func (st *Settings) createToken(writer modules.Writer, keyReader modules.KeyReader) {
+	writer.Write([]byte("\n" + st.colorScheme.Colorize(modules.T(writer, "Name for the new token: "), "text")))
+	name, err := readLine(keyReader, writer)
+	if err != nil || strings.TrimSpace(name) == "" {
+		return
+	}
+
+	token, err := st.db.CreateAPIToken(st.userID, strings.TrimSpace(name))
+	if err != nil {
+		showMessage(writer, keyReader, st.colorScheme, modules.T(writer, "Failed to create token: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+
+	writer.Write([]byte(menu.ClearScreen))
+	header := st.colorScheme.Colorize(modules.T(writer, "--- New API Token ---"), "primary")
+	writer.Write([]byte(st.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	notice := st.colorScheme.Colorize(modules.T(writer, "Copy this token now. It will not be shown again."), "accent")
+	writer.Write([]byte(st.colorScheme.CenterText(notice, modules.Width(writer)) + "\n\n"))
+	writer.Write([]byte(st.colorScheme.CenterText(st.colorScheme.Colorize(token, "highlight"), modules.Width(writer)) + "\n\n"))
+
+	usage := st.colorScheme.Colorize(modules.T(writer, "Use it as: Authorization: Bearer <token>"), "text")
+	writer.Write([]byte(st.colorScheme.CenterText(usage, modules.Width(writer)) + "\n\n"))
+
+	prompt := st.colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	writer.Write([]byte(st.colorScheme.CenterText(prompt, modules.Width(writer))))
+	keyReader.ReadKey()
+}
@@ -0,0 +1,127 @@
+package bans
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+	"bbs/internal/dialog"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// Bans lets the sysop see which addresses are banned and lift bans
+type Bans struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+}
+
+// NewBans creates the banned addresses screen
+func NewBans(db *database.DB, colorScheme menu.ColorScheme) *Bans {
+	return &Bans{
+		db:          db,
+		colorScheme: colorScheme,
+	}
+}
+
+// Execute lists the bans in force until the sysop quits
+func (b *Bans) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		bans, err := b.db.GetIPBans(time.Now())
+		if err != nil {
+			showMessage(writer, keyReader, b.colorScheme, modules.T(writer, "Failed to load bans: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+
+		writer.Write([]byte(menu.ClearScreen))
+
+		header := b.colorScheme.Colorize(modules.T(writer, "--- Banned Addresses ---"), "primary")
+		writer.Write([]byte(b.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+		if len(bans) == 0 {
+			msg := b.colorScheme.Colorize(modules.T(writer, "No addresses are banned."), "secondary")
+			writer.Write([]byte(b.colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+		} else {
+			headerLine := fmt.Sprintf("%-3s %-24s %-20s %-12s %-12s", "#", "Address", "Reason", "Banned", "Expires")
+			writer.Write([]byte(b.colorScheme.CenterText(b.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+			separator := b.colorScheme.DrawSeparator(len(headerLine), "─")
+			writer.Write([]byte(b.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+			for i, ban := range bans {
+				line := fmt.Sprintf("%-3d %-24s %-20s %-12s %-12s", i+1,
+					ansi.Ellipsize(ban.IP, 24), ansi.Ellipsize(ban.Reason, 20),
+					ban.BannedAt.Local().Format("Jan 02 15:04"), ban.ExpiresAt.Local().Format("Jan 02 15:04"))
+				writer.Write([]byte(b.colorScheme.CenterText(b.colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+			}
+		}
+
+		writer.Write([]byte("\n"))
+		instructions := b.colorScheme.Colorize(modules.T(writer, "C: Clear a Ban  A: Clear All  Q: Quit"), "secondary")
+		writer.Write([]byte(b.colorScheme.CenterText(instructions, modules.Width(writer)) + "\n"))
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "c":
+			if len(bans) == 0 {
+				continue
+			}
+			writer.Write([]byte("\n" + b.colorScheme.Colorize(modules.T(writer, "Ban number to clear: "), "text")))
+			input, err := readLine(keyReader, writer)
+			if err != nil || strings.TrimSpace(input) == "" {
+				continue
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(input))
+			if err != nil || index < 1 || index > len(bans) {
+				showMessage(writer, keyReader, b.colorScheme, "Invalid ban number.", "error")
+				continue
+			}
+
+			ip := bans[index-1].IP
+			if err := b.db.UnbanIP(ip); err != nil {
+				showMessage(writer, keyReader, b.colorScheme, modules.ErrorText(writer, err), "error")
+				continue
+			}
+			showMessage(writer, keyReader, b.colorScheme, fmt.Sprintf(modules.T(writer, "Ban on %s cleared."), ip), "success")
+		case "a":
+			if len(bans) == 0 {
+				continue
+			}
+			if !dialog.Confirm(writer, keyReader, b.colorScheme, modules.T(writer, "Clear all bans?"), false) {
+				continue
+			}
+			n, err := b.db.UnbanAllIPs()
+			if err != nil {
+				showMessage(writer, keyReader, b.colorScheme, modules.ErrorText(writer, err), "error")
+				continue
+			}
+			showMessage(writer, keyReader, b.colorScheme, fmt.Sprintf(modules.T(writer, "%d ban(s) cleared."), n), "success")
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// readLine reads a line of input, echoing printable characters
+func readLine(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
+	return input.ReadLine(keyReader, writer, 0)
+}
+
+// showMessage displays a message and waits for a key
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(modules.T(writer, message), messageType), modules.Width(writer)) + "\n\n"))
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	writer.Write([]byte(colorScheme.CenterText(prompt, modules.Width(writer))))
+	keyReader.ReadKey()
+}
@@ -4,299 +4,387 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
-	"golang.org/x/term"
-
+	"bbs/internal/ansi"
 	"bbs/internal/database"
+	"bbs/internal/dialog"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
 )
 
-// ColorScheme interface to avoid import cycle
-type ColorScheme interface {
-	Colorize(text, colorName string) string
-	ColorizeWithBg(text, fgColor, bgColor string) string
-	CenterText(text string, terminalWidth int) string
-	DrawSeparator(width int, char string) string
-}
+// bulletinRows is how many bulletins the editor lists on a page
+const bulletinRows = 12
 
-// Screen control constants
-const (
-	ClearScreen      = "\033[2J\033[H"
-	ClearContentArea = "\033[H\033[J" // Home cursor and clear from cursor to end of scroll region
-	HideCursor       = "\033[?25l"
-	ShowCursor       = "\033[?25h"
-)
+// whenFormat is how publish and expiry times are shown; a date alone can be
+// typed for midnight at its start
+const whenFormat = "2006-01-02 15:04"
 
-// Module implements the sysop bulletin management functionality
+// BulletinEditor lets the sysop post, edit, schedule and delete bulletins
 type BulletinEditor struct {
 	db          *database.DB
-	colorScheme ColorScheme
+	colorScheme menu.ColorScheme
 }
 
 // NewBulletinEditor creates a new sysop bulletin editor
-func NewBulletinEditor(db *database.DB, colorScheme ColorScheme) *BulletinEditor {
+func NewBulletinEditor(db *database.DB, colorScheme menu.ColorScheme) *BulletinEditor {
 	return &BulletinEditor{
 		db:          db,
 		colorScheme: colorScheme,
 	}
 }
 
-// Execute runs the bulletin editor
-func (be *BulletinEditor) Execute(term *term.Terminal) bool {
+// Execute lists every bulletin, published or not, with when it goes up and
+// when it expires, until the sysop quits
+func (be *BulletinEditor) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	page := 0
 	for {
-		// Clear screen and show menu
-		term.Write([]byte(ClearContentArea + HideCursor))
-
-		// Header
-		header := be.colorScheme.Colorize("Sysop Bulletin Editor", "primary")
-		centeredHeader := be.colorScheme.CenterText(header, 79)
-		term.Write([]byte(centeredHeader + "\n"))
-
-		separator := be.colorScheme.DrawSeparator(len("Sysop Bulletin Editor"), "═")
-		centeredSeparator := be.colorScheme.CenterText(separator, 79)
-		term.Write([]byte(centeredSeparator + "\n\n"))
-
-		// Menu options
-		options := []string{
-			"1) List all bulletins",
-			"2) Create new bulletin",
-			"3) Edit existing bulletin",
-			"4) Delete bulletin",
-			"Q) Return to main menu",
+		bulletins, total, err := be.db.GetAllBulletins(bulletinRows, page*bulletinRows)
+		if err != nil {
+			showMessage(writer, keyReader, be.colorScheme, modules.T(writer, "Failed to load bulletins: ")+modules.ErrorText(writer, err), "error")
+			return true
 		}
-
-		for _, option := range options {
-			coloredOption := be.colorScheme.Colorize(option, "text")
-			centeredOption := be.colorScheme.CenterText(coloredOption, 79)
-			term.Write([]byte(centeredOption + "\n"))
+		// Past the last page, as after deleting its only bulletin
+		if len(bulletins) == 0 && page > 0 {
+			page--
+			continue
 		}
+		pages := (total + bulletinRows - 1) / bulletinRows
+		be.drawBulletins(writer, bulletins, page, pages)
 
-		// Prompt
-		prompt := be.colorScheme.Colorize("\nEnter your choice: ", "accent")
-		centeredPrompt := be.colorScheme.CenterText(prompt, 79)
-		term.Write([]byte(centeredPrompt))
-		term.Write([]byte(ShowCursor))
-
-		// Get input
-		input, err := term.ReadLine()
+		key, err := keyReader.ReadKey()
 		if err != nil {
-			return true
+			return false
 		}
 
-		term.Write([]byte(HideCursor))
-
-		switch strings.ToLower(strings.TrimSpace(input)) {
-		case "1":
-			be.ListBulletins(term)
-		case "2":
-			be.CreateBulletin(term)
-		case "3":
-			be.EditBulletin(term)
-		case "4":
-			be.DeleteBulletin(term)
-		case "q", "quit":
-			term.Write([]byte(ShowCursor))
+		switch strings.ToLower(key) {
+		case "c":
+			be.createBulletin(writer, keyReader)
+		case "e":
+			be.editBulletin(writer, keyReader)
+		case "s":
+			be.scheduleBulletin(writer, keyReader)
+		case "d":
+			be.deleteBulletin(writer, keyReader)
+		case "n", "right", "pagedown":
+			if page < pages-1 {
+				page++
+			}
+		case "p", "left", "pageup":
+			if page > 0 {
+				page--
+			}
+		case "home":
+			page = 0
+		case "end":
+			page = max(pages-1, 0)
+		case "q", "quit", "escape":
 			return true
-		default:
-			be.showMessage(term, "Invalid choice. Press any key to continue...", "error")
 		}
 	}
 }
 
-// ListBulletins displays a list of all bulletins
-func (be *BulletinEditor) ListBulletins(term *term.Terminal) {
-	bulletins, err := be.db.GetBulletins(50)
-	if err != nil {
-		be.showMessage(term, "Error retrieving bulletins: "+err.Error(), "error")
-		return
-	}
-
-	term.Write([]byte(ClearScreen))
-
-	header := be.colorScheme.Colorize("All Bulletins", "primary")
-	centeredHeader := be.colorScheme.CenterText(header, 79)
-	term.Write([]byte(centeredHeader + "\n"))
+// drawBulletins shows a page of bulletins and where each one stands
+func (be *BulletinEditor) drawBulletins(writer modules.Writer, bulletins []database.Bulletin, page, pages int) {
+	cs := be.colorScheme
+	writer.Write([]byte(menu.ClearScreen + menu.HideCursor))
 
-	separator := be.colorScheme.DrawSeparator(len("All Bulletins"), "═")
-	centeredSeparator := be.colorScheme.CenterText(separator, 79)
-	term.Write([]byte(centeredSeparator + "\n\n"))
+	header := cs.Colorize(modules.T(writer, "--- Bulletins ---"), "primary")
+	writer.Write([]byte(cs.CenterText(header, modules.Width(writer)) + "\n\n"))
 
 	if len(bulletins) == 0 {
-		msg := be.colorScheme.Colorize("No bulletins found.", "secondary")
-		centeredMsg := be.colorScheme.CenterText(msg, 79)
-		term.Write([]byte(centeredMsg + "\n"))
+		msg := cs.Colorize(modules.T(writer, "No bulletins have been posted."), "secondary")
+		writer.Write([]byte(cs.CenterText(msg, modules.Width(writer)) + "\n"))
 	} else {
-		for _, bulletin := range bulletins {
-			line := fmt.Sprintf("ID: %d | %s | by %s | %s",
-				bulletin.ID,
-				bulletin.Title,
-				bulletin.Author,
-				bulletin.CreatedAt.Format("2006-01-02"))
-
-			coloredLine := be.colorScheme.Colorize(line, "text")
-			centeredLine := be.colorScheme.CenterText(coloredLine, 79)
-			term.Write([]byte(centeredLine + "\n"))
+		headerLine := fmt.Sprintf("%-5s %-26s %-16s %-16s %-9s", "ID", "Title", "Published", "Expires", "Status")
+		writer.Write([]byte(cs.CenterText(cs.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+		separator := cs.DrawSeparator(len(headerLine), "─")
+		writer.Write([]byte(cs.CenterText(separator, modules.Width(writer)) + "\n"))
+
+		now := time.Now()
+		for _, b := range bulletins {
+			published := b.CreatedAt
+			if b.PublishAt != nil {
+				published = *b.PublishAt
+			}
+			expires := "Never"
+			if b.ExpiresAt != nil {
+				expires = b.ExpiresAt.Format(whenFormat)
+			}
+			status, color := bulletinStatus(&b, now)
+			line := fmt.Sprintf("%-5d %-26s %-16s %-16s %-9s", b.ID, ansi.Ellipsize(b.Title, 26),
+				published.Format(whenFormat), expires, status)
+			writer.Write([]byte(cs.CenterText(cs.Colorize(line, color), modules.Width(writer)) + "\n"))
+		}
+		if pages > 1 {
+			status := fmt.Sprintf(modules.T(writer, "Page %d of %d"), page+1, pages)
+			writer.Write([]byte("\n" + cs.CenterText(cs.Colorize(status, "secondary"), modules.Width(writer)) + "\n"))
 		}
 	}
 
-	be.showMessage(term, "\nPress any key to continue...", "text")
+	writer.Write([]byte("\n"))
+	instructions := cs.Colorize(modules.T(writer, "C: Create  E: Edit  S: Schedule  D: Delete  N/P: Page  Q: Quit"), "secondary")
+	writer.Write([]byte(cs.CenterText(instructions, modules.Width(writer)) + "\n"))
+}
+
+// bulletinStatus names where a bulletin stands at now, and the color to
+// list it in
+func bulletinStatus(b *database.Bulletin, now time.Time) (string, string) {
+	switch {
+	case b.Archived:
+		return "Archived", "secondary"
+	case b.PublishAt != nil && b.PublishAt.After(now):
+		return "Scheduled", "accent"
+	case b.ExpiresAt != nil && !b.ExpiresAt.After(now):
+		return "Expired", "error"
+	}
+	return "Live", "text"
 }
 
-// CreateBulletin creates a new bulletin
-func (be *BulletinEditor) CreateBulletin(term *term.Terminal) {
-	term.Write([]byte(ClearScreen + ShowCursor))
+// createBulletin asks for a new bulletin's title, body, and when it is
+// published and expires
+func (be *BulletinEditor) createBulletin(writer modules.Writer, keyReader modules.KeyReader) {
+	cs := be.colorScheme
+	writer.Write([]byte(menu.ClearScreen + menu.ShowCursor))
+	defer writer.Write([]byte(menu.HideCursor))
 
-	// Get title
-	term.Write([]byte(be.colorScheme.Colorize("Create New Bulletin\n\n", "primary")))
-	term.Write([]byte(be.colorScheme.Colorize("Enter bulletin title: ", "text")))
-	title, err := term.ReadLine()
+	writer.Write([]byte(cs.Colorize(modules.T(writer, "Create New Bulletin\n\n"), "primary")))
+	writer.Write([]byte(cs.Colorize(modules.T(writer, "Title: "), "text")))
+	title, err := readLine(keyReader, writer)
 	if err != nil {
 		return
 	}
-
 	if strings.TrimSpace(title) == "" {
-		be.showMessage(term, "Title cannot be empty.", "error")
+		showMessage(writer, keyReader, cs, "Title cannot be empty.", "error")
 		return
 	}
 
-	// Get body (simple single-line for now)
-	term.Write([]byte(be.colorScheme.Colorize("Enter bulletin body: ", "text")))
-	body, err := term.ReadLine()
+	writer.Write([]byte(cs.Colorize(modules.T(writer, "Body: "), "text")))
+	body, err := readLine(keyReader, writer)
 	if err != nil {
 		return
 	}
-
 	if strings.TrimSpace(body) == "" {
-		be.showMessage(term, "Body cannot be empty.", "error")
+		showMessage(writer, keyReader, cs, "Body cannot be empty.", "error")
 		return
 	}
 
-	// Create bulletin
-	bulletin := &database.Bulletin{
-		Title:  strings.TrimSpace(title),
-		Body:   strings.TrimSpace(body),
-		Author: "Sysop",
-	}
-
-	err = be.db.CreateBulletin(bulletin)
-	if err != nil {
-		be.showMessage(term, "Error creating bulletin: "+err.Error(), "error")
-	} else {
-		be.showMessage(term, "Bulletin created successfully!", "success")
-	}
-}
-
-// EditBulletin edits an existing bulletin
-func (be *BulletinEditor) EditBulletin(term *term.Terminal) {
-	term.Write([]byte(ClearScreen + ShowCursor))
-
-	term.Write([]byte(be.colorScheme.Colorize("Edit Bulletin\n\n", "primary")))
-	term.Write([]byte(be.colorScheme.Colorize("Enter bulletin ID to edit: ", "text")))
-
-	idStr, err := term.ReadLine()
-	if err != nil {
+	publishAt, expiresAt, ok := be.readSchedule(writer, keyReader, nil, nil, "Enter for now", "Enter for never")
+	if !ok {
 		return
 	}
 
-	id, err := strconv.Atoi(strings.TrimSpace(idStr))
-	if err != nil {
-		be.showMessage(term, "Invalid ID format.", "error")
+	bulletin := &database.Bulletin{
+		Title:     strings.TrimSpace(title),
+		Body:      strings.TrimSpace(body),
+		Author:    "Sysop",
+		PublishAt: publishAt,
+		ExpiresAt: expiresAt,
+	}
+	if err := be.db.CreateBulletin(bulletin); err != nil {
+		showMessage(writer, keyReader, cs, modules.T(writer, "Error creating bulletin: ")+modules.ErrorText(writer, err), "error")
 		return
 	}
+	showMessage(writer, keyReader, cs, modules.T(writer, "Bulletin created. ")+describeSchedule(time.Now(), publishAt, expiresAt), "success")
+}
 
-	// Get existing bulletin
-	bulletin, err := be.db.GetBulletinByID(id)
-	if err != nil {
-		be.showMessage(term, "Bulletin not found.", "error")
+// editBulletin changes a bulletin's title and body
+func (be *BulletinEditor) editBulletin(writer modules.Writer, keyReader modules.KeyReader) {
+	cs := be.colorScheme
+	bulletin, ok := be.readBulletin(writer, keyReader, "Edit Bulletin")
+	if !ok {
 		return
 	}
+	defer writer.Write([]byte(menu.HideCursor))
 
-	// Show current values and get new ones
-	term.Write([]byte(be.colorScheme.Colorize(fmt.Sprintf("Current title: %s\n", bulletin.Title), "secondary")))
-	term.Write([]byte(be.colorScheme.Colorize("New title (or press Enter to keep current): ", "text")))
-	newTitle, err := term.ReadLine()
+	writer.Write([]byte(cs.Colorize(fmt.Sprintf(modules.T(writer, "Current title: %s\n"), bulletin.Title), "secondary")))
+	writer.Write([]byte(cs.Colorize(modules.T(writer, "New title (or press Enter to keep current): "), "text")))
+	newTitle, err := readLine(keyReader, writer)
 	if err != nil {
 		return
 	}
-
 	if strings.TrimSpace(newTitle) == "" {
 		newTitle = bulletin.Title
 	}
 
-	term.Write([]byte(be.colorScheme.Colorize(fmt.Sprintf("Current body: %s\n", bulletin.Body), "secondary")))
-	term.Write([]byte(be.colorScheme.Colorize("New body (or press Enter to keep current): ", "text")))
-	newBody, err := term.ReadLine()
+	writer.Write([]byte(cs.Colorize(fmt.Sprintf(modules.T(writer, "Current body: %s\n"), bulletin.Body), "secondary")))
+	writer.Write([]byte(cs.Colorize(modules.T(writer, "New body (or press Enter to keep current): "), "text")))
+	newBody, err := readLine(keyReader, writer)
 	if err != nil {
 		return
 	}
-
 	if strings.TrimSpace(newBody) == "" {
 		newBody = bulletin.Body
 	}
 
-	// Update bulletin
-	err = be.db.UpdateBulletin(id, strings.TrimSpace(newTitle), strings.TrimSpace(newBody))
-	if err != nil {
-		be.showMessage(term, "Error updating bulletin: "+err.Error(), "error")
-	} else {
-		be.showMessage(term, "Bulletin updated successfully!", "success")
+	if err := be.db.UpdateBulletin(bulletin.ID, strings.TrimSpace(newTitle), strings.TrimSpace(newBody)); err != nil {
+		showMessage(writer, keyReader, cs, modules.T(writer, "Error updating bulletin: ")+modules.ErrorText(writer, err), "error")
+		return
 	}
+	showMessage(writer, keyReader, cs, "Bulletin updated successfully!", "success")
 }
 
-// DeleteBulletin deletes a bulletin
-func (be *BulletinEditor) DeleteBulletin(term *term.Terminal) {
-	term.Write([]byte(ClearScreen + ShowCursor))
+// scheduleBulletin changes when a bulletin is published and when it
+// expires. Giving an archived bulletin a new expiry brings it back.
+func (be *BulletinEditor) scheduleBulletin(writer modules.Writer, keyReader modules.KeyReader) {
+	cs := be.colorScheme
+	bulletin, ok := be.readBulletin(writer, keyReader, "Schedule Bulletin")
+	if !ok {
+		return
+	}
+	defer writer.Write([]byte(menu.HideCursor))
 
-	term.Write([]byte(be.colorScheme.Colorize("Delete Bulletin\n\n", "primary")))
-	term.Write([]byte(be.colorScheme.Colorize("Enter bulletin ID to delete: ", "text")))
+	current := fmt.Sprintf("%s: %s\n", bulletin.Title, describeSchedule(bulletin.CreatedAt, bulletin.PublishAt, bulletin.ExpiresAt))
+	writer.Write([]byte(cs.Colorize(current, "secondary")))
+	publishAt, expiresAt, ok := be.readSchedule(writer, keyReader, bulletin.PublishAt, bulletin.ExpiresAt,
+		"now; Enter keeps current", "never; Enter keeps current")
+	if !ok {
+		return
+	}
 
-	idStr, err := term.ReadLine()
-	if err != nil {
+	if err := be.db.ScheduleBulletin(bulletin.ID, publishAt, expiresAt); err != nil {
+		showMessage(writer, keyReader, cs, modules.T(writer, "Error scheduling bulletin: ")+modules.ErrorText(writer, err), "error")
 		return
 	}
+	showMessage(writer, keyReader, cs, bulletin.Title+": "+describeSchedule(bulletin.CreatedAt, publishAt, expiresAt), "success")
+}
 
-	id, err := strconv.Atoi(strings.TrimSpace(idStr))
-	if err != nil {
-		be.showMessage(term, "Invalid ID format.", "error")
+// deleteBulletin deletes a bulletin after asking the sysop to confirm
+func (be *BulletinEditor) deleteBulletin(writer modules.Writer, keyReader modules.KeyReader) {
+	cs := be.colorScheme
+	bulletin, ok := be.readBulletin(writer, keyReader, "Delete Bulletin")
+	if !ok {
+		return
+	}
+	defer writer.Write([]byte(menu.HideCursor))
+
+	question := fmt.Sprintf(modules.T(writer, "Delete bulletin: %s\nAre you sure?"), bulletin.Title)
+	if !dialog.Confirm(writer, keyReader, cs, question, false) {
+		showMessage(writer, keyReader, cs, "Deletion cancelled.", "text")
 		return
 	}
 
-	// Get bulletin to show what will be deleted
+	if err := be.db.DeleteBulletin(bulletin.ID); err != nil {
+		showMessage(writer, keyReader, cs, modules.T(writer, "Error deleting bulletin: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	showMessage(writer, keyReader, cs, "Bulletin deleted successfully!", "success")
+}
+
+// readBulletin clears the screen under title and asks for a bulletin ID,
+// leaving the cursor showing for the prompts that follow
+func (be *BulletinEditor) readBulletin(writer modules.Writer, keyReader modules.KeyReader, title string) (*database.Bulletin, bool) {
+	cs := be.colorScheme
+	writer.Write([]byte(menu.ClearScreen + menu.ShowCursor))
+	writer.Write([]byte(cs.Colorize(title+"\n\n", "primary")))
+	writer.Write([]byte(cs.Colorize(modules.T(writer, "Bulletin ID: "), "text")))
+
+	input, err := readLine(keyReader, writer)
+	if err != nil || strings.TrimSpace(input) == "" {
+		writer.Write([]byte(menu.HideCursor))
+		return nil, false
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil {
+		showMessage(writer, keyReader, cs, "Invalid ID format.", "error")
+		return nil, false
+	}
 	bulletin, err := be.db.GetBulletinByID(id)
 	if err != nil {
-		be.showMessage(term, "Bulletin not found.", "error")
-		return
+		showMessage(writer, keyReader, cs, "Bulletin not found.", "error")
+		return nil, false
+	}
+	return bulletin, true
+}
+
+// readSchedule asks when a bulletin is published and when it expires.
+// Enter keeps publishAt and expiresAt; the hints say what that means.
+func (be *BulletinEditor) readSchedule(writer modules.Writer, keyReader modules.KeyReader,
+	publishAt, expiresAt *time.Time, publishHint, expiryHint string) (*time.Time, *time.Time, bool) {
+	cs := be.colorScheme
+
+	prompt := fmt.Sprintf(modules.T(writer, "Publish at (YYYY-MM-DD [HH:MM], %s): "), publishHint)
+	publishAt, ok := be.readWhen(writer, keyReader, prompt, publishAt, "now", time.Now())
+	if !ok {
+		return nil, nil, false
 	}
 
-	// Confirm deletion
-	term.Write([]byte(be.colorScheme.Colorize(fmt.Sprintf("Delete bulletin: %s\n", bulletin.Title), "secondary")))
-	term.Write([]byte(be.colorScheme.Colorize("Are you sure? (y/N): ", "text")))
+	from := time.Now()
+	if publishAt != nil && publishAt.After(from) {
+		from = *publishAt
+	}
+	prompt = fmt.Sprintf(modules.T(writer, "Expire at (YYYY-MM-DD [HH:MM], +days, %s): "), expiryHint)
+	expiresAt, ok = be.readWhen(writer, keyReader, prompt, expiresAt, "never", from)
+	if !ok {
+		return nil, nil, false
+	}
+	if expiresAt != nil && !expiresAt.After(from) {
+		showMessage(writer, keyReader, cs, "A bulletin has to expire after it is published.", "error")
+		return nil, nil, false
+	}
+	return publishAt, expiresAt, true
+}
 
-	confirm, err := term.ReadLine()
+// readWhen asks for a time: a date, a date and time, or +days counted from
+// from. Enter gives def and word gives nil.
+func (be *BulletinEditor) readWhen(writer modules.Writer, keyReader modules.KeyReader,
+	prompt string, def *time.Time, word string, from time.Time) (*time.Time, bool) {
+	writer.Write([]byte(be.colorScheme.Colorize(prompt, "text")))
+	input, err := readLine(keyReader, writer)
 	if err != nil {
-		return
+		return nil, false
+	}
+	input = strings.TrimSpace(input)
+	switch {
+	case input == "":
+		return def, true
+	case strings.EqualFold(input, word):
+		return nil, true
+	}
+	if when, ok := parseWhen(input, from); ok {
+		return &when, true
 	}
+	showMessage(writer, keyReader, be.colorScheme,
+		fmt.Sprintf("Enter a date such as 2026-12-31, a time such as 2026-12-31 18:00, +days or %s.", word), "error")
+	return nil, false
+}
 
-	if strings.ToLower(strings.TrimSpace(confirm)) == "y" {
-		err = be.db.DeleteBulletin(id)
-		if err != nil {
-			be.showMessage(term, "Error deleting bulletin: "+err.Error(), "error")
-		} else {
-			be.showMessage(term, "Bulletin deleted successfully!", "success")
+// parseWhen reads a date, which means midnight at its start, a date and a
+// time, or a number of days after from written as +days
+func parseWhen(input string, from time.Time) (time.Time, bool) {
+	if strings.HasPrefix(input, "+") {
+		days, err := strconv.Atoi(input[1:])
+		if err != nil || days < 1 {
+			return time.Time{}, false
+		}
+		return from.AddDate(0, 0, days), true
+	}
+	for _, layout := range []string{whenFormat, "2006-01-02"} {
+		if when, err := time.ParseInLocation(layout, input, time.Local); err == nil {
+			return when, true
 		}
-	} else {
-		be.showMessage(term, "Deletion cancelled.", "text")
 	}
+	return time.Time{}, false
 }
 
-// showMessage displays a message and waits for key press
-func (be *BulletinEditor) showMessage(term *term.Terminal, message, colorType string) {
-	term.Write([]byte(HideCursor))
-
-	coloredMsg := be.colorScheme.Colorize(message, colorType)
-	centeredMsg := be.colorScheme.CenterText(coloredMsg, 79)
-	term.Write([]byte("\n" + centeredMsg))
+// describeSchedule says when a bulletin posted at posted is published and
+// when it expires
+func describeSchedule(posted time.Time, publishAt, expiresAt *time.Time) string {
+	if publishAt != nil {
+		posted = *publishAt
+	}
+	published := "Published " + posted.Format(whenFormat)
+	if posted.After(time.Now()) {
+		published = "Goes up " + posted.Format(whenFormat)
+	}
+	if expiresAt == nil {
+		return published + ", never expires."
+	}
+	return published + ", expires " + expiresAt.Format(whenFormat) + "."
+}
 
-	term.Write([]byte(ShowCursor))
-	term.ReadLine()
-	term.Write([]byte(HideCursor))
+// readLine reads a line of input, echoing printable characters
+func readLine(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
+	return input.ReadLine(keyReader, writer, 0)
 }
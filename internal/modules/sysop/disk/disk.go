@@ -0,0 +1,313 @@
+package disk
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/dialog"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/paths"
+)
+
+// staleUpload is how long an unfinished upload is left before it counts as
+// abandoned. Uploads are written to hidden ".upload-" files until they finish.
+const staleUpload = 24 * time.Hour
+
+// Dashboard shows the sysop how much space the board takes on disk, warns
+// when it passes the configured sizes, and offers the cleanups
+type Dashboard struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	layout      paths.Layout
+	limits      config.DiskConfig
+	prune       func()
+}
+
+// usage is one line of the dashboard
+type usage struct {
+	name   string
+	bytes  int64
+	limit  int64  // Warning size, 0 for none
+	over   string // Start of the warning, as in "Logs are"
+	indent bool   // A file area under the total
+}
+
+// report is what the dashboard measured
+type report struct {
+	rows     []usage
+	free     int64 // -1 when the volume can't be measured
+	stale    []string
+	staleLen int64
+	warnings []string
+}
+
+// NewDashboard creates the disk usage screen. prune runs the nightly
+// retention job straight away.
+func NewDashboard(db *database.DB, colorScheme menu.ColorScheme, cfg *config.Config, prune func()) *Dashboard {
+	return &Dashboard{
+		db:          db,
+		colorScheme: colorScheme,
+		layout:      cfg.Paths,
+		limits:      cfg.BBS.Disk,
+		prune:       prune,
+	}
+}
+
+// Execute shows the usage until the sysop quits, measuring again after
+// each cleanup
+func (d *Dashboard) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		r := d.measure(time.Now())
+		d.render(writer, r)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "p":
+			if d.denyIfReadOnly(writer, keyReader) || !d.confirm(writer, keyReader, "Prune old records and delete closed accounts that are due now?") {
+				continue
+			}
+			d.prune()
+			showMessage(writer, keyReader, d.colorScheme, "Old records pruned. The server log lists what was removed.", "success")
+		case "c":
+			if d.denyIfReadOnly(writer, keyReader) {
+				continue
+			}
+			writer.Write([]byte("\n" + d.colorScheme.Colorize(modules.T(writer, "Compacting the database..."), "text")))
+			before, _ := d.db.Size()
+			if err := d.db.Compact(); err != nil {
+				showMessage(writer, keyReader, d.colorScheme, modules.ErrorText(writer, err), "error")
+				continue
+			}
+			after, _ := d.db.Size()
+			showMessage(writer, keyReader, d.colorScheme,
+				fmt.Sprintf("Database compacted from %s to %s.", formatSize(before), formatSize(after)), "success")
+		case "u":
+			if len(r.stale) == 0 {
+				continue
+			}
+			if !d.confirm(writer, keyReader, fmt.Sprintf("Remove %d unfinished upload(s)?", len(r.stale))) {
+				continue
+			}
+			removed := 0
+			for _, path := range r.stale {
+				if err := os.Remove(path); err == nil {
+					removed++
+				}
+			}
+			showMessage(writer, keyReader, d.colorScheme, fmt.Sprintf(modules.T(writer, "%d unfinished upload(s) removed."), removed), "success")
+		case "l":
+			if !d.confirm(writer, keyReader, "Empty the log files?") {
+				continue
+			}
+			emptied, err := emptyLogs(d.layout.Logs)
+			if err != nil {
+				showMessage(writer, keyReader, d.colorScheme, modules.ErrorText(writer, err), "error")
+				continue
+			}
+			showMessage(writer, keyReader, d.colorScheme, fmt.Sprintf(modules.T(writer, "%d log file(s) emptied."), emptied), "success")
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// measure sizes up the file areas, logs and database and checks them
+// against the configured limits
+func (d *Dashboard) measure(now time.Time) report {
+	r := report{free: -1}
+
+	var areas []usage
+	var filesTotal int64
+	entries, _ := os.ReadDir(d.layout.Files)
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(d.layout.Files, entry.Name())
+		size := dirSize(dir)
+		areas = append(areas, usage{name: entry.Name(), bytes: size, indent: true})
+		filesTotal += size
+
+		uploads, _ := filepath.Glob(filepath.Join(dir, ".upload-*"))
+		for _, path := range uploads {
+			if info, err := os.Stat(path); err == nil && now.Sub(info.ModTime()) > staleUpload {
+				r.stale = append(r.stale, path)
+				r.staleLen += info.Size()
+			}
+		}
+	}
+	r.rows = append(r.rows, usage{name: "File areas", bytes: filesTotal, limit: megabytes(d.limits.FilesMB), over: "File areas are"})
+	r.rows = append(r.rows, areas...)
+	r.rows = append(r.rows, usage{name: "Logs", bytes: dirSize(d.layout.Logs), limit: megabytes(d.limits.LogsMB), over: "Logs are"})
+
+	dbSize, err := d.db.Size()
+	if err != nil {
+		r.warnings = append(r.warnings, err.Error())
+	}
+	r.rows = append(r.rows, usage{name: "Database", bytes: dbSize, limit: megabytes(d.limits.DatabaseMB), over: "The database is"})
+
+	for _, row := range r.rows {
+		if row.limit > 0 && row.bytes > row.limit {
+			r.warnings = append(r.warnings, fmt.Sprintf("%s over %s.", row.over, formatSize(row.limit)))
+		}
+	}
+
+	if free, err := freeSpace(d.layout.Root); err == nil {
+		r.free = free
+		if min := megabytes(d.limits.MinFreeMB); min > 0 && free < min {
+			r.warnings = append(r.warnings, fmt.Sprintf("Only %s is free, under the %s minimum.", formatSize(free), formatSize(min)))
+		}
+	}
+	return r
+}
+
+// render draws the dashboard
+func (d *Dashboard) render(writer modules.Writer, r report) {
+	writer.Write([]byte(menu.ClearScreen))
+
+	header := d.colorScheme.Colorize(modules.T(writer, "--- Disk Usage ---"), "primary")
+	writer.Write([]byte(d.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	headerLine := fmt.Sprintf("%-2s%-28s %10s %10s", "", "Area", "Size", "Warn At")
+	writer.Write([]byte(d.colorScheme.CenterText(d.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+	separator := d.colorScheme.DrawSeparator(len(headerLine), "─")
+	writer.Write([]byte(d.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+	for _, row := range r.rows {
+		name := row.name
+		if row.indent {
+			name = "  " + name
+		}
+		limit, marker, style := "", "", "text"
+		if row.limit > 0 {
+			limit = formatSize(row.limit)
+			if row.bytes > row.limit {
+				marker, style = "!", "error"
+			}
+		}
+		line := fmt.Sprintf("%-2s%-28s %10s %10s", marker, ansi.Ellipsize(name, 28), formatSize(row.bytes), limit)
+		writer.Write([]byte(d.colorScheme.CenterText(d.colorScheme.Colorize(line, style), modules.Width(writer)) + "\n"))
+	}
+
+	if r.free >= 0 {
+		limit, marker, style := "", "", "text"
+		if min := megabytes(d.limits.MinFreeMB); min > 0 {
+			limit = formatSize(min)
+			if r.free < min {
+				marker, style = "!", "error"
+			}
+		}
+		line := fmt.Sprintf("%-2s%-28s %10s %10s", marker, "Free space", formatSize(r.free), limit)
+		writer.Write([]byte(d.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+		writer.Write([]byte(d.colorScheme.CenterText(d.colorScheme.Colorize(line, style), modules.Width(writer)) + "\n"))
+	}
+
+	writer.Write([]byte("\n"))
+	if len(r.stale) > 0 {
+		note := fmt.Sprintf(modules.T(writer, "%d unfinished upload(s) taking %s."), len(r.stale), formatSize(r.staleLen))
+		writer.Write([]byte(d.colorScheme.CenterText(d.colorScheme.Colorize(note, "secondary"), modules.Width(writer)) + "\n"))
+	}
+	for _, warning := range r.warnings {
+		writer.Write([]byte(d.colorScheme.CenterText(d.colorScheme.Colorize(warning, "error"), modules.Width(writer)) + "\n"))
+	}
+	if len(r.stale) > 0 || len(r.warnings) > 0 {
+		writer.Write([]byte("\n"))
+	}
+
+	instructions := d.colorScheme.Colorize(modules.T(writer, "P: Prune  C: Compact  U: Uploads  L: Empty Logs  Q: Quit"), "secondary")
+	writer.Write([]byte(d.colorScheme.CenterText(instructions, modules.Width(writer)) + "\n"))
+}
+
+// confirm asks a yes or no question, defaulting to no
+func (d *Dashboard) confirm(writer modules.Writer, keyReader modules.KeyReader, question string) bool {
+	return dialog.Confirm(writer, keyReader, d.colorScheme, question, false)
+}
+
+// denyIfReadOnly shows a notice and returns true when the board is a read-only mirror
+func (d *Dashboard) denyIfReadOnly(writer modules.Writer, keyReader modules.KeyReader) bool {
+	if !d.db.ReadOnly() {
+		return false
+	}
+	showMessage(writer, keyReader, d.colorScheme, "This board is a read-only mirror. The database can't be changed.", "error")
+	return true
+}
+
+// dirSize adds up the sizes of the regular files under dir
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// emptyLogs truncates each file in the logs directory. The server keeps
+// its log open for appending, so it carries on writing from the start.
+func emptyLogs(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read logs: %w", err)
+	}
+	emptied := 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if err := os.Truncate(filepath.Join(dir, entry.Name()), 0); err != nil {
+			return emptied, fmt.Errorf("failed to empty %s: %w", entry.Name(), err)
+		}
+		emptied++
+	}
+	return emptied, nil
+}
+
+// megabytes converts a size from the config to bytes
+func megabytes(mb int) int64 {
+	return int64(mb) * 1024 * 1024
+}
+
+// formatSize formats a byte count for display
+func formatSize(bytes int64) string {
+	switch {
+	case bytes >= 1024*1024*1024:
+		return fmt.Sprintf("%.1fG", float64(bytes)/(1024*1024*1024))
+	case bytes >= 1024*1024:
+		return fmt.Sprintf("%.1fM", float64(bytes)/(1024*1024))
+	case bytes >= 1024:
+		return fmt.Sprintf("%.1fK", float64(bytes)/1024)
+	default:
+		return fmt.Sprintf("%dB", bytes)
+	}
+}
+
+// showMessage displays a message and waits for a key
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(modules.T(writer, message), messageType), modules.Width(writer)) + "\n\n"))
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	writer.Write([]byte(colorScheme.CenterText(prompt, modules.Width(writer))))
+	keyReader.ReadKey()
+}
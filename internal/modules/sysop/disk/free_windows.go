@@ -0,0 +1,18 @@
+//go:build windows
+
+package disk
+
+import "golang.org/x/sys/windows"
+
+// freeSpace returns the bytes available to the BBS on the volume holding path
+func freeSpace(path string) (int64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &available, &total, &free); err != nil {
+		return 0, err
+	}
+	return int64(available), nil
+}
@@ -49,25 +49,25 @@ func handleToggleUserStatus(writer modules.Writer, keyReader modules.KeyReader,
 func handleSystemStats(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
 	writer.Write([]byte(menu.ClearScreen))
 
-	header := colorScheme.Colorize("--- System Statistics ---", "primary")
-	centeredHeader := colorScheme.CenterText(header, 79)
+	header := colorScheme.Colorize(modules.T(writer, "--- System Statistics ---"), "primary")
+	centeredHeader := colorScheme.CenterText(header, modules.Width(writer))
 	writer.Write([]byte(centeredHeader + "\n"))
 
 	separator := colorScheme.DrawSeparator(len("System Statistics"), "═")
-	centeredSeparator := colorScheme.CenterText(separator, 79)
+	centeredSeparator := colorScheme.CenterText(separator, modules.Width(writer))
 	writer.Write([]byte(centeredSeparator + "\n\n"))
 
 	// Get users count
 	users, err := db.GetAllUsers(1000)
 	if err != nil {
-		showMessage(writer, keyReader, colorScheme, "Error retrieving user statistics: "+err.Error(), "error")
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Error retrieving user statistics: ")+modules.ErrorText(writer, err), "error")
 		return true
 	}
 
 	// Get bulletins count
 	bulletins, err := db.GetBulletins(1000)
 	if err != nil {
-		showMessage(writer, keyReader, colorScheme, "Error retrieving bulletin statistics: "+err.Error(), "error")
+		showMessage(writer, keyReader, colorScheme, modules.T(writer, "Error retrieving bulletin statistics: ")+modules.ErrorText(writer, err), "error")
 		return true
 	}
 
@@ -92,35 +92,37 @@ func handleSystemStats(writer modules.Writer, keyReader modules.KeyReader, db *d
 
 	for _, stat := range stats {
 		coloredStat := colorScheme.Colorize(stat, "text")
-		centeredStat := colorScheme.CenterText(coloredStat, 79)
+		centeredStat := colorScheme.CenterText(coloredStat, modules.Width(writer))
 		writer.Write([]byte(centeredStat + "\n"))
 	}
 
 	writer.Write([]byte("\n"))
-	prompt := colorScheme.Colorize("Press any key to continue...", "text")
-	centeredPrompt := colorScheme.CenterText(prompt, 79)
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
 	writer.Write([]byte(centeredPrompt))
 
 	keyReader.ReadKey()
 	return true
 }
 
-// handleBulletinManagement manages bulletins (placeholder for now)
+// handleBulletinManagement posts, schedules and removes bulletins
 func handleBulletinManagement(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
-	showMessage(writer, keyReader, colorScheme, "Bulletin Management - Not yet implemented", "secondary")
-	return true
+	return NewBulletinEditor(db, colorScheme).Execute(writer, keyReader)
 }
 
 // Helper function for showing messages
 func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
 	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
 
-	coloredMessage := colorScheme.Colorize(message, messageType)
-	centeredMessage := colorScheme.CenterText(coloredMessage, 79)
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
 	writer.Write([]byte(centeredMessage + "\n\n"))
 
-	prompt := colorScheme.Colorize("Press any key to continue...", "text")
-	centeredPrompt := colorScheme.CenterText(prompt, 79)
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
 	writer.Write([]byte(centeredPrompt))
 
 	keyReader.ReadKey()
@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/scheduler"
+)
+
+// Runner lists the scheduled jobs and runs one on demand
+type Runner interface {
+	Status() []scheduler.Status
+	RunNow(name string) (scheduler.Result, error)
+}
+
+// Jobs shows the sysop when each maintenance job runs next and how it went
+// last time, and runs one straight away
+type Jobs struct {
+	colorScheme menu.ColorScheme
+	runner      Runner
+	selected    int
+}
+
+// NewJobs creates the scheduled jobs screen
+func NewJobs(colorScheme menu.ColorScheme, runner Runner) *Jobs {
+	return &Jobs{
+		colorScheme: colorScheme,
+		runner:      runner,
+	}
+}
+
+// Execute shows the jobs, read again each time a key is pressed, until the
+// sysop quits
+func (j *Jobs) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		jobs := j.runner.Status()
+		j.selected = max(min(j.selected, len(jobs)-1), 0)
+		j.display(writer, jobs, time.Now())
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "up":
+			if j.selected > 0 {
+				j.selected--
+			}
+		case "down":
+			if j.selected < len(jobs)-1 {
+				j.selected++
+			}
+		case "pageup", "pagedown", "home", "end":
+			j.selected = menu.Jump(key, j.selected, len(jobs), menu.ListRows(writer))
+		case "r":
+			if len(jobs) > 0 {
+				j.run(writer, keyReader, jobs[j.selected].Name)
+			}
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// display draws the table of jobs with the selected one's last result below
+func (j *Jobs) display(writer modules.Writer, jobs []scheduler.Status, now time.Time) {
+	cs := j.colorScheme
+	writer.Write([]byte(menu.ClearScreen + menu.HideCursor))
+	header := cs.Colorize(modules.T(writer, "--- Scheduled Jobs ---"), "primary")
+	writer.Write([]byte(cs.CenterText(header, modules.Width(writer)) + "\n"))
+	clock := fmt.Sprintf("Server time %s. Jobs are listed under bbs.jobs.", now.Format("Jan 02 15:04"))
+	writer.Write([]byte(cs.CenterText(cs.Colorize(clock, "secondary"), modules.Width(writer)) + "\n\n"))
+
+	if len(jobs) == 0 {
+		msg := cs.Colorize(modules.T(writer, "No jobs are scheduled."), "secondary")
+		writer.Write([]byte(cs.CenterText(msg, modules.Width(writer)) + "\n\n"))
+		writer.Write([]byte(cs.CenterText(cs.Colorize(modules.T(writer, "Q: Quit"), "secondary"), modules.Width(writer))))
+		return
+	}
+
+	headerLine := fmt.Sprintf("%-20s %-12s %-12s %-12s %-10s", "Job", "Schedule", "Next Run", "Last Run", "Result")
+	writer.Write([]byte(cs.CenterText(cs.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+	separator := cs.DrawSeparator(len(headerLine), "─")
+	writer.Write([]byte(cs.CenterText(separator, modules.Width(writer)) + "\n"))
+
+	for i, job := range jobs {
+		lastRun, result := "Never", ""
+		if job.Last != nil {
+			lastRun = job.Last.At.Format("Jan 02 15:04")
+			result = "OK"
+			if job.Last.Err != nil {
+				result = "Failed"
+			}
+		}
+		if job.Running {
+			result = "Running"
+		}
+		line := fmt.Sprintf("%-20s %-12s %-12s %-12s %-10s", ansi.Ellipsize(job.Name, 20), ansi.Ellipsize(job.Schedule, 12),
+			job.Next.Format("Jan 02 15:04"), lastRun, result)
+		writer.Write([]byte(cs.CenterText(cs.HighlightSelection(line, i == j.selected, len(line)+2), modules.Width(writer)) + "\n"))
+	}
+
+	writer.Write([]byte("\n"))
+	if last := jobs[j.selected].Last; last != nil {
+		style, detail := "text", last.Summary
+		if last.Err != nil {
+			style, detail = "error", last.Err.Error()
+		}
+		if detail == "" {
+			detail = "done"
+		}
+		took := fmt.Sprintf("Last run took %s:", last.Took.Round(time.Millisecond))
+		writer.Write([]byte(cs.CenterText(cs.Colorize(took, "secondary"), modules.Width(writer)) + "\n"))
+		writer.Write([]byte(cs.CenterText(cs.Colorize(ansi.Ellipsize(detail, 77), style), modules.Width(writer)) + "\n\n"))
+	}
+	instructions := cs.Colorize(modules.T(writer, "↑↓: Select  R: Run Now  Q: Quit"), "secondary")
+	writer.Write([]byte(cs.CenterText(instructions, modules.Width(writer))))
+}
+
+// run runs a job straight away and shows how it went
+func (j *Jobs) run(writer modules.Writer, keyReader modules.KeyReader, name string) {
+	writer.Write([]byte("\n\n" + j.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "Running %s..."), name), "text")))
+	result, err := j.runner.RunNow(name)
+	switch {
+	case errors.Is(err, scheduler.ErrRunning):
+		showMessage(writer, keyReader, j.colorScheme, name+" is running already.", "error")
+	case err != nil:
+		showMessage(writer, keyReader, j.colorScheme, modules.ErrorText(writer, err), "error")
+	case result.Err != nil:
+		showMessage(writer, keyReader, j.colorScheme, name+modules.T(writer, " failed: ")+result.Err.Error(), "error")
+	default:
+		summary := result.Summary
+		if summary == "" {
+			summary = "done"
+		}
+		showMessage(writer, keyReader, j.colorScheme, name+": "+summary, "success")
+	}
+}
+
+// showMessage displays a message and waits for a key
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(modules.T(writer, message), messageType), modules.Width(writer)) + "\n\n"))
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	writer.Write([]byte(colorScheme.CenterText(prompt, modules.Width(writer))))
+	keyReader.ReadKey()
+}
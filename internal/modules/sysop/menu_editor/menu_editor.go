@@ -0,0 +1,361 @@
+package menu_editor
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/config"
+	"bbs/internal/dialog"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// pageSize is how many items fit on one screen of the editor
+const pageSize = 14
+
+// MenuEditor lets the sysop change the menus from inside the board
+type MenuEditor struct {
+	colorScheme menu.ColorScheme
+	menus       []config.MenuItem
+	save        func([]config.MenuItem) error
+
+	path  []int // Items opened to reach the list being edited
+	page  int
+	dirty bool
+}
+
+// NewMenuEditor creates a menu editor working on a copy of menus. save is
+// called with the edited menus when the sysop saves them.
+func NewMenuEditor(colorScheme menu.ColorScheme, menus []config.MenuItem, save func([]config.MenuItem) error) *MenuEditor {
+	return &MenuEditor{
+		colorScheme: colorScheme,
+		menus:       cloneItems(menus),
+		save:        save,
+	}
+}
+
+// Execute shows the menus until the sysop quits
+func (me *MenuEditor) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		items := me.items()
+		pages := (len(*items) + pageSize - 1) / pageSize
+		me.page = min(me.page, max(pages-1, 0))
+		me.display(writer, *items, pages)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "o", "enter":
+			if index, ok := me.pickItem(writer, keyReader, "Item number to open: ", len(*items)); ok {
+				me.path = append(me.path, index)
+				me.page = 0
+			}
+		case "a":
+			me.addItem(writer, keyReader, items)
+		case "e":
+			if index, ok := me.pickItem(writer, keyReader, "Item number to edit: ", len(*items)); ok {
+				me.editItem(writer, keyReader, *items, index)
+			}
+		case "d":
+			me.deleteItem(writer, keyReader, items)
+		case "m":
+			me.moveItem(writer, keyReader, *items)
+		case "n", "right":
+			if me.page < pages-1 {
+				me.page++
+			}
+		case "p", "left":
+			if me.page > 0 {
+				me.page--
+			}
+		case "b", "backspace":
+			if len(me.path) > 0 {
+				me.path = me.path[:len(me.path)-1]
+				me.page = 0
+			}
+		case "s":
+			me.saveMenus(writer, keyReader)
+		case "q", "quit", "escape":
+			if me.dirty {
+				save := dialog.Confirm(writer, keyReader, me.colorScheme, modules.T(writer, "Save your changes first?"), true)
+				if save && !me.saveMenus(writer, keyReader) {
+					continue
+				}
+			}
+			return true
+		}
+	}
+}
+
+// display draws the list being edited
+func (me *MenuEditor) display(writer modules.Writer, items []config.MenuItem, pages int) {
+	writer.Write([]byte(menu.ClearScreen))
+
+	header := me.colorScheme.Colorize(modules.T(writer, "--- Menu Editor ---"), "primary")
+	writer.Write([]byte(me.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	trail := "Menus"
+	level := me.menus
+	for _, index := range me.path {
+		trail += " > " + level[index].Title
+		level = level[index].Submenu
+	}
+	if me.dirty {
+		trail += "  (unsaved changes)"
+	}
+	writer.Write([]byte(me.colorScheme.CenterText(me.colorScheme.Colorize(trail, "secondary"), modules.Width(writer)) + "\n\n"))
+
+	if len(items) == 0 {
+		msg := me.colorScheme.Colorize(modules.T(writer, "This menu has no items yet."), "secondary")
+		writer.Write([]byte(me.colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+	} else {
+		headerLine := fmt.Sprintf("%-3s %-4s %-24s %-22s %-6s %-5s", "#", "Key", "Title", "Command", "Level", "Items")
+		writer.Write([]byte(me.colorScheme.CenterText(me.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+		separator := me.colorScheme.DrawSeparator(len(headerLine), "─")
+		writer.Write([]byte(me.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+		start := me.page * pageSize
+		for i := start; i < len(items) && i < start+pageSize; i++ {
+			item := items[i]
+			submenu := ""
+			if len(item.Submenu) > 0 {
+				submenu = fmt.Sprintf("%d", len(item.Submenu))
+			}
+			line := fmt.Sprintf("%-3d %-4s %-24s %-22s %-6d %-5s", i+1, item.Hotkey,
+				ansi.Ellipsize(item.Title, 24), ansi.Ellipsize(item.Command, 22), item.AccessLevel, submenu)
+			writer.Write([]byte(me.colorScheme.CenterText(me.colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+		}
+	}
+
+	writer.Write([]byte("\n"))
+	instructions := modules.T(writer, "O: Open  A: Add  E: Edit  D: Delete  M: Move  S: Save  Q: Quit")
+	if len(me.path) > 0 {
+		instructions = modules.T(writer, "O: Open  A: Add  E: Edit  D: Delete  M: Move  B: Back  S: Save  Q: Quit")
+	}
+	writer.Write([]byte(me.colorScheme.CenterText(me.colorScheme.Colorize(instructions, "secondary"), modules.Width(writer)) + "\n"))
+	if pages > 1 {
+		paging := fmt.Sprintf("Page %d of %d  N: Next  P: Previous", me.page+1, pages)
+		writer.Write([]byte(me.colorScheme.CenterText(me.colorScheme.Colorize(paging, "secondary"), modules.Width(writer)) + "\n"))
+	}
+}
+
+// items returns the list being edited: the menus themselves, or the
+// submenu of the last item opened
+func (me *MenuEditor) items() *[]config.MenuItem {
+	items := &me.menus
+	for _, index := range me.path {
+		items = &(*items)[index].Submenu
+	}
+	return items
+}
+
+// pickItem asks for an item number, returning its index
+func (me *MenuEditor) pickItem(writer modules.Writer, keyReader modules.KeyReader, prompt string, count int) (int, bool) {
+	if count == 0 {
+		return 0, false
+	}
+	if count == 1 {
+		return 0, true
+	}
+	writer.Write([]byte("\n" + me.colorScheme.Colorize(prompt, "text")))
+	input, err := readLine(keyReader, writer)
+	if err != nil || strings.TrimSpace(input) == "" {
+		return 0, false
+	}
+	number, err := parseNumber(input, 1, count)
+	if err != nil {
+		showMessage(writer, keyReader, me.colorScheme, "Invalid item number.", "error")
+		return 0, false
+	}
+	return number - 1, true
+}
+
+// addItem asks for a new item and adds it to the end of the list
+func (me *MenuEditor) addItem(writer modules.Writer, keyReader modules.KeyReader, items *[]config.MenuItem) {
+	writer.Write([]byte(menu.ClearScreen))
+	header := me.colorScheme.Colorize(modules.T(writer, "--- Add Menu Item ---"), "primary")
+	writer.Write([]byte(me.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	writer.Write([]byte(me.colorScheme.Colorize(modules.T(writer, "ID: "), "text")))
+	id, err := readLine(keyReader, writer)
+	id = strings.TrimSpace(id)
+	if err != nil || id == "" {
+		showMessage(writer, keyReader, me.colorScheme, "Operation cancelled.", "error")
+		return
+	}
+	for _, item := range *items {
+		if item.ID == id {
+			showMessage(writer, keyReader, me.colorScheme, fmt.Sprintf(modules.T(writer, "This menu already has an item called %q."), id), "error")
+			return
+		}
+	}
+
+	item := config.MenuItem{ID: id, Title: id, Command: id}
+	if !me.askFields(writer, keyReader, *items, -1, &item) {
+		return
+	}
+	*items = append(*items, item)
+	me.dirty = true
+	me.page = (len(*items) - 1) / pageSize
+}
+
+// editItem changes the fields of one item
+func (me *MenuEditor) editItem(writer modules.Writer, keyReader modules.KeyReader, items []config.MenuItem, index int) {
+	writer.Write([]byte(menu.ClearScreen))
+	header := me.colorScheme.Colorize(modules.T(writer, "--- Edit Menu Item ---"), "primary")
+	writer.Write([]byte(me.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	info := me.colorScheme.Colorize(modules.T(writer, "Press Enter to keep a value."), "secondary")
+	writer.Write([]byte(me.colorScheme.CenterText(info, modules.Width(writer)) + "\n\n"))
+
+	item := items[index]
+	if !me.askFields(writer, keyReader, items, index, &item) {
+		return
+	}
+	items[index] = item
+	me.dirty = true
+}
+
+// askFields asks for each field of item in turn, offering its current
+// value. siblings are the other items on its menu, which can't share its
+// hotkey; skip is item's own place among them, or -1 for a new item.
+func (me *MenuEditor) askFields(writer modules.Writer, keyReader modules.KeyReader, siblings []config.MenuItem, skip int, item *config.MenuItem) bool {
+	for _, field := range []struct {
+		label string
+		value *string
+	}{
+		{"Title", &item.Title},
+		{"Description", &item.Description},
+		{"Command", &item.Command},
+	} {
+		input, ok := me.ask(writer, keyReader, field.label, *field.value)
+		if !ok {
+			return false
+		}
+		*field.value = input
+	}
+
+	hotkey, ok := me.ask(writer, keyReader, "Hotkey (- for none)", item.Hotkey)
+	if !ok {
+		return false
+	}
+	if hotkey == "-" {
+		hotkey = ""
+	}
+	hotkey = strings.ToLower(hotkey)
+	if len(hotkey) > 1 {
+		showMessage(writer, keyReader, me.colorScheme, "A hotkey is a single key.", "error")
+		return false
+	}
+	for i, sibling := range siblings {
+		if i != skip && hotkey != "" && strings.EqualFold(sibling.Hotkey, hotkey) {
+			showMessage(writer, keyReader, me.colorScheme, fmt.Sprintf(modules.T(writer, "%s already uses the %s key."), sibling.Title, hotkey), "error")
+			return false
+		}
+	}
+	item.Hotkey = hotkey
+
+	art, ok := me.ask(writer, keyReader, "ANSI art file (- for none)", item.Art)
+	if !ok {
+		return false
+	}
+	if art == "-" {
+		art = ""
+	}
+	item.Art = art
+
+	level, ok := me.ask(writer, keyReader, "Access level (0-255)", fmt.Sprintf("%d", item.AccessLevel))
+	if !ok {
+		return false
+	}
+	accessLevel, err := parseNumber(level, 0, 255)
+	if err != nil {
+		showMessage(writer, keyReader, me.colorScheme, "Access level must be 0-255.", "error")
+		return false
+	}
+	item.AccessLevel = accessLevel
+	return true
+}
+
+// ask prompts for one field, returning current if the sysop just presses
+// Enter
+func (me *MenuEditor) ask(writer modules.Writer, keyReader modules.KeyReader, label, current string) (string, bool) {
+	writer.Write([]byte(me.colorScheme.Colorize(fmt.Sprintf("%s [%s]: ", label, current), "text")))
+	input, err := readLine(keyReader, writer)
+	if err != nil {
+		showMessage(writer, keyReader, me.colorScheme, "Operation cancelled.", "error")
+		return "", false
+	}
+	if strings.TrimSpace(input) == "" {
+		return current, true
+	}
+	return strings.TrimSpace(input), true
+}
+
+// deleteItem removes an item, and any submenu under it, after asking
+func (me *MenuEditor) deleteItem(writer modules.Writer, keyReader modules.KeyReader, items *[]config.MenuItem) {
+	index, ok := me.pickItem(writer, keyReader, "Item number to delete: ", len(*items))
+	if !ok {
+		return
+	}
+	item := (*items)[index]
+	if len(me.path) == 0 && item.ID == "main" {
+		showMessage(writer, keyReader, me.colorScheme, "The main menu can't be deleted.", "error")
+		return
+	}
+
+	question := fmt.Sprintf(modules.T(writer, "Delete %s?"), item.Title)
+	if len(item.Submenu) > 0 {
+		question = fmt.Sprintf(modules.T(writer, "Delete %s and the %d items under it?"), item.Title, len(item.Submenu))
+	}
+	if !dialog.Confirm(writer, keyReader, me.colorScheme, question, false) {
+		return
+	}
+	*items = append((*items)[:index], (*items)[index+1:]...)
+	me.dirty = true
+}
+
+// moveItem moves an item to another place in the list
+func (me *MenuEditor) moveItem(writer modules.Writer, keyReader modules.KeyReader, items []config.MenuItem) {
+	if len(items) < 2 {
+		return
+	}
+	from, ok := me.pickItem(writer, keyReader, "Item number to move: ", len(items))
+	if !ok {
+		return
+	}
+	writer.Write([]byte(me.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "Move %s to position (1-%d): "), items[from].Title, len(items)), "text")))
+	input, err := readLine(keyReader, writer)
+	if err != nil || strings.TrimSpace(input) == "" {
+		return
+	}
+	to, err := parseNumber(input, 1, len(items))
+	if err != nil {
+		showMessage(writer, keyReader, me.colorScheme, "Invalid position.", "error")
+		return
+	}
+	to--
+
+	item := items[from]
+	if from < to {
+		copy(items[from:to], items[from+1:to+1])
+	} else {
+		copy(items[to+1:from+1], items[to:from])
+	}
+	items[to] = item
+	me.dirty = to != from || me.dirty
+}
+
+// saveMenus hands the edited menus to save, reporting whether they were saved
+func (me *MenuEditor) saveMenus(writer modules.Writer, keyReader modules.KeyReader) bool {
+	if err := me.save(cloneItems(me.menus)); err != nil {
+		showMessage(writer, keyReader, me.colorScheme, modules.T(writer, "Failed to save menus: ")+modules.ErrorText(writer, err), "error")
+		return false
+	}
+	me.dirty = false
+	showMessage(writer, keyReader, me.colorScheme, "Menus saved. Callers already online keep the old menus until they call back.", "success")
+	return true
+}
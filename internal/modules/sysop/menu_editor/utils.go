@@ -0,0 +1,52 @@
+package menu_editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bbs/internal/config"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// cloneItems copies menu items and their submenus, so edits don't reach
+// the configuration sessions are using
+func cloneItems(items []config.MenuItem) []config.MenuItem {
+	if items == nil {
+		return nil
+	}
+	clone := make([]config.MenuItem, len(items))
+	for i, item := range items {
+		clone[i] = item
+		clone[i].Submenu = cloneItems(item.Submenu)
+	}
+	return clone
+}
+
+// parseNumber parses a whole number between low and high
+func parseNumber(s string, low, high int) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < low || n > high {
+		return 0, fmt.Errorf("must be a number from %d to %d", low, high)
+	}
+	return n, nil
+}
+
+// readLine reads a line of input, echoing printable characters
+func readLine(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
+	return input.ReadLine(keyReader, writer, 0)
+}
+
+// showMessage displays a message and waits for a key
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(modules.T(writer, message), messageType), modules.Width(writer)) + "\n\n"))
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	writer.Write([]byte(colorScheme.CenterText(prompt, modules.Width(writer))))
+	keyReader.ReadKey()
+}
@@ -94,7 +94,7 @@ func (m *Module) getHandlerForCommand(command string) func(modules.Writer, modul
 	default:
 		return func(writer modules.Writer, keyReader modules.KeyReader, db *database.DB, colorScheme menu.ColorScheme) bool {
 			errorMsg := colorScheme.Colorize(fmt.Sprintf("Unknown command: %s", command), "error")
-			centeredError := colorScheme.CenterText(errorMsg, 79)
+			centeredError := colorScheme.CenterText(errorMsg, modules.Width(writer))
 			writer.Write([]byte(centeredError + "\n"))
 			keyReader.ReadKey()
 			return true
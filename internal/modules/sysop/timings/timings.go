@@ -0,0 +1,155 @@
+package timings
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/dialog"
+	"bbs/internal/menu"
+	"bbs/internal/metrics"
+	"bbs/internal/modules"
+)
+
+// pageSize is how many commands fit on one screen
+const pageSize = 12
+
+// Tracer logs everything one node does, with timings, to the server log
+type Tracer interface {
+	TraceNode(node int) error // 0 stops tracing
+	TracedNode() int          // 0 when no node is traced
+}
+
+// Timings shows the sysop how often each command runs, how long the board
+// spends on it and how quickly it answers keys, and lets them trace a node
+type Timings struct {
+	colorScheme menu.ColorScheme
+	metrics     *metrics.Registry
+	tracer      Tracer
+	page        int
+}
+
+// NewTimings creates the command timings screen
+func NewTimings(colorScheme menu.ColorScheme, registry *metrics.Registry, tracer Tracer) *Timings {
+	return &Timings{
+		colorScheme: colorScheme,
+		metrics:     registry,
+		tracer:      tracer,
+	}
+}
+
+// Execute shows the timings, counted again each time a key is pressed,
+// until the sysop quits
+func (t *Timings) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		commands, since := t.metrics.Snapshot()
+		pages := max((len(commands)+pageSize-1)/pageSize, 1)
+		t.page = min(t.page, pages-1)
+		t.display(writer, commands, since, pages)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "n":
+			if t.page < pages-1 {
+				t.page++
+			}
+		case "p":
+			if t.page > 0 {
+				t.page--
+			}
+		case "t":
+			t.trace(writer, keyReader)
+		case "r":
+			if dialog.Confirm(writer, keyReader, t.colorScheme, modules.T(writer, "Reset all counts?"), false) {
+				t.metrics.Reset()
+				t.page = 0
+			}
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// display draws one page of the table
+func (t *Timings) display(writer modules.Writer, commands []metrics.Command, since time.Time, pages int) {
+	writer.Write([]byte(menu.ClearScreen))
+	header := t.colorScheme.Colorize(modules.T(writer, "--- Command Timings ---"), "primary")
+	writer.Write([]byte(t.colorScheme.CenterText(header, modules.Width(writer)) + "\n"))
+	counting := fmt.Sprintf("Counting since %s. Keys answered in %s or more are slow.",
+		since.Local().Format("Jan 02 15:04"), short(t.metrics.Slow()))
+	writer.Write([]byte(t.colorScheme.CenterText(t.colorScheme.Colorize(counting, "secondary"), modules.Width(writer)) + "\n\n"))
+
+	if len(commands) == 0 {
+		msg := t.colorScheme.Colorize(modules.T(writer, "Nothing has run yet."), "secondary")
+		writer.Write([]byte(t.colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+	} else {
+		headerLine := fmt.Sprintf("%-18s %6s %6s %9s %7s %6s %9s", "Command", "Runs", "Errors", "Avg busy", "Keys", "Slow", "Max wait")
+		writer.Write([]byte(t.colorScheme.CenterText(t.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+		separator := t.colorScheme.DrawSeparator(len(headerLine), "─")
+		writer.Write([]byte(t.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+		start := t.page * pageSize
+		for _, c := range commands[start:min(start+pageSize, len(commands))] {
+			line := fmt.Sprintf("%-18s %6d %6d %9s %7d %6d %9s", ansi.Ellipsize(c.Name, 18),
+				c.Runs, c.Errors, short(c.AvgBusy()), c.Keys, c.Slow, short(c.MaxWait))
+			style := "text"
+			if c.Errors > 0 || c.Slow > 0 {
+				style = "highlight"
+			}
+			writer.Write([]byte(t.colorScheme.CenterText(t.colorScheme.Colorize(line, style), modules.Width(writer)) + "\n"))
+		}
+	}
+
+	writer.Write([]byte("\n"))
+	tracing := "Tracing: off"
+	if node := t.tracer.TracedNode(); node != 0 {
+		tracing = fmt.Sprintf("Tracing node %d to the server log", node)
+	}
+	writer.Write([]byte(t.colorScheme.CenterText(t.colorScheme.Colorize(tracing, "text"), modules.Width(writer)) + "\n"))
+	if pages > 1 {
+		paging := fmt.Sprintf("Page %d of %d  N: Next  P: Previous", t.page+1, pages)
+		writer.Write([]byte(t.colorScheme.CenterText(t.colorScheme.Colorize(paging, "secondary"), modules.Width(writer)) + "\n"))
+	}
+	instructions := t.colorScheme.Colorize(modules.T(writer, "T: Trace a Node  R: Reset  Q: Quit"), "secondary")
+	writer.Write([]byte(t.colorScheme.CenterText(instructions, modules.Width(writer)) + "\n"))
+}
+
+// trace asks which node to trace and starts or stops tracing it
+func (t *Timings) trace(writer modules.Writer, keyReader modules.KeyReader) {
+	writer.Write([]byte("\n" + t.colorScheme.Colorize(modules.T(writer, "Node to trace (0 to stop): "), "text")))
+	input, err := readLine(keyReader, writer)
+	if err != nil || strings.TrimSpace(input) == "" {
+		return
+	}
+	node, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || node < 0 {
+		showMessage(writer, keyReader, t.colorScheme, "Invalid node number.", "error")
+		return
+	}
+	if err := t.tracer.TraceNode(node); err != nil {
+		showMessage(writer, keyReader, t.colorScheme, modules.ErrorText(writer, err), "error")
+		return
+	}
+	if node == 0 {
+		showMessage(writer, keyReader, t.colorScheme, "Tracing stopped.", "success")
+	} else {
+		showMessage(writer, keyReader, t.colorScheme, fmt.Sprintf(modules.T(writer, "Node %d is being traced to the server log."), node), "success")
+	}
+}
+
+// short formats a duration to fit a column
+func short(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,92 @@
+package user_editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// ReviewRegistrations lists self-registered accounts awaiting approval and
+// lets the sysop approve or reject them
+func (ue *UserEditor) ReviewRegistrations(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		pending, err := ue.db.GetPendingRegistrations()
+		if err != nil {
+			showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to load registrations: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+
+		writer.Write([]byte(menu.ClearScreen))
+
+		header := ue.colorScheme.Colorize(modules.T(writer, "--- Pending New User Applications ---"), "primary")
+		centeredHeader := ue.colorScheme.CenterText(header, modules.Width(writer))
+		writer.Write([]byte(centeredHeader + "\n\n"))
+
+		if len(pending) == 0 {
+			msg := ue.colorScheme.Colorize(modules.T(writer, "No applications are waiting for approval."), "secondary")
+			writer.Write([]byte(ue.colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+		} else {
+			headerLine := fmt.Sprintf("%-3s %-16s %-20s %-24s %-10s", "#", "Username", "Real Name", "Email", "Applied")
+			writer.Write([]byte(ue.colorScheme.CenterText(ue.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+			separator := ue.colorScheme.DrawSeparator(len(headerLine), "─")
+			writer.Write([]byte(ue.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+			for i, p := range pending {
+				line := fmt.Sprintf("%-3d %-16s %-20s %-24s %-10s", i+1,
+					ansi.Ellipsize(p.User.Username, 16), ansi.Ellipsize(p.User.RealName, 20),
+					ansi.Ellipsize(p.User.Email, 24), p.RequestedAt.Format("2006-01-02"))
+				writer.Write([]byte(ue.colorScheme.CenterText(ue.colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+			}
+		}
+
+		writer.Write([]byte("\n"))
+		instructions := ue.colorScheme.Colorize(modules.T(writer, "A: Approve  R: Reject  Q: Quit"), "secondary")
+		writer.Write([]byte(ue.colorScheme.CenterText(instructions, modules.Width(writer)) + "\n"))
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "a", "r":
+			if len(pending) == 0 {
+				continue
+			}
+			approve := strings.ToLower(key) == "a"
+
+			action := "reject"
+			if approve {
+				action = "approve"
+			}
+			writer.Write([]byte("\n" + ue.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "Application number to %s: "), action), "text")))
+			input, err := readLine(keyReader, writer)
+			if err != nil || strings.TrimSpace(input) == "" {
+				continue
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(input))
+			if err != nil || index < 1 || index > len(pending) {
+				showMessage(writer, keyReader, ue.colorScheme, "Invalid application number.", "error")
+				continue
+			}
+
+			user := pending[index-1].User
+			if approve {
+				err = ue.db.ApproveRegistration(user.ID)
+			} else {
+				err = ue.db.RejectRegistration(user.ID)
+			}
+			if err != nil {
+				showMessage(writer, keyReader, ue.colorScheme, fmt.Sprintf(modules.T(writer, "Failed to %s %s: %v"), action, user.Username, err), "error")
+				continue
+			}
+			showMessage(writer, keyReader, ue.colorScheme, fmt.Sprintf(modules.T(writer, "User %s %sd."), user.Username, action), "success")
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
@@ -15,8 +15,10 @@ import (
 func (ue *UserEditor) CreateUser(writer modules.Writer, keyReader modules.KeyReader) bool {
 	// Create the form
 	form := components.NewForm(components.FormConfig{
-		Title: "Create New User",
-		Width: 79,
+		Title:        "Create New User",
+		Width:        modules.Width(writer),
+		Instructions: "Tab/↑↓: Move  ←→: Change  Enter: Submit  Esc: Cancel",
+		Compact:      true,
 	}, ue.getComponentAdapter())
 
 	// Add username field
@@ -48,6 +50,7 @@ func (ue *UserEditor) CreateUser(writer modules.Writer, keyReader modules.KeyRea
 		MaxLength:   64,
 		Required:    true,
 		Width:       40,
+		Mask:        true,
 		Validator: func(value string) error {
 			if len(strings.TrimSpace(value)) < 6 {
 				return fmt.Errorf("password must be at least 6 characters")
@@ -56,6 +59,18 @@ func (ue *UserEditor) CreateUser(writer modules.Writer, keyReader modules.KeyRea
 		},
 	}, ue.getComponentAdapter())
 
+	// Add a second password field to catch typing mistakes
+	confirmField := components.NewTextInput(components.TextInputConfig{
+		Name:        "confirm_password",
+		Label:       "Confirm Password",
+		Placeholder: "Type it again...",
+		MaxLength:   64,
+		Required:    true,
+		Width:       40,
+		Mask:        true,
+		Validator:   components.Matches(passwordField),
+	}, ue.getComponentAdapter())
+
 	// Add real name field
 	realNameField := components.NewTextInput(components.TextInputConfig{
 		Name:        "real_name",
@@ -77,26 +92,19 @@ func (ue *UserEditor) CreateUser(writer modules.Writer, keyReader modules.KeyRea
 	}, ue.getComponentAdapter())
 
 	// Add access level field
-	accessLevelField := components.NewTextInput(components.TextInputConfig{
-		Name:        "access_level",
-		Label:       "Access Level",
-		Placeholder: "0-255",
-		Value:       "10", // Default access level
-		MaxLength:   3,
-		Required:    true,
-		Width:       40,
-		Validator: func(value string) error {
-			accessLevel, err := strconv.Atoi(strings.TrimSpace(value))
-			if err != nil || accessLevel < 0 || accessLevel > 255 {
-				return fmt.Errorf("access level must be 0-255")
-			}
-			return nil
-		},
+	accessLevelField := components.NewSelectList(components.SelectListConfig{
+		Name:     "access_level",
+		Label:    "Access Level",
+		Options:  accessLevelOptions(10),
+		Value:    "10", // Default access level
+		Required: true,
+		Width:    40,
 	}, ue.getComponentAdapter())
 
 	// Add components to form
 	form.AddComponent(usernameField)
 	form.AddComponent(passwordField)
+	form.AddComponent(confirmField)
 	form.AddComponent(realNameField)
 	form.AddComponent(emailField)
 	form.AddComponent(accessLevelField)
@@ -109,33 +117,11 @@ func (ue *UserEditor) CreateUser(writer modules.Writer, keyReader modules.KeyRea
 		// Render form
 		writer.Write([]byte(form.Render()))
 
-		// Read single character input
-		keyStr, err := keyReader.ReadKey()
-		if err != nil {
+		// Read a key and let the form act on it
+		if err := form.ReadKey(keyReader); err != nil {
 			break
 		}
 
-		// Convert special key names to runes
-		var char rune
-		switch keyStr {
-		case "enter":
-			char = '\r'
-		case "escape":
-			char = 27
-		case "quit", "goodbye":
-			char = 27
-		default:
-			// Regular character
-			if len(keyStr) > 0 {
-				char = rune(keyStr[0])
-			} else {
-				continue
-			}
-		}
-
-		// Handle the key
-		form.HandleKey(char)
-
 		// Check form state
 		if form.IsSubmitted() {
 			errors := form.Validate()
@@ -146,7 +132,7 @@ func (ue *UserEditor) CreateUser(writer modules.Writer, keyReader modules.KeyRea
 
 				user := &database.User{
 					Username:    strings.TrimSpace(values["username"]),
-					Password:    strings.TrimSpace(values["password"]), // TODO: Hash password
+					Password:    strings.TrimSpace(values["password"]), // Hashed by the database layer
 					RealName:    strings.TrimSpace(values["real_name"]),
 					Email:       strings.TrimSpace(values["email"]),
 					AccessLevel: accessLevel,
@@ -155,7 +141,7 @@ func (ue *UserEditor) CreateUser(writer modules.Writer, keyReader modules.KeyRea
 				}
 
 				if err := ue.db.CreateUser(user); err != nil {
-					showMessage(writer, keyReader, ue.colorScheme, "Error creating user: "+err.Error(), "error")
+					showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Error creating user: ")+modules.ErrorText(writer, err), "error")
 				} else {
 					showMessage(writer, keyReader, ue.colorScheme, "User created successfully!", "success")
 				}
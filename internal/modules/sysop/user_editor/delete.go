@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"bbs/internal/dialog"
 	"bbs/internal/menu"
 	"bbs/internal/modules"
 )
@@ -12,12 +13,12 @@ import (
 func (ue *UserEditor) DeleteUser(writer modules.Writer, keyReader modules.KeyReader) bool {
 	writer.Write([]byte(menu.ClearScreen))
 
-	header := ue.colorScheme.Colorize("--- Delete User Account ---", "primary")
-	centeredHeader := ue.colorScheme.CenterText(header, 79)
+	header := ue.colorScheme.Colorize(modules.T(writer, "--- Delete User Account ---"), "primary")
+	centeredHeader := ue.colorScheme.CenterText(header, modules.Width(writer))
 	writer.Write([]byte(centeredHeader + "\n\n"))
 
 	// Get username to delete
-	writer.Write([]byte(ue.colorScheme.Colorize("Enter username to delete: ", "text")))
+	writer.Write([]byte(ue.colorScheme.Colorize(modules.T(writer, "Enter username to delete: "), "text")))
 	username, err := readLine(keyReader, writer)
 	if err != nil || strings.TrimSpace(username) == "" {
 		showMessage(writer, keyReader, ue.colorScheme, "Operation cancelled.", "error")
@@ -32,17 +33,15 @@ func (ue *UserEditor) DeleteUser(writer modules.Writer, keyReader modules.KeyRea
 	}
 
 	// Confirm deletion
-	confirmMsg := fmt.Sprintf("Are you sure you want to delete user '%s'? (y/N): ", user.Username)
-	writer.Write([]byte(ue.colorScheme.Colorize(confirmMsg, "text")))
-	confirm, err := readLine(keyReader, writer)
-	if err != nil || strings.ToLower(strings.TrimSpace(confirm)) != "y" {
+	confirmMsg := fmt.Sprintf(modules.T(writer, "Are you sure you want to delete user '%s'?"), user.Username)
+	if !dialog.Confirm(writer, keyReader, ue.colorScheme, confirmMsg, false) {
 		showMessage(writer, keyReader, ue.colorScheme, "Operation cancelled.", "error")
 		return true
 	}
 
-	// Delete user
-	if err := ue.db.DeleteUser(user.ID); err != nil {
-		showMessage(writer, keyReader, ue.colorScheme, "Failed to delete user: "+err.Error(), "error")
+	// Delete the account with its tokens and private data
+	if err := ue.db.DeleteAccount(user.ID); err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to delete user: ")+modules.ErrorText(writer, err), "error")
 		return true
 	}
 
@@ -2,8 +2,11 @@ package user_editor
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
+	"bbs/internal/components"
+	"bbs/internal/database"
 	"bbs/internal/menu"
 	"bbs/internal/modules"
 )
@@ -12,12 +15,12 @@ import (
 func (ue *UserEditor) EditUser(writer modules.Writer, keyReader modules.KeyReader) bool {
 	writer.Write([]byte(menu.ClearScreen))
 
-	header := ue.colorScheme.Colorize("--- Edit User Account ---", "primary")
-	centeredHeader := ue.colorScheme.CenterText(header, 79)
+	header := ue.colorScheme.Colorize(modules.T(writer, "--- Edit User Account ---"), "primary")
+	centeredHeader := ue.colorScheme.CenterText(header, modules.Width(writer))
 	writer.Write([]byte(centeredHeader + "\n\n"))
 
 	// Get username to edit
-	writer.Write([]byte(ue.colorScheme.Colorize("Enter username to edit: ", "text")))
+	writer.Write([]byte(ue.colorScheme.Colorize(modules.T(writer, "Enter username to edit: "), "text")))
 	username, err := readLine(keyReader, writer)
 	if err != nil || strings.TrimSpace(username) == "" {
 		showMessage(writer, keyReader, ue.colorScheme, "Operation cancelled.", "error")
@@ -31,50 +34,193 @@ func (ue *UserEditor) EditUser(writer modules.Writer, keyReader modules.KeyReade
 		return true
 	}
 
-	// Show current user info
-	writer.Write([]byte(menu.ClearScreen))
-	writer.Write([]byte(centeredHeader + "\n\n"))
+	ue.editAccount(writer, keyReader, user)
+	return true
+}
 
-	info := fmt.Sprintf("Current user: %s (Access Level: %d, Active: %v)",
-		user.Username, user.AccessLevel, user.IsActive)
-	centeredInfo := ue.colorScheme.CenterText(ue.colorScheme.Colorize(info, "secondary"), 79)
-	writer.Write([]byte(centeredInfo + "\n\n"))
+// editAccount shows every editable field of user on one form. Tab or the
+// arrow keys move between fields, each field is checked as it is left, and
+// the changes are listed for confirmation before they are saved.
+func (ue *UserEditor) editAccount(writer modules.Writer, keyReader modules.KeyReader, user *database.User) {
+	form, fields := ue.buildEditForm(writer, user)
+	form.Start()
 
-	// Get new password (optional)
-	writer.Write([]byte(ue.colorScheme.Colorize("New password (press Enter to keep current): ", "text")))
-	newPassword, err := readLine(keyReader, writer)
-	if err != nil {
-		showMessage(writer, keyReader, ue.colorScheme, "Operation cancelled.", "error")
-		return true
+	for {
+		writer.Write([]byte(form.Render()))
+
+		if err := form.ReadKey(keyReader); err != nil {
+			return
+		}
+
+		if form.IsCancelled() {
+			return
+		}
+		if form.IsSubmitted() {
+			if errs := form.Validate(); len(errs) > 0 {
+				form.Reset()
+				form.Start()
+				form.Validate() // Reset cleared the errors shown under the fields
+				continue
+			}
+			if ue.saveEdit(writer, keyReader, user, fields) {
+				return
+			}
+			form.Reset()
+			form.Start()
+		}
 	}
+}
 
-	// Get new access level (optional)
-	currentLevelStr := fmt.Sprintf("New access level (current: %d, press Enter to keep): ", user.AccessLevel)
-	writer.Write([]byte(ue.colorScheme.Colorize(currentLevelStr, "text")))
-	accessLevelStr, err := readLine(keyReader, writer)
-	if err != nil {
-		showMessage(writer, keyReader, ue.colorScheme, "Operation cancelled.", "error")
+// editFields are the inputs on the edit form
+type editFields struct {
+	realName, email, password, confirm *components.TextInput
+	level                              *components.SelectList
+	active                             *components.Checkbox
+}
+
+// buildEditForm creates the edit form filled in with user's details
+func (ue *UserEditor) buildEditForm(writer modules.Writer, user *database.User) (*components.Form, editFields) {
+	adapter := ue.getComponentAdapter()
+	form := components.NewForm(components.FormConfig{
+		Title:        fmt.Sprintf("Edit User: %s (ID %d)", user.Username, user.ID),
+		Width:        modules.Width(writer),
+		Instructions: "Tab/↑↓: Move  ←→/Space: Change  Enter: Review and Save  Esc: Cancel",
+	}, adapter)
+
+	fields := editFields{
+		realName: components.NewTextInput(components.TextInputConfig{
+			Name:      "real_name",
+			Label:     "Real Name",
+			Value:     user.RealName,
+			MaxLength: 64,
+			Width:     40,
+		}, adapter),
+		email: components.NewTextInput(components.TextInputConfig{
+			Name:      "email",
+			Label:     "Email",
+			Value:     user.Email,
+			MaxLength: 128,
+			Width:     40,
+			Validator: func(value string) error {
+				if value = strings.TrimSpace(value); value != "" && !strings.Contains(value, "@") {
+					return fmt.Errorf("email address looks invalid")
+				}
+				return nil
+			},
+		}, adapter),
+		level: components.NewSelectList(components.SelectListConfig{
+			Name:     "access_level",
+			Label:    "Access Level",
+			Options:  accessLevelOptions(user.AccessLevel),
+			Value:    strconv.Itoa(user.AccessLevel),
+			Required: true,
+			Width:    40,
+		}, adapter),
+		active: components.NewCheckbox(components.CheckboxConfig{
+			Name:    "active",
+			Label:   "Account active",
+			Checked: user.IsActive,
+			Width:   40,
+		}, adapter),
+		password: components.NewTextInput(components.TextInputConfig{
+			Name:        "password",
+			Label:       "New Password",
+			Placeholder: "Leave blank to keep it",
+			MaxLength:   64,
+			Width:       40,
+			Mask:        true,
+			Validator: func(value string) error {
+				if value = strings.TrimSpace(value); value != "" && len(value) < 6 {
+					return fmt.Errorf("password must be at least 6 characters")
+				}
+				return nil
+			},
+		}, adapter),
+	}
+	form.AddComponent(fields.realName)
+	form.AddComponent(fields.email)
+	form.AddComponent(fields.level)
+	form.AddComponent(fields.active)
+	form.AddComponent(fields.password)
+	fields.confirm = components.NewTextInput(components.TextInputConfig{
+		Name:        "confirm_password",
+		Label:       "Confirm Password",
+		Placeholder: "Only if setting one",
+		MaxLength:   64,
+		Width:       40,
+		Mask:        true,
+		Validator:   components.Matches(fields.password),
+	}, adapter)
+	form.AddComponent(fields.confirm)
+	return form, fields
+}
+
+// saveEdit lists what the form changes and saves it once the sysop
+// confirms. It returns false if the sysop wants to go back to the form.
+func (ue *UserEditor) saveEdit(writer modules.Writer, keyReader modules.KeyReader, user *database.User, fields editFields) bool {
+	realName := strings.TrimSpace(fields.realName.GetStringValue())
+	email := strings.TrimSpace(fields.email.GetStringValue())
+	level, _ := parseAccessLevel(fields.level.GetStringValue())
+	active := fields.active.IsChecked()
+	password := strings.TrimSpace(fields.password.GetStringValue())
+
+	var changes []string
+	if realName != user.RealName {
+		changes = append(changes, fmt.Sprintf("Real name: %q → %q", user.RealName, realName))
+	}
+	if email != user.Email {
+		changes = append(changes, fmt.Sprintf("Email: %q → %q", user.Email, email))
+	}
+	if level != user.AccessLevel {
+		changes = append(changes, fmt.Sprintf("Access level: %d → %d", user.AccessLevel, level))
+	}
+	if active != user.IsActive {
+		changes = append(changes, fmt.Sprintf("Active: %v → %v", user.IsActive, active))
+	}
+	if password != "" {
+		changes = append(changes, "Password: set, to be changed at next login")
+	}
+	if len(changes) == 0 {
+		showMessage(writer, keyReader, ue.colorScheme, "Nothing was changed.", "secondary")
 		return true
 	}
 
-	// Update user
-	if strings.TrimSpace(newPassword) != "" {
-		user.Password = strings.TrimSpace(newPassword) // TODO: Hash password
+	writer.Write([]byte(menu.ClearScreen))
+	header := ue.colorScheme.Colorize("--- Save Changes to "+user.Username+"? ---", "primary")
+	writer.Write([]byte(ue.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	for _, change := range changes {
+		writer.Write([]byte(ue.colorScheme.CenterText(ue.colorScheme.Colorize(change, "text"), modules.Width(writer)) + "\n"))
 	}
+	writer.Write([]byte("\n" + ue.colorScheme.CenterText(ue.colorScheme.Colorize(modules.T(writer, "Y: Save  N: Back to the Form  Esc: Discard"), "accent"), modules.Width(writer))))
 
-	if strings.TrimSpace(accessLevelStr) != "" {
-		if level, err := parseAccessLevel(accessLevelStr); err == nil {
-			user.AccessLevel = level
-		} else {
-			showMessage(writer, keyReader, ue.colorScheme, "Invalid access level, keeping current value.", "secondary")
-		}
+	key, err := keyReader.ReadKey()
+	if err != nil {
+		return true
+	}
+	switch strings.ToLower(key) {
+	case "y":
+	case "escape":
+		return true
+	default:
+		return false
 	}
 
-	if err := ue.db.UpdateUser(user.ID, user.Username, user.Password, user.RealName, user.Email, user.AccessLevel, user.IsActive); err != nil {
-		showMessage(writer, keyReader, ue.colorScheme, "Failed to update user: "+err.Error(), "error")
+	stored := user.Password
+	if password != "" {
+		stored = password // Hashed by the database layer
+	}
+	if err := ue.db.UpdateUser(user.ID, user.Username, stored, realName, email, level, active); err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to update user: ")+modules.ErrorText(writer, err), "error")
 		return true
 	}
+	// An admin reset is temporary: the user picks their own at next login
+	if password != "" {
+		if err := ue.db.SetMustChangePassword(user.ID, true); err != nil {
+			showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "User updated, but failed to require a password change: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+	}
 
-	showMessage(writer, keyReader, ue.colorScheme, "User updated successfully!", "primary")
+	showMessage(writer, keyReader, ue.colorScheme, "User updated successfully!", "success")
 	return true
 }
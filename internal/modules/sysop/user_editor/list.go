@@ -2,72 +2,213 @@ package user_editor
 
 import (
 	"fmt"
+	"strings"
 
+	"bbs/internal/ansi"
+	"bbs/internal/database"
 	"bbs/internal/menu"
 	"bbs/internal/modules"
 )
 
-// ListUsers displays all users
+// userRows is how many accounts the user browser lists on a page
+const userRows = 12
+
+// userSorts are the orders the browser cycles through, with their names
+var userSorts = []struct {
+	sort database.UserSort
+	name string
+}{
+	{database.SortByName, "Name"},
+	{database.SortByLevel, "Level"},
+	{database.SortByCalls, "Calls"},
+	{database.SortByLastCall, "Last Call"},
+}
+
+// userStatuses are the account states the browser cycles through
+var userStatuses = []struct {
+	status int
+	name   string
+}{
+	{database.AnyStatus, "All"},
+	{database.ActiveOnly, "Active"},
+	{database.InactiveOnly, "Inactive"},
+}
+
+// ListUsers browses the accounts a page at a time. The sysop can find
+// accounts by name, level or status, sort them, and open the selected one
+// in the editor.
 func (ue *UserEditor) ListUsers(writer modules.Writer, keyReader modules.KeyReader) bool {
-	writer.Write([]byte(menu.ClearScreen))
+	filter := database.UserFilter{Level: database.AnyLevel}
+	sortIndex, statusIndex := 0, 0
+	page, selected := 0, 0
+
+	for {
+		filter.Status = userStatuses[statusIndex].status
+		users, total, err := ue.db.FindUsers(filter, userSorts[sortIndex].sort, userRows, page*userRows)
+		if err != nil {
+			showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to retrieve users: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+		// Past the last page, as after a filter shrank the list
+		if len(users) == 0 && page > 0 {
+			page = 0
+			continue
+		}
+		pages := (total + userRows - 1) / userRows
+		if selected >= len(users) {
+			selected = len(users) - 1
+		}
+		if selected < 0 {
+			selected = 0
+		}
 
-	header := ue.colorScheme.Colorize("--- All Users ---", "primary")
-	centeredHeader := ue.colorScheme.CenterText(header, 79)
-	writer.Write([]byte(centeredHeader + "\n\n"))
+		ue.drawUsers(writer, users, filter, sortIndex, statusIndex, selected, page, pages, total)
 
-	users, err := ue.db.GetAllUsers(1000)
-	if err != nil {
-		showMessage(writer, keyReader, ue.colorScheme, "Failed to retrieve users: "+err.Error(), "error")
-		return true
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "up":
+			if selected > 0 {
+				selected--
+			} else if page > 0 {
+				page--
+				selected = userRows - 1
+			}
+		case "down":
+			if selected < len(users)-1 {
+				selected++
+			} else if page < pages-1 {
+				page++
+				selected = 0
+			}
+		case "n", "right", "pagedown":
+			if page < pages-1 {
+				page++
+				selected = 0
+			}
+		case "p", "left", "pageup":
+			if page > 0 {
+				page--
+				selected = 0
+			}
+		case "home":
+			page, selected = 0, 0
+		case "end":
+			// Selecting past the end picks the last account on the page
+			page, selected = max(pages-1, 0), userRows
+		case "enter":
+			if len(users) > 0 {
+				ue.openUser(writer, keyReader, users[selected].ID)
+			}
+		case "f", "/":
+			writer.Write([]byte("\n" + ue.colorScheme.Colorize(modules.T(writer, "Find username or real name (Enter for all): "), "text")))
+			name, err := readLine(keyReader, writer)
+			if err != nil {
+				continue
+			}
+			filter.Name = strings.TrimSpace(name)
+			page, selected = 0, 0
+		case "l":
+			if level, ok := ue.readLevelFilter(writer, keyReader); ok {
+				filter.Level = level
+				page, selected = 0, 0
+			}
+		case "a":
+			statusIndex = (statusIndex + 1) % len(userStatuses)
+			page, selected = 0, 0
+		case "s":
+			sortIndex = (sortIndex + 1) % len(userSorts)
+			page, selected = 0, 0
+		case "c":
+			filter = database.UserFilter{Level: database.AnyLevel}
+			statusIndex = 0
+			page, selected = 0, 0
+		case "q", "quit", "escape":
+			return true
+		}
 	}
+}
 
-	if len(users) == 0 {
-		showMessage(writer, keyReader, ue.colorScheme, "No users found.", "secondary")
-		return true
+// drawUsers shows a page of accounts with the selected one highlighted
+func (ue *UserEditor) drawUsers(writer modules.Writer, users []database.User, filter database.UserFilter,
+	sortIndex, statusIndex, selected, page, pages, total int) {
+	cs := ue.colorScheme
+	writer.Write([]byte(menu.ClearScreen + menu.HideCursor))
+
+	header := cs.Colorize(modules.T(writer, "--- All Users ---"), "primary")
+	writer.Write([]byte(cs.CenterText(header, modules.Width(writer)) + "\n"))
+
+	shown := []string{"Sort: " + userSorts[sortIndex].name, "Status: " + userStatuses[statusIndex].name}
+	if filter.Name != "" {
+		shown = append(shown, fmt.Sprintf("Name: %q", ansi.Ellipsize(filter.Name, 16)))
 	}
+	if filter.Level != database.AnyLevel {
+		shown = append(shown, fmt.Sprintf("Level: %d", filter.Level))
+	}
+	writer.Write([]byte(cs.CenterText(cs.Colorize(strings.Join(shown, "  "), "secondary"), modules.Width(writer)) + "\n\n"))
 
-	// Header line
-	headerLine := "ID   Username        Real Name            Level Calls  Status"
-	coloredHeader := ue.colorScheme.Colorize(headerLine, "accent")
-	centeredHeaderLine := ue.colorScheme.CenterText(coloredHeader, 79)
-	writer.Write([]byte(centeredHeaderLine + "\n"))
-
-	// Separator line
-	separator := ue.colorScheme.DrawSeparator(len(headerLine), "─")
-	centeredSeparator := ue.colorScheme.CenterText(separator, 79)
-	writer.Write([]byte(centeredSeparator + "\n"))
-
-	// Display users
-	for _, user := range users {
-		// Truncate real name if too long
-		realName := user.RealName
-		if len(realName) > 20 {
-			realName = realName[:17] + "..."
-		}
+	if len(users) == 0 {
+		msg := cs.Colorize(modules.T(writer, "No users found."), "secondary")
+		writer.Write([]byte(cs.CenterText(msg, modules.Width(writer)) + "\n"))
+	} else {
+		headerLine := fmt.Sprintf("%-5s %-15s %-20s %-5s %-6s %-10s %-8s", "ID", "Username", "Real Name", "Level", "Calls", "Last Call", "Status")
+		writer.Write([]byte(cs.CenterText(cs.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+		separator := cs.DrawSeparator(len(headerLine), "─")
+		writer.Write([]byte(cs.CenterText(separator, modules.Width(writer)) + "\n"))
 
-		status := "Active"
-		if !user.IsActive {
-			status = "Inactive"
+		for i, user := range users {
+			lastCall := "Never"
+			if user.LastCall != nil {
+				lastCall = user.LastCall.Format("2006-01-02")
+			}
+			status := modules.T(writer, "Active")
+			if !user.IsActive {
+				status = modules.T(writer, "Inactive")
+			}
+			line := fmt.Sprintf("%-5d %-15s %-20s %-5d %-6d %-10s %-8s", user.ID, ansi.Ellipsize(user.Username, 15),
+				ansi.Ellipsize(user.RealName, 20), user.AccessLevel, user.TotalCalls, lastCall, status)
+			writer.Write([]byte(cs.CenterText(cs.HighlightSelection(line, i == selected, len(line)+2), modules.Width(writer)) + "\n"))
 		}
 
-		line := fmt.Sprintf("%-4d %-15s %-20s %-5d %-8d %-6s",
-			user.ID,
-			user.Username,
-			realName,
-			user.AccessLevel,
-			user.TotalCalls,
-			status)
-
-		coloredLine := ue.colorScheme.Colorize(line, "text")
-		centeredLine := ue.colorScheme.CenterText(coloredLine, 79)
-		writer.Write([]byte(centeredLine + "\n"))
+		status := fmt.Sprintf(modules.T(writer, "%d user(s), page %d of %d"), total, page+1, pages)
+		writer.Write([]byte("\n" + cs.CenterText(cs.Colorize(status, "secondary"), modules.Width(writer)) + "\n"))
 	}
 
 	writer.Write([]byte("\n"))
-	prompt := ue.colorScheme.Colorize("Press any key to continue...", "text")
-	centeredPrompt := ue.colorScheme.CenterText(prompt, 79)
-	writer.Write([]byte(centeredPrompt))
+	instructions := cs.Colorize(modules.T(writer, "↑↓: Select  Enter: Edit  N/P: Page  Q: Quit"), "secondary")
+	writer.Write([]byte(cs.CenterText(instructions, modules.Width(writer)) + "\n"))
+	filters := cs.Colorize(modules.T(writer, "F: Find  L: Level  A: Active/Inactive  S: Sort  C: Clear"), "secondary")
+	writer.Write([]byte(cs.CenterText(filters, modules.Width(writer))))
+}
 
-	keyReader.ReadKey()
-	return true
+// openUser loads an account afresh and opens it in the editor
+func (ue *UserEditor) openUser(writer modules.Writer, keyReader modules.KeyReader, id int) {
+	user, err := ue.db.GetUserByID(id)
+	if err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, "User not found!", "error")
+		return
+	}
+	writer.Write([]byte(menu.ShowCursor))
+	ue.editAccount(writer, keyReader, user)
+}
+
+// readLevelFilter asks for the access level to list, Enter for every level
+func (ue *UserEditor) readLevelFilter(writer modules.Writer, keyReader modules.KeyReader) (int, bool) {
+	writer.Write([]byte("\n" + ue.colorScheme.Colorize(modules.T(writer, "Access level to list (Enter for all): "), "text")))
+	input, err := readLine(keyReader, writer)
+	if err != nil {
+		return 0, false
+	}
+	if strings.TrimSpace(input) == "" {
+		return database.AnyLevel, true
+	}
+	level, err := parseAccessLevel(input)
+	if err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, modules.ErrorText(writer, err), "error")
+		return 0, false
+	}
+	return level, true
 }
@@ -1,8 +1,10 @@
 package user_editor
 
 import (
+	"fmt"
 	"strings"
 
+	"bbs/internal/dialog"
 	"bbs/internal/menu"
 	"bbs/internal/modules"
 )
@@ -11,12 +13,12 @@ import (
 func (ue *UserEditor) ChangePassword(writer modules.Writer, keyReader modules.KeyReader) bool {
 	writer.Write([]byte(menu.ClearScreen))
 
-	header := ue.colorScheme.Colorize("--- Change User Password ---", "primary")
-	centeredHeader := ue.colorScheme.CenterText(header, 79)
+	header := ue.colorScheme.Colorize(modules.T(writer, "--- Change User Password ---"), "primary")
+	centeredHeader := ue.colorScheme.CenterText(header, modules.Width(writer))
 	writer.Write([]byte(centeredHeader + "\n\n"))
 
 	// Get username
-	writer.Write([]byte(ue.colorScheme.Colorize("Enter username: ", "text")))
+	writer.Write([]byte(ue.colorScheme.Colorize(modules.T(writer, "Enter username: "), "text")))
 	username, err := readLine(keyReader, writer)
 	if err != nil || strings.TrimSpace(username) == "" {
 		showMessage(writer, keyReader, ue.colorScheme, "Operation cancelled.", "error")
@@ -31,20 +33,91 @@ func (ue *UserEditor) ChangePassword(writer modules.Writer, keyReader modules.Ke
 	}
 
 	// Get new password
-	writer.Write([]byte(ue.colorScheme.Colorize("Enter new password: ", "text")))
-	newPassword, err := readLine(keyReader, writer)
+	writer.Write([]byte(ue.colorScheme.Colorize(modules.T(writer, "Enter new password: "), "text")))
+	newPassword, err := readPassword(keyReader, writer)
 	if err != nil || strings.TrimSpace(newPassword) == "" {
 		showMessage(writer, keyReader, ue.colorScheme, "Operation cancelled.", "error")
 		return true
 	}
+	writer.Write([]byte(ue.colorScheme.Colorize(modules.T(writer, "Confirm new password: "), "text")))
+	confirm, err := readPassword(keyReader, writer)
+	if err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, "Operation cancelled.", "error")
+		return true
+	}
+	if confirm != newPassword {
+		showMessage(writer, keyReader, ue.colorScheme, "The passwords don't match. The password was not changed.", "error")
+		return true
+	}
 
 	// Update password
-	user.Password = strings.TrimSpace(newPassword) // TODO: Hash password
+	user.Password = strings.TrimSpace(newPassword) // Hashed by the database layer
 	if err := ue.db.UpdateUser(user.ID, user.Username, user.Password, user.RealName, user.Email, user.AccessLevel, user.IsActive); err != nil {
-		showMessage(writer, keyReader, ue.colorScheme, "Failed to update password: "+err.Error(), "error")
+		showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to update password: ")+modules.ErrorText(writer, err), "error")
+		return true
+	}
+
+	// An admin reset is temporary: the user picks their own at next login
+	if err := ue.db.SetMustChangePassword(user.ID, true); err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Password updated, but failed to require a change: ")+modules.ErrorText(writer, err), "error")
+		return true
+	}
+
+	message := fmt.Sprintf(modules.T(writer, "Password updated! %s must choose a new one at next login."), user.Username)
+	showMessage(writer, keyReader, ue.colorScheme, message, "primary")
+	return true
+}
+
+// RequirePasswordChange flags or clears a user's forced password change at next login
+func (ue *UserEditor) RequirePasswordChange(writer modules.Writer, keyReader modules.KeyReader) bool {
+	writer.Write([]byte(menu.ClearScreen))
+
+	header := ue.colorScheme.Colorize(modules.T(writer, "--- Require Password Change ---"), "primary")
+	centeredHeader := ue.colorScheme.CenterText(header, modules.Width(writer))
+	writer.Write([]byte(centeredHeader + "\n\n"))
+
+	// Get username
+	writer.Write([]byte(ue.colorScheme.Colorize(modules.T(writer, "Enter username: "), "text")))
+	username, err := readLine(keyReader, writer)
+	if err != nil || strings.TrimSpace(username) == "" {
+		showMessage(writer, keyReader, ue.colorScheme, "Operation cancelled.", "error")
+		return true
+	}
+
+	// Get user
+	user, err := ue.db.GetUser(strings.TrimSpace(username))
+	if err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, "User not found!", "error")
+		return true
+	}
+
+	status, err := ue.db.GetPasswordStatus(user.ID)
+	if err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to load password status: ")+modules.ErrorText(writer, err), "error")
 		return true
 	}
 
-	showMessage(writer, keyReader, ue.colorScheme, "Password updated successfully!", "primary")
+	info := fmt.Sprintf("Password last changed: %s", status.ChangedAt.Format("2006-01-02"))
+	writer.Write([]byte(ue.colorScheme.Colorize(info, "text") + "\n"))
+
+	question := fmt.Sprintf(modules.T(writer, "Require %s to change password at next login?"), user.Username)
+	if status.MustChange {
+		question = fmt.Sprintf(modules.T(writer, "%s must already change password. Clear the requirement?"), user.Username)
+	}
+	if !dialog.Confirm(writer, keyReader, ue.colorScheme, question, false) {
+		showMessage(writer, keyReader, ue.colorScheme, "Operation cancelled.", "error")
+		return true
+	}
+
+	if err := ue.db.SetMustChangePassword(user.ID, !status.MustChange); err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to update password status: ")+modules.ErrorText(writer, err), "error")
+		return true
+	}
+
+	message := fmt.Sprintf(modules.T(writer, "%s must change password at next login."), user.Username)
+	if status.MustChange {
+		message = fmt.Sprintf(modules.T(writer, "%s no longer needs to change password."), user.Username)
+	}
+	showMessage(writer, keyReader, ue.colorScheme, message, "primary")
 	return true
 }
@@ -12,12 +12,12 @@ import (
 func (ue *UserEditor) ToggleUserStatus(writer modules.Writer, keyReader modules.KeyReader) bool {
 	writer.Write([]byte(menu.ClearScreen))
 
-	header := ue.colorScheme.Colorize("--- Toggle User Status ---", "primary")
-	centeredHeader := ue.colorScheme.CenterText(header, 79)
+	header := ue.colorScheme.Colorize(modules.T(writer, "--- Toggle User Status ---"), "primary")
+	centeredHeader := ue.colorScheme.CenterText(header, modules.Width(writer))
 	writer.Write([]byte(centeredHeader + "\n\n"))
 
 	// Get username
-	writer.Write([]byte(ue.colorScheme.Colorize("Enter username: ", "text")))
+	writer.Write([]byte(ue.colorScheme.Colorize(modules.T(writer, "Enter username: "), "text")))
 	username, err := readLine(keyReader, writer)
 	if err != nil || strings.TrimSpace(username) == "" {
 		showMessage(writer, keyReader, ue.colorScheme, "Operation cancelled.", "error")
@@ -34,16 +34,16 @@ func (ue *UserEditor) ToggleUserStatus(writer modules.Writer, keyReader modules.
 	// Toggle status
 	user.IsActive = !user.IsActive
 	if err := ue.db.UpdateUser(user.ID, user.Username, user.Password, user.RealName, user.Email, user.AccessLevel, user.IsActive); err != nil {
-		showMessage(writer, keyReader, ue.colorScheme, "Failed to update user status: "+err.Error(), "error")
+		showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to update user status: ")+modules.ErrorText(writer, err), "error")
 		return true
 	}
 
-	status := "activated"
+	status := modules.T(writer, "activated")
 	if !user.IsActive {
-		status = "deactivated"
+		status = modules.T(writer, "deactivated")
 	}
 
-	message := fmt.Sprintf("User %s %s successfully!", user.Username, status)
+	message := fmt.Sprintf(modules.T(writer, "User %s %s successfully!"), user.Username, status)
 	showMessage(writer, keyReader, ue.colorScheme, message, "primary")
 	return true
 }
@@ -0,0 +1,250 @@
+package user_editor
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// subscriptionRows is how many accounts are listed on a page
+const subscriptionRows = 15
+
+// dateFormat is how expiry dates are shown and typed
+const dateFormat = "2006-01-02"
+
+// ManageSubscriptions lists accounts by when they expire and lets the sysop
+// set one account's expiry, or extend or expire every account at a level
+func (ue *UserEditor) ManageSubscriptions(writer modules.Writer, keyReader modules.KeyReader) bool {
+	page := 0
+	for {
+		subs, err := ue.db.GetSubscriptions()
+		if err != nil {
+			showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to load accounts: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+		pages := (len(subs) + subscriptionRows - 1) / subscriptionRows
+		if page >= pages && pages > 0 {
+			page = pages - 1
+		}
+		start := page * subscriptionRows
+		end := start + subscriptionRows
+		if end > len(subs) {
+			end = len(subs)
+		}
+		ue.drawSubscriptions(writer, subs[start:end], start, page, pages)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "s":
+			ue.setExpiry(writer, keyReader, subs)
+		case "e":
+			ue.extendLevel(writer, keyReader)
+		case "x":
+			ue.expireLevel(writer, keyReader)
+		case "n", "right", "pagedown":
+			if page < pages-1 {
+				page++
+			}
+		case "p", "left", "pageup":
+			if page > 0 {
+				page--
+			}
+		case "home":
+			page = 0
+		case "end":
+			page = max(pages-1, 0)
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// drawSubscriptions shows a page of accounts and when they expire
+func (ue *UserEditor) drawSubscriptions(writer modules.Writer, subs []database.Subscription, first, page, pages int) {
+	writer.Write([]byte(menu.ClearScreen))
+	header := ue.colorScheme.Colorize(modules.T(writer, "--- Subscriptions ---"), "primary")
+	writer.Write([]byte(ue.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	if len(subs) == 0 {
+		msg := ue.colorScheme.Colorize(modules.T(writer, "There are no accounts below sysop level."), "secondary")
+		writer.Write([]byte(ue.colorScheme.CenterText(msg, modules.Width(writer)) + "\n"))
+	} else {
+		headerLine := fmt.Sprintf("%-4s %-16s %-6s %-11s %-12s", "#", "Username", "Level", "Expires", "")
+		writer.Write([]byte(ue.colorScheme.CenterText(ue.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+		separator := ue.colorScheme.DrawSeparator(len(headerLine), "─")
+		writer.Write([]byte(ue.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+		now := time.Now()
+		for i, sub := range subs {
+			expires, left, color := "Never", "", "text"
+			if sub.ExpiresAt != nil {
+				expires = sub.ExpiresAt.Format(dateFormat)
+				if days := int(math.Ceil(sub.ExpiresAt.Sub(now).Hours() / 24)); days <= 0 {
+					left, color = "Expired", "error"
+				} else {
+					left = fmt.Sprintf("%d day(s)", days)
+				}
+			}
+			line := fmt.Sprintf("%-4d %-16s %-6d %-11s %-12s", first+i+1, ansi.Ellipsize(sub.Username, 16),
+				sub.AccessLevel, expires, left)
+			writer.Write([]byte(ue.colorScheme.CenterText(ue.colorScheme.Colorize(line, color), modules.Width(writer)) + "\n"))
+		}
+		if pages > 1 {
+			status := fmt.Sprintf(modules.T(writer, "Page %d of %d"), page+1, pages)
+			writer.Write([]byte("\n" + ue.colorScheme.CenterText(ue.colorScheme.Colorize(status, "secondary"), modules.Width(writer)) + "\n"))
+		}
+	}
+
+	writer.Write([]byte("\n"))
+	instructions := ue.colorScheme.Colorize(modules.T(writer, "S: Set One  E: Extend a Level  X: Expire a Level  N/P: Page  Q: Quit"), "secondary")
+	writer.Write([]byte(ue.colorScheme.CenterText(instructions, modules.Width(writer)) + "\n"))
+}
+
+// setExpiry asks for an account number and when that account expires
+func (ue *UserEditor) setExpiry(writer modules.Writer, keyReader modules.KeyReader, subs []database.Subscription) {
+	if len(subs) == 0 {
+		return
+	}
+	writer.Write([]byte("\n" + ue.colorScheme.Colorize(modules.T(writer, "Account number: "), "text")))
+	input, err := readLine(keyReader, writer)
+	if err != nil || strings.TrimSpace(input) == "" {
+		return
+	}
+	index, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || index < 1 || index > len(subs) {
+		showMessage(writer, keyReader, ue.colorScheme, "Invalid account number.", "error")
+		return
+	}
+	sub := subs[index-1]
+
+	writer.Write([]byte(ue.colorScheme.Colorize(modules.T(writer, "Expires (YYYY-MM-DD, +days or never): "), "text")))
+	input, err = readLine(keyReader, writer)
+	input = strings.TrimSpace(input)
+	if err != nil || input == "" {
+		return
+	}
+	expires, ok := parseExpiry(input, sub.ExpiresAt)
+	if !ok {
+		showMessage(writer, keyReader, ue.colorScheme, "Enter a date such as 2026-12-31, a number of days such as +30, or never.", "error")
+		return
+	}
+	if err := ue.db.SetExpiry(sub.UserID, expires); err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to set the expiry: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+
+	msg := fmt.Sprintf(modules.T(writer, "%s never expires."), sub.Username)
+	if expires != nil {
+		msg = fmt.Sprintf(modules.T(writer, "%s expires on %s."), sub.Username, expires.Format(dateFormat))
+	}
+	showMessage(writer, keyReader, ue.colorScheme, msg, "success")
+}
+
+// extendLevel adds days to every expiring account at an access level
+func (ue *UserEditor) extendLevel(writer modules.Writer, keyReader modules.KeyReader) {
+	level, ok := ue.readLevel(writer, keyReader)
+	if !ok {
+		return
+	}
+	writer.Write([]byte(ue.colorScheme.Colorize(modules.T(writer, "Days to add: "), "text")))
+	input, err := readLine(keyReader, writer)
+	if err != nil || strings.TrimSpace(input) == "" {
+		return
+	}
+	days, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || days < 1 {
+		showMessage(writer, keyReader, ue.colorScheme, "Enter a number of days to add.", "error")
+		return
+	}
+
+	extended, err := ue.db.ExtendSubscriptions(level, days, time.Now())
+	if err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to extend accounts: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	msg := fmt.Sprintf(modules.T(writer, "Extended %d account(s) at level %d by %d day(s)."), extended, level, days)
+	showMessage(writer, keyReader, ue.colorScheme, msg, "success")
+}
+
+// expireLevel sets every account at an access level to expire on one date
+func (ue *UserEditor) expireLevel(writer modules.Writer, keyReader modules.KeyReader) {
+	level, ok := ue.readLevel(writer, keyReader)
+	if !ok {
+		return
+	}
+	writer.Write([]byte(ue.colorScheme.Colorize(modules.T(writer, "Expire on (YYYY-MM-DD, Enter for now): "), "text")))
+	input, err := readLine(keyReader, writer)
+	if err != nil {
+		return
+	}
+	at := time.Now()
+	if input = strings.TrimSpace(input); input != "" {
+		if at, err = time.ParseInLocation(dateFormat, input, time.Local); err != nil {
+			showMessage(writer, keyReader, ue.colorScheme, "Enter a date such as 2026-12-31.", "error")
+			return
+		}
+	}
+
+	expired, err := ue.db.ExpireSubscriptions(level, at)
+	if err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, modules.T(writer, "Failed to expire accounts: ")+modules.ErrorText(writer, err), "error")
+		return
+	}
+	msg := fmt.Sprintf(modules.T(writer, "%d account(s) at level %d expire on %s."), expired, level, at.Format(dateFormat))
+	showMessage(writer, keyReader, ue.colorScheme, msg, "success")
+}
+
+// readLevel asks for an access level below sysop
+func (ue *UserEditor) readLevel(writer modules.Writer, keyReader modules.KeyReader) (int, bool) {
+	writer.Write([]byte("\n" + ue.colorScheme.Colorize(modules.T(writer, "Access level: "), "text")))
+	input, err := readLine(keyReader, writer)
+	if err != nil || strings.TrimSpace(input) == "" {
+		return 0, false
+	}
+	level, err := parseAccessLevel(input)
+	if err == nil && level == 255 {
+		err = fmt.Errorf("sysop accounts never expire")
+	}
+	if err != nil {
+		showMessage(writer, keyReader, ue.colorScheme, modules.ErrorText(writer, err), "error")
+		return 0, false
+	}
+	return level, true
+}
+
+// parseExpiry reads a date, a number of days to add to the current expiry
+// (or to today, if it has passed or there is none) written as +days, or
+// "never". It returns nil for never.
+func parseExpiry(input string, current *time.Time) (*time.Time, bool) {
+	if strings.EqualFold(input, "never") {
+		return nil, true
+	}
+	if strings.HasPrefix(input, "+") {
+		days, err := strconv.Atoi(input[1:])
+		if err != nil || days < 1 {
+			return nil, false
+		}
+		from := time.Now()
+		if current != nil && current.After(from) {
+			from = *current
+		}
+		expires := from.AddDate(0, 0, days)
+		return &expires, true
+	}
+	expires, err := time.ParseInLocation(dateFormat, input, time.Local)
+	if err != nil {
+		return nil, false
+	}
+	return &expires, true
+}
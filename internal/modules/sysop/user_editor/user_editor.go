@@ -3,6 +3,7 @@ package user_editor
 import (
 	"bbs/internal/components"
 	"bbs/internal/database"
+	"bbs/internal/draw"
 	"bbs/internal/menu"
 )
 
@@ -41,6 +42,10 @@ func (a *ComponentColorSchemeAdapter) DrawSeparator(width int, char string) stri
 	return a.colorScheme.DrawSeparator(width, char)
 }
 
+func (a *ComponentColorSchemeAdapter) DrawBox(box draw.Box) []string {
+	return a.colorScheme.DrawBox(box)
+}
+
 // getComponentAdapter returns a component-compatible color scheme
 func (ue *UserEditor) getComponentAdapter() components.ColorScheme {
 	return &ComponentColorSchemeAdapter{colorScheme: ue.colorScheme}
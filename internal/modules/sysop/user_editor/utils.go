@@ -5,39 +5,20 @@ import (
 	"strconv"
 	"strings"
 
+	"bbs/internal/components"
+	"bbs/internal/input"
 	"bbs/internal/menu"
 	"bbs/internal/modules"
 )
 
 // readLine reads a line of input from the user
 func readLine(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
-	var line strings.Builder
-	for {
-		key, err := keyReader.ReadKey()
-		if err != nil {
-			return "", err
-		}
+	return input.ReadLine(keyReader, writer, 0)
+}
 
-		switch key {
-		case "enter":
-			writer.Write([]byte("\n"))
-			return line.String(), nil
-		case "backspace":
-			if line.Len() > 0 {
-				str := line.String()
-				line.Reset()
-				line.WriteString(str[:len(str)-1])
-				writer.Write([]byte("\b \b")) // Backspace, space, backspace
-			}
-		case "escape", "ctrl+c":
-			return "", fmt.Errorf("cancelled")
-		default:
-			if len(key) == 1 && key[0] >= 32 && key[0] <= 126 { // Printable ASCII
-				line.WriteString(key)
-				writer.Write([]byte(key)) // Echo the character
-			}
-		}
-	}
+// readPassword reads a line, showing * for each character typed
+func readPassword(keyReader modules.KeyReader, writer modules.Writer) (string, error) {
+	return input.ReadPassword(keyReader, writer, 0)
 }
 
 // parseAccessLevel parses an access level string
@@ -52,16 +33,48 @@ func parseAccessLevel(s string) (int, error) {
 	return level, nil
 }
 
+// accessLevels are the levels offered when picking one for an account, from
+// a caller who has just signed up to the sysop
+var accessLevels = []struct {
+	level int
+	name  string
+}{
+	{0, "New"},
+	{10, "User"},
+	{50, "Trusted"},
+	{100, "Co-sysop"},
+	{255, "Sysop"},
+}
+
+// accessLevelOptions lists the access levels to pick from, with current
+// among them in its place if it isn't one of the usual ones
+func accessLevelOptions(current int) []components.Option {
+	var options []components.Option
+	for _, l := range accessLevels {
+		if current >= 0 && current <= l.level {
+			if current < l.level {
+				options = append(options, components.Option{Label: fmt.Sprintf("Level %d", current), Value: strconv.Itoa(current)})
+			}
+			current = -1 // Listed now
+		}
+		options = append(options, components.Option{Label: fmt.Sprintf("%s (%d)", l.name, l.level), Value: strconv.Itoa(l.level)})
+	}
+	return options
+}
+
 // showMessage displays a message and waits for user input
 func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
 	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
 
-	coloredMessage := colorScheme.Colorize(message, messageType)
-	centeredMessage := colorScheme.CenterText(coloredMessage, 79)
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
 	writer.Write([]byte(centeredMessage + "\n\n"))
 
-	prompt := colorScheme.Colorize("Press any key to continue...", "text")
-	centeredPrompt := colorScheme.CenterText(prompt, 79)
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
 	writer.Write([]byte(centeredPrompt))
 
 	keyReader.ReadKey()
@@ -0,0 +1,8 @@
+package taglines
+
+import "embed"
+
+// Help holds the help topics for taglines
+//
+//go:embed help/*.md
+var Help embed.FS
@@ -0,0 +1,150 @@
+package taglines
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// queuePageSize is how many taglines the queue lists at once
+const queuePageSize = 15
+
+// Queue lets the sysop approve or delete the taglines callers send in, and
+// delete approved ones
+type Queue struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	approved    bool // Listing the approved taglines rather than those waiting
+	page        int
+}
+
+// NewQueue creates the sysop's tagline queue screen
+func NewQueue(db *database.DB, colorScheme menu.ColorScheme) *Queue {
+	return &Queue{
+		db:          db,
+		colorScheme: colorScheme,
+	}
+}
+
+// Execute lists the taglines until the sysop quits
+func (q *Queue) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		taglines, err := q.db.GetTaglines(q.approved)
+		if err != nil {
+			showMessage(writer, keyReader, q.colorScheme, modules.T(writer, "Failed to load taglines: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+		pages := (len(taglines) + queuePageSize - 1) / queuePageSize
+		if q.page >= pages {
+			q.page = max(pages-1, 0)
+		}
+
+		q.draw(writer, taglines, pages)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "a":
+			if q.approved {
+				continue
+			}
+			if t := q.pick(writer, keyReader, taglines, "Tagline number to approve: "); t != nil {
+				if err := q.db.ApproveTagline(t.ID); err != nil {
+					showMessage(writer, keyReader, q.colorScheme, modules.ErrorText(writer, err), "error")
+				}
+			}
+		case "d":
+			if t := q.pick(writer, keyReader, taglines, "Tagline number to delete: "); t != nil {
+				if err := q.db.DeleteTagline(t.ID); err != nil {
+					showMessage(writer, keyReader, q.colorScheme, modules.ErrorText(writer, err), "error")
+				}
+			}
+		case "v":
+			q.approved = !q.approved
+			q.page = 0
+		case "n", "pagedown":
+			if q.page+1 < pages {
+				q.page++
+			}
+		case "p", "pageup":
+			if q.page > 0 {
+				q.page--
+			}
+		case "home":
+			q.page = 0
+		case "end":
+			q.page = max(pages-1, 0)
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// draw lists one page of taglines
+func (q *Queue) draw(writer modules.Writer, taglines []database.Tagline, pages int) {
+	writer.Write([]byte(menu.ClearScreen))
+
+	title := modules.T(writer, "--- Tagline Queue: Waiting for Approval ---")
+	if q.approved {
+		title = modules.T(writer, "--- Tagline Queue: Approved ---")
+	}
+	writer.Write([]byte(q.colorScheme.CenterText(q.colorScheme.Colorize(title, "primary"), modules.Width(writer)) + "\n\n"))
+
+	if len(taglines) == 0 {
+		msg := modules.T(writer, "No taglines are waiting for approval.")
+		if q.approved {
+			msg = modules.T(writer, "No taglines have been approved.")
+		}
+		writer.Write([]byte(q.colorScheme.CenterText(q.colorScheme.Colorize(msg, "secondary"), modules.Width(writer)) + "\n"))
+	} else {
+		headerLine := fmt.Sprintf("%-4s %-14s %-56s", "#", "From", "Tagline")
+		writer.Write([]byte(q.colorScheme.CenterText(q.colorScheme.Colorize(headerLine, "accent"), modules.Width(writer)) + "\n"))
+		separator := q.colorScheme.DrawSeparator(len(headerLine), "─")
+		writer.Write([]byte(q.colorScheme.CenterText(separator, modules.Width(writer)) + "\n"))
+
+		start := q.page * queuePageSize
+		end := min(start+queuePageSize, len(taglines))
+		for i := start; i < end; i++ {
+			t := taglines[i]
+			line := fmt.Sprintf("%-4d %-14s %-56s", i+1, ansi.Ellipsize(t.Author, 14), ansi.Ellipsize(t.Text, 56))
+			writer.Write([]byte(q.colorScheme.CenterText(q.colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+		}
+		if pages > 1 {
+			pageLine := fmt.Sprintf("Page %d of %d", q.page+1, pages)
+			writer.Write([]byte("\n" + q.colorScheme.CenterText(q.colorScheme.Colorize(pageLine, "secondary"), modules.Width(writer)) + "\n"))
+		}
+	}
+
+	writer.Write([]byte("\n"))
+	instructions := modules.T(writer, "A: Approve  D: Delete  V: Show Approved  N/P: Page  Q: Quit")
+	if q.approved {
+		instructions = modules.T(writer, "D: Delete  V: Show Waiting  N/P: Page  Q: Quit")
+	}
+	writer.Write([]byte(q.colorScheme.CenterText(q.colorScheme.Colorize(instructions, "secondary"), modules.Width(writer)) + "\n"))
+}
+
+// pick asks for a tagline number and returns that tagline, or nil
+func (q *Queue) pick(writer modules.Writer, keyReader modules.KeyReader, taglines []database.Tagline, prompt string) *database.Tagline {
+	if len(taglines) == 0 {
+		return nil
+	}
+	writer.Write([]byte("\n" + q.colorScheme.Colorize(prompt, "text")))
+	input, err := readLine(keyReader, writer, 5)
+	if err != nil || strings.TrimSpace(input) == "" {
+		return nil
+	}
+	index, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || index < 1 || index > len(taglines) {
+		showMessage(writer, keyReader, q.colorScheme, "Invalid tagline number.", "error")
+		return nil
+	}
+	return &taglines[index-1]
+}
@@ -0,0 +1,126 @@
+package taglines
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+const (
+	minLength        = 5  // Keeps out taglines too short to be worth reading
+	defaultMaxLength = 70 // When the board doesn't set one
+)
+
+// Taglines shows callers a random tagline and lets them send their own in
+type Taglines struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	username    string
+	sysop       bool // The sysop's taglines need no approval
+	maxLength   int
+}
+
+// New creates the taglines screen for a caller
+func New(db *database.DB, colorScheme menu.ColorScheme, username string, accessLevel, maxLength int) *Taglines {
+	if maxLength <= 0 {
+		maxLength = defaultMaxLength
+	}
+	return &Taglines{
+		db:          db,
+		colorScheme: colorScheme,
+		username:    username,
+		sysop:       accessLevel >= 255,
+		maxLength:   maxLength,
+	}
+}
+
+// Execute shows a tagline until the caller quits
+func (t *Taglines) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		tagline, err := t.db.RandomTagline()
+		if err != nil {
+			showMessage(writer, keyReader, t.colorScheme, modules.T(writer, "Failed to load taglines: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+		approved, err := t.db.GetTaglines(true)
+		if err != nil {
+			showMessage(writer, keyReader, t.colorScheme, modules.T(writer, "Failed to load taglines: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+		waiting, err := t.db.CountPendingTaglines(t.username)
+		if err != nil {
+			showMessage(writer, keyReader, t.colorScheme, modules.T(writer, "Failed to load taglines: ")+modules.ErrorText(writer, err), "error")
+			return true
+		}
+
+		writer.Write([]byte(menu.ClearScreen))
+		header := t.colorScheme.Colorize(modules.T(writer, "--- Taglines ---"), "primary")
+		writer.Write([]byte(t.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+		if tagline == "" {
+			msg := t.colorScheme.Colorize(modules.T(writer, "There are no taglines yet. Be the first!"), "secondary")
+			writer.Write([]byte(t.colorScheme.CenterText(msg, modules.Width(writer)) + "\n\n"))
+		} else {
+			writer.Write([]byte(t.colorScheme.CenterText(t.colorScheme.Colorize("... "+tagline, "highlight"), modules.Width(writer)) + "\n\n"))
+		}
+
+		count := fmt.Sprintf("The board has %d tagline(s).", len(approved))
+		if waiting > 0 {
+			count += fmt.Sprintf(" Waiting for the sysop: %d of yours.", waiting)
+		}
+		writer.Write([]byte(t.colorScheme.CenterText(t.colorScheme.Colorize(count, "text"), modules.Width(writer)) + "\n\n"))
+
+		instructions := t.colorScheme.Colorize(modules.T(writer, "N: Send One In  R: Another  Q: Quit"), "secondary")
+		writer.Write([]byte(t.colorScheme.CenterText(instructions, modules.Width(writer)) + "\n"))
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "n":
+			t.send(writer, keyReader)
+		case "r":
+			continue
+		case "q", "quit", "escape":
+			return true
+		}
+	}
+}
+
+// send asks for a tagline and files it for the sysop to approve
+func (t *Taglines) send(writer modules.Writer, keyReader modules.KeyReader) {
+	if t.db.ReadOnly() {
+		showMessage(writer, keyReader, t.colorScheme, "This board is a read-only mirror. Taglines can't be sent in.", "error")
+		return
+	}
+
+	writer.Write([]byte(menu.ShowCursor))
+	defer writer.Write([]byte(menu.HideCursor))
+	writer.Write([]byte("\n" + t.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "Tagline (up to %d characters):"), t.maxLength), "accent") + "\n> "))
+	input, err := readLine(keyReader, writer, t.maxLength)
+	text := strings.TrimSpace(input)
+	if err != nil || text == "" {
+		return
+	}
+	if len(text) < minLength {
+		showMessage(writer, keyReader, t.colorScheme, fmt.Sprintf(modules.T(writer, "Taglines need at least %d characters."), minLength), "error")
+		return
+	}
+
+	err = t.db.AddTagline(&database.Tagline{Text: text, Author: t.username, Approved: t.sysop})
+	switch {
+	case err == database.ErrDuplicateTagline:
+		showMessage(writer, keyReader, t.colorScheme, "That tagline is already on the board.", "error")
+	case err != nil:
+		showMessage(writer, keyReader, t.colorScheme, modules.T(writer, "Failed to send your tagline: ")+modules.ErrorText(writer, err), "error")
+	case t.sysop:
+		showMessage(writer, keyReader, t.colorScheme, "Tagline added.", "success")
+	default:
+		showMessage(writer, keyReader, t.colorScheme, "Thanks! Your tagline will be shown once the sysop approves it.", "success")
+	}
+}
@@ -0,0 +1,8 @@
+package teleconference
+
+import "embed"
+
+// Help holds the help topics for the teleconference
+//
+//go:embed help/*.md
+var Help embed.FS
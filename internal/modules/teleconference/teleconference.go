@@ -0,0 +1,276 @@
+package teleconference
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"bbs/internal/chat"
+	"bbs/internal/config"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// maxLineLength keeps a chat line to one line on most terminals
+const maxLineLength = 200
+
+// prompt is shown in front of the line being typed
+const prompt = "> "
+
+// Teleconference is a line-mode chat room. Incoming lines scroll up above
+// the line the caller is typing, which is redrawn after each one.
+type Teleconference struct {
+	colorScheme menu.ColorScheme
+	hub         *chat.Hub
+	channels    []config.ChatChannel
+	username    string
+	node        int
+	accessLevel int
+	setActivity func(string)
+
+	mu    sync.Mutex // Serializes output from the input loop and incoming lines
+	input []byte
+}
+
+// New creates a teleconference for the caller on node
+func New(colorScheme menu.ColorScheme, hub *chat.Hub, channels []config.ChatChannel, username string, node, accessLevel int, setActivity func(string)) *Teleconference {
+	return &Teleconference{
+		colorScheme: colorScheme,
+		hub:         hub,
+		channels:    channels,
+		username:    username,
+		node:        node,
+		accessLevel: accessLevel,
+		setActivity: setActivity,
+	}
+}
+
+// Execute joins the first open channel and chats until the caller leaves
+func (t *Teleconference) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	available := t.available()
+	if len(available) == 0 {
+		showMessage(writer, keyReader, t.colorScheme, "No teleconference channels are open to you.", "error")
+		return true
+	}
+
+	writer.Write([]byte(menu.ClearContentArea + menu.ShowCursor))
+	header := t.colorScheme.Colorize(modules.T(writer, "--- Teleconference ---"), "primary")
+	writer.Write([]byte(t.colorScheme.CenterText(header, modules.Width(writer)) + "\n"))
+	help := t.colorScheme.Colorize(modules.T(writer, "Type to chat. /help for commands, /quit to leave."), "secondary")
+	writer.Write([]byte(t.colorScheme.CenterText(help, modules.Width(writer)) + "\n\n"))
+
+	member := t.hub.Join(available[0].Name, t.username, t.node)
+	t.setActivity("Chatting in " + available[0].Name)
+
+	// Show incoming lines until Leave closes the queue
+	printed := make(chan struct{})
+	go func() {
+		defer close(printed)
+		for line := range member.Lines {
+			t.print(writer, t.format(line))
+			if line.Kind == chat.Private {
+				modules.Bell(writer, modules.BellMessage)
+			}
+		}
+	}()
+
+	t.printInfo(writer, t.whoLine(member.Channel()))
+	t.redrawInput(writer)
+
+	connected := t.readInput(writer, keyReader, member)
+
+	member.Leave()
+	<-printed
+	writer.Write([]byte(menu.HideCursor))
+	return connected
+}
+
+// readInput handles typing until the caller leaves. It returns false if
+// the connection was lost.
+func (t *Teleconference) readInput(writer modules.Writer, keyReader modules.KeyReader, member *chat.Member) bool {
+	read := input.TextKeys(keyReader)
+	for {
+		key, err := read()
+		if err != nil {
+			return false
+		}
+
+		switch key {
+		case "enter":
+			t.mu.Lock()
+			text := strings.TrimSpace(string(t.input))
+			t.input = t.input[:0]
+			writer.Write([]byte("\r\033[2K" + t.colorScheme.Colorize(prompt, "accent")))
+			t.mu.Unlock()
+
+			if text == "" {
+				continue
+			}
+			if strings.HasPrefix(text, "/") {
+				if !t.command(writer, member, text) {
+					return true
+				}
+				continue
+			}
+			member.Say(text)
+		case "backspace", "\x7f", "\b":
+			t.mu.Lock()
+			if len(t.input) > 0 {
+				t.input = t.input[:len(t.input)-1]
+				writer.Write([]byte("\b \b"))
+			}
+			t.mu.Unlock()
+		case "escape":
+			return true
+		case input.Quit, input.Goodbye:
+			// A reader without ReadTextKey turns q and g into commands; here they are letters
+			t.typeChar(writer, key[0])
+		default:
+			if len(key) == 1 && key[0] >= 32 && key[0] <= 126 {
+				t.typeChar(writer, key[0])
+			}
+		}
+	}
+}
+
+// typeChar adds a character to the line being typed
+func (t *Teleconference) typeChar(writer modules.Writer, c byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.input) < maxLineLength {
+		t.input = append(t.input, c)
+		writer.Write([]byte{c})
+	}
+}
+
+// command runs a slash command. It returns false when the caller leaves.
+func (t *Teleconference) command(writer modules.Writer, member *chat.Member, text string) bool {
+	fields := strings.Fields(text)
+	switch strings.ToLower(fields[0]) {
+	case "/quit", "/q", "/exit":
+		return false
+	case "/who", "/w":
+		t.printInfo(writer, t.whoLine(member.Channel()))
+	case "/msg", "/m":
+		if len(fields) < 3 {
+			t.printInfo(writer, "Usage: /msg <user> <message>")
+			break
+		}
+		message := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text[len(fields[0]):]), fields[1]))
+		if err := member.Whisper(fields[1], message); err != nil {
+			t.printInfo(writer, modules.ErrorText(writer, err))
+		}
+	case "/join", "/j":
+		if len(fields) < 2 {
+			t.printInfo(writer, "Usage: /join <channel>")
+			break
+		}
+		channel, ok := t.find(fields[1])
+		if !ok {
+			t.printInfo(writer, fmt.Sprintf("There is no channel called %s. Try /list.", fields[1]))
+			break
+		}
+		member.Switch(channel.Name)
+		t.setActivity("Chatting in " + channel.Name)
+		t.printInfo(writer, t.whoLine(channel.Name))
+	case "/list", "/l":
+		for _, channel := range t.available() {
+			t.printInfo(writer, fmt.Sprintf("%-12s %2d here  %s", channel.Name, t.hub.Count(channel.Name), channel.Description))
+		}
+	case "/help", "/h", "/?":
+		for _, line := range []string{
+			"/who              Who is in this channel",
+			"/msg <user> <text> Private message to someone in chat",
+			"/join <channel>   Move to another channel",
+			"/list             List channels",
+			"/quit             Leave the teleconference",
+		} {
+			t.printInfo(writer, line)
+		}
+	default:
+		t.printInfo(writer, fmt.Sprintf("Unknown command %s. Try /help.", fields[0]))
+	}
+	return true
+}
+
+// whoLine describes who is in a channel
+func (t *Teleconference) whoLine(channel string) string {
+	var names []string
+	for _, who := range t.hub.Who(channel) {
+		names = append(names, fmt.Sprintf("%s (%d)", who.Name, who.Node))
+	}
+	return fmt.Sprintf("In %s: %s", channel, strings.Join(names, ", "))
+}
+
+// available returns the channels the caller may join
+func (t *Teleconference) available() []config.ChatChannel {
+	var channels []config.ChatChannel
+	for _, channel := range t.channels {
+		if channel.AccessLevel <= t.accessLevel {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// find looks up a channel the caller may join by name
+func (t *Teleconference) find(name string) (config.ChatChannel, bool) {
+	for _, channel := range t.available() {
+		if strings.EqualFold(channel.Name, name) {
+			return channel, true
+		}
+	}
+	return config.ChatChannel{}, false
+}
+
+// format colors a line by its kind
+func (t *Teleconference) format(line chat.Line) string {
+	switch line.Kind {
+	case chat.Notice:
+		return t.colorScheme.Colorize(line.String(), "secondary")
+	case chat.Private, chat.PrivateEcho:
+		return t.colorScheme.Colorize(line.String(), "accent")
+	}
+	if line.From == t.username {
+		return t.colorScheme.Colorize(line.String(), "highlight")
+	}
+	return t.colorScheme.Colorize(line.String(), "text")
+}
+
+// printInfo shows a line only this caller sees
+func (t *Teleconference) printInfo(writer modules.Writer, text string) {
+	t.print(writer, t.colorScheme.Colorize(text, "success"))
+}
+
+// print writes a line above the one being typed, then redraws the input
+func (t *Teleconference) print(writer modules.Writer, line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	writer.Write([]byte("\r\033[2K" + line + "\r\n" + t.colorScheme.Colorize(prompt, "accent") + string(t.input)))
+}
+
+// redrawInput redraws the prompt and the line being typed
+func (t *Teleconference) redrawInput(writer modules.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	writer.Write([]byte("\r\033[2K" + t.colorScheme.Colorize(prompt, "accent") + string(t.input)))
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
+	writer.Write([]byte(centeredMessage + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	keyReader.ReadKey()
+}
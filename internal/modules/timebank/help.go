@@ -0,0 +1,8 @@
+package timebank
+
+import "embed"
+
+// Help holds the help topics for the time bank
+//
+//go:embed help/*.md
+var Help embed.FS
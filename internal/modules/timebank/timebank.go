@@ -0,0 +1,173 @@
+package timebank
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// Account is the caller's time for today and their time bank balance
+type Account struct {
+	Limited bool          // Whether the caller has a daily time limit
+	Left    time.Duration // Time left today
+	Balance int           // Minutes in the bank
+	Max     int           // Most minutes the bank holds, 0 when it is closed
+}
+
+// Bank moves minutes between the caller's time today and their time bank
+type Bank interface {
+	Account() (Account, error)
+	Deposit(minutes int) error
+	Withdraw(minutes int) error
+}
+
+// TimeBank lets callers save unused minutes for another day
+type TimeBank struct {
+	colorScheme menu.ColorScheme
+	bank        Bank
+}
+
+// New creates the time bank screen
+func New(colorScheme menu.ColorScheme, bank Bank) *TimeBank {
+	return &TimeBank{
+		colorScheme: colorScheme,
+		bank:        bank,
+	}
+}
+
+// Execute shows the caller's balance and handles deposits and withdrawals
+func (t *TimeBank) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	for {
+		account, err := t.bank.Account()
+		if err != nil {
+			return showMessage(writer, keyReader, t.colorScheme, "Unable to open the time bank.", "error")
+		}
+		if account.Max == 0 {
+			return showMessage(writer, keyReader, t.colorScheme, "The time bank is closed.", "error")
+		}
+		if !account.Limited {
+			return showMessage(writer, keyReader, t.colorScheme, "You have no daily time limit, so there is nothing to bank.", "text")
+		}
+
+		t.display(writer, account)
+
+		key, err := keyReader.ReadKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "d":
+			if !t.transfer(writer, keyReader, "deposit", t.bank.Deposit) {
+				return false
+			}
+		case "w":
+			if !t.transfer(writer, keyReader, "withdraw", t.bank.Withdraw) {
+				return false
+			}
+		case "quit", "escape", "goodbye":
+			return true
+		}
+	}
+}
+
+// display draws the balance screen
+func (t *TimeBank) display(writer modules.Writer, account Account) {
+	writer.Write([]byte(menu.ClearContentArea))
+	header := t.colorScheme.Colorize(modules.T(writer, "--- Time Bank ---"), "primary")
+	writer.Write([]byte(t.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	left := int(account.Left.Minutes())
+	if left < 0 {
+		left = 0
+	}
+	lines := []string{
+		"Time left today:  " + minutes(left),
+		fmt.Sprintf("Time in the bank: %d of %s", account.Balance, minutes(account.Max)),
+	}
+	for _, line := range lines {
+		writer.Write([]byte(t.colorScheme.CenterText(t.colorScheme.Colorize(line, "text"), modules.Width(writer)) + "\n"))
+	}
+
+	writer.Write([]byte("\n"))
+	help := t.colorScheme.Colorize(modules.T(writer, "D: Deposit  W: Withdraw  Q: Quit"), "accent")
+	writer.Write([]byte(t.colorScheme.CenterText(help, modules.Width(writer)) + "\n"))
+}
+
+// transfer asks how many minutes to move and moves them. It returns false
+// if the connection was lost.
+func (t *TimeBank) transfer(writer modules.Writer, keyReader modules.KeyReader, verb string, move func(minutes int) error) bool {
+	writer.Write([]byte("\n" + menu.ShowCursor))
+	writer.Write([]byte(t.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "Minutes to %s (Enter to cancel): "), verb), "text")))
+	input, err := readLine(keyReader, writer, 4)
+	writer.Write([]byte(menu.HideCursor))
+	if err != nil {
+		return !errors.Is(err, errDisconnected)
+	}
+	if strings.TrimSpace(input) == "" {
+		return true
+	}
+
+	minutes, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || minutes <= 0 {
+		return showMessage(writer, keyReader, t.colorScheme, "Please enter a number of minutes.", "error")
+	}
+	if err := move(minutes); err != nil {
+		return showMessage(writer, keyReader, t.colorScheme, capitalize(modules.ErrorText(writer, err))+".", "error")
+	}
+	return true
+}
+
+// minutes formats a number of minutes
+func minutes(n int) string {
+	if n == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", n)
+}
+
+// capitalize upper-cases the first letter of an error message for display
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// errDisconnected is returned by readLine when the caller hangs up
+var errDisconnected = errors.New("disconnected")
+
+// readLine reads a number of at most max digits from the user
+func readLine(keyReader modules.KeyReader, writer modules.Writer, max int) (string, error) {
+	line, err := input.ReadLineFunc(keyReader, writer, max, input.Digit)
+	if err != nil && !errors.Is(err, input.ErrCancelled) {
+		return "", errDisconnected
+	}
+	return line, err
+}
+
+// showMessage displays a message and waits for a key. It returns false if
+// the connection was lost.
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) bool {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
+	writer.Write([]byte(centeredMessage + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	_, err := keyReader.ReadKey()
+	return err == nil
+}
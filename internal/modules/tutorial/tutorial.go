@@ -0,0 +1,374 @@
+package tutorial
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bbs/internal/database"
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// ctrlS is Ctrl+S as the session key reader delivers it; the editor saves
+// with it
+const ctrlS = "\x13"
+
+// Tutorial walks a caller through the keys they need: moving the lightbar,
+// hotkeys, paging through text, writing, and getting help. Progress is saved
+// after each lesson so a caller who leaves can pick up where they stopped.
+type Tutorial struct {
+	db          *database.DB
+	colorScheme menu.ColorScheme
+	userID      int
+	current     int // Index of the lesson being shown
+}
+
+// lesson is one step of the tutorial; it returns false if the caller left
+type lesson func(writer modules.Writer, keyReader modules.KeyReader) bool
+
+// lessons are the tutorial's steps in order
+func (t *Tutorial) lessons() []lesson {
+	return []lesson{t.lightbar, t.hotkeys, t.reading, t.writing, t.help}
+}
+
+// practiceMenu is the pretend menu the navigation lessons use
+var practiceMenu = []string{"Lighthouse", "Harbour", "Fish Market", "Boatyard"}
+
+// NewTutorial creates the tutorial for a user
+func NewTutorial(db *database.DB, colorScheme menu.ColorScheme, userID int) *Tutorial {
+	return &Tutorial{
+		db:          db,
+		colorScheme: colorScheme,
+		userID:      userID,
+	}
+}
+
+// Offer asks a caller on their first call whether to take the tutorial now,
+// later, or never
+func (t *Tutorial) Offer(writer modules.Writer, keyReader modules.KeyReader) bool {
+	writer.Write([]byte(menu.ClearScreen))
+	header := t.colorScheme.Colorize(modules.T(writer, "--- Welcome Aboard ---"), "primary")
+	writer.Write([]byte(t.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	t.say(writer, "New to the board? A short tour teaches the keys you need to get around:",
+		"moving through menus, hotkeys, reading, writing and getting help.",
+		"",
+		"You can take it any time from the Help menu.")
+	writer.Write([]byte("\n" + t.colorScheme.Colorize(modules.T(writer, "Take the tour now? Y: Yes  N: Not now  S: Skip for good "), "accent")))
+
+	key, err := keyReader.ReadKey()
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(key) {
+	case "y", "enter":
+		return t.Execute(writer, keyReader)
+	case "s":
+		if !t.db.ReadOnly() {
+			t.db.DismissTutorial(t.userID)
+		}
+	}
+	return true
+}
+
+// Execute runs the lessons, resuming after the last one finished if the
+// caller left part way through
+func (t *Tutorial) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	lessons := t.lessons()
+	start := 0
+	if p, err := t.db.GetTutorialProgress(t.userID); err == nil && p != nil && p.CompletedAt == nil && p.Step < len(lessons) {
+		start = p.Step
+	}
+
+	for i := start; i < len(lessons); i++ {
+		t.current = i
+		if !lessons[i](writer, keyReader) {
+			showMessage(writer, keyReader, t.colorScheme, "Tour paused. Pick it up again from the Help menu.", "text")
+			return true
+		}
+		if !t.db.ReadOnly() {
+			if i == len(lessons)-1 {
+				t.db.CompleteTutorial(t.userID, len(lessons), time.Now())
+			} else {
+				t.db.SaveTutorialStep(t.userID, i+1)
+			}
+		}
+	}
+
+	writer.Write([]byte(menu.ClearScreen))
+	header := t.colorScheme.Colorize(modules.T(writer, "--- Tour Complete ---"), "primary")
+	writer.Write([]byte(t.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+	t.say(writer, "That's everything you need to get around. To recap:", "")
+	t.keys(writer,
+		"Up/Down", "move the highlight",
+		"Enter", "choose the highlighted item",
+		"Letters", "choose an item straight away",
+		"Space/B", "next and previous page",
+		"Ctrl+S", "save in the editor (Ctrl+X aborts)",
+		"? or F1", "help for the highlighted item",
+		"Q", "back to the previous menu",
+		"G", "log off")
+	writer.Write([]byte("\n" + t.colorScheme.Colorize(modules.T(writer, "Press any key to start exploring..."), "accent")))
+	keyReader.ReadKey()
+	return true
+}
+
+// start clears the screen for the current lesson and explains it
+func (t *Tutorial) start(writer modules.Writer, title string, lines ...string) {
+	writer.Write([]byte(menu.ClearScreen))
+	header := fmt.Sprintf("--- Lesson %d of %d: %s ---", t.current+1, len(t.lessons()), title)
+	writer.Write([]byte(t.colorScheme.CenterText(t.colorScheme.Colorize(header, "primary"), modules.Width(writer)) + "\n\n"))
+	t.say(writer, lines...)
+	writer.Write([]byte(t.colorScheme.Colorize(modules.T(writer, "(Esc pauses the tour.)"), "secondary") + "\n\n"))
+}
+
+// say writes lines of explanation
+func (t *Tutorial) say(writer modules.Writer, lines ...string) {
+	for _, l := range lines {
+		writer.Write([]byte(t.colorScheme.Colorize(l, "text") + "\n"))
+	}
+}
+
+// keys writes a table of keys and what they do
+func (t *Tutorial) keys(writer modules.Writer, pairs ...string) {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		writer.Write([]byte("  " + t.colorScheme.Colorize(fmt.Sprintf("%-9s", pairs[i]), "accent") +
+			t.colorScheme.Colorize(pairs[i+1], "text") + "\n"))
+	}
+}
+
+// hint replaces the hint line under a practice screen
+func (t *Tutorial) hint(writer modules.Writer, text, style string) {
+	writer.Write([]byte("\r\033[2K" + t.colorScheme.Colorize(text, style)))
+}
+
+// drawMenu draws the practice menu with the selected item highlighted and,
+// with hotkeys, the first letter of each item picked out
+func (t *Tutorial) drawMenu(writer modules.Writer, selected int, hotkeys bool) {
+	for i, item := range practiceMenu {
+		label := item
+		if hotkeys {
+			label = t.colorScheme.Colorize(item[:1], "accent") + item[1:]
+		}
+		if i == selected {
+			label = item
+		}
+		writer.Write([]byte("    " + t.colorScheme.HighlightSelection(" "+label+" ", i == selected, 16) + "\n"))
+	}
+	writer.Write([]byte("\n"))
+}
+
+// lightbar teaches moving the highlight with the arrow keys and choosing
+// with Enter
+func (t *Tutorial) lightbar(writer modules.Writer, keyReader modules.KeyReader) bool {
+	const target = 2
+	selected := 0
+	hint := modules.T(writer, "Move to Fish Market and press Enter.")
+	style := "secondary"
+	for {
+		t.start(writer, "Moving the Highlight",
+			"Menus have a highlight bar. The Up and Down arrows move it, and Enter",
+			"chooses the highlighted item.",
+			"")
+		t.drawMenu(writer, selected, false)
+		t.hint(writer, hint, style)
+
+		key, err := keyReader.ReadKey()
+		if err != nil || key == "escape" {
+			return false
+		}
+		switch key {
+		case "up":
+			selected = (selected + len(practiceMenu) - 1) % len(practiceMenu)
+			hint, style = "Move to Fish Market and press Enter.", "secondary"
+		case "down":
+			selected = (selected + 1) % len(practiceMenu)
+			hint, style = "Move to Fish Market and press Enter.", "secondary"
+		case "enter":
+			if selected == target {
+				return t.passed(writer, keyReader, "You chose Fish Market. That's all there is to menus.")
+			}
+			hint, style = fmt.Sprintf("That's %s. Use the arrows to reach Fish Market first.", practiceMenu[selected]), "error"
+		default:
+			hint, style = "Use the Up and Down arrow keys, then Enter.", "error"
+		}
+	}
+}
+
+// hotkeys teaches choosing an item by its highlighted letter
+func (t *Tutorial) hotkeys(writer modules.Writer, keyReader modules.KeyReader) bool {
+	hint, style := "Press B to go straight to the Boatyard.", "secondary"
+	for {
+		t.start(writer, "Hotkeys",
+			"Each item has a highlighted letter. Pressing it chooses the item straight",
+			"away, without moving the highlight. On the real menus Q goes back and",
+			"G logs off.",
+			"")
+		t.drawMenu(writer, -1, true)
+		t.hint(writer, hint, style)
+
+		key, err := keyReader.ReadKey()
+		if err != nil || key == "escape" {
+			return false
+		}
+		if strings.ToLower(key) == "b" {
+			return t.passed(writer, keyReader, "Straight to the Boatyard. Hotkeys save a lot of arrow presses.")
+		}
+		hint, style = "Not quite. Press the letter B.", "error"
+		for _, item := range practiceMenu {
+			if len(key) == 1 && strings.EqualFold(key, item[:1]) {
+				hint = fmt.Sprintf(modules.T(writer, "%s picks %s. Press B for the Boatyard."), strings.ToUpper(key), item)
+			}
+		}
+	}
+}
+
+// readingPages is the practice text for the reading lesson
+var readingPages = [][]string{
+	{
+		"HARBOUR NOTICE",
+		"",
+		"The harbour master reminds all skippers that the north mooring",
+		"is closed for dredging this week. Please use the visitor pontoon",
+		"and check in at the office on arrival.",
+	},
+	{
+		"HARBOUR NOTICE (continued)",
+		"",
+		"Fuel is available from 8am to 6pm. Out of hours, call the",
+		"number on the office door. Fair winds!",
+	},
+}
+
+// reading teaches paging forwards and back through long text
+func (t *Tutorial) reading(writer modules.Writer, keyReader modules.KeyReader) bool {
+	// What the caller is asked to press next, in order
+	steps := []struct {
+		page int
+		back bool
+		hint string
+	}{
+		{0, false, "Press Space for the next page."},
+		{1, true, "Now press B to go back a page."},
+		{0, false, "Press Space to go forward again."},
+		{1, false, "That's the last page. Press Space to finish."},
+	}
+
+	step := 0
+	style := "secondary"
+	wrong := ""
+	for step < len(steps) {
+		s := steps[step]
+		t.start(writer, "Reading",
+			"Bulletins, mail and posts that don't fit on one screen are shown a page",
+			"at a time. Space or Enter shows the next page and B goes back.",
+			"")
+		for _, l := range readingPages[s.page] {
+			writer.Write([]byte("    " + t.colorScheme.Colorize(l, "highlight") + "\n"))
+		}
+		writer.Write([]byte("\n" + t.colorScheme.Colorize(fmt.Sprintf(modules.T(writer, "    Page %d of %d"), s.page+1, len(readingPages)), "secondary") + "\n\n"))
+		if wrong != "" {
+			t.hint(writer, wrong, "error")
+		} else {
+			t.hint(writer, s.hint, style)
+		}
+
+		key, err := keyReader.ReadKey()
+		if err != nil || key == "escape" {
+			return false
+		}
+		next := key == " " || key == "enter" || key == "down"
+		back := strings.ToLower(key) == "b" || key == "up"
+		if (s.back && back) || (!s.back && next) {
+			step++
+			wrong = ""
+			continue
+		}
+		wrong = "Not that key. " + s.hint
+	}
+	return t.passed(writer, keyReader, "You can page through anything on the board.")
+}
+
+// writing teaches typing a line and saving in the editor
+func (t *Tutorial) writing(writer modules.Writer, keyReader modules.KeyReader) bool {
+	t.start(writer, "Writing",
+		"Prompts such as a subject line take a line of text: type it, use",
+		"Backspace to fix mistakes, and press Enter when you're done.",
+		"")
+	var subject string
+	for subject == "" {
+		writer.Write([]byte(t.colorScheme.Colorize(modules.T(writer, "Subject for a practice post: "), "accent")))
+		line, err := readLine(keyReader, writer, 40)
+		if err != nil {
+			return false
+		}
+		subject = strings.TrimSpace(line)
+	}
+
+	hint, style := "Press Ctrl+S to save your practice post.", "secondary"
+	for {
+		t.start(writer, "Writing",
+			"Posts are written in a full-screen editor. Type as you would anywhere;",
+			"Ctrl+S saves the post, Ctrl+X throws it away, and Ctrl+Y deletes a line.",
+			"")
+		writer.Write([]byte(t.colorScheme.Colorize(modules.T(writer, "    Subject: "), "accent") + t.colorScheme.Colorize(subject, "text") + "\n"))
+		writer.Write([]byte(t.colorScheme.Colorize(modules.T(writer, "    Hello from my first call!"), "text") + "\n\n"))
+		t.hint(writer, hint, style)
+
+		key, err := keyReader.ReadKey()
+		if err != nil || key == "escape" {
+			return false
+		}
+		if key == ctrlS {
+			return t.passed(writer, keyReader, fmt.Sprintf("Saved \"%s\". Nothing was posted; this was only practice.", subject))
+		}
+		hint, style = "Hold Ctrl and press S.", "error"
+	}
+}
+
+// help teaches asking for help
+func (t *Tutorial) help(writer modules.Writer, keyReader modules.KeyReader) bool {
+	hint, style := "Press ? now.", "secondary"
+	for {
+		t.start(writer, "Getting Help",
+			"On any menu, ? or F1 explains the highlighted item. The Help menu lists",
+			"every topic and can search them, and it's where to find this tour again.",
+			"")
+		t.hint(writer, hint, style)
+
+		key, err := keyReader.ReadKey()
+		if err != nil || key == "escape" {
+			return false
+		}
+		if key == "?" || key == "f1" {
+			return t.passed(writer, keyReader, "On a menu, that would have shown help for the highlighted item.")
+		}
+		hint, style = "Press the ? key (Shift and /), or F1.", "error"
+	}
+}
+
+// passed congratulates the caller on a lesson and waits for a key
+func (t *Tutorial) passed(writer modules.Writer, keyReader modules.KeyReader, text string) bool {
+	writer.Write([]byte("\r\033[2K" + t.colorScheme.Colorize(modules.T(writer, "Well done! "), "success") + t.colorScheme.Colorize(text, "text") + "\n\n"))
+	writer.Write([]byte(t.colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "secondary")))
+	key, err := keyReader.ReadKey()
+	return err == nil && key != "escape"
+}
+
+// readLine reads a line of at most max characters, echoing printable ones
+func readLine(keyReader modules.KeyReader, writer modules.Writer, max int) (string, error) {
+	return input.ReadLine(keyReader, writer, max)
+}
+
+// showMessage displays a message and waits for a key
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+	writer.Write([]byte(colorScheme.CenterText(colorScheme.Colorize(modules.T(writer, message), messageType), modules.Width(writer)) + "\n\n"))
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	writer.Write([]byte(colorScheme.CenterText(prompt, modules.Width(writer))))
+	keyReader.ReadKey()
+}
@@ -0,0 +1,8 @@
+package usage
+
+import "embed"
+
+// Help holds the help topics for the board activity graphs
+//
+//go:embed help/*.md
+var Help embed.FS
@@ -0,0 +1,182 @@
+package usage
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// Days is how far back the graphs look
+const Days = 7
+
+// chartHeight is the number of rows in the calls by hour chart
+const chartHeight = 6
+
+// barWidth is the longest bar in the posts per day chart
+const barWidth = 50
+
+// Loader computes fresh activity statistics
+type Loader func(now time.Time) (*database.ActivityStats, error)
+
+// Cache keeps computed statistics for a while so every caller opening the
+// screen doesn't rescan the logs
+type Cache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	load     Loader
+	stats    *database.ActivityStats
+	loadedAt time.Time
+}
+
+// NewCache creates a cache that reloads statistics older than ttl
+func NewCache(ttl time.Duration, load Loader) *Cache {
+	return &Cache{ttl: ttl, load: load}
+}
+
+// Get returns statistics and when they were computed
+func (c *Cache) Get(now time.Time) (*database.ActivityStats, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stats == nil || now.Sub(c.loadedAt) >= c.ttl {
+		stats, err := c.load(now)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		c.stats = stats
+		c.loadedAt = now
+	}
+	return c.stats, c.loadedAt, nil
+}
+
+// Module shows graphs of recent board activity
+type Module struct {
+	colorScheme menu.ColorScheme
+	cache       *Cache
+}
+
+// NewModule creates the board activity screen
+func NewModule(colorScheme menu.ColorScheme, cache *Cache) *Module {
+	return &Module{
+		colorScheme: colorScheme,
+		cache:       cache,
+	}
+}
+
+// Execute shows the graphs until the user leaves
+func (m *Module) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	stats, loadedAt, err := m.cache.Get(time.Now())
+	if err != nil {
+		showMessage(writer, keyReader, m.colorScheme, "Board activity is not available right now.", "error")
+		return true
+	}
+
+	m.render(writer, stats, loadedAt)
+	if _, err := keyReader.ReadKey(); err != nil {
+		return false
+	}
+	return true
+}
+
+// render draws both charts
+func (m *Module) render(writer modules.Writer, stats *database.ActivityStats, loadedAt time.Time) {
+	writer.Write([]byte(menu.ClearContentArea + menu.HideCursor))
+
+	header := m.colorScheme.Colorize(modules.T(writer, "--- Board Activity ---"), "primary")
+	writer.Write([]byte(m.colorScheme.CenterText(header, modules.Width(writer)) + "\n\n"))
+
+	m.renderCalls(writer, stats.CallsByHour)
+	writer.Write([]byte("\n"))
+	m.renderPosts(writer, stats.PostsByDay)
+
+	footer := fmt.Sprintf(modules.T(writer, "As of %s. Press any key to return."), loadedAt.Format("15:04"))
+	writer.Write([]byte("\n" + m.colorScheme.CenterText(m.colorScheme.Colorize(footer, "secondary"), modules.Width(writer))))
+}
+
+// renderCalls draws a column chart of calls by hour of day, three
+// characters per hour
+func (m *Module) renderCalls(writer modules.Writer, calls [24]int) {
+	peak, total := 0, 0
+	for _, n := range calls {
+		peak = max(peak, n)
+		total += n
+	}
+
+	title := fmt.Sprintf(modules.T(writer, "Calls by hour, last %d days (%d total)"), Days, total)
+	writer.Write([]byte(m.indent(m.colorScheme.Colorize(title, "accent")) + "\n"))
+
+	for row := chartHeight; row >= 1; row-- {
+		var line strings.Builder
+		for _, n := range calls {
+			// A column reaches this row if it is at least this tall, rounding up
+			// so any calls at all show
+			if peak > 0 && n*chartHeight > (row-1)*peak {
+				line.WriteString("██ ")
+			} else {
+				line.WriteString("   ")
+			}
+		}
+		label := "    "
+		if row == chartHeight {
+			label = fmt.Sprintf("%3d ", peak)
+		}
+		writer.Write([]byte(m.indent(m.colorScheme.Colorize(label, "text")+m.colorScheme.Colorize(line.String(), "primary")) + "\n"))
+	}
+
+	var axis strings.Builder
+	for hour := 0; hour < 24; hour += 3 {
+		axis.WriteString(fmt.Sprintf("%-9s", fmt.Sprintf("%02d", hour)))
+	}
+	writer.Write([]byte(m.indent(m.colorScheme.Colorize("    "+axis.String(), "secondary")) + "\n"))
+}
+
+// renderPosts draws a bar per day of posts and replies
+func (m *Module) renderPosts(writer modules.Writer, days []database.DayCount) {
+	peak, total := 0, 0
+	for _, day := range days {
+		peak = max(peak, day.Count)
+		total += day.Count
+	}
+
+	title := fmt.Sprintf(modules.T(writer, "Posts per day (%d total)"), total)
+	writer.Write([]byte(m.indent(m.colorScheme.Colorize(title, "accent")) + "\n"))
+
+	for _, day := range days {
+		width := 0
+		if peak > 0 {
+			width = (day.Count*barWidth + peak - 1) / peak
+		}
+		label := day.Day.Format("Mon 01/02")
+		bar := m.colorScheme.Colorize(strings.Repeat("█", width), "primary")
+		line := fmt.Sprintf("%s %s %d", m.colorScheme.Colorize(label, "text"), bar, day.Count)
+		writer.Write([]byte(m.indent(line) + "\n"))
+	}
+}
+
+// indent left-aligns chart lines in the same column as each other
+func (m *Module) indent(line string) string {
+	return "    " + line
+}
+
+// showMessage displays a message and waits for user input
+func showMessage(writer modules.Writer, keyReader modules.KeyReader, colorScheme menu.ColorScheme, message, messageType string) {
+	writer.Write([]byte(menu.ClearScreen))
+	if messageType == "error" {
+		modules.Bell(writer, modules.BellError)
+	}
+
+	coloredMessage := colorScheme.Colorize(modules.T(writer, message), messageType)
+	centeredMessage := colorScheme.CenterText(coloredMessage, modules.Width(writer))
+	writer.Write([]byte(centeredMessage + "\n\n"))
+
+	prompt := colorScheme.Colorize(modules.T(writer, "Press any key to continue..."), "text")
+	centeredPrompt := colorScheme.CenterText(prompt, modules.Width(writer))
+	writer.Write([]byte(centeredPrompt))
+
+	keyReader.ReadKey()
+}
@@ -0,0 +1,173 @@
+// Package notify decides how callers hear about things that concern them,
+// and builds and sends the daily email digest for those they asked to have
+// emailed
+package notify
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+// Kind is a kind of thing a caller can be told about
+type Kind string
+
+const (
+	Mail       Kind = "mail"       // Private mail sent to them
+	Replies    Kind = "replies"    // A reply to one of their posts or replies
+	Messages   Kind = "messages"   // An instant message from another caller
+	Broadcasts Kind = "broadcasts" // Login announcements, scheduled event warnings and sysop broadcasts
+)
+
+// Kinds lists every kind, in the order settings show them
+var Kinds = []Kind{Mail, Replies, Messages, Broadcasts}
+
+// Ways a caller can be told
+const (
+	Board = "board" // A one-line message while they are online
+	Email = "email" // An entry in the daily digest
+	Off   = "off"   // Not at all
+)
+
+// Label names a kind for callers
+func (k Kind) Label() string {
+	switch k {
+	case Mail:
+		return "New mail"
+	case Replies:
+		return "Replies to my posts"
+	case Messages:
+		return "Instant messages"
+	case Broadcasts:
+		return "Announcements"
+	}
+	return string(k)
+}
+
+// Preferences is how a caller wants to hear about each kind
+type Preferences map[Kind]string
+
+// Defaults returns the board's preferences
+func Defaults(cfg config.NotifyConfig) Preferences {
+	return Preferences{
+		Mail:       cfg.Mail,
+		Replies:    cfg.Replies,
+		Messages:   cfg.Messages,
+		Broadcasts: cfg.Broadcasts,
+	}
+}
+
+// With returns a copy of p with a caller's saved choices over it. Kinds
+// and deliveries it doesn't know are left out.
+func (p Preferences) With(saved map[string]string) Preferences {
+	merged := make(Preferences, len(Kinds))
+	for _, kind := range Kinds {
+		merged[kind] = p[kind]
+		if delivery, ok := saved[string(kind)]; ok && valid(delivery) {
+			merged[kind] = delivery
+		}
+	}
+	return merged
+}
+
+// Delivery returns how kind is delivered. Anything not understood is shown
+// on the board, as is email when the board can't send it.
+func (p Preferences) Delivery(kind Kind, emailEnabled bool) string {
+	switch delivery := p[kind]; {
+	case delivery == Off:
+		return Off
+	case delivery == Email && emailEnabled:
+		return Email
+	}
+	return Board
+}
+
+// valid reports whether delivery is a way a caller can be told
+func valid(delivery string) bool {
+	return delivery == Board || delivery == Email || delivery == Off
+}
+
+// Digest builds the email digest of a caller's queued notifications,
+// grouped by kind, oldest first. With a replyDomain, the board takes mail
+// for its callers and the digest says how to answer mail by email, with the
+// caller's mail key, which tells the board the answer is from them.
+func Digest(system, replyDomain, replyKey string, queued []database.QueuedNotification) (subject, body string) {
+	subject = fmt.Sprintf("%s: %d new since your last digest", system, len(queued))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hello %s,\n\nHere is what happened on %s since your last digest.\n", queued[0].Username, system)
+	for _, kind := range Kinds {
+		heading := false
+		for _, n := range queued {
+			if Kind(n.Kind) != kind {
+				continue
+			}
+			if !heading {
+				fmt.Fprintf(&b, "\n%s\n%s\n", kind.Label(), strings.Repeat("-", len(kind.Label())))
+				heading = true
+			}
+			fmt.Fprintf(&b, "%s  %s\n", n.CreatedAt.Format("Jan 2 15:04"), n.Text)
+		}
+	}
+	if replyDomain != "" && replyKey != "" {
+		fmt.Fprintf(&b, "\nTo answer mail, email the sender at their name+%s@%s.\n", replyKey, replyDomain)
+		fmt.Fprintf(&b, "Keep %s to yourself: it is how the board knows the mail is from you.\n", replyKey)
+	}
+	b.WriteString("\nYou can choose what is emailed to you under User Settings > Notifications.\n")
+	return subject, b.String()
+}
+
+// Mailer sends email through the board's mail server
+type Mailer struct {
+	addr     string
+	username string
+	password string
+	from     string
+}
+
+// NewMailer creates a mailer for the digest settings, or returns nil when
+// no mail server is set
+func NewMailer(cfg config.DigestConfig) *Mailer {
+	if cfg.SMTP == "" {
+		return nil
+	}
+	return &Mailer{addr: cfg.SMTP, username: cfg.Username, password: cfg.Password, from: cfg.From}
+}
+
+// Send emails a plain text message to one address
+func (m *Mailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		host, _, err := net.SplitHostPort(m.addr)
+		if err != nil {
+			return fmt.Errorf("invalid mail server %q: %w", m.addr, err)
+		}
+		auth = smtp.PlainAuth("", m.username, m.password, host)
+	}
+	if err := smtp.SendMail(m.addr, auth, m.from, []string{to}, Message(m.from, to, subject, body, time.Now())); err != nil {
+		return fmt.Errorf("failed to email %s: %w", to, err)
+	}
+	return nil
+}
+
+// Message formats a plain text email. Line breaks in the headers are
+// dropped so a subject can't add headers of its own.
+func Message(from, to, subject, body string, date time.Time) []byte {
+	header := func(s string) string {
+		return strings.NewReplacer("\r", "", "\n", " ").Replace(s)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", header(from))
+	fmt.Fprintf(&b, "To: %s\r\n", header(to))
+	fmt.Fprintf(&b, "Subject: %s\r\n", header(subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
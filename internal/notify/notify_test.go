@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+func TestPreferences(t *testing.T) {
+	defaults := Defaults(config.NotifyConfig{Mail: Email, Replies: Board, Messages: Board, Broadcasts: "loud"})
+	prefs := defaults.With(map[string]string{"messages": Off, "replies": "pigeon", "unknown": Off})
+
+	tests := []struct {
+		kind  Kind
+		email bool
+		want  string
+	}{
+		{Mail, true, Email},
+		{Mail, false, Board}, // No mail server
+		{Replies, true, Board},
+		{Messages, true, Off},
+		{Broadcasts, true, Board},
+	}
+	for _, tt := range tests {
+		if got := prefs.Delivery(tt.kind, tt.email); got != tt.want {
+			t.Errorf("Delivery(%s, %v) = %q, want %q", tt.kind, tt.email, got, tt.want)
+		}
+	}
+	if defaults[Messages] != Board {
+		t.Errorf("With changed the defaults: %v", defaults)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	queued := []database.QueuedNotification{
+		{Username: "alice", Kind: "replies", Text: "bob replied to Modems", CreatedAt: at},
+		{Username: "alice", Kind: "mail", Text: "New mail from carol: Lunch", CreatedAt: at},
+		{Username: "alice", Kind: "replies", Text: "dave replied to Modems", CreatedAt: at.Add(time.Hour)},
+	}
+	subject, body := Digest("Coastline BBS", "", "", queued)
+	if subject != "Coastline BBS: 3 new since your last digest" {
+		t.Errorf("subject = %q", subject)
+	}
+	mail := strings.Index(body, "New mail\n--------\nMar 1 09:30  New mail from carol: Lunch\n")
+	replies := strings.Index(body, "Replies to my posts\n-------------------\nMar 1 09:30  bob replied to Modems\nMar 1 10:30  dave replied to Modems\n")
+	if mail < 0 || replies < 0 || mail > replies {
+		t.Errorf("digest should list mail, then replies in order:\n%s", body)
+	}
+	if strings.Contains(body, "To answer mail") {
+		t.Errorf("digest offers email replies without a mail gateway:\n%s", body)
+	}
+
+	if _, body := Digest("Coastline BBS", "bbs.example.org", "k3y", queued); !strings.Contains(body, "their name+k3y@bbs.example.org") {
+		t.Errorf("digest doesn't say how to answer mail:\n%s", body)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	msg := string(Message("bbs@example.com", "alice@example.com", "Hi\r\nBcc: eve@example.com", "one\ntwo", at))
+	for _, want := range []string{
+		"From: bbs@example.com\r\n",
+		"To: alice@example.com\r\n",
+		"Subject: Hi Bcc: eve@example.com\r\n",
+		"Date: Sun, 01 Mar 2026 09:30:00 +0000\r\n",
+		"\r\n\r\none\r\ntwo",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message is missing %q:\n%s", want, msg)
+		}
+	}
+}
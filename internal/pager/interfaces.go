@@ -5,7 +5,8 @@ type Writer interface {
 	Write([]byte) (int, error)
 }
 
-// KeyReader interface for reading user input
+// KeyReader interface for reading user input, with keys named as the
+// input package names them
 type KeyReader interface {
 	ReadKey() (string, error)
 }
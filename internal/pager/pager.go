@@ -3,6 +3,9 @@ package pager
 import (
 	"fmt"
 	"strings"
+
+	"bbs/internal/ansi"
+	"bbs/internal/input"
 )
 
 const (
@@ -32,6 +35,25 @@ func NewPager(writer Writer, keyReader KeyReader, terminalSizer TerminalSizer, c
 	}
 }
 
+// width returns the usable line width, one column short of the terminal so
+// centered lines never trigger an automatic wrap
+func (p *Pager) width() int {
+	w, _, err := p.terminalSizer.Size()
+	if err != nil || w < 2 {
+		return 79
+	}
+	return w - 1
+}
+
+// fit cuts a line wider than the screen, so it can't wrap onto the lines
+// below, and ends the colors it had set
+func fit(line string, width int) string {
+	if ansi.Width(line) <= width {
+		return line
+	}
+	return ansi.Truncate(line, width) + "\033[0m"
+}
+
 // WithStatusBar adds status bar control to the pager
 func (p *Pager) WithStatusBar(mgr StatusBarManager) *Pager {
 	p.statusBarMgr = mgr
@@ -86,10 +108,10 @@ func (p *Pager) Display(lines []string, title string) error {
 
 		// Handle navigation
 		switch key {
-		case "q", "Q":
+		case "q", "Q", input.Quit, input.Escape:
 			// Quit
 			return nil
-		case " ", "enter", "down":
+		case " ", input.Enter, input.Down, input.PageDown:
 			// Next page (or quit if on last page)
 			if currentPage < totalPages-1 {
 				currentPage++
@@ -97,11 +119,15 @@ func (p *Pager) Display(lines []string, title string) error {
 				// On last page, quit
 				return nil
 			}
-		case "b", "B", "up":
+		case "b", "B", input.Up, input.PageUp:
 			// Previous page
 			if currentPage > 0 {
 				currentPage--
 			}
+		case input.Home:
+			currentPage = 0
+		case input.End:
+			currentPage = totalPages - 1
 		}
 	}
 }
@@ -122,13 +148,13 @@ func (p *Pager) displaySinglePage(lines []string, title string) error {
 
 	// Title at line 1
 	coloredTitle := p.colorScheme.Colorize(title, "primary")
-	centeredTitle := p.colorScheme.CenterText(coloredTitle, 79)
+	centeredTitle := p.colorScheme.CenterText(coloredTitle, p.width())
 	position := fmt.Sprintf("\033[%d;1H", currentLine)
 	p.writer.Write([]byte(position + centeredTitle))
 	currentLine++
 
 	// Separator at line 2
-	separator := strings.Repeat("─", 79)
+	separator := strings.Repeat("─", p.width())
 	coloredSeparator := p.colorScheme.Colorize(separator, "secondary")
 	position = fmt.Sprintf("\033[%d;1H", currentLine)
 	p.writer.Write([]byte(position + coloredSeparator))
@@ -137,12 +163,13 @@ func (p *Pager) displaySinglePage(lines []string, title string) error {
 	// Display content lines starting at line 4, using absolute positioning
 	// Leave extra space to ensure status bar is never overwritten
 	maxContentLine := height - 6 // Very conservative: never write to bottom 6 lines
+	width := p.width()
 	for _, line := range lines {
 		if currentLine > maxContentLine {
 			break // Stop if we would exceed the scroll region
 		}
 		position = fmt.Sprintf("\033[%d;1H", currentLine)
-		p.writer.Write([]byte(position + line))
+		p.writer.Write([]byte(position + fit(line, width)))
 		currentLine++
 	}
 
@@ -150,7 +177,7 @@ func (p *Pager) displaySinglePage(lines []string, title string) error {
 	footerLine := height - 5
 	footerPosition := fmt.Sprintf("\033[%d;1H", footerLine)
 	footer := p.colorScheme.Colorize("Press any key to return...", "text")
-	centeredFooter := p.colorScheme.CenterText(footer, 79)
+	centeredFooter := p.colorScheme.CenterText(footer, p.width())
 	p.writer.Write([]byte(footerPosition + centeredFooter))
 
 	// Status bar is protected by scroll region and managed by timer updates
@@ -177,7 +204,7 @@ func (p *Pager) displayPage(lines []string, title string, currentPage, totalPage
 
 	// Title at line 1
 	coloredTitle := p.colorScheme.Colorize(title, "primary")
-	centeredTitle := p.colorScheme.CenterText(coloredTitle, 79)
+	centeredTitle := p.colorScheme.CenterText(coloredTitle, p.width())
 	position := fmt.Sprintf("\033[%d;1H", currentLine)
 	p.writer.Write([]byte(position + centeredTitle))
 	currentLine++
@@ -185,13 +212,13 @@ func (p *Pager) displayPage(lines []string, title string, currentPage, totalPage
 	// Page indicator at line 2 (for multi-page)
 	pageIndicator := fmt.Sprintf("Page %d of %d", currentPage, totalPages)
 	coloredIndicator := p.colorScheme.Colorize(pageIndicator, "secondary")
-	centeredIndicator := p.colorScheme.CenterText(coloredIndicator, 79)
+	centeredIndicator := p.colorScheme.CenterText(coloredIndicator, p.width())
 	position = fmt.Sprintf("\033[%d;1H", currentLine)
 	p.writer.Write([]byte(position + centeredIndicator))
 	currentLine++
 
 	// Separator at line 3
-	separator := strings.Repeat("─", 79)
+	separator := strings.Repeat("─", p.width())
 	coloredSeparator := p.colorScheme.Colorize(separator, "secondary")
 	position = fmt.Sprintf("\033[%d;1H", currentLine)
 	p.writer.Write([]byte(position + coloredSeparator))
@@ -200,12 +227,13 @@ func (p *Pager) displayPage(lines []string, title string, currentPage, totalPage
 	// Display content lines starting at line 5, using absolute positioning
 	// Leave extra space to ensure status bar is never overwritten
 	maxContentLine := height - 6 // Very conservative: never write to bottom 6 lines
+	width := p.width()
 	for _, line := range lines {
 		if currentLine > maxContentLine {
 			break // Stop if we would exceed the scroll region
 		}
 		position = fmt.Sprintf("\033[%d;1H", currentLine)
-		p.writer.Write([]byte(position + line))
+		p.writer.Write([]byte(position + fit(line, width)))
 		currentLine++
 	}
 
@@ -220,19 +248,19 @@ func (p *Pager) displayPage(lines []string, title string, currentPage, totalPage
 func (p *Pager) displayHeader(title string, currentPage, totalPages int) {
 	// Title line
 	coloredTitle := p.colorScheme.Colorize(title, "primary")
-	centeredTitle := p.colorScheme.CenterText(coloredTitle, 79)
+	centeredTitle := p.colorScheme.CenterText(coloredTitle, p.width())
 	p.writer.Write([]byte(centeredTitle + "\n"))
 
 	// Page indicator (only show if multiple pages)
 	if totalPages > 1 {
 		pageIndicator := fmt.Sprintf("Page %d of %d", currentPage, totalPages)
 		coloredIndicator := p.colorScheme.Colorize(pageIndicator, "secondary")
-		centeredIndicator := p.colorScheme.CenterText(coloredIndicator, 79)
+		centeredIndicator := p.colorScheme.CenterText(coloredIndicator, p.width())
 		p.writer.Write([]byte(centeredIndicator + "\n"))
 	}
 
 	// Separator line
-	separator := strings.Repeat("─", 79)
+	separator := strings.Repeat("─", p.width())
 	coloredSeparator := p.colorScheme.Colorize(separator, "secondary")
 	p.writer.Write([]byte(coloredSeparator + "\n\n"))
 }
@@ -265,6 +293,6 @@ func (p *Pager) displayFooter(currentPage, totalPages, terminalHeight int) {
 	footerPosition := fmt.Sprintf("\033[%d;1H", footerLine)
 
 	coloredInstructions := p.colorScheme.Colorize(instructions, "text")
-	centeredInstructions := p.colorScheme.CenterText(coloredInstructions, 79)
+	centeredInstructions := p.colorScheme.CenterText(coloredInstructions, p.width())
 	p.writer.Write([]byte(footerPosition + centeredInstructions))
 }
@@ -0,0 +1,125 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// EnvDataDir is the environment variable that selects the data directory
+const EnvDataDir = "BBS_DATA_DIR"
+
+// Layout describes where the BBS keeps its files on disk
+type Layout struct {
+	Root   string // Base data directory
+	Config string // config.yaml and menu fragments
+	Data   string // SQLite database and SSH host key
+	Art    string // ANSI art and screen templates
+	Files  string // File areas
+	Logs   string // Server logs
+}
+
+// Resolve determines the on-disk layout. The precedence is:
+//  1. the dataDir argument (from --data-dir)
+//  2. the BBS_DATA_DIR environment variable
+//  3. the current directory, if it already contains a config.yaml (legacy layout)
+//  4. $XDG_DATA_HOME/coastline-bbs (or ~/.local/share/coastline-bbs)
+func Resolve(dataDir string) Layout {
+	if dataDir == "" {
+		dataDir = os.Getenv(EnvDataDir)
+	}
+
+	if dataDir == "" {
+		if _, err := os.Stat("config.yaml"); err == nil {
+			return legacyLayout()
+		}
+		dataDir = xdgDataDir()
+	}
+
+	return newLayout(dataDir)
+}
+
+// newLayout builds the structured layout rooted at dir
+func newLayout(dir string) Layout {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return Layout{
+		Root:   dir,
+		Config: filepath.Join(dir, "config"),
+		Data:   filepath.Join(dir, "data"),
+		Art:    filepath.Join(dir, "art"),
+		Files:  filepath.Join(dir, "files"),
+		Logs:   filepath.Join(dir, "logs"),
+	}
+}
+
+// legacyLayout keeps everything in the working directory as older installs expect
+func legacyLayout() Layout {
+	return Layout{
+		Root:   ".",
+		Config: ".",
+		Data:   ".",
+		Art:    "art",
+		Files:  "files",
+		Logs:   "logs",
+	}
+}
+
+// xdgDataDir returns the per-user XDG data directory for the BBS
+func xdgDataDir() string {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "coastline-bbs")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "share", "coastline-bbs")
+	}
+	return "."
+}
+
+// ConfigFile returns the path of the main configuration file
+func (l Layout) ConfigFile() string {
+	return filepath.Join(l.Config, "config.yaml")
+}
+
+// Ensure creates any missing layout directories
+func (l Layout) Ensure() error {
+	for _, dir := range []string{l.Config, l.Data, l.Art, l.Files, l.Logs} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RootPath resolves a path relative to the base data directory
+func (l Layout) RootPath(path string) string {
+	return resolve(l.Root, path)
+}
+
+// DataPath resolves a path from config relative to the data directory.
+// Absolute paths are returned unchanged.
+func (l Layout) DataPath(path string) string {
+	return resolve(l.Data, path)
+}
+
+// ArtPath resolves a path relative to the art directory
+func (l Layout) ArtPath(path string) string {
+	return resolve(l.Art, path)
+}
+
+// FilesPath resolves a path relative to the file areas directory
+func (l Layout) FilesPath(path string) string {
+	return resolve(l.Files, path)
+}
+
+// LogPath resolves a path relative to the logs directory
+func (l Layout) LogPath(path string) string {
+	return resolve(l.Logs, path)
+}
+
+func resolve(base, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
@@ -0,0 +1,251 @@
+// Package publish renders the board's public topics and bulletins into a
+// static HTML archive that any web server can host. Each run writes only
+// the pages whose content changed and removes the pages of posts and
+// topics that are no longer published.
+package publish
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// allRows asks for every row; SQLite reads a negative LIMIT as no limit
+const allRows = -1
+
+// Result counts what a run did to the archive
+type Result struct {
+	Written   int      // Pages that were new or changed
+	Unchanged int      // Pages left as they were
+	Removed   int      // Pages of posts and topics no longer published
+	Skipped   []string // Configured topics that don't exist or aren't public
+}
+
+// Publisher writes the archive for one board
+type Publisher struct {
+	db     *database.DB
+	cfg    config.PublishConfig
+	dir    string
+	system string
+	pages  map[string]*template.Template
+	result Result
+	seen   map[string]bool // Pages written or kept by the current run
+}
+
+// page is the data every template is rendered with
+type page struct {
+	System       string
+	Banner       string
+	Title        string
+	HasBulletins bool
+	Topics       []database.Topic
+	Bulletins    []database.Bulletin
+	Topic        *database.Topic
+	Posts        []database.Post
+	Post         *database.Post
+	Replies      []database.ThreadedReply
+}
+
+// NewPublisher creates a publisher for the board's archive directory
+func NewPublisher(db *database.DB, cfg *config.Config) (*Publisher, error) {
+	funcs := template.FuncMap{
+		"date": func(t time.Time) string { return t.Local().Format("Jan 02, 2006 15:04") },
+		"inc":  func(i int) int { return i + 1 },
+		// Replies are indented under the reply they answer
+		"indent": func(depth int) int { return depth * 4 },
+	}
+
+	pages := make(map[string]*template.Template)
+	for _, name := range []string{"index", "bulletins", "topic", "post"} {
+		tmpl, err := template.New(name).Funcs(funcs).ParseFS(templates, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
+		}
+		pages[name] = tmpl
+	}
+
+	return &Publisher{
+		db:     db,
+		cfg:    cfg.BBS.Publish,
+		dir:    cfg.Paths.RootPath(cfg.BBS.Publish.Dir),
+		system: cfg.BBS.SystemName,
+		pages:  pages,
+	}, nil
+}
+
+// Dir returns where the archive is written
+func (p *Publisher) Dir() string {
+	return p.dir
+}
+
+// Run brings the archive up to date with the database
+func (p *Publisher) Run() (Result, error) {
+	p.result = Result{}
+	p.seen = make(map[string]bool)
+
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return p.result, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	style, err := templates.ReadFile("templates/style.css")
+	if err != nil {
+		return p.result, err
+	}
+	if err := p.write("style.css", style); err != nil {
+		return p.result, err
+	}
+
+	topics, err := p.topics()
+	if err != nil {
+		return p.result, err
+	}
+
+	base := page{System: p.system, Banner: banner(p.system), HasBulletins: p.cfg.Bulletins}
+
+	if p.cfg.Bulletins {
+		bulletins, err := p.db.GetBulletins(allRows)
+		if err != nil {
+			return p.result, fmt.Errorf("failed to load bulletins: %w", err)
+		}
+		data := base
+		data.Title = "System Bulletins"
+		data.Bulletins = bulletins
+		if err := p.render("bulletins.html", "bulletins", data); err != nil {
+			return p.result, err
+		}
+	}
+
+	limit := p.cfg.MaxPosts
+	if limit <= 0 {
+		limit = allRows
+	}
+	for i := range topics {
+		topic := &topics[i]
+		posts, err := p.db.GetPosts(topic.ID, limit)
+		if err != nil {
+			return p.result, fmt.Errorf("failed to load posts in %s: %w", topic.Name, err)
+		}
+
+		data := base
+		data.Title = topic.Name
+		data.Topic = topic
+		data.Posts = posts
+		if err := p.render(fmt.Sprintf("topic-%d.html", topic.ID), "topic", data); err != nil {
+			return p.result, err
+		}
+
+		for j := range posts {
+			replies, err := p.db.GetReplies(posts[j].ID)
+			if err != nil {
+				return p.result, fmt.Errorf("failed to load replies to post %d: %w", posts[j].ID, err)
+			}
+			data := base
+			data.Title = posts[j].Subject
+			data.Topic = topic
+			data.Post = &posts[j]
+			data.Replies = database.Thread(replies)
+			if err := p.render(fmt.Sprintf("post-%d.html", posts[j].ID), "post", data); err != nil {
+				return p.result, err
+			}
+		}
+	}
+
+	data := base
+	data.Title = "Message Boards"
+	data.Topics = topics
+	if err := p.render("index.html", "index", data); err != nil {
+		return p.result, err
+	}
+
+	return p.result, p.removeStale()
+}
+
+// topics returns the configured topics that are open to everyone, in the
+// order the board lists them, noting the rest as skipped
+func (p *Publisher) topics() ([]database.Topic, error) {
+	topics, missing, err := p.db.GetPublicTopics(p.cfg.Topics)
+	p.result.Skipped = append(p.result.Skipped, missing...)
+	return topics, err
+}
+
+// render executes a page template and writes the result
+func (p *Publisher) render(name, tmpl string, data page) error {
+	var buf bytes.Buffer
+	if err := p.pages[tmpl].ExecuteTemplate(&buf, "layout", data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return p.write(name, buf.Bytes())
+}
+
+// write replaces a page when its content has changed. The new page is
+// written beside the old one and renamed over it, so the web server never
+// serves half a page.
+func (p *Publisher) write(name string, content []byte) error {
+	p.seen[name] = true
+	path := filepath.Join(p.dir, name)
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, content) {
+		p.result.Unchanged++
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(p.dir, ".publish-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	// CreateTemp makes the file private; the archive is for everyone
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	p.result.Written++
+	return nil
+}
+
+// removeStale deletes the archive's own pages that this run didn't write,
+// leaving any other files the sysop keeps in the directory alone
+func (p *Publisher) removeStale() error {
+	for _, pattern := range []string{"bulletins.html", "topic-*.html", "post-*.html"} {
+		matches, err := filepath.Glob(filepath.Join(p.dir, pattern))
+		if err != nil {
+			return err
+		}
+		for _, path := range matches {
+			if p.seen[filepath.Base(path)] {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", filepath.Base(path), err)
+			}
+			p.result.Removed++
+		}
+	}
+	return nil
+}
+
+// banner draws the board's name in a double-lined box
+func banner(name string) string {
+	line := strings.Repeat("═", utf8.RuneCountInString(name)+4)
+	return "╔" + line + "╗\n║  " + name + "  ║\n╚" + line + "╝"
+}
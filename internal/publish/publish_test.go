@@ -0,0 +1,134 @@
+package publish
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/paths"
+)
+
+func newTestPublisher(t *testing.T, topics ...string) (*Publisher, *database.DB) {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := database.Initialize(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := &config.Config{Paths: paths.Layout{Root: dir}}
+	cfg.BBS.SystemName = "Test BBS"
+	cfg.BBS.Publish = config.PublishConfig{Dir: "public", Topics: topics, Bulletins: true}
+
+	p, err := NewPublisher(db, cfg)
+	if err != nil {
+		t.Fatalf("NewPublisher failed: %v", err)
+	}
+	return p, db
+}
+
+func readPage(t *testing.T, p *Publisher, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(p.Dir(), name))
+	if err != nil {
+		t.Fatalf("reading %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestRun(t *testing.T) {
+	p, db := newTestPublisher(t, "general", "Sysops", "Missing")
+
+	general := &database.Topic{Name: "General"}
+	sysops := &database.Topic{Name: "Sysops", AccessLevel: 255}
+	for _, topic := range []*database.Topic{general, sysops} {
+		if err := db.CreateTopic(topic); err != nil {
+			t.Fatalf("CreateTopic failed: %v", err)
+		}
+	}
+	post := &database.Post{TopicID: general.ID, Author: "alice", Subject: "Hello <world>", Body: "First post"}
+	if err := db.CreatePost(post); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	bob := &database.Reply{PostID: post.ID, Author: "bob", Body: "Welcome!"}
+	if err := db.CreateReply(bob); err != nil {
+		t.Fatalf("CreateReply failed: %v", err)
+	}
+	secret := &database.Post{TopicID: sysops.ID, Author: "sysop", Subject: "Private", Body: "Not for the web"}
+	if err := db.CreatePost(secret); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	if err := db.CreateBulletin(&database.Bulletin{Title: "News", Body: "Open house", Author: "sysop"}); err != nil {
+		t.Fatalf("CreateBulletin failed: %v", err)
+	}
+
+	result, err := p.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Written != 5 || result.Unchanged != 0 {
+		t.Errorf("first run wrote %d and kept %d pages, want 5 and 0", result.Written, result.Unchanged)
+	}
+	if strings.Join(result.Skipped, ",") != "Sysops,Missing" {
+		t.Errorf("skipped %q, want the restricted and missing topics", result.Skipped)
+	}
+
+	index := readPage(t, p, "index.html")
+	if !strings.Contains(index, "topic-1.html") || strings.Contains(index, "Sysops") {
+		t.Errorf("index lists the wrong topics:\n%s", index)
+	}
+	postPage := readPage(t, p, "post-1.html")
+	if !strings.Contains(postPage, "Hello &lt;world&gt;") || !strings.Contains(postPage, "Welcome!") {
+		t.Errorf("post page missing the escaped subject or the reply:\n%s", postPage)
+	}
+	if _, err := os.Stat(filepath.Join(p.Dir(), "post-2.html")); !os.IsNotExist(err) {
+		t.Error("published a post from a restricted topic")
+	}
+	if !strings.Contains(readPage(t, p, "bulletins.html"), "Open house") {
+		t.Error("bulletin missing from bulletins.html")
+	}
+
+	// Nothing changed, so nothing is rewritten
+	result, err = p.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Written != 0 || result.Unchanged != 5 {
+		t.Errorf("second run wrote %d and kept %d pages, want 0 and 5", result.Written, result.Unchanged)
+	}
+
+	// A new reply rewrites its post and the topic's reply count
+	if err := db.CreateReply(&database.Reply{PostID: post.ID, ParentID: bob.ID, Author: "carol", Body: "Hi"}); err != nil {
+		t.Fatalf("CreateReply failed: %v", err)
+	}
+	result, err = p.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Written != 2 {
+		t.Errorf("run after a reply wrote %d pages, want 2", result.Written)
+	}
+	if !strings.Contains(readPage(t, p, "post-1.html"), `style="margin-left: 4ch"`) {
+		t.Error("reply to a reply isn't indented under it")
+	}
+
+	// Dropping the topic removes its pages but leaves the sysop's own files
+	if err := os.WriteFile(filepath.Join(p.Dir(), "about.html"), []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p.cfg.Topics = nil
+	result, err = p.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Removed != 2 {
+		t.Errorf("removed %d pages, want the topic and its post", result.Removed)
+	}
+	if _, err := os.Stat(filepath.Join(p.Dir(), "about.html")); err != nil {
+		t.Errorf("removed a file the archive didn't write: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"bbs/internal/config"
+)
+
+// DefaultWarning is how long callers are warned when an event sets no warning
+const DefaultWarning = 10 * time.Minute
+
+// Event is a parsed daily event during which the board is closed
+type Event struct {
+	Name     string
+	Hour     int
+	Minute   int
+	Duration time.Duration
+	Warning  time.Duration
+}
+
+// Window is a single occurrence of an event
+type Window struct {
+	Name    string
+	Start   time.Time
+	End     time.Time
+	Warning time.Duration
+}
+
+// Parse converts configured events, rejecting malformed times and durations
+func Parse(cfgs []config.EventConfig) ([]Event, error) {
+	var parsed []Event
+	for _, cfg := range cfgs {
+		clock, err := time.Parse("15:04", cfg.Time)
+		if err != nil {
+			return nil, fmt.Errorf("event %q: invalid time %q, expected HH:MM", cfg.Name, cfg.Time)
+		}
+		if cfg.Duration <= 0 || cfg.Duration >= 24*60 {
+			return nil, fmt.Errorf("event %q: duration must be between 1 and 1439 minutes", cfg.Name)
+		}
+
+		warning := DefaultWarning
+		if cfg.Warning > 0 {
+			warning = time.Duration(cfg.Warning) * time.Minute
+		}
+
+		name := cfg.Name
+		if name == "" {
+			name = "System maintenance"
+		}
+
+		parsed = append(parsed, Event{
+			Name:     name,
+			Hour:     clock.Hour(),
+			Minute:   clock.Minute(),
+			Duration: time.Duration(cfg.Duration) * time.Minute,
+			Warning:  warning,
+		})
+	}
+	return parsed, nil
+}
+
+// window returns the occurrence of the event that has not yet ended at now.
+// An occurrence that started yesterday and runs past midnight counts.
+func (e Event) window(now time.Time) Window {
+	y, m, d := now.Date()
+	start := time.Date(y, m, d-1, e.Hour, e.Minute, 0, 0, now.Location())
+	for !start.Add(e.Duration).After(now) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return Window{
+		Name:    e.Name,
+		Start:   start,
+		End:     start.Add(e.Duration),
+		Warning: e.Warning,
+	}
+}
+
+// Next returns the event window that is in progress at now or, if none is,
+// the one that starts soonest
+func Next(events []Event, now time.Time) (Window, bool) {
+	var next Window
+	found := false
+	for _, event := range events {
+		w := event.window(now)
+		if w.Active(now) {
+			return w, true
+		}
+		if !found || w.Start.Before(next.Start) {
+			next = w
+			found = true
+		}
+	}
+	return next, found
+}
+
+// Active reports whether the board is closed for this window at t
+func (w Window) Active(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Warn reports whether t falls in the warning period before the window
+func (w Window) Warn(t time.Time) bool {
+	return !t.Before(w.Start.Add(-w.Warning)) && t.Before(w.Start)
+}
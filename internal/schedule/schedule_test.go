@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"bbs/internal/config"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2024, 3, 10, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseRejectsBadTime(t *testing.T) {
+	if _, err := Parse([]config.EventConfig{{Name: "bad", Time: "25:00", Duration: 10}}); err == nil {
+		t.Error("expected an error for an invalid time")
+	}
+	if _, err := Parse([]config.EventConfig{{Name: "bad", Time: "03:00"}}); err == nil {
+		t.Error("expected an error for a missing duration")
+	}
+}
+
+func TestNextWindow(t *testing.T) {
+	events, err := Parse([]config.EventConfig{{Name: "Maintenance", Time: "03:00", Duration: 30}})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := []struct {
+		now       time.Time
+		wantStart time.Time
+		active    bool
+		warn      bool
+	}{
+		{at(1, 0), at(3, 0), false, false},
+		{at(2, 55), at(3, 0), false, true},
+		{at(3, 10), at(3, 0), true, false},
+		{at(3, 30), at(3, 0).AddDate(0, 0, 1), false, false},
+	}
+
+	for _, tt := range tests {
+		w, ok := Next(events, tt.now)
+		if !ok {
+			t.Fatalf("%s: expected a window", tt.now.Format("15:04"))
+		}
+		if !w.Start.Equal(tt.wantStart) {
+			t.Errorf("%s: start = %s, want %s", tt.now.Format("15:04"), w.Start, tt.wantStart)
+		}
+		if w.Active(tt.now) != tt.active {
+			t.Errorf("%s: active = %v, want %v", tt.now.Format("15:04"), !tt.active, tt.active)
+		}
+		if w.Warn(tt.now) != tt.warn {
+			t.Errorf("%s: warn = %v, want %v", tt.now.Format("15:04"), !tt.warn, tt.warn)
+		}
+	}
+}
+
+func TestWindowSpanningMidnight(t *testing.T) {
+	events, err := Parse([]config.EventConfig{{Name: "Mail toss", Time: "23:50", Duration: 20}})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	w, _ := Next(events, at(0, 5))
+	if !w.Active(at(0, 5)) {
+		t.Errorf("expected the event from the previous night to be active, got %s-%s", w.Start, w.End)
+	}
+}
@@ -0,0 +1,187 @@
+// Package scheduler runs the board's maintenance jobs on cron-like
+// schedules and keeps how each one last went for the sysop
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrRunning is returned when a job is asked to run while it already is
+var ErrRunning = errors.New("job is already running")
+
+// Func runs a job at now and returns a short account of what it did
+type Func func(now time.Time) (string, error)
+
+// Result is how a run of a job went
+type Result struct {
+	At      time.Time
+	Took    time.Duration
+	Summary string
+	Err     error
+}
+
+// Status describes a job for the sysop
+type Status struct {
+	Name     string
+	Schedule string // As configured
+	Next     time.Time
+	Running  bool
+	Last     *Result // nil until the job first runs
+}
+
+// job is a scheduled job and its last result
+type job struct {
+	name    string
+	expr    string
+	spec    Spec
+	run     Func
+	next    time.Time
+	running bool
+	last    *Result
+}
+
+// Scheduler runs jobs when their schedules come due. Jobs run one at a
+// time, in the order they were added; runs missed while the server was
+// down are not made up.
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs []*job
+}
+
+// New creates an empty scheduler
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add schedules a job, rejecting a malformed schedule or a name already used
+func (s *Scheduler) Add(name, expr string, run Func) error {
+	spec, err := Parse(expr)
+	if err != nil {
+		return fmt.Errorf("job %q: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.name == name {
+			return fmt.Errorf("job %q is listed twice", name)
+		}
+	}
+	s.jobs = append(s.jobs, &job{name: name, expr: expr, spec: spec, run: run, next: spec.Next(time.Now())})
+	return nil
+}
+
+// Start runs the jobs in the background as they come due
+func (s *Scheduler) Start() {
+	go func() {
+		for {
+			// Wake at least once a minute in case the clock is changed
+			wait := time.Minute
+			if next, ok := s.soonest(); ok {
+				wait = min(wait, time.Until(next))
+			}
+			time.Sleep(max(wait, time.Second))
+			s.runDue(time.Now())
+		}
+	}()
+}
+
+// soonest returns when the next job comes due
+func (s *Scheduler) soonest() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, j := range s.jobs {
+		if next.IsZero() || j.next.Before(next) {
+			next = j.next
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// runDue runs every job due at now and schedules its next run
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	var due []*job
+	for _, j := range s.jobs {
+		if !j.next.After(now) {
+			j.next = j.spec.Next(now)
+			if !j.running {
+				due = append(due, j)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	for _, j := range due {
+		s.start(j, now)
+	}
+}
+
+// RunNow runs a job straight away, whatever its schedule, and returns how
+// it went. Its next scheduled run is unchanged.
+func (s *Scheduler) RunNow(name string) (Result, error) {
+	s.mu.Lock()
+	var found *job
+	for _, j := range s.jobs {
+		if j.name == name {
+			found = j
+		}
+	}
+	s.mu.Unlock()
+
+	if found == nil {
+		return Result{}, fmt.Errorf("no job named %q", name)
+	}
+	return s.start(found, time.Now())
+}
+
+// start runs a job unless it is running already, recording and logging
+// the result
+func (s *Scheduler) start(j *job, now time.Time) (Result, error) {
+	s.mu.Lock()
+	if j.running {
+		s.mu.Unlock()
+		return Result{}, ErrRunning
+	}
+	j.running = true
+	s.mu.Unlock()
+
+	began := time.Now()
+	summary, err := j.run(now)
+	result := Result{At: now, Took: time.Since(began), Summary: summary, Err: err}
+	if err != nil {
+		log.Printf("Job %q failed: %v", j.name, err)
+	} else if summary != "" {
+		log.Printf("Job %q: %s", j.name, summary)
+	}
+
+	s.mu.Lock()
+	j.running = false
+	j.last = &result
+	s.mu.Unlock()
+	return result, nil
+}
+
+// Status returns every job's schedule and last result, in the order the
+// jobs were added
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		status := Status{Name: j.name, Schedule: j.expr, Next: j.next, Running: j.running}
+		if j.last != nil {
+			last := *j.last
+			status.Last = &last
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func at(day, hour, minute int) time.Time {
+	// March 2024: the 10th is a Sunday
+	return time.Date(2024, 3, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseRejectsBadSchedules(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "0 0 30 2 *", "@sometimes"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"@hourly", at(10, 3, 0), at(10, 4, 0)},
+		{"*/15 * * * *", at(10, 3, 7), at(10, 3, 15)},
+		{"30 4 * * *", at(10, 4, 30), at(11, 4, 30)},
+		{"0 2 * * 0", at(11, 0, 0), at(17, 2, 0)},   // Next Sunday
+		{"0 2 * * 7", at(11, 0, 0), at(17, 2, 0)},   // 7 is Sunday too
+		{"0 9 * * 1-5", at(9, 12, 0), at(11, 9, 0)}, // Saturday to Monday
+		{"0 0 1 * *", at(10, 0, 0), at(10, 0, 0).AddDate(0, 0, 22)},
+		{"0 0 15 * 1", at(10, 0, 0), at(11, 0, 0)}, // Either the 15th or a Monday
+		{"5/20 * * * *", at(10, 3, 30), at(10, 3, 45)},
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.expr, err)
+		}
+		if got := spec.Next(tt.after); !got.Equal(tt.want) {
+			t.Errorf("%q after %s = %s, want %s", tt.expr, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	s := New()
+	runs := 0
+	if err := s.Add("count", "* * * * *", func(now time.Time) (string, error) {
+		runs++
+		return "counted", nil
+	}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := s.Add("fail", "@yearly", func(now time.Time) (string, error) {
+		return "", errors.New("disk full")
+	}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := s.Add("count", "@daily", nil); err == nil {
+		t.Error("adding a second job named count succeeded, want an error")
+	}
+
+	now := s.Status()[0].Next
+	s.runDue(now)
+	if runs != 1 {
+		t.Errorf("runs = %d after the job came due, want 1", runs)
+	}
+	s.runDue(now)
+	if runs != 1 {
+		t.Errorf("runs = %d after running again at the same time, want 1", runs)
+	}
+
+	statuses := s.Status()
+	if last := statuses[0].Last; last == nil || last.Summary != "counted" || !last.At.Equal(now) {
+		t.Errorf("last run = %+v, want counted at %s", last, now)
+	}
+	if want := now.Add(time.Minute); !statuses[0].Next.Equal(want) {
+		t.Errorf("next run = %s, want %s", statuses[0].Next, want)
+	}
+	if statuses[1].Last != nil {
+		t.Errorf("a job that isn't due ran: %+v", statuses[1].Last)
+	}
+
+	result, err := s.RunNow("fail")
+	if err != nil || result.Err == nil {
+		t.Errorf("RunNow = %+v, %v; want the job's error in the result", result, err)
+	}
+	if _, err := s.RunNow("missing"); err == nil {
+		t.Error("running a job that doesn't exist succeeded")
+	}
+}
@@ -0,0 +1,232 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"bbs/internal/input"
+
+	"go.starlark.net/starlark"
+)
+
+// api holds the builtins one run of a script calls
+type api struct {
+	script string
+	env    Env
+}
+
+// write(*values) shows values to the caller with nothing between them
+func (a *api) write(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(kwargs) > 0 {
+		return nil, fmt.Errorf("%s: unexpected keyword arguments", fn.Name())
+	}
+	var text strings.Builder
+	for _, arg := range args {
+		text.WriteString(str(arg))
+	}
+	if err := a.env.Terminal.Write(text.String()); err != nil {
+		return nil, err
+	}
+	return starlark.None, nil
+}
+
+// read_key() waits for a key and returns it: a letter, digit or symbol, or
+// a name such as "enter", "escape", "backspace", "up" or "down"
+func (a *api) readKey(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	key, err := a.env.Terminal.ReadKey()
+	if err != nil {
+		return nil, err
+	}
+	return starlark.String(key), nil
+}
+
+// read_line(prompt="", max=60) shows prompt and returns what the caller
+// types before Enter, or None if they press Escape
+func (a *api) readLine(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	prompt := ""
+	max := 60
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "prompt?", &prompt, "max?", &max); err != nil {
+		return nil, err
+	}
+	if max < 1 || max > 250 {
+		return nil, fmt.Errorf("%s: max must be between 1 and 250", fn.Name())
+	}
+
+	t := a.env.Terminal
+	if err := t.Write(prompt); err != nil {
+		return nil, err
+	}
+	line, err := input.ReadLine(t, terminalWriter{t}, max)
+	if errors.Is(err, input.ErrCancelled) {
+		t.Write("\n")
+		return starlark.None, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return starlark.String(line), nil
+}
+
+// terminalWriter lets the line editor echo to a script's Terminal
+type terminalWriter struct {
+	t Terminal
+}
+
+func (w terminalWriter) Write(p []byte) (int, error) {
+	if err := w.t.Write(string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// pause() waits for any key
+func (a *api) pause(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	t := a.env.Terminal
+	if err := t.Write("\n" + t.Color("Press any key to continue...", "secondary")); err != nil {
+		return nil, err
+	}
+	if _, err := t.ReadKey(); err != nil {
+		return nil, err
+	}
+	t.Write("\n")
+	return starlark.None, nil
+}
+
+// clear() clears the screen below the status bar
+func (a *api) clear(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	a.env.Terminal.Clear()
+	return starlark.None, nil
+}
+
+// color(text, style) returns text in one of the caller's theme colors:
+// "primary", "secondary", "accent", "text", "success" or "error"
+func (a *api) color(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var text starlark.Value
+	var style string
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 2, &text, &style); err != nil {
+		return nil, err
+	}
+	return starlark.String(a.env.Terminal.Color(str(text), style)), nil
+}
+
+// random(n) returns a whole number from 0 up to but not including n
+func (a *api) random(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var n int
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &n); err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("%s: n must be at least 1", fn.Name())
+	}
+	return starlark.MakeInt(rand.Intn(n)), nil
+}
+
+// store.get(key, default=None) returns what the script stored under key
+func (a *api) storeGet(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key string
+	var def starlark.Value = starlark.None
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "key", &key, "default?", &def); err != nil {
+		return nil, err
+	}
+	value, ok, err := a.env.Store.GetScriptValue(a.script, key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return def, nil
+	}
+	return starlark.String(value), nil
+}
+
+// store.set(key, value) keeps value, as text, under key
+func (a *api) storeSet(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key string
+	var value starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "key", &key, "value", &value); err != nil {
+		return nil, err
+	}
+	if err := checkKey(fn, key); err != nil {
+		return nil, err
+	}
+	text := str(value)
+	if len(text) > MaxValueLength {
+		return nil, fmt.Errorf("%s: value is longer than %d bytes", fn.Name(), MaxValueLength)
+	}
+	if err := a.env.Store.SetScriptValue(a.script, key, text); err != nil {
+		return nil, err
+	}
+	return starlark.None, nil
+}
+
+// store.incr(key, by=1) adds by to the number under key and returns it
+func (a *api) storeIncr(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key string
+	by := 1
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "key", &key, "by?", &by); err != nil {
+		return nil, err
+	}
+	if err := checkKey(fn, key); err != nil {
+		return nil, err
+	}
+	n, err := a.env.Store.IncrementScriptValue(a.script, key, int64(by))
+	if err != nil {
+		return nil, err
+	}
+	return starlark.MakeInt64(n), nil
+}
+
+// store.delete(key) forgets what is under key
+func (a *api) storeDelete(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "key", &key); err != nil {
+		return nil, err
+	}
+	if err := a.env.Store.DeleteScriptValue(a.script, key); err != nil {
+		return nil, err
+	}
+	return starlark.None, nil
+}
+
+// store.keys(prefix="") lists the script's keys that start with prefix
+func (a *api) storeKeys(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	prefix := ""
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "prefix?", &prefix); err != nil {
+		return nil, err
+	}
+	keys, err := a.env.Store.ScriptKeys(a.script, prefix)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]starlark.Value, len(keys))
+	for i, key := range keys {
+		list[i] = starlark.String(key)
+	}
+	return starlark.NewList(list), nil
+}
+
+// checkKey refuses keys the store won't hold
+func checkKey(fn *starlark.Builtin, key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return fmt.Errorf("%s: key must be 1 to %d bytes", fn.Name(), MaxKeyLength)
+	}
+	return nil
+}
+
+// str is a value as text: strings as they are, anything else as str() shows it
+func str(v starlark.Value) string {
+	if s, ok := starlark.AsString(v); ok {
+		return s
+	}
+	return v.String()
+}
@@ -0,0 +1,168 @@
+// Package scripts runs the small interactive programs sysops write in
+// Starlark, a dialect of Python. A script can only talk to the caller and
+// keep its own data; it has no files, network or programs to reach.
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// Dir is the directory beside config.yaml that holds the scripts
+const Dir = "scripts"
+
+// Ext is the extension of a script file
+const Ext = ".star"
+
+// CommandPrefix starts a menu command that runs a script, as in
+// "script:quiz" for scripts/quiz.star
+const CommandPrefix = "script:"
+
+// MaxSteps is how much work a script may do between starting and ending.
+// Waiting for the caller to type costs nothing, so it only stops scripts
+// stuck in a loop.
+const MaxSteps = 10_000_000
+
+// Limits on what a script may store
+const (
+	MaxKeyLength   = 200
+	MaxValueLength = 4096
+)
+
+// ErrNotFound is returned for a script that isn't in the scripts directory
+var ErrNotFound = errors.New("no such script")
+
+// validName is what a script may be called: it names a file, so no paths
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Terminal is the caller's screen and keyboard
+type Terminal interface {
+	Write(text string) error
+	ReadKey() (string, error)
+	Clear()
+	Color(text, style string) string
+}
+
+// Store keeps each script's data between calls. The database implements it.
+type Store interface {
+	GetScriptValue(script, key string) (string, bool, error)
+	SetScriptValue(script, key, value string) error
+	IncrementScriptValue(script, key string, by int64) (int64, error)
+	DeleteScriptValue(script, key string) error
+	ScriptKeys(script, prefix string) ([]string, error)
+}
+
+// Caller is who is running the script
+type Caller struct {
+	Username    string
+	RealName    string
+	AccessLevel int
+	Node        int
+}
+
+// Board is what a script knows about the board
+type Board struct {
+	SystemName string
+	SysopName  string
+}
+
+// Env is everything a script can reach
+type Env struct {
+	Caller   Caller
+	Board    Board
+	Terminal Terminal
+	Store    Store
+}
+
+// Path returns the file for the script called name in dir
+func Path(dir, name string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return filepath.Join(dir, name+Ext), nil
+}
+
+// Run runs the script called name from dir until it ends, fails, uses up
+// MaxSteps or ctx is done
+func Run(ctx context.Context, dir, name string, env Env) error {
+	path, err := Path(dir, name)
+	if err != nil {
+		return err
+	}
+	src, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read script: %w", err)
+	}
+
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			env.Terminal.Write(msg + "\n")
+		},
+	}
+	thread.SetMaxExecutionSteps(MaxSteps)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+		case <-done:
+		}
+	}()
+
+	opts := &syntax.FileOptions{
+		While:           true,
+		TopLevelControl: true,
+		GlobalReassign:  true,
+	}
+	_, err = starlark.ExecFileOptions(opts, thread, filepath.Base(path), src, predeclared(name, env))
+	return err
+}
+
+// predeclared is the API scripts see
+func predeclared(name string, env Env) starlark.StringDict {
+	a := &api{script: name, env: env}
+	return starlark.StringDict{
+		"write":     starlark.NewBuiltin("write", a.write),
+		"read_key":  starlark.NewBuiltin("read_key", a.readKey),
+		"read_line": starlark.NewBuiltin("read_line", a.readLine),
+		"pause":     starlark.NewBuiltin("pause", a.pause),
+		"clear":     starlark.NewBuiltin("clear", a.clear),
+		"color":     starlark.NewBuiltin("color", a.color),
+		"random":    starlark.NewBuiltin("random", a.random),
+		"user": starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+			"name":      starlark.String(env.Caller.Username),
+			"real_name": starlark.String(env.Caller.RealName),
+			"level":     starlark.MakeInt(env.Caller.AccessLevel),
+			"node":      starlark.MakeInt(env.Caller.Node),
+			"is_sysop":  starlark.Bool(env.Caller.AccessLevel >= 255),
+		}),
+		"board": starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+			"name":  starlark.String(env.Board.SystemName),
+			"sysop": starlark.String(env.Board.SysopName),
+		}),
+		"store": &starlarkstruct.Module{
+			Name: "store",
+			Members: starlark.StringDict{
+				"get":    starlark.NewBuiltin("store.get", a.storeGet),
+				"set":    starlark.NewBuiltin("store.set", a.storeSet),
+				"incr":   starlark.NewBuiltin("store.incr", a.storeIncr),
+				"delete": starlark.NewBuiltin("store.delete", a.storeDelete),
+				"keys":   starlark.NewBuiltin("store.keys", a.storeKeys),
+			},
+		},
+	}
+}
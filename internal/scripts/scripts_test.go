@@ -0,0 +1,171 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTerminal types keys from a list and records what is written
+type fakeTerminal struct {
+	keys []string
+	out  strings.Builder
+}
+
+func (f *fakeTerminal) Write(text string) error {
+	f.out.WriteString(text)
+	return nil
+}
+
+func (f *fakeTerminal) ReadKey() (string, error) {
+	if len(f.keys) == 0 {
+		return "", io.EOF
+	}
+	key := f.keys[0]
+	f.keys = f.keys[1:]
+	return key, nil
+}
+
+func (f *fakeTerminal) Clear() {
+	f.out.WriteString("<clear>")
+}
+
+func (f *fakeTerminal) Color(text, style string) string {
+	return "<" + style + ">" + text
+}
+
+// fakeStore keeps values in a map
+type fakeStore map[string]string
+
+func (s fakeStore) GetScriptValue(script, key string) (string, bool, error) {
+	value, ok := s[script+"/"+key]
+	return value, ok, nil
+}
+
+func (s fakeStore) SetScriptValue(script, key, value string) error {
+	s[script+"/"+key] = value
+	return nil
+}
+
+func (s fakeStore) IncrementScriptValue(script, key string, by int64) (int64, error) {
+	n, _ := strconv.ParseInt(s[script+"/"+key], 10, 64)
+	n += by
+	s[script+"/"+key] = strconv.FormatInt(n, 10)
+	return n, nil
+}
+
+func (s fakeStore) DeleteScriptValue(script, key string) error {
+	delete(s, script+"/"+key)
+	return nil
+}
+
+func (s fakeStore) ScriptKeys(script, prefix string) ([]string, error) {
+	var keys []string
+	for k := range s {
+		if key, ok := strings.CutPrefix(k, script+"/"); ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func writeScript(t *testing.T, dir, name, src string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name+Ext), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunVotingBooth(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "vote", `
+clear()
+print(color("Vote on " + board.name, "primary"))
+if store.get("voted:" + user.name):
+    print("You already voted.")
+else:
+    key = read_key()
+    choice = {"1": "vi", "2": "emacs"}.get(key)
+    if choice:
+        store.set("voted:" + user.name, choice)
+        write("Votes for ", choice, ": ", store.incr("votes:" + choice), "\n")
+name = read_line("Name? ", max=5)
+print("Hi", name)
+for key in store.keys("votes:"):
+    print(key, "=", int(store.get(key)))
+`)
+
+	store := fakeStore{"vote/votes:vi": "4", "other/votes:vi": "100"}
+	term := &fakeTerminal{keys: []string{"1", "b", "o", "b", "b", "y", "!", "backspace", "enter"}}
+	env := Env{
+		Caller:   Caller{Username: "alice", AccessLevel: 10, Node: 1},
+		Board:    Board{SystemName: "Coastline BBS"},
+		Terminal: term,
+		Store:    store,
+	}
+	if err := Run(context.Background(), dir, "vote", env); err != nil {
+		t.Fatalf("Run failed: %v\n%s", err, term.out.String())
+	}
+
+	want := "<clear><primary>Vote on Coastline BBS\nVotes for vi: 5\nName? bobby\b \b\nHi bobb\nvotes:vi = 5\n"
+	if got := term.out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if store["vote/voted:alice"] != "vi" || store["other/votes:vi"] != "100" {
+		t.Errorf("store = %v, want alice's vote kept apart from other scripts", store)
+	}
+
+	// The second visit finds the vote
+	term = &fakeTerminal{keys: []string{"escape"}}
+	env.Terminal = term
+	if err := Run(context.Background(), dir, "vote", env); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !strings.Contains(term.out.String(), "You already voted.\nName? \nHi None\n") {
+		t.Errorf("second visit output = %q", term.out.String())
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "loop", "while True:\n    pass\n")
+	writeScript(t, dir, "wait", "read_key()\n")
+	writeScript(t, dir, "files", "open('/etc/passwd')\n")
+	env := Env{Terminal: &fakeTerminal{}, Store: fakeStore{}}
+
+	if err := Run(context.Background(), dir, "loop", env); err == nil || !strings.Contains(err.Error(), "too many steps") {
+		t.Errorf("endless loop: err = %v, want too many steps", err)
+	}
+	if err := Run(context.Background(), dir, "files", env); err == nil || !strings.Contains(err.Error(), "undefined: open") {
+		t.Errorf("open: err = %v, want undefined", err)
+	}
+	// A caller who hangs up ends the script
+	if err := Run(context.Background(), dir, "wait", env); !errors.Is(err, io.EOF) {
+		t.Errorf("hang up: err = %v, want EOF", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	writeScript(t, dir, "busy", "x = 0\nwhile x >= 0:\n    x = (x + 1) % 1000\n")
+	start := time.Now()
+	if err := Run(ctx, dir, "busy", Env{Terminal: &fakeTerminal{}, Store: fakeStore{}}); err == nil {
+		t.Error("Run kept going after its context was done")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Run took too long to stop")
+	}
+
+	for _, name := range []string{"../loop", "Loop", "missing", ""} {
+		if err := Run(context.Background(), dir, name, env); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Run(%q) = %v, want ErrNotFound", name, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"bbs/internal/modules/online"
+)
+
+// menuActivity is shown for callers moving around the menus
+const menuActivity = "Browsing menus"
+
+// activity tracks what a logged-in caller is doing and when they last typed.
+// Other sessions read it for who's online, so it has its own lock.
+type activity struct {
+	mu        sync.Mutex
+	doing     string
+	lastInput time.Time
+}
+
+// setActivity records what the caller is doing
+func (s *Session) setActivity(doing string) {
+	s.activity.mu.Lock()
+	defer s.activity.mu.Unlock()
+	s.activity.doing = doing
+}
+
+// touch records a key press for idle tracking
+func (s *Session) touch() {
+	s.activity.mu.Lock()
+	defer s.activity.mu.Unlock()
+	s.activity.lastInput = time.Now()
+}
+
+// currentActivity returns what the caller is doing and how long they have been idle
+func (s *Session) currentActivity() (string, time.Duration) {
+	s.activity.mu.Lock()
+	defer s.activity.mu.Unlock()
+	return s.activity.doing, time.Since(s.activity.lastInput)
+}
+
+// commandActivity returns the activity shown while a menu command runs
+func (s *Server) commandActivity(command string) string {
+	if m, ok := s.modules.Lookup(command); ok && m.MenuEntry().Activity != "" {
+		return m.MenuEntry().Activity
+	}
+	return menuActivity
+}
+
+// online lists the callers a viewer may see, in node order. Invisible
+// callers are only shown to sysops.
+func (t *nodeTable) online(viewer *Session, awayAfter time.Duration) []online.Node {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var nodes []online.Node
+	for number, s := range t.nodes {
+		if !canSee(viewer, s) {
+			continue
+		}
+
+		doing, idle := s.currentActivity()
+		nodes = append(nodes, online.Node{
+			Number:      number,
+			Username:    s.user.Username,
+			Activity:    doing,
+			LoginTime:   s.loginTime,
+			ConnectedAt: s.connectedAt,
+			Address:     s.log.remote,
+			Idle:        idle,
+			Away:        awayAfter > 0 && idle >= awayAfter,
+			Invisible:   s.invisible,
+		})
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Number < nodes[j].Number })
+	return nodes
+}
+
+// visible returns the session on node if the viewer may see it, or nil
+func (t *nodeTable) visible(viewer *Session, node int) *Session {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.nodes[node]
+	if !ok || !canSee(viewer, s) {
+		return nil
+	}
+	return s
+}
+
+// canSee reports whether viewer may see the caller in s. Invisible callers
+// are only shown to sysops and themselves. A nil viewer is the board itself,
+// as for the admin API, and sees everyone.
+func canSee(viewer, s *Session) bool {
+	if viewer == nil {
+		return true
+	}
+	isSysop := viewer.user != nil && viewer.user.AccessLevel >= 255
+	return !s.invisible || isSysop || s == viewer
+}
+
+// WhosOnline lists every caller on the board, invisible ones included
+func (s *Server) WhosOnline() []online.Node {
+	return s.nodes.online(nil, time.Duration(s.cfg().BBS.AwayMinutes)*time.Minute)
+}
+
+// whosOnline lists the callers this session may see
+func (s *Session) whosOnline() []online.Node {
+	return s.server.nodes.online(s, time.Duration(s.config.BBS.AwayMinutes)*time.Minute)
+}
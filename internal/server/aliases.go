@@ -0,0 +1,97 @@
+package server
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"bbs/internal/config"
+	"bbs/internal/menu"
+)
+
+// commandKey opens the command prompt from any menu
+const commandKey = "/"
+
+// findCommand looks up what a caller typed: one of the sysop's aliases, or
+// the command or ID of a menu item. Items above the caller's access level
+// aren't found, so an alias never reaches further than the menus do.
+func (s *Server) findCommand(name string, accessLevel int) *config.MenuItem {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	for alias, command := range s.cfg().BBS.CommandAliases {
+		if strings.EqualFold(alias, name) {
+			name = command
+			break
+		}
+	}
+
+	for _, m := range s.cfg().BBS.Menus {
+		for _, item := range m.Submenu {
+			if item.AccessLevel > accessLevel {
+				continue
+			}
+			if strings.EqualFold(item.Command, name) || strings.EqualFold(item.ID, name) {
+				return &item
+			}
+		}
+	}
+	return nil
+}
+
+// checkAliases logs the aliases that don't name a command on any menu
+func (s *Server) checkAliases() {
+	var aliases []string
+	for alias := range s.cfg().BBS.CommandAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		if s.findCommand(alias, 255) == nil {
+			log.Printf("Command alias %q names %q, which no menu offers", alias, s.cfg().BBS.CommandAliases[alias])
+		}
+	}
+}
+
+// aliasHotkey returns the command a one-letter alias runs when the current
+// menu has no item of its own on that key
+func (s *Session) aliasHotkey(key string) *config.MenuItem {
+	if len(key) != 1 {
+		return nil
+	}
+	for alias := range s.config.BBS.CommandAliases {
+		if strings.EqualFold(alias, key) {
+			return s.server.findCommand(alias, s.accessLevel())
+		}
+	}
+	return nil
+}
+
+// commandPrompt asks for a command or alias and runs it. It returns false
+// when the caller has logged off.
+func (s *Session) commandPrompt() bool {
+	s.write([]byte(menu.ClearContentArea + menu.ShowCursor))
+	s.write([]byte(s.colorScheme.Colorize(s.t("Command: "), "text")))
+	name, err := s.readInput(false)
+	s.write([]byte(menu.HideCursor))
+	if err != nil || strings.TrimSpace(name) == "" {
+		return true
+	}
+
+	item := s.server.findCommand(name, s.accessLevel())
+	if item == nil {
+		s.write([]byte("\n" + s.colorScheme.Colorize("Unknown command: "+strings.TrimSpace(name), "error") + "\n"))
+		s.waitForKey()
+		return true
+	}
+	return s.executeCommand(item)
+}
+
+// accessLevel is the caller's access level, 0 before they have logged in
+func (s *Session) accessLevel() int {
+	if s.user == nil {
+		return 0
+	}
+	return s.user.AccessLevel
+}
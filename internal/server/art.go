@@ -0,0 +1,85 @@
+package server
+
+import (
+	"log"
+	"strings"
+
+	"bbs/internal/ansiart"
+	"bbs/internal/menu"
+)
+
+// StartArtWatcher caches art screens and watches the art directory, so
+// sysops can edit art while connected and see it the next time it is drawn.
+// Without the watcher art is read from disk every time it is shown.
+func (s *Server) StartArtWatcher() error {
+	dir := s.cfg().Paths.Art
+	err := s.art.Watch(dir, func(path string) {
+		log.Printf("Art changed: %s", path)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Watching %s for art changes", dir)
+	return nil
+}
+
+// loadArt reads an art file from the art directory. It returns nil when
+// name is empty or the file can't be read, so the built-in screen is shown.
+func (s *Session) loadArt(name string) *ansiart.Art {
+	if name == "" {
+		return nil
+	}
+	art, err := s.server.art.Load(s.config.Paths.ArtPath(name))
+	if err != nil {
+		s.log.Printf("failed to load art: %v", err)
+		return nil
+	}
+	return art
+}
+
+// showArt writes art a screen at a time, pausing between screens until
+// the caller has seen it all or stops
+func (s *Session) showArt(art *ansiart.Art) {
+	_, height, err := s.size()
+	if err != nil || height < 4 {
+		height = 24
+	}
+	page := height - 2 // Room for the status bar and the pause prompt
+
+	rows := art.Rows
+	for len(rows) > page {
+		s.write([]byte(strings.Join(rows[:page], "\n") + ansiart.Reset + "\n"))
+		rows = rows[page:]
+
+		s.write([]byte(s.colorScheme.Colorize(s.t("-- More -- (Q to stop)"), "secondary")))
+		key, err := s.readKey()
+		s.write([]byte("\r\033[K"))
+		if err != nil || key == "quit" || key == "escape" || strings.ToLower(key) == "q" {
+			return
+		}
+	}
+	s.write([]byte(strings.Join(rows, "\n") + ansiart.Reset + "\n"))
+}
+
+// showMenuArt clears the screen for a menu's art and shows it
+func (s *Session) showMenuArt(art *ansiart.Art) {
+	s.write([]byte(menu.ClearContentArea + menu.ShowCursor))
+	s.showArt(art)
+}
+
+// sayGoodbye shows the logoff art, or thanks the caller for calling, and
+// a tagline if the board shows them
+func (s *Session) sayGoodbye() {
+	s.write([]byte(menu.ShowCursor))
+	if art := s.loadArt(s.config.BBS.Art.Logoff); art != nil {
+		s.write([]byte(menu.ClearContentArea))
+		s.showArt(art)
+	} else {
+		s.write([]byte(s.colorScheme.Colorize(s.t("\nThank you for calling! Goodbye!\n"), "success")))
+	}
+	if s.config.BBS.Taglines.Goodbye {
+		if tagline := s.tagline(); tagline != "" {
+			s.write([]byte("\n" + s.colorScheme.Colorize("... "+tagline, "highlight") + "\n"))
+		}
+	}
+}
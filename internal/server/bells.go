@@ -0,0 +1,125 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// loadBellSettings loads when the caller wants their bell rung, falling back
+// to the board's bells settings
+func (s *Session) loadBellSettings() {
+	cfg := s.config.BBS.Bells
+	bells := &database.BellSettings{
+		Messages: cfg.Messages,
+		Pages:    cfg.Pages,
+		Errors:   cfg.Errors,
+	}
+	if saved, err := s.db.GetBellSettings(s.user.ID); err != nil {
+		s.log.Printf("failed to load bell settings for %s: %v", s.user.Username, err)
+	} else if saved != nil {
+		bells = saved
+	}
+	s.bells.Store(bells)
+}
+
+// bellSettings returns the caller's bell settings, or the board's before
+// they have logged in
+func (s *Session) bellSettings() database.BellSettings {
+	if b := s.bells.Load(); b != nil {
+		return *b
+	}
+	cfg := s.config.BBS.Bells
+	return database.BellSettings{Messages: cfg.Messages, Pages: cfg.Pages, Errors: cfg.Errors}
+}
+
+// ring rings the caller's terminal bell for reason, if they want it rung
+func (s *Session) ring(reason string) {
+	b := s.bellSettings()
+	if b.Silent {
+		return
+	}
+	switch {
+	case reason == modules.BellMessage && b.Messages,
+		reason == modules.BellPage && b.Pages,
+		reason == modules.BellError && b.Errors:
+		s.writeDirect("\a")
+	}
+}
+
+// Bell rings the caller's bell for a module, subject to their settings
+func (w *TerminalWriter) Bell(reason string) {
+	w.session.ring(reason)
+}
+
+// bellOptions lets the caller choose when their bell rings. Changes are
+// saved for later calls unless the board is a read-only mirror.
+func (s *Session) bellOptions() {
+	for {
+		b := s.bellSettings()
+
+		s.write([]byte(menu.ClearScreen))
+		header := s.colorScheme.Colorize(s.t("--- Sounds ---"), "primary")
+		s.write([]byte(s.colorScheme.CenterText(header, s.width()) + "\n\n"))
+
+		onOff := func(on bool) string {
+			if on {
+				return "On"
+			}
+			return "Off"
+		}
+		rows := []struct {
+			label string
+			on    bool
+		}{
+			{"A) All bells:", !b.Silent},
+			{"M) Instant messages:", b.Messages},
+			{"P) Pages and sysop chat:", b.Pages},
+			{"E) Errors:", b.Errors},
+		}
+		for i, row := range rows {
+			style := "text"
+			if i > 0 && b.Silent {
+				style = "secondary"
+			}
+			s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf("%-28s", s.t(row.label)), "accent") + s.colorScheme.Colorize(onOff(row.on), style) + "\n"))
+		}
+		s.write([]byte("\n" + s.colorScheme.Colorize(s.t("With all bells off, the others are kept but never ring."), "text") + "\n\n"))
+		s.write([]byte(s.colorScheme.Colorize(s.t("A/M/P/E: Change  T: Test  Q: Quit"), "secondary") + "\n"))
+
+		key, err := s.readKey()
+		if err != nil {
+			return
+		}
+		switch strings.ToLower(key) {
+		case "a":
+			b.Silent = !b.Silent
+		case "m":
+			b.Messages = !b.Messages
+		case "p":
+			b.Pages = !b.Pages
+		case "e":
+			b.Errors = !b.Errors
+		case "t":
+			if !b.Silent {
+				s.writeDirect("\a")
+			}
+			continue
+		case "quit", "q", "escape":
+			return
+		default:
+			continue
+		}
+
+		s.bells.Store(&b)
+		if s.db.ReadOnly() {
+			continue
+		}
+		if err := s.db.SaveBellSettings(s.user.ID, b); err != nil {
+			s.log.Printf("failed to save bell settings: %v", err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package server
+
+import "strings"
+
+// boardSeparator divides the board name from the username when a caller
+// logs in to another board, as in "retro/alice"
+const boardSeparator = "/"
+
+// AddBoard lets callers connecting to this server reach another board by
+// logging in as "name/username"
+func (s *Server) AddBoard(name string, board *Server) {
+	s.boards[name] = board
+}
+
+// route returns the board an SSH login is for and the username on that
+// board. Logins without a known board name are for this server's own board.
+func (s *Server) route(login string) (*Server, string) {
+	if name, username, ok := strings.Cut(login, boardSeparator); ok {
+		if board, found := s.boards[name]; found {
+			return board, username
+		}
+	}
+	return s, login
+}
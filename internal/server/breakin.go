@@ -0,0 +1,317 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+)
+
+// Panes of a sysop chat; the sysop types in the top one
+const (
+	sysopPane = iota
+	callerPane
+)
+
+// maxChatLines is how much of each side's text is kept for redrawing
+const maxChatLines = 100
+
+// chatPane holds what one side of a sysop chat has typed
+type chatPane struct {
+	name  string
+	lines []string // Finished lines, oldest first
+	line  []byte   // The line being typed
+}
+
+// sysopChat is a split-screen chat a sysop started by breaking into a
+// caller's session. Both see the same screen; the sysop's keys come from
+// the sysop's session and the caller's from readKey, which hands them here
+// instead of to whatever screen the caller was on.
+type sysopChat struct {
+	mu     sync.Mutex
+	sysop  *Session
+	caller *Session
+	panes  [2]chatPane
+	ended  bool
+}
+
+// breakInto starts a chat with the caller on node and runs it until the
+// sysop presses Escape. It returns false if the sysop's connection was lost.
+func (s *Session) breakInto(node int) (bool, error) {
+	caller := s.server.nodes.visible(s, node)
+	if caller == nil {
+		return true, fmt.Errorf("nobody is on node %d", node)
+	}
+	if caller == s {
+		return true, fmt.Errorf("that's your own node")
+	}
+	if caller.transferring.Load() {
+		return true, fmt.Errorf("%s is in a file transfer or door game", caller.user.Username)
+	}
+	if caller.chatting.Load() {
+		return true, fmt.Errorf("%s is already in a sysop chat", caller.user.Username)
+	}
+
+	chat := &sysopChat{sysop: s, caller: caller}
+	chat.panes[sysopPane].name = s.user.Username
+	chat.panes[callerPane].name = caller.user.Username
+	if !caller.breakIn.CompareAndSwap(nil, chat) {
+		return true, fmt.Errorf("%s is already in a sysop chat", caller.user.Username)
+	}
+
+	s.log.Printf("%s broke in to chat with %s on node %d", s.user.Username, caller.user.Username, node)
+	doing, _ := caller.currentActivity()
+	caller.setActivity("Chatting with the sysop")
+	s.setActivity("Chatting with " + caller.user.Username)
+	defer func() {
+		caller.setActivity(doing)
+		s.setActivity(s.server.commandActivity("whos_online"))
+	}()
+
+	chat.start()
+	for {
+		key, err := s.readTextKey()
+		if err != nil {
+			chat.finish()
+			return false, nil
+		}
+		if key == "escape" || !chat.key(sysopPane, key) {
+			break
+		}
+	}
+	chat.finish()
+	return true, nil
+}
+
+// start takes over both screens
+func (c *sysopChat) start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, s := range []*Session{c.sysop, c.caller} {
+		s.chatting.Store(true)
+		if s.statusBar != nil {
+			s.statusBar.Pause()
+		}
+		s.writeDirect(menu.ClearScreen + menu.ShowCursor)
+		c.drawAll(s)
+	}
+	c.caller.ring(modules.BellPage)
+}
+
+// key handles a key typed on one side. It returns false once the chat has
+// ended.
+func (c *sysopChat) key(side int, key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ended {
+		return false
+	}
+
+	pane := &c.panes[side]
+	switch key {
+	case "enter":
+		pane.newLine(string(pane.line), nil)
+		c.redraw(side, true)
+	case "backspace", "\x7f", "\b":
+		if len(pane.line) > 0 {
+			pane.line = pane.line[:len(pane.line)-1]
+			c.redraw(side, false)
+		}
+	case "quit", "goodbye":
+		// The session reader turns q and g into commands; here they are letters
+		c.typeChar(side, key[0])
+	default:
+		if len(key) == 1 && key[0] >= 32 && key[0] <= 126 {
+			c.typeChar(side, key[0])
+		}
+	}
+	return true
+}
+
+// typeChar adds a character to one side's line, wrapping the last word onto
+// a new line when it fills up
+func (c *sysopChat) typeChar(side int, ch byte) {
+	pane := &c.panes[side]
+	pane.line = append(pane.line, ch)
+	if len(pane.line) < chatWidth {
+		c.redraw(side, false)
+		return
+	}
+
+	cut := strings.LastIndexByte(string(pane.line), ' ')
+	if cut <= 0 {
+		cut = len(pane.line) - 1
+	}
+	carry := append([]byte(nil), pane.line[cut:]...)
+	pane.newLine(string(pane.line[:cut]), []byte(strings.TrimLeft(string(carry), " ")))
+	c.redraw(side, true)
+}
+
+// newLine finishes the current line and starts the next with carry
+func (p *chatPane) newLine(finished string, carry []byte) {
+	p.lines = append(p.lines, finished)
+	if len(p.lines) > maxChatLines {
+		p.lines = p.lines[len(p.lines)-maxChatLines:]
+	}
+	p.line = carry
+}
+
+// finish ends the chat from the sysop's side. The caller's screen is
+// restored by readKey on their next key press.
+func (c *sysopChat) finish() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.ended {
+		c.ended = true
+		c.notice(c.caller, "The sysop has ended the chat. Press any key to continue.")
+	}
+
+	c.sysop.chatting.Store(false)
+	if c.sysop.statusBar != nil {
+		c.sysop.statusBar.Resume()
+	}
+	c.sysop.writeDirect(menu.ClearScreen + menu.HideCursor)
+	c.sysop.ensureStatusBar()
+}
+
+// hangup ends the chat because the caller left
+func (c *sysopChat) hangup() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ended {
+		return
+	}
+	c.ended = true
+	c.notice(c.sysop, c.caller.user.Username+" has disconnected. Press any key to continue.")
+}
+
+// restoreCaller gives the caller their screen back after the chat
+func (c *sysopChat) restoreCaller() {
+	s := c.caller
+	s.breakIn.Store(nil)
+	s.chatting.Store(false)
+	if s.statusBar != nil {
+		s.statusBar.Resume()
+	}
+	s.writeDirect(menu.ClearScreen + menu.HideCursor)
+	s.ensureStatusBar()
+}
+
+// chatWidth is the longest line either side can type
+const chatWidth = 78
+
+// chatLayout is where the panes sit on one terminal
+type chatLayout struct {
+	top    [2]int // First row of each pane
+	height int    // Rows in each pane
+	last   int    // Bottom row the chat may use
+}
+
+// layoutFor fits the panes to a session's terminal, leaving the status bar
+// line alone
+func layoutFor(s *Session) chatLayout {
+	_, height, err := s.size()
+	if err != nil || height < 12 {
+		height = 24
+	}
+	last := height - 1
+	paneHeight := (last - 4) / 2
+	return chatLayout{
+		top:    [2]int{3, 4 + paneHeight},
+		height: paneHeight,
+		last:   last,
+	}
+}
+
+// drawAll draws the whole chat screen for one side
+func (c *sysopChat) drawAll(s *Session) {
+	l := layoutFor(s)
+	var b strings.Builder
+
+	title := fmt.Sprintf("Chat between %s and %s", c.panes[sysopPane].name, c.panes[callerPane].name)
+	hint := "The sysop ends the chat"
+	if s == c.sysop {
+		hint = "Esc ends the chat"
+	}
+	fmt.Fprintf(&b, "\033[1;1H\033[2K%s  %s", s.colorScheme.Colorize(title, "primary"), s.colorScheme.Colorize(hint, "secondary"))
+	for side := range c.panes {
+		fmt.Fprintf(&b, "\033[%d;1H\033[2K%s", l.top[side]-1, c.divider(s, side))
+		c.writePane(&b, s, l, side)
+	}
+	b.WriteString(c.cursor(s, l))
+	s.writeDirect(b.String())
+}
+
+// redraw updates one side's pane on both screens, either just the line
+// being typed or the whole pane after a new line
+func (c *sysopChat) redraw(side int, whole bool) {
+	for _, s := range []*Session{c.sysop, c.caller} {
+		l := layoutFor(s)
+		var b strings.Builder
+		if whole {
+			c.writePane(&b, s, l, side)
+		} else {
+			visible := c.visible(side, l.height)
+			c.writeRow(&b, s, l.top[side]+len(visible)-1, side, visible[len(visible)-1])
+		}
+		b.WriteString(c.cursor(s, l))
+		s.writeDirect(b.String())
+	}
+}
+
+// writePane writes every row of a pane
+func (c *sysopChat) writePane(b *strings.Builder, s *Session, l chatLayout, side int) {
+	visible := c.visible(side, l.height)
+	for row := 0; row < l.height; row++ {
+		text := ""
+		if row < len(visible) {
+			text = visible[row]
+		}
+		c.writeRow(b, s, l.top[side]+row, side, text)
+	}
+}
+
+// writeRow writes one line of a pane
+func (c *sysopChat) writeRow(b *strings.Builder, s *Session, row, side int, text string) {
+	color := "text"
+	if side == sysopPane {
+		color = "accent"
+	}
+	fmt.Fprintf(b, "\033[%d;1H\033[2K%s", row, s.colorScheme.Colorize(text, color))
+}
+
+// visible returns the last lines of a pane that fit, ending with the line
+// being typed
+func (c *sysopChat) visible(side, height int) []string {
+	pane := c.panes[side]
+	lines := append(append([]string(nil), pane.lines...), string(pane.line))
+	if len(lines) > height {
+		lines = lines[len(lines)-height:]
+	}
+	return lines
+}
+
+// divider is the line above a pane naming whose it is
+func (c *sysopChat) divider(s *Session, side int) string {
+	label := "── " + c.panes[side].name + " "
+	return s.colorScheme.Colorize(label+strings.Repeat("─", max(chatWidth-len([]rune(label)), 0)), "border")
+}
+
+// cursor places the cursor at the end of the viewer's own line
+func (c *sysopChat) cursor(s *Session, l chatLayout) string {
+	side := callerPane
+	if s == c.sysop {
+		side = sysopPane
+	}
+	visible := c.visible(side, l.height)
+	return fmt.Sprintf("\033[%d;%dH", l.top[side]+len(visible)-1, len(c.panes[side].line)+1)
+}
+
+// notice shows a line below the panes on one side's screen
+func (c *sysopChat) notice(s *Session, text string) {
+	l := layoutFor(s)
+	s.writeDirect(fmt.Sprintf("\033[%d;1H\033[2K%s", l.last, s.colorScheme.Colorize(text, "highlight")))
+}
@@ -3,8 +3,11 @@ package server
 import (
 	"fmt"
 	"strings"
+	"sync/atomic"
 
+	"bbs/internal/ansi"
 	"bbs/internal/config"
+	"bbs/internal/draw"
 )
 
 // ANSI color codes
@@ -44,34 +47,43 @@ var bgColorCodes = map[string]string{
 }
 
 type ColorScheme struct {
-	config *config.ColorConfig
+	config atomic.Pointer[config.ColorConfig] // Swapped when a caller changes theme mid-call
 }
 
 func NewColorScheme(cfg *config.ColorConfig) *ColorScheme {
-	return &ColorScheme{config: cfg}
+	cs := &ColorScheme{}
+	cs.config.Store(cfg)
+	return cs
+}
+
+// setColors switches the scheme to other colors. Everything drawn from then
+// on uses them, including output from the session's other goroutines.
+func (cs *ColorScheme) setColors(cfg *config.ColorConfig) {
+	cs.config.Store(cfg)
 }
 
 func (cs *ColorScheme) GetColor(colorName string) string {
+	colors := cs.config.Load()
 	var configColor string
 	switch colorName {
 	case "primary":
-		configColor = cs.config.Primary
+		configColor = colors.Primary
 	case "secondary":
-		configColor = cs.config.Secondary
+		configColor = colors.Secondary
 	case "accent":
-		configColor = cs.config.Accent
+		configColor = colors.Accent
 	case "text":
-		configColor = cs.config.Text
+		configColor = colors.Text
 	case "background":
-		configColor = cs.config.Background
+		configColor = colors.Background
 	case "border":
-		configColor = cs.config.Border
+		configColor = colors.Border
 	case "success":
-		configColor = cs.config.Success
+		configColor = colors.Success
 	case "error":
-		configColor = cs.config.Error
+		configColor = colors.Error
 	case "highlight":
-		configColor = cs.config.Highlight
+		configColor = colors.Highlight
 	default:
 		configColor = colorName
 	}
@@ -86,7 +98,7 @@ func (cs *ColorScheme) GetBgColor(colorName string) string {
 	var configColor string
 	switch colorName {
 	case "background":
-		configColor = cs.config.Background
+		configColor = cs.config.Load().Background
 	default:
 		configColor = colorName
 	}
@@ -135,9 +147,8 @@ func MoveCursorDown(lines int) string {
 
 // Selection highlighting
 func (cs *ColorScheme) HighlightSelection(text string, selected bool, width int) string {
-	// Calculate padding based on clean text length (without ANSI codes)
-	cleanText := cs.stripAnsiCodes(text)
-	textLen := len(cleanText)
+	// Calculate padding based on the columns the text takes (without ANSI codes)
+	textLen := ansi.Width(text)
 
 	if selected {
 		// Create a full-width highlight bar with background color
@@ -147,12 +158,12 @@ func (cs *ColorScheme) HighlightSelection(text string, selected bool, width int)
 		}
 
 		// Classic BBS selection bar: cyan background with black text
-		bgCyan := "\033[46m"      // Cyan background
-		fgBlack := "\033[30m"     // Black text
-		reset := "\033[0m"        // Reset
+		bgCyan := "\033[46m"  // Cyan background
+		fgBlack := "\033[30m" // Black text
+		reset := "\033[0m"    // Reset
 
 		// Strip existing colors from text for selection bar
-		cleanText := cs.stripAnsiCodes(text)
+		cleanText := ansi.Strip(text)
 
 		// Build selection bar with background color spanning full width
 		highlightText := bgCyan + fgBlack + " " + cleanText + strings.Repeat(" ", padding) + " " + reset
@@ -165,29 +176,12 @@ func (cs *ColorScheme) HighlightSelection(text string, selected bool, width int)
 	}
 	normalText := " " + text + strings.Repeat(" ", padding) + " "
 	return cs.Colorize(normalText, "text")
-} // Create decorative border pattern
-func (cs *ColorScheme) CreateBorderPattern(width int, pattern string) string {
-	if len(pattern) == 0 {
-		pattern = "-"
-	}
-
-	// Repeat the pattern to fill the width
-	repeats := width / len(pattern)
-	remainder := width % len(pattern)
-
-	borderText := strings.Repeat(pattern, repeats)
-	if remainder > 0 {
-		borderText += pattern[:remainder]
-	}
-
-	return cs.Colorize(borderText, "border")
 }
 
 // Center text within a given terminal width
 func (cs *ColorScheme) CenterText(text string, terminalWidth int) string {
-	// Remove ANSI codes to calculate actual text length
-	cleanText := cs.stripAnsiCodes(text)
-	textLen := len(cleanText)
+	// Measure in columns, without the ANSI codes
+	textLen := ansi.Width(text)
 
 	if textLen >= terminalWidth {
 		return text
@@ -197,36 +191,9 @@ func (cs *ColorScheme) CenterText(text string, terminalWidth int) string {
 	return strings.Repeat(" ", padding) + text
 }
 
-// Helper function to strip ANSI codes for length calculation
-func (cs *ColorScheme) stripAnsiCodes(text string) string {
-	// More robust ANSI stripping using a simple state machine
-	result := ""
-	i := 0
-	
-	for i < len(text) {
-		if i <= len(text)-4 && text[i] == '\033' && text[i+1] == '[' {
-			// Found ESC[, skip until we find a letter (usually 'm')
-			i += 2 // skip \033[
-			for i < len(text) {
-				char := text[i]
-				i++
-				// ANSI sequences typically end with a letter
-				if (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z') {
-					break
-				}
-			}
-		} else {
-			result += string(text[i])
-			i++
-		}
-	}
-	
-	return result
-}
-
 // StripAnsiCodes removes ANSI escape codes from text (public version for interface compatibility)
 func (cs *ColorScheme) StripAnsiCodes(text string) string {
-	return cs.stripAnsiCodes(text)
+	return ansi.Strip(text)
 }
 
 // Center container but left-align content within it
@@ -244,50 +211,34 @@ func (cs *ColorScheme) CenterContainerLeftAlign(text string, containerWidth, ter
 	return leftPadding + textWithMargin
 }
 
-// ASCII Art and Box Drawing
-func (cs *ColorScheme) DrawBox(title string, width int) string {
-	if width < len(title)+4 {
-		width = len(title) + 4
-	}
-
-	topLine := "╔" + strings.Repeat("═", width-2) + "╗"
-	titleLine := fmt.Sprintf("║ %s%s ║",
-		cs.Colorize(title, "primary"),
-		strings.Repeat(" ", width-len(title)-4))
-	bottomLine := "╚" + strings.Repeat("═", width-2) + "╝"
+// BorderStyle returns the style the caller's theme draws boxes and rules in
+func (cs *ColorScheme) BorderStyle() draw.Style {
+	st, _ := draw.StyleNamed(cs.config.Load().Borders)
+	return st
+}
 
-	return cs.Colorize(topLine, "border") + "\n" +
-		cs.Colorize("║", "border") + titleLine + cs.Colorize("║", "border") + "\n" +
-		cs.Colorize(bottomLine, "border")
+// DrawBox returns the rows of box drawn in the theme's border style and
+// colors
+func (cs *ColorScheme) DrawBox(box draw.Box) []string {
+	box.Style = cs.BorderStyle()
+	return box.Draw(cs.Colorize)
 }
 
+// DrawSeparator returns a rule width columns long, in the theme's line for
+// an empty char
 func (cs *ColorScheme) DrawSeparator(width int, char string) string {
+	st := cs.BorderStyle()
 	if char == "" {
-		char = "═"
+		return cs.Colorize(st.Rule(width), "border")
 	}
-	return cs.Colorize(strings.Repeat(char, width), "border")
+	return cs.Colorize(draw.Pattern(st.Convert(char), width), "border")
 }
 
 // BBS-style welcome banner
 func (cs *ColorScheme) CreateWelcomeBanner(systemName, welcomeMsg string) string {
-	width := 78
-
-	banner := cs.Colorize(ClearScreen, "")
-
-	// Top border
-	banner += cs.Colorize("╔"+strings.Repeat("═", width-2)+"╗", "border") + "\n"
-
-	// Title with padding
-	titlePadding := (width - len(systemName) - 2) / 2
-	leftPad := strings.Repeat(" ", titlePadding)
-	rightPad := strings.Repeat(" ", width-len(systemName)-titlePadding-2)
-
-	banner += cs.Colorize("║", "border") +
-		cs.Colorize(leftPad+systemName+rightPad, "primary") +
-		cs.Colorize("║", "border") + "\n"
-
-	// Bottom border
-	banner += cs.Colorize("╚"+strings.Repeat("═", width-2)+"╝", "border") + "\n\n"
+	banner := ClearScreen
+	box := draw.Box{Lines: []string{cs.Colorize(systemName, "primary")}, Width: 78, Center: true}
+	banner += strings.Join(cs.DrawBox(box), "\n") + "\n\n"
 
 	// Welcome message
 	banner += cs.Colorize(welcomeMsg, "text") + "\n\n"
@@ -0,0 +1,451 @@
+package server
+
+import (
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/modules"
+	"bbs/internal/modules/boards"
+	"bbs/internal/modules/bulletins"
+	"bbs/internal/modules/callers"
+	"bbs/internal/modules/feedback"
+	"bbs/internal/modules/files"
+	"bbs/internal/modules/games"
+	"bbs/internal/modules/newscan"
+	"bbs/internal/modules/online"
+	"bbs/internal/modules/page"
+	"bbs/internal/modules/search"
+	"bbs/internal/modules/settings"
+	"bbs/internal/modules/sysop/bans"
+	"bbs/internal/modules/sysop/disk"
+	"bbs/internal/modules/sysop/jobs"
+	"bbs/internal/modules/sysop/menu_editor"
+	"bbs/internal/modules/sysop/timings"
+	"bbs/internal/modules/taglines"
+	"bbs/internal/modules/teleconference"
+	"bbs/internal/modules/timebank"
+	"bbs/internal/modules/usage"
+)
+
+// sysopLevel is the access level of the board's sysops
+const sysopLevel = 255
+
+// sessionModule is a module built into the board. Built-in modules reach
+// into the caller's session, which the writer carries.
+type sessionModule struct {
+	name  string
+	entry modules.MenuEntry
+	run   func(s *Session, keyReader modules.KeyReader) bool
+}
+
+func (m *sessionModule) Name() string {
+	return m.name
+}
+
+func (m *sessionModule) MenuEntry() modules.MenuEntry {
+	return m.entry
+}
+
+func (m *sessionModule) Execute(writer modules.Writer, keyReader modules.KeyReader) bool {
+	w, ok := writer.(*TerminalWriter)
+	if !ok {
+		return true
+	}
+	return m.run(w.session, keyReader)
+}
+
+// Register adds a module to the board before it starts taking calls. Menu
+// items whose command is the module's name run it.
+func (s *Server) Register(m modules.Module) error {
+	return s.modules.Register(m)
+}
+
+// menuWithModules fills in what a menu's items leave to their modules: the
+// title and description when an item has none, and an access level no lower
+// than the module allows
+func (s *Server) menuWithModules(m config.MenuItem) config.MenuItem {
+	items := make([]config.MenuItem, len(m.Submenu))
+	for i, item := range m.Submenu {
+		if module, ok := s.modules.Lookup(item.Command); ok {
+			entry := module.MenuEntry()
+			if item.Title == "" {
+				item.Title = entry.Title
+			}
+			if item.Description == "" {
+				item.Description = entry.Description
+			}
+			if item.AccessLevel < entry.AccessLevel {
+				item.AccessLevel = entry.AccessLevel
+			}
+		}
+		items[i] = item
+	}
+	m.Submenu = items
+	return m
+}
+
+// newRegistry holds the board's built-in modules
+func newRegistry() *modules.Registry {
+	registry := modules.NewRegistry()
+	for _, m := range builtinModules() {
+		if err := registry.Register(m); err != nil {
+			panic(err)
+		}
+	}
+	return registry
+}
+
+// sysopCommand runs one of the sysop screens that live in handleSysopCommand
+func sysopCommand(name, title, description string, writes bool) *sessionModule {
+	return &sessionModule{
+		name: name,
+		entry: modules.MenuEntry{
+			Title:       title,
+			Description: description,
+			Activity:    "Sysop functions",
+			AccessLevel: sysopLevel,
+			Writes:      writes,
+		},
+		run: func(s *Session, keyReader modules.KeyReader) bool {
+			s.handleSysopCommand(name)
+			return true
+		},
+	}
+}
+
+// openMenu returns a module that moves the caller into the menu with id
+func openMenu(id, title, description string, accessLevel int) *sessionModule {
+	return &sessionModule{
+		name:  id,
+		entry: modules.MenuEntry{Title: title, Description: description, AccessLevel: accessLevel},
+		run: func(s *Session, keyReader modules.KeyReader) bool {
+			s.menuHistory = append(s.menuHistory, s.currentMenu)
+			s.currentMenu = id
+			s.selectedIndex = 0
+			return true
+		},
+	}
+}
+
+// builtinModules lists the commands the board has out of the box
+func builtinModules() []modules.Module {
+	goodbye := func(s *Session, keyReader modules.KeyReader) bool {
+		s.sayGoodbye()
+		return false
+	}
+
+	return []modules.Module{
+		openMenu("sysop_menu", "Sysop", "System operator menu", sysopLevel),
+		openMenu("settings_menu", "Settings", "Your account settings", 0),
+		&sessionModule{
+			name:  "goodbye",
+			entry: modules.MenuEntry{Title: "Goodbye", Description: "Logoff system"},
+			run:   goodbye,
+		},
+		&sessionModule{
+			name:  "logout",
+			entry: modules.MenuEntry{Title: "Goodbye", Description: "Logoff system"},
+			run:   goodbye,
+		},
+
+		&sessionModule{
+			name:  "bulletins",
+			entry: modules.MenuEntry{Title: "Bulletins", Description: "Read system bulletins", Activity: "Reading bulletins"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				bulletins.NewModule(s.db, s.colorScheme, s.user.ID).Execute(s.writer, keyReader)
+				return true
+			},
+		},
+		&sessionModule{
+			name:  "messages",
+			entry: modules.MenuEntry{Title: "Messages", Description: "Private mail", Activity: "Reading mail"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				s.mail().Execute(s.writer, keyReader)
+				s.refreshStatusBar()
+				return true
+			},
+		},
+		&sessionModule{
+			name:  "boards",
+			entry: modules.MenuEntry{Title: "Message Boards", Description: "Public message boards", Activity: "Reading message boards"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				boardsModule := boards.NewModule(s.db, s.colorScheme, s.user.Username, s.user.ID, s.user.AccessLevel)
+				boardsModule.SetTagline(s.postTagline)
+				boardsModule.SetModerator(s.moderates)
+				boardsModule.Execute(s.writer, keyReader)
+				return true
+			},
+		},
+		&sessionModule{
+			name:  "new_scan",
+			entry: modules.MenuEntry{Title: "New Scan", Description: "Read what's new since you last read", Activity: "Reading new messages"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return newscan.NewScan(s.db, s.colorScheme, s.user.ID, s.user.AccessLevel).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "search",
+			entry: modules.MenuEntry{Title: "Search", Description: "Find posts, bulletins and mail by keyword", Activity: "Searching messages"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return search.NewSearch(s.db, s.colorScheme, s.user.Username, s.user.ID, s.user.AccessLevel).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "whos_online",
+			entry: modules.MenuEntry{Title: "Who's Online", Description: "See who else is on the board", Activity: "Checking who's online"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				var breakIn online.BreakIn
+				if s.user.AccessLevel >= sysopLevel {
+					breakIn = s.breakInto
+				}
+				online.NewWhosOnline(s.colorScheme, s.whosOnline, s.sendNodeMessage, breakIn, s.node).Execute(s.writer, keyReader)
+				return true
+			},
+		},
+		&sessionModule{
+			name:  "chat",
+			entry: modules.MenuEntry{Title: "Teleconference", Description: "Chat with other callers", Activity: "In the teleconference"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				chatModule := teleconference.New(s.colorScheme, s.server.chat, s.config.BBS.ChatChannels, s.user.Username, s.node, s.user.AccessLevel, s.setActivity)
+				return chatModule.Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "files",
+			entry: modules.MenuEntry{Title: "Files", Description: "File areas", Activity: "Browsing file areas"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				filesModule := files.NewModule(s.db, s.colorScheme, s.config.Paths.Files, s.config.BBS.Files, s.user, &transferChannel{session: s})
+				filesModule.Execute(s.writer, keyReader)
+				return true
+			},
+		},
+		&sessionModule{
+			name:  "games",
+			entry: modules.MenuEntry{Title: "Games", Description: "Online games", Activity: "Choosing a game"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				games.NewModule(s.db, s.colorScheme, s.config.BBS.Doors, s.user.AccessLevel, s.runDoor).Execute(s.writer, keyReader)
+				return true
+			},
+		},
+		&sessionModule{
+			name:  "last_callers",
+			entry: modules.MenuEntry{Title: "Last Callers", Description: "The last ten callers", Activity: "Viewing the last callers"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return callers.NewLastCallers(s.db, s.colorScheme).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "activity",
+			entry: modules.MenuEntry{Title: "Board Activity", Description: "Graphs of calls and posts", Activity: "Viewing board activity"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return usage.NewModule(s.colorScheme, s.server.usage).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "taglines",
+			entry: modules.MenuEntry{Title: "Taglines", Description: "Read and send in taglines", Activity: "Reading taglines"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				taglinesModule := taglines.New(s.db, s.colorScheme, s.user.Username, s.user.AccessLevel, s.config.BBS.Taglines.MaxLength)
+				return taglinesModule.Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "page_sysop",
+			entry: modules.MenuEntry{Title: "Page Sysop", Description: "Ask the sysop to chat", Activity: "Paging the sysop"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return page.New(s.colorScheme, s.pageSysop, s.config.BBS.SysopName).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "help",
+			entry: modules.MenuEntry{Title: "Help", Description: "Help topics and search", Activity: "Reading help"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return s.helpBrowser().Execute(s.writer, keyReader)
+			},
+		},
+
+		// User settings
+		&sessionModule{
+			name:  "profile",
+			entry: modules.MenuEntry{Title: "Profile", Description: "Name, email, password, colors and screen", Activity: "Changing settings"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				userSettings := settings.NewSettings(s.db, s.colorScheme, s.user.ID, s.user.Username)
+				return userSettings.EditProfile(s.writer, keyReader, sessionProfile{session: s})
+			},
+		},
+		&sessionModule{
+			name:  "api_tokens",
+			entry: modules.MenuEntry{Title: "API Tokens", Description: "Manage Personal API Tokens", Activity: "Changing settings"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				settings.NewSettings(s.db, s.colorScheme, s.user.ID, s.user.Username).ManageTokens(s.writer, keyReader)
+				return true
+			},
+		},
+		&sessionModule{
+			name:  "time_bank",
+			entry: modules.MenuEntry{Title: "Time Bank", Description: "Save unused minutes for another day", Activity: "Visiting the time bank"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return timebank.New(s.colorScheme, sessionTimeBank{session: s}).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "terminal_options",
+			entry: modules.MenuEntry{Title: "Terminal Options", Description: "Fix doubled typing, Enter or line drawing", Activity: "Changing settings"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				s.terminalOptions()
+				return true
+			},
+		},
+		&sessionModule{
+			name:  "bell_options",
+			entry: modules.MenuEntry{Title: "Sounds", Description: "Choose when your terminal bell rings", Activity: "Changing settings"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				s.bellOptions()
+				return true
+			},
+		},
+		&sessionModule{
+			name:  "hotkey_options",
+			entry: modules.MenuEntry{Title: "Hotkeys", Description: "Choose the key that jumps to unread messages", Activity: "Changing settings"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				s.hotkeyOptions()
+				return true
+			},
+		},
+		&sessionModule{
+			name:  "notify_options",
+			entry: modules.MenuEntry{Title: "Notifications", Description: "Choose how you hear about mail, replies and messages", Activity: "Changing settings"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				s.notifyOptions()
+				return true
+			},
+		},
+		&sessionModule{
+			name:  "delete_account",
+			entry: modules.MenuEntry{Title: "Delete Account", Description: "Close your account and delete your data", Activity: "Changing settings"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				userSettings := settings.NewSettings(s.db, s.colorScheme, s.user.ID, s.user.Username)
+				return userSettings.DeleteAccount(s.writer, keyReader, s.config.BBS.Retention.DeletionGraceDays, s.closeAccount)
+			},
+		},
+
+		// Sysop functions
+		sysopCommand("create_user", "Create New User", "Create New User Account", true),
+		sysopCommand("edit_user", "Edit User Account", "Edit User Account", true),
+		sysopCommand("delete_user", "Delete User Account", "Delete User Account", true),
+		sysopCommand("view_users", "View All Users", "View, Find and Sort Users", false),
+		sysopCommand("change_password", "Change User Password", "Change User Password", true),
+		sysopCommand("force_password_change", "Require Password Change", "Require Password Change at Next Login", true),
+		sysopCommand("toggle_user", "Toggle User Status", "Toggle User Active Status", true),
+		sysopCommand("pending_registrations", "New User Approvals", "Review Pending Registrations", true),
+		sysopCommand("subscriptions", "Subscriptions", "Extend or Expire Accounts", true),
+		sysopCommand("system_stats", "System Statistics", "System Statistics", false),
+		sysopCommand("bulletin_management", "Bulletin Management", "Post, Schedule and Expire Bulletins", true),
+		&sessionModule{
+			name:  "caller_report",
+			entry: modules.MenuEntry{Title: "Caller Report", Description: "Caller Log Report", Activity: "Sysop functions", AccessLevel: sysopLevel},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return callers.NewReport(s.db, s.colorScheme).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "banned_ips",
+			entry: modules.MenuEntry{Title: "Banned Addresses", Description: "View and Clear IP Bans", Activity: "Sysop functions", AccessLevel: sysopLevel, Writes: true},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return bans.NewBans(s.db, s.colorScheme).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "tagline_queue",
+			entry: modules.MenuEntry{Title: "Tagline Queue", Description: "Approve Waiting Taglines", Activity: "Sysop functions", AccessLevel: sysopLevel, Writes: true},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return taglines.NewQueue(s.db, s.colorScheme).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "feedback_queue",
+			entry: modules.MenuEntry{Title: "Feedback Queue", Description: "Read Notes from Callers", Activity: "Sysop functions", AccessLevel: sysopLevel, Writes: true},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return feedback.NewQueue(s.db, s.colorScheme).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "disk_usage",
+			entry: modules.MenuEntry{Title: "Disk Usage", Description: "Disk Usage and Cleanup", Activity: "Sysop functions", AccessLevel: sysopLevel},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				dashboard := disk.NewDashboard(s.db, s.colorScheme, s.config, func() { s.server.applyRetention(time.Now()) })
+				return dashboard.Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			// Co-sysops below the sysop's level may be allowed to review
+			// uploads, so the module checks for itself
+			name:  "upload_review",
+			entry: modules.MenuEntry{Title: "Upload Approvals", Description: "Review Uploads Waiting for Approval", Activity: "Sysop functions"},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				if !files.CanReview(s.config.BBS.Files, s.user.AccessLevel) {
+					s.denyAccess()
+					return true
+				}
+				return files.NewReview(s.db, s.colorScheme, s.config.Paths.Files, s.user.Username).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "menu_editor",
+			entry: modules.MenuEntry{Title: "Menu Editor", Description: "Add, Edit and Reorder Menus", Activity: "Sysop functions", AccessLevel: sysopLevel},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				if len(s.config.MenuFiles) > 0 {
+					// Saving would write the menus from every file into config.yaml
+					s.write([]byte("\n\n" + s.colorScheme.Colorize(s.t("The menus are split into files under menus/. Edit those files instead."), "error") + "\n"))
+					s.waitForKey()
+					return true
+				}
+				editor := menu_editor.NewMenuEditor(s.colorScheme, s.config.BBS.Menus, func(menus []config.MenuItem) error {
+					cfg, err := s.server.saveMenus(menus)
+					if err != nil {
+						return err
+					}
+					s.config = cfg // The sysop sees their changes straight away
+					return nil
+				})
+				return editor.Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "command_timings",
+			entry: modules.MenuEntry{Title: "Command Timings", Description: "Find What Makes the Board Slow", Activity: "Sysop functions", AccessLevel: sysopLevel},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return timings.NewTimings(s.colorScheme, s.server.metrics, s.server).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "scheduled_jobs",
+			entry: modules.MenuEntry{Title: "Scheduled Jobs", Description: "Next Runs and Last Results of Jobs", Activity: "Sysop functions", AccessLevel: sysopLevel},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return jobs.NewJobs(s.colorScheme, s.server.jobs).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "node_monitor",
+			entry: modules.MenuEntry{Title: "Node Monitor", Description: "Watch, Message and Disconnect Nodes", Activity: "Sysop functions", AccessLevel: sysopLevel},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				monitor := online.NewNodeMonitor(s.colorScheme, s.whosOnline, s.sendNodeMessage, s.disconnectNode, s.node)
+				return monitor.Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "broadcast",
+			entry: modules.MenuEntry{Title: "Broadcast", Description: "Send a Message to Everyone Online", Activity: "Sysop functions", AccessLevel: sysopLevel},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return online.NewBroadcast(s.colorScheme, s.broadcast).Execute(s.writer, keyReader)
+			},
+		},
+		&sessionModule{
+			name:  "force_logoff",
+			entry: modules.MenuEntry{Title: "Force Logoff", Description: "End a Caller's Session", Activity: "Sysop functions", AccessLevel: sysopLevel},
+			run: func(s *Session, keyReader modules.KeyReader) bool {
+				return online.NewForceLogoff(s.colorScheme, s.whosOnline, s.logoffUser, s.node).Execute(s.writer, keyReader)
+			},
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"sync/atomic"
+)
+
+// connLogger prefixes log lines with a short per-connection ID so that
+// activity from the goroutines serving one caller can be correlated
+type connLogger struct {
+	id     string
+	remote string
+	quiet  atomic.Bool // Drop lines, for connections from tarpitted addresses
+}
+
+// newConnLogger creates a logger with a fresh random connection ID
+func newConnLogger(remote string) *connLogger {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return &connLogger{id: "????????", remote: remote}
+	}
+	return &connLogger{id: hex.EncodeToString(buf), remote: remote}
+}
+
+// Printf logs a message tagged with the connection ID
+func (l *connLogger) Printf(format string, args ...interface{}) {
+	if l.quiet.Load() {
+		return
+	}
+	log.Printf("[%s] %s", l.id, fmt.Sprintf(format, args...))
+}
+
+// registerConn associates a logger with a connection's remote address so the
+// SSH auth callbacks, which only see connection metadata, can find it. It
+// returns false without registering when the host already has as many
+// connections open as server.max_per_ip allows.
+func (s *Server) registerConn(remote string, l *connLogger) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	if limit := s.cfg().Server.MaxPerIP; limit > 0 {
+		if host, _, err := net.SplitHostPort(remote); err == nil && s.connsFrom(host) >= limit {
+			return false
+		}
+	}
+	s.conns[remote] = l
+	return true
+}
+
+// unregisterConn forgets a closed connection
+func (s *Server) unregisterConn(remote string) {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	delete(s.conns, remote)
+}
+
+// connLog returns the logger for a remote address, creating an untracked one
+// if the connection is unknown
+func (s *Server) connLog(remote string) *connLogger {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	if l, ok := s.conns[remote]; ok {
+		return l
+	}
+	return newConnLogger(remote)
+}
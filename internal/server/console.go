@@ -0,0 +1,139 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/terminal"
+)
+
+// consoleTimeBump is how much time Alt-T gives the caller for this call
+const consoleTimeBump = 5 * time.Minute
+
+// consoleLegend lists the sysop keys on the console's second status line.
+// %s is what Alt-A does next.
+const consoleLegend = "Sysop keys  Alt-T: +5 min  Alt-A: %s  Alt-C: Chat  Alt-H: Hang up"
+
+// onConsole reports whether the session is at the board's own keyboard,
+// where the sysop keys work
+func (s *Session) onConsole() bool {
+	_, ok := s.terminal.(*terminal.LocalTerminal)
+	return ok
+}
+
+// consoleLegendText is the legend for the caller's current access
+func (s *Session) consoleLegendText() string {
+	if s.raisedAccess {
+		return fmt.Sprintf(consoleLegend, fmt.Sprintf("Back to level %d", s.ownAccess))
+	}
+	return fmt.Sprintf(consoleLegend, "Sysop access")
+}
+
+// consoleKey handles a sysop key pressed at the console. It reports whether
+// the key was one, and whether the screen needs redrawing afterwards.
+func (s *Session) consoleKey(key string) (handled, redraw bool) {
+	if !s.consoleLegend.Load() || s.node == 0 {
+		return false, false
+	}
+
+	switch key {
+	case "alt-t":
+		s.bumpTime()
+		return true, false
+	case "alt-a":
+		s.toggleAccess()
+		return true, true
+	case "alt-c":
+		s.consoleChat()
+		return true, true
+	case "alt-h":
+		s.endCall("the console")
+		return true, false
+	}
+	return false, false
+}
+
+// bumpTime gives the caller more time for this call
+func (s *Session) bumpTime() {
+	s.timeMu.Lock()
+	limited := !s.timeDeadline.IsZero()
+	if limited {
+		s.timeDeadline = s.timeDeadline.Add(consoleTimeBump)
+	}
+	s.timeMu.Unlock()
+
+	if !limited {
+		s.queueOLM("You have no time limit to extend.", "")
+		return
+	}
+	s.log.Printf("console gave %s %s more", s.user.Username, consoleTimeBump)
+	s.updateCountdown()
+	s.refreshStatusBar()
+	s.queueOLM(fmt.Sprintf("The sysop gave you %d more minutes.", int(consoleTimeBump.Minutes())), "")
+}
+
+// toggleAccess raises the caller to sysop access for the rest of the call,
+// or puts their own level back. Nothing is saved to their account.
+func (s *Session) toggleAccess() {
+	if s.raisedAccess {
+		s.user.AccessLevel = s.ownAccess
+		s.raisedAccess = false
+	} else {
+		if s.user.AccessLevel >= 255 {
+			s.queueOLM("You already have sysop access.", "")
+			return
+		}
+		s.ownAccess = s.user.AccessLevel
+		s.user.AccessLevel = 255
+		s.raisedAccess = true
+	}
+	s.log.Printf("console set %s's access to level %d for this call", s.user.Username, s.user.AccessLevel)
+	s.statusBar.SetLegend(s.consoleLegendText())
+	s.queueOLM(fmt.Sprintf("Your access is level %d for this call.", s.user.AccessLevel), "")
+}
+
+// consoleChat is a chat at the console: the sysop and the caller take turns
+// at the same keyboard on one screen, until Escape
+func (s *Session) consoleChat() {
+	doing, _ := s.currentActivity()
+	s.setActivity("Chatting with the sysop")
+	defer s.setActivity(doing)
+	s.log.Printf("console started a chat with %s", s.user.Username)
+
+	s.write([]byte(menu.ClearScreen + menu.ShowCursor))
+	header := s.colorScheme.Colorize(s.t("--- Chat with the Sysop ---"), "primary")
+	s.write([]byte(s.colorScheme.CenterText(header, s.width()) + "\n"))
+	s.write([]byte(s.colorScheme.Colorize(s.t("Take turns at the keyboard. Esc ends the chat."), "secondary") + "\n\n"))
+	s.ring(modules.BellPage)
+
+	column := 0
+	for {
+		key, err := s.readTerminalKey(true)
+		if err != nil || key == input.Escape {
+			break
+		}
+		switch key {
+		case input.Enter:
+			s.write([]byte("\n"))
+			column = 0
+		case input.Backspace:
+			if column > 0 {
+				s.write([]byte("\b \b"))
+				column--
+			}
+		default:
+			if len(key) == 1 && input.Printable(key[0]) {
+				s.write([]byte(key))
+				column++
+			}
+		}
+		if column >= chatWidth {
+			s.write([]byte("\n"))
+			column = 0
+		}
+	}
+	s.write([]byte(menu.HideCursor))
+}
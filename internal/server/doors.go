@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/doors"
+)
+
+// runDoor runs a door for the caller with drop files in the node's own
+// directory, so callers on different nodes can play at once
+func (s *Session) runDoor(door config.DoorConfig) error {
+	width, height, err := s.size()
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	totals, err := s.db.GetTransferTotals(s.user.Username)
+	if err != nil {
+		totals = &database.TransferTotals{}
+	}
+
+	info := doors.Info{
+		Node:        s.node,
+		Username:    s.user.Username,
+		RealName:    s.user.RealName,
+		AccessLevel: s.user.AccessLevel,
+		TotalCalls:  s.user.TotalCalls,
+		TimeLeft:    doors.TimeLimit(door),
+		Width:       width,
+		Height:      height,
+		ANSI:        true,
+		Uploads:     totals.Uploads,
+		Downloads:   totals.Downloads,
+		UploadKB:    totals.UploadBytes / 1024,
+		DownloadKB:  totals.DownloadBytes / 1024,
+		SystemName:  s.config.BBS.SystemName,
+		SysopName:   s.config.BBS.SysopName,
+		Dir:         filepath.Join(s.config.Paths.Data, "doors", fmt.Sprintf("node%d", s.node)),
+	}
+	// Doors see the caller's time left today when it's the shorter limit,
+	// unless they are allowed to run past it
+	if s.timeLimit > 0 && !s.config.BBS.Exempt.Doors.TimeLimit {
+		if left := s.timeLeft(time.Now()); left < info.TimeLeft {
+			info.TimeLeft = left
+		}
+	}
+	if s.user.LastCall != nil {
+		info.LastCall = *s.user.LastCall
+	}
+
+	workDir := s.config.Paths.Data
+	if door.Dir != "" {
+		workDir = s.config.Paths.DataPath(door.Dir)
+	}
+
+	s.setActivity("Playing " + door.Name)
+	defer s.setActivity(s.server.commandActivity("games"))
+	s.log.Printf("%s opened door %s", s.user.Username, door.Name)
+
+	err = s.withRawTerminal(s.config.BBS.Exempt.Doors, func(rw io.ReadWriter) error {
+		return doors.Run(context.Background(), door, workDir, info, rw)
+	})
+	if err != nil {
+		s.log.Printf("door %s for %s: %v", door.Name, s.user.Username, err)
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bbs/internal/terminal"
+)
+
+// encodingSample is drawn in each character set so callers can see which
+// one their client shows as a box
+const encodingSample = "╔══════╗ ░▒▓█"
+
+// encodingNames describes each character set on the Terminal Options screen
+var encodingNames = map[string]string{
+	terminal.UTF8:  "UTF-8",
+	terminal.CP437: "CP437 (IBM PC)",
+}
+
+// negotiateEncoding picks the character set for the caller's client from
+// server.terminal.encoding. With "auto" it goes by the TERM the client
+// sent; with "ask" the caller picks whichever sample looks right.
+func (s *Session) negotiateEncoding(t *terminal.SSHTerminal) {
+	encoding := terminal.DetectEncoding(t.TermType())
+	switch s.config.Server.Terminal.Encoding {
+	case terminal.UTF8, terminal.CP437:
+		encoding = s.config.Server.Terminal.Encoding
+	case "ask":
+		encoding = s.askEncoding(encoding)
+	}
+	s.clientEncoding = encoding
+	s.setEncoding(encoding)
+	if encoding == terminal.CP437 {
+		s.log.Printf("client draws with CP437, translating output")
+	}
+}
+
+// setEncoding sets the character set output is translated to
+func (s *Session) setEncoding(encoding string) {
+	s.cp437.Store(encoding == terminal.CP437)
+}
+
+// encoding returns the caller's character set
+func (s *Session) encoding() string {
+	if s.cp437.Load() {
+		return terminal.CP437
+	}
+	return terminal.UTF8
+}
+
+// askEncoding shows the sample in both character sets and returns the one
+// the caller picks, or guess if they just press Enter
+func (s *Session) askEncoding(guess string) string {
+	// Both samples go out untranslated, each already in its own bytes
+	s.cp437.Store(false)
+	def := "1"
+	if guess == terminal.CP437 {
+		def = "2"
+	}
+	s.write([]byte("\nWhich line shows a box with shading?\n\n"))
+	s.write([]byte("  1) " + encodingSample + "  " + encodingNames[terminal.UTF8] + "\n"))
+	s.write([]byte("  2) " + string(terminal.ToCP437([]byte(encodingSample))) + "  " + encodingNames[terminal.CP437] + "\n\n"))
+	s.write([]byte("Press 1 or 2 [" + def + "]: "))
+
+	for {
+		key, err := s.readKey()
+		if err != nil {
+			return guess
+		}
+		if key == "enter" {
+			key = def
+		}
+		switch key {
+		case "1":
+			s.write([]byte("1\n"))
+			return terminal.UTF8
+		case "2":
+			s.write([]byte("2\n"))
+			return terminal.CP437
+		}
+	}
+}
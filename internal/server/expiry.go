@@ -0,0 +1,41 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"bbs/internal/database"
+)
+
+// checkExpiry refuses callers whose account has expired and warns those
+// whose account expires soon
+func (s *Session) checkExpiry() bool {
+	now := time.Now()
+	expires, err := s.db.CheckExpiry(s.user, now)
+	if err != nil && !errors.Is(err, database.ErrAccountExpired) {
+		// Don't keep callers out because the expiry couldn't be read
+		s.log.Printf("account expiry: %v", err)
+		return true
+	}
+	if expires == nil {
+		return true
+	}
+
+	cfg := s.config.BBS.Expiry
+	if err != nil {
+		s.log.Printf("login refused for %s: account expired on %s", s.user.Username, expires.Format("2006-01-02"))
+		s.write([]byte("\n" + s.colorScheme.Colorize(cfg.Message, "error") + "\n"))
+		return false
+	}
+
+	if cfg.WarnDays > 0 && expires.Before(now.AddDate(0, 0, cfg.WarnDays)) {
+		days := int(math.Ceil(expires.Sub(now).Hours() / 24))
+		notice := fmt.Sprintf("Your account expires on %s, in %d day(s).", expires.Format("Jan 2, 2006"), days)
+		s.write([]byte("\n" + s.colorScheme.Colorize(notice, "accent") + "\n"))
+		s.write([]byte(s.colorScheme.Colorize(s.t("Please contact the sysop to renew it."), "text") + "\n\n"))
+		s.waitForKey()
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+)
+
+// fakeTerminal records what a session writes and has nothing to read
+type fakeTerminal struct {
+	mu  sync.Mutex
+	out bytes.Buffer
+}
+
+func (t *fakeTerminal) Read(p []byte) (int, error) { return 0, io.EOF }
+func (t *fakeTerminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.out.Write(p)
+}
+func (t *fakeTerminal) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.out.String()
+}
+func (t *fakeTerminal) SetSize(width, height int) error { return nil }
+func (t *fakeTerminal) Size() (int, int, error)         { return 80, 24, nil }
+func (t *fakeTerminal) MakeRaw() error                  { return nil }
+func (t *fakeTerminal) Restore() error                  { return nil }
+func (t *fakeTerminal) Close() error                    { return nil }
+func (t *fakeTerminal) ReadLine() (string, error)       { return "", io.EOF }
+func (t *fakeTerminal) SetPrompt(prompt string)         {}
+
+// fakeChannel records an SSH command's output and exit status
+type fakeChannel struct {
+	out, stderr bytes.Buffer
+	status      uint32
+}
+
+func (c *fakeChannel) Read(p []byte) (int, error)  { return 0, io.EOF }
+func (c *fakeChannel) Write(p []byte) (int, error) { return c.out.Write(p) }
+func (c *fakeChannel) Close() error                { return nil }
+func (c *fakeChannel) CloseWrite() error           { return nil }
+func (c *fakeChannel) Stderr() io.ReadWriter       { return &c.stderr }
+func (c *fakeChannel) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	if name == "exit-status" {
+		var msg struct{ Status uint32 }
+		ssh.Unmarshal(payload, &msg)
+		c.status = msg.Status
+	}
+	return true, nil
+}
+
+// newExpiryServer returns a board with alice on it, whose account expired
+// an hour ago
+func newExpiryServer(t *testing.T) (*Server, *database.User) {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := database.Initialize(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.CreateUser(&database.User{Username: "alice", Password: "secret", AccessLevel: 10}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	alice, err := db.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	lapsed := time.Now().Add(-time.Hour)
+	if err := db.SetExpiry(alice.ID, &lapsed); err != nil {
+		t.Fatalf("SetExpiry failed: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Server.HostKeyPath = filepath.Join(dir, "host_key")
+	cfg.BBS.Expiry.Message = "Your account has expired."
+	return NewServer(cfg, db), alice
+}
+
+func TestCheckExpiry_Login(t *testing.T) {
+	board, alice := newExpiryServer(t)
+	term := &fakeTerminal{}
+	session := board.NewSession(term, "alice")
+	session.user = alice
+
+	if session.checkExpiry() {
+		t.Error("an expired account was let in")
+	}
+	session.output.Close(closeWait)
+	if !strings.Contains(term.String(), "Your account has expired.") {
+		t.Errorf("the caller wasn't told why: %q", term.String())
+	}
+}
+
+func TestCheckExpiry_Export(t *testing.T) {
+	board, _ := newExpiryServer(t)
+	channel := &fakeChannel{}
+	board.NewSession(&fakeTerminal{}, "alice").runExport(channel, false, []string{"bulletins"})
+
+	if channel.status != 1 || !strings.Contains(channel.stderr.String(), "Access denied") {
+		t.Errorf("export for an expired account exited %d with %q", channel.status, channel.stderr.String())
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"bbs/internal/database"
+	"bbs/internal/transcript"
+)
+
+// exportCommand is the exec command that prints a bulletin or thread as
+// plain text, as in "ssh user@host export thread 12 > thread.txt"
+const exportCommand = "export"
+
+// errExportUsage explains the export command
+var errExportUsage = errors.New(`usage: export bulletins
+       export bulletin ID [WIDTH]
+       export thread POST-ID [WIDTH]
+WIDTH is the line width, 20 to 200 (default 72).`)
+
+// runExport writes a transcript to the channel for the logged-in caller
+// and sends the exit status. Lines end in CRLF when the client asked for
+// a pty.
+func (s *Session) runExport(channel ssh.Channel, pty bool, args []string) {
+	exit := func(code uint32) {
+		channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{code}))
+	}
+	fail := func(msg string) {
+		io.WriteString(channel.Stderr(), msg)
+		exit(1)
+	}
+
+	user, err := s.db.GetUser(s.prefilledUsername)
+	if err == nil {
+		_, err = s.db.CheckExpiry(user, time.Now())
+	}
+	if err != nil {
+		s.log.Printf("export refused for %q: %v", s.prefilledUsername, err)
+		fail("Access denied.\n")
+		return
+	}
+	s.user = user
+
+	text, err := s.exportText(args)
+	if err != nil {
+		fail(err.Error() + "\n")
+		return
+	}
+	s.log.Printf("export %s requested by %s", strings.Join(args, " "), user.Username)
+
+	if pty {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	io.WriteString(channel, text)
+	exit(0)
+}
+
+// exportText renders what the export command's arguments ask for
+func (s *Session) exportText(args []string) (string, error) {
+	if len(args) == 1 && args[0] == "bulletins" {
+		bulletins, err := s.db.GetBulletins(50)
+		if err != nil {
+			return "", fmt.Errorf("failed to load bulletins: %w", err)
+		}
+		var b strings.Builder
+		for _, bulletin := range bulletins {
+			fmt.Fprintf(&b, "%4d  %s  %s\n", bulletin.ID, bulletin.CreatedAt.Format("2006-01-02"), transcript.Plain(bulletin.Title))
+		}
+		return b.String(), nil
+	}
+
+	if len(args) < 2 || len(args) > 3 {
+		return "", errExportUsage
+	}
+	id, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "", errExportUsage
+	}
+	width := 0
+	if len(args) == 3 {
+		if width, err = strconv.Atoi(args[2]); err != nil {
+			return "", errExportUsage
+		}
+	}
+
+	switch args[0] {
+	case "bulletin":
+		// Bulletins callers can't see yet, or any more, don't exist for them
+		bulletin, err := s.db.GetBulletinByID(id)
+		if err != nil || !bulletin.Current(time.Now()) {
+			return "", fmt.Errorf("no bulletin %d", id)
+		}
+		return transcript.Bulletin(bulletin, width), nil
+	case "thread":
+		post, err := s.db.GetPost(id)
+		if err != nil {
+			return "", fmt.Errorf("no post %d", id)
+		}
+		// Posts in topics above the caller's access level don't exist for them
+		topics, err := s.db.GetTopics(s.user.AccessLevel)
+		if err != nil {
+			return "", fmt.Errorf("failed to load topics: %w", err)
+		}
+		for _, topic := range topics {
+			if topic.ID != post.TopicID {
+				continue
+			}
+			replies, err := s.db.GetReplies(post.ID)
+			if err != nil {
+				return "", fmt.Errorf("failed to load replies: %w", err)
+			}
+			return transcript.Thread(topic.Name, post, database.Thread(replies), width), nil
+		}
+		return "", fmt.Errorf("no post %d", id)
+	}
+	return "", errExportUsage
+}
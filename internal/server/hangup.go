@@ -0,0 +1,23 @@
+package server
+
+// hangUp notes that the caller's connection has been lost, found when a
+// write to it failed. The terminal is closed so a pending read fails too,
+// and the session unwinds through the normal logoff path instead of going
+// on drawing screens nobody will see.
+func (s *Session) hangUp(err error) {
+	if !s.hungUp.CompareAndSwap(false, true) {
+		return
+	}
+
+	who := "caller"
+	if s.user != nil {
+		who = s.user.Username
+	}
+	s.log.Printf("connection to %s lost: %v", who, err)
+	s.terminal.Close()
+}
+
+// HungUp reports whether the caller's connection has been lost
+func (w *TerminalWriter) HungUp() bool {
+	return w.session.hungUp.Load()
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"log"
+	"os"
+
+	"bbs/internal/help"
+	"bbs/internal/modules/boards"
+	"bbs/internal/modules/bulletins"
+	"bbs/internal/modules/callers"
+	"bbs/internal/modules/files"
+	"bbs/internal/modules/games"
+	"bbs/internal/modules/messages"
+	"bbs/internal/modules/newscan"
+	"bbs/internal/modules/online"
+	"bbs/internal/modules/page"
+	"bbs/internal/modules/search"
+	"bbs/internal/modules/settings"
+	"bbs/internal/modules/taglines"
+	"bbs/internal/modules/teleconference"
+	"bbs/internal/modules/timebank"
+	"bbs/internal/modules/tutorial"
+	"bbs/internal/modules/usage"
+)
+
+// boardHelp holds the help topics for the menus and the screens the server
+// runs itself
+//
+//go:embed help/*.md
+var boardHelp embed.FS
+
+// helpDir is the directory under the data directory where the sysop can add
+// help topics or replace the built-in ones
+const helpDir = "help"
+
+// loadHelp registers the help topics of each module, then the sysop's own
+func (s *Server) loadHelp() *help.Registry {
+	registry := help.NewRegistry()
+	sources := []struct {
+		module string
+		files  embed.FS
+	}{
+		{"board", boardHelp},
+		{"bulletins", bulletins.Help},
+		{"messages", messages.Help},
+		{"boards", boards.Help},
+		{"online", online.Help},
+		{"teleconference", teleconference.Help},
+		{"files", files.Help},
+		{"games", games.Help},
+		{"callers", callers.Help},
+		{"usage", usage.Help},
+		{"page", page.Help},
+		{"timebank", timebank.Help},
+		{"settings", settings.Help},
+		{"newscan", newscan.Help},
+		{"search", search.Help},
+		{"taglines", taglines.Help},
+	}
+	for _, src := range sources {
+		sub, err := fs.Sub(src.files, "help")
+		if err == nil {
+			err = registry.Register(src.module, sub)
+		}
+		if err != nil {
+			log.Printf("help: %v", err)
+		}
+	}
+
+	dir := s.cfg().Paths.RootPath(helpDir)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		if err := registry.Register("sysop", os.DirFS(dir)); err != nil {
+			log.Printf("help: %v", err)
+		}
+	}
+	return registry
+}
+
+// showHelp opens the help topic for a menu command, falling back to the
+// topic for the menu it is on and then to the help menu
+func (s *Session) showHelp(command, menuID string) {
+	writer := s.writer
+	keyReader := &TerminalKeyReader{session: s}
+	browser := s.helpBrowser()
+
+	for _, name := range []string{command, menuID} {
+		if topic := s.server.help.Topic(name); topic != nil {
+			browser.Show(writer, keyReader, topic)
+			return
+		}
+	}
+	browser.Execute(writer, keyReader)
+}
+
+// helpBrowser creates the Help menu with the keyboard tour on it
+func (s *Session) helpBrowser() *help.Browser {
+	return help.NewBrowser(s.server.help, s.colorScheme).WithTutorial(func() bool {
+		return tutorial.NewTutorial(s.db, s.colorScheme, s.user.ID).Execute(s.writer, &TerminalKeyReader{session: s})
+	})
+}
+
+// offerTutorial offers the keyboard tour on a caller's first call, unless
+// they have already started or turned it down
+func (s *Session) offerTutorial() {
+	if !s.config.BBS.TutorialOnFirstCall || s.user.TotalCalls > 0 || s.db.ReadOnly() {
+		return
+	}
+	progress, err := s.db.GetTutorialProgress(s.user.ID)
+	if err != nil {
+		s.log.Printf("failed to load tutorial progress for %s: %v", s.user.Username, err)
+		return
+	}
+	if progress != nil {
+		return
+	}
+	tutorial.NewTutorial(s.db, s.colorScheme, s.user.ID).Offer(s.writer, &TerminalKeyReader{session: s})
+}
@@ -11,8 +11,9 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
-// GenerateHostKey creates a new RSA host key and saves it to file
-func GenerateHostKey(filename string) (ssh.Signer, error) {
+// GenerateHostKey creates a new RSA host key of at least bits (and never
+// under 2048) and saves it to file, or loads the one already there
+func GenerateHostKey(filename string, bits int) (ssh.Signer, error) {
 	// Check if key file already exists
 	if _, err := os.Stat(filename); err == nil {
 		// Load existing key
@@ -30,7 +31,7 @@ func GenerateHostKey(filename string) (ssh.Signer, error) {
 	}
 
 	// Generate new RSA key
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	privateKey, err := rsa.GenerateKey(rand.Reader, max(bits, 2048))
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate RSA key: %w", err)
 	}
@@ -0,0 +1,41 @@
+package server
+
+import "time"
+
+// idleWarning is how long before an idle caller is logged off they are warned
+const idleWarning = time.Minute
+
+// watchIdle logs the caller off once they have pressed no key for
+// bbs.idle_minutes, warning them a minute before, until the session leaves
+// its node. Transfers and doors exempt from it hold the clock.
+func (s *Session) watchIdle() {
+	limit := time.Duration(s.config.BBS.IdleMinutes) * time.Minute
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	warned := false
+	for {
+		select {
+		case <-ticker.C:
+			if s.exempt().Idle {
+				warned = false
+				continue
+			}
+			_, idle := s.currentActivity()
+			switch {
+			case idle >= limit:
+				s.forceLogoff("idle timeout", "You have been idle too long. Please call again!")
+				return
+			case idle >= limit-idleWarning:
+				if !warned {
+					warned = true
+					s.queueOLM("Press a key within a minute or you'll be logged off for being idle.", "")
+				}
+			default:
+				warned = false
+			}
+		case <-s.olmDone:
+			return
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/scheduler"
+)
+
+// statsCatchUpDays is how many missed days the stats rollup counts when the
+// board has been down
+const statsCatchUpDays = 31
+
+// StartJobScheduler schedules the maintenance jobs listed under bbs.jobs
+func (s *Server) StartJobScheduler() error {
+	for _, cfg := range s.cfg().BBS.Jobs {
+		name := cfg.Name
+		if name == "" {
+			name = cfg.Task
+		}
+		run, err := s.jobTask(cfg)
+		if err != nil {
+			return fmt.Errorf("job %q: %w", name, err)
+		}
+		if err := s.jobs.Add(name, cfg.Schedule, run); err != nil {
+			return err
+		}
+		log.Printf("Job %q runs on schedule %q", name, cfg.Schedule)
+	}
+	s.jobs.Start()
+	return nil
+}
+
+// jobTask returns what a configured job does. Every task writes to the
+// database, so none runs on a read-only mirror.
+func (s *Server) jobTask(cfg config.JobConfig) (scheduler.Func, error) {
+	var run scheduler.Func
+	switch cfg.Task {
+	case "vacuum":
+		run = s.compactDatabase
+	case "expire_bulletins":
+		run = func(now time.Time) (string, error) {
+			archived, err := s.db.ArchiveExpiredBulletins(now)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("archived %d expired bulletins", archived), nil
+		}
+	case "stats_rollup":
+		run = s.rollupStats
+	case "bulletin":
+		if cfg.Title == "" || cfg.Body == "" {
+			return nil, fmt.Errorf("a bulletin job needs a title and a body")
+		}
+		if cfg.ExpireDays < 0 {
+			return nil, fmt.Errorf("expire_days can't be negative")
+		}
+		run = func(now time.Time) (string, error) {
+			return s.postScheduledBulletin(cfg, now)
+		}
+	default:
+		return nil, fmt.Errorf("unknown task %q, expected vacuum, expire_bulletins, stats_rollup or bulletin", cfg.Task)
+	}
+
+	return func(now time.Time) (string, error) {
+		if s.db.ReadOnly() {
+			return "skipped, the database is read-only", nil
+		}
+		return run(now)
+	}, nil
+}
+
+// compactDatabase rebuilds the database to give back the space left by
+// deleted rows
+func (s *Server) compactDatabase(now time.Time) (string, error) {
+	before, err := s.db.Size()
+	if err != nil {
+		return "", err
+	}
+	if err := s.db.Compact(); err != nil {
+		return "", err
+	}
+	after, err := s.db.Size()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("compacted the database from %d KB to %d KB", before/1024, after/1024), nil
+}
+
+// rollupStats keeps the counts of each day since the last one rolled up,
+// through yesterday
+func (s *Server) rollupStats(now time.Time) (string, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	yesterday := today.AddDate(0, 0, -1)
+
+	from := yesterday
+	last, err := s.db.LastStatsDay()
+	if err != nil {
+		return "", err
+	}
+	if last != nil && last.Before(yesterday) {
+		from = last.AddDate(0, 0, 1)
+		if earliest := today.AddDate(0, 0, -statsCatchUpDays); from.Before(earliest) {
+			from = earliest
+		}
+	}
+
+	var stats *database.DailyStats
+	days := 0
+	for day := from; !day.After(yesterday); day = day.AddDate(0, 0, 1) {
+		if stats, err = s.db.RollupDailyStats(day); err != nil {
+			return "", err
+		}
+		days++
+	}
+	return fmt.Sprintf("counted %d day(s); %s had %d calls and %d posts and replies",
+		days, yesterday.Format("Jan 02"), stats.Calls, stats.Posts+stats.Replies), nil
+}
+
+// postScheduledBulletin posts a job's bulletin in the sysop's name
+func (s *Server) postScheduledBulletin(cfg config.JobConfig, now time.Time) (string, error) {
+	bulletin := &database.Bulletin{
+		Title:  cfg.Title,
+		Body:   cfg.Body,
+		Author: s.cfg().BBS.SysopName,
+	}
+	if cfg.ExpireDays > 0 {
+		expires := now.AddDate(0, 0, cfg.ExpireDays)
+		bulletin.ExpiresAt = &expires
+	}
+	if err := s.db.CreateBulletin(bulletin); err != nil {
+		return "", fmt.Errorf("failed to post bulletin: %w", err)
+	}
+	return fmt.Sprintf("posted bulletin #%d, %q", bulletin.ID, bulletin.Title), nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"log"
+	"path/filepath"
+
+	"bbs/internal/i18n"
+)
+
+// loadStrings reads the string catalogs built into the board and those in
+// the strings directory beside config.yaml. If the sysop's catalogs can't
+// be read, callers get the built-in ones.
+func (s *Server) loadStrings() *i18n.Catalog {
+	cfg := s.cfg()
+	dir := filepath.Join(cfg.Paths.Config, i18n.Dir)
+	catalog, err := i18n.Load(dir)
+	if err != nil {
+		log.Printf("String catalog problem: %v", err)
+		if catalog, err = i18n.Load(""); err != nil {
+			return i18n.New()
+		}
+	}
+	if lang := cfg.BBS.Language; lang != "" && !catalog.Has(lang) {
+		log.Printf("String catalog problem: no catalog for bbs.language %q, callers get English", lang)
+	}
+	return catalog
+}
+
+// language returns the catalog the caller sees the board in: their own
+// choice, or the board's until they make one
+func (s *Session) language() string {
+	if lang := s.prefs.Language; lang != "" && s.server.strings.Has(lang) {
+		return lang
+	}
+	if lang := s.config.BBS.Language; lang != "" {
+		return lang
+	}
+	return i18n.English
+}
+
+// t returns text in the caller's language
+func (s *Session) t(text string) string {
+	return s.server.strings.Translate(s.language(), text)
+}
+
+// Translate shows a module's text in the caller's language
+func (w *TerminalWriter) Translate(text string) string {
+	return w.session.t(text)
+}
+
+// Languages lists the languages the caller can choose
+func (p sessionProfile) Languages() []i18n.Locale {
+	return p.session.server.strings.Locales()
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net"
+
+	"bbs/internal/draw"
+)
+
+// nodeLimit returns how many nodes may be in use for a caller at an access
+// level to get one. Sysops can always log in, so a full board can still be
+// looked after. 0 means no limit.
+func (s *Server) nodeLimit(accessLevel int) int {
+	if accessLevel >= 255 {
+		return 0
+	}
+	return s.cfg().Server.MaxUsers
+}
+
+// connsFrom counts the open connections from a host. The caller holds
+// connsMu.
+func (s *Server) connsFrom(host string) int {
+	count := 0
+	for remote := range s.conns {
+		if h, _, err := net.SplitHostPort(remote); err == nil && h == host {
+			count++
+		}
+	}
+	return count
+}
+
+// boardFull reports, before login, whether every node is taken and the SSH
+// caller isn't a sysop. Callers at the console aren't known until they log
+// in; joinNode turns them away if the board is still full by then.
+func (s *Session) boardFull() bool {
+	if s.prefilledUsername == "" || s.server.cfg().Server.MaxUsers <= 0 {
+		return false
+	}
+	if s.server.nodes.count() < s.server.cfg().Server.MaxUsers {
+		return false
+	}
+	if user, err := s.db.GetUser(s.prefilledUsername); err == nil && s.server.nodeLimit(user.AccessLevel) == 0 {
+		return false
+	}
+	return true
+}
+
+// showBoardFull tells a caller that every node is in use
+func (s *Session) showBoardFull() {
+	s.log.Printf("login refused: all %d nodes are in use", s.server.cfg().Server.MaxUsers)
+
+	s.write([]byte(ClearScreen + "\n\n"))
+	box := s.colorScheme.DrawBox(draw.Box{
+		Title: s.config.BBS.SystemName + " is full",
+		Lines: []string{
+			"",
+			s.colorScheme.Colorize(s.t("Every node is in use right now."), "text"),
+			s.colorScheme.Colorize(s.t("Please try again in a few minutes."), "text"),
+			"",
+		},
+		Center: true,
+		Shadow: true,
+	})
+	for _, row := range box {
+		s.write([]byte(s.colorScheme.CenterText(row, s.width()) + "\n"))
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/menu"
+	"bbs/internal/terminal"
+)
+
+// negotiateLineMode sets up Enter and echo handling for the caller's client
+// from server.terminal. With echo "auto", the board leaves echoing to the
+// client when it asked for no pty, since it is then line buffered and echoes
+// itself, or when its pty modes turn ECHO off.
+func (s *Session) negotiateLineMode(t *terminal.SSHTerminal) {
+	cfg := s.config.Server.Terminal
+	t.SetJoinNewlines(cfg.JoinNewlines)
+
+	var local bool
+	switch cfg.Echo {
+	case "client":
+		local = true
+	case "server":
+		local = false
+	default:
+		echo, ok := t.PtyEcho()
+		local = !t.HasPty() || (ok && !echo)
+	}
+	t.SetLocalEcho(local)
+	if local {
+		s.log.Printf("client echoes locally, board echo off")
+	}
+}
+
+// terminalOptions lets the caller fix doubled typing, a doubled Enter or
+// garbled line drawing for this session
+func (s *Session) terminalOptions() {
+	sshTerm, ok := s.terminal.(*terminal.SSHTerminal)
+	if !ok {
+		s.write([]byte("\n\n" + s.colorScheme.Colorize(s.t("Terminal options only apply to SSH callers."), "text") + "\n"))
+		s.waitForKey()
+		return
+	}
+
+	for {
+		s.write([]byte(menu.ClearScreen))
+		header := s.colorScheme.Colorize(s.t("--- Terminal Options ---"), "primary")
+		s.write([]byte(s.colorScheme.CenterText(header, s.width()) + "\n\n"))
+
+		echo := "The board echoes what you type"
+		if sshTerm.LocalEcho() {
+			echo = "Your client echoes what you type"
+		}
+		join := "On"
+		if !sshTerm.JoinNewlines() {
+			join = "Off"
+		}
+		enter := sshTerm.EnterSequence()
+		if enter == "" {
+			enter = "not seen yet"
+		}
+
+		s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf("%-24s", s.t("E) Echo:")), "accent") + s.colorScheme.Colorize(echo, "text") + "\n"))
+		s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf("%-24s", s.t("N) Join CR LF pairs:")), "accent") + s.colorScheme.Colorize(join, "text") + "\n"))
+		s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf("%-24s", s.t("   Your Enter key sends:")), "secondary") + s.colorScheme.Colorize(enter, "text") + "\n"))
+		s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf("%-24s", s.t("C) Character set:")), "accent") + s.colorScheme.Colorize(encodingNames[s.encoding()], "text") + "\n"))
+		s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf("%-24s", s.t("   Sample:")), "secondary") + s.colorScheme.Colorize(encodingSample, "text") + "\n\n"))
+		s.write([]byte(s.colorScheme.Colorize(s.t("If everything you type appears twice, switch echo to your client."), "text") + "\n"))
+		s.write([]byte(s.colorScheme.Colorize(s.t("If nothing you type appears, switch it to the board."), "text") + "\n"))
+		s.write([]byte(s.colorScheme.Colorize(s.t("If one Enter skips past two prompts, turn joining on."), "text") + "\n"))
+		s.write([]byte(s.colorScheme.Colorize(s.t("If the sample isn't a box with shading, change the character set."), "text") + "\n\n"))
+		s.write([]byte(s.colorScheme.Colorize(s.t("These last until you log off. E/N/C: Change  Q: Quit"), "secondary") + "\n"))
+
+		key, err := s.readKey()
+		if err != nil {
+			return
+		}
+		switch strings.ToLower(key) {
+		case "e":
+			sshTerm.SetLocalEcho(!sshTerm.LocalEcho())
+			s.log.Printf("local echo set to %v", sshTerm.LocalEcho())
+		case "n":
+			sshTerm.SetJoinNewlines(!sshTerm.JoinNewlines())
+			s.log.Printf("newline joining set to %v", sshTerm.JoinNewlines())
+		case "c":
+			if s.encoding() == terminal.CP437 {
+				s.setEncoding(terminal.UTF8)
+			} else {
+				s.setEncoding(terminal.CP437)
+			}
+			s.log.Printf("character set set to %s", s.encoding())
+		case "quit", "q", "escape":
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// userKey and addrKey keep usernames and addresses apart in the login
+// throttle
+func userKey(username string) string { return "user:" + strings.ToLower(username) }
+func addrKey(host string) string     { return "addr:" + host }
+
+// banned reports whether an address is banned. Refused connections aren't
+// logged: the ban was, and a banned scanner would fill the log.
+func (s *Server) banned(addr net.Addr) bool {
+	ban, err := s.db.GetIPBan(hostOf(addr), time.Now())
+	if err != nil {
+		return false
+	}
+	return ban != nil
+}
+
+// throttleLogin waits before answering a login attempt for as long as the
+// username's or the address's recent failures call for
+func (s *Server) throttleLogin(username string, addr net.Addr) {
+	if s.logins == nil {
+		return
+	}
+	now := time.Now()
+	delay := s.logins.Delay(userKey(username), now)
+	if d := s.logins.Delay(addrKey(hostOf(addr)), now); d > delay {
+		delay = d
+	}
+	time.Sleep(delay)
+}
+
+// loginFailed counts a failed login against the username and the address,
+// and bans the address once it reaches logins.ban_after failures
+func (s *Server) loginFailed(username string, addr net.Addr, logger *connLogger) {
+	if s.logins == nil {
+		return
+	}
+	now := time.Now()
+	host := hostOf(addr)
+	s.logins.Failed(userKey(username), now)
+	failures := s.logins.Failed(addrKey(host), now)
+
+	cfg := s.cfg().Server.Logins
+	if cfg.BanAfter <= 0 || failures < cfg.BanAfter || s.db.ReadOnly() {
+		return
+	}
+	until := now.Add(time.Duration(cfg.BanMinutes) * time.Minute)
+	if err := s.db.BanIP(host, fmt.Sprintf("%d failed logins", failures), now, until); err != nil {
+		logger.Printf("%v", err)
+		return
+	}
+	s.logins.Succeeded(addrKey(host))
+	logger.Printf("banned %s until %s after %d failed logins", host, until.Format("2006-01-02 15:04"), failures)
+}
+
+// loginSucceeded forgets the failures of the username and the address
+func (s *Server) loginSucceeded(username string, addr net.Addr) {
+	if s.logins == nil {
+		return
+	}
+	s.logins.Succeeded(userKey(username))
+	s.logins.Succeeded(addrKey(hostOf(addr)))
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"bbs/internal/mirror"
+)
+
+// StartMirrorJob brings mirrored file areas up to date with their sources
+// at startup and then every interval_minutes
+func (s *Server) StartMirrorJob() error {
+	cfg := s.cfg().BBS.Files.Mirror
+	if cfg.IntervalMinutes <= 0 || len(cfg.Sources) == 0 || s.db.ReadOnly() {
+		return nil
+	}
+
+	log.Printf("Mirroring %d file source(s) every %d minutes", len(cfg.Sources), cfg.IntervalMinutes)
+	go s.runMirrorJob(mirror.NewMirror(s.db, s.cfg()), time.Duration(cfg.IntervalMinutes)*time.Minute)
+	return nil
+}
+
+// runMirrorJob mirrors on each tick, logging changes and every file that
+// couldn't be fetched
+func (s *Server) runMirrorJob(m *mirror.Mirror, interval time.Duration) {
+	for {
+		result, err := m.Run()
+		for _, failure := range result.Failed {
+			log.Printf("Mirror: %s", failure)
+		}
+		if err != nil {
+			log.Printf("Mirror: %v", err)
+		} else if result.Changed() {
+			log.Printf("Mirror: %d file(s) added, %d updated, %d removed", len(result.Added), len(result.Updated), len(result.Removed))
+		}
+		time.Sleep(interval)
+	}
+}
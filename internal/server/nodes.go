@@ -0,0 +1,241 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"bbs/internal/database"
+	"bbs/internal/events"
+	"bbs/internal/modules"
+	"bbs/internal/notify"
+)
+
+// nodeTable assigns node numbers to logged-in sessions
+type nodeTable struct {
+	mu    sync.Mutex
+	nodes map[int]*Session
+}
+
+// newNodeTable creates an empty node table
+func newNodeTable() *nodeTable {
+	return &nodeTable{
+		nodes: make(map[int]*Session),
+	}
+}
+
+// claim gives a session the lowest free node number. It fails when limit
+// nodes are already in use; 0 means no limit.
+func (t *nodeTable) claim(s *Session, limit int) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if limit > 0 && len(t.nodes) >= limit {
+		return 0, false
+	}
+
+	node := 1
+	for {
+		if _, taken := t.nodes[node]; !taken {
+			break
+		}
+		node++
+	}
+	t.nodes[node] = s
+	return node, true
+}
+
+// count returns the number of nodes in use
+func (t *nodeTable) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.nodes)
+}
+
+// others returns every logged-in session except one
+func (t *nodeTable) others(except *Session) []*Session {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var sessions []*Session
+	for _, s := range t.nodes {
+		if s != except {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions
+}
+
+// release frees a node number
+func (t *nodeTable) release(node int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.nodes, node)
+}
+
+// joinNode assigns the logged-in session a node, starts delivery of online
+// messages, and announces the login to other callers. It returns false if
+// every node the caller may use is taken.
+func (s *Session) joinNode() bool {
+	// Set up what other sessions read before the node becomes visible to them
+	s.loginTime = time.Now()
+	s.setActivity(menuActivity)
+	s.touch()
+	node, ok := s.server.nodes.claim(s, s.server.nodeLimit(s.user.AccessLevel))
+	if !ok {
+		return false
+	}
+	s.node = node
+	s.logCall()
+
+	s.olm = make(chan onlineMessage, 16)
+	s.olmDone = make(chan struct{})
+	go s.deliverOLMs()
+	if s.timeLimit > 0 {
+		go s.watchTimeLimit()
+	}
+	if s.config.BBS.IdleMinutes > 0 {
+		go s.watchIdle()
+	}
+	go s.watchStatusBar()
+
+	s.unsubscribe = s.server.events.Subscribe(s.handleEvent)
+
+	// Callers arriving during an event's warning period still get warned
+	if w, ok := s.server.pendingEvent(time.Now()); ok {
+		s.startEventCountdown(w.Start)
+		s.queueOLM(eventWarning(w, time.Until(w.Start)), "")
+	}
+
+	if !s.invisible {
+		s.server.events.Publish(events.Event{
+			Type:     events.UserLogin,
+			Username: s.user.Username,
+			Node:     s.node,
+			Message:  fmt.Sprintf("%s just logged on node %d", s.user.Username, s.node),
+		})
+	}
+	return true
+}
+
+// leaveNode announces the logoff and frees the session's node
+func (s *Session) leaveNode() {
+	if s.node == 0 {
+		return
+	}
+
+	s.unsubscribe()
+	close(s.olmDone)
+	s.recordTimeUsed()
+	if chat := s.breakIn.Load(); chat != nil {
+		chat.hangup()
+	}
+
+	if !s.invisible {
+		s.server.events.Publish(events.Event{
+			Type:     events.UserLogout,
+			Username: s.user.Username,
+			Node:     s.node,
+			Message:  fmt.Sprintf("%s logged off node %d", s.user.Username, s.node),
+		})
+	}
+
+	s.server.nodes.release(s.node)
+	s.node = 0
+	if s.callID != 0 {
+		if err := s.db.EndCall(s.callID); err != nil {
+			s.log.Printf("caller log: %v", err)
+		}
+	}
+}
+
+// disconnectNode logs off the caller on a node for the sysop and returns
+// their username
+func (s *Session) disconnectNode(node int) (string, error) {
+	caller := s.server.nodes.visible(s, node)
+	if caller == nil {
+		return "", fmt.Errorf("nobody is on node %d", node)
+	}
+	if caller == s {
+		return "", fmt.Errorf("that's your own node")
+	}
+	s.log.Printf("%s disconnected %s from node %d", s.user.Username, caller.user.Username, node)
+	caller.endCall(s.user.Username)
+	return caller.user.Username, nil
+}
+
+// logoffUser ends the call of a logged-in user for the sysop and returns
+// the node they were on
+func (s *Session) logoffUser(username string) (int, error) {
+	caller := s.server.nodes.find(username)
+	if caller == nil {
+		return 0, fmt.Errorf("%s isn't online", username)
+	}
+	if caller == s {
+		return 0, fmt.Errorf("that's you")
+	}
+	node := caller.node
+	s.log.Printf("%s logged off %s from node %d", s.user.Username, username, node)
+	caller.endCall(s.user.Username)
+	return node, nil
+}
+
+// broadcast shows a one-line message from the sysop to every other caller,
+// above their status bar, and returns how many it reached
+func (s *Session) broadcast(text string) int {
+	s.log.Printf("%s broadcast %q", s.user.Username, text)
+
+	reached := 0
+	for _, caller := range s.server.nodes.others(s) {
+		message := fmt.Sprintf(caller.t("Sysop broadcast: %s"), text)
+		if caller.notify(notify.Broadcasts, message, modules.BellMessage) {
+			reached++
+		}
+	}
+	return reached
+}
+
+// logCall adds the call to the caller log
+func (s *Session) logCall() {
+	if s.db.ReadOnly() {
+		return
+	}
+	id, err := s.db.StartCall(s.user.Username, s.node)
+	if err != nil {
+		s.log.Printf("caller log: %v", err)
+		return
+	}
+	s.callID = id
+	if s.invisible {
+		s.noteAction(database.ActionInvisible)
+	}
+}
+
+// noteAction adds something the caller did to their caller log entry
+func (s *Session) noteAction(action string) {
+	if s.callID == 0 {
+		return
+	}
+	if err := s.db.RecordCallAction(s.callID, action); err != nil {
+		s.log.Printf("caller log: %v", err)
+	}
+}
+
+// handleEvent reacts to events published by other sessions
+func (s *Session) handleEvent(event events.Event) {
+	if event.Node == s.node {
+		return
+	}
+
+	switch event.Type {
+	case events.UserLogin, events.UserLogout:
+		if s.config.BBS.AnnounceLogins {
+			s.notify(notify.Broadcasts, event.Message, "")
+		}
+	case events.ScheduledEventWarning:
+		s.startEventCountdown(event.At)
+		s.notify(notify.Broadcasts, event.Message, "")
+	case events.ScheduledEventStart:
+		go s.forceLogoff("scheduled event", event.Message)
+	}
+}
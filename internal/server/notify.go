@@ -0,0 +1,260 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"bbs/internal/menu"
+	"bbs/internal/modules"
+	"bbs/internal/notify"
+)
+
+// notifyPreferences returns how a user wants to hear about each kind of
+// notification: their saved choices over the board's
+func (s *Server) notifyPreferences(userID int) notify.Preferences {
+	saved, err := s.db.GetNotifySettings(userID)
+	if err != nil {
+		log.Printf("Notify: %v", err)
+	}
+	return notify.Defaults(s.cfg().BBS.Notify).With(saved)
+}
+
+// emailEnabled reports whether the board has a mail server for digests
+func (s *Server) emailEnabled() bool {
+	return s.cfg().BBS.Notify.Digest.SMTP != ""
+}
+
+// notify tells username about something of kind the way they asked: as a
+// one-line message if they are online, in their next digest, or not at all.
+// Names that aren't callers here, such as imported authors, are ignored.
+func (s *Server) notify(username string, kind notify.Kind, text, bell string) {
+	if session := s.nodes.find(username); session != nil {
+		session.notify(kind, text, bell)
+		return
+	}
+	user, err := s.db.GetUser(username)
+	if err != nil {
+		return
+	}
+	if s.notifyPreferences(user.ID).Delivery(kind, s.emailEnabled()) == notify.Email {
+		s.queueDigest(user.ID, kind, text)
+	}
+}
+
+// queueDigest saves a notification for a user's next email digest
+func (s *Server) queueDigest(userID int, kind notify.Kind, text string) {
+	if s.db.ReadOnly() {
+		return
+	}
+	if err := s.db.QueueNotification(userID, string(kind), text); err != nil {
+		log.Printf("Notify: %v", err)
+	}
+}
+
+// loadNotifySettings loads how the caller wants to hear about things
+func (s *Session) loadNotifySettings() {
+	prefs := s.server.notifyPreferences(s.user.ID)
+	s.notifyPrefs.Store(&prefs)
+}
+
+// notifySettings returns how the caller wants to hear about things, or the
+// board's defaults before they have logged in
+func (s *Session) notifySettings() notify.Preferences {
+	if p := s.notifyPrefs.Load(); p != nil {
+		return *p
+	}
+	return notify.Defaults(s.config.BBS.Notify)
+}
+
+// notify tells the caller about something of kind the way they asked. It
+// reports whether it was shown on the board or saved for their digest.
+func (s *Session) notify(kind notify.Kind, text, bell string) bool {
+	switch s.notifySettings().Delivery(kind, s.server.emailEnabled()) {
+	case notify.Board:
+		return s.queueOLM(text, bell)
+	case notify.Email:
+		s.server.queueDigest(s.user.ID, kind, text)
+		return true
+	}
+	return false
+}
+
+// Notify tells another caller about something for a module, subject to
+// their notification settings
+func (w *TerminalWriter) Notify(username string, kind notify.Kind, text string) {
+	w.session.server.notify(username, kind, text, modules.BellMessage)
+}
+
+// NotifyMail tells username about mail that came from off the board, such
+// as through the mail gateway, subject to their notification settings
+func (s *Server) NotifyMail(username, text string) {
+	s.notify(username, notify.Mail, text, modules.BellMessage)
+}
+
+// StartDigestJob emails each caller the notifications they asked to have
+// emailed, once a day at the configured time. Without a mail server there
+// is nothing to send.
+func (s *Server) StartDigestJob() error {
+	cfg := s.cfg().BBS.Notify.Digest
+	if cfg.SMTP == "" || s.db.ReadOnly() {
+		return nil
+	}
+	clock, err := time.Parse("15:04", cfg.Time)
+	if err != nil {
+		return fmt.Errorf("invalid digest time %q, expected HH:MM", cfg.Time)
+	}
+
+	log.Printf("Email digest goes out daily at %02d:%02d through %s", clock.Hour(), clock.Minute(), cfg.SMTP)
+	go s.runDigestJob(clock.Hour(), clock.Minute())
+	return nil
+}
+
+// runDigestJob sleeps until each day's send time and sends the digests
+func (s *Server) runDigestJob(hour, minute int) {
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		time.Sleep(time.Until(next))
+		s.sendDigests()
+	}
+}
+
+// sendDigests emails every caller with queued notifications one digest.
+// A digest that can't be sent is kept for the next day; notifications for
+// callers with no email address are dropped.
+func (s *Server) sendDigests() {
+	cfg := s.cfg()
+	mailer := notify.NewMailer(cfg.BBS.Notify.Digest)
+	queued, err := s.db.GetQueuedNotifications()
+	if err != nil || mailer == nil {
+		if err != nil {
+			log.Printf("Digest: %v", err)
+		}
+		return
+	}
+
+	for len(queued) > 0 {
+		n := 1
+		for n < len(queued) && queued[n].UserID == queued[0].UserID {
+			n++
+		}
+		batch := queued[:n]
+		queued = queued[n:]
+
+		user := batch[0]
+		if user.Email != "" {
+			replyDomain, replyKey := "", ""
+			if cfg.Server.MailGateway.Enabled {
+				key, err := s.db.MailKey(user.UserID)
+				if err != nil {
+					log.Printf("Digest: %v", err)
+				}
+				replyDomain, replyKey = cfg.Server.MailGateway.Domain, key
+			}
+			subject, body := notify.Digest(cfg.BBS.SystemName, replyDomain, replyKey, batch)
+			if err := mailer.Send(user.Email, subject, body); err != nil {
+				log.Printf("Digest: %v", err)
+				continue
+			}
+			log.Printf("Digest: emailed %d notifications to %s", len(batch), user.Username)
+		} else {
+			log.Printf("Digest: %s has no email address, dropping %d notifications", user.Username, len(batch))
+		}
+		if err := s.db.DeleteNotifications(user.UserID, batch[len(batch)-1].ID); err != nil {
+			log.Printf("Digest: %v", err)
+		}
+	}
+}
+
+// notifyOptions lets the caller choose how they hear about each kind of
+// notification. Changes are saved for later calls unless the board is a
+// read-only mirror.
+func (s *Session) notifyOptions() {
+	email := s.server.emailEnabled()
+	choices := []string{notify.Board, notify.Off}
+	if email {
+		choices = []string{notify.Board, notify.Email, notify.Off}
+	}
+	keys := []string{"m", "r", "i", "a"} // One for each of notify.Kinds
+
+	for {
+		prefs := s.notifySettings()
+
+		s.write([]byte(menu.ClearScreen))
+		header := s.colorScheme.Colorize(s.t("--- Notifications ---"), "primary")
+		s.write([]byte(s.colorScheme.CenterText(header, s.width()) + "\n\n"))
+
+		columns := fmt.Sprintf("%-28s", "")
+		for _, choice := range choices {
+			columns += fmt.Sprintf("%-8s", strings.ToUpper(choice[:1])+choice[1:])
+		}
+		s.write([]byte(s.colorScheme.Colorize(columns, "secondary") + "\n"))
+		for i, kind := range notify.Kinds {
+			row := s.colorScheme.Colorize(fmt.Sprintf("%-28s", strings.ToUpper(keys[i])+") "+kind.Label()+":"), "accent")
+			delivery := prefs.Delivery(kind, email)
+			for _, choice := range choices {
+				mark := "  -     "
+				if choice == delivery {
+					mark = s.colorScheme.Colorize("  *     ", "text")
+				}
+				row += mark
+			}
+			s.write([]byte(row + "\n"))
+		}
+
+		s.write([]byte("\n" + s.colorScheme.Colorize(s.t("Board shows a one-line message while you are on."), "text") + "\n"))
+		if email {
+			to := s.user.Email
+			if to == "" {
+				to = "your email address (ask the sysop to add one)"
+			}
+			digest := fmt.Sprintf("Email saves it for a digest sent to %s at %s.", to, s.config.BBS.Notify.Digest.Time)
+			s.write([]byte(s.colorScheme.Colorize(digest, "text") + "\n"))
+		}
+		s.write([]byte("\n" + s.colorScheme.Colorize(s.t("M/R/I/A: Change  Q: Quit"), "secondary") + "\n"))
+
+		key, err := s.readKey()
+		if err != nil {
+			return
+		}
+		key = strings.ToLower(key)
+		if key == "quit" || key == "q" || key == "escape" {
+			return
+		}
+		row := -1
+		for i, k := range keys {
+			if k == key {
+				row = i
+			}
+		}
+		if row < 0 {
+			continue
+		}
+
+		kind := notify.Kinds[row]
+		next := choices[0]
+		for i, choice := range choices {
+			if choice == prefs.Delivery(kind, email) {
+				next = choices[(i+1)%len(choices)]
+			}
+		}
+		changed := make(notify.Preferences, len(prefs))
+		for k, v := range prefs {
+			changed[k] = v
+		}
+		changed[kind] = next
+		s.notifyPrefs.Store(&changed)
+
+		if s.db.ReadOnly() {
+			continue
+		}
+		if err := s.db.SaveNotifySetting(s.user.ID, string(kind), next); err != nil {
+			s.log.Printf("failed to save notification settings: %v", err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"bbs/internal/modules"
+	"bbs/internal/notify"
+)
+
+// onlineMessage is a queued OLM and the reason to ring the bell for it, if any
+type onlineMessage struct {
+	text string
+	bell string
+}
+
+// queueOLM queues an online message (OLM) for display without blocking the
+// sender. Messages are dropped if the recipient's queue is full; it reports
+// whether the message was queued.
+func (s *Session) queueOLM(text, bell string) bool {
+	select {
+	case s.olm <- onlineMessage{text: text, bell: bell}:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendNodeMessage delivers an instant message from this caller to the
+// caller on another node
+func (s *Session) sendNodeMessage(node int, text string) (string, error) {
+	recipient := s.server.nodes.visible(s, node)
+	if recipient == nil {
+		return "", fmt.Errorf("nobody is on node %d", node)
+	}
+
+	message := fmt.Sprintf("%s (node %d): %s", s.user.Username, s.node, text)
+	switch recipient.notifySettings().Delivery(notify.Messages, s.server.emailEnabled()) {
+	case notify.Off:
+		return "", fmt.Errorf("%s isn't taking instant messages", recipient.user.Username)
+	case notify.Email:
+		s.server.queueDigest(recipient.user.ID, notify.Messages, message)
+	default:
+		if !recipient.queueOLM(message, modules.BellMessage) {
+			return "", fmt.Errorf("%s has too many messages waiting", recipient.user.Username)
+		}
+	}
+	return recipient.user.Username, nil
+}
+
+// deliverOLMs renders queued online messages until the session leaves its node
+func (s *Session) deliverOLMs() {
+	for {
+		select {
+		case m := <-s.olm:
+			// Hold the message while a file transfer or sysop chat owns the screen
+			for s.transferring.Load() || s.chatting.Load() {
+				select {
+				case <-time.After(500 * time.Millisecond):
+				case <-s.olmDone:
+					return
+				}
+			}
+			s.renderOLM(m.text)
+			s.ring(m.bell)
+		case <-s.olmDone:
+			return
+		}
+	}
+}
+
+// renderOLM shows a one-line message on the line just above the status bar,
+// leaving the cursor where it was so the current screen keeps working
+func (s *Session) renderOLM(text string) {
+	_, height, err := s.size()
+	if err != nil {
+		height = 24
+	}
+
+	line := height
+	if s.statusBar != nil {
+		line = height - 1
+	}
+
+	message := s.colorScheme.Colorize("» "+text, "accent")
+	s.writeDirect(fmt.Sprintf("\033[s\033[%d;1H\033[2K%s\033[u", line, message))
+}
+
+// writeDirect writes to the terminal without TerminalWriter's screen clear
+// checks, so output from other goroutines doesn't trigger status bar handling
+func (s *Session) writeDirect(output string) {
+	// Anything written during a file transfer would corrupt it
+	if s.transferring.Load() {
+		return
+	}
+	s.writer.writeTerminal([]byte(output))
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"bbs/internal/modules"
+)
+
+// pageCommandTimeout bounds the configured page notification command
+const pageCommandTimeout = 30 * time.Second
+
+// pageSysop tells the sysop that this caller wants to chat: sysops online
+// get a message, with a bell if they want one, and the server console gets the bell and the
+// configured command
+func (s *Session) pageSysop(reason string) error {
+	cfg := s.config.BBS.Page
+	if !cfg.Enabled {
+		return fmt.Errorf("paging the sysop is turned off")
+	}
+
+	interval := time.Duration(cfg.IntervalMinutes) * time.Minute
+	if wait := time.Until(s.lastPage.Add(interval)); !s.lastPage.IsZero() && wait > 0 {
+		return fmt.Errorf("you paged the sysop recently; try again in %d minute(s)", int(wait.Minutes())+1)
+	}
+	s.lastPage = time.Now()
+
+	s.log.Printf("PAGE from %s on node %d: %s", s.user.Username, s.node, reason)
+	if cfg.Bell {
+		fmt.Fprint(os.Stderr, "\a")
+	}
+	if cfg.Command != "" {
+		go runPageCommand(s.log, cfg.Command, pageArgs(cfg.Args, s.user.Username, s.node, reason))
+	}
+
+	for _, sysop := range s.server.nodes.sysops(s) {
+		message := fmt.Sprintf(sysop.t("%s on node %d is paging you: %s (Who's Online, C to chat)"), s.user.Username, s.node, reason)
+		sysop.queueOLM(message, modules.BellPage)
+	}
+	return nil
+}
+
+// pageArgs fills in the placeholders in the page command's arguments
+func pageArgs(args []string, username string, node int, reason string) []string {
+	replacer := strings.NewReplacer(
+		"{user}", username,
+		"{node}", strconv.Itoa(node),
+		"{reason}", reason,
+	)
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = replacer.Replace(arg)
+	}
+	return expanded
+}
+
+// runPageCommand runs the configured page notification command
+func runPageCommand(logger *connLogger, command string, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), pageCommandTimeout)
+	defer cancel()
+	if output, err := exec.CommandContext(ctx, command, args...).CombinedOutput(); err != nil {
+		logger.Printf("page command failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+}
+
+// sysops returns the logged-in sysops other than except
+func (t *nodeTable) sysops(except *Session) []*Session {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var sysops []*Session
+	for _, s := range t.nodes {
+		if s != except && s.user.AccessLevel >= 255 {
+			sysops = append(sysops, s)
+		}
+	}
+	return sysops
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"bbs/internal/database"
+)
+
+// minPasswordLength matches the rule for new accounts
+const minPasswordLength = 6
+
+// enforcePasswordChange makes the caller choose a new password when a sysop
+// has required it or the current one is too old. It returns false if the
+// caller doesn't complete the change and should be logged off.
+func (s *Session) enforcePasswordChange() bool {
+	// A read-only mirror can't store a new password
+	if s.db.ReadOnly() {
+		return true
+	}
+
+	status, err := s.db.GetPasswordStatus(s.user.ID)
+	if err != nil {
+		s.log.Printf("failed to load password status for %s: %v", s.user.Username, err)
+		return true
+	}
+
+	maxAge := time.Duration(s.config.BBS.PasswordMaxAgeDays) * 24 * time.Hour
+	var reason string
+	switch {
+	case status.MustChange:
+		reason = "Your sysop has asked you to choose a new password."
+	case status.Expired(maxAge, time.Now()):
+		reason = "Your password has expired. Please choose a new one."
+	default:
+		return true
+	}
+
+	s.write([]byte("\n" + s.colorScheme.Colorize(reason, "accent") + "\n\n"))
+
+	for attempts := 0; attempts < 3; attempts++ {
+		s.write([]byte("New password: "))
+		password, err := s.readInput(true)
+		if err != nil {
+			return false
+		}
+		s.write([]byte("Confirm new password: "))
+		confirm, err := s.readInput(true)
+		if err != nil {
+			return false
+		}
+
+		if problem := s.checkNewPassword(password, confirm); problem != "" {
+			s.write([]byte(s.colorScheme.Colorize(problem, "error") + "\n\n"))
+			continue
+		}
+
+		if err := s.db.ChangePassword(s.user.ID, password); err != nil {
+			s.log.Printf("failed to change password for %s: %v", s.user.Username, err)
+			s.write([]byte(s.colorScheme.Colorize(s.t("Failed to save your new password. Please try again later."), "error") + "\n"))
+			return false
+		}
+
+		s.log.Printf("%s changed their password at login", s.user.Username)
+		s.write([]byte(s.colorScheme.Colorize(s.t("Password changed."), "success") + "\n\n"))
+		return true
+	}
+
+	s.log.Printf("%s did not complete a required password change", s.user.Username)
+	s.write([]byte(s.colorScheme.Colorize(s.t("Password not changed. Goodbye."), "error") + "\n"))
+	return false
+}
+
+// checkNewPassword returns why a new password is unacceptable, or "" if it is fine
+func (s *Session) checkNewPassword(password, confirm string) string {
+	switch {
+	case len(password) < minPasswordLength:
+		return fmt.Sprintf("Passwords must be at least %d characters.", minPasswordLength)
+	case password != confirm:
+		return "The passwords don't match."
+	}
+
+	// Reusing the current password defeats the point
+	if _, err := s.db.VerifyPassword(s.user.Username, password); err == nil {
+		return "Choose a password different from your current one."
+	} else if err != database.ErrInvalidCredentials {
+		s.log.Printf("failed to check password for %s: %v", s.user.Username, err)
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/terminal"
+)
+
+// loadPrefs loads the caller's saved preferences and puts them into effect.
+// The caller gets a color scheme of their own, so changing theme doesn't
+// recolor anyone else's screen.
+func (s *Session) loadPrefs() {
+	s.colorScheme = NewColorScheme(&s.config.BBS.Colors)
+	s.menuRenderer = menu.NewMenuRenderer(s.colorScheme, s.writer)
+
+	prefs, err := s.db.GetUserPrefs(s.user.ID)
+	if err != nil {
+		s.log.Printf("failed to load preferences for %s: %v", s.user.Username, err)
+	}
+	s.applyPrefs(prefs)
+}
+
+// applyPrefs puts the caller's preferences into effect for the rest of the call
+func (s *Session) applyPrefs(prefs database.UserPrefs) {
+	s.prefs = prefs
+
+	colors := &s.config.BBS.Colors
+	if prefs.Theme != "" {
+		if theme, ok := s.config.BBS.Theme(prefs.Theme); ok {
+			colors = &theme
+		} else {
+			s.log.Printf("unknown theme %q, using the board's colors", prefs.Theme)
+		}
+	}
+	s.colorScheme.setColors(colors)
+
+	// Only SSH callers choose their character set; the console and web
+	// terminal always draw UTF-8
+	if t, ok := s.terminal.(*terminal.SSHTerminal); ok {
+		if prefs.Terminal != "" {
+			s.setEncoding(terminal.DetectEncoding(prefs.Terminal))
+		} else if s.clientEncoding != "" {
+			s.setEncoding(s.clientEncoding)
+		} else {
+			s.setEncoding(terminal.DetectEncoding(t.TermType()))
+		}
+	}
+
+	lines := s.screenLines.Swap(int32(prefs.ScreenLines))
+	if lines != int32(prefs.ScreenLines) {
+		s.handleResize()
+	}
+
+	s.menuRenderer.SetHotkeysOnly(prefs.Navigation == database.NavHotkeys)
+}
+
+// hotkeysOnly reports whether the caller chooses menu items only by their
+// hotkeys, with no lightbar
+func (s *Session) hotkeysOnly() bool {
+	return s.prefs.Navigation == database.NavHotkeys
+}
+
+// size returns the terminal's size, with the caller's chosen screen length
+// in place of the height their client reports
+func (s *Session) size() (width, height int, err error) {
+	width, height, err = s.terminal.Size()
+	if lines := int(s.screenLines.Load()); err == nil && lines > 0 {
+		height = lines
+	}
+	// The console's sysop keys take the bottom row
+	if s.consoleLegend.Load() && height > 2 {
+		height--
+	}
+	return width, height, err
+}
+
+// sessionProfile lets the profile editor change the caller's session
+type sessionProfile struct {
+	session *Session
+}
+
+// Prefs returns the caller's preferences in effect
+func (p sessionProfile) Prefs() database.UserPrefs {
+	return p.session.prefs
+}
+
+// SetPrefs puts changed preferences into effect
+func (p sessionProfile) SetPrefs(prefs database.UserPrefs) {
+	p.session.applyPrefs(prefs)
+}
+
+// SetContact updates the caller's real name and email for the rest of the call
+func (p sessionProfile) SetContact(realName, email string) {
+	p.session.user.RealName = realName
+	p.session.user.Email = email
+}
+
+// Themes lists the themes in config.yaml
+func (p sessionProfile) Themes() []string {
+	return p.session.config.BBS.ThemeNames()
+}
+
+// ThemeSample draws the names of a theme's main colors in those colors
+func (p sessionProfile) ThemeSample(theme string) string {
+	colors := p.session.config.BBS.Colors
+	if theme != "" {
+		colors, _ = p.session.config.BBS.Theme(theme)
+	}
+	cs := NewColorScheme(&colors)
+	return cs.Colorize("Title", "primary") + " " +
+		cs.Colorize("Text", "text") + " " +
+		cs.Colorize("Hotkey", "accent") + " " +
+		cs.Colorize("Done", "success") + " " +
+		cs.Colorize("Error", "error")
+}
+
+// CheckPassword applies the same rules as a forced password change
+func (p sessionProfile) CheckPassword(password, confirm string) string {
+	return p.session.checkNewPassword(password, confirm)
+}
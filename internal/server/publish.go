@@ -0,0 +1,47 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"bbs/internal/publish"
+)
+
+// StartPublishJob brings the static HTML archive up to date at startup and
+// then every interval_minutes
+func (s *Server) StartPublishJob() error {
+	cfg := s.cfg().BBS.Publish
+	if cfg.IntervalMinutes <= 0 || (len(cfg.Topics) == 0 && !cfg.Bulletins) {
+		return nil
+	}
+
+	publisher, err := publish.NewPublisher(s.db, s.cfg())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Publishing the archive to %s every %d minutes", publisher.Dir(), cfg.IntervalMinutes)
+	go s.runPublishJob(publisher, time.Duration(cfg.IntervalMinutes)*time.Minute)
+	return nil
+}
+
+// runPublishJob publishes on each tick, logging only runs that changed
+// something. Skipped topics are reported on the first run.
+func (s *Server) runPublishJob(publisher *publish.Publisher, interval time.Duration) {
+	first := true
+	for {
+		result, err := publisher.Run()
+		if first {
+			for _, name := range result.Skipped {
+				log.Printf("Publish: topic %q is not a public topic, skipping it", name)
+			}
+			first = false
+		}
+		if err != nil {
+			log.Printf("Publish: %v", err)
+		} else if result.Written > 0 || result.Removed > 0 {
+			log.Printf("Publish: %d page(s) written, %d removed", result.Written, result.Removed)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+
+	"bbs/internal/config"
+	"bbs/internal/draw"
+)
+
+// cfg returns the current configuration
+func (s *Server) cfg() *config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// colors returns the current color scheme
+func (s *Server) colors() *ColorScheme {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.colorScheme
+}
+
+// current returns the configuration and its color scheme together
+func (s *Server) current() (*config.Config, *ColorScheme) {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config, s.colorScheme
+}
+
+// Reload swaps in a new configuration for sessions that start from now
+// on. Callers already online keep the configuration they logged in with.
+// Settings only read at startup, such as the ports, database and
+// scheduled jobs, keep their running values; Reload returns the names of
+// those the new configuration changes, which need a restart.
+func (s *Server) Reload(cfg *config.Config) []string {
+	restart := keepStartupSettings(s.cfg(), cfg)
+
+	s.configMu.Lock()
+	s.config = cfg
+	s.colorScheme = NewColorScheme(&cfg.BBS.Colors)
+	s.configMu.Unlock()
+
+	s.checkMenus()
+	s.checkThemes()
+	s.checkAliases()
+	return restart
+}
+
+// checkMenus logs the mistakes in the menus, as the check command reports them
+func (s *Server) checkMenus() {
+	for _, problem := range s.cfg().CheckMenus() {
+		log.Printf("Menu problem: %s", problem)
+	}
+}
+
+// checkThemes logs colors in the themes that the board can't draw, which
+// would otherwise leave that part of the screen uncolored, and border
+// styles it doesn't know, which are drawn with double lines
+func (s *Server) checkThemes() {
+	cfg := s.cfg()
+	if _, ok := draw.StyleNamed(cfg.BBS.Colors.Borders); !ok {
+		log.Printf("Theme problem: colors: unknown border style %q", cfg.BBS.Colors.Borders)
+	}
+	for _, name := range cfg.BBS.ThemeNames() {
+		theme := cfg.BBS.Themes[name]
+		for _, c := range []struct{ role, color string }{
+			{"primary", theme.Primary},
+			{"secondary", theme.Secondary},
+			{"accent", theme.Accent},
+			{"text", theme.Text},
+			{"border", theme.Border},
+			{"success", theme.Success},
+			{"error", theme.Error},
+			{"highlight", theme.Highlight},
+		} {
+			if _, ok := colorCodes[c.color]; c.color != "" && !ok {
+				log.Printf("Theme problem: theme %q: unknown %s color %q", name, c.role, c.color)
+			}
+		}
+		if _, ok := bgColorCodes[theme.Background]; theme.Background != "" && !ok {
+			log.Printf("Theme problem: theme %q: unknown background color %q", name, theme.Background)
+		}
+		if _, ok := draw.StyleNamed(theme.Borders); !ok {
+			log.Printf("Theme problem: theme %q: unknown border style %q", name, theme.Borders)
+		}
+	}
+}
+
+// saveMenus writes menus to the config file and hands them to sessions that
+// start from now on. The file is read again first, so settings from the
+// environment or a board's overrides aren't written into it.
+func (s *Server) saveMenus(menus []config.MenuItem) (*config.Config, error) {
+	running := s.cfg()
+	if running.File == "" {
+		return nil, fmt.Errorf("the configuration wasn't loaded from a file")
+	}
+	saved, err := config.Load(running.File)
+	if err != nil {
+		return nil, err
+	}
+	saved.BBS.Menus = menus
+	if err := saved.Save(running.File); err != nil {
+		return nil, err
+	}
+
+	next := *running
+	next.BBS.Menus = menus
+	s.Reload(&next)
+	return &next, nil
+}
+
+// keepStartupSettings copies the settings that only take effect at startup
+// from the running configuration into cfg, returning the names of those the
+// new file changes
+func keepStartupSettings(old, cfg *config.Config) []string {
+	var changed []string
+	keep(&changed, "server.port", old.Server.Port, &cfg.Server.Port)
+	keep(&changed, "server.host_key_path", old.Server.HostKeyPath, &cfg.Server.HostKeyPath)
+	keep(&changed, "server.api", old.Server.API, &cfg.Server.API)
+	keep(&changed, "server.web", old.Server.Web, &cfg.Server.Web)
+	keep(&changed, "server.gopher", old.Server.Gopher, &cfg.Server.Gopher)
+	keep(&changed, "server.feeds", old.Server.Feeds, &cfg.Server.Feeds)
+	keep(&changed, "server.mail_gateway", old.Server.MailGateway, &cfg.Server.MailGateway)
+	keep(&changed, "server.ssh", old.Server.SSH, &cfg.Server.SSH)
+	keep(&changed, "server.tarpit", old.Server.Tarpit, &cfg.Server.Tarpit)
+	keep(&changed, "server.logins", old.Server.Logins, &cfg.Server.Logins)
+	keep(&changed, "server.metrics", old.Server.Metrics, &cfg.Server.Metrics)
+	keep(&changed, "server.boards", old.Server.Boards, &cfg.Server.Boards)
+	keep(&changed, "database", old.Database, &cfg.Database)
+	keep(&changed, "bbs.events", old.BBS.Events, &cfg.BBS.Events)
+	keep(&changed, "bbs.jobs", old.BBS.Jobs, &cfg.BBS.Jobs)
+	keep(&changed, "bbs.retention", old.BBS.Retention, &cfg.BBS.Retention)
+	keep(&changed, "bbs.notify.digest", old.BBS.Notify.Digest, &cfg.BBS.Notify.Digest)
+	keep(&changed, "bbs.publish", old.BBS.Publish, &cfg.BBS.Publish)
+	keep(&changed, "bbs.files.mirror", old.BBS.Files.Mirror, &cfg.BBS.Files.Mirror)
+	cfg.Paths = old.Paths
+	return changed
+}
+
+// keep sets *next back to running, adding name to changed if they differed
+func keep[T any](changed *[]string, name string, running T, next *T) {
+	if !reflect.DeepEqual(running, *next) {
+		*changed = append(*changed, name)
+		*next = running
+	}
+}
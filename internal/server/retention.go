@@ -0,0 +1,194 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/database"
+	"bbs/internal/dialog"
+	"bbs/internal/undo"
+)
+
+// StartRetentionJob runs the retention job every night at the configured
+// time: closed accounts whose grace period is over are deleted, old
+// records are pruned and old posts and expired bulletins are archived
+func (s *Server) StartRetentionJob() error {
+	cfg := s.cfg().BBS.Retention
+	clock, err := time.Parse("15:04", cfg.Time)
+	if err != nil {
+		return fmt.Errorf("invalid retention time %q, expected HH:MM", cfg.Time)
+	}
+	for _, rule := range cfg.Topics {
+		if rule.Topic == "" {
+			return fmt.Errorf("a topic retention rule has no topic")
+		}
+		if rule.KeepPosts < 0 || rule.KeepDays < 0 {
+			return fmt.Errorf("invalid retention rule for topic %q, keep_posts and keep_days can't be negative", rule.Topic)
+		}
+	}
+
+	log.Printf("Retention job runs daily at %02d:%02d", clock.Hour(), clock.Minute())
+	go s.runRetentionJob(clock.Hour(), clock.Minute())
+	return nil
+}
+
+// runRetentionJob sleeps until each day's run time and applies retention
+func (s *Server) runRetentionJob(hour, minute int) {
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		time.Sleep(time.Until(next))
+		s.applyRetention(time.Now())
+	}
+}
+
+// applyRetention deletes the accounts that are due and prunes records older
+// than the retention policy allows
+func (s *Server) applyRetention(now time.Time) {
+	if s.db.ReadOnly() {
+		return
+	}
+	cfg := s.cfg().BBS.Retention
+
+	due, err := s.db.GetDueAccountDeletions(now)
+	if err != nil {
+		log.Printf("Retention: %v", err)
+	}
+	for _, d := range due {
+		// Deleting the account under a caller would break their session
+		if s.nodes.find(d.Username) != nil {
+			log.Printf("Retention: %s is online, deleting their account tomorrow", d.Username)
+			continue
+		}
+		if err := s.db.DeleteAccount(d.UserID); err != nil {
+			log.Printf("Retention: %v", err)
+			continue
+		}
+		log.Printf("Retention: deleted account %s, closed on %s", d.Username, d.RequestedAt.Format("2006-01-02"))
+	}
+
+	if cfg.CallerLogDays > 0 {
+		if removed, err := s.db.PruneCallerLog(now.AddDate(0, 0, -cfg.CallerLogDays)); err != nil {
+			log.Printf("Retention: %v", err)
+		} else if removed > 0 {
+			log.Printf("Retention: removed %d caller log entries older than %d days", removed, cfg.CallerLogDays)
+		}
+	}
+	if cfg.ReadMailDays > 0 {
+		if removed, err := s.db.PruneReadMail(now.AddDate(0, 0, -cfg.ReadMailDays)); err != nil {
+			log.Printf("Retention: %v", err)
+		} else if removed > 0 {
+			log.Printf("Retention: removed %d read messages older than %d days", removed, cfg.ReadMailDays)
+		}
+	}
+	// Mail deleted on calls that ended before the deletion was made final
+	if _, err := s.db.PurgeDeletedMail(now.Add(-undo.Window)); err != nil {
+		log.Printf("Retention: %v", err)
+	}
+	if _, err := s.db.PruneExpiredBans(now); err != nil {
+		log.Printf("Retention: %v", err)
+	}
+	if archived, err := s.db.ArchiveExpiredBulletins(now); err != nil {
+		log.Printf("Retention: %v", err)
+	} else if archived > 0 {
+		log.Printf("Retention: archived %d expired bulletins", archived)
+	}
+	s.archivePosts(cfg.Topics, now)
+}
+
+// archivePosts moves the posts each topic's rule no longer keeps to the
+// topic's archive
+func (s *Server) archivePosts(rules []config.TopicRetention, now time.Time) {
+	if len(rules) == 0 {
+		return
+	}
+	topics, err := s.db.GetTopics(255)
+	if err != nil {
+		log.Printf("Retention: failed to load topics: %v", err)
+		return
+	}
+	for _, rule := range rules {
+		var topic *database.Topic
+		for i := range topics {
+			if strings.EqualFold(topics[i].Name, rule.Topic) {
+				topic = &topics[i]
+				break
+			}
+		}
+		if topic == nil {
+			log.Printf("Retention: no topic named %q to archive", rule.Topic)
+			continue
+		}
+
+		var cutoff time.Time
+		if rule.KeepDays > 0 {
+			cutoff = now.AddDate(0, 0, -rule.KeepDays)
+		}
+		archived, err := s.db.ArchivePosts(topic.ID, rule.KeepPosts, cutoff)
+		if err != nil {
+			log.Printf("Retention: %v", err)
+		} else if archived > 0 {
+			log.Printf("Retention: archived %d posts in %s", archived, topic.Name)
+		}
+	}
+}
+
+// find returns the session of a logged-in user, or nil
+func (t *nodeTable) find(username string) *Session {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, s := range t.nodes {
+		if s.user.Username == username {
+			return s
+		}
+	}
+	return nil
+}
+
+// closeAccount schedules the caller's account for deletion after the grace
+// period and returns when it will be deleted
+func (s *Session) closeAccount() (time.Time, error) {
+	deleteAfter := time.Now().AddDate(0, 0, s.config.BBS.Retention.DeletionGraceDays)
+	if err := s.db.RequestAccountDeletion(s.user.ID, deleteAfter); err != nil {
+		return time.Time{}, err
+	}
+	s.log.Printf("%s closed their account, deleting it after %s", s.user.Username, deleteAfter.Format("2006-01-02"))
+	return deleteAfter, nil
+}
+
+// promptKeepAccount asks a caller whose account is scheduled for deletion
+// whether to keep it
+func (s *Session) promptKeepAccount() {
+	if s.db.ReadOnly() {
+		return
+	}
+	deleteAfter, err := s.db.GetAccountDeletion(s.user.ID)
+	if err != nil {
+		s.log.Printf("failed to check account deletion for %s: %v", s.user.Username, err)
+		return
+	}
+	if deleteAfter == nil {
+		return
+	}
+
+	notice := fmt.Sprintf("Your account will be deleted after %s.", deleteAfter.Format("Jan 2, 2006"))
+	s.write([]byte(s.colorScheme.Colorize(notice, "accent") + "\n"))
+	if !dialog.Confirm(s.writer, &TerminalKeyReader{session: s}, s.colorScheme, s.t("Keep your account?"), false) {
+		s.write([]byte("\n"))
+		return
+	}
+	if err := s.db.CancelAccountDeletion(s.user.ID); err != nil {
+		s.log.Printf("failed to cancel account deletion for %s: %v", s.user.Username, err)
+		s.write([]byte(s.colorScheme.Colorize(s.t("Failed to cancel the deletion. Please try again later."), "error") + "\n\n"))
+		return
+	}
+	s.log.Printf("%s cancelled their account deletion", s.user.Username)
+	s.write([]byte(s.colorScheme.Colorize(s.t("Your account will be kept."), "success") + "\n\n"))
+}
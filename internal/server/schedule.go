@@ -0,0 +1,143 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"bbs/internal/events"
+	"bbs/internal/menu"
+	"bbs/internal/schedule"
+)
+
+// StartEventScheduler watches the configured daily events, warning callers
+// ahead of each one and logging everyone off when it begins
+func (s *Server) StartEventScheduler() error {
+	scheduled, err := schedule.Parse(s.cfg().BBS.Events)
+	if err != nil {
+		return err
+	}
+	if len(scheduled) == 0 {
+		return nil
+	}
+
+	s.scheduled = scheduled
+	for _, event := range scheduled {
+		log.Printf("Scheduled event %q daily at %02d:%02d for %s", event.Name, event.Hour, event.Minute, event.Duration)
+	}
+
+	go s.runEventScheduler()
+	return nil
+}
+
+// runEventScheduler publishes a warning when an event's warning period
+// begins, a final warning a minute out, and the start of the event
+func (s *Server) runEventScheduler() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	// Start times of the windows already announced at each stage
+	var warned, finalWarned, started time.Time
+
+	for now := range ticker.C {
+		w, ok := schedule.Next(s.scheduled, now)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case w.Active(now):
+			if started.Equal(w.Start) {
+				continue
+			}
+			started = w.Start
+			log.Printf("Scheduled event %q started, logging off all callers until %s", w.Name, w.End.Format("15:04"))
+			s.events.Publish(events.Event{
+				Type:    events.ScheduledEventStart,
+				Message: fmt.Sprintf("%s has begun. The board will reopen at %s.", w.Name, w.End.Format("15:04")),
+				At:      w.Start,
+			})
+		case w.Warn(now):
+			remaining := w.Start.Sub(now)
+			if warned.Equal(w.Start) && (remaining > time.Minute || finalWarned.Equal(w.Start)) {
+				continue
+			}
+			if remaining <= time.Minute {
+				finalWarned = w.Start
+			}
+			warned = w.Start
+			s.events.Publish(events.Event{
+				Type:    events.ScheduledEventWarning,
+				Message: eventWarning(w, remaining),
+				At:      w.Start,
+			})
+		}
+	}
+}
+
+// eventWarning describes an upcoming event to the callers it will log off
+func eventWarning(w schedule.Window, remaining time.Duration) string {
+	minutes := int(math.Ceil(remaining.Minutes()))
+	if minutes <= 1 {
+		return fmt.Sprintf("%s begins in 1 minute. Please finish up, you will be logged off.", w.Name)
+	}
+	return fmt.Sprintf("%s begins at %s. You will be logged off in %d minutes.", w.Name, w.Start.Format("15:04"), minutes)
+}
+
+// closedFor returns the scheduled event that has the board closed at now
+func (s *Server) closedFor(now time.Time) (schedule.Window, bool) {
+	w, ok := schedule.Next(s.scheduled, now)
+	return w, ok && w.Active(now)
+}
+
+// pendingEvent returns the scheduled event callers are being warned about at now
+func (s *Server) pendingEvent(now time.Time) (schedule.Window, bool) {
+	w, ok := schedule.Next(s.scheduled, now)
+	return w, ok && w.Warn(now)
+}
+
+// startEventCountdown shows the time left until a scheduled event logs the
+// caller off on the status bar
+func (s *Session) startEventCountdown(at time.Time) {
+	s.timeMu.Lock()
+	s.eventAt = at
+	s.timeMu.Unlock()
+	s.updateCountdown()
+}
+
+// forceLogoff ends the session from another goroutine, such as when a
+// scheduled event begins or the caller's time runs out
+func (s *Session) forceLogoff(cause, message string) {
+	s.logOff(cause, "\n\n"+s.colorScheme.Colorize(s.t(message), "error")+"\n")
+}
+
+// endCall logs the caller off for a sysop, with a goodbye screen that
+// thanks them for calling rather than a bare error
+func (s *Session) endCall(sysop string) {
+	cs := s.colorScheme
+	width := s.width()
+	var screen strings.Builder
+	screen.WriteString(menu.ClearContentArea + menu.ShowCursor + "\n")
+	screen.WriteString(cs.CenterText(cs.Colorize(s.t("--- Call Ended ---"), "primary"), width) + "\n\n")
+	screen.WriteString(cs.CenterText(cs.Colorize(s.t("The sysop has ended your call."), "text"), width) + "\n")
+	thanks := fmt.Sprintf(s.t("Thank you for calling %s. Please call again soon!"), s.config.BBS.SystemName)
+	screen.WriteString(cs.CenterText(cs.Colorize(thanks, "success"), width) + "\n")
+	s.logOff("a logoff by "+sysop, screen.String())
+}
+
+// logOff shows a last screen and closes the terminal, once. The screen is
+// only queued, so it gets the time the end of a session gives the goodbye
+// to reach the caller first. Closing the terminal fails the session's
+// pending read, which unwinds it through the normal logoff path.
+func (s *Session) logOff(cause, screen string) {
+	if !s.loggedOff.CompareAndSwap(false, true) {
+		return
+	}
+
+	s.log.Printf("logging off %s for %s", s.user.Username, cause)
+	s.writeDirect(screen)
+	s.output.Close(closeWait)
+	s.terminal.Close()
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+
+	"bbs/internal/input"
+	"bbs/internal/menu"
+	"bbs/internal/scripts"
+
+	"go.starlark.net/starlark"
+)
+
+// scriptTerminal gives a script the caller's screen and keyboard
+type scriptTerminal struct {
+	session *Session
+}
+
+func (t scriptTerminal) Write(text string) error {
+	_, err := t.session.writer.Write([]byte(text))
+	return err
+}
+
+// ReadKey returns keys as typed
+func (t scriptTerminal) ReadKey() (string, error) {
+	return t.session.readTextKey()
+}
+
+// History shares the caller's prompt history with read_line
+func (t scriptTerminal) History() *input.History {
+	return &t.session.history
+}
+
+func (t scriptTerminal) Clear() {
+	t.session.write([]byte(menu.ClearContentArea))
+}
+
+func (t scriptTerminal) Color(text, style string) string {
+	return t.session.colorScheme.Colorize(text, style)
+}
+
+// runScript runs the sysop's script called name from the scripts directory
+// beside config.yaml. A script that fails is logged with where it failed,
+// and the caller is told it stopped.
+func (s *Session) runScript(name string) bool {
+	env := scripts.Env{
+		Caller: scripts.Caller{
+			Username:    s.user.Username,
+			RealName:    s.user.RealName,
+			AccessLevel: s.user.AccessLevel,
+			Node:        s.node,
+		},
+		Board: scripts.Board{
+			SystemName: s.config.BBS.SystemName,
+			SysopName:  s.config.BBS.SysopName,
+		},
+		Terminal: scriptTerminal{session: s},
+		Store:    s.db,
+	}
+
+	s.log.Printf("%s ran script %s", s.user.Username, name)
+	s.write([]byte(menu.ClearContentArea + menu.ShowCursor))
+	err := scripts.Run(context.Background(), filepath.Join(s.config.Paths.Config, scripts.Dir), name, env)
+	s.write([]byte(menu.HideCursor))
+	if s.hungUp.Load() {
+		return false
+	}
+	if err == nil {
+		return true
+	}
+
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		s.log.Printf("script %s for %s: %s", name, s.user.Username, evalErr.Backtrace())
+	} else {
+		s.log.Printf("script %s for %s: %v", name, s.user.Username, err)
+	}
+	if errors.Is(err, scripts.ErrNotFound) {
+		s.displaySafeMessage("That command isn't available right now.", "error")
+	} else {
+		s.displaySafeMessage("The script stopped with an error. The sysop can find it in the log.", "error")
+	}
+	s.waitForKey()
+	return true
+}
@@ -5,22 +5,63 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/crypto/ssh"
 
+	"bbs/internal/ansiart"
+	"bbs/internal/chat"
 	"bbs/internal/config"
 	"bbs/internal/database"
+	"bbs/internal/events"
+	"bbs/internal/help"
+	"bbs/internal/i18n"
+	"bbs/internal/input"
 	"bbs/internal/menu"
+	"bbs/internal/metrics"
+	"bbs/internal/modules"
+	"bbs/internal/modules/feedback"
+	"bbs/internal/modules/registration"
+	"bbs/internal/modules/usage"
+	"bbs/internal/schedule"
+	"bbs/internal/scheduler"
+	"bbs/internal/tarpit"
 	"bbs/internal/terminal"
+	"bbs/internal/throttle"
+	"bbs/internal/undo"
 )
 
+// usageCacheTTL is how long board activity statistics are reused
+const usageCacheTTL = 5 * time.Minute
+
 // Server represents a unified BBS server that can handle both SSH and local connections
 type Server struct {
-	config      *config.Config
-	db          *database.DB
+	db        *database.DB
+	sshConfig *ssh.ServerConfig
+
+	configMu    sync.RWMutex
+	config      *config.Config // Replaced by Reload; read it with cfg
 	colorScheme *ColorScheme
-	sshConfig   *ssh.ServerConfig
+
+	connsMu sync.Mutex
+	conns   map[string]*connLogger // Per-connection loggers keyed by remote address
+
+	nodes     *nodeTable           // Node numbers of logged-in sessions
+	events    *events.Bus          // Board-wide events such as logins
+	scheduled []schedule.Event     // Daily events that close the board
+	jobs      *scheduler.Scheduler // Maintenance jobs listed under bbs.jobs
+	chat      *chat.Hub            // Teleconference channels
+	tarpit    *tarpit.Tracker      // Addresses that keep aborting logins, nil when disabled
+	logins    *throttle.Throttle   // Failed logins by username and address, nil when disabled
+	usage     *usage.Cache         // Statistics for the board activity graphs
+	boards    map[string]*Server   // Other boards callers reach by logging in as "name/username"
+	help      *help.Registry       // Help topics registered by the modules and the sysop
+	strings   *i18n.Catalog        // What callers see in each language
+	modules   *modules.Registry    // What each menu command runs
+	art       *ansiart.Cache       // Parsed art screens, emptied when the art directory changes
+	metrics   *metrics.Registry    // Per-command timings
+	traceNode atomic.Int32         // Node whose keys and commands are logged in detail, 0 for none
 }
 
 // NewServer creates a new unified server
@@ -29,7 +70,31 @@ func NewServer(cfg *config.Config, db *database.DB) *Server {
 		config:      cfg,
 		db:          db,
 		colorScheme: NewColorScheme(&cfg.BBS.Colors),
+		conns:       make(map[string]*connLogger),
+		nodes:       newNodeTable(),
+		events:      events.NewBus(),
+		jobs:        scheduler.New(),
+		chat:        chat.NewHub(),
+		boards:      make(map[string]*Server),
+		art:         ansiart.NewCache(),
+		modules:     newRegistry(),
+		metrics:     metrics.New(time.Duration(cfg.Server.Metrics.SlowMillis) * time.Millisecond),
+		usage: usage.NewCache(usageCacheTTL, func(now time.Time) (*database.ActivityStats, error) {
+			return db.GetActivityStats(now, usage.Days)
+		}),
+	}
+	if tp := cfg.Server.Tarpit; tp.Enabled {
+		server.tarpit = tarpit.New(tp.Strikes, time.Duration(tp.WindowMinutes)*time.Minute)
+	}
+	if lc := cfg.Server.Logins; lc.Throttle {
+		server.logins = throttle.New(time.Duration(lc.BaseDelaySeconds)*time.Second,
+			time.Duration(lc.MaxDelaySeconds)*time.Second, time.Duration(lc.WindowMinutes)*time.Minute)
 	}
+	server.help = server.loadHelp()
+	server.strings = server.loadStrings()
+	server.checkMenus()
+	server.checkThemes()
+	server.checkAliases()
 	server.setupSSHConfig()
 	return server
 }
@@ -39,32 +104,68 @@ func (s *Server) setupSSHConfig() {
 	s.sshConfig = &ssh.ServerConfig{
 		PasswordCallback: s.passwordCallback,
 		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			s.tarpitAuth(conn)
 			return nil, fmt.Errorf("public key authentication not supported")
 		},
 	}
 
+	policy := s.cfg().Server.SSH
+	if err := applySSHPolicy(&s.sshConfig.Config, policy); err != nil {
+		panic(fmt.Sprintf("Invalid SSH policy: %v", err))
+	}
+
 	// Generate or load host key
-	hostKey, err := GenerateHostKey(s.config.Server.HostKeyPath)
+	hostKey, err := GenerateHostKey(s.cfg().HostKeyPath(), policy.MinRSABits)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to load host key: %v", err))
 	}
+	hostKey, err = policyHostKey(hostKey, policy)
+	if err != nil {
+		panic(fmt.Sprintf("Host key does not meet SSH policy: %v", err))
+	}
 	s.sshConfig.AddHostKey(hostKey)
 }
 
 // passwordCallback handles SSH password authentication
 func (s *Server) passwordCallback(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
-	username := conn.User()
+	s.tarpitAuth(conn)
+	board, username := s.route(conn.User())
+	logger := s.connLog(conn.RemoteAddr().String())
+
+	// New callers log in as "new" with any password and register in-session
+	if username == registration.NewUserName && board.cfg().BBS.Registration.Enabled && !board.db.ReadOnly() {
+		logger.Printf("new user registration login")
+		return &ssh.Permissions{
+			Extensions: map[string]string{
+				"username": username,
+			},
+		}, nil
+	}
 
-	// Try to authenticate user
-	user, err := s.db.GetUser(username)
-	if err != nil {
+	// Callers without an account log in as "feedback" to leave the sysop a note
+	if username == feedback.LoginName && board.cfg().BBS.Feedback.Enabled && !board.db.ReadOnly() {
+		logger.Printf("feedback login")
+		return &ssh.Permissions{
+			Extensions: map[string]string{
+				"username": username,
+			},
+		}, nil
+	}
+
+	// An address banned during this connection gets no more attempts
+	if s.banned(conn.RemoteAddr()) {
 		return nil, fmt.Errorf("authentication failed")
 	}
+	s.throttleLogin(conn.User(), conn.RemoteAddr())
 
-	// Simple password check (in production, use proper hashing)
-	if user.Password != string(password) {
+	// Verify the password against the stored hash
+	if _, err := board.db.VerifyPassword(username, string(password)); err != nil {
+		logger.Printf("authentication failed for %q", conn.User())
+		s.loginFailed(conn.User(), conn.RemoteAddr(), logger)
 		return nil, fmt.Errorf("authentication failed")
 	}
+	s.loginSucceeded(conn.User(), conn.RemoteAddr())
+	logger.Printf("authenticated as %q", conn.User())
 
 	return &ssh.Permissions{
 		Extensions: map[string]string{
@@ -75,15 +176,23 @@ func (s *Server) passwordCallback(conn ssh.ConnMetadata, password []byte) (*ssh.
 
 // NewSession creates a new unified session
 func (s *Server) NewSession(term terminal.Terminal, prefilledUsername string) *Session {
+	// The session keeps the configuration it starts with, even if it is
+	// reloaded while the caller is online
+	cfg, colorScheme := s.current()
 	session := &Session{
 		terminal:          term,
+		keys:              input.NewDecoder(term),
 		db:                s.db,
-		config:            s.config,
+		config:            cfg,
 		currentMenu:       "main",
 		selectedIndex:     0,
 		authenticated:     false,
-		colorScheme:       s.colorScheme,
+		colorScheme:       colorScheme,
 		prefilledUsername: prefilledUsername,
+		log:               &connLogger{id: "local", remote: "console"},
+		server:            s,
+		undo:              undo.New(),
+		connectedAt:       time.Now(),
 	}
 
 	// Initialize the TerminalWriter for this session
@@ -93,8 +202,16 @@ func (s *Server) NewSession(term terminal.Terminal, prefilledUsername string) *S
 		pendingRedraw:       false,
 	}
 
+	// Output goes out from a goroutine of its own, so a slow link doesn't
+	// hold up the session
+	session.output = terminal.NewQueue(terminalOutput{session}, maxQueued)
+	session.screen = terminal.NewScreen(80, 24)
+
+	// Alt and a letter are the sysop keys at the console
+	session.keys.SetAlt(session.onConsole())
+
 	// Initialize the MenuRenderer
-	session.menuRenderer = menu.NewMenuRenderer(s.colorScheme, session.writer)
+	session.menuRenderer = menu.NewMenuRenderer(colorScheme, session.writer)
 
 	return session
 }
@@ -108,12 +225,45 @@ func (s *Server) NewLocalSession(term terminal.Terminal) *Session {
 func (s *Server) HandleConnection(netConn net.Conn) {
 	defer netConn.Close()
 
+	if s.banned(netConn.RemoteAddr()) {
+		return
+	}
+
+	remote := netConn.RemoteAddr().String()
+	logger := newConnLogger(remote)
+	if !s.registerConn(remote, logger) {
+		logger.Printf("refused connection from %s: too many connections from that address", remote)
+		return
+	}
+	defer s.unregisterConn(remote)
+
+	netConn = s.tarpitConn(netConn, logger)
+	logger.Printf("connection from %s", remote)
+	start := time.Now()
+	defer func() {
+		logger.Printf("connection closed after %s", time.Since(start).Round(time.Second))
+	}()
+
 	// Perform SSH handshake
 	sshConn, chans, reqs, err := ssh.NewServerConn(netConn, s.sshConfig)
 	if err != nil {
+		if reason := describePolicyFailure(err); reason != "" {
+			logger.Printf("%s", reason)
+		} else {
+			logger.Printf("SSH handshake failed: %v", err)
+		}
+		s.handshakeAborted(netConn.RemoteAddr(), logger)
 		return
 	}
 	defer sshConn.Close()
+	s.handshakeSucceeded(netConn.RemoteAddr(), logger)
+	if meta, ok := sshConn.Conn.(ssh.AlgorithmsConnMetadata); ok {
+		algorithms := meta.Algorithms()
+		logger.Printf("SSH client %q (%s, %s, %s)", sshConn.ClientVersion(),
+			algorithms.KeyExchange, algorithms.Read.Cipher, algorithms.HostKey)
+	} else {
+		logger.Printf("SSH client %q", sshConn.ClientVersion())
+	}
 
 	// Handle out-of-band requests
 	go ssh.DiscardRequests(reqs)
@@ -121,12 +271,14 @@ func (s *Server) HandleConnection(netConn net.Conn) {
 	// Handle channels
 	for newChannel := range chans {
 		if newChannel.ChannelType() != "session" {
+			logger.Printf("rejected %q channel", newChannel.ChannelType())
 			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
 			continue
 		}
 
 		channel, requests, err := newChannel.Accept()
 		if err != nil {
+			logger.Printf("failed to accept channel: %v", err)
 			continue
 		}
 
@@ -139,10 +291,12 @@ func (s *Server) HandleConnection(netConn net.Conn) {
 		// Create SSH terminal interface
 		sshTerm := terminal.NewSSHTerminal(channel)
 
-		// Create unified session
-		session := s.NewSession(sshTerm, username)
+		// Create unified session on the board the caller logged in to
+		board, _ := s.route(sshConn.User())
+		session := board.NewSession(sshTerm, username)
+		session.log = logger
 
-		go s.handleSSHSession(session, channel, requests)
+		go board.handleSSHSession(session, channel, requests)
 	}
 }
 
@@ -150,6 +304,11 @@ func (s *Server) HandleConnection(netConn net.Conn) {
 func (s *Server) handleSSHSession(session *Session, channel ssh.Channel, requests <-chan *ssh.Request) {
 	defer channel.Close()
 
+	sshTerm, _ := session.terminal.(*terminal.SSHTerminal)
+	shellStarted := make(chan struct{})
+	started := false
+	command := "" // Set before shellStarted closes for "ssh host status"
+
 	// Handle session requests
 	go func() {
 		for req := range requests {
@@ -158,9 +317,28 @@ func (s *Server) handleSSHSession(session *Session, channel ssh.Channel, request
 				if req.WantReply {
 					req.Reply(true, nil)
 				}
+				if !started {
+					started = true
+					if req.Type == "exec" {
+						command = execCommand(req.Payload)
+					}
+					close(shellStarted)
+				}
 			case "pty-req":
+				ok := sshTerm != nil && sshTerm.HandlePtyRequest(req.Payload) == nil
+				if ok {
+					w, h, _ := sshTerm.Size()
+					session.log.Printf("pty-req %s %dx%d", sshTerm.TermType(), w, h)
+				}
 				if req.WantReply {
-					req.Reply(true, nil)
+					req.Reply(ok, nil)
+				}
+			case "window-change":
+				if sshTerm != nil && sshTerm.HandleWindowChange(req.Payload) == nil && started {
+					session.handleResize()
+				}
+				if req.WantReply {
+					req.Reply(false, nil) // window-change never wants a reply (RFC 4254 §6.7)
 				}
 			default:
 				if req.WantReply {
@@ -168,12 +346,40 @@ func (s *Server) handleSSHSession(session *Session, channel ssh.Channel, request
 				}
 			}
 		}
+		if !started {
+			close(shellStarted)
+		}
 	}()
 
+	// Wait for the shell request so the pty size is known before drawing
+	<-shellStarted
+
+	pty := sshTerm != nil && sshTerm.TermType() != ""
+	if command == statusCommand {
+		session.runStatus(channel, pty)
+		return
+	}
+	if args := strings.Fields(command); len(args) > 0 && args[0] == exportCommand {
+		session.runExport(channel, pty, args[1:])
+		return
+	}
+	if sshTerm != nil {
+		session.negotiateLineMode(sshTerm)
+		session.negotiateEncoding(sshTerm)
+	}
+
 	// Run the unified session
 	session.Run()
 }
 
+// maxQueued is how much output may wait for a slow link before the
+// session waits for it to go
+const maxQueued = 256 * 1024
+
+// closeWait is how long the last output has to reach the caller when the
+// session ends
+const closeWait = 5 * time.Second
+
 // TerminalWriter adapts session to Writer interface for modules
 type TerminalWriter struct {
 	session              *Session
@@ -199,11 +405,30 @@ func (w *TerminalWriter) Resume() {
 // Size returns the terminal dimensions (for pager compatibility)
 func (w *TerminalWriter) Size() (width, height int, err error) {
 	if w.session.terminal != nil {
-		return w.session.terminal.Size()
+		return w.session.size()
 	}
 	return 80, 24, nil // Fallback dimensions
 }
 
+// UndoBuffer returns the caller's undo buffer, which lasts the whole call
+func (w *TerminalWriter) UndoBuffer() *undo.Buffer {
+	return w.session.undo
+}
+
+// Caller tells modules whose call this is
+func (w *TerminalWriter) Caller() modules.Caller {
+	s := w.session
+	if s.user == nil {
+		return modules.Caller{Node: s.node}
+	}
+	return modules.Caller{
+		UserID:      s.user.ID,
+		Username:    s.user.Username,
+		AccessLevel: s.user.AccessLevel,
+		Node:        s.node,
+	}
+}
+
 // ForceStatusBarRedraw forces an immediate synchronous status bar redraw
 // This version does NOT restore the cursor, leaving it at the status bar line
 func (w *TerminalWriter) ForceStatusBarRedraw() {
@@ -213,31 +438,72 @@ func (w *TerminalWriter) ForceStatusBarRedraw() {
 }
 
 func (w *TerminalWriter) Write(data []byte) (int, error) {
-	// For SSH terminals, use the underlying term.Terminal for proper ANSI handling
-	if sshTerm, ok := w.session.terminal.(*terminal.SSHTerminal); ok {
-		terminalInstance := sshTerm.GetTerminal()
-		n, err := terminalInstance.Write(data)
-		// After any write, redraw status bar if screen was cleared
-		w.handleStatusBarRedraw(data)
-		return n, err
-	}
-
-	// For local terminals, also use term.Terminal for consistent ANSI processing
-	if localTerm, ok := w.session.terminal.(*terminal.LocalTerminal); ok {
-		terminalInstance := localTerm.GetTerminal()
-		n, err := terminalInstance.Write(data)
-		// After any write, redraw status bar if screen was cleared
-		w.handleStatusBarRedraw(data)
-		return n, err
-	}
-
-	// Fallback to direct write
-	n, err := w.session.terminal.Write(data)
+	n, err := w.writeTerminal(data)
 	// After any write, redraw status bar if screen was cleared
 	w.handleStatusBarRedraw(data)
 	return n, err
 }
 
+// writeTerminal queues data for the terminal in the caller's character set,
+// without watching for screen clears
+func (w *TerminalWriter) writeTerminal(data []byte) (int, error) {
+	if w.session.hungUp.Load() {
+		return 0, modules.ErrHungUp
+	}
+	if _, err := w.session.output.Write(w.encode(data)); err != nil {
+		return 0, modules.ErrHungUp
+	}
+	w.session.screen.Write(data)
+	// Callers count the bytes they passed in, not the translated ones
+	return len(data), nil
+}
+
+// offerTerminal queues data like writeTerminal, unless a slow link has
+// left output waiting, for updates the next one replaces
+func (w *TerminalWriter) offerTerminal(data []byte) {
+	if !w.session.hungUp.Load() && w.session.output.Offer(w.encode(data)) {
+		w.session.screen.Write(data)
+	}
+}
+
+// Redraw returns output that draws rows first to last of the caller's
+// screen as they are now, for dialogs to put back what they cover
+func (w *TerminalWriter) Redraw(first, last int) string {
+	return w.session.screen.Redraw(first, last)
+}
+
+// encode puts data in the caller's character set
+func (w *TerminalWriter) encode(data []byte) []byte {
+	if w.session.cp437.Load() {
+		return terminal.ToCP437(data)
+	}
+	return data
+}
+
+// terminalOutput writes to the caller's terminal for the session's output
+// queue. SSH and local terminals go through term.Terminal for consistent
+// ANSI handling.
+type terminalOutput struct {
+	session *Session
+}
+
+func (o terminalOutput) Write(out []byte) (int, error) {
+	s := o.session
+	var err error
+	if sshTerm, ok := s.terminal.(*terminal.SSHTerminal); ok {
+		_, err = sshTerm.GetTerminal().Write(out)
+	} else if localTerm, ok := s.terminal.(*terminal.LocalTerminal); ok {
+		_, err = localTerm.GetTerminal().Write(out)
+	} else {
+		_, err = s.terminal.Write(out)
+	}
+	if err != nil {
+		s.hangUp(err)
+		return 0, err
+	}
+	return len(out), nil
+}
+
 // handleStatusBarRedraw checks if screen was cleared and redraws status bar if needed
 func (w *TerminalWriter) handleStatusBarRedraw(data []byte) {
 	dataStr := string(data)
@@ -276,7 +542,7 @@ func (w *TerminalWriter) handleStatusBarRedraw(data []byte) {
 // Used for timer updates to avoid disrupting the display
 func (w *TerminalWriter) doStatusBarRedraw() {
 	// Get terminal height for proper positioning
-	_, height, err := w.session.terminal.Size()
+	_, height, err := w.session.size()
 	if err != nil {
 		height = 24 // Default height
 	}
@@ -297,22 +563,14 @@ func (w *TerminalWriter) doStatusBarRedraw() {
 	statusBarOutput := saveCursor + positionCode + statusBarContent + restoreCursor
 
 	// Write status bar directly to terminal (avoid recursion)
-	if sshTerm, ok := w.session.terminal.(*terminal.SSHTerminal); ok {
-		terminalInstance := sshTerm.GetTerminal()
-		terminalInstance.Write([]byte(statusBarOutput))
-	} else if localTerm, ok := w.session.terminal.(*terminal.LocalTerminal); ok {
-		terminalInstance := localTerm.GetTerminal()
-		terminalInstance.Write([]byte(statusBarOutput))
-	} else {
-		w.session.terminal.Write([]byte(statusBarOutput))
-	}
+	w.writeTerminal([]byte(statusBarOutput))
 }
 
 // doStatusBarRedrawNoRestore performs status bar redraw WITHOUT restoring cursor
 // Used by pager to ensure cursor stays at status bar line
 func (w *TerminalWriter) doStatusBarRedrawNoRestore() {
 	// Get terminal height for proper positioning
-	_, height, err := w.session.terminal.Size()
+	_, height, err := w.session.size()
 	if err != nil {
 		height = 24 // Default height
 	}
@@ -327,15 +585,7 @@ func (w *TerminalWriter) doStatusBarRedrawNoRestore() {
 	statusBarOutput := positionCode + statusBarContent
 
 	// Write status bar directly to terminal (avoid recursion)
-	if sshTerm, ok := w.session.terminal.(*terminal.SSHTerminal); ok {
-		terminalInstance := sshTerm.GetTerminal()
-		terminalInstance.Write([]byte(statusBarOutput))
-	} else if localTerm, ok := w.session.terminal.(*terminal.LocalTerminal); ok {
-		terminalInstance := localTerm.GetTerminal()
-		terminalInstance.Write([]byte(statusBarOutput))
-	} else {
-		w.session.terminal.Write([]byte(statusBarOutput))
-	}
+	w.writeTerminal([]byte(statusBarOutput))
 }
 
 // TerminalKeyReader adapts session to KeyReader interface for modules
@@ -346,3 +596,13 @@ type TerminalKeyReader struct {
 func (r *TerminalKeyReader) ReadKey() (string, error) {
 	return r.session.readKey()
 }
+
+// ReadTextKey lets forms read every letter as typed
+func (r *TerminalKeyReader) ReadTextKey() (string, error) {
+	return r.session.readTextKey()
+}
+
+// History lets prompts bring back the lines typed earlier in the call
+func (r *TerminalKeyReader) History() *input.History {
+	return &r.session.history
+}
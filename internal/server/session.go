@@ -1,23 +1,44 @@
 package server
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"bbs/internal/config"
 	"bbs/internal/database"
+	"bbs/internal/dialog"
+	"bbs/internal/input"
 	"bbs/internal/menu"
+	"bbs/internal/modules"
 	"bbs/internal/modules/bulletins"
+	"bbs/internal/modules/feedback"
+	"bbs/internal/modules/messages"
+	"bbs/internal/modules/newscan"
+	"bbs/internal/modules/registration"
+	"bbs/internal/modules/sysop"
 	"bbs/internal/modules/sysop/user_editor"
+	"bbs/internal/notify"
+	"bbs/internal/scripts"
 	"bbs/internal/statusbar"
 	"bbs/internal/terminal"
+	"bbs/internal/undo"
 )
 
 // Session represents a unified BBS session that can work with any terminal type
 type Session struct {
 	terminal          terminal.Terminal
-	writer            *TerminalWriter // Use TerminalWriter for all output
+	output            *terminal.Queue  // What is waiting to be sent to the caller
+	screen            *terminal.Screen // What the caller's terminal shows, for redrawing under dialogs
+	keys              *input.Decoder   // Decodes what the caller types into key presses
+	pasted            []byte           // What the caller pasted, still to be read a key at a time
+	pasteMarks        bool             // Set while the caller's terminal marks what they paste
+	history           input.History    // Lines typed at prompts this call, for Up to bring back
+	writer            *TerminalWriter  // Use TerminalWriter for all output
 	db                *database.DB
 	config            *config.Config
 	user              *database.User
@@ -29,14 +50,75 @@ type Session struct {
 	prefilledUsername string // For SSH connections where username is already known
 	menuRenderer      *menu.MenuRenderer
 	statusBar         *statusbar.Manager
+	log               *connLogger // Tags log lines with the connection ID
+	remote            net.Addr    // Caller's address when the login is in-session, as from the web; nil on the console
+
+	server       *Server
+	node         int       // Node number while logged in, 0 before login
+	loginTime    time.Time // When the caller claimed their node
+	connectedAt  time.Time // When the connection was made
+	invisible    bool      // Hidden from other callers and login announcements
+	olm          chan onlineMessage
+	olmDone      chan struct{}
+	unsubscribe  func()      // Removes the session's event bus subscription
+	loggedOff    atomic.Bool // Set once the session has been forced off
+	hungUp       atomic.Bool // Set once a write to the caller has failed
+	transferring atomic.Bool // Set while a file transfer owns the channel
+	cp437        atomic.Bool // Output is translated to CP437 for classic clients
+	activity     activity    // What the caller is doing, for who's online
+	lastPage     time.Time   // When the caller last paged the sysop
+	callID       int64       // Caller log entry for this call, 0 if not logged
+
+	timeMu       sync.Mutex // Guards the time limit and countdown fields below
+	timeLimit    int        // Daily minutes for the caller's access level, 0 for no limit
+	timeDeadline time.Time  // When the caller's time runs out, zero with no limit
+	timeCounted  time.Time  // Time online before this is recorded in user_time
+	eventAt      time.Time  // Start of the scheduled event the caller was warned about
+
+	breakIn  atomic.Pointer[sysopChat] // Chat a sysop broke into this session with
+	chatting atomic.Bool               // Set while either side of a sysop chat owns the screen
+
+	consoleLegend atomic.Bool // Set while the console's sysop keys take the bottom row
+	raisedAccess  bool        // Set while the console has raised the caller to sysop access
+	ownAccess     int         // The caller's own access level while it is raised
+
+	unreadKey int  // Index in unreadKeys of the key that jumps to unread items
+	jumping   bool // Set while showing what the unread key found
+
+	undo *undo.Buffer // The caller's last deletion, for a few seconds
+
+	prefs          database.UserPrefs // The caller's theme, terminal type, screen length and menu style
+	clientEncoding string             // Character set chosen when the caller connected
+	screenLines    atomic.Int32       // Screen length the caller chose, 0 to use their client's
+
+	command string        // Menu command running, "" at the menus
+	waited  time.Duration // Time spent waiting for keys, transfers and doors
+	keyAt   time.Time     // When the last key arrived, zero before the first
+
+	bells       atomic.Pointer[database.BellSettings] // When to ring the caller's bell, nil before login
+	notifyPrefs atomic.Pointer[notify.Preferences]    // How the caller hears about things, nil before login
+	exemption   atomic.Pointer[config.Exemption]      // Cutoffs the running transfer or door is exempt from
 }
 
 // Run is the unified entry point for all sessions (SSH and local)
 func (s *Session) Run() {
+	start := time.Now()
 	defer func() {
+		if s.user != nil {
+			s.log.Printf("session ended for %s after %s", s.user.Username, time.Since(start).Round(time.Second))
+		}
+
+		// Deletions the caller could still have undone are final now
+		if err := s.undo.Flush(); err != nil {
+			s.log.Printf("failed to finish deleting: %v", err)
+		}
+
 		// Stop and clear status bar
 		s.stopStatusBar()
+		s.markPastes(false)
 
+		// Let the goodbye reach the caller before hanging up
+		s.output.Close(closeWait)
 		if s.terminal != nil {
 			s.terminal.Close()
 		}
@@ -49,13 +131,59 @@ func (s *Session) Run() {
 		}
 	}
 
+	// The terminal's size is known by the time the call starts
+	s.resizeScreen()
+
 	// Display welcome message
 	s.displayWelcome()
 
+	// Don't attempt a login against a database that is gone or corrupt
+	if !s.ensureDatabaseAvailable() {
+		return
+	}
+
+	// Keep callers out while a scheduled event has the board closed
+	if w, closed := s.server.closedFor(time.Now()); closed {
+		s.log.Printf("login refused during scheduled event %q", w.Name)
+		msg := fmt.Sprintf(s.t("The board is closed for %s until %s. Please call back later."), w.Name, w.End.Format("15:04"))
+		s.write([]byte(s.colorScheme.Colorize(msg, "error") + "\n"))
+		return
+	}
+
+	if s.boardFull() {
+		s.showBoardFull()
+		return
+	}
+
 	// Handle authentication (username prefilled for SSH)
 	if !s.handleLogin() {
 		return
 	}
+	if !s.checkExpiry() {
+		return
+	}
+	if !s.enforcePasswordChange() {
+		return
+	}
+	if !s.checkTimeLimit() {
+		return
+	}
+	s.loadBellSettings()
+	s.loadUnreadKey()
+	s.loadNotifySettings()
+	s.loadPrefs()
+	s.promptKeepAccount()
+	s.promptInvisibleLogin()
+	if !s.joinNode() {
+		s.showBoardFull()
+		return
+	}
+	defer s.leaveNode()
+	if s.invisible {
+		s.log.Printf("session started for %s on node %d (access level %d, invisible)", s.user.Username, s.node, s.user.AccessLevel)
+	} else {
+		s.log.Printf("session started for %s on node %d (access level %d)", s.user.Username, s.node, s.user.AccessLevel)
+	}
 
 	// Ensure raw mode is enabled for navigation (should already be enabled for local)
 	if s.terminal != nil {
@@ -63,12 +191,26 @@ func (s *Session) Run() {
 			s.write([]byte("Warning: Could not set raw mode for navigation\n"))
 		}
 	}
+	s.markPastes(true)
+
+	// Let callers know the board is a read-only mirror
+	if s.db.ReadOnly() {
+		notice := s.colorScheme.Colorize(s.t("NOTICE: This board is running as a read-only mirror during maintenance."), "accent")
+		s.write([]byte(notice + "\n"))
+		s.write([]byte(s.colorScheme.Colorize(s.t("You can read everything, but posting and account changes are disabled."), "text") + "\n"))
+		s.waitForKey()
+	}
 
-	// Show bulletins after successful login
-	bulletinsModule := bulletins.NewModule(s.db, s.colorScheme)
+	// Show the bulletins the caller hasn't seen after successful login
 	writer := &TerminalWriter{session: s}
 	keyReader := &TerminalKeyReader{session: s}
-	bulletinsModule.Execute(writer, keyReader)
+	if s.config.BBS.BulletinsAtLogin {
+		bulletins.NewModule(s.db, s.colorScheme, s.user.ID).ShowUnseen(writer, keyReader)
+	}
+	s.offerTutorial()
+	if s.config.BBS.NewScanAtLogin {
+		newscan.NewScan(s.db, s.colorScheme, s.user.ID, s.user.AccessLevel).Offer(writer, keyReader)
+	}
 
 	// Set to main menu after bulletins
 	s.currentMenu = "main"
@@ -79,11 +221,21 @@ func (s *Session) Run() {
 
 // handleLogin handles the login process for both SSH and local sessions
 func (s *Session) handleLogin() bool {
+	// SSH callers who logged in as "new" go straight to registration
+	if s.prefilledUsername == registration.NewUserName {
+		return s.handleRegistration()
+	}
+	if s.prefilledUsername == feedback.LoginName {
+		s.handleFeedback()
+		return false
+	}
+
 	// For SSH sessions, user is already authenticated, just get user info
 	if s.prefilledUsername != "" {
 		user, err := s.db.GetUser(s.prefilledUsername)
 		if err != nil {
-			s.write([]byte(s.colorScheme.Colorize("Error retrieving user information.", "error") + "\n"))
+			s.log.Printf("failed to load user %s: %v", s.prefilledUsername, err)
+			s.write([]byte(s.colorScheme.Colorize(s.t("Error retrieving user information."), "error") + "\n"))
 			return false
 		}
 		s.user = user
@@ -93,12 +245,12 @@ func (s *Session) handleLogin() bool {
 		// Initialize status bar after successful authentication
 		s.initializeStatusBar()
 
-		s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf("Welcome back, %s!", user.Username), "accent") + "\n"))
+		s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf(s.t("Welcome back, %s!"), user.Username), "accent") + "\n"))
 		if user.LastCall != nil {
 			lastCallStr := fmt.Sprintf("Last call: %s", user.LastCall.Format("2006-01-02 15:04:05"))
 			s.write([]byte(s.colorScheme.Colorize(lastCallStr, "text") + "\n"))
 		} else {
-			s.write([]byte(s.colorScheme.Colorize("Last call: First time login", "text") + "\n"))
+			s.write([]byte(s.colorScheme.Colorize(s.t("Last call: First time login"), "text") + "\n"))
 		}
 		totalCallsStr := fmt.Sprintf("Total calls: %d", user.TotalCalls)
 		s.write([]byte(s.colorScheme.Colorize(totalCallsStr, "text") + "\n\n"))
@@ -106,7 +258,15 @@ func (s *Session) handleLogin() bool {
 	}
 
 	// For local sessions, perform login process
-	s.write([]byte(s.colorScheme.Colorize("=== Coastline BBS ===", "header") + "\n\n"))
+	s.write([]byte(s.colorScheme.Colorize(s.t("=== Coastline BBS ==="), "header") + "\n\n"))
+	if s.config.BBS.Registration.Enabled {
+		hint := fmt.Sprintf(s.t("New callers: log in as '%s' to apply for an account."), registration.NewUserName)
+		s.write([]byte(s.colorScheme.Colorize(hint, "text") + "\n\n"))
+	}
+	if s.config.BBS.Feedback.Enabled {
+		hint := fmt.Sprintf(s.t("Trouble getting in? Log in as '%s' to leave the sysop a note."), feedback.LoginName)
+		s.write([]byte(s.colorScheme.Colorize(hint, "text") + "\n\n"))
+	}
 
 	for attempts := 0; attempts < 3; attempts++ {
 		// Get username
@@ -120,6 +280,14 @@ func (s *Session) handleLogin() bool {
 			continue
 		}
 
+		if username == registration.NewUserName && s.config.BBS.Registration.Enabled {
+			return s.handleRegistration()
+		}
+		if username == feedback.LoginName && s.config.BBS.Feedback.Enabled {
+			s.handleFeedback()
+			return false
+		}
+
 		// Get password
 		s.write([]byte("Password: "))
 		password, err := s.readInput(true)
@@ -131,12 +299,27 @@ func (s *Session) handleLogin() bool {
 			continue
 		}
 
+		// Remote callers are throttled and banned as SSH logins are
+		if s.remote != nil {
+			s.server.throttleLogin(username, s.remote)
+		}
+
 		// Validate credentials
-		user, err := s.db.GetUser(username)
-		if err != nil || user.Password != password {
-			s.write([]byte(s.colorScheme.Colorize("Invalid username or password.", "error") + "\n"))
+		user, err := s.db.VerifyPassword(username, password)
+		if err != nil {
+			s.log.Printf("login failed for %q", username)
+			s.write([]byte(s.colorScheme.Colorize(s.t("Invalid username or password."), "error") + "\n"))
+			if s.remote != nil {
+				s.server.loginFailed(username, s.remote, s.log)
+				if s.server.banned(s.remote) {
+					return false
+				}
+			}
 			continue
 		}
+		if s.remote != nil {
+			s.server.loginSucceeded(username, s.remote)
+		}
 
 		// Successful login
 		s.user = user
@@ -146,28 +329,89 @@ func (s *Session) handleLogin() bool {
 		// Initialize status bar after successful authentication
 		s.initializeStatusBar()
 
-		s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf("Welcome, %s!", user.Username), "accent") + "\n\n"))
+		s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf(s.t("Welcome, %s!"), user.Username), "accent") + "\n\n"))
 		return true
 	}
 
-	s.write([]byte(s.colorScheme.Colorize("Too many failed attempts. Access denied.", "error") + "\n"))
+	s.write([]byte(s.colorScheme.Colorize(s.t("Too many failed attempts. Access denied."), "error") + "\n"))
 	return false
 }
 
+// promptInvisibleLogin offers high-level accounts the option to log in
+// invisibly: hidden from other callers and without a login announcement.
+// The call itself is still recorded in the user record and the server log.
+func (s *Session) promptInvisibleLogin() {
+	level := s.config.BBS.InvisibleLoginLevel
+	if level <= 0 || s.user.AccessLevel < level {
+		return
+	}
+
+	s.invisible = dialog.Confirm(s.writer, &TerminalKeyReader{session: s}, s.colorScheme, s.t("Log in invisibly?"), false)
+	if s.invisible {
+		s.write([]byte(s.colorScheme.Colorize(s.t("You are invisible to other callers this session."), "text") + "\n\n"))
+	}
+}
+
+// handleFeedback runs the note form for a caller without an account. The
+// session ends afterwards either way.
+func (s *Session) handleFeedback() {
+	address := "console"
+	if host, _, err := net.SplitHostPort(s.log.remote); err == nil {
+		address = host
+	}
+
+	form := feedback.NewForm(s.db, s.colorScheme, s.config.BBS.Feedback, address)
+	keyReader := &TerminalKeyReader{session: s}
+	if form.Execute(s.writer, keyReader) {
+		s.log.Printf("feedback left from %s", address)
+	}
+}
+
+// handleRegistration runs the new user application and logs the caller in
+// as the new account when it doesn't need sysop approval
+func (s *Session) handleRegistration() bool {
+	reg := registration.NewRegistration(s.db, s.colorScheme, s.config.BBS.Registration, s.log)
+	keyReader := &TerminalKeyReader{session: s}
+
+	user := reg.Register(s.writer, keyReader)
+	if user == nil {
+		s.log.Printf("registration ended without a usable account")
+		return false
+	}
+	s.log.Printf("registered new user %s", user.Username)
+
+	s.user = user
+	s.authenticated = true
+	s.db.UpdateUserLastCall(user.Username)
+
+	s.write([]byte(menu.ClearScreen))
+	s.initializeStatusBar()
+
+	s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf(s.t("Welcome, %s!"), user.Username), "accent") + "\n\n"))
+	return true
+}
+
 // initializeStatusBar creates and starts the status bar for the session
 func (s *Session) initializeStatusBar() {
 	if s.user == nil {
 		return
 	}
 
+	// The console's sysop keys take a second status line, below the bar
+	s.consoleLegend.Store(s.onConsole())
+
 	// Get terminal dimensions
-	_, height, err := s.terminal.Size()
+	_, height, err := s.size()
 	if err != nil {
 		height = 24 // Default height if unable to get terminal size
 	}
 
 	// Create status bar manager
 	s.statusBar = statusbar.NewManager(s.user.Username, s.config, height)
+	if s.consoleLegend.Load() {
+		s.statusBar.SetLegend(s.consoleLegendText())
+	}
+	s.statusBar.Resize(s.width(), height)
 
 	// Start status bar updates every second
 	statusUpdates := s.statusBar.Start(time.Second)
@@ -175,17 +419,13 @@ func (s *Session) initializeStatusBar() {
 	// Handle timer updates in a goroutine - these are just timer updates, not full redraws
 	go func() {
 		for timerUpdate := range statusUpdates {
-			// Write timer updates directly to terminal without going through TerminalWriter
-			// to avoid triggering screen-clear detection
-			if sshTerm, ok := s.terminal.(*terminal.SSHTerminal); ok {
-				terminalInstance := sshTerm.GetTerminal()
-				terminalInstance.Write([]byte(timerUpdate))
-			} else if localTerm, ok := s.terminal.(*terminal.LocalTerminal); ok {
-				terminalInstance := localTerm.GetTerminal()
-				terminalInstance.Write([]byte(timerUpdate))
-			} else {
-				s.terminal.Write([]byte(timerUpdate))
+			if s.transferring.Load() {
+				continue
 			}
+			// Write timer updates directly to terminal, skipping TerminalWriter's
+			// screen-clear detection; one a slow link has no room for is
+			// dropped, since the next replaces it
+			s.writer.offerTerminal([]byte(timerUpdate))
 		}
 	}()
 
@@ -193,6 +433,76 @@ func (s *Session) initializeStatusBar() {
 	s.ensureStatusBar()
 }
 
+// statusRefresh is how often the status bar's node count, unread mail and
+// time left are brought up to date
+const statusRefresh = 10 * time.Second
+
+// refreshStatusBar brings the status bar's node count, unread mail and time
+// left up to date
+func (s *Session) refreshStatusBar() {
+	if s.statusBar == nil {
+		return
+	}
+	s.statusBar.SetNodes(len(s.whosOnline()))
+	if unread, err := s.db.CountUnreadMail(s.user.Username); err == nil {
+		s.statusBar.SetUnread(unread)
+	}
+	s.timeMu.Lock()
+	deadline := s.timeDeadline
+	s.timeMu.Unlock()
+	s.statusBar.SetDeadline(deadline)
+}
+
+// watchStatusBar keeps the status bar's counts current until the session
+// leaves its node. The bar's own ticker draws them.
+func (s *Session) watchStatusBar() {
+	ticker := time.NewTicker(statusRefresh)
+	defer ticker.Stop()
+
+	s.refreshStatusBar()
+	for {
+		select {
+		case <-ticker.C:
+			s.refreshStatusBar()
+		case <-s.olmDone:
+			return
+		}
+	}
+}
+
+// width returns the usable line width for centered output, one column short
+// of the terminal so full-width lines don't wrap
+func (s *Session) width() int {
+	w, _, err := s.size()
+	if err != nil || w < 2 {
+		return 79
+	}
+	return w - 1
+}
+
+// handleResize adapts the status bar after the client's terminal changed size.
+// Menus and the pager pick up the new size on their next redraw.
+func (s *Session) handleResize() {
+	s.resizeScreen()
+	if s.statusBar == nil {
+		return
+	}
+	_, height, err := s.size()
+	if err != nil {
+		return
+	}
+	if output := s.statusBar.Resize(s.width(), height); output != "" {
+		s.write([]byte(output))
+	}
+}
+
+// resizeScreen sizes the copy of the caller's screen to their terminal
+func (s *Session) resizeScreen() {
+	if width, height, err := s.terminal.Size(); err == nil {
+		s.screen.Resize(width, height)
+	}
+}
+
 // stopStatusBar stops and clears the status bar
 func (s *Session) stopStatusBar() {
 	if s.statusBar != nil {
@@ -208,7 +518,7 @@ func (s *Session) stopStatusBar() {
 func (s *Session) ensureStatusBar() {
 	if s.statusBar != nil {
 		// Get terminal height for proper positioning
-		_, height, err := s.terminal.Size()
+		_, height, err := s.size()
 		if err != nil {
 			height = 24 // Default height
 		}
@@ -221,55 +531,27 @@ func (s *Session) ensureStatusBar() {
 
 // displayWelcome displays the welcome message
 func (s *Session) displayWelcome() {
+	if art := s.loadArt(s.config.BBS.Art.Welcome); art != nil {
+		s.write([]byte(menu.ClearScreen))
+		s.showArt(art)
+		return
+	}
 	banner := s.colorScheme.CreateWelcomeBanner(s.config.BBS.SystemName, s.config.BBS.WelcomeMsg)
 	s.write([]byte(banner))
 }
 
 // readInput reads user input with optional masking (for passwords)
 func (s *Session) readInput(maskInput bool) (string, error) {
-	// Use character-by-character reading for both SSH and local terminals
-	// to ensure consistent behavior
-	var input string
-	buf := make([]byte, 1)
-
-	for {
-		n, err := s.terminal.Read(buf)
-		if err != nil {
-			return "", err
-		}
-
-		if n == 0 {
-			continue
-		}
-
-		switch buf[0] {
-		case 13, 10: // Enter or newline - finish input
-			s.terminal.Write([]byte("\r\n"))
-			return input, nil
-		case 8, 127: // Backspace or DEL
-			if len(input) > 0 {
-				input = input[:len(input)-1]
-				// Move cursor back, overwrite with space, move back again
-				s.terminal.Write([]byte("\b \b"))
-			}
-		case 3: // Ctrl+C
-			return "", fmt.Errorf("interrupted")
-		case 27: // Escape - ignore
-			continue
-		default:
-			// Add character to input
-			if buf[0] >= 32 && buf[0] <= 126 { // Printable ASCII
-				input += string(buf[0])
-				// Echo the character appropriately
-				if maskInput {
-					s.terminal.Write([]byte("*"))
-				} else {
-					// Echo the actual character for non-masked input
-					s.terminal.Write(buf[:1])
-				}
-			}
-		}
+	keys := &TerminalKeyReader{session: s}
+	read := input.ReadLine
+	if maskInput {
+		read = input.ReadPassword
 	}
+	line, err := read(keys, s.writer, 0)
+	if errors.Is(err, input.ErrCancelled) {
+		return "", fmt.Errorf("interrupted")
+	}
+	return line, err
 }
 
 // write is a unified method to write to either terminal type
@@ -285,6 +567,7 @@ func (s *Session) menuLoop() {
 		var currentMenu *config.MenuItem
 		for _, menu := range s.config.BBS.Menus {
 			if menu.ID == s.currentMenu {
+				menu = s.server.menuWithModules(menu)
 				currentMenu = &menu
 				break
 			}
@@ -309,7 +592,7 @@ func (s *Session) menuLoop() {
 		}
 
 		// Display menu
-		s.displayMenu(currentMenu)
+		art := s.displayMenu(currentMenu)
 
 		// Navigation loop
 	NavigationLoop:
@@ -319,7 +602,21 @@ func (s *Session) menuLoop() {
 				return
 			}
 
+			// Art menus have no lightbar, so callers choose with the hotkeys,
+			// as do callers who turned the lightbar off
+			if (art || s.hotkeysOnly()) && (key == "up" || key == "down" || key == "enter") {
+				continue
+			}
+
 			switch key {
+			case "pageup", "pagedown", "home", "end":
+				// A menu longer than the screen is shown a screenful at a time
+				if art {
+					continue
+				}
+				s.selectedIndex = menu.Jump(key, s.selectedIndex, len(accessibleItems), menu.ListRows(s.writer))
+				s.displayMenu(currentMenu)
+
 			case "up":
 				s.selectedIndex--
 				if s.selectedIndex < 0 {
@@ -327,6 +624,20 @@ func (s *Session) menuLoop() {
 				}
 				s.displayMenu(currentMenu)
 
+			case "redraw":
+				s.displayMenu(currentMenu)
+
+			case commandKey:
+				if !s.commandPrompt() {
+					s.write([]byte(menu.ShowCursor))
+					return
+				}
+				break NavigationLoop
+
+			case "?", "f1":
+				s.showHelp(accessibleItems[s.selectedIndex].Command, s.currentMenu)
+				break NavigationLoop
+
 			case "down":
 				s.selectedIndex++
 				if s.selectedIndex >= len(accessibleItems) {
@@ -368,9 +679,7 @@ func (s *Session) menuLoop() {
 
 			case "goodbye", "g", "G":
 				// Handle G key - goodbye from any menu
-				s.write([]byte(menu.ShowCursor))
-				goodbyeMsg := s.colorScheme.Colorize("\nThank you for calling! Goodbye!\n", "success")
-				s.write([]byte(goodbyeMsg))
+				s.sayGoodbye()
 				return
 
 			default:
@@ -389,6 +698,14 @@ func (s *Session) menuLoop() {
 							break NavigationLoop
 						}
 					}
+					// The sysop's one-letter aliases work on every menu
+					if item := s.aliasHotkey(key); item != nil {
+						if !s.executeCommand(item) {
+							s.write([]byte(menu.ShowCursor))
+							return
+						}
+						break NavigationLoop
+					}
 				}
 				// Ignore other keys
 				continue
@@ -397,8 +714,15 @@ func (s *Session) menuLoop() {
 	}
 }
 
-// displayMenu displays the current menu - unified for both SSH and local
-func (s *Session) displayMenu(menu *config.MenuItem) {
+// displayMenu displays the current menu - unified for both SSH and local.
+// It reports whether the menu's art was shown in place of its items.
+func (s *Session) displayMenu(menu *config.MenuItem) bool {
+	if art := s.loadArt(menu.Art); art != nil {
+		s.showMenuArt(art)
+		s.ensureStatusBar()
+		return true
+	}
+
 	// Get user access level (default to 0 if not authenticated)
 	userAccessLevel := 0
 	if s.user != nil {
@@ -410,225 +734,147 @@ func (s *Session) displayMenu(menu *config.MenuItem) {
 
 	// Ensure status bar is visible after menu display
 	s.ensureStatusBar()
+	return false
 }
 
-// readKey reads a single key press - unified for both SSH and local
+// readKey reads a single key press - unified for both SSH and local. The
+// menus take q and g as Quit and Goodbye, and Ctrl+C as Goodbye.
 func (s *Session) readKey() (string, error) {
-	// For SSH terminals, use the terminal interface
-	if _, ok := s.terminal.(*terminal.SSHTerminal); ok {
-		// SSH session - use existing SSH readKey logic
-		return s.readKeySSH()
-	} else {
-		// Local session - use our terminal interface
-		return s.readKeyLocal()
-	}
+	return s.nextKey(false)
 }
 
-// readKeyLocal handles key reading for local terminal
-func (s *Session) readKeyLocal() (string, error) {
-	buf := make([]byte, 1)
-	n, err := s.terminal.Read(buf)
-	if err != nil {
-		return "", err
-	}
-
-	if n == 0 {
-		return "", nil
-	}
-
-	// Handle single character
-	switch buf[0] {
-	case 13, 10: // Enter or newline
-		return "enter", nil
-	case 27: // Escape - check for arrow key sequence
-		// Read next character to see if it's an arrow key
-		buf2 := make([]byte, 1)
-		n2, err := s.terminal.Read(buf2)
-		if err != nil || n2 == 0 {
-			return "escape", nil
-		}
+// readTextKey reads a single key press with every letter as typed, for
+// fields the caller types text into
+func (s *Session) readTextKey() (string, error) {
+	return s.nextKey(true)
+}
 
-		if buf2[0] == 91 { // '['
-			// Read the final character of the arrow key sequence
-			buf3 := make([]byte, 1)
-			n3, err := s.terminal.Read(buf3)
-			if err != nil || n3 == 0 {
-				return "escape", nil
+// nextKey reads a key press, handling the keys that work from anywhere
+func (s *Session) nextKey(text bool) (string, error) {
+	for {
+		key, err := s.readTerminalKey(text)
+
+		// Keys belong to the sysop's chat while one is going on
+		chat := s.breakIn.Load()
+		if chat == nil {
+			// The unread key works from anywhere, then the screen it
+			// interrupted redraws itself
+			if err == nil && s.isUnreadKey(key) {
+				s.jumpToUnread()
+				return "redraw", nil
 			}
-
-			switch buf3[0] {
-			case 65: // 'A' - Up arrow
-				return "up", nil
-			case 66: // 'B' - Down arrow
-				return "down", nil
-			case 67: // 'C' - Right arrow
-				return "right", nil
-			case 68: // 'D' - Left arrow
-				return "left", nil
+			// So do the sysop keys at the console
+			if err == nil {
+				if handled, redraw := s.consoleKey(key); handled {
+					if s.loggedOff.Load() {
+						return "", modules.ErrHungUp
+					}
+					if redraw {
+						return "redraw", nil
+					}
+					continue
+				}
 			}
+			return key, err
+		}
+		if err != nil {
+			chat.hangup()
+			return key, err
+		}
+		if !chat.key(callerPane, key) {
+			chat.restoreCaller()
+			return "redraw", nil
 		}
-		return "escape", nil
-	case 'q', 'Q':
-		return "quit", nil
-	case 'g', 'G':
-		return "goodbye", nil
-	case 3: // Ctrl+C
-		return "goodbye", nil
-	default:
-		return string(buf[0]), nil
 	}
 }
 
-// readKeySSH handles key reading for SSH terminal
-func (s *Session) readKeySSH() (string, error) {
-	buf := make([]byte, 1)
-	n, err := s.terminal.Read(buf)
+// readTerminalKey reads one key from the terminal. Unless text is set, q, g
+// and Ctrl+C come back as the menu commands they stand for. Pasted text is
+// typed a character at a time into text, and dropped anywhere else so it
+// can't be taken as commands.
+func (s *Session) readTerminalKey(text bool) (string, error) {
+	if s.hungUp.Load() {
+		return "", modules.ErrHungUp
+	}
+	if !text {
+		s.pasted = nil
+	}
+	if len(s.pasted) > 0 {
+		return s.pastedKey(), nil
+	}
+
+	waiting := s.startKeyWait()
+	key, err := s.keys.ReadKey()
+	s.endKeyWait(waiting)
 	if err != nil {
 		return "", err
 	}
+	s.touch()
 
-	if n == 0 {
-		return "", nil
+	if !text {
+		return menuKey(key.Name), nil
+	}
+	if key.Name == input.Paste {
+		s.pasted = []byte(key.Text)
+		return s.pastedKey(), nil
 	}
+	return key.Name, nil
+}
 
-	// Handle single character
-	switch buf[0] {
-	case 13, 10: // Enter or newline
-		return "enter", nil
-	case 27: // Escape - check for arrow key sequence
-		// Read next character to see if it's an arrow key
-		buf2 := make([]byte, 1)
-		n2, err := s.terminal.Read(buf2)
-		if err != nil || n2 == 0 {
-			return "escape", nil
-		}
+// markPastes asks the caller's terminal to mark what they paste, or to stop.
+// Only terminals that draw UTF-8 are asked; classic BBS clients may not
+// know the sequence and show it.
+func (s *Session) markPastes(on bool) {
+	if on == s.pasteMarks || on && s.encoding() != terminal.UTF8 {
+		return
+	}
+	s.pasteMarks = on
+	if on {
+		s.write([]byte(input.PasteOn))
+	} else {
+		s.write([]byte(input.PasteOff))
+	}
+}
 
-		if buf2[0] == 91 { // '['
-			// Read the final character of the arrow key sequence
-			buf3 := make([]byte, 1)
-			n3, err := s.terminal.Read(buf3)
-			if err != nil || n3 == 0 {
-				return "escape", nil
-			}
+// pastedKey hands out the next character of what the caller pasted
+func (s *Session) pastedKey() string {
+	if len(s.pasted) == 0 {
+		return ""
+	}
+	b := s.pasted[0]
+	s.pasted = s.pasted[1:]
+	if b == '\n' {
+		return input.Enter
+	}
+	return string(b)
+}
 
-			switch buf3[0] {
-			case 65: // 'A' - Up arrow
-				return "up", nil
-			case 66: // 'B' - Down arrow
-				return "down", nil
-			case 67: // 'C' - Right arrow
-				return "right", nil
-			case 68: // 'D' - Left arrow
-				return "left", nil
-			}
-		}
-		return "escape", nil
-	case 'q', 'Q':
-		return "quit", nil
-	case 'g', 'G':
-		return "goodbye", nil
-	case 3: // Ctrl+C
-		return "goodbye", nil
-	default:
-		return string(buf[0]), nil
+// menuKey turns the keys the menus take as commands into them. A paste is
+// no key at all there.
+func menuKey(name string) string {
+	switch name {
+	case input.Paste:
+		return ""
+	case "q", "Q":
+		return input.Quit
+	case "g", "G", input.CtrlC:
+		return input.Goodbye
 	}
+	return name
 }
 
-// executeCommand executes the selected menu command - unified for both SSH and local
-func (s *Session) executeCommand(item *config.MenuItem) bool {
-	switch item.Command {
-	case "bulletins":
-		bulletinsModule := bulletins.NewModule(s.db, s.colorScheme)
-		keyReader := &TerminalKeyReader{session: s}
-		bulletinsModule.Execute(s.writer, keyReader)
-		return true
-	case "sysop_menu":
-		// Check if user has sysop access
-		if s.user == nil || s.user.AccessLevel < 255 {
-			s.write([]byte("\n\n" + s.colorScheme.Colorize("Access denied. Sysop privileges required.", "error") + "\n"))
-			s.waitForKey()
-			return true
-		}
-		// Navigate to sysop_menu submenu
-		s.menuHistory = append(s.menuHistory, s.currentMenu)
-		s.currentMenu = "sysop_menu"
-		s.selectedIndex = 0
-		return true
-	// Sysop command handlers
-	case "create_user":
-		if s.user == nil || s.user.AccessLevel < 255 {
-			s.write([]byte("\n\n" + s.colorScheme.Colorize("Access denied. Sysop privileges required.", "error") + "\n"))
-			s.waitForKey()
-			return true
-		}
-		s.handleSysopCommand("create_user")
-		return true
-	case "edit_user":
-		if s.user == nil || s.user.AccessLevel < 255 {
-			s.write([]byte("\n\n" + s.colorScheme.Colorize("Access denied. Sysop privileges required.", "error") + "\n"))
-			s.waitForKey()
-			return true
-		}
-		s.handleSysopCommand("edit_user")
-		return true
-	case "delete_user":
-		if s.user == nil || s.user.AccessLevel < 255 {
-			s.write([]byte("\n\n" + s.colorScheme.Colorize("Access denied. Sysop privileges required.", "error") + "\n"))
-			s.waitForKey()
-			return true
-		}
-		s.handleSysopCommand("delete_user")
-		return true
-	case "view_users":
-		if s.user == nil || s.user.AccessLevel < 255 {
-			s.write([]byte("\n\n" + s.colorScheme.Colorize("Access denied. Sysop privileges required.", "error") + "\n"))
-			s.waitForKey()
-			return true
-		}
-		s.handleSysopCommand("view_users")
-		return true
-	case "change_password":
-		if s.user == nil || s.user.AccessLevel < 255 {
-			s.write([]byte("\n\n" + s.colorScheme.Colorize("Access denied. Sysop privileges required.", "error") + "\n"))
-			s.waitForKey()
-			return true
-		}
-		s.handleSysopCommand("change_password")
-		return true
-	case "toggle_user":
-		if s.user == nil || s.user.AccessLevel < 255 {
-			s.write([]byte("\n\n" + s.colorScheme.Colorize("Access denied. Sysop privileges required.", "error") + "\n"))
-			s.waitForKey()
-			return true
-		}
-		s.handleSysopCommand("toggle_user")
-		return true
-	case "system_stats":
-		if s.user == nil || s.user.AccessLevel < 255 {
-			s.write([]byte("\n\n" + s.colorScheme.Colorize("Access denied. Sysop privileges required.", "error") + "\n"))
-			s.waitForKey()
-			return true
-		}
-		s.handleSysopCommand("system_stats")
-		return true
-	case "bulletin_management":
-		if s.user == nil || s.user.AccessLevel < 255 {
-			s.write([]byte("\n\n" + s.colorScheme.Colorize("Access denied. Sysop privileges required.", "error") + "\n"))
-			s.waitForKey()
-			return true
-		}
-		s.handleSysopCommand("bulletin_management")
-		return true
-	case "messages":
-		// TODO: Implement messages module
-		s.write([]byte(s.colorScheme.Colorize("Messages feature coming soon...", "text") + "\n"))
-		s.waitForKey()
-		return true
-	case "goodbye":
-		return false
-	case "logout":
-		return false
-	default:
+// runCommand executes the selected menu command - unified for both SSH and local
+func (s *Session) runCommand(item *config.MenuItem) bool {
+	defer s.setActivity(menuActivity)
+
+	if name, ok := strings.CutPrefix(item.Command, scripts.CommandPrefix); ok {
+		s.noteAction(item.Command)
+		s.setActivity("Using " + item.Title)
+		return s.runScript(name)
+	}
+
+	module, ok := s.server.modules.Lookup(item.Command)
+	if !ok {
 		// Check if this item has submenus
 		if len(item.Submenu) > 0 {
 			// Navigate to submenu
@@ -636,17 +882,87 @@ func (s *Session) executeCommand(item *config.MenuItem) bool {
 			s.currentMenu = item.ID
 			s.selectedIndex = 0
 		} else {
-			s.write([]byte("\n\n" + s.colorScheme.Colorize(fmt.Sprintf("Command '%s' not implemented yet.", item.Command), "text") + "\n"))
+			s.write([]byte("\n\n" + s.colorScheme.Colorize(fmt.Sprintf(s.t("Command '%s' not implemented yet."), item.Command), "text") + "\n"))
 			s.waitForKey()
 		}
 		return true
 	}
+
+	entry := module.MenuEntry()
+	if s.user == nil || s.user.AccessLevel < entry.AccessLevel {
+		s.denyAccess()
+		return true
+	}
+	if entry.Writes && s.denyIfReadOnly() {
+		return true
+	}
+	if entry.Activity != "" {
+		s.setActivity(entry.Activity)
+		s.noteAction(item.Command)
+	}
+
+	keyReader := &TerminalKeyReader{session: s}
+	return module.Execute(s.writer, keyReader)
+}
+
+// denyAccess tells a caller they can't run a command
+func (s *Session) denyAccess() {
+	s.write([]byte("\n\n" + s.colorScheme.Colorize(s.t("Access denied. Sysop privileges required."), "error") + "\n"))
+	s.waitForKey()
+}
+
+// ensureDatabaseAvailable shows a maintenance screen while the database is
+// unavailable and returns false if the caller chose to log off instead of waiting
+func (s *Session) ensureDatabaseAvailable() bool {
+	for !s.db.Healthy() {
+		s.write([]byte(menu.ClearContentArea))
+
+		lines := []struct{ text, color string }{
+			{"*** Board Temporarily Unavailable ***", "error"},
+			{"", ""},
+			{"The system is undergoing unscheduled maintenance.", "text"},
+			{"The sysop has been notified. Please try again shortly.", "text"},
+			{"", ""},
+			{"R: Retry  G: Goodbye", "secondary"},
+		}
+		for _, line := range lines {
+			if line.text == "" {
+				s.write([]byte("\n"))
+				continue
+			}
+			s.write([]byte(s.colorScheme.CenterText(s.colorScheme.Colorize(line.text, line.color), s.width()) + "\n"))
+		}
+
+		key, err := s.readKey()
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(key) {
+		case "r":
+			s.db.CheckHealth()
+		case "goodbye", "g", "quit", "q", "escape":
+			s.sayGoodbye()
+			return false
+		}
+	}
+	return true
+}
+
+// denyIfReadOnly shows a notice and returns true when the board is a read-only mirror
+func (s *Session) denyIfReadOnly() bool {
+	if !s.db.ReadOnly() {
+		return false
+	}
+	s.displaySafeMessage("This board is a read-only mirror. Changes are disabled.", "error")
+	s.waitForKey()
+	return true
 }
 
 // waitForKey waits for any key press - unified for both SSH and local
 func (s *Session) waitForKey() {
 	// Get terminal height to position prompt safely above status bar
-	_, height, err := s.terminal.Size()
+	_, height, err := s.size()
 	if err != nil {
 		height = 24 // Default height
 	}
@@ -660,25 +976,17 @@ func (s *Session) waitForKey() {
 	// Clear the line and position cursor
 	clearLine := "\033[2K" // Clear entire line
 	promptPosition := fmt.Sprintf("\033[%d;1H", promptLine)
-	prompt := s.colorScheme.Colorize("Press any key to continue...", "text")
-	centeredPrompt := s.colorScheme.CenterText(prompt, 79)
+	prompt := s.colorScheme.Colorize(s.t("Press any key to continue..."), "text")
+	centeredPrompt := s.colorScheme.CenterText(prompt, s.width())
 	s.write([]byte(promptPosition + clearLine + centeredPrompt))
 
-	// For both SSH and local, use the unified terminal interface
-	if sshTerm, ok := s.terminal.(*terminal.SSHTerminal); ok {
-		// SSH session - use ReadLine since it handles enter key nicely
-		sshTerm.ReadLine()
-	} else {
-		// Local session - read single byte
-		buf := make([]byte, 1)
-		s.terminal.Read(buf)
-	}
+	s.readTerminalKey(true)
 }
 
 // displaySafeMessage displays a message positioned safely above the status bar
 func (s *Session) displaySafeMessage(message, colorType string) {
 	// Get terminal height to position message safely above status bar
-	_, height, err := s.terminal.Size()
+	_, height, err := s.size()
 	if err != nil {
 		height = 24 // Default height
 	}
@@ -692,9 +1000,24 @@ func (s *Session) displaySafeMessage(message, colorType string) {
 	// Clear the line and position cursor
 	clearLine := "\033[2K" // Clear entire line
 	messagePosition := fmt.Sprintf("\033[%d;1H", messageLine)
-	coloredMessage := s.colorScheme.Colorize(message, colorType)
-	centeredMessage := s.colorScheme.CenterText(coloredMessage, 79)
+	coloredMessage := s.colorScheme.Colorize(s.t(message), colorType)
+	centeredMessage := s.colorScheme.CenterText(coloredMessage, s.width())
 	s.write([]byte(messagePosition + clearLine + centeredMessage))
+	if colorType == "error" {
+		s.ring(modules.BellError)
+	}
+}
+
+// moderates reports whether the caller moderates the named topic
+func (s *Session) moderates(topic string) bool {
+	return s.config.BBS.Moderates(s.user.Username, topic)
+}
+
+// mail returns the caller's mailbox, able to attach files
+func (s *Session) mail() *messages.Messages {
+	m := messages.NewMessages(s.db, s.colorScheme, s.user.Username)
+	m.SetAttachments(s.config.Paths.Files, s.config.BBS.Files, s.user.AccessLevel)
+	return m
 }
 
 // handleSysopCommand executes sysop commands using the user_editor package
@@ -715,15 +1038,20 @@ func (s *Session) handleSysopCommand(command string) {
 		editor.ListUsers(s.writer, keyReader)
 	case "change_password":
 		editor.ChangePassword(s.writer, keyReader)
+	case "force_password_change":
+		editor.RequirePasswordChange(s.writer, keyReader)
 	case "toggle_user":
 		editor.ToggleUserStatus(s.writer, keyReader)
+	case "pending_registrations":
+		editor.ReviewRegistrations(s.writer, keyReader)
+	case "subscriptions":
+		editor.ManageSubscriptions(s.writer, keyReader)
 	case "system_stats":
 		s.handleSystemStats()
 	case "bulletin_management":
-		s.displaySafeMessage("Bulletin Management - Not yet implemented", "secondary")
-		s.waitForKey()
+		sysop.NewBulletinEditor(s.db, s.colorScheme).Execute(s.writer, keyReader)
 	default:
-		s.displaySafeMessage(fmt.Sprintf("Unknown sysop command: %s", command), "error")
+		s.displaySafeMessage(fmt.Sprintf(s.t("Unknown sysop command: %s"), command), "error")
 		s.waitForKey()
 	}
 }
@@ -732,12 +1060,12 @@ func (s *Session) handleSysopCommand(command string) {
 func (s *Session) handleSystemStats() {
 	s.write([]byte(menu.ClearScreen))
 
-	header := s.colorScheme.Colorize("--- System Statistics ---", "primary")
-	centeredHeader := s.colorScheme.CenterText(header, 79)
+	header := s.colorScheme.Colorize(s.t("--- System Statistics ---"), "primary")
+	centeredHeader := s.colorScheme.CenterText(header, s.width())
 	s.write([]byte(centeredHeader + "\n"))
 
-	separator := s.colorScheme.DrawSeparator(len("System Statistics"), "═")
-	centeredSeparator := s.colorScheme.CenterText(separator, 79)
+	separator := s.colorScheme.DrawSeparator(len("System Statistics"), "")
+	centeredSeparator := s.colorScheme.CenterText(separator, s.width())
 	s.write([]byte(centeredSeparator + "\n\n"))
 
 	// Get users count
@@ -777,7 +1105,7 @@ func (s *Session) handleSystemStats() {
 
 	for _, stat := range stats {
 		coloredStat := s.colorScheme.Colorize(stat, "text")
-		centeredStat := s.colorScheme.CenterText(coloredStat, 79)
+		centeredStat := s.colorScheme.CenterText(coloredStat, s.width())
 		s.write([]byte(centeredStat + "\n"))
 	}
 
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"bbs/internal/config"
+)
+
+// applySSHPolicy sets the negotiated algorithms from the configured policy.
+// Unknown names are an error so a typo can't silently change what is offered.
+func applySSHPolicy(sshConfig *ssh.Config, policy config.SSHPolicy) error {
+	supported := ssh.SupportedAlgorithms()
+	insecure := ssh.InsecureAlgorithms()
+
+	var err error
+	if sshConfig.Ciphers, err = policyList("cipher", policy.Ciphers, supported.Ciphers, insecure.Ciphers, policy.DisableSHA1); err != nil {
+		return err
+	}
+	if sshConfig.KeyExchanges, err = policyList("key exchange", policy.KeyExchanges, supported.KeyExchanges, insecure.KeyExchanges, policy.DisableSHA1); err != nil {
+		return err
+	}
+	if sshConfig.MACs, err = policyList("MAC", policy.MACs, supported.MACs, insecure.MACs, policy.DisableSHA1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// policyList checks configured algorithm names and drops SHA-1 ones when
+// asked. It returns nil, meaning the library defaults, when nothing is
+// configured and nothing needs removing.
+func policyList(kind string, names, supported, insecure []string, disableSHA1 bool) ([]string, error) {
+	if len(names) == 0 {
+		if !disableSHA1 {
+			return nil, nil
+		}
+		names = supported
+	}
+
+	var allowed []string
+	for _, name := range names {
+		if !slices.Contains(supported, name) && !slices.Contains(insecure, name) {
+			return nil, fmt.Errorf("unknown SSH %s %q", kind, name)
+		}
+		if disableSHA1 && usesSHA1(name) {
+			continue
+		}
+		allowed = append(allowed, name)
+	}
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("SSH policy leaves no %s algorithms", kind)
+	}
+	return allowed, nil
+}
+
+// usesSHA1 reports whether an algorithm depends on SHA-1
+func usesSHA1(name string) bool {
+	return strings.Contains(name, "sha1") || name == ssh.KeyAlgoRSA
+}
+
+// policyHostKey checks the host key against the policy and, with SHA-1
+// disabled, stops an RSA key from signing with ssh-rsa
+func policyHostKey(signer ssh.Signer, policy config.SSHPolicy) (ssh.Signer, error) {
+	if cryptoKey, ok := signer.PublicKey().(ssh.CryptoPublicKey); ok {
+		if rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey); ok && policy.MinRSABits > 0 {
+			if bits := rsaKey.N.BitLen(); bits < policy.MinRSABits {
+				return nil, fmt.Errorf("host key is %d-bit RSA but server.ssh.min_rsa_bits is %d; move it aside to generate a new one", bits, policy.MinRSABits)
+			}
+		}
+	}
+
+	if !policy.DisableSHA1 || signer.PublicKey().Type() != ssh.KeyAlgoRSA {
+		return signer, nil
+	}
+	algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+	if !ok {
+		return nil, errors.New("host key cannot choose its signature algorithm")
+	}
+	return ssh.NewSignerWithAlgorithms(algorithmSigner, []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256})
+}
+
+// describePolicyFailure explains a handshake that failed because the client
+// shares no algorithm with the policy, or returns "" for other failures
+func describePolicyFailure(err error) string {
+	var negotiation *ssh.AlgorithmNegotiationError
+	if !errors.As(err, &negotiation) {
+		return ""
+	}
+	return fmt.Sprintf("client refused by SSH policy: no common %s (client offered %s)",
+		negotiation.What, strings.Join(negotiation.RequestedAlgorithms, ","))
+}
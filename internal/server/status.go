@@ -0,0 +1,154 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// statusCommand is the exec command that prints a summary of the board, so
+// a sysop can run "ssh sysop@host status" from a phone
+const statusCommand = "status"
+
+// statusCallers is how many recent calls the status summary lists
+const statusCallers = 5
+
+// execCommand returns the command line of an exec request
+func execCommand(payload []byte) string {
+	var req struct{ Command string }
+	if err := ssh.Unmarshal(payload, &req); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(req.Command)
+}
+
+// runStatus writes the status summary to the channel for a sysop and sends
+// the exit status. Lines end in CRLF when the client asked for a pty.
+func (s *Session) runStatus(channel ssh.Channel, pty bool) {
+	exit := func(code uint32) {
+		channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{code}))
+	}
+
+	user, err := s.db.GetUser(s.prefilledUsername)
+	if err != nil || user.AccessLevel < 255 {
+		s.log.Printf("status refused for %q", s.prefilledUsername)
+		io.WriteString(channel.Stderr(), "Access denied. The status command is for sysops.\n")
+		exit(1)
+		return
+	}
+	s.user = user
+	s.log.Printf("status requested by %s", user.Username)
+
+	summary := s.statusSummary(time.Now())
+	if pty {
+		summary = strings.ReplaceAll(summary, "\n", "\r\n")
+	}
+	io.WriteString(channel, summary)
+	exit(0)
+}
+
+// statusSummary builds the compact board summary: nodes in use, recent
+// callers, accounts waiting for approval, and errors logged in the last day
+func (s *Session) statusSummary(now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s at %s\n", s.config.BBS.SystemName, now.Format("2006-01-02 15:04"))
+
+	nodes := s.whosOnline()
+	if limit := s.config.Server.MaxUsers; limit > 0 {
+		fmt.Fprintf(&b, "\nNodes: %d of %d in use\n", len(nodes), limit)
+	} else {
+		fmt.Fprintf(&b, "\nNodes: %d in use\n", len(nodes))
+	}
+	for _, n := range nodes {
+		name := n.Username
+		if n.Invisible {
+			name += "*"
+		}
+		fmt.Fprintf(&b, " %2d %-12s %s\n", n.Number, name, n.Activity)
+	}
+
+	b.WriteString("\nLast callers:\n")
+	calls, err := s.db.GetRecentCalls(statusCallers, true)
+	if err != nil {
+		fmt.Fprintf(&b, " unavailable: %v\n", err)
+	}
+	for _, c := range calls {
+		name := c.Username
+		if c.Invisible() {
+			name += "*"
+		}
+		fmt.Fprintf(&b, " %s %-12s node %d\n", c.LoginAt.Local().Format("01-02 15:04"), name, c.Node)
+	}
+
+	pending, err := s.db.GetPendingRegistrations()
+	if err != nil {
+		fmt.Fprintf(&b, "\nPending validations: unavailable: %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "\nPending validations: %d\n", len(pending))
+		for _, p := range pending {
+			fmt.Fprintf(&b, " %s (%s)\n", p.User.Username, p.RequestedAt.Local().Format("01-02 15:04"))
+		}
+	}
+
+	count, last, err := recentErrors(s.config.Paths.LogPath("bbs.log"), now.Add(-24*time.Hour))
+	if err != nil {
+		fmt.Fprintf(&b, "\nErrors (24h): unavailable: %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "\nErrors (24h): %d\n", count)
+		if last != "" {
+			fmt.Fprintf(&b, " last: %s\n", last)
+		}
+	}
+
+	return b.String()
+}
+
+// logTimeLayout is the timestamp the standard logger puts on each line
+const logTimeLayout = "2006/01/02 15:04:05"
+
+// recentErrors counts the error lines in the server log since a time and
+// returns the latest. Failed logins and handshakes are what callers and
+// scanners do, not errors, so they aren't counted.
+func recentErrors(path string, since time.Time) (int, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	count := 0
+	last := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) < len(logTimeLayout) {
+			continue
+		}
+		at, err := time.ParseInLocation(logTimeLayout, line[:len(logTimeLayout)], time.Local)
+		if err != nil || at.Before(since) || !isErrorLine(line) {
+			continue
+		}
+		count++
+		last = line
+	}
+	return count, last, scanner.Err()
+}
+
+// isErrorLine reports whether a log line records something going wrong
+func isErrorLine(line string) bool {
+	lower := strings.ToLower(line)
+	if !strings.Contains(lower, "error") && !strings.Contains(lower, "failed") {
+		return false
+	}
+	for _, routine := range []string{"authentication failed", "login failed", "handshake failed"} {
+		if strings.Contains(lower, routine) {
+			return false
+		}
+	}
+	return true
+}
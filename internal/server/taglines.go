@@ -0,0 +1,20 @@
+package server
+
+// tagline returns one of the board's approved taglines at random, or "" if
+// there are none
+func (s *Session) tagline() string {
+	tagline, err := s.db.RandomTagline()
+	if err != nil {
+		s.log.Printf("failed to pick a tagline: %v", err)
+	}
+	return tagline
+}
+
+// postTagline returns the tagline for the foot of a new post or reply, or
+// "" if the board doesn't add them
+func (s *Session) postTagline() string {
+	if !s.config.BBS.Taglines.Posts {
+		return ""
+	}
+	return s.tagline()
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// slowStart holds back the first write on a connection, which is the SSH
+// version banner
+type slowStart struct {
+	net.Conn
+	delay time.Duration
+	once  sync.Once
+}
+
+// Write waits out the delay before the first write
+func (c *slowStart) Write(p []byte) (int, error) {
+	c.once.Do(func() { time.Sleep(c.delay) })
+	return c.Conn.Write(p)
+}
+
+// hostOf returns the IP address part of a remote address
+func hostOf(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// trapped reports whether connections from addr are being tarpitted
+func (s *Server) trapped(addr net.Addr) bool {
+	return s.tarpit != nil && s.tarpit.Trapped(hostOf(addr), time.Now())
+}
+
+// tarpitConn slows the banner for a trapped address and silences its
+// connection log, so scanners cost them time and us nothing
+func (s *Server) tarpitConn(netConn net.Conn, logger *connLogger) net.Conn {
+	if !s.trapped(netConn.RemoteAddr()) {
+		return netConn
+	}
+	logger.quiet.Store(true)
+	return &slowStart{
+		Conn:  netConn,
+		delay: time.Duration(s.cfg().Server.Tarpit.BannerDelaySeconds) * time.Second,
+	}
+}
+
+// tarpitAuth delays the answer to a login attempt from a trapped address
+func (s *Server) tarpitAuth(conn ssh.ConnMetadata) {
+	if s.trapped(conn.RemoteAddr()) {
+		time.Sleep(time.Duration(s.cfg().Server.Tarpit.AuthDelaySeconds) * time.Second)
+	}
+}
+
+// handshakeAborted counts a connection that ended before logging in and
+// notes when that puts its address in the tarpit
+func (s *Server) handshakeAborted(addr net.Addr, logger *connLogger) {
+	if s.tarpit == nil {
+		return
+	}
+	if s.tarpit.Aborted(hostOf(addr), time.Now()) {
+		logger.Printf("tarpitting %s after %d connections ended before login; further attempts are not logged",
+			hostOf(addr), s.cfg().Server.Tarpit.Strikes)
+	}
+}
+
+// handshakeSucceeded releases an address from the tarpit once someone there
+// logs in
+func (s *Server) handshakeSucceeded(addr net.Addr, logger *connLogger) {
+	if s.tarpit == nil {
+		return
+	}
+	s.tarpit.Authenticated(hostOf(addr))
+	if logger.quiet.Swap(false) {
+		logger.Printf("connection from tarpitted address %s logged in; tarpit lifted", logger.remote)
+	}
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"bbs/internal/database"
+	"bbs/internal/modules/timebank"
+)
+
+// timeLimitWarnings are how long before their time runs out callers are
+// warned. The first also starts the logoff countdown on the status bar.
+var timeLimitWarnings = []time.Duration{5 * time.Minute, time.Minute}
+
+// checkTimeLimit works out how long the caller may stay today. Callers who
+// have used up their time are told to call back and refused.
+func (s *Session) checkTimeLimit() bool {
+	s.timeLimit = s.config.BBS.Time.DailyMinutes(s.user.AccessLevel)
+	if s.timeLimit == 0 {
+		return true
+	}
+
+	now := time.Now()
+	used, err := s.db.GetUserTime(s.user.ID, now)
+	if err != nil {
+		// Don't keep callers out because the usage couldn't be read
+		s.log.Printf("time limit: %v", err)
+		used = &database.UserTime{}
+	}
+
+	left := time.Duration(s.timeLimit*60-used.UsedSeconds) * time.Second
+	if left <= 0 {
+		s.log.Printf("login refused for %s: daily time limit used", s.user.Username)
+		msg := fmt.Sprintf(s.t("You have used your %d minutes for today. Please call back tomorrow."), s.timeLimit)
+		s.write([]byte(s.colorScheme.Colorize(msg, "error") + "\n"))
+		return false
+	}
+
+	s.timeMu.Lock()
+	s.timeCounted = now
+	s.timeDeadline = now.Add(left)
+	s.timeMu.Unlock()
+	return true
+}
+
+// watchTimeLimit warns the caller as their time runs out and logs them off
+// when it has, until the session leaves its node
+func (s *Session) watchTimeLimit() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	// Smallest warning given so far, reset when the caller withdraws time
+	var warned time.Duration
+	// Whether the logoff is waiting for an exempt transfer or door to end
+	var deferred bool
+
+	for {
+		select {
+		case now := <-ticker.C:
+			left := s.timeLeft(now)
+			if left <= 0 {
+				if s.exempt().TimeLimit {
+					if !deferred {
+						deferred = true
+						s.log.Printf("time limit reached for %s; logging off when the transfer or door ends", s.user.Username)
+					}
+					continue
+				}
+				s.forceLogoff("daily time limit", "Your time for today is up. Please call back tomorrow.")
+				return
+			}
+
+			// Only the most urgent warning due, so a late login gets one
+			var due time.Duration
+			for _, w := range timeLimitWarnings {
+				if left <= w {
+					due = w
+				}
+			}
+			switch {
+			case due == warned:
+			case due != 0 && (warned == 0 || due < warned):
+				warned = due
+				s.updateCountdown()
+				s.queueOLM(timeLimitWarning(left), "")
+			default:
+				// Time withdrawn from the bank; warn again as it runs out
+				warned = due
+				s.updateCountdown()
+			}
+		case <-s.olmDone:
+			return
+		}
+	}
+}
+
+// timeLimitWarning tells the caller how long they have left
+func timeLimitWarning(left time.Duration) string {
+	minutes := int(math.Ceil(left.Minutes()))
+	if minutes <= 1 {
+		return "You have 1 minute left today. Please finish up."
+	}
+	return fmt.Sprintf("You have %d minutes left today.", minutes)
+}
+
+// timeLeft returns how long the caller may stay on from now
+func (s *Session) timeLeft(now time.Time) time.Duration {
+	s.timeMu.Lock()
+	defer s.timeMu.Unlock()
+	return s.timeDeadline.Sub(now)
+}
+
+// recordTimeUsed adds the time online since it was last recorded to the
+// caller's usage for today
+func (s *Session) recordTimeUsed() {
+	s.timeMu.Lock()
+	defer s.timeMu.Unlock()
+	s.recordTimeUsedLocked(time.Now())
+}
+
+// recordTimeUsedLocked is recordTimeUsed with timeMu held
+func (s *Session) recordTimeUsedLocked(now time.Time) {
+	if s.timeCounted.IsZero() || s.db.ReadOnly() {
+		return
+	}
+	seconds := int(now.Sub(s.timeCounted).Seconds())
+	if seconds <= 0 {
+		return
+	}
+	if err := s.db.AddTimeUsed(s.user.ID, now, seconds); err != nil {
+		s.log.Printf("time limit: %v", err)
+		return
+	}
+	s.timeCounted = s.timeCounted.Add(time.Duration(seconds) * time.Second)
+}
+
+// updateCountdown shows whichever comes first on the status bar: a scheduled
+// event or the end of the caller's time, once they have been warned about it
+func (s *Session) updateCountdown() {
+	if s.statusBar == nil {
+		return
+	}
+
+	s.timeMu.Lock()
+	at := s.eventAt
+	if !s.timeDeadline.IsZero() && time.Until(s.timeDeadline) <= timeLimitWarnings[0] &&
+		(at.IsZero() || s.timeDeadline.Before(at)) {
+		at = s.timeDeadline
+	}
+	s.timeMu.Unlock()
+
+	if at.IsZero() {
+		s.statusBar.SetCountdown("", at)
+		return
+	}
+	s.statusBar.SetCountdown("Logoff", at)
+}
+
+// sessionTimeBank lets the time bank module move the caller's minutes
+type sessionTimeBank struct {
+	session *Session
+}
+
+// Account reports the caller's time left today and their bank balance
+func (b sessionTimeBank) Account() (timebank.Account, error) {
+	s := b.session
+	account := timebank.Account{
+		Limited: s.timeLimit > 0,
+		Left:    s.timeLeft(time.Now()),
+		Max:     s.config.BBS.Time.BankMax,
+	}
+	used, err := s.db.GetUserTime(s.user.ID, time.Now())
+	if err != nil {
+		return account, err
+	}
+	account.Balance = used.BankMinutes
+	return account, nil
+}
+
+// Deposit saves minutes of the caller's time today in the bank
+func (b sessionTimeBank) Deposit(minutes int) error {
+	s := b.session
+	return b.move(func(now time.Time) error {
+		return s.db.DepositTime(s.user.ID, now, minutes, s.timeLimit, s.config.BBS.Time.BankMax)
+	})
+}
+
+// Withdraw adds minutes from the bank to the caller's time today
+func (b sessionTimeBank) Withdraw(minutes int) error {
+	s := b.session
+	return b.move(func(now time.Time) error {
+		return s.db.WithdrawTime(s.user.ID, now, minutes)
+	})
+}
+
+// move records the time used so far, applies a bank transaction, and moves
+// the caller's deadline to match
+func (b sessionTimeBank) move(transaction func(now time.Time) error) error {
+	s := b.session
+	if s.db.ReadOnly() {
+		return database.ErrReadOnly
+	}
+
+	s.timeMu.Lock()
+	now := time.Now()
+	s.recordTimeUsedLocked(now)
+	err := transaction(now)
+	if err == nil {
+		var used *database.UserTime
+		if used, err = s.db.GetUserTime(s.user.ID, now); err == nil {
+			s.timeDeadline = now.Add(time.Duration(s.timeLimit*60-used.UsedSeconds) * time.Second)
+		}
+	}
+	s.timeMu.Unlock()
+
+	switch {
+	case err == nil:
+		s.updateCountdown()
+		return nil
+	case errors.Is(err, database.ErrNotEnoughTime), errors.Is(err, database.ErrTimeBankFull),
+		errors.Is(err, database.ErrTimeBankEmpty), errors.Is(err, database.ErrReadOnly):
+		return err
+	default:
+		s.log.Printf("time bank: %v", err)
+		return errors.New("unable to update the time bank")
+	}
+}
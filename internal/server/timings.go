@@ -0,0 +1,112 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/metrics"
+)
+
+// Metrics returns the board's per-command timings
+func (s *Server) Metrics() *metrics.Registry {
+	return s.metrics
+}
+
+// TraceNode logs everything the caller on node does, with timings, to the
+// server log. Node 0 stops tracing.
+func (s *Server) TraceNode(node int) error {
+	if node != 0 && s.nodes.visible(nil, node) == nil {
+		return fmt.Errorf("nobody is on node %d", node)
+	}
+	s.traceNode.Store(int32(node))
+	if node == 0 {
+		log.Printf("Tracing stopped")
+	} else {
+		log.Printf("Tracing node %d", node)
+	}
+	return nil
+}
+
+// TracedNode returns the node being traced, or 0
+func (s *Server) TracedNode() int {
+	return int(s.traceNode.Load())
+}
+
+// traced reports whether the sysop is tracing this caller's node
+func (s *Session) traced() bool {
+	return s.node != 0 && int32(s.node) == s.server.traceNode.Load()
+}
+
+// executeCommand runs a menu command, counting its run and how long the
+// board spent on it. A command that panics is logged and counted as an
+// error, and ends the caller's session rather than the whole server.
+func (s *Session) executeCommand(item *config.MenuItem) (ok bool) {
+	name := item.Command
+	outer := s.command
+	s.command = name
+	start, waited := time.Now(), s.waited
+	if s.traced() {
+		s.log.Printf("trace: %s started", name)
+	}
+
+	failed := true
+	defer func() {
+		if r := recover(); r != nil {
+			s.log.Printf("command %s panicked: %v\n%s", name, r, debug.Stack())
+			s.write([]byte("\n\n" + s.colorScheme.Colorize(s.t("Sorry, something went wrong. Please call again."), "error") + "\n"))
+			ok = false
+		}
+		s.command = outer
+
+		total := time.Since(start)
+		busy := total - (s.waited - waited)
+		s.server.metrics.Run(name, total, busy, failed)
+		if s.traced() {
+			s.log.Printf("trace: %s finished after %s, board busy %s (failed: %v)", name, total.Round(time.Millisecond), busy.Round(time.Millisecond), failed)
+		}
+	}()
+
+	if name != "goodbye" && name != "logout" && !s.ensureDatabaseAvailable() {
+		return false
+	}
+	// A module that finished after the caller hung up still ends the call
+	ok = s.runCommand(item) && !s.hungUp.Load()
+	failed = false
+	return ok
+}
+
+// startKeyWait notes that the board is waiting for a key, counting how
+// long it took to answer the last one, and returns when waiting began
+func (s *Session) startKeyWait() time.Time {
+	now := time.Now()
+	if s.keyAt.IsZero() {
+		return now
+	}
+
+	name := s.command
+	switch {
+	case s.user == nil:
+		name = metrics.Login
+	case name == "":
+		name = metrics.Menu
+	}
+	answer := now.Sub(s.keyAt)
+	slow := s.server.metrics.Key(name, answer)
+	if s.traced() {
+		mark := ""
+		if slow {
+			mark = " SLOW"
+		}
+		s.log.Printf("trace: %s answered a key in %s%s", name, answer.Round(time.Microsecond), mark)
+	}
+	return now
+}
+
+// endKeyWait notes that a key arrived after waiting since start
+func (s *Session) endKeyWait(start time.Time) {
+	s.keyAt = time.Now()
+	s.waited += s.keyAt.Sub(start)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"bbs/internal/config"
+	"bbs/internal/terminal"
+)
+
+// transferChannel hands the session's raw connection to file transfers
+type transferChannel struct {
+	session *Session
+}
+
+// Transfer runs fn with the raw SSH channel
+func (c *transferChannel) Transfer(fn func(rw io.ReadWriter) error) error {
+	s := c.session
+	if _, ok := s.terminal.(*terminal.SSHTerminal); !ok {
+		return errors.New("file transfers need an SSH connection")
+	}
+
+	s.log.Printf("file transfer started for %s", s.user.Username)
+	err := s.withRawTerminal(s.config.BBS.Exempt.Transfers, fn)
+	if err != nil {
+		s.log.Printf("file transfer for %s failed: %v", s.user.Username, err)
+	}
+	return err
+}
+
+// SetActivity updates what the caller is shown doing in who's online
+func (c *transferChannel) SetActivity(doing string) {
+	c.session.setActivity(doing)
+}
+
+// Transfer lets modules that are only given the session's writer, such as
+// the bulletins and boards, send files
+func (w *TerminalWriter) Transfer(fn func(rw io.ReadWriter) error) error {
+	return (&transferChannel{session: w.session}).Transfer(fn)
+}
+
+// withRawTerminal runs fn with the session's raw terminal. The status bar,
+// online messages and other asynchronous output are held back until it
+// returns so they can't corrupt a transfer or a door's screen, and the idle
+// and time limit cutoffs wait for it as exempt says.
+func (s *Session) withRawTerminal(exempt config.Exemption, fn func(rw io.ReadWriter) error) error {
+	if s.statusBar != nil {
+		s.statusBar.Pause()
+	}
+	// A door or transfer would take the marks around pasted text as typing
+	marked := s.pasteMarks
+	s.markPastes(false)
+	s.exemption.Store(&exempt)
+	s.transferring.Store(true)
+	start := time.Now()
+	defer func() {
+		s.transferring.Store(false)
+		s.exemption.Store(nil)
+		// Time in the transfer or door is the caller's, not the board's
+		s.waited += time.Since(start)
+		s.keyAt = time.Time{}
+		// The idle clock starts again from the end of the transfer or door
+		s.touch()
+		if s.statusBar != nil {
+			s.statusBar.Resume()
+		}
+		s.ensureStatusBar()
+		s.markPastes(marked)
+	}()
+
+	// The door or transfer writes to the terminal itself, after what the
+	// board has already written
+	if err := s.output.Flush(); err != nil {
+		return err
+	}
+	return fn(s.terminal)
+}
+
+// exempt returns the cutoffs the running transfer or door is exempt from
+func (s *Session) exempt() config.Exemption {
+	if e := s.exemption.Load(); e != nil {
+		return *e
+	}
+	return config.Exemption{}
+}
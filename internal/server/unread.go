@@ -0,0 +1,149 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"bbs/internal/database"
+	"bbs/internal/menu"
+	"bbs/internal/modules/boards"
+	"bbs/internal/modules/bulletins"
+)
+
+// unreadActivity is shown for callers reading what the unread key found
+const unreadActivity = "Reading unread messages"
+
+// unreadKeys are the keys a caller may choose to jump to their next unread
+// item, in the order the hotkeys screen offers them
+var unreadKeys = []struct {
+	name  string // As written in config.yaml and user_hotkeys
+	label string
+	key   string // What the terminal sends, "" for none
+}{
+	{"ctrl+n", "Ctrl+N", "\x0e"},
+	{"ctrl+o", "Ctrl+O", "\x0f"},
+	{"ctrl+t", "Ctrl+T", "\x14"},
+	{"ctrl+u", "Ctrl+U", "\x15"},
+	{"off", "Off", ""},
+}
+
+// unreadKeyIndex returns where name is in unreadKeys
+func unreadKeyIndex(name string) (int, bool) {
+	for i, k := range unreadKeys {
+		if k.name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// loadUnreadKey loads the key the caller jumps to unread items with,
+// falling back to the board's unread_key
+func (s *Session) loadUnreadKey() {
+	name := s.config.BBS.UnreadKey
+	if saved, err := s.db.GetUnreadHotkey(s.user.ID); err != nil {
+		s.log.Printf("failed to load hotkeys for %s: %v", s.user.Username, err)
+	} else if saved != "" {
+		name = saved
+	}
+	i, ok := unreadKeyIndex(name)
+	if !ok {
+		s.log.Printf("unknown unread key %q, turning it off", name)
+		i, _ = unreadKeyIndex("off")
+	}
+	s.unreadKey = i
+}
+
+// isUnreadKey reports whether key is the caller's unread key, outside of
+// the jump it starts
+func (s *Session) isUnreadKey(key string) bool {
+	if s.user == nil || s.jumping || s.transferring.Load() {
+		return false
+	}
+	k := unreadKeys[s.unreadKey].key
+	return k != "" && key == k
+}
+
+// jumpToUnread shows the caller the next mail, bulletin or post they
+// haven't read, wherever they are on the board. Reading it moves their
+// last-read pointer, so pressing the key again shows the one after.
+func (s *Session) jumpToUnread() {
+	s.jumping = true
+	doing, _ := s.currentActivity()
+	s.setActivity(unreadActivity)
+	defer func() {
+		s.jumping = false
+		s.setActivity(doing)
+	}()
+
+	next, err := s.db.NextUnread(s.user.ID, s.user.Username, s.user.AccessLevel)
+	if err != nil {
+		s.log.Printf("failed to find unread items for %s: %v", s.user.Username, err)
+		s.unreadNotice("Unread messages are unavailable right now.", "error")
+		return
+	}
+	if next == nil {
+		s.unreadNotice("You have read everything. Nothing new is waiting.", "success")
+		return
+	}
+
+	keyReader := &TerminalKeyReader{session: s}
+	switch next.Kind {
+	case database.UnreadMail:
+		s.mail().Read(s.writer, keyReader, next.ID)
+		s.refreshStatusBar()
+	case database.UnreadBulletin:
+		bulletins.NewModule(s.db, s.colorScheme, s.user.ID).ShowBulletin(s.writer, keyReader, next.ID)
+	case database.UnreadPost:
+		boardsModule := boards.NewModule(s.db, s.colorScheme, s.user.Username, s.user.ID, s.user.AccessLevel)
+		boardsModule.SetTagline(s.postTagline)
+		boardsModule.SetModerator(s.moderates)
+		boardsModule.ShowPost(s.writer, keyReader, next.ID)
+	}
+}
+
+// unreadNotice shows a message on a cleared screen until any key is pressed
+func (s *Session) unreadNotice(message, colorType string) {
+	s.write([]byte(menu.ClearScreen))
+	s.displaySafeMessage(message, colorType)
+	prompt := s.colorScheme.Colorize(s.t("Press any key to continue..."), "text")
+	s.write([]byte("\n\n" + s.colorScheme.CenterText(prompt, s.width())))
+	s.readKey()
+}
+
+// hotkeyOptions lets the caller choose the key that jumps to their next
+// unread item. Changes are saved for later calls unless the board is a
+// read-only mirror.
+func (s *Session) hotkeyOptions() {
+	for {
+		s.write([]byte(menu.ClearScreen))
+		header := s.colorScheme.Colorize(s.t("--- Hotkeys ---"), "primary")
+		s.write([]byte(s.colorScheme.CenterText(header, s.width()) + "\n\n"))
+
+		s.write([]byte(s.colorScheme.Colorize(fmt.Sprintf("%-28s", s.t("N) Next unread:")), "accent") +
+			s.colorScheme.Colorize(unreadKeys[s.unreadKey].label, "text") + "\n\n"))
+		s.write([]byte(s.colorScheme.Colorize(s.t("From any menu or screen, this key shows your oldest unread mail,"), "text") + "\n"))
+		s.write([]byte(s.colorScheme.Colorize(s.t("then new bulletins, then new posts in each topic."), "text") + "\n\n"))
+		s.write([]byte(s.colorScheme.Colorize(s.t("N: Change  Q: Quit"), "secondary") + "\n"))
+
+		key, err := s.readKey()
+		if err != nil {
+			return
+		}
+		switch strings.ToLower(key) {
+		case "n":
+			s.unreadKey = (s.unreadKey + 1) % len(unreadKeys)
+		case "quit", "q", "escape":
+			return
+		default:
+			continue
+		}
+
+		if s.db.ReadOnly() {
+			continue
+		}
+		if err := s.db.SaveUnreadHotkey(s.user.ID, unreadKeys[s.unreadKey].name); err != nil {
+			s.log.Printf("failed to save hotkeys: %v", err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	_ "embed"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"bbs/internal/terminal"
+	"bbs/internal/websocket"
+)
+
+//go:embed web/index.html
+var webPage string
+
+var webTemplate = template.Must(template.New("web").Parse(webPage))
+
+// WebHandler serves the browser gateway: a terminal page at / and the
+// WebSocket it connects to at /ws
+func (s *Server) WebHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleWebPage)
+	mux.HandleFunc("GET /ws", s.handleWebSocket)
+	return mux
+}
+
+// ListenAndServeWeb serves the browser gateway on the configured address
+func (s *Server) ListenAndServeWeb() error {
+	addr := s.cfg().Server.Web.Listen
+	log.Printf("Web gateway listening on %s", addr)
+	return http.ListenAndServe(addr, s.WebHandler())
+}
+
+// handleWebPage serves the terminal page
+func (s *Server) handleWebPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	webTemplate.Execute(w, struct{ Name string }{s.cfg().BBS.SystemName})
+}
+
+// handleWebSocket runs a session over the page's WebSocket. Callers log in
+// at the board's own prompt, throttled and banned by address as SSH logins
+// are.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	// Other sites' pages may not open sessions in a visitor's browser
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			http.Error(w, "cross-origin request refused", http.StatusForbidden)
+			return
+		}
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	addr := conn.RemoteAddr()
+	if s.banned(addr) {
+		return
+	}
+	remote := addr.String()
+	logger := newConnLogger(remote)
+	if !s.registerConn(remote, logger) {
+		logger.Printf("refused web connection from %s: too many connections from that address", remote)
+		return
+	}
+	defer s.unregisterConn(remote)
+
+	logger.Printf("web connection from %s (%s)", remote, r.UserAgent())
+	start := time.Now()
+	defer func() {
+		logger.Printf("connection closed after %s", time.Since(start).Round(time.Second))
+	}()
+
+	webTerm := terminal.NewWebTerminal(conn)
+	session := s.NewSession(webTerm, "")
+	session.log = logger
+	session.remote = addr
+	webTerm.OnResize(session.handleResize)
+	session.Run()
+}
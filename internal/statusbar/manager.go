@@ -2,10 +2,10 @@ package statusbar
 
 import (
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
+	"bbs/internal/ansi"
 	"bbs/internal/config"
 )
 
@@ -18,6 +18,7 @@ type Manager struct {
 	stopChan       chan bool
 	isInitialized  bool
 	paused         bool
+	drawnRight     int // Width of the right section last drawn by the ticker
 }
 
 // NewManager creates a new status bar manager
@@ -43,27 +44,27 @@ func (m *Manager) Start(updateInterval time.Duration) <-chan string {
 		// Send initial fixed setup ONLY
 		if !m.isInitialized {
 			statusBar := m.statusBar.InitializeFixed(m.terminalHeight)
+			m.drawnRight = ansi.Width(m.statusBar.RightText())
 			m.isInitialized = true
 			updateChan <- statusBar
 		}
 
-		// Start timer updates for just the timer portion
+		// Start updates for just the right section
 		m.updateTicker = time.NewTicker(updateInterval)
 		defer m.updateTicker.Stop()
 
 		for {
 			select {
 			case <-m.updateTicker.C:
-				// Only update the timer portion to avoid flicker
-				m.mu.RLock()
-				isPaused := m.paused
-				m.mu.RUnlock()
-
-				if !isPaused {
-					timerUpdate := m.getTimerUpdate()
-					if timerUpdate != "" {
-						updateChan <- timerUpdate
-					}
+				// Only update the right section to avoid flicker
+				m.mu.Lock()
+				var update string
+				if !m.paused {
+					update = m.getSegmentsUpdate()
+				}
+				m.mu.Unlock()
+				if update != "" {
+					updateChan <- update
 				}
 			case <-m.stopChan:
 				return
@@ -116,6 +117,62 @@ func (m *Manager) SetTerminalHeight(height int) {
 	m.terminalHeight = height
 }
 
+// Resize adapts the status bar to a new terminal size. When the bar is
+// already on screen it returns the escape sequence that moves the scroll
+// region and redraws the bar on the new bottom line.
+func (m *Manager) Resize(width, height int) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.terminalHeight = height
+	m.statusBar.SetWidth(width)
+
+	if !m.isInitialized {
+		return ""
+	}
+	return "\033[s" + m.statusBar.InitializeFixed(height) + "\033[u"
+}
+
+// SetCountdown shows a countdown to at in place of the system name, or
+// removes it when label is empty. The bar picks it up on the next tick.
+func (m *Manager) SetCountdown(label string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statusBar.SetCountdown(label, at)
+}
+
+// SetNodes shows how many callers are online. The bar picks it up on the
+// next tick.
+func (m *Manager) SetNodes(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statusBar.SetNodes(n)
+}
+
+// SetUnread shows how much unread mail the caller has
+func (m *Manager) SetUnread(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statusBar.SetUnread(n)
+}
+
+// SetDeadline shows the caller's time left until at, or removes it for a
+// zero time
+func (m *Manager) SetDeadline(at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statusBar.SetDeadline(at)
+}
+
+// SetLegend shows a second line under the bar, on the row below the
+// terminal height the manager was given, or removes it when legend is empty.
+// It is drawn with the bar from the next full redraw.
+func (m *Manager) SetLegend(legend string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statusBar.SetLegend(legend)
+}
+
 // SetActive enables or disables the status bar
 func (m *Manager) SetActive(active bool) {
 	m.mu.Lock()
@@ -159,53 +216,36 @@ func (m *Manager) RenderContent() string {
 	return m.statusBar.Render()
 }
 
-// getTimerUpdate returns just a timer update without repositioning the entire status bar
-func (m *Manager) getTimerUpdate() string {
+// getSegmentsUpdate returns an update of just the right section: the node
+// count, unread mail, time left and call timer. When the section has changed
+// width, or a countdown is changing the center, the whole bar is redrawn.
+func (m *Manager) getSegmentsUpdate() string {
 	if !m.isInitialized {
 		return ""
 	}
 
-	// Get the current timer string
-	durationStr := m.statusBar.GetTimerString()
-
-	// Calculate timer position using the same logic as the full render
-	// to ensure consistency and prevent column shifts
-	leftSection := fmt.Sprintf(" %s", m.statusBar.GetUsername())
-	rightSection := fmt.Sprintf("%s ", durationStr)
-	centerSection := m.statusBar.GetSystemName()
-
-	// Calculate padding for center alignment (same as Render method)
-	usedSpace := len(leftSection) + len(rightSection) + len(centerSection)
-	width := m.statusBar.GetWidth()
-	if usedSpace >= width {
-		// Truncate if too long (same logic as Render method)
-		centerSection = m.statusBar.TruncateString(centerSection, width-len(leftSection)-len(rightSection)-2)
-		usedSpace = len(leftSection) + len(rightSection) + len(centerSection)
+	leftSection, centerSection, rightSection, leftPadding, rightPadding := m.statusBar.layout()
+	if m.statusBar.HasCountdown() || ansi.Width(rightSection) != m.drawnRight {
+		m.drawnRight = ansi.Width(rightSection)
+		return m.renderStatusBar()
 	}
 
-	totalPadding := width - usedSpace
-	rightPadding := totalPadding - (totalPadding / 2) // Right padding calculation
-	
-	// Timer starts after: leftSection + leftPadding + centerSection + rightPadding
-	timerStartCol := len(leftSection) + (totalPadding / 2) + len(centerSection) + rightPadding + 1
+	// The right section starts after the left, its padding, the center and
+	// its padding, as Render lays them out
+	startCol := ansi.Width(leftSection) + leftPadding + ansi.Width(centerSection) + rightPadding + 1
+	positionCode := fmt.Sprintf("\033[%d;%dH", m.terminalHeight, startCol)
 
-	positionCode := fmt.Sprintf("\033[%d;%dH", m.terminalHeight, timerStartCol)
-
-	// ANSI color codes for timer (bright yellow with blue background to match status bar)
+	// ANSI color codes (bright yellow with blue background to match status bar)
 	blue := "\033[44m"
 	brightYellow := "\033[93m"
 	reset := "\033[0m"
 
-	// Clear the timer area by writing spaces, then reposition and write new timer
-	clearSpaces := strings.Repeat(" ", len(rightSection))
-	repositionCode := fmt.Sprintf("\033[%d;%dH", m.terminalHeight, timerStartCol)
-
-	// Just update the timer, don't move cursor afterward
+	// Just update the section, don't move cursor afterward
 	// The scroll region protects the status bar, and cursor is hidden anyway
-	return fmt.Sprintf("%s%s%s%s%s%s%s%s",
-		positionCode, blue, clearSpaces,
-		repositionCode, blue, brightYellow, rightSection, reset)
-} // renderStatusBar generates the positioned status bar
+	return fmt.Sprintf("%s%s%s%s%s", positionCode, blue, brightYellow, rightSection, reset)
+}
+
+// renderStatusBar generates the positioned status bar
 func (m *Manager) renderStatusBar() string {
 	positionCode := m.statusBar.GetPositionCode(m.terminalHeight)
 	statusBarContent := m.statusBar.Render()
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"bbs/internal/ansi"
 	"bbs/internal/config"
 )
 
@@ -17,6 +18,21 @@ type StatusBar struct {
 	height        int
 	isActive      bool
 	isInitialized bool
+
+	// An optional countdown replaces the system name, e.g. before a scheduled event
+	countdownLabel string
+	countdownAt    time.Time
+
+	// Optional segments shown before the call timer once they are set
+	nodes     int       // Callers online
+	unread    int       // Unread mail in the caller's mailbox
+	deadline  time.Time // When the caller's time today runs out
+	hasNodes  bool
+	hasUnread bool
+
+	// An optional second line under the bar, e.g. the console's sysop keys.
+	// It sits on the row below the one the bar is given.
+	legend string
 }
 
 // New creates a new status bar instance
@@ -37,9 +53,6 @@ func (sb *StatusBar) Render() string {
 		return ""
 	}
 
-	duration := time.Since(sb.startTime)
-	durationStr := formatDuration(duration)
-
 	// ANSI color codes
 	const (
 		blue         = "\033[44m" // Blue background
@@ -50,22 +63,7 @@ func (sb *StatusBar) Render() string {
 		clearLine    = "\033[2K"  // Clear entire line
 	)
 
-	// Calculate available space for each section
-	leftSection := fmt.Sprintf(" %s", sb.username)
-	rightSection := fmt.Sprintf("%s ", durationStr)
-	centerSection := sb.systemName
-
-	// Calculate padding for center alignment
-	usedSpace := len(leftSection) + len(rightSection) + len(centerSection)
-	if usedSpace >= sb.width {
-		// Truncate if too long
-		centerSection = truncateString(centerSection, sb.width-len(leftSection)-len(rightSection)-2)
-		usedSpace = len(leftSection) + len(rightSection) + len(centerSection)
-	}
-
-	totalPadding := sb.width - usedSpace
-	leftPadding := totalPadding / 2
-	rightPadding := totalPadding - leftPadding
+	leftSection, centerSection, rightSection, leftPadding, rightPadding := sb.layout()
 
 	// Build the status bar
 	statusBar := fmt.Sprintf("%s%s%s%s%s%s%s%s%s%s%s",
@@ -75,13 +73,42 @@ func (sb *StatusBar) Render() string {
 		strings.Repeat(" ", leftPadding), // Left padding
 		brightGreen, centerSection,       // Bright green system name in center
 		strings.Repeat(" ", rightPadding), // Right padding
-		brightYellow, rightSection,        // Bright yellow duration on right
+		brightYellow, rightSection,        // Bright yellow segments and duration on right
 		reset, // Reset formatting
 	)
 
+	// The legend goes on the next row, below the scroll region
+	if sb.legend != "" {
+		legend := " " + ansi.Ellipsize(sb.legend, sb.width-1)
+		statusBar += fmt.Sprintf("\033[B\r%s%s%s%s%s%s",
+			clearLine, blue, white, legend,
+			strings.Repeat(" ", sb.width-ansi.Width(legend)), reset)
+	}
+
 	return statusBar
 }
 
+// layout splits the bar into its left, center and right sections and the
+// padding either side of the center one
+func (sb *StatusBar) layout() (left, center, right string, leftPadding, rightPadding int) {
+	left = fmt.Sprintf(" %s", sb.username)
+	right = sb.RightText()
+	center = sb.CenterText()
+
+	// Calculate padding for center alignment
+	usedSpace := ansi.Width(left) + ansi.Width(right) + ansi.Width(center)
+	if usedSpace >= sb.width {
+		// Truncate if too long
+		center = ansi.Ellipsize(center, sb.width-ansi.Width(left)-ansi.Width(right)-2)
+		usedSpace = ansi.Width(left) + ansi.Width(right) + ansi.Width(center)
+	}
+
+	totalPadding := sb.width - usedSpace
+	leftPadding = totalPadding / 2
+	rightPadding = totalPadding - leftPadding
+	return left, center, right, leftPadding, rightPadding
+}
+
 // InitializeFixed sets up the status bar with scroll region protection
 func (sb *StatusBar) InitializeFixed(terminalHeight int) string {
 	sb.height = terminalHeight
@@ -119,6 +146,13 @@ func (sb *StatusBar) Update() string {
 	return sb.Render()
 }
 
+// SetWidth sets the number of columns the status bar spans
+func (sb *StatusBar) SetWidth(width int) {
+	if width > 0 {
+		sb.width = width
+	}
+}
+
 // SetActive enables or disables the status bar
 func (sb *StatusBar) SetActive(active bool) {
 	sb.isActive = active
@@ -144,6 +178,75 @@ func (sb *StatusBar) GetSystemName() string {
 	return sb.systemName
 }
 
+// SetCountdown shows "label in MM:SS" counting down to at in place of the
+// system name. An empty label removes the countdown.
+func (sb *StatusBar) SetCountdown(label string, at time.Time) {
+	sb.countdownLabel = label
+	sb.countdownAt = at
+}
+
+// HasCountdown reports whether a countdown is being shown
+func (sb *StatusBar) HasCountdown() bool {
+	return sb.countdownLabel != ""
+}
+
+// CenterText returns the center section: the countdown if one is set,
+// otherwise the system name
+func (sb *StatusBar) CenterText() string {
+	if sb.countdownLabel == "" {
+		return sb.systemName
+	}
+
+	remaining := time.Until(sb.countdownAt)
+	if remaining < 0 {
+		remaining = 0
+	}
+	minutes := int(remaining.Minutes())
+	seconds := int(remaining.Seconds()) % 60
+	return fmt.Sprintf("%s in %02d:%02d", sb.countdownLabel, minutes, seconds)
+}
+
+// SetNodes shows how many callers are online
+func (sb *StatusBar) SetNodes(n int) {
+	sb.nodes = n
+	sb.hasNodes = true
+}
+
+// SetUnread shows how much unread mail the caller has
+func (sb *StatusBar) SetUnread(n int) {
+	sb.unread = n
+	sb.hasUnread = true
+}
+
+// SetDeadline shows the caller's time left until at. A zero time, for
+// callers with no daily limit, removes it.
+func (sb *StatusBar) SetDeadline(at time.Time) {
+	sb.deadline = at
+}
+
+// SetLegend shows a second line of text under the bar, or removes it when
+// legend is empty. The terminal needs a row for it below the bar's row.
+func (sb *StatusBar) SetLegend(legend string) {
+	sb.legend = legend
+}
+
+// RightText returns the right section: the node count, unread mail and time
+// left, where set, then the call timer
+func (sb *StatusBar) RightText() string {
+	var segments []string
+	if sb.hasNodes {
+		segments = append(segments, fmt.Sprintf("Nodes:%d", sb.nodes))
+	}
+	if sb.hasUnread {
+		segments = append(segments, fmt.Sprintf("Mail:%d", sb.unread))
+	}
+	if !sb.deadline.IsZero() {
+		segments = append(segments, "Left:"+formatTimeLeft(time.Until(sb.deadline)))
+	}
+	segments = append(segments, sb.GetTimerString())
+	return strings.Join(segments, "  ") + " "
+}
+
 // GetTimerString returns just the formatted timer string
 func (sb *StatusBar) GetTimerString() string {
 	duration := time.Since(sb.startTime)
@@ -152,7 +255,7 @@ func (sb *StatusBar) GetTimerString() string {
 
 // TruncateString truncates a string to the specified length
 func (sb *StatusBar) TruncateString(s string, maxLen int) string {
-	return truncateString(s, maxLen)
+	return ansi.Ellipsize(s, maxLen)
 }
 
 // Clear returns ANSI codes to clear the status bar area
@@ -162,10 +265,17 @@ func (sb *StatusBar) Clear(terminalHeight int) string {
 	}
 
 	// Reset scroll region to full screen
-	resetScroll := fmt.Sprintf("\033[1;%dr", terminalHeight)
+	bottom := terminalHeight
+	if sb.legend != "" {
+		bottom++
+	}
+	resetScroll := fmt.Sprintf("\033[1;%dr", bottom)
 
-	// Clear status bar line
+	// Clear status bar line, and the legend under it
 	clearStatus := fmt.Sprintf("\033[%d;1H\033[2K", terminalHeight)
+	if sb.legend != "" {
+		clearStatus += fmt.Sprintf("\033[%d;1H\033[2K", bottom)
+	}
 
 	sb.isInitialized = false
 	return resetScroll + clearStatus
@@ -179,13 +289,12 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
 }
 
-// truncateString truncates a string to the specified length
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	if maxLen <= 3 {
-		return s[:maxLen]
+// formatTimeLeft formats the time a caller has left as H:MM, rounding up
+// so it doesn't read 0:00 while there is still time
+func formatTimeLeft(d time.Duration) string {
+	if d < 0 {
+		d = 0
 	}
-	return s[:maxLen-3] + "..."
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
 }
@@ -1,6 +1,7 @@
 package statusbar
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -107,10 +108,150 @@ func TestTruncateString(t *testing.T) {
 		{"ab", 1, "a"},
 	}
 
+	sb := &StatusBar{}
 	for _, test := range tests {
-		result := truncateString(test.input, test.maxLen)
+		result := sb.TruncateString(test.input, test.maxLen)
 		if result != test.expected {
-			t.Errorf("truncateString(%s, %d) = %s, expected %s", test.input, test.maxLen, result, test.expected)
+			t.Errorf("TruncateString(%s, %d) = %s, expected %s", test.input, test.maxLen, result, test.expected)
 		}
 	}
 }
+
+func TestManager_Resize(t *testing.T) {
+	cfg := &config.Config{
+		BBS: config.BBSConfig{
+			SystemName:    "Test BBS",
+			MaxLineLength: 79,
+		},
+	}
+
+	m := NewManager("testuser", cfg, 24)
+
+	// Nothing to redraw before the bar has been initialized
+	if out := m.Resize(131, 50); out != "" {
+		t.Errorf("Resize before start should return nothing, got %q", out)
+	}
+
+	if m.statusBar.width != 131 {
+		t.Errorf("Expected width 131, got %d", m.statusBar.width)
+	}
+
+	m.isInitialized = true
+	out := m.Resize(99, 40)
+	if !strings.Contains(out, "\033[1;39r") {
+		t.Error("Resize should move the scroll region above the new bottom line")
+	}
+	if !strings.Contains(out, "\033[40;1H") {
+		t.Error("Resize should draw the status bar on the new bottom line")
+	}
+}
+
+func TestStatusBar_Countdown(t *testing.T) {
+	cfg := &config.Config{
+		BBS: config.BBSConfig{
+			SystemName:    "Test BBS",
+			MaxLineLength: 79,
+		},
+	}
+
+	sb := New("testuser", cfg)
+	sb.SetCountdown("Maintenance", time.Now().Add(5*time.Minute+30*time.Second))
+
+	if !strings.Contains(sb.Render(), "Maintenance in 05:") {
+		t.Errorf("Expected countdown in status bar, got %q", sb.Render())
+	}
+	if strings.Contains(sb.Render(), "Test BBS") {
+		t.Error("Countdown should replace the system name")
+	}
+
+	sb.SetCountdown("", time.Time{})
+	if !strings.Contains(sb.Render(), "Test BBS") {
+		t.Error("Clearing the countdown should restore the system name")
+	}
+}
+
+func TestStatusBar_Segments(t *testing.T) {
+	cfg := &config.Config{
+		BBS: config.BBSConfig{
+			SystemName:    "Test BBS",
+			MaxLineLength: 79,
+		},
+	}
+
+	sb := New("testuser", cfg)
+	if right := sb.RightText(); right != "00:00:00 " {
+		t.Errorf("Expected only the timer before segments are set, got %q", right)
+	}
+
+	sb.SetNodes(3)
+	sb.SetUnread(0)
+	sb.SetDeadline(time.Now().Add(90*time.Minute + 30*time.Second))
+	if right := sb.RightText(); right != "Nodes:3  Mail:0  Left:1:31  00:00:00 " {
+		t.Errorf("Unexpected right section %q", right)
+	}
+
+	sb.SetDeadline(time.Time{})
+	if strings.Contains(sb.RightText(), "Left:") {
+		t.Error("Clearing the deadline should remove the time left")
+	}
+}
+
+func TestManager_SegmentsUpdate(t *testing.T) {
+	cfg := &config.Config{
+		BBS: config.BBSConfig{
+			SystemName:    "Test BBS",
+			MaxLineLength: 79,
+		},
+	}
+
+	m := NewManager("testuser", cfg, 24)
+	m.SetNodes(2)
+	m.SetUnread(5)
+	m.isInitialized = true
+	m.drawnRight = len(m.statusBar.RightText())
+
+	// Same width: only the right section is rewritten, ending at the last column
+	right := m.statusBar.RightText()
+	out := m.getSegmentsUpdate()
+	if want := fmt.Sprintf("\033[24;%dH", 79-len(right)+1); !strings.HasPrefix(out, want) {
+		t.Errorf("Expected the update to start at %q, got %q", want, out)
+	}
+	if strings.Contains(out, "Test BBS") || !strings.Contains(out, "Mail:5") {
+		t.Errorf("Expected just the right section, got %q", out)
+	}
+
+	// A wider section moves the center, so the whole bar is redrawn
+	m.SetUnread(12)
+	if out := m.getSegmentsUpdate(); !strings.Contains(out, "Test BBS") || !strings.Contains(out, "Mail:12") {
+		t.Errorf("Expected a full redraw, got %q", out)
+	}
+	if out := m.getSegmentsUpdate(); strings.Contains(out, "Test BBS") {
+		t.Errorf("Expected a partial update once redrawn, got %q", out)
+	}
+}
+
+func TestStatusBar_Legend(t *testing.T) {
+	cfg := &config.Config{
+		BBS: config.BBSConfig{
+			SystemName:    "Test BBS",
+			MaxLineLength: 40,
+		},
+	}
+
+	sb := New("testuser", cfg)
+	sb.SetLegend("Alt-T: +5 min  Alt-H: Hang up")
+	if !strings.Contains(sb.Render(), "\033[B\r\033[2K\033[44m\033[37m Alt-T: +5 min  Alt-H: Hang up") {
+		t.Errorf("Expected the legend on the next row, got %q", sb.Render())
+	}
+
+	sb.InitializeFixed(23)
+	clear := sb.Clear(23)
+	if !strings.Contains(clear, "\033[1;24r") || !strings.Contains(clear, "\033[24;1H\033[2K") {
+		t.Errorf("Expected Clear to free and blank the legend row, got %q", clear)
+	}
+
+	sb.SetLegend("")
+	if strings.Contains(sb.Render(), "\033[B") {
+		t.Error("Removing the legend should leave one line")
+	}
+}
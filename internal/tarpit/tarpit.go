@@ -0,0 +1,88 @@
+package tarpit
+
+import (
+	"sync"
+	"time"
+)
+
+// Tracker counts connections from each address that end before logging in.
+// An address with too many of them in the window is trapped, and stays
+// trapped until it goes a full window without another one or logs in.
+type Tracker struct {
+	mu        sync.Mutex
+	strikes   int
+	window    time.Duration
+	hosts     map[string]*host
+	lastSweep time.Time
+}
+
+// host is the recent history of one address
+type host struct {
+	count int       // Aborted connections since first
+	first time.Time // Start of the current count
+	last  time.Time // Most recent aborted connection
+}
+
+// New creates a tracker that traps an address after strikes aborted
+// connections within window
+func New(strikes int, window time.Duration) *Tracker {
+	return &Tracker{
+		strikes: strikes,
+		window:  window,
+		hosts:   make(map[string]*host),
+	}
+}
+
+// Trapped reports whether connections from addr should be slowed down
+func (t *Tracker) Trapped(addr string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.hosts[addr]
+	return ok && h.count >= t.strikes && now.Sub(h.last) < t.window
+}
+
+// Aborted records a connection from addr that ended before logging in. It
+// returns true when this connection is the one that trapped the address.
+func (t *Tracker) Aborted(addr string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(now)
+
+	h, ok := t.hosts[addr]
+	if !ok {
+		h = &host{first: now}
+		t.hosts[addr] = h
+	}
+
+	// A trapped address keeps its count; otherwise old strikes expire
+	if h.count < t.strikes && now.Sub(h.first) >= t.window {
+		h.count = 0
+		h.first = now
+	}
+	h.count++
+	h.last = now
+	return h.count == t.strikes
+}
+
+// Authenticated forgets addr after someone there logs in
+func (t *Tracker) Authenticated(addr string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.hosts, addr)
+}
+
+// sweep drops addresses that have been quiet for a whole window. It runs at
+// most once per window so a flood of addresses can't make it quadratic.
+func (t *Tracker) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < t.window {
+		return
+	}
+	t.lastSweep = now
+	for addr, h := range t.hosts {
+		if now.Sub(h.last) >= t.window {
+			delete(t.hosts, addr)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package tarpit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrapsAfterStrikes(t *testing.T) {
+	tracker := New(3, 10*time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 1; i <= 3; i++ {
+		if tracker.Trapped("192.0.2.1", now) {
+			t.Fatalf("trapped after %d strikes", i-1)
+		}
+		trapped := tracker.Aborted("192.0.2.1", now)
+		if trapped != (i == 3) {
+			t.Errorf("Aborted #%d returned %v", i, trapped)
+		}
+		now = now.Add(time.Minute)
+	}
+	if !tracker.Trapped("192.0.2.1", now) {
+		t.Fatal("not trapped after 3 strikes")
+	}
+	if tracker.Trapped("192.0.2.2", now) {
+		t.Error("unrelated address trapped")
+	}
+
+	// Further strikes keep it trapped but don't report it again
+	if tracker.Aborted("192.0.2.1", now) {
+		t.Error("fourth strike reported as newly trapped")
+	}
+
+	// A quiet window releases it
+	if tracker.Trapped("192.0.2.1", now.Add(10*time.Minute)) {
+		t.Error("still trapped after a quiet window")
+	}
+}
+
+func TestStrikesExpire(t *testing.T) {
+	tracker := New(3, 10*time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker.Aborted("192.0.2.1", now)
+	tracker.Aborted("192.0.2.1", now.Add(time.Minute))
+	if tracker.Aborted("192.0.2.1", now.Add(11*time.Minute)) {
+		t.Error("strikes from an earlier window counted")
+	}
+	if tracker.Trapped("192.0.2.1", now.Add(11*time.Minute)) {
+		t.Error("trapped by strikes spread over two windows")
+	}
+}
+
+func TestAuthenticatedForgets(t *testing.T) {
+	tracker := New(2, 10*time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker.Aborted("192.0.2.1", now)
+	tracker.Aborted("192.0.2.1", now)
+	tracker.Authenticated("192.0.2.1")
+	if tracker.Trapped("192.0.2.1", now) {
+		t.Error("still trapped after logging in")
+	}
+}
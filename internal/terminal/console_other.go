@@ -0,0 +1,13 @@
+//go:build !windows
+
+package terminal
+
+import "os"
+
+// console is a no-op outside Windows; term.MakeRaw already configures
+// Unix terminals correctly.
+type console struct{}
+
+func (c *console) enable(stdout *os.File) error  { return nil }
+func (c *console) restore(stdout *os.File) error { return nil }
+func (c *console) filterInput(p []byte) int      { return len(p) }
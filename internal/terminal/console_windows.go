@@ -0,0 +1,66 @@
+//go:build windows
+
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// console holds the Windows console output mode changed while the BBS runs,
+// so it can be put back when the terminal is closed.
+type console struct {
+	outMode  uint32
+	modified bool
+	lastCR   bool
+}
+
+// enable turns on ANSI escape processing for console output. Without it
+// Windows consoles print color and cursor sequences literally. Raw input
+// (including VT key sequences for arrows) is handled by term.MakeRaw.
+func (c *console) enable(stdout *os.File) error {
+	if c.modified {
+		return nil
+	}
+
+	out := windows.Handle(stdout.Fd())
+	if err := windows.GetConsoleMode(out, &c.outMode); err != nil {
+		return nil // Output is redirected, nothing to configure
+	}
+
+	mode := c.outMode | windows.ENABLE_PROCESSED_OUTPUT | windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING |
+		windows.DISABLE_NEWLINE_AUTO_RETURN
+	if err := windows.SetConsoleMode(out, mode); err != nil {
+		return err
+	}
+
+	c.modified = true
+	return nil
+}
+
+// restore puts back the console output mode saved by enable
+func (c *console) restore(stdout *os.File) error {
+	if !c.modified {
+		return nil
+	}
+	c.modified = false
+	return windows.SetConsoleMode(windows.Handle(stdout.Fd()), c.outMode)
+}
+
+// filterInput collapses the CR LF pair some Windows terminals send for Enter
+// into a single CR, so one key press is not read as two. It returns the new
+// length of p.
+func (c *console) filterInput(p []byte) int {
+	n := 0
+	for _, b := range p {
+		if b == '\n' && c.lastCR {
+			c.lastCR = false
+			continue
+		}
+		c.lastCR = b == '\r'
+		p[n] = b
+		n++
+	}
+	return n
+}
@@ -0,0 +1,71 @@
+package terminal
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Character sets a caller's terminal can display
+const (
+	UTF8  = "utf-8"
+	CP437 = "cp437" // The IBM PC character set classic BBS clients draw with
+)
+
+// cp437Terms are TERM names sent by clients that draw with CP437
+var cp437Terms = []string{"syncterm", "ansi-bbs", "pcansi", "pc-ansi", "scoansi", "ansi"}
+
+// DetectEncoding guesses the character set of a client from the TERM it
+// sent in its pty-req. Modern clients send xterm or similar and get UTF-8.
+func DetectEncoding(termType string) string {
+	termType = strings.ToLower(termType)
+	for _, t := range cp437Terms {
+		if termType == t || strings.HasPrefix(termType, t+"-") {
+			return CP437
+		}
+	}
+	return UTF8
+}
+
+// cp437Fallback spells out characters CP437 lacks that the board's own
+// screens use
+var cp437Fallback = map[rune]string{
+	'↑': "^", '↓': "v", '←': "<", '→': ">",
+	'‘': "'", '’': "'", '“': "\"", '”': "\"",
+	'–': "-", '—': "-", '…': "...", '•': "\xf9", '✓': "\xfb",
+}
+
+// ToCP437 converts UTF-8 text to CP437. ASCII, and so every escape
+// sequence, passes through unchanged. Characters CP437 has no glyph for
+// become their nearest ASCII spelling, or "?".
+func ToCP437(p []byte) []byte {
+	i := 0
+	for i < len(p) && p[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(p) {
+		return p
+	}
+
+	out := make([]byte, i, len(p))
+	copy(out, p[:i])
+	for i < len(p) {
+		r, size := utf8.DecodeRune(p[i:])
+		i += size
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+			continue
+		}
+		// Glyphs CP437 keeps in its control range are left out, since the
+		// client would act on the control code instead of drawing them
+		if b, ok := charmap.CodePage437.EncodeRune(r); ok && b >= 0x80 {
+			out = append(out, b)
+		} else if s, ok := cp437Fallback[r]; ok {
+			out = append(out, s...)
+		} else {
+			out = append(out, '?')
+		}
+	}
+	return out
+}
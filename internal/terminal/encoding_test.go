@@ -0,0 +1,33 @@
+package terminal
+
+import "testing"
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct{ term, want string }{
+		{"xterm-256color", UTF8},
+		{"", UTF8},
+		{"SyncTERM", CP437},
+		{"ansi", CP437},
+		{"ansi-bbs", CP437},
+		{"ansiterm", UTF8},
+	}
+	for _, tt := range tests {
+		if got := DetectEncoding(tt.term); got != tt.want {
+			t.Errorf("DetectEncoding(%q) = %q, want %q", tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestToCP437(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"\x1b[1;34mplain\r\n", "\x1b[1;34mplain\r\n"},
+		{"╔═╗ ░▒▓ é", "\xc9\xcd\xbb \xb0\xb1\xb2 \x82"},
+		{"U → undo…", "U > undo..."},
+		{"♥ ✗", "? ?"},
+	}
+	for _, tt := range tests {
+		if got := string(ToCP437([]byte(tt.in))); got != tt.want {
+			t.Errorf("ToCP437(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package terminal
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// ttyOpEcho is the ECHO opcode in the terminal modes of a pty-req
+// (RFC 4254 §8)
+const ttyOpEcho = 53
+
+// ptyEcho reports the ECHO setting in the encoded terminal modes of a
+// pty-req, and whether the client sent one
+func ptyEcho(modes string) (echo bool, ok bool) {
+	for i := 0; i < len(modes); i += 5 {
+		op := modes[i]
+		// 0 ends the list; opcodes from 160 up are undefined, so their
+		// argument size is unknown and nothing after them can be read
+		if op == 0 || op >= 160 || i+5 > len(modes) {
+			break
+		}
+		if op == ttyOpEcho {
+			echo = binary.BigEndian.Uint32([]byte(modes[i+1:i+5])) != 0
+			ok = true
+		}
+	}
+	return echo, ok
+}
+
+// newlineFilter joins the two bytes some clients send for Enter (CR LF,
+// LF CR, or CR NUL) into one, so a single key press isn't read as two. It
+// also remembers what the client's Enter key sends.
+type newlineFilter struct {
+	last  byte   // CR or LF that may start a pair, 0 if none
+	enter string // What Enter sent most recently, e.g. "CR LF"
+}
+
+// filter removes the second byte of each pair from p in place and returns
+// the new length
+func (f *newlineFilter) filter(p []byte) int {
+	n := 0
+	for _, b := range p {
+		if f.last != 0 {
+			if pair := newlinePair(f.last, b); pair != "" {
+				f.enter = pair
+				f.last = 0
+				continue
+			}
+		}
+		f.last = 0
+		switch b {
+		case '\r':
+			f.last = b
+			f.enter = "CR"
+		case '\n':
+			f.last = b
+			f.enter = "LF"
+		}
+		p[n] = b
+		n++
+	}
+	return n
+}
+
+// newlinePair names the pair a and b make for Enter, or returns "" if they
+// aren't one
+func newlinePair(a, b byte) string {
+	switch {
+	case a == '\r' && b == '\n':
+		return "CR LF"
+	case a == '\n' && b == '\r':
+		return "LF CR"
+	case a == '\r' && b == 0:
+		return "CR NUL"
+	}
+	return ""
+}
+
+// maxTyped bounds how many unechoed keys the echo filter remembers
+const maxTyped = 16
+
+// echoFilter drops the board's echo of what the caller typed, for clients
+// that already echo it themselves. Keys read since the last write are
+// compared with the next writes; the first write that isn't their echo
+// clears them, so ordinary output is never held back.
+type echoFilter struct {
+	mu    sync.Mutex
+	typed []byte
+}
+
+// read remembers keys the caller typed
+func (f *echoFilter) read(p []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.typed = append(f.typed, p...)
+	if len(f.typed) > maxTyped {
+		f.typed = f.typed[len(f.typed)-maxTyped:]
+	}
+}
+
+// drop reports whether p is the echo of the next typed key, and if so
+// forgets that key
+func (f *echoFilter) drop(p []byte) bool {
+	if len(p) == 0 {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.typed) == 0 {
+		return false
+	}
+
+	key := f.typed[0]
+	var echo bool
+	switch key {
+	case '\r', '\n':
+		s := string(p)
+		echo = s == "\r\n" || s == "\n" || s == "\r"
+	case '\b', 127:
+		echo = string(p) == "\b \b"
+	default:
+		echo = len(p) == 1 && p[0] == key
+	}
+
+	if !echo {
+		f.typed = f.typed[:0]
+		return false
+	}
+	f.typed = f.typed[1:]
+	return true
+}
+
+// reset forgets the typed keys
+func (f *echoFilter) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.typed = f.typed[:0]
+}
@@ -0,0 +1,101 @@
+package terminal
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// modes encodes opcode/value pairs the way a pty-req carries them
+func modes(pairs ...uint32) string {
+	var b []byte
+	for i := 0; i+1 < len(pairs); i += 2 {
+		b = append(b, byte(pairs[i]))
+		b = binary.BigEndian.AppendUint32(b, pairs[i+1])
+	}
+	return string(append(b, 0))
+}
+
+func TestPtyEcho(t *testing.T) {
+	tests := []struct {
+		name     string
+		modes    string
+		echo, ok bool
+	}{
+		{"none", "", false, false},
+		{"echo on", modes(3, 127, ttyOpEcho, 1), true, true},
+		{"echo off", modes(ttyOpEcho, 0, 128, 38400), false, true},
+		{"no echo mode", modes(3, 127), false, false},
+		{"truncated", modes(ttyOpEcho, 0)[:3], false, false},
+	}
+	for _, tt := range tests {
+		echo, ok := ptyEcho(tt.modes)
+		if echo != tt.echo || ok != tt.ok {
+			t.Errorf("%s: ptyEcho = %v, %v; want %v, %v", tt.name, echo, ok, tt.echo, tt.ok)
+		}
+	}
+}
+
+func TestNewlineFilter(t *testing.T) {
+	tests := []struct {
+		in, want, enter string
+	}{
+		{"ab\r", "ab\r", "CR"},
+		{"ab\r\n", "ab\r", "CR LF"},
+		{"\r\n\r\n", "\r\r", "CR LF"},
+		{"x\n\ry", "x\ny", "LF CR"},
+		{"\r\x00", "\r", "CR NUL"},
+		{"\n\n", "\n\n", "LF"},
+	}
+	for _, tt := range tests {
+		var f newlineFilter
+		p := []byte(tt.in)
+		if got := string(p[:f.filter(p)]); got != tt.want {
+			t.Errorf("filter(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if f.enter != tt.enter {
+			t.Errorf("filter(%q) saw Enter as %q, want %q", tt.in, f.enter, tt.enter)
+		}
+	}
+}
+
+func TestNewlineFilterAcrossReads(t *testing.T) {
+	// Keys are read one byte at a time, so a pair arrives in two reads
+	var f newlineFilter
+	var got []byte
+	for _, b := range []byte("a\r\nb") {
+		p := []byte{b}
+		got = append(got, p[:f.filter(p)]...)
+	}
+	if string(got) != "a\rb" {
+		t.Errorf("got %q, want %q", got, "a\rb")
+	}
+}
+
+func TestEchoFilter(t *testing.T) {
+	var f echoFilter
+
+	f.read([]byte("h"))
+	f.read([]byte("i"))
+	f.read([]byte{127})
+	f.read([]byte("\r"))
+	for _, echo := range []string{"h", "", "i", "\b \b", "\r\n"} {
+		if echo == "" {
+			if f.drop([]byte(echo)) {
+				t.Errorf("dropped an empty write")
+			}
+			continue
+		}
+		if !f.drop([]byte(echo)) {
+			t.Errorf("echo %q was not dropped", echo)
+		}
+	}
+
+	// Output that isn't an echo goes through and clears the typed keys
+	f.read([]byte("x"))
+	if f.drop([]byte("\033[2J")) {
+		t.Errorf("dropped output that isn't an echo")
+	}
+	if f.drop([]byte("x")) {
+		t.Errorf("dropped output after the typed keys were cleared")
+	}
+}
@@ -14,6 +14,8 @@ type LocalTerminal struct {
 	oldState *term.State
 	terminal *term.Terminal
 	rawMode  bool
+	console  console // Platform-specific console setup (Windows VT mode)
+	owned    bool    // The terminal opened its own device and closes it
 }
 
 // NewLocalTerminal creates a new local terminal
@@ -25,8 +27,32 @@ func NewLocalTerminal() *LocalTerminal {
 	}
 }
 
+// OpenLocalTerminal opens a terminal device other than the console, such
+// as another tmux pane's tty, as a local terminal. Closing the terminal
+// closes the device, which fails a read waiting on it.
+func OpenLocalTerminal(path string) (*LocalTerminal, error) {
+	tty, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalTerminal{
+		stdin:  tty,
+		stdout: tty,
+		owned:  true,
+	}, nil
+}
+
 func (t *LocalTerminal) Read(p []byte) (n int, err error) {
-	return t.stdin.Read(p)
+	for {
+		n, err = t.stdin.Read(p)
+		if n > 0 {
+			n = t.console.filterInput(p[:n])
+		}
+		// Don't report an empty read when only a filtered LF arrived
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
 }
 
 func (t *LocalTerminal) Write(p []byte) (n int, err error) {
@@ -46,6 +72,10 @@ func (t *LocalTerminal) MakeRaw() error {
 		return nil // Not a terminal, no raw mode needed
 	}
 
+	if err := t.console.enable(t.stdout); err != nil {
+		return err
+	}
+
 	state, err := term.MakeRaw(int(t.stdin.Fd()))
 	if err != nil {
 		return err
@@ -70,7 +100,12 @@ func (t *LocalTerminal) SetSize(width int, height int) error {
 }
 
 func (t *LocalTerminal) Close() error {
-	return t.Restore()
+	err := t.Restore()
+	t.console.restore(t.stdout)
+	if t.owned {
+		t.stdin.Close()
+	}
+	return err
 }
 
 func (t *LocalTerminal) ReadLine() (string, error) {
@@ -0,0 +1,158 @@
+package terminal
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrQueueClosed is returned by writes to a Queue after Close
+var ErrQueueClosed = errors.New("output queue closed")
+
+// Queue sends output to a terminal from a goroutine of its own, so a slow
+// link holds up that goroutine rather than whoever wrote. Each write goes
+// out whole and in the order it was queued, so output written at once by
+// the screen, the status bar and messages from other nodes can't interleave
+// in the middle of an escape sequence.
+type Queue struct {
+	w     io.Writer
+	limit int
+
+	mu      sync.Mutex
+	changed *sync.Cond // Signalled whenever anything below changes
+	pending [][]byte
+	queued  int   // Bytes waiting or being sent
+	err     error // The write that failed, after which nothing more is sent
+	closed  bool
+	done    chan struct{}
+}
+
+// NewQueue starts sending what is written to the queue to w. Once limit
+// bytes are waiting, Write waits for them to go and Offer drops what it is
+// given.
+func NewQueue(w io.Writer, limit int) *Queue {
+	q := &Queue{w: w, limit: limit, done: make(chan struct{})}
+	q.changed = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+// Write queues p to be sent, waiting first while the queue is full. It
+// returns the error a write to the terminal failed with, if one has.
+func (q *Queue) Write(p []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.queued >= q.limit && q.err == nil && !q.closed {
+		q.changed.Wait()
+	}
+	if err := q.usable(); err != nil {
+		return 0, err
+	}
+	q.add(p)
+	return len(p), nil
+}
+
+// Offer queues p unless the queue is full, for output such as a clock that
+// the next update replaces anyway. It reports whether p was queued.
+func (q *Queue) Offer(p []byte) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.usable() != nil || q.queued >= q.limit {
+		return false
+	}
+	q.add(p)
+	return true
+}
+
+// Flush waits until everything queued has been sent, as before handing the
+// terminal to something that writes to it directly
+func (q *Queue) Flush() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.queued > 0 && q.err == nil {
+		q.changed.Wait()
+	}
+	return q.err
+}
+
+// Close stops the queue once what is queued has been sent, waiting at most
+// wait for it to go. Whatever is left after that is dropped when the
+// terminal is closed and the write holding it fails.
+func (q *Queue) Close(wait time.Duration) {
+	q.mu.Lock()
+	q.closed = true
+	q.changed.Broadcast()
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+	case <-time.After(wait):
+	}
+}
+
+// usable returns why nothing more can be queued, if anything. The caller
+// holds mu.
+func (q *Queue) usable() error {
+	if q.err != nil {
+		return q.err
+	}
+	if q.closed {
+		return ErrQueueClosed
+	}
+	return nil
+}
+
+// add queues a copy of p, since the caller may reuse it. The caller holds
+// mu.
+func (q *Queue) add(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	q.pending = append(q.pending, append([]byte(nil), p...))
+	q.queued += len(p)
+	q.changed.Broadcast()
+}
+
+// run sends what is queued until the queue is closed and empty, or a write
+// fails
+func (q *Queue) run() {
+	defer close(q.done)
+	for {
+		q.mu.Lock()
+		for len(q.pending) == 0 && !q.closed {
+			q.changed.Wait()
+		}
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return
+		}
+		batch := q.pending
+		q.pending = nil
+		q.mu.Unlock()
+
+		// Writes go out one at a time, as written, since the echo filter
+		// on SSH terminals looks at each one
+		var err error
+		sent := 0
+		for _, p := range batch {
+			if _, err = q.w.Write(p); err != nil {
+				break
+			}
+			sent += len(p)
+		}
+
+		q.mu.Lock()
+		q.queued -= sent
+		if err != nil {
+			q.err = err
+			q.pending = nil
+			q.queued = 0
+		}
+		q.changed.Broadcast()
+		q.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package terminal
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter records each write, holding them up until released
+type slowWriter struct {
+	mu      sync.Mutex
+	writes  []string
+	release chan struct{}
+	fail    error
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	if w.release != nil {
+		<-w.release
+	}
+	if w.fail != nil {
+		return 0, w.fail
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func (w *slowWriter) sent() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.writes...)
+}
+
+func TestQueue_Order(t *testing.T) {
+	w := &slowWriter{}
+	q := NewQueue(w, 1024)
+	buf := []byte("one")
+	q.Write(buf)
+	copy(buf, "xxx") // The queue keeps its own copy
+	q.Write([]byte("\033[2J"))
+	q.Offer([]byte("three"))
+	if err := q.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got := strings.Join(w.sent(), "|"); got != "one|\033[2J|three" {
+		t.Errorf("sent %q", got)
+	}
+	q.Close(time.Second)
+}
+
+func TestQueue_Full(t *testing.T) {
+	w := &slowWriter{release: make(chan struct{})}
+	q := NewQueue(w, 4)
+	q.Write([]byte("abcd"))
+	if q.Offer([]byte("tick")) {
+		t.Error("Offer queued output past the limit")
+	}
+
+	written := make(chan struct{})
+	go func() {
+		q.Write([]byte("efgh"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("Write didn't wait for a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(w.release)
+	<-written
+	q.Flush()
+	if got := strings.Join(w.sent(), "|"); got != "abcd|efgh" {
+		t.Errorf("sent %q", got)
+	}
+	q.Close(time.Second)
+}
+
+func TestQueue_Failed(t *testing.T) {
+	lost := errors.New("connection lost")
+	q := NewQueue(&slowWriter{fail: lost}, 1024)
+	q.Write([]byte("hello"))
+	if err := q.Flush(); !errors.Is(err, lost) {
+		t.Errorf("Flush = %v, want the write's error", err)
+	}
+	if _, err := q.Write([]byte("again")); !errors.Is(err, lost) {
+		t.Errorf("Write after a failure = %v", err)
+	}
+}
+
+func TestQueue_Close(t *testing.T) {
+	w := &slowWriter{}
+	q := NewQueue(w, 1024)
+	q.Write([]byte("goodbye"))
+	q.Close(time.Second)
+	if got := strings.Join(w.sent(), "|"); got != "goodbye" {
+		t.Errorf("sent %q before closing", got)
+	}
+	if _, err := q.Write([]byte("late")); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Write after Close = %v", err)
+	}
+
+	// A link that never drains doesn't hold up closing for long
+	stuck := NewQueue(&slowWriter{release: make(chan struct{})}, 1024)
+	stuck.Write([]byte("never sent"))
+	start := time.Now()
+	stuck.Close(20 * time.Millisecond)
+	if time.Since(start) > time.Second {
+		t.Error("Close waited for a stuck write")
+	}
+}
@@ -0,0 +1,328 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"bbs/internal/ansi"
+)
+
+// Screen keeps a copy of what a caller's terminal shows, made by following
+// the same output, so part of it can be drawn again after something such as
+// a dialog has been drawn over it. It follows the cursor movement, erasing,
+// scroll regions and colors the board uses; anything else is passed over.
+type Screen struct {
+	mu            sync.Mutex
+	width, height int
+	cells         [][]cell
+	row, col      int
+	wrap          bool   // The last column was just written, so the next character starts a new line
+	top, bottom   int    // Scroll region, inclusive
+	saved         [2]int // Cursor saved with ESC 7 or ESC [ s
+	attr          string // Color sequences in effect
+	partial       []byte // The start of a sequence or character cut off at the end of a write
+}
+
+// cell is one column of the screen
+type cell struct {
+	text string // "" for the second column of a wide character
+	attr string
+}
+
+// NewScreen returns an empty screen of width columns and height rows
+func NewScreen(width, height int) *Screen {
+	s := &Screen{}
+	s.resize(width, height)
+	return s
+}
+
+// Resize changes the screen's size, keeping what still fits
+func (s *Screen) Resize(width, height int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if width != s.width || height != s.height {
+		s.resize(width, height)
+	}
+}
+
+// resize reallocates the cells. The caller holds mu.
+func (s *Screen) resize(width, height int) {
+	width, height = max(width, 1), max(height, 1)
+	cells := make([][]cell, height)
+	for r := range cells {
+		cells[r] = blankRow(width)
+		if r < len(s.cells) {
+			copy(cells[r], s.cells[r])
+		}
+	}
+	s.cells, s.width, s.height = cells, width, height
+	s.top, s.bottom = 0, height-1
+	s.row, s.col = min(s.row, height-1), min(s.col, width-1)
+	s.wrap = false
+}
+
+// Write follows output sent to the terminal
+func (s *Screen) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := p
+	if len(s.partial) > 0 {
+		data = append(s.partial, p...)
+		s.partial = nil
+	}
+	for i := 0; i < len(data); {
+		n := s.step(data[i:])
+		if n == 0 {
+			s.partial = append([]byte(nil), data[i:]...)
+			break
+		}
+		i += n
+	}
+	return len(p), nil
+}
+
+// Redraw returns output that draws rows first to last (counted from 1) as
+// they were, then puts the cursor and colors back as they are
+func (s *Screen) Redraw(first, last int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	first, last = max(first, 1), min(last, s.height)
+	var b strings.Builder
+	for r := first - 1; r < last; r++ {
+		fmt.Fprintf(&b, "\033[%d;1H\033[0m", r+1)
+		attr := ""
+		for _, c := range s.cells[r] {
+			if c.text == "" {
+				continue
+			}
+			if c.attr != attr {
+				b.WriteString("\033[0m" + c.attr)
+				attr = c.attr
+			}
+			b.WriteString(c.text)
+		}
+	}
+	fmt.Fprintf(&b, "\033[0m\033[%d;%dH%s", s.row+1, s.col+1, s.attr)
+	return b.String()
+}
+
+// step follows the sequence or character at the start of p, returning how
+// many bytes it took, or 0 if p ends before it does. The caller holds mu.
+func (s *Screen) step(p []byte) int {
+	switch b := p[0]; {
+	case b == '\033':
+		return s.escape(p)
+	case b == '\r':
+		s.col, s.wrap = 0, false
+	case b == '\n':
+		// Terminals the board writes to turn a newline into CR LF
+		s.col, s.wrap = 0, false
+		s.lineFeed()
+	case b == '\b':
+		s.col, s.wrap = max(s.col-1, 0), false
+	case b == '\t':
+		s.col, s.wrap = min((s.col/8+1)*8, s.width-1), false
+	case b < 0x20 || b == 0x7f:
+		// Bells and other controls leave the screen as it is
+	default:
+		r, size := utf8.DecodeRune(p)
+		if r == utf8.RuneError && size == 1 {
+			if !utf8.FullRune(p) {
+				return 0
+			}
+			r = rune(b) // A byte on its own, such as CP437 art
+		}
+		s.put(string(p[:size]), ansi.RuneWidth(r))
+		return size
+	}
+	return 1
+}
+
+// put writes a character width columns wide at the cursor. The caller
+// holds mu.
+func (s *Screen) put(text string, width int) {
+	if width == 0 {
+		// Combining marks join the character before
+		c := s.col - 1
+		if s.wrap {
+			c = s.col
+		}
+		if c > 0 && s.cells[s.row][c].text == "" {
+			c-- // The second column of a wide character
+		}
+		if c >= 0 {
+			s.cells[s.row][c].text += text
+		}
+		return
+	}
+	if s.wrap || s.col+width > s.width {
+		s.col, s.wrap = 0, false
+		s.lineFeed()
+	}
+	// Half a wide character left behind would throw out the columns after
+	// it when the row is drawn again
+	row := s.cells[s.row]
+	if row[s.col].text == "" && s.col > 0 {
+		row[s.col-1] = cell{text: " ", attr: row[s.col-1].attr}
+	}
+	if end := s.col + width; end < s.width && row[end].text == "" {
+		row[end] = cell{text: " ", attr: row[end].attr}
+	}
+	row[s.col] = cell{text: text, attr: s.attr}
+	if width == 2 && s.col+1 < s.width {
+		row[s.col+1] = cell{attr: s.attr}
+	}
+	if s.col+width >= s.width {
+		s.col, s.wrap = s.width-1, true
+	} else {
+		s.col += width
+	}
+}
+
+// lineFeed moves the cursor down a row, scrolling the scroll region when
+// it is on the region's last row. The caller holds mu.
+func (s *Screen) lineFeed() {
+	if s.row != s.bottom {
+		s.row = min(s.row+1, s.height-1)
+		return
+	}
+	copy(s.cells[s.top:s.bottom], s.cells[s.top+1:s.bottom+1])
+	s.cells[s.bottom] = blankRow(s.width)
+}
+
+// escape follows an escape sequence, returning its length, or 0 if p ends
+// before it does. The caller holds mu.
+func (s *Screen) escape(p []byte) int {
+	if len(p) < 2 {
+		return 0
+	}
+	switch p[1] {
+	case '[':
+	case '7':
+		s.saved = [2]int{s.row, s.col}
+		return 2
+	case '8':
+		s.row, s.col, s.wrap = s.saved[0], s.saved[1], false
+		return 2
+	default:
+		return 2
+	}
+
+	end := 2
+	for ; end < len(p); end++ {
+		if p[end] >= 0x40 && p[end] <= 0x7e {
+			break
+		}
+	}
+	if end == len(p) {
+		return 0
+	}
+	params := string(p[2:end])
+	if strings.HasPrefix(params, "?") {
+		return end + 1 // Modes such as the cursor being hidden don't change the cells
+	}
+	args := strings.Split(params, ";")
+	arg := func(i, def int) int {
+		if i < len(args) {
+			if n, err := strconv.Atoi(args[i]); err == nil && n > 0 {
+				return n
+			}
+		}
+		return def
+	}
+
+	switch p[end] {
+	case 'm':
+		switch {
+		case params == "" || params == "0":
+			s.attr = ""
+		case strings.HasPrefix(params, "0;") || len(s.attr) > 64:
+			s.attr = string(p[:end+1])
+		default:
+			s.attr += string(p[:end+1])
+		}
+	case 'H', 'f':
+		s.moveTo(arg(0, 1)-1, arg(1, 1)-1)
+	case 'A':
+		s.moveTo(s.row-arg(0, 1), s.col)
+	case 'B':
+		s.moveTo(s.row+arg(0, 1), s.col)
+	case 'C':
+		s.moveTo(s.row, s.col+arg(0, 1))
+	case 'D':
+		s.moveTo(s.row, s.col-arg(0, 1))
+	case 'G':
+		s.moveTo(s.row, arg(0, 1)-1)
+	case 'd':
+		s.moveTo(arg(0, 1)-1, s.col)
+	case 'J':
+		s.erase(args[0], true)
+	case 'K':
+		s.erase(args[0], false)
+	case 'r':
+		top, bottom := arg(0, 1)-1, arg(1, s.height)-1
+		if top < bottom && bottom < s.height {
+			s.top, s.bottom = top, bottom
+		} else {
+			s.top, s.bottom = 0, s.height-1
+		}
+		s.moveTo(0, 0)
+	case 's':
+		s.saved = [2]int{s.row, s.col}
+	case 'u':
+		s.moveTo(s.saved[0], s.saved[1])
+	}
+	return end + 1
+}
+
+// moveTo puts the cursor at row and col, counted from 0, kept on the
+// screen. The caller holds mu.
+func (s *Screen) moveTo(row, col int) {
+	s.row = min(max(row, 0), s.height-1)
+	s.col = min(max(col, 0), s.width-1)
+	s.wrap = false
+}
+
+// erase blanks part of the screen for ED, or of the cursor's row for EL:
+// from the cursor on for mode 0, up to the cursor for 1, or all of it for
+// 2. The caller holds mu.
+func (s *Screen) erase(mode string, screen bool) {
+	first, last := s.row, s.row
+	if screen {
+		switch mode {
+		case "1":
+			first = 0
+		case "2":
+			first, last = 0, s.height-1
+		default:
+			last = s.height - 1
+		}
+	}
+	for r := first; r <= last; r++ {
+		from, to := 0, s.width
+		if r == s.row {
+			switch mode {
+			case "1":
+				to = s.col + 1
+			case "2":
+			default:
+				from = s.col
+			}
+		}
+		for c := from; c < to; c++ {
+			s.cells[r][c] = cell{text: " ", attr: s.attr}
+		}
+	}
+}
+
+// blankRow returns an empty row width columns wide
+func blankRow(width int) []cell {
+	row := make([]cell, width)
+	for c := range row {
+		row[c] = cell{text: " "}
+	}
+	return row
+}
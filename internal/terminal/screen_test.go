@@ -0,0 +1,84 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"bbs/internal/ansi"
+)
+
+// rows returns what the screen shows, a row to a line with trailing
+// spaces trimmed
+func (s *Screen) rows() string {
+	var lines []string
+	for r := 1; r <= s.height; r++ {
+		out := s.Redraw(r, r)
+		out = out[:strings.LastIndex(out, "\033[0m\033[")] // Drop the cursor put back
+		lines = append(lines, strings.TrimRight(ansi.Strip(out), " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestScreen_Follows(t *testing.T) {
+	tests := []struct {
+		name, out, want string
+	}{
+		{"lines", "one\ntwo\r\nthree", "one\ntwo\nthree\n"},
+		{"cursor", "\033[2;3Hx\033[Ay\033[3Gz\033[B\033[2Dw", "  zy\n wx\n\n"},
+		{"clear", "abc\ndef\033[2J\033[Hg", "g\n\n\n"},
+		{"erase line", "abcdef\033[1;3H\033[K\ngh\033[1K", "ab\n\n\n"},
+		{"wrap", "abcdefghij", "abcde\nfghij\n\n"},
+		{"scroll", "1\n2\n3\n4\n5", "2\n3\n4\n5"},
+		{"region", "\033[1;2r\033[1;1Htop\n\033[4;1Hbar\033[1;1H\n\nnext", "\nnext\n\nbar"},
+		{"wide", "日本\033[1;2Hx", " x本\n\n\n"},
+		{"save", "\033[sab\033[3;1Hc\033[ud", "db\n\nc\n"},
+	}
+	for _, tt := range tests {
+		s := NewScreen(5, 4)
+		s.Write([]byte(tt.out))
+		if got := s.rows(); got != tt.want {
+			t.Errorf("%s: screen =\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScreen_Split(t *testing.T) {
+	// Sequences and characters cut off at the end of one write are
+	// finished by the next
+	s := NewScreen(5, 4)
+	s.Write([]byte("\033[3;"))
+	s.Write([]byte("2Hq\xe6\x97"))
+	s.Write([]byte("\xa5"))
+	if got := s.rows(); got != "\n\n q日\n" {
+		t.Errorf("screen = %q", got)
+	}
+}
+
+func TestScreen_Redraw(t *testing.T) {
+	s := NewScreen(10, 3)
+	s.Write([]byte("\033[36mhi\033[0m there\n\033[1;33mwarn\033[0m\033[3;4H"))
+	got := s.Redraw(1, 2)
+	want := "\033[1;1H\033[0m\033[0m\033[36mhi\033[0m there  " +
+		"\033[2;1H\033[0m\033[0m\033[1;33mwarn\033[0m      " +
+		"\033[0m\033[3;4H"
+	if got != want {
+		t.Errorf("Redraw =\n%q\nwant\n%q", got, want)
+	}
+
+	// Drawing the rows again leaves the screen as it was
+	before := s.rows()
+	s.Write([]byte("\033[1;1H\033[2KXXXXXXXXXX\033[2;1Hover"))
+	s.Write([]byte(got))
+	if after := s.rows(); after != before {
+		t.Errorf("after redrawing, screen =\n%q\nwant\n%q", after, before)
+	}
+}
+
+func TestScreen_Resize(t *testing.T) {
+	s := NewScreen(4, 2)
+	s.Write([]byte("abcd\nef"))
+	s.Resize(2, 3)
+	if got := s.rows(); got != "ab\nef\n" {
+		t.Errorf("after resizing, screen = %q", got)
+	}
+}
@@ -1,6 +1,8 @@
 package terminal
 
 import (
+	"sync"
+
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/term"
 )
@@ -9,34 +11,187 @@ import (
 type SSHTerminal struct {
 	channel  ssh.Channel
 	terminal *term.Terminal
+
+	mu        sync.RWMutex
+	width     int
+	height    int
+	termType  string
+	pty       bool // The client sent a pty-req
+	ptyEcho   bool // ECHO from the pty-req terminal modes
+	ptyModeOK bool // The pty-req included ECHO
+
+	joinNewlines bool // Read CR LF, LF CR and CR NUL as one Enter
+	localEcho    bool // The client echoes typing, so the board's echo is dropped
+	newlines     newlineFilter
+	echo         echoFilter
 }
 
 // NewSSHTerminal creates a new SSH terminal wrapper
 func NewSSHTerminal(channel ssh.Channel) *SSHTerminal {
-	terminal := term.NewTerminal(channel, "")
-	return &SSHTerminal{
-		channel:  channel,
-		terminal: terminal,
+	t := &SSHTerminal{
+		channel:      channel,
+		width:        80, // Until the client sends pty-req
+		height:       24,
+		joinNewlines: true,
+	}
+	// Output from term.Terminal goes through Write so the echo filter sees it
+	t.terminal = term.NewTerminal(t, "")
+	return t
+}
+
+// ptyRequest is the payload of an SSH "pty-req" request (RFC 4254 §6.2)
+type ptyRequest struct {
+	Term     string
+	Columns  uint32
+	Rows     uint32
+	WidthPx  uint32
+	HeightPx uint32
+	Modes    string
+}
+
+// windowChange is the payload of an SSH "window-change" request (RFC 4254 §6.7)
+type windowChange struct {
+	Columns  uint32
+	Rows     uint32
+	WidthPx  uint32
+	HeightPx uint32
+}
+
+// HandlePtyRequest records the terminal type and size from a pty-req payload
+func (t *SSHTerminal) HandlePtyRequest(payload []byte) error {
+	var req ptyRequest
+	if err := ssh.Unmarshal(payload, &req); err != nil {
+		return err
 	}
+
+	t.mu.Lock()
+	t.termType = req.Term
+	t.pty = true
+	t.ptyEcho, t.ptyModeOK = ptyEcho(req.Modes)
+	t.mu.Unlock()
+
+	return t.SetSize(int(req.Columns), int(req.Rows))
+}
+
+// HandleWindowChange updates the terminal size from a window-change payload
+func (t *SSHTerminal) HandleWindowChange(payload []byte) error {
+	var req windowChange
+	if err := ssh.Unmarshal(payload, &req); err != nil {
+		return err
+	}
+	return t.SetSize(int(req.Columns), int(req.Rows))
+}
+
+// TermType returns the client's TERM value from the pty-req, if any
+func (t *SSHTerminal) TermType() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.termType
+}
+
+// HasPty reports whether the client asked for a pty. Clients that don't
+// usually echo locally and send whole lines.
+func (t *SSHTerminal) HasPty() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.pty
+}
+
+// PtyEcho returns the ECHO terminal mode from the client's pty-req, and
+// whether it sent one
+func (t *SSHTerminal) PtyEcho() (echo bool, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.ptyEcho, t.ptyModeOK
+}
+
+// SetJoinNewlines sets whether CR LF, LF CR and CR NUL are read as one Enter
+func (t *SSHTerminal) SetJoinNewlines(join bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.joinNewlines = join
+	t.newlines.last = 0
+}
+
+// JoinNewlines reports whether newline pairs are read as one Enter
+func (t *SSHTerminal) JoinNewlines() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.joinNewlines
+}
+
+// SetLocalEcho sets whether the client echoes typing itself. While it does,
+// the board's echo of each key is dropped so input doesn't appear twice.
+func (t *SSHTerminal) SetLocalEcho(local bool) {
+	t.mu.Lock()
+	t.localEcho = local
+	t.mu.Unlock()
+	t.echo.reset()
+}
+
+// LocalEcho reports whether the client echoes typing itself
+func (t *SSHTerminal) LocalEcho() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.localEcho
+}
+
+// EnterSequence describes what the client's Enter key sends, such as
+// "CR LF", or returns "" before the caller has pressed it. Pairs are only
+// recognised while newlines are joined.
+func (t *SSHTerminal) EnterSequence() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.newlines.enter
 }
 
 func (t *SSHTerminal) Read(p []byte) (n int, err error) {
-	return t.channel.Read(p)
+	for {
+		n, err = t.channel.Read(p)
+		if n > 0 {
+			t.mu.Lock()
+			if t.joinNewlines {
+				n = t.newlines.filter(p[:n])
+			}
+			local := t.localEcho
+			t.mu.Unlock()
+			if local {
+				t.echo.read(p[:n])
+			}
+		}
+		// Don't report an empty read when only the second byte of a pair arrived
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
 }
 
 func (t *SSHTerminal) Write(p []byte) (n int, err error) {
+	if t.LocalEcho() && t.echo.drop(p) {
+		return len(p), nil
+	}
 	return t.channel.Write(p)
 }
 
+// SetSize records the client's terminal size as reported by pty-req or
+// window-change. Zero dimensions (size unknown) are ignored.
 func (t *SSHTerminal) SetSize(width int, height int) error {
-	// SSH terminal size is managed by the SSH protocol
-	return nil
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	t.width = width
+	t.height = height
+	t.mu.Unlock()
+
+	return t.terminal.SetSize(width, height)
 }
 
 func (t *SSHTerminal) Size() (width int, height int, error error) {
-	// For SSH terminals, we'll use a default size
-	// Terminal size should be handled via SSH window-change requests
-	return 80, 24, nil
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.width, t.height, nil
 }
 
 func (t *SSHTerminal) MakeRaw() error {
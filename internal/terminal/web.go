@@ -0,0 +1,142 @@
+package terminal
+
+import (
+	"encoding/json"
+	"sync"
+
+	"golang.org/x/term"
+
+	"bbs/internal/websocket"
+)
+
+// WebTerminal carries a session to a browser over a WebSocket. Keys arrive
+// as binary messages and terminal size changes as JSON text messages, such
+// as {"cols":80,"rows":24}; output is sent as binary messages.
+type WebTerminal struct {
+	conn     *websocket.Conn
+	terminal *term.Terminal
+
+	mu       sync.RWMutex
+	width    int
+	height   int
+	onResize func()
+
+	pending []byte // Keys received but not yet read
+
+	writeMu sync.Mutex
+	lastCR  bool // The last byte written was CR
+}
+
+// webResize is a size change sent by the page
+type webResize struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+}
+
+// NewWebTerminal creates a terminal over an upgraded WebSocket connection
+func NewWebTerminal(conn *websocket.Conn) *WebTerminal {
+	t := &WebTerminal{
+		conn:   conn,
+		width:  80, // Until the page reports its size
+		height: 24,
+	}
+	t.terminal = term.NewTerminal(t, "")
+	return t
+}
+
+// OnResize sets a function called after the page reports a new size
+func (t *WebTerminal) OnResize(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onResize = fn
+}
+
+func (t *WebTerminal) Read(p []byte) (int, error) {
+	for len(t.pending) == 0 {
+		kind, data, err := t.conn.ReadMessage()
+		if err != nil {
+			return 0, err
+		}
+		if kind == websocket.BinaryMessage {
+			t.pending = data
+			continue
+		}
+
+		var resize webResize
+		if json.Unmarshal(data, &resize) != nil || resize.Cols <= 0 || resize.Rows <= 0 {
+			continue
+		}
+		t.SetSize(resize.Cols, resize.Rows)
+		t.mu.RLock()
+		onResize := t.onResize
+		t.mu.RUnlock()
+		if onResize != nil {
+			onResize()
+		}
+	}
+
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+// Write sends output to the page. Bare line feeds become CR LF, as a
+// terminal's output processing would make them.
+func (t *WebTerminal) Write(p []byte) (int, error) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	out := make([]byte, 0, len(p)+8)
+	for _, b := range p {
+		if b == '\n' && !t.lastCR {
+			out = append(out, '\r')
+		}
+		out = append(out, b)
+		t.lastCR = b == '\r'
+	}
+	if err := t.conn.WriteMessage(websocket.BinaryMessage, out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// SetSize records the page's terminal size. Zero dimensions are ignored.
+func (t *WebTerminal) SetSize(width int, height int) error {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	t.width = width
+	t.height = height
+	t.mu.Unlock()
+
+	return t.terminal.SetSize(width, height)
+}
+
+func (t *WebTerminal) Size() (width int, height int, error error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.width, t.height, nil
+}
+
+func (t *WebTerminal) MakeRaw() error {
+	// The page passes every key through as typed
+	return nil
+}
+
+func (t *WebTerminal) Restore() error {
+	return nil
+}
+
+func (t *WebTerminal) Close() error {
+	return t.conn.Close()
+}
+
+func (t *WebTerminal) ReadLine() (string, error) {
+	return t.terminal.ReadLine()
+}
+
+func (t *WebTerminal) SetPrompt(prompt string) {
+	t.terminal.SetPrompt(prompt)
+}
@@ -0,0 +1,95 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle counts failed logins for each key, such as a username or an
+// address, and makes every further attempt wait twice as long as the one
+// before. A key's failures are forgotten after a window with no new ones or
+// as soon as a login succeeds.
+type Throttle struct {
+	mu        sync.Mutex
+	base      time.Duration
+	max       time.Duration
+	window    time.Duration
+	keys      map[string]*record
+	lastSweep time.Time
+}
+
+// record is the recent failures of one key
+type record struct {
+	failures int
+	last     time.Time
+}
+
+// New creates a throttle whose delay starts at base after the first failure
+// and doubles with each one up to max. Failures expire after window.
+func New(base, max, window time.Duration) *Throttle {
+	return &Throttle{
+		base:   base,
+		max:    max,
+		window: window,
+		keys:   make(map[string]*record),
+	}
+}
+
+// Delay returns how long the next login attempt for key should wait
+func (t *Throttle) Delay(key string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r, ok := t.keys[key]
+	if !ok || r.failures == 0 || now.Sub(r.last) >= t.window {
+		return 0
+	}
+
+	delay := t.base
+	for i := 1; i < r.failures && delay < t.max; i++ {
+		delay *= 2
+	}
+	if delay > t.max {
+		delay = t.max
+	}
+	return delay
+}
+
+// Failed records a failed login for key and returns its failures within the
+// window, including this one
+func (t *Throttle) Failed(key string, now time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(now)
+
+	r, ok := t.keys[key]
+	if !ok || now.Sub(r.last) >= t.window {
+		r = &record{}
+		t.keys[key] = r
+	}
+	r.failures++
+	r.last = now
+	return r.failures
+}
+
+// Succeeded forgets key's failures after a successful login
+func (t *Throttle) Succeeded(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.keys, key)
+}
+
+// sweep drops keys that have been quiet for a whole window. It runs at most
+// once per window so a flood of keys can't make it quadratic.
+func (t *Throttle) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < t.window {
+		return
+	}
+	t.lastSweep = now
+	for key, r := range t.keys {
+		if now.Sub(r.last) >= t.window {
+			delete(t.keys, key)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelayDoubles(t *testing.T) {
+	throttle := New(time.Second, 8*time.Second, 15*time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if d := throttle.Delay("alice", now); d != 0 {
+		t.Fatalf("delay before any failure = %v", d)
+	}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second}
+	for i, w := range want {
+		if n := throttle.Failed("alice", now); n != i+1 {
+			t.Errorf("Failed returned %d, want %d", n, i+1)
+		}
+		if d := throttle.Delay("alice", now); d != w {
+			t.Errorf("delay after %d failures = %v, want %v", i+1, d, w)
+		}
+	}
+
+	if d := throttle.Delay("bob", now); d != 0 {
+		t.Errorf("unrelated key delayed %v", d)
+	}
+}
+
+func TestFailuresExpire(t *testing.T) {
+	throttle := New(time.Second, time.Minute, 15*time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	throttle.Failed("192.0.2.1", now)
+	throttle.Failed("192.0.2.1", now)
+
+	later := now.Add(15 * time.Minute)
+	if d := throttle.Delay("192.0.2.1", later); d != 0 {
+		t.Errorf("delay after a quiet window = %v", d)
+	}
+	if n := throttle.Failed("192.0.2.1", later); n != 1 {
+		t.Errorf("count after a quiet window = %d, want 1", n)
+	}
+}
+
+func TestSucceededClears(t *testing.T) {
+	throttle := New(time.Second, time.Minute, 15*time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	throttle.Failed("alice", now)
+	throttle.Succeeded("alice")
+	if d := throttle.Delay("alice", now); d != 0 {
+		t.Errorf("delay after success = %v", d)
+	}
+}
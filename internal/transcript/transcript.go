@@ -0,0 +1,168 @@
+// Package transcript renders bulletins and message threads as plain text,
+// wrapped to a chosen width, for callers to keep or read with a screen
+// reader
+package transcript
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"bbs/internal/ansi"
+	"bbs/internal/database"
+)
+
+// Line widths a transcript may be wrapped to
+const (
+	DefaultWidth = 72
+	MinWidth     = 20
+	MaxWidth     = 200
+)
+
+// ClampWidth returns width within MinWidth and MaxWidth, or DefaultWidth
+// when it is 0 or less
+func ClampWidth(width int) int {
+	switch {
+	case width <= 0:
+		return DefaultWidth
+	case width < MinWidth:
+		return MinWidth
+	case width > MaxWidth:
+		return MaxWidth
+	}
+	return width
+}
+
+// Plain drops ANSI codes and control characters, keeping line breaks.
+// Tabs become spaces.
+func Plain(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(ansi.Strip(text), "\t", "    ")
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r >= ' ' && r != 0x7f && (r < 0x80 || r > 0x9f) {
+			return r
+		}
+		return -1
+	}, text)
+}
+
+// Wrap breaks text into lines no longer than width. Line breaks and blank
+// lines in the text are kept, as is the indent at the start of a line;
+// words longer than width are split.
+func Wrap(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(Plain(text), "\n") {
+		trimmed := strings.TrimLeft(paragraph, " ")
+		if trimmed == "" {
+			lines = append(lines, "")
+			continue
+		}
+		indent := paragraph[:len(paragraph)-len(trimmed)]
+		if len(indent) > width/2 {
+			indent = indent[:width/2]
+		}
+		lines = append(lines, wrapWords(trimmed, indent, width)...)
+	}
+	return lines
+}
+
+// wrapWords fills lines with words, starting the first with indent
+func wrapWords(text, indent string, width int) []string {
+	var lines []string
+	line := indent
+	for _, word := range strings.Fields(text) {
+		for utf8.RuneCountInString(word) > width {
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+			line = ""
+			cut := cutAt(word, width)
+			lines = append(lines, word[:cut])
+			word = word[cut:]
+		}
+		n := utf8.RuneCountInString(line)
+		switch {
+		case strings.TrimSpace(line) == "":
+			line += word
+		case n+1+utf8.RuneCountInString(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if strings.TrimSpace(line) != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// cutAt returns the byte offset of the rune after the first n
+func cutAt(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
+
+// rule is a line as wide as the transcript
+func rule(char string, width int) string {
+	return strings.Repeat(char, width)
+}
+
+// Bulletin renders a bulletin as plain text
+func Bulletin(b *database.Bulletin, width int) string {
+	width = ClampWidth(width)
+	var out strings.Builder
+	writeWrapped(&out, b.Title, width)
+	writeWrapped(&out, fmt.Sprintf("By %s, %s", b.Author, b.CreatedAt.Format("January 2, 2006 15:04")), width)
+	out.WriteString(rule("=", width) + "\n\n")
+	writeWrapped(&out, b.Body, width)
+	return out.String()
+}
+
+// Thread renders a post and its replies as plain text, in the order the
+// thread reads
+func Thread(topic string, post *database.Post, thread []database.ThreadedReply, width int) string {
+	width = ClampWidth(width)
+	var out strings.Builder
+	writeWrapped(&out, post.Subject, width)
+	replies := fmt.Sprintf("%d replies", len(thread))
+	if len(thread) == 1 {
+		replies = "1 reply"
+	}
+	writeWrapped(&out, topic+", "+replies, width)
+	out.WriteString(rule("=", width) + "\n\n")
+
+	writeWrapped(&out, fmt.Sprintf("From %s, %s", post.Author, post.CreatedAt.Format("January 2, 2006 15:04")), width)
+	out.WriteString("\n")
+	writeWrapped(&out, post.Body, width)
+
+	authors := make(map[int]string, len(thread))
+	for _, reply := range thread {
+		authors[reply.ID] = reply.Author
+	}
+	for i, reply := range thread {
+		answered := post.Author
+		if name, ok := authors[reply.ParentID]; ok {
+			answered = name
+		}
+		out.WriteString("\n" + rule("-", width) + "\n")
+		writeWrapped(&out, fmt.Sprintf("Reply %d of %d, from %s to %s, %s", i+1, len(thread),
+			reply.Author, answered, reply.CreatedAt.Format("January 2, 2006 15:04")), width)
+		out.WriteString("\n")
+		writeWrapped(&out, reply.Body, width)
+	}
+	return out.String()
+}
+
+// writeWrapped writes text wrapped to width, without trailing blank lines
+func writeWrapped(out *strings.Builder, body string, width int) {
+	lines := Wrap(strings.TrimRight(body, " \t\r\n"), width)
+	for _, line := range lines {
+		out.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+}
@@ -0,0 +1,80 @@
+package transcript
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"bbs/internal/database"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  []string
+	}{
+		{"the quick brown fox jumps", 10, []string{"the quick", "brown fox", "jumps"}},
+		{"one\n\ntwo", 20, []string{"one", "", "two"}},
+		{"  indented text here", 12, []string{"  indented", "text here"}},
+		{"abcdefghijkl", 5, []string{"abcde", "fghij", "kl"}},
+		{"\x1b[1;31mred\x1b[0m\tbold\r\n", 20, []string{"red bold", ""}},
+		{"héllo wörld", 5, []string{"héllo", "wörld"}},
+	}
+	for _, tt := range tests {
+		if got := Wrap(tt.text, tt.width); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Wrap(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestClampWidth(t *testing.T) {
+	for width, want := range map[int]int{0: DefaultWidth, -5: DefaultWidth, 5: MinWidth, 80: 80, 500: MaxWidth} {
+		if got := ClampWidth(width); got != want {
+			t.Errorf("ClampWidth(%d) = %d, want %d", width, got, want)
+		}
+	}
+}
+
+func TestBulletin(t *testing.T) {
+	b := &database.Bulletin{
+		Title:     "Welcome",
+		Author:    "sysop",
+		Body:      "\x1b[36mHello\x1b[0m callers, and welcome aboard.\n\n",
+		CreatedAt: time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+	}
+	got := Bulletin(b, 32)
+	want := "Welcome\nBy sysop, March 1, 2026 09:30\n" + strings.Repeat("=", 32) + "\n\n" +
+		"Hello callers, and welcome\naboard.\n"
+	if got != want {
+		t.Errorf("Bulletin = %q, want %q", got, want)
+	}
+}
+
+func TestThread(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	post := &database.Post{ID: 1, Author: "alice", Subject: "Modems", Body: "Which modem?", CreatedAt: at}
+	thread := database.Thread([]database.Reply{
+		{ID: 10, PostID: 1, Author: "bob", Body: "A USR.", CreatedAt: at},
+		{ID: 11, PostID: 1, ParentID: 10, Author: "carol", Body: "Agreed.", CreatedAt: at},
+	})
+
+	got := Thread("Hardware", post, thread, 60)
+	for _, want := range []string{
+		"Modems\nHardware, 2 replies\n",
+		"From alice, March 1, 2026 09:30\n\nWhich modem?\n",
+		"Reply 1 of 2, from bob to alice, March 1, 2026 09:30\n\nA USR.\n",
+		"Reply 2 of 2, from carol to bob,",
+		"\nAgreed.\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("thread is missing %q:\n%s", want, got)
+		}
+	}
+	for _, line := range strings.Split(Thread("Hardware", post, thread, 30), "\n") {
+		if len([]rune(line)) > 30 {
+			t.Errorf("line %q is wider than 30", line)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Received describes a file that was uploaded successfully
+type Received struct {
+	Name string // Sanitized file name
+	Path string // Where the file was written
+	Size int64
+}
+
+// maxInfoSize bounds the ZFILE subpacket holding the file name and info
+const maxInfoSize = 2048
+
+// Receive accepts files from a ZMODEM sender on rw and writes them into dir.
+// Files whose names are unsafe, that already exist, or that are larger than
+// opts.MaxSize are skipped. Files received before an error are kept.
+func Receive(rw io.ReadWriter, dir string, opts Options) ([]Received, error) {
+	c := newConn(rw)
+	var received []Received
+
+	if err := c.sendReceiverInit(); err != nil {
+		return nil, err
+	}
+
+	errorsInRow := 0
+	for {
+		h, err := c.readHeader()
+		if err != nil {
+			if err == ErrCancelled || errors.Is(err, io.EOF) || errorsInRow >= maxRetries {
+				return received, err
+			}
+			errorsInRow++
+			c.sendReceiverInit()
+			continue
+		}
+		errorsInRow = 0
+
+		switch h.typ {
+		case zrqinit, zeof:
+			c.sendReceiverInit()
+		case zsinit:
+			// Sender capabilities and attention string; nothing to keep
+			c.crc32 = c.binCRC32
+			if _, _, err := c.readData(maxInfoSize); err != nil {
+				c.sendHexHeader(header{typ: znak})
+				continue
+			}
+			c.sendHexHeader(header{typ: zack})
+		case zfile:
+			file, err := c.receiveFile(dir, opts)
+			if err != nil {
+				if err == ErrCancelled || errors.Is(err, io.EOF) {
+					return received, err
+				}
+				c.cancel()
+				return received, err
+			}
+			if file != nil {
+				received = append(received, *file)
+			}
+			c.sendReceiverInit()
+		case zfin:
+			c.sendHexHeader(header{typ: zfin})
+			c.readOverAndOut()
+			return received, nil
+		case zcommand:
+			// Never run commands sent by the remote side
+			c.cancel()
+			return received, errors.New("sender requested a command")
+		default:
+			c.sendReceiverInit()
+		}
+	}
+}
+
+// sendReceiverInit advertises the receiver's capabilities
+func (c *conn) sendReceiverInit() error {
+	return c.sendHexHeader(flagHeader(zrinit, canfdx|canovio|canfc32))
+}
+
+// receiveFile handles a ZFILE frame. It returns nil without an error when
+// the file was skipped.
+func (c *conn) receiveFile(dir string, opts Options) (*Received, error) {
+	c.crc32 = c.binCRC32
+	info, _, err := c.readData(maxInfoSize)
+	if err != nil {
+		return nil, c.sendHexHeader(header{typ: znak})
+	}
+
+	name, size := parseFileInfo(info)
+	name = SafeName(name)
+	if name == "" || (opts.MaxSize > 0 && size > opts.MaxSize) {
+		return nil, c.sendHexHeader(header{typ: zskip})
+	}
+
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return nil, c.sendHexHeader(header{typ: zskip})
+	}
+
+	// Write to a temporary file so an interrupted upload never shows up
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	written, err := c.receiveFileData(tmp, name, size, opts)
+	if err != nil {
+		return nil, err
+	}
+	// CreateTemp makes the file private; uploads are for everyone
+	if err := tmp.Chmod(0644); err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	// Don't replace a file another caller uploaded meanwhile
+	if err := os.Link(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", name, err)
+	}
+
+	return &Received{Name: name, Path: path, Size: written}, nil
+}
+
+// receiveFileData reads ZDATA frames into w until a ZEOF for the current
+// position arrives
+func (c *conn) receiveFileData(w io.Writer, name string, size int64, opts Options) (int64, error) {
+	var pos int64
+	if err := c.sendHexHeader(posHeader(zrpos, pos)); err != nil {
+		return 0, err
+	}
+
+	errorsInRow := 0
+	for {
+		if errorsInRow > maxRetries {
+			return pos, errors.New("too many errors")
+		}
+
+		h, err := c.readHeader()
+		if err != nil {
+			if err == ErrCancelled || errors.Is(err, io.EOF) {
+				return pos, err
+			}
+			errorsInRow++
+			c.sendHexHeader(posHeader(zrpos, pos))
+			continue
+		}
+
+		switch h.typ {
+		case zdata:
+			c.crc32 = c.binCRC32
+			if h.pos() != pos {
+				errorsInRow++
+				c.sendHexHeader(posHeader(zrpos, pos))
+				continue
+			}
+			if err := c.receiveSubpackets(w, &pos, name, size, opts); err != nil {
+				if err == ErrCancelled || errors.Is(err, io.EOF) || err == errTooLarge {
+					return pos, err
+				}
+				errorsInRow++
+				c.sendHexHeader(posHeader(zrpos, pos))
+				continue
+			}
+			errorsInRow = 0
+		case zeof:
+			if h.pos() == pos {
+				return pos, nil
+			}
+			// A ZEOF for data we don't have yet is ignored; ask for the rest
+			c.sendHexHeader(posHeader(zrpos, pos))
+		case zfile:
+			// The sender missed our ZRPOS and offered the file again
+			c.readData(maxInfoSize)
+			c.sendHexHeader(posHeader(zrpos, pos))
+		case zfin, zabort:
+			return pos, errors.New("sender ended the session mid-file")
+		default:
+			c.sendHexHeader(posHeader(zrpos, pos))
+		}
+	}
+}
+
+// errTooLarge stops an upload that grows past the configured limit
+var errTooLarge = errors.New("file exceeds the upload size limit")
+
+// receiveSubpackets writes the data subpackets following a ZDATA header
+// until one ends the frame
+func (c *conn) receiveSubpackets(w io.Writer, pos *int64, name string, size int64, opts Options) error {
+	for {
+		data, end, err := c.readData(8 * subpacketSize)
+		if err != nil {
+			return err
+		}
+
+		if opts.MaxSize > 0 && *pos+int64(len(data)) > opts.MaxSize {
+			c.cancel()
+			return errTooLarge
+		}
+		if _, err := w.Write(data); err != nil {
+			c.cancel()
+			return err
+		}
+		*pos += int64(len(data))
+		opts.report(name, *pos, size)
+
+		switch end {
+		case zcrcw:
+			return c.sendHexHeader(posHeader(zack, *pos))
+		case zcrcq:
+			c.sendHexHeader(posHeader(zack, *pos))
+		case zcrce:
+			return nil
+		}
+	}
+}
+
+// readOverAndOut consumes the "OO" the sender writes after the final ZFIN so
+// it isn't read as key presses
+func (c *conn) readOverAndOut() {
+	for i := 0; i < 2; i++ {
+		if b, err := c.r.ReadByte(); err != nil || b != 'O' {
+			return
+		}
+	}
+}
+
+// parseFileInfo extracts the name and size from a ZFILE subpacket:
+// "name\0size mtime mode ..."
+func parseFileInfo(info []byte) (string, int64) {
+	name, rest, _ := bytes.Cut(info, []byte{0})
+	fields := strings.Fields(string(bytes.TrimRight(rest, "\x00")))
+
+	var size int64
+	if len(fields) > 0 {
+		size, _ = strconv.ParseInt(fields[0], 10, 64)
+	}
+	return string(name), size
+}
+
+// SafeName reduces an uploaded file name to a plain name that can't escape
+// the upload directory, or returns "" if nothing usable is left
+func SafeName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	if name == "" || name == "." || name == ".." || strings.HasPrefix(name, ".") || len(name) > 255 {
+		return ""
+	}
+	for _, r := range name {
+		if r < 32 || r == 127 || strings.ContainsRune(`:*?"<>|`, r) {
+			return ""
+		}
+	}
+	return name
+}
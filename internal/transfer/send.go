@@ -0,0 +1,295 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// windowSize is how much data is sent before waiting for the receiver to
+// acknowledge, which is when a receiver's ZRPOS after an error is noticed
+const windowSize = 32 * 1024
+
+// File is a file offered for download
+type File struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	Data    io.ReadSeeker
+}
+
+// Progress reports how many bytes of the named file have been transferred
+type Progress func(name string, transferred, total int64)
+
+// Options tunes a transfer
+type Options struct {
+	Progress Progress // Called as data is sent or received, may be nil
+	MaxSize  int64    // Largest file accepted when receiving, 0 for no limit
+}
+
+// report calls the progress callback if there is one
+func (o Options) report(name string, transferred, total int64) {
+	if o.Progress != nil {
+		o.Progress(name, transferred, total)
+	}
+}
+
+// Send transmits files to a ZMODEM receiver on rw and returns the number of
+// bytes sent. Files the receiver skips are not counted.
+func Send(rw io.ReadWriter, files []File, opts Options) (int64, error) {
+	c := newConn(rw)
+
+	// "rz\r" starts the receiver on terminals that don't autodetect ZRQINIT
+	if _, err := c.w.Write([]byte("rz\r")); err != nil {
+		return 0, err
+	}
+	if err := c.waitReceiver(); err != nil {
+		if err != ErrCancelled {
+			c.cancel()
+		}
+		return 0, err
+	}
+
+	var total int64
+	var remaining int64
+	for _, f := range files {
+		remaining += f.Size
+	}
+
+	for i, f := range files {
+		sent, err := c.sendFile(f, len(files)-i, remaining, opts)
+		total += sent
+		if err != nil {
+			if err != ErrCancelled {
+				c.cancel()
+			}
+			return total, err
+		}
+		remaining -= f.Size
+	}
+
+	return total, c.finishSend()
+}
+
+// waitReceiver sends ZRQINIT until the receiver answers with ZRINIT
+func (c *conn) waitReceiver() error {
+	for tries := 0; tries < maxRetries; tries++ {
+		if err := c.sendHexHeader(header{typ: zrqinit}); err != nil {
+			return err
+		}
+
+		h, err := c.readHeader()
+		if err != nil {
+			if err == ErrCancelled || errors.Is(err, io.EOF) || err == errGarbage {
+				return err
+			}
+			continue
+		}
+
+		switch h.typ {
+		case zrinit:
+			c.crc32 = h.f0()&canfc32 != 0
+			return nil
+		case zchallenge:
+			// Echo the challenge value back
+			c.sendHexHeader(header{typ: zack, data: h.data})
+		case zcommand:
+			return errors.New("receiver sent a command")
+		}
+	}
+	return errors.New("receiver did not respond")
+}
+
+// sendFile offers one file and sends it from whatever position the receiver
+// asks for. It returns the bytes sent, or 0 if the receiver skipped it.
+func (c *conn) sendFile(f File, filesLeft int, bytesLeft int64, opts Options) (int64, error) {
+	info := fmt.Sprintf("%s\x00%d %o 0 0 %d %d\x00", f.Name, f.Size, f.ModTime.Unix(), filesLeft, bytesLeft)
+
+	var pos int64
+	accepted := false
+	resend := true
+	for tries := 0; tries < maxRetries && !accepted; tries++ {
+		if resend {
+			if err := c.sendBinHeader(flagHeader(zfile, zcbin)); err != nil {
+				return 0, err
+			}
+			if err := c.sendData([]byte(info), zcrcw); err != nil {
+				return 0, err
+			}
+		}
+		resend = true
+
+		h, err := c.readHeader()
+		if err != nil {
+			if err == ErrCancelled || errors.Is(err, io.EOF) {
+				return 0, err
+			}
+			continue
+		}
+
+		switch h.typ {
+		case zrpos:
+			pos = h.pos()
+			accepted = true
+		case zskip:
+			return 0, nil
+		case zrinit:
+			// Left over from the previous file; the answer to ours follows
+			resend = false
+		case zcrc:
+			// The receiver wants the file's CRC to decide whether to resume
+			crc, err := fileCRC(f.Data)
+			if err != nil {
+				return 0, err
+			}
+			c.sendHexHeader(posHeader(zcrc, int64(crc)))
+			resend = false
+		}
+	}
+	if !accepted {
+		return 0, errors.New("receiver did not accept the file")
+	}
+
+	return c.sendFileData(f, pos, opts)
+}
+
+// sendFileData streams file data starting at pos, going back whenever the
+// receiver reports an error with ZRPOS, and finishes with ZEOF
+func (c *conn) sendFileData(f File, pos int64, opts Options) (int64, error) {
+	start := pos
+	buf := make([]byte, subpacketSize)
+	errorsInRow := 0
+
+	for {
+		if errorsInRow > maxRetries {
+			return pos - start, errors.New("too many errors")
+		}
+		if _, err := f.Data.Seek(pos, io.SeekStart); err != nil {
+			return pos - start, err
+		}
+		if err := c.sendBinHeader(posHeader(zdata, pos)); err != nil {
+			return pos - start, err
+		}
+
+		// Send one window of data, or the rest of the file
+		sentInWindow := 0
+		atEOF := false
+		for !atEOF && sentInWindow < windowSize {
+			n, err := io.ReadFull(f.Data, buf)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				atEOF = true
+			} else if err != nil {
+				return pos - start, err
+			}
+
+			end := byte(zcrcg)
+			switch {
+			case atEOF:
+				end = zcrce
+			case sentInWindow+n >= windowSize:
+				end = zcrcw
+			}
+			if err := c.sendData(buf[:n], end); err != nil {
+				return pos - start, err
+			}
+
+			pos += int64(n)
+			sentInWindow += n
+			opts.report(f.Name, pos, f.Size)
+		}
+
+		if atEOF {
+			if err := c.sendHexHeader(posHeader(zeof, pos)); err != nil {
+				return pos - start, err
+			}
+		}
+
+		// Wait for the receiver's verdict on this window
+		resumeAt, done, err := c.awaitWindow(atEOF, pos)
+		if err != nil {
+			return pos - start, err
+		}
+		if done {
+			return pos - start, nil
+		}
+		if resumeAt != pos {
+			errorsInRow++
+		} else {
+			errorsInRow = 0
+		}
+		pos = resumeAt
+	}
+}
+
+// awaitWindow reads the receiver's response after a window or ZEOF. It
+// returns the position to continue from and whether the file is finished.
+func (c *conn) awaitWindow(atEOF bool, pos int64) (int64, bool, error) {
+	for tries := 0; tries < maxRetries; tries++ {
+		h, err := c.readHeader()
+		if err != nil {
+			if err == ErrCancelled || errors.Is(err, io.EOF) {
+				return 0, false, err
+			}
+			continue
+		}
+
+		switch h.typ {
+		case zack:
+			if !atEOF {
+				return pos, false, nil
+			}
+			// An ack of the last subpacket; ZRINIT follows the ZEOF
+		case zrpos:
+			return h.pos(), false, nil
+		case zrinit, zskip:
+			if atEOF || h.typ == zskip {
+				return pos, true, nil
+			}
+		case znak:
+			if atEOF {
+				c.sendHexHeader(posHeader(zeof, pos))
+			} else {
+				return pos, false, nil
+			}
+		case zabort, zferr, zfin:
+			return 0, false, fmt.Errorf("receiver aborted the transfer")
+		}
+	}
+	return 0, false, errors.New("no response from receiver")
+}
+
+// finishSend ends the session with ZFIN and the closing "OO"
+func (c *conn) finishSend() error {
+	for tries := 0; tries < maxRetries; tries++ {
+		if err := c.sendHexHeader(header{typ: zfin}); err != nil {
+			return err
+		}
+		h, err := c.readHeader()
+		if err != nil {
+			if err == ErrCancelled || errors.Is(err, io.EOF) {
+				return err
+			}
+			continue
+		}
+		if h.typ == zfin {
+			_, err := c.w.Write([]byte("OO"))
+			return err
+		}
+	}
+	return errors.New("receiver did not finish the session")
+}
+
+// fileCRC computes the CRC-32 of a whole file and rewinds it
+func fileCRC(data io.ReadSeeker) (uint32, error) {
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, data); err != nil {
+		return 0, err
+	}
+	_, err := data.Seek(0, io.SeekStart)
+	return h.Sum32(), err
+}
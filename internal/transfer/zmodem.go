@@ -0,0 +1,464 @@
+// Package transfer implements the ZMODEM file transfer protocol over a raw
+// session channel, so callers using terminals such as SyncTERM or Qodem can
+// download and upload files without leaving the board.
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Framing characters
+const (
+	zpad = '*'  // Pad character that begins frames
+	zdle = 0x18 // ZMODEM escape, also ASCII CAN
+	can  = 0x18 // Five in a row cancel the transfer
+
+	zbin   = 'A' // Binary header with CRC-16
+	zhex   = 'B' // Hex header with CRC-16
+	zbin32 = 'C' // Binary header with CRC-32
+
+	xon  = 0x11
+	xoff = 0x13
+)
+
+// Frame types
+const (
+	zrqinit    = 0  // Request receiver init
+	zrinit     = 1  // Receiver capabilities
+	zsinit     = 2  // Sender capabilities
+	zack       = 3  // Acknowledge
+	zfile      = 4  // File name and info follow
+	zskip      = 5  // Receiver skips the file
+	znak       = 6  // Last header was garbled
+	zabort     = 7  // Abort the batch
+	zfin       = 8  // Finish the session
+	zrpos      = 9  // Resume data at this position
+	zdata      = 10 // Data subpackets follow
+	zeof       = 11 // End of file
+	zferr      = 12 // Fatal I/O error
+	zcrc       = 13 // File CRC request or response
+	zchallenge = 14 // Receiver challenge
+	zcompl     = 15 // Request is complete
+	zcan       = 16 // Pseudo frame for a received cancel sequence
+	zfreecnt   = 17 // Request free disk space
+	zcommand   = 18 // Command from sending program
+)
+
+// Data subpacket terminators
+const (
+	zcrce = 'h' // End of frame, no response expected
+	zcrcg = 'i' // Frame continues, no response expected
+	zcrcq = 'j' // Frame continues, ZACK expected
+	zcrcw = 'k' // End of frame, ZACK expected
+	zrub0 = 'l' // Escaped 0x7f
+	zrub1 = 'm' // Escaped 0xff
+)
+
+// ZRINIT capability flags (ZF0)
+const (
+	canfdx  = 0x01 // Full duplex
+	canovio = 0x02 // Can receive data during disk I/O
+	canfc32 = 0x20 // Can use 32-bit CRCs
+)
+
+// zcbin marks a ZFILE as a binary transfer (ZF0)
+const zcbin = 1
+
+const (
+	subpacketSize = 1024      // Bytes of file data per subpacket
+	maxGarbage    = 64 * 1024 // Non-frame bytes tolerated while hunting for a header
+	maxRetries    = 10        // Consecutive errors before giving up
+)
+
+// frameEnd marks a data subpacket terminator returned by zdlRead
+const frameEnd = 0x100
+
+var (
+	// ErrCancelled is returned when the other side cancels the transfer
+	ErrCancelled = errors.New("transfer cancelled by remote")
+
+	errBadCRC    = errors.New("crc mismatch")
+	errBadEscape = errors.New("invalid escape sequence")
+	errGarbage   = errors.New("too much garbage waiting for a header")
+	errTooLong   = errors.New("data subpacket too long")
+)
+
+// header is a ZMODEM frame header. data holds ZP0..ZP3, which for flag
+// headers are ZF3..ZF0.
+type header struct {
+	typ  byte
+	data [4]byte
+}
+
+// posHeader builds a header carrying a file position
+func posHeader(typ byte, pos int64) header {
+	h := header{typ: typ}
+	binary.LittleEndian.PutUint32(h.data[:], uint32(pos))
+	return h
+}
+
+// flagHeader builds a header carrying ZF0
+func flagHeader(typ byte, f0 byte) header {
+	return header{typ: typ, data: [4]byte{0, 0, 0, f0}}
+}
+
+// pos returns the file position carried by the header
+func (h header) pos() int64 {
+	return int64(binary.LittleEndian.Uint32(h.data[:]))
+}
+
+// f0 returns the ZF0 flags byte
+func (h header) f0() byte {
+	return h.data[3]
+}
+
+// conn frames ZMODEM headers and data subpackets over a byte stream
+type conn struct {
+	r        *bufio.Reader
+	w        io.Writer
+	crc32    bool // Use 32-bit CRCs for binary headers and data we send
+	binCRC32 bool // Whether the last binary header received used a 32-bit CRC
+}
+
+func newConn(rw io.ReadWriter) *conn {
+	return &conn{
+		r: bufio.NewReader(rw),
+		w: rw,
+	}
+}
+
+// crc16 computes the CRC-16/XMODEM used by ZMODEM
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// needsEscape reports whether b must be sent as ZDLE plus b^0x40. Flow
+// control characters and ZDLE itself are always escaped, as is a CR
+// following '@' so Telenet-style command sequences can't form.
+func needsEscape(b, last byte) bool {
+	switch b {
+	case zdle, zdle | 0x80, 0x10, 0x90, xon, xon | 0x80, xoff, xoff | 0x80:
+		return true
+	}
+	return b&0x7f == '\r' && last&0x7f == '@'
+}
+
+// escape appends data to buf with ZDLE escaping
+func escape(buf *bytes.Buffer, data []byte) {
+	var last byte
+	for _, b := range data {
+		if needsEscape(b, last) {
+			buf.WriteByte(zdle)
+			buf.WriteByte(b ^ 0x40)
+		} else {
+			buf.WriteByte(b)
+		}
+		last = b
+	}
+}
+
+// sendHexHeader writes a header in hex form, which is used for headers the
+// receiver sends and before the sender knows the receiver's capabilities
+func (c *conn) sendHexHeader(h header) error {
+	raw := append([]byte{h.typ}, h.data[:]...)
+	crc := crc16(raw)
+
+	var buf bytes.Buffer
+	buf.Write([]byte{zpad, zpad, zdle, zhex})
+	fmt.Fprintf(&buf, "%02x%02x%02x%02x%02x%04x\r\x8a", h.typ, h.data[0], h.data[1], h.data[2], h.data[3], crc)
+	if h.typ != zfin && h.typ != zack {
+		buf.WriteByte(xon)
+	}
+
+	_, err := c.w.Write(buf.Bytes())
+	return err
+}
+
+// sendBinHeader writes a header in binary form
+func (c *conn) sendBinHeader(h header) error {
+	raw := append([]byte{h.typ}, h.data[:]...)
+
+	var buf bytes.Buffer
+	buf.WriteByte(zpad)
+	buf.WriteByte(zdle)
+	if c.crc32 {
+		buf.WriteByte(zbin32)
+		raw = binary.LittleEndian.AppendUint32(raw, crc32.ChecksumIEEE(raw))
+	} else {
+		buf.WriteByte(zbin)
+		raw = binary.BigEndian.AppendUint16(raw, crc16(raw))
+	}
+	escape(&buf, raw)
+
+	_, err := c.w.Write(buf.Bytes())
+	return err
+}
+
+// sendData writes a data subpacket terminated by end
+func (c *conn) sendData(data []byte, end byte) error {
+	var buf bytes.Buffer
+	escape(&buf, data)
+	buf.WriteByte(zdle)
+	buf.WriteByte(end)
+
+	covered := append(append([]byte{}, data...), end)
+	if c.crc32 {
+		escape(&buf, binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(covered)))
+	} else {
+		escape(&buf, binary.BigEndian.AppendUint16(nil, crc16(covered)))
+	}
+
+	_, err := c.w.Write(buf.Bytes())
+	return err
+}
+
+// cancel tells the other side to abort: a run of CANs followed by
+// backspaces to erase them from a terminal that didn't understand
+func (c *conn) cancel() {
+	c.w.Write(append(bytes.Repeat([]byte{can}, 10), bytes.Repeat([]byte{'\b'}, 10)...))
+}
+
+// readHeader scans the input for the next valid header
+func (c *conn) readHeader() (header, error) {
+	garbage := 0
+	cans := 0
+
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return header{}, err
+		}
+
+		if b == can {
+			cans++
+			if cans >= 5 {
+				return header{}, ErrCancelled
+			}
+			continue
+		}
+		cans = 0
+
+		if b != zpad {
+			garbage++
+			if garbage > maxGarbage {
+				return header{}, errGarbage
+			}
+			continue
+		}
+
+		// Skip any further pads, then expect ZDLE and the header format
+		for b == zpad {
+			if b, err = c.r.ReadByte(); err != nil {
+				return header{}, err
+			}
+		}
+		if b != zdle {
+			c.r.UnreadByte()
+			continue
+		}
+		format, err := c.r.ReadByte()
+		if err != nil {
+			return header{}, err
+		}
+
+		var h header
+		switch format {
+		case zhex:
+			h, err = c.readHexHeader()
+		case zbin:
+			h, err = c.readBinHeader(false)
+		case zbin32:
+			h, err = c.readBinHeader(true)
+		case can:
+			// "*" followed by a cancel sequence
+			cans = 2
+			continue
+		default:
+			continue
+		}
+
+		if err == nil {
+			return h, nil
+		}
+		if err == ErrCancelled || errors.Is(err, io.EOF) {
+			return header{}, err
+		}
+		// A corrupted header: keep hunting
+	}
+}
+
+// readHexHeader reads the body of a hex header
+func (c *conn) readHexHeader() (header, error) {
+	var digits [14]byte
+	if _, err := io.ReadFull(c.r, digits[:]); err != nil {
+		return header{}, err
+	}
+	var raw [7]byte
+	if _, err := hex.Decode(raw[:], digits[:]); err != nil {
+		return header{}, errBadCRC
+	}
+
+	if crc16(raw[:5]) != binary.BigEndian.Uint16(raw[5:]) {
+		return header{}, errBadCRC
+	}
+
+	// Consume the trailing CR LF so it isn't mistaken for data
+	if b, err := c.r.ReadByte(); err == nil {
+		if b&0x7f == '\r' {
+			if b, err = c.r.ReadByte(); err == nil && b&0x7f != '\n' {
+				c.r.UnreadByte()
+			}
+		} else {
+			c.r.UnreadByte()
+		}
+	}
+
+	h := header{typ: raw[0]}
+	copy(h.data[:], raw[1:5])
+	return h, nil
+}
+
+// readBinHeader reads the body of a binary header
+func (c *conn) readBinHeader(use32 bool) (header, error) {
+	size := 7
+	if use32 {
+		size = 9
+	}
+
+	raw := make([]byte, size)
+	for i := range raw {
+		v, err := c.zdlRead()
+		if err != nil {
+			return header{}, err
+		}
+		if v&frameEnd != 0 {
+			return header{}, errBadEscape
+		}
+		raw[i] = byte(v)
+	}
+
+	if use32 {
+		if crc32.ChecksumIEEE(raw[:5]) != binary.LittleEndian.Uint32(raw[5:]) {
+			return header{}, errBadCRC
+		}
+	} else if crc16(raw[:5]) != binary.BigEndian.Uint16(raw[5:]) {
+		return header{}, errBadCRC
+	}
+
+	c.binCRC32 = use32
+
+	h := header{typ: raw[0]}
+	copy(h.data[:], raw[1:5])
+	return h, nil
+}
+
+// zdlRead reads one byte, undoing ZDLE escaping. Subpacket terminators are
+// returned with the frameEnd bit set. Unescaped flow control is ignored.
+func (c *conn) zdlRead() (int, error) {
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != zdle {
+			if b&0x7f == xon || b&0x7f == xoff {
+				continue
+			}
+			return int(b), nil
+		}
+
+		cans := 1
+		for {
+			if b, err = c.r.ReadByte(); err != nil {
+				return 0, err
+			}
+			switch {
+			case b == can:
+				cans++
+				if cans >= 5 {
+					return 0, ErrCancelled
+				}
+				continue
+			case b&0x7f == xon || b&0x7f == xoff:
+				continue
+			case b >= zcrce && b <= zcrcw:
+				return frameEnd | int(b), nil
+			case b == zrub0:
+				return 0x7f, nil
+			case b == zrub1:
+				return 0xff, nil
+			case b&0x60 == 0x40:
+				return int(b ^ 0x40), nil
+			default:
+				return 0, errBadEscape
+			}
+		}
+	}
+}
+
+// readData reads a data subpacket of at most max bytes and returns its data
+// and terminator
+func (c *conn) readData(max int) ([]byte, byte, error) {
+	var data []byte
+	for {
+		v, err := c.zdlRead()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if v&frameEnd == 0 {
+			if len(data) >= max {
+				return nil, 0, errTooLong
+			}
+			data = append(data, byte(v))
+			continue
+		}
+
+		end := byte(v)
+		covered := append(append([]byte{}, data...), end)
+
+		crcLen := 2
+		if c.crc32 {
+			crcLen = 4
+		}
+		crc := make([]byte, crcLen)
+		for i := range crc {
+			b, err := c.zdlRead()
+			if err != nil {
+				return nil, 0, err
+			}
+			if b&frameEnd != 0 {
+				return nil, 0, errBadEscape
+			}
+			crc[i] = byte(b)
+		}
+
+		if c.crc32 {
+			if crc32.ChecksumIEEE(covered) != binary.LittleEndian.Uint32(crc) {
+				return nil, 0, errBadCRC
+			}
+		} else if crc16(covered) != binary.BigEndian.Uint16(crc) {
+			return nil, 0, errBadCRC
+		}
+
+		return data, end, nil
+	}
+}
@@ -0,0 +1,190 @@
+package transfer
+
+import (
+	"bytes"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// connPair returns two ends of a buffered full-duplex connection, like the
+// SSH channel between the board and a caller's terminal
+func connPair(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := listener.Accept()
+		accepted <- conn
+	}()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	server := <-accepted
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+func TestEscapeRoundTrip(t *testing.T) {
+	data := make([]byte, 512)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	data = append(data, '@', '\r', 0xc0, 0x8d)
+
+	for _, use32 := range []bool{false, true} {
+		var buf bytes.Buffer
+		sender := &conn{w: &buf, crc32: use32}
+		if err := sender.sendData(data, zcrcw); err != nil {
+			t.Fatalf("sendData: %v", err)
+		}
+
+		receiver := newConn(bytes.NewBuffer(buf.Bytes()))
+		receiver.crc32 = use32
+
+		got, end, err := receiver.readData(len(data))
+		if err != nil {
+			t.Fatalf("readData (crc32=%v): %v", use32, err)
+		}
+		if end != zcrcw || !bytes.Equal(got, data) {
+			t.Errorf("round trip mismatch (crc32=%v)", use32)
+		}
+	}
+}
+
+func TestSendReceive(t *testing.T) {
+	senderEnd, receiverEnd := connPair(t)
+	dir := t.TempDir()
+
+	rng := rand.New(rand.NewSource(1))
+	big := make([]byte, 100*1024+17)
+	rng.Read(big)
+	small := []byte("hello \x18\x11\x13 world\r\n")
+
+	files := []File{
+		{Name: "big.bin", Size: int64(len(big)), ModTime: time.Now(), Data: bytes.NewReader(big)},
+		{Name: "../../small.txt", Size: int64(len(small)), ModTime: time.Now(), Data: bytes.NewReader(small)},
+	}
+
+	type result struct {
+		received []Received
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		received, err := Receive(receiverEnd, dir, Options{})
+		done <- result{received, err}
+	}()
+
+	var progressed int64
+	sent, err := Send(senderEnd, files, Options{
+		Progress: func(name string, transferred, total int64) { progressed = transferred },
+	})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if sent != int64(len(big)+len(small)) {
+		t.Errorf("sent %d bytes, want %d", sent, len(big)+len(small))
+	}
+	if progressed != int64(len(small)) {
+		t.Errorf("last progress report was %d, want %d", progressed, len(small))
+	}
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("Receive failed: %v", res.err)
+	}
+	if len(res.received) != 2 {
+		t.Fatalf("received %d files, want 2", len(res.received))
+	}
+
+	for name, want := range map[string][]byte{"big.bin": big, "small.txt": small} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s does not match what was sent", name)
+		}
+	}
+}
+
+func TestReceiveSkipsExistingAndOversizedFiles(t *testing.T) {
+	senderEnd, receiverEnd := connPair(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "exists.txt"), []byte("original"), 0644)
+
+	files := []File{
+		{Name: "exists.txt", Size: 3, Data: bytes.NewReader([]byte("new"))},
+		{Name: "huge.bin", Size: 4096, Data: bytes.NewReader(make([]byte, 4096))},
+		{Name: "ok.txt", Size: 2, Data: bytes.NewReader([]byte("ok"))},
+	}
+
+	done := make(chan []Received, 1)
+	go func() {
+		received, _ := Receive(receiverEnd, dir, Options{MaxSize: 1024})
+		done <- received
+	}()
+
+	sent, err := Send(senderEnd, files, Options{})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if sent != 2 {
+		t.Errorf("sent %d bytes, want only ok.txt's 2", sent)
+	}
+
+	received := <-done
+	if len(received) != 1 || received[0].Name != "ok.txt" {
+		t.Errorf("expected only ok.txt to be received, got %+v", received)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "exists.txt")); string(got) != "original" {
+		t.Errorf("existing file was overwritten: %q", got)
+	}
+}
+
+func TestSenderSeesCancel(t *testing.T) {
+	senderEnd, receiverEnd := connPair(t)
+
+	go func() {
+		buf := make([]byte, 64)
+		receiverEnd.Read(buf)
+		receiverEnd.Write(bytes.Repeat([]byte{can}, 8))
+	}()
+
+	_, err := Send(senderEnd, []File{{Name: "x", Size: 1, Data: bytes.NewReader([]byte("x"))}}, Options{})
+	if err != ErrCancelled {
+		t.Errorf("expected ErrCancelled, got %v", err)
+	}
+}
+
+func TestSafeName(t *testing.T) {
+	tests := map[string]string{
+		"game.zip":           "game.zip",
+		"../../etc/passwd":   "passwd",
+		`C:\Users\bob\a.txt`: "a.txt",
+		".hidden":            "",
+		"..":                 "",
+		"bad\x00name":        "",
+		"dir/":               "",
+	}
+	for in, want := range tests {
+		if got := SafeName(in); got != want {
+			t.Errorf("SafeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Package undo keeps a caller's last destructive action for a few seconds
+// so they can take it back
+package undo
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Window is how long an action can be undone
+const Window = 10 * time.Second
+
+// ErrNothing is returned when there is no action left to undo
+var ErrNothing = errors.New("nothing to undo")
+
+// action is one undoable action
+type action struct {
+	label   string
+	undo    func() error
+	commit  func() error
+	expires time.Time
+}
+
+// Buffer holds one caller's last undoable action. A nil Buffer holds
+// nothing, so actions pushed to it are made final straight away.
+type Buffer struct {
+	mu   sync.Mutex
+	last *action
+	now  func() time.Time
+}
+
+// New creates an empty undo buffer
+func New() *Buffer {
+	return &Buffer{now: time.Now}
+}
+
+// Push records an action that undo takes back. commit makes the action
+// final; it runs once the window has passed and the buffer is next used,
+// when another action is pushed, or when the buffer is flushed.
+func (b *Buffer) Push(label string, undo, commit func() error) error {
+	if b == nil {
+		return commit()
+	}
+	b.mu.Lock()
+	prev := b.last
+	b.last = &action{label: label, undo: undo, commit: commit, expires: b.now().Add(Window)}
+	b.mu.Unlock()
+
+	if prev != nil {
+		return prev.commit()
+	}
+	return nil
+}
+
+// Pending returns the label of the action that can still be undone, or ""
+func (b *Buffer) Pending() string {
+	if b == nil {
+		return ""
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire()
+	if b.last == nil {
+		return ""
+	}
+	return b.last.label
+}
+
+// Undo takes back the last action if its window hasn't passed, returning
+// its label
+func (b *Buffer) Undo() (string, error) {
+	if b == nil {
+		return "", ErrNothing
+	}
+	b.mu.Lock()
+	b.expire()
+	last := b.last
+	b.last = nil
+	b.mu.Unlock()
+
+	if last == nil {
+		return "", ErrNothing
+	}
+	return last.label, last.undo()
+}
+
+// Flush makes any pending action final, as when the caller hangs up
+func (b *Buffer) Flush() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	last := b.last
+	b.last = nil
+	b.mu.Unlock()
+
+	if last == nil {
+		return nil
+	}
+	return last.commit()
+}
+
+// expire makes the last action final once its window has passed. An error
+// from commit has nobody to go to, so the action is left as it stood.
+// b.mu must be held.
+func (b *Buffer) expire() {
+	if b.last != nil && !b.now().Before(b.last.expires) {
+		b.last.commit()
+		b.last = nil
+	}
+}
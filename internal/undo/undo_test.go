@@ -0,0 +1,74 @@
+package undo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBuffer(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	b := New()
+	b.now = func() time.Time { return now }
+
+	var undone, committed []string
+	push := func(label string) {
+		t.Helper()
+		err := b.Push(label,
+			func() error { undone = append(undone, label); return nil },
+			func() error { committed = append(committed, label); return nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	push("first")
+	push("second") // Makes the first final
+	if got := b.Pending(); got != "second" {
+		t.Errorf("Pending() = %q, want second", got)
+	}
+	if label, err := b.Undo(); label != "second" || err != nil {
+		t.Errorf("Undo() = %q, %v", label, err)
+	}
+	if _, err := b.Undo(); !errors.Is(err, ErrNothing) {
+		t.Errorf("second Undo() error = %v, want ErrNothing", err)
+	}
+
+	push("third")
+	now = now.Add(Window)
+	if got := b.Pending(); got != "" {
+		t.Errorf("Pending() after the window = %q", got)
+	}
+	if _, err := b.Undo(); !errors.Is(err, ErrNothing) {
+		t.Errorf("Undo() after the window error = %v, want ErrNothing", err)
+	}
+
+	push("fourth")
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"second"}; len(undone) != 1 || undone[0] != want[0] {
+		t.Errorf("undone %v, want %v", undone, want)
+	}
+	want := []string{"first", "third", "fourth"}
+	if len(committed) != len(want) {
+		t.Fatalf("committed %v, want %v", committed, want)
+	}
+	for i := range want {
+		if committed[i] != want[i] {
+			t.Errorf("committed %v, want %v", committed, want)
+		}
+	}
+}
+
+func TestNilBuffer(t *testing.T) {
+	var b *Buffer
+	committed := false
+	if err := b.Push("gone", func() error { return nil }, func() error { committed = true; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if !committed || b.Pending() != "" {
+		t.Error("a nil buffer should make actions final straight away")
+	}
+}
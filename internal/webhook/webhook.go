@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, as
+// "sha256=<hex>", when a secret is configured
+const SignatureHeader = "X-BBS-Signature"
+
+// maxResponseSize bounds how much of the webhook's answer is read
+const maxResponseSize = 64 * 1024
+
+// Application is a new account as posted to the webhook. The password is
+// never sent.
+type Application struct {
+	Username    string    `json:"username"`
+	RealName    string    `json:"real_name"`
+	Email       string    `json:"email"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// Decision is the webhook's answer to an application
+type Decision struct {
+	Approved bool
+	Reason   string // Optional; shown to the caller when they are turned away
+}
+
+// response is the JSON body a webhook answers with
+type response struct {
+	Approved *bool  `json:"approved"`
+	Reason   string `json:"reason"`
+}
+
+// Checker asks an external service whether new accounts may be activated
+type Checker struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// New creates a checker that posts applications to url and gives up after
+// timeout. Requests are signed when secret is not empty.
+func New(url, secret string, timeout time.Duration) *Checker {
+	return &Checker{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Check posts an application and returns the webhook's decision. Any
+// failure, including a timeout or an answer that is neither an approval nor
+// a denial, is returned as an error so the account can be left for the
+// sysop.
+func (c *Checker) Check(app Application) (Decision, error) {
+	body, err := json.Marshal(app)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, fmt.Errorf("invalid webhook url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(c.secret, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Decision{}, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Decision{}, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+
+	var r response
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&r); err != nil {
+		return Decision{}, fmt.Errorf("invalid webhook response: %w", err)
+	}
+	if r.Approved == nil {
+		return Decision{}, errors.New(`webhook response has no "approved" field`)
+	}
+
+	return Decision{Approved: *r.Approved, Reason: r.Reason}, nil
+}
+
+// Sign returns the signature header value for a request body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
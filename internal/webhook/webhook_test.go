@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckApprovesAndSigns(t *testing.T) {
+	var got Application
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if sig := r.Header.Get(SignatureHeader); sig != Sign("club", body) {
+			t.Errorf("bad signature %q", sig)
+		}
+		json.Unmarshal(body, &got)
+		w.Write([]byte(`{"approved": true}`))
+	}))
+	defer server.Close()
+
+	checker := New(server.URL, "club", time.Second)
+	decision, err := checker.Check(Application{Username: "carol", Email: "carol@example.com"})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !decision.Approved {
+		t.Error("expected approval")
+	}
+	if got.Username != "carol" || got.Email != "carol@example.com" {
+		t.Errorf("webhook received %+v", got)
+	}
+}
+
+func TestCheckDenies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(SignatureHeader) != "" {
+			t.Error("unsigned checker sent a signature")
+		}
+		w.Write([]byte(`{"approved": false, "reason": "Not a club member"}`))
+	}))
+	defer server.Close()
+
+	decision, err := New(server.URL, "", time.Second).Check(Application{Username: "mallory"})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if decision.Approved || decision.Reason != "Not a club member" {
+		t.Errorf("unexpected decision %+v", decision)
+	}
+}
+
+func TestCheckFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "down", http.StatusInternalServerError)
+		}},
+		{"not json", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}},
+		{"no verdict", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"reason": "thinking about it"}`))
+		}},
+		{"timeout", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(`{"approved": true}`))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			if _, err := New(server.URL, "", 50*time.Millisecond).Check(Application{}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+// Package websocket is the server side of the WebSocket protocol (RFC 6455),
+// just enough to carry a terminal session to a browser
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"bbs/internal/ansi"
+)
+
+// Message types
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+
+	continuation = 0
+	closeFrame   = 8
+	pingFrame    = 9
+	pongFrame    = 10
+)
+
+// MaxMessageSize is the largest message a client may send
+const MaxMessageSize = 64 * 1024
+
+// acceptGUID is appended to the client's key to prove the server speaks
+// WebSocket (RFC 6455 §1.3)
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrTooLarge is returned for messages over MaxMessageSize
+var ErrTooLarge = errors.New("websocket: message too large")
+
+// Conn is an upgraded connection
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	writeMu sync.Mutex
+	closed  bool // A close frame was sent
+}
+
+// Upgrade answers a WebSocket handshake and takes over the connection.
+// On failure it has already replied with an HTTP error.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	switch {
+	case r.Method != http.MethodGet:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, errors.New("websocket: handshake is not a GET")
+	case !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket"):
+		http.Error(w, "expected a WebSocket handshake", http.StatusBadRequest)
+		return nil, errors.New("websocket: not an upgrade request")
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: unsupported version")
+	case key == "":
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("websocket: missing key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "upgrade not supported", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response can't be hijacked")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+	return &Conn{conn: conn, r: rw.Reader}, nil
+}
+
+// acceptKey computes Sec-WebSocket-Accept for a client's key
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains reports whether a comma-separated header lists token
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RemoteAddr returns the client's address
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// ReadMessage returns the next text or binary message, joining fragments.
+// Pings are answered as they arrive. When the client closes the connection
+// it returns io.EOF.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var kind int
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case pingFrame:
+			if err := c.writeFrame(pongFrame, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case pongFrame:
+			continue
+		case closeFrame:
+			c.writeClose()
+			return 0, nil, io.EOF
+		case TextMessage, BinaryMessage:
+			if kind != 0 {
+				return 0, nil, c.fail("websocket: new message inside a fragmented one")
+			}
+			kind = opcode
+		case continuation:
+			if kind == 0 {
+				return 0, nil, c.fail("websocket: continuation without a message")
+			}
+		default:
+			return 0, nil, c.fail(fmt.Sprintf("websocket: unknown opcode %d", opcode))
+		}
+
+		if len(message)+len(payload) > MaxMessageSize {
+			c.fail(ErrTooLarge.Error())
+			return 0, nil, ErrTooLarge
+		}
+		message = append(message, payload...)
+		if fin {
+			return kind, message, nil
+		}
+	}
+}
+
+// readFrame reads one frame and unmasks its payload
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0f)
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail("websocket: reserved bits set")
+	}
+	// Clients must mask every frame (RFC 6455 §5.1)
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, c.fail("websocket: unmasked client frame")
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if opcode >= closeFrame && (length > 125 || !fin) {
+		return false, 0, nil, c.fail("websocket: bad control frame")
+	}
+	if length > MaxMessageSize {
+		c.fail(ErrTooLarge.Error())
+		return false, 0, nil, ErrTooLarge
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends a text or binary message in one frame
+func (c *Conn) WriteMessage(kind int, data []byte) error {
+	return c.writeFrame(kind, data)
+}
+
+// writeFrame sends one unmasked frame, as servers do
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+
+	header := []byte{0x80 | byte(opcode), 0}
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	if opcode == closeFrame {
+		c.closed = true
+	}
+	return nil
+}
+
+// writeClose sends a normal closure frame
+func (c *Conn) writeClose() {
+	c.writeFrame(closeFrame, []byte{0x03, 0xe8}) // 1000, normal closure
+}
+
+// fail tells the client why the connection is being dropped and returns
+// the reason as an error. 1002 is a protocol error.
+func (c *Conn) fail(reason string) error {
+	c.writeFrame(closeFrame, append([]byte{0x03, 0xea}, ansi.Truncate(reason, 123)...))
+	return errors.New(reason)
+}
+
+// Close says goodbye to the client and closes the connection
+func (c *Conn) Close() error {
+	c.writeClose()
+	return c.conn.Close()
+}
@@ -0,0 +1,171 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// client is a bare WebSocket client for driving the server
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dial(t *testing.T, handler func(*Conn)) *client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handler(conn)
+	}))
+	t.Cleanup(server.Close)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// The sample key and answer from RFC 6455 §1.3
+	request := "GET / HTTP/1.1\r\nHost: example\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake answered %s with accept %q", resp.Status, resp.Header.Get("Sec-WebSocket-Accept"))
+	}
+	return &client{conn: conn, r: r}
+}
+
+// send writes a masked frame
+func (c *client) send(t *testing.T, fin bool, opcode int, payload []byte) {
+	t.Helper()
+	first := byte(opcode)
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	default:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// receive reads an unmasked frame
+func (c *client) receive(t *testing.T) (int, []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		t.Fatal(err)
+	}
+	if header[1]&0x80 != 0 {
+		t.Fatal("server masked a frame")
+	}
+	n := int(header[1])
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(c.r, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return int(header[0] & 0x0f), payload
+}
+
+func TestEcho(t *testing.T) {
+	c := dial(t, func(conn *Conn) {
+		for {
+			kind, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(kind, data)
+		}
+	})
+
+	c.send(t, true, TextMessage, []byte("hello"))
+	if kind, data := c.receive(t); kind != TextMessage || string(data) != "hello" {
+		t.Errorf("echo = %d %q", kind, data)
+	}
+
+	// A ping in the middle of a fragmented message is answered first
+	c.send(t, false, BinaryMessage, []byte("frag"))
+	c.send(t, true, pingFrame, []byte("p"))
+	c.send(t, true, continuation, []byte(strings.Repeat("x", 200)))
+	if kind, data := c.receive(t); kind != pongFrame || string(data) != "p" {
+		t.Errorf("pong = %d %q", kind, data)
+	}
+	if kind, data := c.receive(t); kind != BinaryMessage || len(data) != 204 || string(data[:4]) != "frag" {
+		t.Errorf("joined message = %d %q", kind, data)
+	}
+
+	c.send(t, true, closeFrame, []byte{0x03, 0xe8})
+	if kind, _ := c.receive(t); kind != closeFrame {
+		t.Errorf("close answered with opcode %d", kind)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	errs := make(chan error, 1)
+	c := dial(t, func(conn *Conn) {
+		_, _, err := conn.ReadMessage()
+		errs <- err
+	})
+
+	// Unmasked client frame
+	c.conn.Write([]byte{0x81, 0x02, 'h', 'i'})
+	if err := <-errs; err == nil || !strings.Contains(err.Error(), "unmasked") {
+		t.Errorf("ReadMessage = %v", err)
+	}
+	if kind, data := c.receive(t); kind != closeFrame || binary.BigEndian.Uint16(data) != 1002 {
+		t.Errorf("refusal = %d %v", kind, data)
+	}
+}
+
+func TestUpgradeRefusesPlainRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := Upgrade(w, r); err == nil {
+			t.Error("Upgrade accepted a plain GET")
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("plain GET answered %s", resp.Status)
+	}
+}
@@ -1,7 +1,15 @@
 package main
 
-import "bbs/cmd"
+import (
+	_ "embed"
+
+	"bbs/cmd"
+)
+
+//go:embed config.yaml
+var defaultConfig []byte
 
 func main() {
+	cmd.DefaultConfig = defaultConfig
 	cmd.Execute()
 }
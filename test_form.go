@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"bbs/internal/components"
+	"bbs/internal/draw"
 )
 
 // Simple ColorScheme implementation for testing
@@ -33,6 +34,10 @@ func (cs *TestColorScheme) DrawSeparator(width int, char string) string {
 	return result
 }
 
+func (cs *TestColorScheme) DrawBox(box draw.Box) []string {
+	return box.Draw(nil)
+}
+
 func testForm() {
 	colorScheme := &TestColorScheme{}
 